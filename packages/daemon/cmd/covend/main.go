@@ -21,6 +21,10 @@ import (
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runTask(os.Args[2:]))
+	}
+
 	workspace := flag.String("workspace", "", "Path to workspace directory")
 	showVersion := flag.Bool("version", false, "Show version")
 	flag.Parse()
@@ -46,3 +50,32 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runTask implements `covend run --task <id>`: it runs one task's workflow to
+// completion, prints a summary and returns the process exit code.
+func runTask(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	workspace := fs.String("workspace", "", "Path to workspace directory")
+	taskID := fs.String("task", "", "ID of the task to run")
+	fs.Parse(args)
+
+	if *workspace == "" || *taskID == "" {
+		fmt.Fprintln(os.Stderr, "Error: --workspace and --task are required")
+		return daemon.ExitFailure
+	}
+
+	d, err := daemon.New(*workspace, version)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return daemon.ExitFailure
+	}
+
+	result, err := d.RunTask(context.Background(), *taskID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return daemon.RunExitCode(nil, err)
+	}
+
+	fmt.Print(daemon.RunSummary(*taskID, result))
+	return daemon.RunExitCode(result, nil)
+}
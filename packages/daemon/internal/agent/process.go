@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/coven/daemon/internal/logging"
+	"github.com/coven/daemon/internal/secrets"
 	"github.com/coven/daemon/pkg/types"
 )
 
@@ -115,6 +116,11 @@ type SpawnConfig struct {
 	Timeout    time.Duration
 	// CloseStdin closes stdin immediately after spawn (for non-interactive agents like claude -p)
 	CloseStdin bool
+	// Stdin, if set, is written to the process's stdin, which is then closed
+	Stdin string
+	// Secrets are added to the process environment and zeroed, and removed
+	// from the map, once the process has started
+	Secrets secrets.Map
 }
 
 // Spawn starts a new agent process.
@@ -139,6 +145,8 @@ func (m *ProcessManager) Spawn(ctx context.Context, cfg SpawnConfig) (*ProcessIn
 	cmd := exec.CommandContext(procCtx, cfg.Command, cfg.Args...)
 	cmd.Dir = cfg.WorkingDir
 	cmd.Env = append(os.Environ(), cfg.Env...)
+	cmd.Env = append(cmd.Env, cfg.Secrets.Env()...)
+	defer cfg.Secrets.Zero()
 
 	// Set up process group for clean termination
 	cmd.SysProcAttr = &syscall.SysProcAttr{
@@ -194,6 +202,13 @@ func (m *ProcessManager) Spawn(ctx context.Context, cfg SpawnConfig) (*ProcessIn
 	if cfg.CloseStdin {
 		stdin.Close()
 		proc.stdin = nil
+	} else if cfg.Stdin != "" {
+		// Write in the background so a large prompt can't block on a full pipe
+		proc.stdin = nil
+		go func() {
+			io.WriteString(stdin, cfg.Stdin)
+			stdin.Close()
+		}()
 	}
 
 	// Start output capture goroutines
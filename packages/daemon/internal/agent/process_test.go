@@ -2,12 +2,14 @@ package agent
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/coven/daemon/internal/logging"
+	"github.com/coven/daemon/internal/secrets"
 )
 
 func newTestProcessManager(t *testing.T) *ProcessManager {
@@ -583,3 +585,36 @@ func TestProcessManagerExitCode(t *testing.T) {
 		t.Errorf("ExitCode = %d, want 42", result.ExitCode)
 	}
 }
+
+func TestProcessManagerSpawnSecrets(t *testing.T) {
+	pm := newTestProcessManager(t)
+	out := filepath.Join(t.TempDir(), "token.txt")
+
+	token := []byte("s3cret")
+	cfg := SpawnConfig{
+		TaskID:  "task-1",
+		Command: "sh",
+		Args:    []string{"-c", `printf %s "$API_TOKEN" > "$1"`, "sh", out},
+		Secrets: secrets.Map{"API_TOKEN": secrets.FromBytes(token)},
+	}
+	if _, err := pm.Spawn(context.Background(), cfg); err != nil {
+		t.Fatalf("Spawn() error: %v", err)
+	}
+	if _, err := pm.WaitForCompletion("task-1"); err != nil {
+		t.Fatalf("WaitForCompletion() error: %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error: %v", err)
+	}
+	if string(got) != "s3cret" {
+		t.Errorf("API_TOKEN = %q, want %q", got, "s3cret")
+	}
+	if len(cfg.Secrets) != 0 {
+		t.Errorf("Secrets has %d entries after Spawn(), want 0", len(cfg.Secrets))
+	}
+	if string(token) != "\x00\x00\x00\x00\x00\x00" {
+		t.Errorf("secret bytes = %q after Spawn(), want zeroed", token)
+	}
+}
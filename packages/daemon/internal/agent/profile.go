@@ -0,0 +1,133 @@
+package agent
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+)
+
+// PromptMode is how a prompt is handed to an agent process.
+type PromptMode string
+
+const (
+	// PromptArg passes the prompt as the final command-line argument.
+	PromptArg PromptMode = "arg"
+
+	// PromptStdin writes the prompt to the process's stdin and then closes it.
+	PromptStdin PromptMode = "stdin"
+)
+
+// IsValid reports whether the prompt mode is known. An empty mode is treated as PromptArg.
+func (m PromptMode) IsValid() bool {
+	switch m {
+	case "", PromptArg, PromptStdin:
+		return true
+	default:
+		return false
+	}
+}
+
+// AutoProfile is the profile name that selects the first built-in profile
+// whose command is installed.
+const AutoProfile = "auto"
+
+// Profile describes how to invoke an agent CLI.
+type Profile struct {
+	// Name identifies the profile.
+	Name string
+
+	// Command is the executable to run.
+	Command string
+
+	// Args are the base arguments, passed before the prompt.
+	Args []string
+
+	// PromptMode is how the prompt is passed (default: arg).
+	PromptMode PromptMode
+}
+
+// builtinProfiles are the agent CLIs coven knows how to invoke, in detection order.
+var builtinProfiles = []Profile{
+	{
+		Name:       "claude",
+		Command:    "claude",
+		Args:       []string{"-p", "--output-format", "stream-json", "--verbose"},
+		PromptMode: PromptArg,
+	},
+	{
+		Name:       "codex",
+		Command:    "codex",
+		Args:       []string{"exec", "-"},
+		PromptMode: PromptStdin,
+	},
+}
+
+// BuiltinProfile returns the built-in profile with the given name.
+func BuiltinProfile(name string) (Profile, bool) {
+	for _, p := range builtinProfiles {
+		if p.Name == name {
+			p.Args = append([]string{}, p.Args...)
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// BuiltinProfileNames returns the names of the built-in profiles, sorted.
+func BuiltinProfileNames() []string {
+	names := make([]string, 0, len(builtinProfiles))
+	for _, p := range builtinProfiles {
+		names = append(names, p.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsKnownProfile reports whether name is a built-in profile or AutoProfile.
+func IsKnownProfile(name string) bool {
+	if name == AutoProfile {
+		return true
+	}
+	_, ok := BuiltinProfile(name)
+	return ok
+}
+
+// DetectProfile returns the first built-in profile whose command is found by
+// lookPath, falling back to the first built-in profile if none are installed.
+// lookPath is usually exec.LookPath.
+func DetectProfile(lookPath func(string) (string, error)) Profile {
+	for _, p := range builtinProfiles {
+		if _, err := lookPath(p.Command); err == nil {
+			p, _ = BuiltinProfile(p.Name)
+			return p
+		}
+	}
+	p, _ := BuiltinProfile(builtinProfiles[0].Name)
+	return p
+}
+
+// ResolveProfile returns the profile named by name: a built-in profile, the
+// detected profile for AutoProfile, or custom when name is empty.
+func ResolveProfile(name string, custom Profile) (Profile, error) {
+	switch name {
+	case "":
+		return custom, nil
+	case AutoProfile:
+		return DetectProfile(exec.LookPath), nil
+	}
+	if p, ok := BuiltinProfile(name); ok {
+		return p, nil
+	}
+	return Profile{}, fmt.Errorf("unknown agent profile %q", name)
+}
+
+// Invocation returns the arguments and stdin content for running the
+// profile's command with the given prompt. stdin is empty when the prompt is
+// passed as an argument.
+func (p Profile) Invocation(prompt string) (args []string, stdin string) {
+	args = append([]string{}, p.Args...)
+	if p.PromptMode == PromptStdin {
+		return args, prompt
+	}
+	return append(args, prompt), ""
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestBuiltinProfile(t *testing.T) {
+	p, ok := BuiltinProfile("claude")
+	if !ok {
+		t.Fatal("BuiltinProfile(claude) not found")
+	}
+	if p.Command != "claude" || p.PromptMode != PromptArg {
+		t.Errorf("claude profile = %+v, want claude command with arg prompt", p)
+	}
+
+	// Callers may modify the returned args without affecting the built-in
+	p.Args[0] = "changed"
+	again, _ := BuiltinProfile("claude")
+	if again.Args[0] == "changed" {
+		t.Error("BuiltinProfile returned shared args")
+	}
+
+	if _, ok := BuiltinProfile("unknown"); ok {
+		t.Error("BuiltinProfile(unknown) should not be found")
+	}
+}
+
+func TestIsKnownProfile(t *testing.T) {
+	for _, name := range []string{"claude", "codex", AutoProfile} {
+		if !IsKnownProfile(name) {
+			t.Errorf("IsKnownProfile(%q) = false, want true", name)
+		}
+	}
+	for _, name := range []string{"", "custom", "gpt"} {
+		if IsKnownProfile(name) {
+			t.Errorf("IsKnownProfile(%q) = true, want false", name)
+		}
+	}
+}
+
+func TestDetectProfile(t *testing.T) {
+	onlyCodex := func(name string) (string, error) {
+		if name == "codex" {
+			return "/usr/bin/codex", nil
+		}
+		return "", errors.New("not found")
+	}
+	if p := DetectProfile(onlyCodex); p.Name != "codex" {
+		t.Errorf("DetectProfile() = %q, want codex", p.Name)
+	}
+
+	none := func(string) (string, error) { return "", errors.New("not found") }
+	if p := DetectProfile(none); p.Name != "claude" {
+		t.Errorf("DetectProfile() with nothing installed = %q, want claude", p.Name)
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	custom := Profile{Name: "custom", Command: "my-agent", PromptMode: PromptStdin}
+
+	p, err := ResolveProfile("", custom)
+	if err != nil {
+		t.Fatalf("ResolveProfile(\"\") error: %v", err)
+	}
+	if !reflect.DeepEqual(p, custom) {
+		t.Errorf("ResolveProfile(\"\") = %+v, want custom profile", p)
+	}
+
+	p, err = ResolveProfile("codex", custom)
+	if err != nil {
+		t.Fatalf("ResolveProfile(codex) error: %v", err)
+	}
+	if p.Command != "codex" {
+		t.Errorf("ResolveProfile(codex).Command = %q, want codex", p.Command)
+	}
+
+	if _, err := ResolveProfile("unknown", custom); err == nil {
+		t.Error("ResolveProfile(unknown) should return an error")
+	}
+}
+
+func TestProfile_Invocation(t *testing.T) {
+	tests := []struct {
+		name      string
+		profile   Profile
+		wantArgs  []string
+		wantStdin string
+	}{
+		{
+			name:     "arg",
+			profile:  Profile{Command: "claude", Args: []string{"-p"}, PromptMode: PromptArg},
+			wantArgs: []string{"-p", "do the thing"},
+		},
+		{
+			name:     "default is arg",
+			profile:  Profile{Command: "claude", Args: []string{"-p"}},
+			wantArgs: []string{"-p", "do the thing"},
+		},
+		{
+			name:      "stdin",
+			profile:   Profile{Command: "codex", Args: []string{"exec", "-"}, PromptMode: PromptStdin},
+			wantArgs:  []string{"exec", "-"},
+			wantStdin: "do the thing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, stdin := tt.profile.Invocation("do the thing")
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+			if stdin != tt.wantStdin {
+				t.Errorf("stdin = %q, want %q", stdin, tt.wantStdin)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"github.com/coven/daemon/pkg/types"
+)
+
+// EventFilter restricts an event subscription to a task or workflow, or to
+// several of them. A zero EventFilter matches every event.
+type EventFilter struct {
+	// TaskID matches events about this task.
+	TaskID string
+
+	// WorkflowID matches workflow events for this workflow. Events that carry
+	// no workflow ID (agent output, for example) match on TaskID instead, so
+	// callers filtering by workflow should also set TaskID when it is known.
+	WorkflowID string
+
+	// AnyOf, when set, matches events matching any of these filters, and
+	// TaskID and WorkflowID are ignored. A subscription to several workflows
+	// has one filter per workflow.
+	AnyOf []EventFilter
+}
+
+// IsEmpty reports whether the filter matches every event.
+func (f EventFilter) IsEmpty() bool {
+	return f.TaskID == "" && f.WorkflowID == "" && len(f.AnyOf) == 0
+}
+
+// Apply returns the event as seen through the filter, or nil if the event
+// does not match. State snapshots and task lists are scoped to the filtered
+// task rather than dropped, so a filtered client still receives heartbeats.
+// Events matched by a workflow filter are tagged with that workflow's ID, so
+// a client following several workflows can tell their events apart.
+func (f EventFilter) Apply(event *types.Event) *types.Event {
+	if f.IsEmpty() {
+		return event
+	}
+
+	switch data := event.Data.(type) {
+	case *types.DaemonState:
+		scoped := *event
+		scoped.Data = f.scopeState(data)
+		return &scoped
+
+	case []types.Task:
+		tasks := f.scopeTasks(data)
+		if len(tasks) == 0 {
+			return nil
+		}
+		scoped := *event
+		scoped.Data = tasks
+		return &scoped
+	}
+
+	taskID, workflowID := eventIDs(event.Data)
+	matched, ok := f.match(taskID, workflowID)
+	if !ok {
+		return nil
+	}
+	if matched == "" || event.WorkflowID == matched {
+		return event
+	}
+	tagged := *event
+	tagged.WorkflowID = matched
+	return &tagged
+}
+
+// match reports whether an event with the given task and workflow IDs
+// matches the filter, and the ID of the workflow it was matched for, if any.
+func (f EventFilter) match(taskID, workflowID string) (string, bool) {
+	if len(f.AnyOf) > 0 {
+		for _, sub := range f.AnyOf {
+			if matched, ok := sub.match(taskID, workflowID); ok {
+				return matched, true
+			}
+		}
+		return "", false
+	}
+	if f.WorkflowID != "" && workflowID != "" {
+		return f.WorkflowID, workflowID == f.WorkflowID
+	}
+	if f.TaskID != "" && taskID == f.TaskID {
+		return f.WorkflowID, true
+	}
+	return "", false
+}
+
+// includesTask reports whether the filter covers the given task.
+func (f EventFilter) includesTask(taskID string) bool {
+	if len(f.AnyOf) > 0 {
+		for _, sub := range f.AnyOf {
+			if sub.includesTask(taskID) {
+				return true
+			}
+		}
+		return false
+	}
+	return f.TaskID != "" && taskID == f.TaskID
+}
+
+// scopeState returns a copy of the state containing only the filtered tasks.
+func (f EventFilter) scopeState(s *types.DaemonState) *types.DaemonState {
+	scoped := &types.DaemonState{
+		Workflow:     s.Workflow,
+		Agents:       make(map[string]*types.Agent),
+		Tasks:        f.scopeTasks(s.Tasks),
+		LastTaskSync: s.LastTaskSync,
+	}
+	for taskID, agent := range s.Agents {
+		if f.includesTask(taskID) {
+			scoped.Agents[taskID] = agent
+		}
+	}
+	return scoped
+}
+
+// scopeTasks returns the tasks matching the filtered tasks.
+func (f EventFilter) scopeTasks(tasks []types.Task) []types.Task {
+	scoped := []types.Task{}
+	for _, task := range tasks {
+		if f.includesTask(task.ID) {
+			scoped = append(scoped, task)
+		}
+	}
+	return scoped
+}
+
+// eventIDs extracts the task and workflow IDs carried by an event payload.
+func eventIDs(data any) (taskID, workflowID string) {
+	switch d := data.(type) {
+	case WorkflowEventData:
+		return d.TaskID, d.WorkflowID
+	case *WorkflowEventData:
+		return d.TaskID, d.WorkflowID
+	case *types.Agent:
+		return d.TaskID, ""
+	case map[string]string:
+		return d["task_id"], d["workflow_id"]
+	case map[string]any:
+		taskID, _ = d["task_id"].(string)
+		workflowID, _ = d["workflow_id"].(string)
+		if agent, ok := d["agent"].(*types.Agent); ok && taskID == "" {
+			taskID = agent.TaskID
+		}
+		return taskID, workflowID
+	}
+	return "", ""
+}
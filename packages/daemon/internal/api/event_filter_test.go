@@ -0,0 +1,100 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/pkg/types"
+)
+
+func TestEventFilter_Apply(t *testing.T) {
+	agentFor := func(taskID string) *types.Agent {
+		return &types.Agent{TaskID: taskID, Status: types.AgentStatusRunning}
+	}
+	twoWorkflows := EventFilter{AnyOf: []EventFilter{
+		{TaskID: "task-1", WorkflowID: "wf-1"},
+		{TaskID: "task-2", WorkflowID: "wf-2"},
+	}}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		data   any
+		want   bool
+	}{
+		{"empty filter matches everything", EventFilter{}, "anything", true},
+		{"task matches workflow event", EventFilter{TaskID: "task-1"}, WorkflowEventData{TaskID: "task-1", WorkflowID: "wf-1"}, true},
+		{"task rejects other workflow event", EventFilter{TaskID: "task-1"}, WorkflowEventData{TaskID: "task-2", WorkflowID: "wf-2"}, false},
+		{"task matches agent", EventFilter{TaskID: "task-1"}, agentFor("task-1"), true},
+		{"task rejects other agent", EventFilter{TaskID: "task-1"}, agentFor("task-2"), false},
+		{"task matches agent output", EventFilter{TaskID: "task-1"}, map[string]string{"task_id": "task-1", "output": "hi"}, true},
+		{"task matches agent failure", EventFilter{TaskID: "task-1"}, map[string]any{"agent": agentFor("task-1"), "error": "boom"}, true},
+		{"task matches question", EventFilter{TaskID: "task-1"}, map[string]interface{}{"task_id": "task-1", "text": "?"}, true},
+		{"workflow matches workflow event", EventFilter{WorkflowID: "wf-1"}, WorkflowEventData{TaskID: "task-1", WorkflowID: "wf-1"}, true},
+		{"workflow rejects other workflow of same task", EventFilter{TaskID: "task-1", WorkflowID: "wf-1"}, WorkflowEventData{TaskID: "task-1", WorkflowID: "wf-old"}, false},
+		{"workflow with task matches agent output", EventFilter{TaskID: "task-1", WorkflowID: "wf-1"}, map[string]string{"task_id": "task-1"}, true},
+		{"workflow without task rejects agent output", EventFilter{WorkflowID: "wf-1"}, map[string]string{"task_id": "task-1"}, false},
+		{"unknown payload is rejected", EventFilter{TaskID: "task-1"}, "test", false},
+		{"any of matches first workflow", twoWorkflows, WorkflowEventData{TaskID: "task-1", WorkflowID: "wf-1"}, true},
+		{"any of matches second workflow's agent output", twoWorkflows, map[string]string{"task_id": "task-2"}, true},
+		{"any of rejects other workflow", twoWorkflows, WorkflowEventData{TaskID: "task-3", WorkflowID: "wf-3"}, false},
+		{"any of rejects other agent output", twoWorkflows, map[string]string{"task_id": "task-3"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &types.Event{Type: "test", Data: tt.data, Timestamp: time.Now()}
+			got := tt.filter.Apply(event) != nil
+			if got != tt.want {
+				t.Errorf("Apply() matched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventFilter_Apply_ScopesSnapshot(t *testing.T) {
+	filter := EventFilter{TaskID: "task-1"}
+	state := &types.DaemonState{
+		Agents: map[string]*types.Agent{
+			"task-1": {TaskID: "task-1"},
+			"task-2": {TaskID: "task-2"},
+		},
+		Tasks: []types.Task{{ID: "task-1"}, {ID: "task-2"}},
+	}
+	event := &types.Event{Type: types.EventTypeStateSnapshot, Data: state, Timestamp: time.Now()}
+
+	scoped := filter.Apply(event)
+	if scoped == nil {
+		t.Fatal("Apply() should never drop state snapshots")
+	}
+	scopedState := scoped.Data.(*types.DaemonState)
+	if len(scopedState.Agents) != 1 || scopedState.Agents["task-1"] == nil {
+		t.Errorf("Agents = %v, want only task-1", scopedState.Agents)
+	}
+	if len(scopedState.Tasks) != 1 || scopedState.Tasks[0].ID != "task-1" {
+		t.Errorf("Tasks = %v, want only task-1", scopedState.Tasks)
+	}
+
+	// The original event must not be modified
+	if len(state.Agents) != 2 || len(state.Tasks) != 2 {
+		t.Error("Apply() modified the original state")
+	}
+}
+
+func TestEventFilter_Apply_ScopesTaskList(t *testing.T) {
+	filter := EventFilter{TaskID: "task-2"}
+
+	event := &types.Event{Type: types.EventTypeTasksUpdated, Data: []types.Task{{ID: "task-1"}, {ID: "task-2"}}}
+	scoped := filter.Apply(event)
+	if scoped == nil {
+		t.Fatal("Apply() dropped a task list containing the filtered task")
+	}
+	if tasks := scoped.Data.([]types.Task); len(tasks) != 1 || tasks[0].ID != "task-2" {
+		t.Errorf("Tasks = %v, want only task-2", tasks)
+	}
+
+	other := &types.Event{Type: types.EventTypeTasksUpdated, Data: []types.Task{{ID: "task-1"}}}
+	if filter.Apply(other) != nil {
+		t.Error("Apply() should drop task lists without the filtered task")
+	}
+}
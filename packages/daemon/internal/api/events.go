@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -14,9 +15,12 @@ import (
 // EventBroker manages SSE client connections and event broadcasting.
 type EventBroker struct {
 	mu      sync.RWMutex
-	clients map[chan *types.Event]struct{}
+	clients map[chan *types.Event]EventFilter
 	store   *state.Store
 
+	// resolveWorkflow maps a workflow ID to its task ID (optional)
+	resolveWorkflow func(workflowID string) string
+
 	// Heartbeat configuration
 	heartbeatInterval time.Duration
 	stopCh            chan struct{}
@@ -26,7 +30,7 @@ type EventBroker struct {
 // NewEventBroker creates a new event broker.
 func NewEventBroker(store *state.Store) *EventBroker {
 	return &EventBroker{
-		clients:           make(map[chan *types.Event]struct{}),
+		clients:           make(map[chan *types.Event]EventFilter),
 		store:             store,
 		heartbeatInterval: 30 * time.Second,
 	}
@@ -66,15 +70,29 @@ func (b *EventBroker) Stop() {
 
 // Subscribe adds a new client and returns their event channel.
 func (b *EventBroker) Subscribe() chan *types.Event {
+	return b.SubscribeFiltered(EventFilter{})
+}
+
+// SubscribeFiltered adds a new client that only receives events matching the filter.
+func (b *EventBroker) SubscribeFiltered(filter EventFilter) chan *types.Event {
 	ch := make(chan *types.Event, 100) // Buffer to prevent blocking
 
 	b.mu.Lock()
-	b.clients[ch] = struct{}{}
+	b.clients[ch] = filter
 	b.mu.Unlock()
 
 	return ch
 }
 
+// SetWorkflowResolver sets the function used to find the task for a workflow ID.
+// It lets workflow-filtered subscriptions also receive task events (such as
+// agent output) that do not carry a workflow ID.
+func (b *EventBroker) SetWorkflowResolver(resolve func(workflowID string) string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resolveWorkflow = resolve
+}
+
 // Unsubscribe removes a client.
 func (b *EventBroker) Unsubscribe(ch chan *types.Event) {
 	b.mu.Lock()
@@ -86,14 +104,18 @@ func (b *EventBroker) Unsubscribe(ch chan *types.Event) {
 	}
 }
 
-// Broadcast sends an event to all connected clients.
+// Broadcast sends an event to all connected clients whose filter matches it.
 func (b *EventBroker) Broadcast(event *types.Event) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
-	for ch := range b.clients {
+	for ch, filter := range b.clients {
+		filtered := filter.Apply(event)
+		if filtered == nil {
+			continue
+		}
 		select {
-		case ch <- event:
+		case ch <- filtered:
 		default:
 			// Client buffer full, skip this event
 		}
@@ -297,9 +319,37 @@ func (b *EventBroker) EmitWorkflowCancelled(workflowID, taskID string) {
 	})
 }
 
+// GrimoireChangeEventData describes a user grimoire or spell file that was
+// added, changed or removed.
+type GrimoireChangeEventData struct {
+	// Kind is "grimoire" or "spell".
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// Path is the file's path relative to the .coven directory.
+	Path    string `json:"path"`
+	Removed bool   `json:"removed,omitempty"`
+
+	// Valid reports whether the file loads; Error says why it doesn't.
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// EmitGrimoireChanged broadcasts a grimoire changed event.
+func (b *EventBroker) EmitGrimoireChanged(change GrimoireChangeEventData) {
+	b.Broadcast(&types.Event{
+		Type:      types.EventTypeGrimoireChanged,
+		Data:      change,
+		Timestamp: time.Now(),
+	})
+}
+
 // SSE HTTP Handler
 
 // HandleEvents handles the SSE endpoint.
+// The optional query parameters task and workflow restrict the stream
+// (including the initial snapshot) to events about that task or workflow.
+// workflow may be repeated to merge several workflows' events into one stream.
 func (b *EventBroker) HandleEvents(w http.ResponseWriter, r *http.Request) {
 	// Check if the client supports SSE
 	flusher, ok := w.(http.Flusher)
@@ -315,16 +365,17 @@ func (b *EventBroker) HandleEvents(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("X-Accel-Buffering", "no") // Disable nginx buffering
 
 	// Subscribe to events
-	eventCh := b.Subscribe()
+	filter := b.filterFromRequest(r)
+	eventCh := b.SubscribeFiltered(filter)
 	defer b.Unsubscribe(eventCh)
 
 	// Send initial state snapshot
 	state := b.store.GetState()
-	initialEvent := &types.Event{
+	initialEvent := filter.Apply(&types.Event{
 		Type:      types.EventTypeStateSnapshot,
 		Data:      state,
 		Timestamp: time.Now(),
-	}
+	})
 	if err := writeSSEEvent(w, initialEvent); err != nil {
 		return
 	}
@@ -349,6 +400,52 @@ func (b *EventBroker) HandleEvents(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// filterFromRequest builds an event filter from the task and workflow query
+// parameters. When several workflows are requested, the filter matches any of
+// them, each paired with its own task, and a task parameter adds that task.
+func (b *EventBroker) filterFromRequest(r *http.Request) EventFilter {
+	query := r.URL.Query()
+	taskID := query.Get("task")
+
+	var workflowIDs []string
+	for _, id := range query["workflow"] {
+		if id != "" && !slices.Contains(workflowIDs, id) {
+			workflowIDs = append(workflowIDs, id)
+		}
+	}
+
+	b.mu.RLock()
+	resolve := b.resolveWorkflow
+	b.mu.RUnlock()
+
+	resolveTask := func(workflowID string) string {
+		if resolve == nil {
+			return ""
+		}
+		return resolve(workflowID)
+	}
+
+	switch len(workflowIDs) {
+	case 0:
+		return EventFilter{TaskID: taskID}
+	case 1:
+		filter := EventFilter{TaskID: taskID, WorkflowID: workflowIDs[0]}
+		if filter.TaskID == "" {
+			filter.TaskID = resolveTask(filter.WorkflowID)
+		}
+		return filter
+	}
+
+	var filter EventFilter
+	for _, id := range workflowIDs {
+		filter.AnyOf = append(filter.AnyOf, EventFilter{TaskID: resolveTask(id), WorkflowID: id})
+	}
+	if taskID != "" {
+		filter.AnyOf = append(filter.AnyOf, EventFilter{TaskID: taskID})
+	}
+	return filter
+}
+
 // writeSSEEvent writes a single SSE event to the response writer.
 func writeSSEEvent(w http.ResponseWriter, event *types.Event) error {
 	data, err := json.Marshal(event)
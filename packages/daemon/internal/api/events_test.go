@@ -239,6 +239,11 @@ func TestEventEmitters(t *testing.T) {
 			emit:     func() { broker.EmitAgentFailed(&types.Agent{}, "error") },
 			wantType: types.EventTypeAgentFailed,
 		},
+		{
+			name:     "GrimoireChanged",
+			emit:     func() { broker.EmitGrimoireChanged(GrimoireChangeEventData{Kind: "grimoire", Name: "review"}) },
+			wantType: types.EventTypeGrimoireChanged,
+		},
 	}
 
 	for _, tt := range tests {
@@ -403,3 +408,226 @@ func TestSetHeartbeatInterval(t *testing.T) {
 		t.Errorf("heartbeatInterval = %v, want 5s", broker.heartbeatInterval)
 	}
 }
+
+func TestEventBrokerSubscribeFiltered(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := state.NewStore(tmpDir)
+	broker := NewEventBroker(store)
+
+	all := broker.Subscribe()
+	filtered := broker.SubscribeFiltered(EventFilter{TaskID: "task-1"})
+	defer broker.Unsubscribe(all)
+	defer broker.Unsubscribe(filtered)
+
+	broker.EmitAgentOutput("task-2", "other output")
+	broker.EmitAgentOutput("task-1", "my output")
+
+	// Unfiltered client receives both events
+	for i := 0; i < 2; i++ {
+		select {
+		case <-all:
+		case <-time.After(time.Second):
+			t.Fatalf("unfiltered client received %d events, want 2", i)
+		}
+	}
+
+	// Filtered client only receives the matching event
+	select {
+	case received := <-filtered:
+		data := received.Data.(map[string]string)
+		if data["task_id"] != "task-1" {
+			t.Errorf("filtered client received event for %q, want task-1", data["task_id"])
+		}
+	case <-time.After(time.Second):
+		t.Fatal("filtered client did not receive matching event")
+	}
+
+	select {
+	case received := <-filtered:
+		t.Errorf("filtered client received unexpected event: %+v", received)
+	default:
+	}
+}
+
+func TestHandleEventsFiltered(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := state.NewStore(tmpDir)
+	store.AddAgent(&types.Agent{TaskID: "task-1", Status: types.AgentStatusRunning})
+	store.AddAgent(&types.Agent{TaskID: "task-2", Status: types.AgentStatusRunning})
+	broker := NewEventBroker(store)
+	broker.SetWorkflowResolver(func(workflowID string) string {
+		if workflowID == "wf-1" {
+			return "task-1"
+		}
+		return ""
+	})
+
+	socketPath := "/tmp/coven-events-test3.sock"
+	server := NewServer(socketPath)
+	broker.Register(server)
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop(context.Background())
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://unix/events?workflow=wf-1", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() types.Event {
+		t.Helper()
+		reader.ReadString('\n') // event line
+		dataLine, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read data line: %v", err)
+		}
+		reader.ReadString('\n') // blank line
+		var event types.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &event); err != nil {
+			t.Fatalf("Failed to parse event: %v", err)
+		}
+		return event
+	}
+
+	// Initial snapshot is scoped to the workflow's task
+	snapshot := readEvent()
+	if snapshot.Type != types.EventTypeStateSnapshot {
+		t.Fatalf("first event type = %q, want %q", snapshot.Type, types.EventTypeStateSnapshot)
+	}
+	agents := snapshot.Data.(map[string]any)["agents"].(map[string]any)
+	if len(agents) != 1 || agents["task-1"] == nil {
+		t.Errorf("snapshot agents = %v, want only task-1", agents)
+	}
+
+	// Non-matching events are dropped; matching ones are delivered in order
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		broker.EmitWorkflowStepStarted("wf-2", "task-2", "other", "script", 0)
+		broker.EmitAgentOutput("task-2", "other output")
+		broker.EmitWorkflowStepStarted("wf-1", "task-1", "build", "script", 0)
+		broker.EmitAgentOutput("task-1", "my output")
+	}()
+
+	first := readEvent()
+	if first.Type != types.EventTypeWorkflowStepStarted {
+		t.Fatalf("event type = %q, want %q", first.Type, types.EventTypeWorkflowStepStarted)
+	}
+	if wf := first.Data.(map[string]any)["workflow_id"]; wf != "wf-1" {
+		t.Errorf("workflow_id = %v, want wf-1", wf)
+	}
+
+	second := readEvent()
+	if second.Type != types.EventTypeAgentOutput {
+		t.Fatalf("event type = %q, want %q", second.Type, types.EventTypeAgentOutput)
+	}
+	if task := second.Data.(map[string]any)["task_id"]; task != "task-1" {
+		t.Errorf("task_id = %v, want task-1", task)
+	}
+}
+
+func TestHandleEventsMultipleWorkflows(t *testing.T) {
+	tmpDir := t.TempDir()
+	store := state.NewStore(tmpDir)
+	store.AddAgent(&types.Agent{TaskID: "task-1", Status: types.AgentStatusRunning})
+	store.AddAgent(&types.Agent{TaskID: "task-2", Status: types.AgentStatusRunning})
+	store.AddAgent(&types.Agent{TaskID: "task-3", Status: types.AgentStatusRunning})
+	broker := NewEventBroker(store)
+	broker.SetWorkflowResolver(func(workflowID string) string {
+		return map[string]string{"wf-1": "task-1", "wf-2": "task-2", "wf-3": "task-3"}[workflowID]
+	})
+
+	socketPath := "/tmp/coven-events-test4.sock"
+	server := NewServer(socketPath)
+	broker.Register(server)
+
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop(context.Background())
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://unix/events?workflow=wf-1&workflow=wf-2", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() types.Event {
+		t.Helper()
+		reader.ReadString('\n') // event line
+		dataLine, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read data line: %v", err)
+		}
+		reader.ReadString('\n') // blank line
+		var event types.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &event); err != nil {
+			t.Fatalf("Failed to parse event: %v", err)
+		}
+		return event
+	}
+
+	// Initial snapshot is scoped to both workflows' tasks
+	snapshot := readEvent()
+	if snapshot.Type != types.EventTypeStateSnapshot {
+		t.Fatalf("first event type = %q, want %q", snapshot.Type, types.EventTypeStateSnapshot)
+	}
+	agents := snapshot.Data.(map[string]any)["agents"].(map[string]any)
+	if len(agents) != 2 || agents["task-1"] == nil || agents["task-2"] == nil {
+		t.Errorf("snapshot agents = %v, want task-1 and task-2", agents)
+	}
+
+	// Events from either workflow are delivered, tagged with their workflow;
+	// events from other workflows are dropped
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		broker.EmitWorkflowStepStarted("wf-3", "task-3", "other", "script", 0)
+		broker.EmitAgentOutput("task-3", "other output")
+		broker.EmitWorkflowStepStarted("wf-1", "task-1", "build", "script", 0)
+		broker.EmitAgentOutput("task-2", "test output")
+		broker.EmitWorkflowCompleted("wf-2", "task-2", "implement", "1s")
+	}()
+
+	want := []struct {
+		eventType  string
+		workflowID string
+	}{
+		{types.EventTypeWorkflowStepStarted, "wf-1"},
+		{types.EventTypeAgentOutput, "wf-2"},
+		{types.EventTypeWorkflowCompleted, "wf-2"},
+	}
+	for _, w := range want {
+		event := readEvent()
+		if event.Type != w.eventType || event.WorkflowID != w.workflowID {
+			t.Errorf("event = %s tagged %q, want %s tagged %q", event.Type, event.WorkflowID, w.eventType, w.workflowID)
+		}
+	}
+}
@@ -17,6 +17,9 @@ type Handlers struct {
 	buildTime string
 	startTime time.Time
 	workspace string
+
+	// conditions reports degraded conditions for /health (optional)
+	conditions func() []types.HealthCondition
 }
 
 // NewHandlers creates a new handlers instance.
@@ -31,9 +34,16 @@ func NewHandlers(store *state.Store, version, gitCommit, buildTime, workspace st
 	}
 }
 
+// SetConditionsProvider sets the function that reports degraded conditions.
+// When it returns any conditions, /health reports a "degraded" status.
+func (h *Handlers) SetConditionsProvider(conditions func() []types.HealthCondition) {
+	h.conditions = conditions
+}
+
 // HandleHealth returns the daemon health status.
 // @Summary      Get daemon health status
-// @Description  Returns the current health status, version, uptime, and workspace of the daemon
+// @Description  Returns the current health status, version, uptime, and workspace of the daemon.
+// @Description  Status is "degraded" when conditions such as low disk space prevent new agents from starting.
 // @Tags         health
 // @Accept       json
 // @Produce      json
@@ -52,6 +62,12 @@ func (h *Handlers) HandleHealth(w http.ResponseWriter, r *http.Request) {
 		Uptime:    time.Since(h.startTime).String(),
 		Workspace: h.workspace,
 	}
+	if h.conditions != nil {
+		health.Conditions = h.conditions()
+	}
+	if len(health.Conditions) > 0 {
+		health.Status = "degraded"
+	}
 
 	WriteJSON(w, http.StatusOK, health)
 }
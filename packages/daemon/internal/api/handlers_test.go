@@ -85,6 +85,39 @@ func TestHandleHealth(t *testing.T) {
 	})
 }
 
+func TestHandleHealth_Degraded(t *testing.T) {
+	_, handlers, client, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	handlers.SetConditionsProvider(func() []types.HealthCondition {
+		return []types.HealthCondition{
+			{Name: types.HealthConditionLowDiskSpace, Message: "insufficient disk space"},
+		}
+	})
+
+	resp, err := client.Get("http://unix/health")
+	if err != nil {
+		t.Fatalf("GET /health error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var health types.HealthStatus
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	if health.Status != "degraded" {
+		t.Errorf("Status = %q, want %q", health.Status, "degraded")
+	}
+	if len(health.Conditions) != 1 || health.Conditions[0].Name != types.HealthConditionLowDiskSpace {
+		t.Errorf("Conditions = %+v, want one %q condition", health.Conditions, types.HealthConditionLowDiskSpace)
+	}
+}
+
 func TestHandleVersion(t *testing.T) {
 	_, _, client, cleanup := setupTestServer(t)
 	defer cleanup()
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/logging"
+)
+
+// startLoggedServer starts a server with request logging to a temp file and
+// returns a client for it and the log path.
+func startLoggedServer(t *testing.T, register func(s *Server)) (*http.Client, string) {
+	t.Helper()
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "api.log")
+	logger, err := logging.New(logPath)
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	t.Cleanup(func() { logger.Close() })
+
+	socketPath := filepath.Join(dir, "test.sock")
+	server := NewServer(socketPath)
+	server.SetLogger(logger)
+	register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	return client, logPath
+}
+
+// readRequestLogs returns the "api request" entries written to the log.
+func readRequestLogs(t *testing.T, logPath string) []logging.LogEntry {
+	t.Helper()
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	var entries []logging.LogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry logging.LogEntry
+		if json.Unmarshal([]byte(line), &entry) == nil && entry.Message == "api request" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func TestRequestLogging(t *testing.T) {
+	var handlerID string
+	client, logPath := startLoggedServer(t, func(s *Server) {
+		s.RegisterHandlerFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+			handlerID = RequestID(r.Context())
+			WriteError(w, http.StatusNotFound, "not found")
+		})
+	})
+
+	resp, err := client.Get("http://unix/missing?verbose=1")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	resp.Body.Close()
+
+	id := resp.Header.Get(RequestIDHeader)
+	if id == "" {
+		t.Fatal("Response should include a generated request ID")
+	}
+	if handlerID != id {
+		t.Errorf("RequestID() in handler = %q, want %q", handlerID, id)
+	}
+
+	entries := readRequestLogs(t, logPath)
+	if len(entries) != 1 {
+		t.Fatalf("Logged %d requests, want 1", len(entries))
+	}
+	fields := entries[0].Fields
+	if fields["request_id"] != id {
+		t.Errorf("request_id = %v, want %q", fields["request_id"], id)
+	}
+	if fields["method"] != "GET" || fields["path"] != "/missing" {
+		t.Errorf("method/path = %v %v, want GET /missing", fields["method"], fields["path"])
+	}
+	if fields["status"] != float64(http.StatusNotFound) {
+		t.Errorf("status = %v, want %d", fields["status"], http.StatusNotFound)
+	}
+	if _, ok := fields["duration_ms"]; !ok {
+		t.Error("Log entry should include duration_ms")
+	}
+}
+
+func TestRequestLogging_PassesThroughRequestID(t *testing.T) {
+	client, logPath := startLoggedServer(t, func(s *Server) {
+		s.RegisterHandlerFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://unix/ok", nil)
+	req.Header.Set(RequestIDHeader, "client-123")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := resp.Header.Get(RequestIDHeader); got != "client-123" {
+		t.Errorf("%s = %q, want %q", RequestIDHeader, got, "client-123")
+	}
+
+	entries := readRequestLogs(t, logPath)
+	if len(entries) != 1 || entries[0].Fields["request_id"] != "client-123" {
+		t.Errorf("Log entries = %+v, want one with request_id client-123", entries)
+	}
+	if entries[0].Fields["status"] != float64(http.StatusOK) {
+		t.Errorf("status = %v, want %d", entries[0].Fields["status"], http.StatusOK)
+	}
+}
+
+func TestRequestLogging_StreamsUnbuffered(t *testing.T) {
+	release := make(chan struct{})
+	client, _ := startLoggedServer(t, func(s *Server) {
+		s.RegisterHandlerFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+			flusher, ok := w.(http.Flusher)
+			if !ok {
+				WriteError(w, http.StatusInternalServerError, "streaming not supported")
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: first\n\n"))
+			flusher.Flush()
+			<-release
+		})
+	})
+	defer close(release)
+
+	resp, err := client.Get("http://unix/events")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The first event must arrive while the handler is still running
+	lineCh := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(resp.Body).ReadString('\n')
+		lineCh <- line
+	}()
+	select {
+	case line := <-lineCh:
+		if line != "data: first\n" {
+			t.Errorf("First line = %q, want %q", line, "data: first\n")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Streamed event was buffered by the middleware")
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/coven/daemon/internal/logging"
 )
 
 // Server is an HTTP server that listens on a Unix socket.
@@ -19,6 +22,7 @@ type Server struct {
 	mux        *http.ServeMux
 	mu         sync.Mutex
 	running    bool
+	logger     atomic.Pointer[logging.Logger]
 }
 
 // HealthResponse is the response from the health endpoint.
@@ -32,15 +36,16 @@ type HealthResponse struct {
 // NewServer creates a new HTTP server that will listen on the given Unix socket path.
 func NewServer(socketPath string) *Server {
 	mux := http.NewServeMux()
-	return &Server{
+	s := &Server{
 		socketPath: socketPath,
 		mux:        mux,
 		server: &http.Server{
-			Handler:      mux,
 			ReadTimeout:  30 * time.Second,
 			WriteTimeout: 30 * time.Second,
 		},
 	}
+	s.server.Handler = s.withRequestLogging(mux)
+	return s
 }
 
 // RegisterHandler registers an HTTP handler for the given pattern.
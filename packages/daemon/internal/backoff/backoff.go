@@ -0,0 +1,109 @@
+// Package backoff computes exponential retry delays with jitter, so that
+// retries started together don't stay synchronized.
+package backoff
+
+import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// Jitter is how a computed delay is randomized.
+type Jitter string
+
+const (
+	// JitterNone uses the exponential delay as is.
+	JitterNone Jitter = "none"
+
+	// JitterFull picks a delay uniformly between 0 and the exponential delay.
+	JitterFull Jitter = "full"
+
+	// JitterEqual keeps half the exponential delay and picks the other half
+	// uniformly, so a retry never comes sooner than half the delay.
+	JitterEqual Jitter = "equal"
+)
+
+// IsValidJitter returns true if j is a known jitter mode.
+func IsValidJitter(j Jitter) bool {
+	return j == JitterNone || j == JitterFull || j == JitterEqual
+}
+
+// defaultJitter is the jitter used by policies that don't set one.
+var defaultJitter atomic.Value
+
+func init() {
+	defaultJitter.Store(JitterEqual)
+}
+
+// SetDefaultJitter sets the jitter used by policies that don't set their own.
+func SetDefaultJitter(j Jitter) {
+	defaultJitter.Store(j)
+}
+
+// DefaultJitter returns the jitter used by policies that don't set their own.
+func DefaultJitter() Jitter {
+	return defaultJitter.Load().(Jitter)
+}
+
+// Policy describes an exponential backoff.
+type Policy struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+
+	// Max caps the delay before jitter is applied. Zero means no cap.
+	Max time.Duration
+
+	// Multiplier is how much the delay grows after each attempt (default 2).
+	Multiplier float64
+
+	// Jitter randomizes each delay. If empty, DefaultJitter is used.
+	Jitter Jitter
+}
+
+// Delay returns how long to wait before retry number attempt, counting from 0.
+func (p Policy) Delay(attempt int) time.Duration {
+	return p.jitter(p.Base(attempt))
+}
+
+// Base returns the delay before retry number attempt without jitter.
+func (p Policy) Base(attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	d := float64(p.Initial) * math.Pow(multiplier, float64(attempt))
+	if p.Max > 0 && d > float64(p.Max) {
+		return p.Max
+	}
+	if d > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(d)
+}
+
+// jitter randomizes d according to the policy's jitter mode.
+func (p Policy) jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+
+	mode := p.Jitter
+	if mode == "" {
+		mode = DefaultJitter()
+	}
+
+	switch mode {
+	case JitterFull:
+		return time.Duration(rand.Int64N(int64(d) + 1))
+	case JitterEqual:
+		half := d / 2
+		return half + time.Duration(rand.Int64N(int64(d-half)+1))
+	default:
+		return d
+	}
+}
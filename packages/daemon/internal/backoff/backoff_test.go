@@ -0,0 +1,95 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicy_Base(t *testing.T) {
+	p := Policy{Initial: 100 * time.Millisecond, Max: time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{-1, 100 * time.Millisecond},
+		{0, 100 * time.Millisecond},
+		{1, 200 * time.Millisecond},
+		{3, 800 * time.Millisecond},
+		{4, time.Second},
+		{100, time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := p.Base(tt.attempt); got != tt.want {
+			t.Errorf("Base(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+
+	tripled := Policy{Initial: time.Second, Multiplier: 3}
+	if got := tripled.Base(2); got != 9*time.Second {
+		t.Errorf("Base(2) with multiplier 3 = %v, want 9s", got)
+	}
+}
+
+func TestPolicy_Delay(t *testing.T) {
+	tests := []struct {
+		jitter Jitter
+		min    time.Duration
+		max    time.Duration
+	}{
+		{JitterNone, 800 * time.Millisecond, 800 * time.Millisecond},
+		{JitterFull, 0, 800 * time.Millisecond},
+		{JitterEqual, 400 * time.Millisecond, 800 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.jitter), func(t *testing.T) {
+			p := Policy{Initial: 100 * time.Millisecond, Jitter: tt.jitter}
+
+			seen := make(map[time.Duration]bool)
+			for i := 0; i < 100; i++ {
+				d := p.Delay(3)
+				if d < tt.min || d > tt.max {
+					t.Fatalf("Delay(3) = %v, want within [%v, %v]", d, tt.min, tt.max)
+				}
+				seen[d] = true
+			}
+
+			if tt.jitter != JitterNone && len(seen) < 2 {
+				t.Errorf("Delay(3) returned %d distinct values over 100 calls, want them to differ", len(seen))
+			}
+		})
+	}
+}
+
+func TestPolicy_DelayUsesDefaultJitter(t *testing.T) {
+	defer SetDefaultJitter(DefaultJitter())
+
+	p := Policy{Initial: time.Second}
+
+	SetDefaultJitter(JitterNone)
+	if got := p.Delay(0); got != time.Second {
+		t.Errorf("Delay(0) with no jitter = %v, want 1s", got)
+	}
+
+	SetDefaultJitter(JitterEqual)
+	for i := 0; i < 20; i++ {
+		if got := p.Delay(0); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("Delay(0) with equal jitter = %v, want within [500ms, 1s]", got)
+		}
+	}
+}
+
+func TestIsValidJitter(t *testing.T) {
+	for _, j := range []Jitter{JitterNone, JitterFull, JitterEqual} {
+		if !IsValidJitter(j) {
+			t.Errorf("IsValidJitter(%q) = false, want true", j)
+		}
+	}
+	for _, j := range []Jitter{"", "decorrelated"} {
+		if IsValidJitter(j) {
+			t.Errorf("IsValidJitter(%q) = true, want false", j)
+		}
+	}
+}
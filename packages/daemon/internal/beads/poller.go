@@ -2,6 +2,7 @@ package beads
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -20,6 +21,8 @@ type Poller struct {
 	logger   *logging.Logger
 	interval time.Duration
 
+	onBeadClosed func(taskID, reason string)
+
 	stopCh  chan struct{}
 	running bool
 }
@@ -42,6 +45,15 @@ func (p *Poller) SetInterval(d time.Duration) {
 	p.interval = d
 }
 
+// OnBeadClosed sets a callback called during a poll for each task with a
+// running agent whose bead beads now reports closed or no longer lists.
+// It is called on every poll until the agent stops.
+func (p *Poller) OnBeadClosed(fn func(taskID, reason string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onBeadClosed = fn
+}
+
 // Start begins the polling loop.
 func (p *Poller) Start() {
 	p.mu.Lock()
@@ -84,10 +96,12 @@ func (p *Poller) Poll(ctx context.Context) error {
 
 	// Check for changes
 	oldTasks := p.store.GetTasks()
+	agents := p.store.GetAllAgents()
+	closed := closedActiveTasks(tasks, agents)
 
-	// Merge status updates: preserve local "terminal" statuses (blocked, closed)
-	// that may not have propagated to beads yet
-	mergedTasks := p.mergeTaskStatuses(oldTasks, tasks)
+	// Merge status updates: preserve local statuses beads hasn't caught up
+	// with yet, so a sync can't undo them
+	mergedTasks := p.mergeTaskStatuses(oldTasks, tasks, agents)
 
 	changed := p.tasksChanged(oldTasks, mergedTasks)
 
@@ -99,36 +113,106 @@ func (p *Poller) Poll(ctx context.Context) error {
 		p.broker.EmitTasksUpdated(mergedTasks)
 	}
 
+	p.mu.Lock()
+	onBeadClosed := p.onBeadClosed
+	p.mu.Unlock()
+	if onBeadClosed != nil {
+		for _, taskID := range sortedKeys(closed) {
+			onBeadClosed(taskID, closed[taskID])
+		}
+	}
+
 	return nil
 }
 
+// closedActiveTasks returns the tasks with a running agent whose bead is
+// closed or missing from fetched, mapped to the reason.
+func closedActiveTasks(fetched []types.Task, agents map[string]*types.Agent) map[string]string {
+	statuses := make(map[string]types.TaskStatus, len(fetched))
+	for _, t := range fetched {
+		statuses[t.ID] = t.Status
+	}
+
+	closed := make(map[string]string)
+	for taskID, agent := range agents {
+		if !isAgentActive(agent) {
+			continue
+		}
+		status, ok := statuses[taskID]
+		switch {
+		case !ok:
+			closed[taskID] = "bead was deleted"
+		case status == types.TaskStatusClosed:
+			closed[taskID] = "bead was closed"
+		}
+	}
+	return closed
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // mergeTaskStatuses merges local status updates with fetched tasks.
 // This preserves terminal statuses (blocked, closed) that may not have
-// propagated to beads yet due to timing.
-func (p *Poller) mergeTaskStatuses(oldTasks, newTasks []types.Task) []types.Task {
-	// Build map of old task statuses
-	oldStatusMap := make(map[string]types.TaskStatus)
+// propagated to beads yet due to timing. It also keeps a task in progress
+// while beads still reports it open if an agent is working on it, or if the
+// local status was set more recently than beads last updated the task, so a
+// running task isn't picked up again.
+func (p *Poller) mergeTaskStatuses(oldTasks, newTasks []types.Task, agents map[string]*types.Agent) []types.Task {
+	// Build map of old tasks
+	oldTaskMap := make(map[string]types.Task, len(oldTasks))
 	for _, t := range oldTasks {
-		oldStatusMap[t.ID] = t.Status
+		oldTaskMap[t.ID] = t
 	}
 
 	// Merge statuses
 	for i := range newTasks {
-		oldStatus, exists := oldStatusMap[newTasks[i].ID]
+		if newTasks[i].Status == types.TaskStatusOpen && isAgentActive(agents[newTasks[i].ID]) {
+			newTasks[i].Status = types.TaskStatusInProgress
+			continue
+		}
+
+		oldTask, exists := oldTaskMap[newTasks[i].ID]
 		if !exists {
 			continue
 		}
 
 		// If local status is a terminal status that beads doesn't have yet,
 		// preserve the local status
-		if isTerminalStatus(oldStatus) && !isTerminalStatus(newTasks[i].Status) {
-			newTasks[i].Status = oldStatus
+		if isTerminalStatus(oldTask.Status) && !isTerminalStatus(newTasks[i].Status) {
+			newTasks[i].Status = oldTask.Status
+			continue
+		}
+
+		// A local in_progress newer than beads' open hasn't reached beads yet
+		if oldTask.Status == types.TaskStatusInProgress && newTasks[i].Status == types.TaskStatusOpen &&
+			oldTask.UpdatedAt.After(newTasks[i].UpdatedAt) {
+			newTasks[i].Status = types.TaskStatusInProgress
+		}
+	}
+
+	// Keep the reason for a status the merge left as it was
+	for i := range newTasks {
+		if oldTask, exists := oldTaskMap[newTasks[i].ID]; exists && newTasks[i].Status == oldTask.Status {
+			newTasks[i].StatusReason = oldTask.StatusReason
 		}
 	}
 
 	return newTasks
 }
 
+// isAgentActive returns true if the agent is starting or running a workflow.
+func isAgentActive(agent *types.Agent) bool {
+	return agent != nil && (agent.Status == types.AgentStatusStarting || agent.Status == types.AgentStatusRunning)
+}
+
 // isTerminalStatus returns true for statuses that indicate workflow completion.
 func isTerminalStatus(status types.TaskStatus) bool {
 	switch status {
@@ -276,3 +276,101 @@ func TestPollerLoopRuns(t *testing.T) {
 		t.Errorf("Expected 1 task after polling, got %d", len(tasks))
 	}
 }
+
+func TestPollerKeepsRunningTaskInProgress(t *testing.T) {
+	output := `[{"id":"task-1","title":"Test","status":"open","priority":2,"issue_type":"task","updated_at":"2030-01-01T00:00:00Z"}]`
+	poller, store, _ := newTestPoller(t, output)
+
+	store.SetTasks([]types.Task{{ID: "task-1", Title: "Test", Status: types.TaskStatusOpen}})
+	store.UpdateTaskStatus("task-1", types.TaskStatusInProgress, types.StatusReasonStarted)
+	store.AddAgent(&types.Agent{TaskID: "task-1", Status: types.AgentStatusRunning})
+
+	// Syncing repeatedly must not reset the running task to open
+	for i := 0; i < 2; i++ {
+		if err := poller.Poll(context.Background()); err != nil {
+			t.Fatalf("Poll() error: %v", err)
+		}
+		if status := store.GetTasks()[0].Status; status != types.TaskStatusInProgress {
+			t.Fatalf("poll %d: Status = %q, want %q", i+1, status, types.TaskStatusInProgress)
+		}
+		if reason := store.GetTasks()[0].StatusReason; reason != types.StatusReasonStarted {
+			t.Fatalf("poll %d: StatusReason = %q, want %q", i+1, reason, types.StatusReasonStarted)
+		}
+	}
+
+	// Once the agent has finished, beads is the source of truth again
+	store.UpdateAgentStatus("task-1", types.AgentStatusFailed)
+	if err := poller.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+	if status := store.GetTasks()[0].Status; status != types.TaskStatusOpen {
+		t.Errorf("Status = %q, want %q", status, types.TaskStatusOpen)
+	}
+}
+
+func TestPollerMergeInProgress(t *testing.T) {
+	poller, _, _ := newTestPoller(t, "[]")
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		localAt    time.Time
+		beadsAt    time.Time
+		wantStatus types.TaskStatus
+	}{
+		{
+			name:       "local in_progress newer than beads is kept",
+			localAt:    now,
+			beadsAt:    now.Add(-time.Minute),
+			wantStatus: types.TaskStatusInProgress,
+		},
+		{
+			name:       "beads reopened the task after the local change",
+			localAt:    now.Add(-time.Minute),
+			beadsAt:    now,
+			wantStatus: types.TaskStatusOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldTasks := []types.Task{{ID: "task-1", Status: types.TaskStatusInProgress, UpdatedAt: tt.localAt}}
+			newTasks := []types.Task{{ID: "task-1", Status: types.TaskStatusOpen, UpdatedAt: tt.beadsAt}}
+
+			merged := poller.mergeTaskStatuses(oldTasks, newTasks, nil)
+			if merged[0].Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", merged[0].Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestPollerOnBeadClosed(t *testing.T) {
+	output := `[{"id":"task-1","title":"Closed","status":"closed","priority":2,"issue_type":"task"},` +
+		`{"id":"task-2","title":"Open","status":"open","priority":2,"issue_type":"task"},` +
+		`{"id":"task-4","title":"Done","status":"closed","priority":2,"issue_type":"task"}]`
+	poller, store, _ := newTestPoller(t, output)
+
+	store.AddAgent(&types.Agent{TaskID: "task-1", Status: types.AgentStatusRunning})
+	store.AddAgent(&types.Agent{TaskID: "task-2", Status: types.AgentStatusRunning})
+	store.AddAgent(&types.Agent{TaskID: "task-3", Status: types.AgentStatusStarting})
+	store.AddAgent(&types.Agent{TaskID: "task-4", Status: types.AgentStatusCompleted})
+
+	got := make(map[string]string)
+	poller.OnBeadClosed(func(taskID, reason string) {
+		got[taskID] = reason
+	})
+	if err := poller.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+
+	want := map[string]string{"task-1": "bead was closed", "task-3": "bead was deleted"}
+	if len(got) != len(want) {
+		t.Fatalf("closed beads = %v, want %v", got, want)
+	}
+	for taskID, reason := range want {
+		if got[taskID] != reason {
+			t.Errorf("reason for %s = %q, want %q", taskID, got[taskID], reason)
+		}
+	}
+}
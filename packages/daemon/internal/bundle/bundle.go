@@ -0,0 +1,249 @@
+// Package bundle reads and installs bundles of grimoires and spells.
+//
+// A bundle is either a tar archive (optionally gzip-compressed) or a
+// multi-document YAML stream. Installation is all-or-nothing: every item is
+// validated before anything is written to the user directory.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a file extracted from a bundle.
+type File struct {
+	// Name is the file name, relative to the install directory.
+	Name string
+
+	// Data is the file content.
+	Data []byte
+}
+
+// Result describes the outcome of installing a single bundle item.
+type Result struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// InstallResponse is the response for bundle install endpoints.
+type InstallResponse struct {
+	Installed int      `json:"installed"`
+	Results   []Result `json:"results"`
+}
+
+// InvalidBundleError is returned when one or more bundle items fail
+// validation. Nothing is installed when this error is returned.
+type InvalidBundleError struct {
+	Failed int
+	Total  int
+}
+
+func (e *InvalidBundleError) Error() string {
+	return fmt.Sprintf("%d of %d bundle items failed validation, nothing installed", e.Failed, e.Total)
+}
+
+// IsInvalidBundle checks if an error is an InvalidBundleError.
+func IsInvalidBundle(err error) bool {
+	_, ok := err.(*InvalidBundleError)
+	return ok
+}
+
+// FormatError is returned when a bundle cannot be read, for example because
+// the archive is corrupt, the YAML is malformed, or the bundle is empty.
+type FormatError struct {
+	Err error
+}
+
+func (e *FormatError) Error() string {
+	return fmt.Sprintf("invalid bundle: %v", e.Err)
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+// IsFormatError checks if an error is a FormatError.
+func IsFormatError(err error) bool {
+	_, ok := err.(*FormatError)
+	return ok
+}
+
+// CheckResults returns an InvalidBundleError if any result failed.
+func CheckResults(results []Result) error {
+	failed := 0
+	for _, r := range results {
+		if !r.Success {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return &InvalidBundleError{Failed: failed, Total: len(results)}
+	}
+	return nil
+}
+
+// IsArchive reports whether data looks like a tar or gzip-compressed archive.
+func IsArchive(data []byte) bool {
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		return true
+	}
+	return len(data) >= 262 && string(data[257:262]) == "ustar"
+}
+
+// ReadArchive extracts the regular files with one of the given extensions from
+// a tar archive, decompressing it first if it is gzipped. Files are returned in
+// archive order with their base names; directories and other files are skipped.
+// A FormatError is returned if the archive cannot be read.
+func ReadArchive(data []byte, exts ...string) ([]File, error) {
+	var r io.Reader = bytes.NewReader(data)
+	if len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, &FormatError{Err: fmt.Errorf("failed to decompress archive: %w", err)}
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var files []File
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &FormatError{Err: fmt.Errorf("failed to read archive: %w", err)}
+		}
+		if hdr.Typeflag != tar.TypeReg || !hasExt(hdr.Name, exts) {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, &FormatError{Err: fmt.Errorf("failed to read %s from archive: %w", hdr.Name, err)}
+		}
+		files = append(files, File{Name: path.Base(hdr.Name), Data: content})
+	}
+	return files, nil
+}
+
+// hasExt reports whether name ends with one of the extensions.
+func hasExt(name string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteAll writes files into dir as a unit. Each file is staged to a temporary
+// file first; if any write or rename fails, staged files are removed and files
+// that were already replaced are restored to their previous content.
+func WriteAll(dir string, files []File) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	staged := make([]string, len(files))
+	removeStaged := func() {
+		for _, p := range staged {
+			if p != "" {
+				os.Remove(p)
+			}
+		}
+	}
+
+	for i, f := range files {
+		tmp, err := os.CreateTemp(dir, "."+f.Name+".tmp-*")
+		if err != nil {
+			removeStaged()
+			return fmt.Errorf("failed to stage %s: %w", f.Name, err)
+		}
+		staged[i] = tmp.Name()
+		_, werr := tmp.Write(f.Data)
+		cerr := tmp.Close()
+		if werr == nil {
+			werr = cerr
+		}
+		if werr == nil {
+			werr = os.Chmod(tmp.Name(), 0644)
+		}
+		if werr != nil {
+			removeStaged()
+			return fmt.Errorf("failed to stage %s: %w", f.Name, werr)
+		}
+	}
+
+	type previous struct {
+		path    string
+		data    []byte
+		existed bool
+	}
+	var replaced []previous
+	rollback := func() {
+		for _, p := range replaced {
+			if p.existed {
+				os.WriteFile(p.path, p.data, 0644)
+			} else {
+				os.Remove(p.path)
+			}
+		}
+	}
+
+	for i, f := range files {
+		target := filepath.Join(dir, f.Name)
+		prev := previous{path: target}
+		if data, err := os.ReadFile(target); err == nil {
+			prev.data = data
+			prev.existed = true
+		}
+		if err := os.Rename(staged[i], target); err != nil {
+			removeStaged()
+			rollback()
+			return fmt.Errorf("failed to install %s: %w", f.Name, err)
+		}
+		staged[i] = ""
+		replaced = append(replaced, prev)
+	}
+	return nil
+}
+
+// SplitYAML splits a multi-document YAML stream into its documents, each
+// re-encoded as a standalone YAML document. Empty documents are skipped.
+// A FormatError is returned if the stream is not valid YAML.
+func SplitYAML(data []byte) ([][]byte, error) {
+	var docs [][]byte
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &FormatError{Err: fmt.Errorf("failed to parse YAML document %d: %w", len(docs)+1, err)}
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind == yaml.ScalarNode && node.Content[0].Tag == "!!null" {
+			continue
+		}
+
+		doc, err := yaml.Marshal(&node)
+		if err != nil {
+			return nil, &FormatError{Err: fmt.Errorf("failed to encode YAML document %d: %w", len(docs)+1, err)}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
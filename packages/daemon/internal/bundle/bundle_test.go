@@ -0,0 +1,177 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// makeTar builds a tar archive from name/content pairs.
+func makeTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader() error: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close() error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsArchive(t *testing.T) {
+	tarData := makeTar(t, map[string]string{"a.yaml": "name: a"})
+
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"tar", tarData, true},
+		{"gzip", gzipBytes(t, tarData), true},
+		{"yaml", []byte("name: a\n---\nname: b\n"), false},
+		{"empty", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsArchive(tt.data); got != tt.want {
+				t.Errorf("IsArchive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadArchive(t *testing.T) {
+	tarData := makeTar(t, map[string]string{
+		"bundle/one.yaml": "name: one",
+		"two.yml":         "name: two",
+		"README.md":       "ignored",
+	})
+
+	for name, data := range map[string][]byte{"tar": tarData, "tar.gz": gzipBytes(t, tarData)} {
+		t.Run(name, func(t *testing.T) {
+			files, err := ReadArchive(data, ".yaml", ".yml")
+			if err != nil {
+				t.Fatalf("ReadArchive() error: %v", err)
+			}
+			got := make(map[string]string)
+			for _, f := range files {
+				got[f.Name] = string(f.Data)
+			}
+			if len(got) != 2 || got["one.yaml"] != "name: one" || got["two.yml"] != "name: two" {
+				t.Errorf("ReadArchive() = %v", got)
+			}
+		})
+	}
+
+	t.Run("corrupt gzip", func(t *testing.T) {
+		_, err := ReadArchive([]byte{0x1f, 0x8b, 0x00}, ".yaml")
+		if !IsFormatError(err) {
+			t.Errorf("ReadArchive() error = %v, want FormatError", err)
+		}
+	})
+}
+
+func TestSplitYAML(t *testing.T) {
+	docs, err := SplitYAML([]byte("name: one\n---\n---\nname: two\n"))
+	if err != nil {
+		t.Fatalf("SplitYAML() error: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("SplitYAML() returned %d documents, want 2", len(docs))
+	}
+	if string(docs[0]) != "name: one\n" || string(docs[1]) != "name: two\n" {
+		t.Errorf("SplitYAML() = %q", docs)
+	}
+
+	if _, err := SplitYAML([]byte("name: [unclosed\n")); !IsFormatError(err) {
+		t.Errorf("SplitYAML() error = %v, want FormatError", err)
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "grimoires")
+
+	files := []File{
+		{Name: "one.yaml", Data: []byte("one")},
+		{Name: "two.yaml", Data: []byte("two")},
+	}
+	if err := WriteAll(dir, files); err != nil {
+		t.Fatalf("WriteAll() error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir() error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("WriteAll() left %d entries, want 2 (no staged files)", len(entries))
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "two.yaml"))
+	if string(data) != "two" {
+		t.Errorf("two.yaml = %q, want two", data)
+	}
+}
+
+func TestWriteAll_RollsBackOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "one.yaml"), []byte("original"), 0644); err != nil {
+		t.Fatalf("WriteFile() error: %v", err)
+	}
+	// A directory in the way makes the second rename fail
+	if err := os.MkdirAll(filepath.Join(dir, "two.yaml", "child"), 0755); err != nil {
+		t.Fatalf("MkdirAll() error: %v", err)
+	}
+
+	err := WriteAll(dir, []File{
+		{Name: "one.yaml", Data: []byte("replaced")},
+		{Name: "two.yaml", Data: []byte("two")},
+	})
+	if err == nil {
+		t.Fatal("WriteAll() should fail when a target cannot be replaced")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "one.yaml"))
+	if string(data) != "original" {
+		t.Errorf("one.yaml = %q, want original content restored", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("WriteAll() left %d entries, want 2 (no staged files)", len(entries))
+	}
+}
+
+func TestCheckResults(t *testing.T) {
+	if err := CheckResults([]Result{{Name: "a", Success: true}}); err != nil {
+		t.Errorf("CheckResults() error = %v, want nil", err)
+	}
+
+	err := CheckResults([]Result{{Name: "a", Success: true}, {Name: "b", Error: "bad"}})
+	if !IsInvalidBundle(err) {
+		t.Fatalf("CheckResults() error = %v, want InvalidBundleError", err)
+	}
+	if e := err.(*InvalidBundleError); e.Failed != 1 || e.Total != 2 {
+		t.Errorf("InvalidBundleError = %+v", e)
+	}
+}
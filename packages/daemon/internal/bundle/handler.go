@@ -0,0 +1,48 @@
+package bundle
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/coven/daemon/internal/api"
+)
+
+// MaxBundleSize is the largest bundle accepted by install endpoints.
+const MaxBundleSize = 32 << 20
+
+// InstallFunc validates and installs a bundle, returning per-item results.
+type InstallFunc func(data []byte) ([]Result, error)
+
+// ServeInstall handles a bundle install request by passing the request body to
+// install. It responds with 200 when every item was installed, 422 with the
+// per-item results when any item was invalid, and 400 for unreadable bundles.
+func ServeInstall(w http.ResponseWriter, r *http.Request, install InstallFunc) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBundleSize))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "failed to read bundle: "+err.Error())
+		return
+	}
+
+	results, err := install(data)
+	if err != nil {
+		switch {
+		case IsInvalidBundle(err):
+			api.WriteJSON(w, http.StatusUnprocessableEntity, InstallResponse{Results: results})
+		case IsFormatError(err):
+			api.WriteError(w, http.StatusBadRequest, err.Error())
+		default:
+			api.WriteError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, InstallResponse{
+		Installed: len(results),
+		Results:   results,
+	})
+}
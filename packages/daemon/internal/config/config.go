@@ -5,6 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+
+	"github.com/coven/daemon/internal/agent"
+	"github.com/coven/daemon/internal/backoff"
+	"github.com/coven/daemon/internal/cron"
 )
 
 // Config represents the daemon configuration.
@@ -18,21 +23,135 @@ type Config struct {
 	// AgentArgs are the arguments to pass to the agent command (default: ["-p", "--output-format", "stream-json", "--verbose"]).
 	AgentArgs []string `json:"agent_args"`
 
+	// AgentPromptMode is how the prompt is passed to agent_command: "arg" (default) or "stdin".
+	AgentPromptMode string `json:"agent_prompt_mode,omitempty"`
+
+	// AgentProfile selects a built-in agent profile ("claude", "codex") or "auto" to detect an installed one.
+	// When empty, agents are run with agent_command, agent_args and agent_prompt_mode.
+	AgentProfile string `json:"agent_profile,omitempty"`
+
 	// MaxConcurrentAgents is the maximum number of concurrent agents.
 	MaxConcurrentAgents int `json:"max_concurrent_agents"`
 
+	// MaxConcurrentWorkflows is the maximum number of unfinished workflows, including blocked ones and those not running an agent (0 means no limit).
+	MaxConcurrentWorkflows int `json:"max_concurrent_workflows,omitempty"`
+
+	// PreemptLowerPriority pauses the lowest-priority running workflow when every agent slot is taken and a higher-priority task is ready, resuming it once a slot is free (default false).
+	PreemptLowerPriority bool `json:"preempt_lower_priority,omitempty"`
+
 	// LogLevel is the logging level (debug, info, warn, error).
 	LogLevel string `json:"log_level"`
+
+	// MinFreeDiskMB is the free disk space, in megabytes, required to start a new agent (0 disables the check).
+	MinFreeDiskMB int `json:"min_free_disk_mb"`
+
+	// ShutdownGraceSeconds is how long running workflows may take to finish their current step on shutdown before their agents are killed.
+	ShutdownGraceSeconds int `json:"shutdown_grace_seconds"`
+
+	// LogFlushIntervalMs is the longest, in milliseconds, a workflow log entry is buffered before being written (0 flushes only when the buffer fills or the workflow ends).
+	LogFlushIntervalMs int `json:"log_flush_interval_ms"`
+
+	// LogBufferBytes is the size of the workflow log write buffer (0 writes each entry immediately).
+	LogBufferBytes int `json:"log_buffer_bytes"`
+
+	// LogFormat is the encoding of workflow logs: "verbose" (default) or "compact", which uses short field names and leaves out zero values.
+	LogFormat string `json:"log_format"`
+
+	// MergeStepValidation is how grimoires with misplaced merge steps are reported: "warning" (default) or "error".
+	MergeStepValidation string `json:"merge_step_validation"`
+
+	// MaxNestingDepth is how deep grimoire steps may be nested, counting top-level steps as depth 1 (default 10).
+	MaxNestingDepth int `json:"max_nesting_depth"`
+
+	// GrimoireEnvPrefixes are the prefixes of environment variables grimoires may interpolate with ${VAR} (default ["COVEN_"]).
+	GrimoireEnvPrefixes []string `json:"grimoire_env_prefixes,omitempty"`
+
+	// RetryJitter is how retry backoff delays are randomized: "equal" (default), "full" or "none".
+	RetryJitter string `json:"retry_jitter,omitempty"`
+
+	// WorktreeRoot is the directory task worktrees are created in, relative to the workspace if not absolute (default .coven/worktrees).
+	WorktreeRoot string `json:"worktree_root,omitempty"`
+
+	// CancelOnBeadClose cancels a running workflow when its bead is closed or deleted outside coven (default true).
+	CancelOnBeadClose bool `json:"cancel_on_bead_close"`
+
+	// WatchGrimoires watches .coven/grimoires and .coven/spells and emits a grimoire.changed event, with the file's validation result, when a file there changes (default true).
+	WatchGrimoires bool `json:"watch_grimoires"`
+
+	// DebugStepRun enables POST /grimoires/:name/steps/:step/run, which runs a single grimoire step on its own for debugging (default false).
+	DebugStepRun bool `json:"debug_step_run"`
+
+	// Schedules are grimoires to run on a cron schedule instead of from a bead.
+	Schedules []ScheduleConfig `json:"schedules,omitempty"`
+
+	// ScriptPolicy restricts the commands script steps may run.
+	ScriptPolicy ScriptPolicyConfig `json:"script_policy,omitempty"`
+
+	// AgentCassette records agent runs to a cassette file, or replays them from one instead of running agents.
+	AgentCassette AgentCassetteConfig `json:"agent_cassette,omitempty"`
+}
+
+// AgentCassetteConfig records or replays agent runs, for testing grimoires
+// deterministically against real agent behaviour.
+type AgentCassetteConfig struct {
+	// Mode is "record" to record each agent step's prompt and response, or "replay" to answer agent steps from the cassette. When empty, agents run normally.
+	Mode string `json:"mode,omitempty"`
+
+	// Path is the cassette file, relative to the .coven directory if not absolute.
+	Path string `json:"path,omitempty"`
+}
+
+// ScriptPolicyConfig restricts the commands script steps may run. A step
+// whose rendered command is refused fails with a policy violation.
+type ScriptPolicyConfig struct {
+	// Deny are regular expressions; commands matching any of them are refused.
+	Deny []string `json:"deny,omitempty"`
+
+	// Allow lists the executables script steps may run. When empty, any
+	// executable not refused by Deny may run.
+	Allow []string `json:"allow,omitempty"`
+}
+
+// ScheduleConfig declares a grimoire that runs on a cron schedule.
+type ScheduleConfig struct {
+	// Name identifies the schedule (default: the grimoire name).
+	Name string `json:"name,omitempty"`
+
+	// Grimoire is the name of the grimoire to run.
+	Grimoire string `json:"grimoire"`
+
+	// Cron is a standard 5-field cron expression (e.g., "0 3 * * 1").
+	Cron string `json:"cron"`
+
+	// Repo is the path to the repository to run in (default: the daemon workspace).
+	Repo string `json:"repo,omitempty"`
+}
+
+// ScheduleName returns the schedule's name, defaulting to the grimoire name.
+func (s ScheduleConfig) ScheduleName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Grimoire
 }
 
 // DefaultConfig returns the default configuration.
 func DefaultConfig() *Config {
 	return &Config{
-		PollInterval:        1,
-		AgentCommand:        "claude",
-		AgentArgs:           []string{"-p", "--output-format", "stream-json", "--verbose"},
-		MaxConcurrentAgents: 3,
-		LogLevel:            "info",
+		PollInterval:         1,
+		AgentCommand:         "claude",
+		AgentArgs:            []string{"-p", "--output-format", "stream-json", "--verbose"},
+		MaxConcurrentAgents:  3,
+		LogLevel:             "info",
+		MinFreeDiskMB:        1024,
+		ShutdownGraceSeconds: 30,
+		LogFlushIntervalMs:   500,
+		LogBufferBytes:       64 * 1024,
+		LogFormat:            "verbose",
+		MergeStepValidation:  "warning",
+		MaxNestingDepth:      10,
+		CancelOnBeadClose:    true,
+		WatchGrimoires:       true,
 	}
 }
 
@@ -80,5 +199,94 @@ func (c *Config) Validate() error {
 	if c.MaxConcurrentAgents < 1 {
 		return fmt.Errorf("max_concurrent_agents must be at least 1")
 	}
+	if c.MaxConcurrentWorkflows < 0 {
+		return fmt.Errorf("max_concurrent_workflows must not be negative")
+	}
+	if c.AgentProfile != "" && !agent.IsKnownProfile(c.AgentProfile) {
+		return fmt.Errorf("agent_profile must be %q or one of %v, got %q", agent.AutoProfile, agent.BuiltinProfileNames(), c.AgentProfile)
+	}
+	if !agent.PromptMode(c.AgentPromptMode).IsValid() {
+		return fmt.Errorf("agent_prompt_mode must be \"arg\" or \"stdin\", got %q", c.AgentPromptMode)
+	}
+	if c.MinFreeDiskMB < 0 {
+		return fmt.Errorf("min_free_disk_mb must not be negative")
+	}
+	if c.ShutdownGraceSeconds < 0 {
+		return fmt.Errorf("shutdown_grace_seconds must not be negative")
+	}
+	if c.LogFlushIntervalMs < 0 {
+		return fmt.Errorf("log_flush_interval_ms must not be negative")
+	}
+	if c.LogBufferBytes < 0 {
+		return fmt.Errorf("log_buffer_bytes must not be negative")
+	}
+	if c.LogFormat != "" && c.LogFormat != "verbose" && c.LogFormat != "compact" {
+		return fmt.Errorf("log_format must be \"verbose\" or \"compact\", got %q", c.LogFormat)
+	}
+	if c.MergeStepValidation != "" && c.MergeStepValidation != "warning" && c.MergeStepValidation != "error" {
+		return fmt.Errorf("merge_step_validation must be \"warning\" or \"error\", got %q", c.MergeStepValidation)
+	}
+
+	if c.MaxNestingDepth < 0 {
+		return fmt.Errorf("max_nesting_depth must not be negative")
+	}
+
+	for _, prefix := range c.GrimoireEnvPrefixes {
+		if prefix == "" {
+			return fmt.Errorf("grimoire_env_prefixes must not contain an empty prefix")
+		}
+	}
+
+	if c.RetryJitter != "" && !backoff.IsValidJitter(backoff.Jitter(c.RetryJitter)) {
+		return fmt.Errorf("retry_jitter must be \"equal\", \"full\" or \"none\", got %q", c.RetryJitter)
+	}
+	switch c.AgentCassette.Mode {
+	case "":
+	case "record", "replay":
+		if c.AgentCassette.Path == "" {
+			return fmt.Errorf("agent_cassette.path is required with mode %q", c.AgentCassette.Mode)
+		}
+	default:
+		return fmt.Errorf("agent_cassette.mode must be \"record\" or \"replay\", got %q", c.AgentCassette.Mode)
+	}
+	for i, pattern := range c.ScriptPolicy.Deny {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("script_policy.deny[%d]: invalid pattern %q: %w", i, pattern, err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for i, sched := range c.Schedules {
+		if sched.Grimoire == "" {
+			return fmt.Errorf("schedules[%d]: grimoire is required", i)
+		}
+		if _, err := cron.Parse(sched.Cron); err != nil {
+			return fmt.Errorf("schedules[%d]: %w", i, err)
+		}
+		name := sched.ScheduleName()
+		if !isValidScheduleName(name) {
+			return fmt.Errorf("schedules[%d]: name %q may only contain letters, digits, '-' and '_'", i, name)
+		}
+		if names[name] {
+			return fmt.Errorf("schedules[%d]: duplicate schedule name %q", i, name)
+		}
+		names[name] = true
+	}
+
 	return nil
 }
+
+// isValidScheduleName checks that a schedule name is safe to use in task IDs and branch names.
+func isValidScheduleName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
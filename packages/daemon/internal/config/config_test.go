@@ -21,6 +21,33 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.LogLevel != "info" {
 		t.Errorf("LogLevel = %q, want %q", cfg.LogLevel, "info")
 	}
+	if cfg.MinFreeDiskMB != 1024 {
+		t.Errorf("MinFreeDiskMB = %d, want 1024", cfg.MinFreeDiskMB)
+	}
+	if cfg.ShutdownGraceSeconds != 30 {
+		t.Errorf("ShutdownGraceSeconds = %d, want 30", cfg.ShutdownGraceSeconds)
+	}
+	if cfg.LogFlushIntervalMs != 500 {
+		t.Errorf("LogFlushIntervalMs = %d, want 500", cfg.LogFlushIntervalMs)
+	}
+	if cfg.LogBufferBytes != 64*1024 {
+		t.Errorf("LogBufferBytes = %d, want %d", cfg.LogBufferBytes, 64*1024)
+	}
+	if cfg.LogFormat != "verbose" {
+		t.Errorf("LogFormat = %q, want %q", cfg.LogFormat, "verbose")
+	}
+	if cfg.MergeStepValidation != "warning" {
+		t.Errorf("MergeStepValidation = %q, want %q", cfg.MergeStepValidation, "warning")
+	}
+	if cfg.MaxNestingDepth != 10 {
+		t.Errorf("MaxNestingDepth = %d, want 10", cfg.MaxNestingDepth)
+	}
+	if !cfg.CancelOnBeadClose {
+		t.Error("CancelOnBeadClose = false, want true")
+	}
+	if !cfg.WatchGrimoires {
+		t.Error("WatchGrimoires = false, want true")
+	}
 }
 
 func TestLoadNoFile(t *testing.T) {
@@ -190,6 +217,244 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative max concurrent workflows",
+			cfg: &Config{
+				PollInterval:           1,
+				AgentCommand:           "claude",
+				MaxConcurrentAgents:    1,
+				MaxConcurrentWorkflows: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative min free disk",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				MinFreeDiskMB:       -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative shutdown grace",
+			cfg: &Config{
+				PollInterval:         1,
+				AgentCommand:         "claude",
+				MaxConcurrentAgents:  1,
+				ShutdownGraceSeconds: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown agent profile",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				AgentProfile:        "gpt",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid agent prompt mode",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				AgentPromptMode:     "file",
+			},
+			wantErr: true,
+		},
+		{
+			name: "auto agent profile",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				AgentProfile:        "auto",
+				AgentPromptMode:     "stdin",
+			},
+			wantErr: false,
+		},
+		{
+			name: "negative log flush interval",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				LogFlushIntervalMs:  -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative log buffer size",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				LogBufferBytes:      -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid log format",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				LogFormat:           "binary",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid merge step validation",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				MergeStepValidation: "fatal",
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative max nesting depth",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				MaxNestingDepth:     -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty grimoire env prefix",
+			cfg: &Config{
+				PollInterval:        1,
+				AgentCommand:        "claude",
+				MaxConcurrentAgents: 1,
+				GrimoireEnvPrefixes: []string{"COVEN_", ""},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid retry jitter",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				RetryJitter:         "full",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid retry jitter",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				RetryJitter:         "random",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid script policy",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				ScriptPolicy: ScriptPolicyConfig{
+					Deny:  []string{`rm\s+-rf\s+/(\s|$)`},
+					Allow: []string{"go", "npm"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid script policy deny pattern",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				ScriptPolicy:        ScriptPolicyConfig{Deny: []string{"rm ("}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid agent cassette",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				AgentCassette:       AgentCassetteConfig{Mode: "replay", Path: "cassettes/implement.json"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "agent cassette without path",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				AgentCassette:       AgentCassetteConfig{Mode: "record"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid agent cassette mode",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				AgentCassette:       AgentCassetteConfig{Mode: "rewind", Path: "cassette.json"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid schedules",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				Schedules: []ScheduleConfig{
+					{Grimoire: "update-deps", Cron: "0 3 * * 1"},
+					{Name: "nightly-cleanup", Grimoire: "cleanup", Cron: "@daily", Repo: "/repo"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "schedule missing grimoire",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				Schedules:           []ScheduleConfig{{Cron: "@daily"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "schedule invalid cron",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				Schedules:           []ScheduleConfig{{Grimoire: "cleanup", Cron: "every day"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "schedule invalid name",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				Schedules:           []ScheduleConfig{{Name: "nightly cleanup", Grimoire: "cleanup", Cron: "@daily"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "duplicate schedule names",
+			cfg: &Config{
+				PollInterval:        1,
+				MaxConcurrentAgents: 1,
+				Schedules: []ScheduleConfig{
+					{Grimoire: "cleanup", Cron: "@daily"},
+					{Grimoire: "cleanup", Cron: "@weekly"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,204 @@
+// Package cron parses standard 5-field cron expressions and computes run times.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	// expr is the original expression.
+	expr string
+
+	minute, hour, dom, month, dow uint64
+
+	// domStar and dowStar record whether the day fields were unrestricted.
+	// When both day fields are restricted, a time matches if either matches.
+	domStar, dowStar bool
+}
+
+// field describes the valid range for one cron field.
+type field struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteField = field{"minute", 0, 59}
+	hourField   = field{"hour", 0, 23}
+	domField    = field{"day of month", 1, 31}
+	monthField  = field{"month", 1, 12}
+	dowField    = field{"day of week", 0, 7} // 0 and 7 are both Sunday
+)
+
+// aliases maps predefined schedules to their 5-field equivalents.
+var aliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression of the form "minute hour dom month dow".
+// Each field accepts "*", numbers, ranges ("1-5"), lists ("1,3,5"), and
+// steps ("*/15", "0-30/5"). The aliases @hourly, @daily, @weekly, @monthly,
+// and @yearly are also accepted.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if alias, ok := aliases[spec]; ok {
+		spec = alias
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expr, len(fields))
+	}
+
+	s := &Schedule{expr: expr}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	if s.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	if s.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+	if has(s.dow, 7) {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+
+	return s, nil
+}
+
+// String returns the original expression.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time after t that matches the schedule.
+// The result is truncated to the minute. A zero time is returned if no
+// matching time exists within the next five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			// Jump to the start of the next month
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches checks the day-of-month and day-of-week fields.
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := has(s.dom, t.Day())
+	dowMatch := has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField parses a single cron field into a bitset.
+func parseField(spec string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(spec, ",") {
+		b, err := parsePart(part, f)
+		if err != nil {
+			return 0, err
+		}
+		bits |= b
+	}
+	return bits, nil
+}
+
+// parsePart parses one comma-separated element of a cron field.
+func parsePart(part string, f field) (uint64, error) {
+	rangeSpec, stepSpec, hasStep := strings.Cut(part, "/")
+
+	step := 1
+	if hasStep {
+		n, err := strconv.Atoi(stepSpec)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%s: invalid step %q", f.name, stepSpec)
+		}
+		step = n
+	}
+
+	lo, hi := f.min, f.max
+	if rangeSpec != "*" {
+		loSpec, hiSpec, isRange := strings.Cut(rangeSpec, "-")
+		var err error
+		if lo, err = parseValue(loSpec, f); err != nil {
+			return 0, err
+		}
+		hi = lo
+		if isRange {
+			if hi, err = parseValue(hiSpec, f); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			// "5/15" means starting at 5, every 15
+			hi = f.max
+		}
+		if lo > hi {
+			return 0, fmt.Errorf("%s: invalid range %q", f.name, rangeSpec)
+		}
+	}
+
+	var bits uint64
+	for v := lo; v <= hi; v += step {
+		bits |= 1 << uint(v)
+	}
+	return bits, nil
+}
+
+// parseValue parses a single numeric value within the field's range.
+func parseValue(spec string, f field) (int, error) {
+	n, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, spec)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s: value %d out of range %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// has reports whether bit v is set.
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
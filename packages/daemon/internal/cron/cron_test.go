@@ -0,0 +1,113 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		expr   string
+		errMsg string
+	}{
+		{"empty", "", "expected 5 fields"},
+		{"too few fields", "* * * *", "expected 5 fields"},
+		{"too many fields", "* * * * * *", "expected 5 fields"},
+		{"minute out of range", "60 * * * *", "out of range"},
+		{"hour out of range", "* 24 * * *", "out of range"},
+		{"day of month zero", "* * 0 * *", "out of range"},
+		{"month out of range", "* * * 13 *", "out of range"},
+		{"day of week out of range", "* * * * 8", "out of range"},
+		{"not a number", "abc * * * *", "invalid value"},
+		{"bad step", "*/0 * * * *", "invalid step"},
+		{"reversed range", "30-10 * * * *", "invalid range"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil {
+				t.Fatal("Parse() should return error")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Error = %q, want to contain %q", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// Wednesday, 2025-01-15 10:30
+	base := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want time.Time
+	}{
+		{"every minute", "* * * * *", time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"every 15 minutes", "*/15 * * * *", time.Date(2025, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"top of hour", "0 * * * *", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"hourly alias", "@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"daily at 3am", "0 3 * * *", time.Date(2025, 1, 16, 3, 0, 0, 0, time.UTC)},
+		{"daily alias", "@daily", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"weekdays at 9", "0 9 * * 1-5", time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"first of month", "0 0 1 * *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"list of minutes", "10,40 * * * *", time.Date(2025, 1, 15, 10, 40, 0, 0, time.UTC)},
+		{"range with step", "0-20/10 11 * * *", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"start with step", "35/10 * * * *", time.Date(2025, 1, 15, 10, 35, 0, 0, time.UTC)},
+		{"specific month", "0 0 1 6 *", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"dom or dow", "0 0 20 * 5", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error: %v", err)
+			}
+			got := s.Next(base)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next_TruncatesSeconds(t *testing.T) {
+	s, err := Parse("* * * * *")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	got := s.Next(time.Date(2025, 1, 15, 10, 30, 45, 0, time.UTC))
+	want := time.Date(2025, 1, 15, 10, 31, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
+
+func TestSchedule_Next_Impossible(t *testing.T) {
+	s, err := Parse("0 0 31 2 *")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if got := s.Next(time.Now()); !got.IsZero() {
+		t.Errorf("Next() = %v, want zero time", got)
+	}
+}
+
+func TestSchedule_String(t *testing.T) {
+	s, err := Parse("@daily")
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if s.String() != "@daily" {
+		t.Errorf("String() = %q, want %q", s.String(), "@daily")
+	}
+}
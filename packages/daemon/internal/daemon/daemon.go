@@ -14,14 +14,18 @@ import (
 
 	"github.com/coven/daemon/internal/agent"
 	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/backoff"
 	"github.com/coven/daemon/internal/beads"
 	"github.com/coven/daemon/internal/config"
 	"github.com/coven/daemon/internal/defaults"
 	"github.com/coven/daemon/internal/git"
+	"github.com/coven/daemon/internal/grimoire"
 	"github.com/coven/daemon/internal/logging"
 	"github.com/coven/daemon/internal/questions"
 	"github.com/coven/daemon/internal/scheduler"
+	"github.com/coven/daemon/internal/spell"
 	"github.com/coven/daemon/internal/state"
+	"github.com/coven/daemon/internal/workflow"
 	"github.com/coven/daemon/pkg/types"
 )
 
@@ -43,9 +47,11 @@ type Daemon struct {
 	processManager   *agent.ProcessManager
 	worktreeManager  *git.WorktreeManager
 	scheduler        *scheduler.Scheduler
+	cronScheduler    *scheduler.CronScheduler
 	questionStore    *questions.Store
 	questionDetector *questions.Detector
 	eventBroker      *api.EventBroker
+	grimoireWatcher  *grimoire.Watcher
 }
 
 // New creates a new daemon for the given workspace.
@@ -81,6 +87,7 @@ func New(workspace, version string) (*Daemon, error) {
 
 	socketPath := filepath.Join(covenDir, "covend.sock")
 	server := api.NewServer(socketPath)
+	server.SetLogger(logger)
 
 	// Initialize components
 	store := state.NewStore(covenDir)
@@ -88,6 +95,13 @@ func New(workspace, version string) (*Daemon, error) {
 	eventBroker := api.NewEventBroker(store)
 	processManager := agent.NewProcessManager(logger)
 	worktreeManager := git.NewWorktreeManager(workspace, logger)
+	if cfg.WorktreeRoot != "" {
+		root := cfg.WorktreeRoot
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(workspace, root)
+		}
+		worktreeManager.SetWorktreesDir(root)
+	}
 	questionStore := questions.NewStore(covenDir)
 	questionDetector := questions.NewDetector()
 	sched := scheduler.NewScheduler(store, beadsClient, processManager, worktreeManager, logger, covenDir)
@@ -100,16 +114,89 @@ func New(workspace, version string) (*Daemon, error) {
 
 	// Apply config settings
 	sched.SetMaxAgents(cfg.MaxConcurrentAgents)
-	if cfg.AgentCommand != "" {
-		args := cfg.AgentArgs
-		if args == nil {
-			args = []string{"-p"} // Default to print mode for claude
+	sched.SetMaxWorkflows(cfg.MaxConcurrentWorkflows)
+	sched.SetPreemption(cfg.PreemptLowerPriority)
+	if cfg.MinFreeDiskMB > 0 {
+		sched.SetMinFreeDisk(uint64(cfg.MinFreeDiskMB) * 1024 * 1024)
+	}
+	sched.SetLogFlushPolicy(workflow.LogFlushPolicy{
+		Interval:   time.Duration(cfg.LogFlushIntervalMs) * time.Millisecond,
+		BufferSize: cfg.LogBufferBytes,
+	})
+	sched.SetLogFormat(workflow.LogFormat(cfg.LogFormat))
+	commandPolicy, err := workflow.NewCommandPolicy(cfg.ScriptPolicy.Deny, cfg.ScriptPolicy.Allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script_policy: %w", err)
+	}
+	sched.SetCommandPolicy(commandPolicy)
+	if cfg.CancelOnBeadClose {
+		beadsPoller.OnBeadClosed(func(taskID, reason string) {
+			sched.CancelForClosedBead(taskID, reason)
+		})
+	}
+	if jitter := backoff.Jitter(cfg.RetryJitter); backoff.IsValidJitter(jitter) {
+		backoff.SetDefaultJitter(jitter)
+	}
+	sched.SetGrimoireOptions(grimoireOptions(cfg))
+	args := cfg.AgentArgs
+	if args == nil {
+		args = []string{"-p"} // Default to print mode for claude
+	}
+	custom := agent.Profile{
+		Name:       "custom",
+		Command:    cfg.AgentCommand,
+		Args:       args,
+		PromptMode: agent.PromptMode(cfg.AgentPromptMode),
+	}
+	profile, err := agent.ResolveProfile(cfg.AgentProfile, custom)
+	if err != nil {
+		logger.Warn("ignoring agent profile, using agent_command", "error", err)
+		profile = custom
+	}
+	if profile.Command != "" {
+		if cfg.AgentProfile != "" {
+			logger.Info("using agent profile", "profile", profile.Name, "command", profile.Command)
 		}
-		sched.SetAgentCommand(cfg.AgentCommand, args)
+		sched.SetAgentProfile(profile)
+	}
+	if cassette := cfg.AgentCassette; cassette.Mode != "" {
+		path := cassette.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(covenDir, path)
+		}
+		switch cassette.Mode {
+		case "record":
+			sched.RecordAgentRuns(path)
+		case "replay":
+			recorded, err := workflow.LoadCassette(path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid agent_cassette: %w", err)
+			}
+			replay := workflow.NewReplayAgentRunner(recorded)
+			replay.OnPromptMismatch(func(task, step, _, _ string) {
+				logger.Warn("replayed agent prompt differs from the recording", "task_id", task, "step", step)
+			})
+			sched.SetAgentRunner(replay)
+		}
+		logger.Info("agent cassette enabled", "mode", cassette.Mode, "path", path)
 	}
 
 	// Wire up event emitter for workflow events
 	sched.SetEventEmitter(eventBroker)
+	eventBroker.SetWorkflowResolver(sched.TaskIDForWorkflow)
+
+	// Report edits to user grimoires and spells as they are made
+	var grimoireWatcher *grimoire.Watcher
+	if cfg.WatchGrimoires {
+		grimoireWatcher = grimoire.NewWatcher(covenDir, eventBroker)
+		grimoireWatcher.SetOptions(grimoireOptions(cfg))
+	}
+
+	// Set up cron-scheduled grimoires
+	cronScheduler, err := scheduler.NewCronScheduler(sched, cfg.Schedules, logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedules config: %w", err)
+	}
 
 	// Wire up event callbacks - this handles both state updates and event emission
 	processManager.OnComplete(func(result *agent.ProcessResult) {
@@ -233,12 +320,23 @@ func New(workspace, version string) (*Daemon, error) {
 		processManager:   processManager,
 		worktreeManager:  worktreeManager,
 		scheduler:        sched,
+		cronScheduler:    cronScheduler,
 		questionStore:    questionStore,
 		questionDetector: questionDetector,
 		eventBroker:      eventBroker,
+		grimoireWatcher:  grimoireWatcher,
 	}, nil
 }
 
+// grimoireOptions returns how cfg asks for grimoires to be validated.
+func grimoireOptions(cfg *config.Config) grimoire.Options {
+	return grimoire.Options{
+		MergePlacement:  grimoire.Severity(cfg.MergeStepValidation),
+		MaxNestingDepth: cfg.MaxNestingDepth,
+		EnvPrefixes:     cfg.GrimoireEnvPrefixes,
+	}
+}
+
 // Run starts the daemon and blocks until shutdown.
 func (d *Daemon) Run(ctx context.Context) error {
 	// Check for stale daemon
@@ -271,9 +369,21 @@ func (d *Daemon) Run(ctx context.Context) error {
 	d.beadsPoller.Start()
 	defer d.beadsPoller.Stop()
 
-	// Start scheduler (handles workflow resumption and reconciliation)
+	// Start scheduler (handles workflow resumption and reconciliation) and
+	// cron scheduler (runs scheduled grimoires). On shutdown, running
+	// workflows, scheduled ones included, get a grace period to finish their
+	// current step and are left resumable either way; the cron scheduler
+	// is stopped after, once its runs have wound down.
 	d.scheduler.Start()
-	defer d.scheduler.Stop()
+	d.cronScheduler.Start()
+	defer d.cronScheduler.Stop()
+	defer d.scheduler.Shutdown(time.Duration(d.config.ShutdownGraceSeconds) * time.Second)
+
+	// Start grimoire watcher (emits grimoire.changed events)
+	if d.grimoireWatcher != nil {
+		d.grimoireWatcher.Start()
+		defer d.grimoireWatcher.Stop()
+	}
 
 	// Handle signals
 	sigCh := make(chan os.Signal, 1)
@@ -382,6 +492,7 @@ func (d *Daemon) registerHandlers() {
 
 	// API handlers (health, version, state, tasks)
 	apiHandlers := api.NewHandlers(d.store, d.version, "", "", d.workspace)
+	apiHandlers.SetConditionsProvider(d.scheduler.Conditions)
 	apiHandlers.Register(d.server)
 
 	// Beads handlers
@@ -403,8 +514,31 @@ func (d *Daemon) registerHandlers() {
 	// Workflow handlers
 	workflowHandlers := scheduler.NewWorkflowHandlers(d.store, d.scheduler, d.covenDir)
 	workflowHandlers.SetEventEmitter(d.eventBroker)
+	workflowHandlers.SetGrimoireOptions(grimoireOptions(d.config))
 	workflowHandlers.Register(d.server)
 
+	// Schedule handlers
+	scheduleHandlers := scheduler.NewScheduleHandlers(d.cronScheduler)
+	scheduleHandlers.Register(d.server)
+
+	// Grimoire mapping handlers
+	mappingHandlers := scheduler.NewMappingHandlers(d.scheduler)
+	mappingHandlers.Register(d.server)
+
+	// Admin handlers
+	adminHandlers := scheduler.NewAdminHandlers(d.scheduler)
+	adminHandlers.Register(d.server)
+
+	// Grimoire and spell install handlers
+	grimoireHandlers := grimoire.NewHandlers(d.covenDir)
+	grimoireHandlers.SetOptions(grimoireOptions(d.config))
+	if d.config.DebugStepRun {
+		grimoireHandlers.SetStepRunHandler(scheduler.NewStepRunHandlers(d.scheduler).HandleRunStep)
+	}
+	grimoireHandlers.Register(d.server)
+	spellHandlers := spell.NewHandlers(d.covenDir)
+	spellHandlers.Register(d.server)
+
 	// SSE event stream
 	d.eventBroker.Register(d.server)
 }
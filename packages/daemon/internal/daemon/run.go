@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/coven/daemon/internal/scheduler"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// Exit codes for `covend run`.
+const (
+	// ExitSuccess means the workflow completed.
+	ExitSuccess = 0
+
+	// ExitFailure means the workflow failed, blocked, found nothing to merge,
+	// was stopped, or couldn't be run at all.
+	ExitFailure = 1
+
+	// ExitNeedsAttention means the workflow is waiting for someone to approve
+	// its merge or confirm its next step.
+	ExitNeedsAttention = 2
+)
+
+// RunTask runs a single task's workflow to completion and returns its result,
+// without the API server, beads poller or reconcile loop. It is used by
+// `covend run` for CI-style runs. It refuses to run while a daemon is active
+// in the workspace, since both would manage the same worktrees.
+//
+// SIGINT and SIGTERM stop the workflow as a daemon shutdown would, leaving it
+// resumable the next time the daemon starts.
+func (d *Daemon) RunTask(ctx context.Context, taskID string) (*scheduler.WorkflowResult, error) {
+	if err := d.checkAndCleanStale(); err != nil {
+		return nil, err
+	}
+
+	// Claim the workspace so a daemon can't start while the task runs
+	if err := d.writePIDFile(); err != nil {
+		return nil, err
+	}
+	defer d.removePIDFile()
+
+	task, err := d.beadsClient.Show(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load task %s: %w", taskID, err)
+	}
+	d.store.SetTasks([]types.Task{*task})
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case sig := <-sigCh:
+			d.logger.Info("received signal, stopping workflow", "signal", sig.String())
+			d.scheduler.Shutdown(time.Duration(d.config.ShutdownGraceSeconds) * time.Second)
+		case <-done:
+		}
+	}()
+
+	d.logger.Info("running single task", "task_id", taskID, "workspace", d.workspace, "version", d.version)
+	return d.scheduler.RunTask(ctx, *task)
+}
+
+// RunExitCode returns the `covend run` exit code for a task's workflow result,
+// or for err if the workflow couldn't be run.
+func RunExitCode(result *scheduler.WorkflowResult, err error) int {
+	if err != nil || result == nil || result.Interrupted {
+		return ExitFailure
+	}
+
+	switch result.Status {
+	case workflow.WorkflowCompleted:
+		if result.Success && !result.NoChanges {
+			return ExitSuccess
+		}
+		return ExitFailure
+	case workflow.WorkflowPendingMerge, workflow.WorkflowAwaitingConfirmation:
+		return ExitNeedsAttention
+	default:
+		return ExitFailure
+	}
+}
+
+// RunSummary describes the outcome of a `covend run` for the terminal.
+func RunSummary(taskID string, result *scheduler.WorkflowResult) string {
+	status := string(result.Status)
+	if status == "" {
+		status = string(workflow.WorkflowFailed)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Task %s: %s", taskID, status)
+	if result.GrimoireName != "" {
+		fmt.Fprintf(&b, " (grimoire %s)", result.GrimoireName)
+	}
+	fmt.Fprintf(&b, " after %d step(s) in %s\n", result.StepCount, result.Duration.Round(time.Millisecond))
+
+	if result.LastStepName != "" {
+		fmt.Fprintf(&b, "Last step: %s\n", result.LastStepName)
+	}
+	if result.Error != "" {
+		fmt.Fprintf(&b, "Error: %s\n", result.Error)
+	}
+
+	switch {
+	case result.Interrupted:
+		b.WriteString("Interrupted; the workflow resumes when the daemon next starts.\n")
+	case result.NoChanges:
+		b.WriteString("No changes to merge.\n")
+	case result.Status == workflow.WorkflowPendingMerge:
+		fmt.Fprintf(&b, "Waiting for merge review; start the daemon and approve it with POST /workflows/%s/approve-merge.\n", taskID)
+	case result.Status == workflow.WorkflowAwaitingConfirmation:
+		fmt.Fprintf(&b, "Waiting for confirmation; start the daemon and confirm it with POST /workflows/%s/confirm.\n", taskID)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,247 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/scheduler"
+	"github.com/coven/daemon/internal/workflow"
+)
+
+// setupRunWorkspace creates a git workspace with a grimoire running command
+// and a mock bd that shows task-1, labeled to use that grimoire.
+func setupRunWorkspace(t *testing.T, command string) *Daemon {
+	t.Helper()
+
+	workspace := shortTempDir(t)
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@test.com"},
+		{"config", "user.name", "Test"},
+		{"commit", "--allow-empty", "-m", "Initial commit"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", workspace}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	grimoireDir := filepath.Join(workspace, ".coven", "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: ci-check
+description: Runs a single check
+steps:
+  - name: check
+    type: script
+    command: "` + command + `"
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "ci-check.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	mockBd := filepath.Join(t.TempDir(), "mock-bd")
+	script := `#!/bin/sh
+case "$1" in
+    show)
+        echo '{"id": "task-1", "title": "Check", "status": "open", "issue_type": "task", "labels": ["grimoire:ci-check"]}'
+        ;;
+    *)
+        exit 0
+        ;;
+esac
+`
+	if err := os.WriteFile(mockBd, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to create mock bd: %v", err)
+	}
+
+	d, err := New(workspace, "1.0.0")
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	d.beadsClient.SetBdPath(mockBd)
+	t.Cleanup(func() { d.scheduler.Shutdown(time.Second) })
+	return d
+}
+
+func TestDaemonRunTask(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		status   workflow.WorkflowStatus
+		exitCode int
+	}{
+		{name: "passing workflow", command: "true", status: workflow.WorkflowCompleted, exitCode: ExitSuccess},
+		{name: "failing workflow", command: "exit 3", status: workflow.WorkflowFailed, exitCode: ExitFailure},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := setupRunWorkspace(t, tt.command)
+
+			result, err := d.RunTask(context.Background(), "task-1")
+			if err != nil {
+				t.Fatalf("RunTask() error: %v", err)
+			}
+			if result.Status != tt.status {
+				t.Errorf("Status = %q, want %q (error: %s)", result.Status, tt.status, result.Error)
+			}
+			if got := RunExitCode(result, err); got != tt.exitCode {
+				t.Errorf("RunExitCode() = %d, want %d", got, tt.exitCode)
+			}
+
+			// The workspace is released for a daemon once the run ends
+			if _, err := os.Stat(d.pidFilePath()); !os.IsNotExist(err) {
+				t.Error("PID file should be removed after the run")
+			}
+		})
+	}
+}
+
+func TestDaemonRunTask_DaemonAlreadyRunning(t *testing.T) {
+	d := setupRunWorkspace(t, "true")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-errCh
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	other, err := New(d.Workspace(), "1.0.0")
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	result, err := other.RunTask(context.Background(), "task-1")
+	if err == nil {
+		t.Fatal("RunTask() should fail while a daemon is running")
+	}
+	if got := RunExitCode(result, err); got != ExitFailure {
+		t.Errorf("RunExitCode() = %d, want %d", got, ExitFailure)
+	}
+}
+
+func TestRunExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *scheduler.WorkflowResult
+		err    error
+		want   int
+	}{
+		{
+			name:   "completed",
+			result: &scheduler.WorkflowResult{Success: true, Status: workflow.WorkflowCompleted},
+			want:   ExitSuccess,
+		},
+		{
+			name:   "completed without changes",
+			result: &scheduler.WorkflowResult{Success: true, Status: workflow.WorkflowCompleted, NoChanges: true},
+			want:   ExitFailure,
+		},
+		{
+			name:   "failed",
+			result: &scheduler.WorkflowResult{Status: workflow.WorkflowFailed, Error: "boom"},
+			want:   ExitFailure,
+		},
+		{
+			name:   "blocked",
+			result: &scheduler.WorkflowResult{Status: workflow.WorkflowBlocked},
+			want:   ExitFailure,
+		},
+		{
+			name:   "cancelled",
+			result: &scheduler.WorkflowResult{Status: workflow.WorkflowCancelled},
+			want:   ExitFailure,
+		},
+		{
+			name:   "interrupted",
+			result: &scheduler.WorkflowResult{Status: workflow.WorkflowRunning, Interrupted: true},
+			want:   ExitFailure,
+		},
+		{
+			name:   "pending merge",
+			result: &scheduler.WorkflowResult{Success: true, Status: workflow.WorkflowPendingMerge},
+			want:   ExitNeedsAttention,
+		},
+		{
+			name:   "awaiting confirmation",
+			result: &scheduler.WorkflowResult{Success: true, Status: workflow.WorkflowAwaitingConfirmation},
+			want:   ExitNeedsAttention,
+		},
+		{
+			name: "error",
+			err:  errors.New("daemon already running"),
+			want: ExitFailure,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RunExitCode(tt.result, tt.err); got != tt.want {
+				t.Errorf("RunExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *scheduler.WorkflowResult
+		want   []string
+	}{
+		{
+			name: "completed",
+			result: &scheduler.WorkflowResult{
+				Success:      true,
+				Status:       workflow.WorkflowCompleted,
+				GrimoireName: "ci-check",
+				StepCount:    2,
+				LastStepName: "check",
+				Duration:     1500 * time.Millisecond,
+			},
+			want: []string{"Task task-1: completed (grimoire ci-check) after 2 step(s) in 1.5s", "Last step: check"},
+		},
+		{
+			name: "failed",
+			result: &scheduler.WorkflowResult{
+				Status:       workflow.WorkflowBlocked,
+				GrimoireName: "ci-check",
+				StepCount:    1,
+				Error:        "step check failed with exit code 3",
+			},
+			want: []string{"Task task-1: blocked", "Error: step check failed with exit code 3"},
+		},
+		{
+			name:   "runner error without status",
+			result: &scheduler.WorkflowResult{Error: "failed to resolve grimoire"},
+			want:   []string{"Task task-1: failed after 0 step(s)", "Error: failed to resolve grimoire"},
+		},
+		{
+			name:   "pending merge",
+			result: &scheduler.WorkflowResult{Success: true, Status: workflow.WorkflowPendingMerge},
+			want:   []string{"POST /workflows/task-1/approve-merge"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RunSummary("task-1", tt.result)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("RunSummary() = %q, want it to contain %q", got, want)
+				}
+			}
+		})
+	}
+}
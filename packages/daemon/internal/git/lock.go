@@ -0,0 +1,114 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coven/daemon/internal/backoff"
+)
+
+// CommandFunc runs git with args in dir and returns its combined output.
+type CommandFunc func(ctx context.Context, dir string, args ...string) ([]byte, error)
+
+// Exec is the CommandFunc that runs the git binary.
+func Exec(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	return cmd.CombinedOutput()
+}
+
+// lockRetryAttempts is how many times a git command that fails on a lock is
+// run before giving up.
+const lockRetryAttempts = 6
+
+// lockRetryPolicy is the backoff between attempts at a git command that
+// failed on a lock.
+var lockRetryPolicy = backoff.Policy{Initial: 50 * time.Millisecond, Max: time.Second}
+
+// LockError reports a git command that kept failing because another git
+// process held a lock in the repository, such as .git/index.lock.
+type LockError struct {
+	Args     []string
+	Attempts int
+	Output   string
+	Err      error
+}
+
+func (e *LockError) Error() string {
+	return fmt.Sprintf("git %s: repository still locked after %d attempts (remove the lock file if no other git process is running): %s",
+		strings.Join(e.Args, " "), e.Attempts, strings.TrimSpace(e.Output))
+}
+
+func (e *LockError) Unwrap() error {
+	return e.Err
+}
+
+// IsLockError returns true if err is a LockError.
+func IsLockError(err error) bool {
+	var lockErr *LockError
+	return errors.As(err, &lockErr)
+}
+
+// isLockFailure reports whether git's output shows it failed to take a lock
+// another git process holds.
+func isLockFailure(output []byte) bool {
+	s := string(output)
+	if strings.Contains(s, "Another git process seems to be running") {
+		return true
+	}
+	return strings.Contains(s, "File exists") && (strings.Contains(s, ".lock") || strings.Contains(s, "could not lock"))
+}
+
+// RunWithLockRetry runs git with run, retrying with backoff while it fails
+// because a lock is held. A command still failing on a lock after the last
+// attempt returns a LockError; other failures are returned at once.
+func RunWithLockRetry(ctx context.Context, run CommandFunc, dir string, args ...string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		output, err := run(ctx, dir, args...)
+		if err == nil || !isLockFailure(output) {
+			return output, err
+		}
+		if attempt+1 >= lockRetryAttempts {
+			return output, &LockError{Args: args, Attempts: attempt + 1, Output: string(output), Err: err}
+		}
+
+		select {
+		case <-ctx.Done():
+			return output, ctx.Err()
+		case <-time.After(lockRetryPolicy.Delay(attempt)):
+		}
+	}
+}
+
+var (
+	repoLocksMu sync.Mutex
+	repoLocks   = make(map[string]*sync.Mutex)
+)
+
+// LockRepo serializes the daemon's changes to the repository at path, such
+// as adding worktrees and merging, which would otherwise contend for its
+// locks. It blocks until the repository is free and returns the function
+// that frees it.
+func LockRepo(path string) func() {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = filepath.Clean(path)
+	}
+
+	repoLocksMu.Lock()
+	mu, ok := repoLocks[key]
+	if !ok {
+		mu = &sync.Mutex{}
+		repoLocks[key] = mu
+	}
+	repoLocksMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
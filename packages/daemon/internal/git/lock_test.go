@@ -0,0 +1,164 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/backoff"
+)
+
+const indexLockOutput = `fatal: Unable to create '/repo/.git/index.lock': File exists.
+
+Another git process seems to be running in this repository, e.g.
+an editor opened by 'git commit'. Please make sure all processes
+are terminated then try again.`
+
+// fastLockRetry makes lock retries immediate for the test.
+func fastLockRetry(t *testing.T) {
+	t.Helper()
+	saved := lockRetryPolicy
+	lockRetryPolicy = backoff.Policy{Initial: time.Millisecond, Max: time.Millisecond, Jitter: backoff.JitterNone}
+	t.Cleanup(func() { lockRetryPolicy = saved })
+}
+
+// lockedGit returns a CommandFunc that fails on a held lock the first
+// failures times it runs a command matching args, then runs git.
+func lockedGit(failures int, args string) (CommandFunc, *int) {
+	calls := 0
+	return func(ctx context.Context, dir string, gitArgs ...string) ([]byte, error) {
+		if strings.Join(gitArgs, " ") == args {
+			calls++
+			if calls <= failures {
+				return []byte(indexLockOutput), errors.New("exit status 128")
+			}
+		}
+		return Exec(ctx, dir, gitArgs...)
+	}, &calls
+}
+
+func TestRunWithLockRetry(t *testing.T) {
+	fastLockRetry(t)
+
+	t.Run("retries until the lock is free", func(t *testing.T) {
+		calls := 0
+		run := func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+			calls++
+			if calls < 3 {
+				return []byte(indexLockOutput), errors.New("exit status 128")
+			}
+			return []byte("ok"), nil
+		}
+
+		output, err := RunWithLockRetry(context.Background(), run, "/repo", "add", "-A")
+		if err != nil {
+			t.Fatalf("RunWithLockRetry() error: %v", err)
+		}
+		if string(output) != "ok" || calls != 3 {
+			t.Errorf("output = %q after %d calls, want %q after 3", output, calls, "ok")
+		}
+	})
+
+	t.Run("reports a persistent lock", func(t *testing.T) {
+		calls := 0
+		run := func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+			calls++
+			return []byte(indexLockOutput), errors.New("exit status 128")
+		}
+
+		_, err := RunWithLockRetry(context.Background(), run, "/repo", "add", "-A")
+		if !IsLockError(err) {
+			t.Fatalf("RunWithLockRetry() error = %v, want a LockError", err)
+		}
+		if calls != lockRetryAttempts {
+			t.Errorf("calls = %d, want %d", calls, lockRetryAttempts)
+		}
+		if msg := err.Error(); !strings.Contains(msg, "git add -A") || !strings.Contains(msg, "index.lock") {
+			t.Errorf("error = %q, want the command and the lock file", msg)
+		}
+	})
+
+	t.Run("does not retry other failures", func(t *testing.T) {
+		calls := 0
+		run := func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+			calls++
+			return []byte("fatal: not a git repository"), errors.New("exit status 128")
+		}
+
+		_, err := RunWithLockRetry(context.Background(), run, "/repo", "status")
+		if err == nil || IsLockError(err) || calls != 1 {
+			t.Errorf("error = %v after %d calls, want the failure after 1", err, calls)
+		}
+	})
+}
+
+func TestIsLockFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		want   bool
+	}{
+		{indexLockOutput, true},
+		{"error: cannot lock ref 'refs/heads/coven/task-1': Unable to create '/repo/.git/refs/heads/coven/task-1.lock': File exists.", true},
+		{"error: could not lock config file .git/config: File exists", true},
+		{"fatal: a branch named 'coven/task-1' already exists", false},
+		{"fatal: 'main' is already checked out", false},
+	}
+	for _, tt := range tests {
+		if got := isLockFailure([]byte(tt.output)); got != tt.want {
+			t.Errorf("isLockFailure(%q) = %v, want %v", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestLockRepo(t *testing.T) {
+	dir := t.TempDir()
+	unlock := LockRepo(dir)
+
+	acquired := make(chan struct{})
+	go func() {
+		// The same repository by another path waits for the first lock
+		release := LockRepo(filepath.Join(dir, "sub", ".."))
+		close(acquired)
+		release()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second LockRepo() acquired the lock while it was held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second LockRepo() not acquired after unlock")
+	}
+}
+
+func TestWorktreeRetriesLockContention(t *testing.T) {
+	fastLockRetry(t)
+	repoPath := initTestRepo(t)
+	manager := newTestManager(t, repoPath)
+
+	info, err := manager.Create(context.Background(), "task-locked")
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+
+	run, calls := lockedGit(2, "worktree remove --force "+info.Path)
+	manager.git = run
+	if err := manager.Remove(context.Background(), "task-locked"); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if *calls != 3 {
+		t.Errorf("worktree remove ran %d times, want 3", *calls)
+	}
+	if _, err := os.Stat(info.Path); !os.IsNotExist(err) {
+		t.Errorf("worktree %s still exists", info.Path)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/coven/daemon/internal/logging"
@@ -17,6 +18,7 @@ type WorktreeManager struct {
 	repoPath     string
 	worktreesDir string
 	logger       *logging.Logger
+	git          CommandFunc
 }
 
 // WorktreeInfo contains information about a worktree.
@@ -32,12 +34,30 @@ func NewWorktreeManager(repoPath string, logger *logging.Logger) *WorktreeManage
 		repoPath:     repoPath,
 		worktreesDir: filepath.Join(repoPath, ".coven", "worktrees"),
 		logger:       logger,
+		git:          Exec,
 	}
 }
 
+// SetWorktreesDir sets the directory worktrees are created in, instead of
+// .coven/worktrees in the repository.
+func (m *WorktreeManager) SetWorktreesDir(dir string) {
+	m.worktreesDir = dir
+}
+
 // Create creates a new worktree for a task, or returns the existing one if already created.
 // This is idempotent - calling it multiple times for the same task is safe.
 func (m *WorktreeManager) Create(ctx context.Context, taskID string) (*WorktreeInfo, error) {
+	return m.CreateSparse(ctx, taskID, nil)
+}
+
+// CreateSparse is like Create, but a new worktree only materializes the given
+// directories, using a cone-mode sparse checkout. Files at the top level of the
+// repository are always checked out. If paths is empty the worktree is checked
+// out in full. An existing worktree is returned as is.
+//
+// The branch still tracks the whole tree, so commits made in a sparse worktree
+// merge into the base branch like any other.
+func (m *WorktreeManager) CreateSparse(ctx context.Context, taskID string, paths []string) (*WorktreeInfo, error) {
 	// Ensure worktrees directory exists
 	if err := os.MkdirAll(m.worktreesDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create worktrees dir: %w", err)
@@ -77,21 +97,32 @@ func (m *WorktreeManager) Create(ctx context.Context, taskID string) (*WorktreeI
 	// Check if branch already exists (from a previous partial creation)
 	branchExists := m.branchExists(ctx, branchName)
 
-	// Create the worktree
-	var args []string
+	// Create the worktree. A sparse worktree is checked out once its
+	// sparse-checkout patterns are set, so the full tree is never written.
+	args := []string{"worktree", "add"}
+	if len(paths) > 0 {
+		args = append(args, "--no-checkout")
+	}
 	if branchExists {
 		// Branch exists, attach worktree to existing branch
-		args = []string{"worktree", "add", worktreePath, branchName}
+		args = append(args, worktreePath, branchName)
 	} else {
 		// Create new branch
-		args = []string{"worktree", "add", "-b", branchName, worktreePath, baseBranch}
+		args = append(args, "-b", branchName, worktreePath, baseBranch)
 	}
 
 	if err := m.runGit(ctx, args...); err != nil {
 		return nil, fmt.Errorf("failed to create worktree: %w", err)
 	}
 
-	m.logger.Info("created worktree", "task_id", taskID, "path", worktreePath, "branch", branchName)
+	if len(paths) > 0 {
+		if err := m.checkoutSparse(ctx, worktreePath, paths); err != nil {
+			_ = m.runGit(ctx, "worktree", "remove", "--force", worktreePath)
+			return nil, fmt.Errorf("failed to check out sparse worktree: %w", err)
+		}
+	}
+
+	m.logger.Info("created worktree", "task_id", taskID, "path", worktreePath, "branch", branchName, "sparse_paths", paths)
 
 	return &WorktreeInfo{
 		Path:   worktreePath,
@@ -100,6 +131,16 @@ func (m *WorktreeManager) Create(ctx context.Context, taskID string) (*WorktreeI
 	}, nil
 }
 
+// checkoutSparse limits a worktree created with --no-checkout to paths and
+// then checks it out.
+func (m *WorktreeManager) checkoutSparse(ctx context.Context, worktreePath string, paths []string) error {
+	args := append([]string{"-C", worktreePath, "sparse-checkout", "set", "--cone", "--"}, paths...)
+	if err := m.runGit(ctx, args...); err != nil {
+		return err
+	}
+	return m.runGit(ctx, "-C", worktreePath, "checkout")
+}
+
 // branchExists checks if a branch exists in the repository.
 func (m *WorktreeManager) branchExists(ctx context.Context, branchName string) bool {
 	cmd := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", "refs/heads/"+branchName)
@@ -149,6 +190,21 @@ func (m *WorktreeManager) Get(taskID string) (*WorktreeInfo, error) {
 	}, nil
 }
 
+// Repair relinks a task's worktree with the repository after either of them
+// was moved, using git worktree repair, and returns the worktree.
+func (m *WorktreeManager) Repair(ctx context.Context, taskID string) (*WorktreeInfo, error) {
+	info, err := m.Get(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.runGit(ctx, "worktree", "repair", info.Path); err != nil {
+		return nil, fmt.Errorf("failed to repair worktree: %w", err)
+	}
+
+	m.logger.Info("repaired worktree", "task_id", taskID, "path", info.Path)
+	return info, nil
+}
+
 // List returns all coven-managed worktrees.
 func (m *WorktreeManager) List() ([]WorktreeInfo, error) {
 	if _, err := os.Stat(m.worktreesDir); os.IsNotExist(err) {
@@ -238,6 +294,41 @@ func (m *WorktreeManager) GetBaseBranch(ctx context.Context) (string, error) {
 	return m.getCurrentBranch(ctx)
 }
 
+// Divergence counts how far a branch and the base branch have moved apart
+// since the branch was created.
+type Divergence struct {
+	// Ahead is the number of commits on the branch that aren't on the base.
+	Ahead int
+
+	// Behind is the number of commits on the base that aren't on the branch.
+	Behind int
+}
+
+// Divergence compares branch with baseBranch, counting the commits each has
+// that the other doesn't.
+func (m *WorktreeManager) Divergence(ctx context.Context, branch, baseBranch string) (*Divergence, error) {
+	ahead, err := m.countCommits(ctx, baseBranch+".."+branch)
+	if err != nil {
+		return nil, err
+	}
+	behind, err := m.countCommits(ctx, branch+".."+baseBranch)
+	if err != nil {
+		return nil, err
+	}
+	return &Divergence{Ahead: ahead, Behind: behind}, nil
+}
+
+// countCommits returns the number of commits in a revision range.
+func (m *WorktreeManager) countCommits(ctx context.Context, revRange string) (int, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "--count", revRange)
+	cmd.Dir = m.repoPath
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits in %s: %s: %w", revRange, strings.TrimSpace(string(output)), err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
 // GetPath returns the path for a task's worktree.
 func (m *WorktreeManager) GetPath(taskID string) string {
 	return filepath.Join(m.worktreesDir, taskID)
@@ -259,12 +350,18 @@ func (m *WorktreeManager) getCurrentBranch(ctx context.Context) (string, error)
 	return strings.TrimSpace(string(output)), nil
 }
 
-// runGit runs a git command in the repo.
+// runGit runs a git command that changes the repo. Commands are run one at a
+// time per repo, and retried while another git process holds a lock.
 func (m *WorktreeManager) runGit(ctx context.Context, args ...string) error {
-	cmd := exec.CommandContext(ctx, "git", args...)
-	cmd.Dir = m.repoPath
-	output, err := cmd.CombinedOutput()
+	unlock := LockRepo(m.repoPath)
+	defer unlock()
+
+	output, err := RunWithLockRetry(ctx, m.git, m.repoPath, args...)
 	if err != nil {
+		if IsLockError(err) {
+			m.logger.Warn("git lock contention persisted", "args", args, "error", err)
+			return err
+		}
 		return fmt.Errorf("%s: %s", err, string(output))
 	}
 	return nil
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/coven/daemon/internal/logging"
@@ -102,6 +103,59 @@ func TestWorktreeCreate(t *testing.T) {
 	}
 }
 
+func TestWorktreeCreateSparse(t *testing.T) {
+	repoPath := initTestRepo(t)
+	manager := newTestManager(t, repoPath)
+	ctx := context.Background()
+
+	for _, file := range []string{"packages/api/main.go", "packages/web/index.js", "docs/guide.md"} {
+		path := filepath.Join(repoPath, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+	exec.Command("git", "-C", repoPath, "add", ".").Run()
+	if err := exec.Command("git", "-C", repoPath, "commit", "-m", "Add packages").Run(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	info, err := manager.CreateSparse(ctx, "task-1", []string{"packages/api"})
+	if err != nil {
+		t.Fatalf("CreateSparse() error: %v", err)
+	}
+
+	for _, file := range []string{"README.md", "packages/api/main.go"} {
+		if _, err := os.Stat(filepath.Join(info.Path, file)); err != nil {
+			t.Errorf("%s should be checked out: %v", file, err)
+		}
+	}
+	for _, file := range []string{"packages/web", "docs"} {
+		if _, err := os.Stat(filepath.Join(info.Path, file)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be checked out", file)
+		}
+	}
+
+	// A commit in the sparse worktree only changes what it touched
+	if err := os.WriteFile(filepath.Join(info.Path, "packages/api/main.go"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	exec.Command("git", "-C", info.Path, "add", ".").Run()
+	if err := exec.Command("git", "-C", info.Path, "commit", "-m", "Change api").Run(); err != nil {
+		t.Fatalf("Failed to commit in worktree: %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", repoPath, "diff", "--name-only", "HEAD", info.Branch).Output()
+	if err != nil {
+		t.Fatalf("git diff error: %v", err)
+	}
+	if got := strings.TrimSpace(string(out)); got != "packages/api/main.go" {
+		t.Errorf("branch changes = %q, want only packages/api/main.go", got)
+	}
+}
+
 func TestWorktreeRemove(t *testing.T) {
 	repoPath := initTestRepo(t)
 	manager := newTestManager(t, repoPath)
@@ -157,6 +211,54 @@ func TestWorktreeGet(t *testing.T) {
 	}
 }
 
+func TestWorktreeDivergence(t *testing.T) {
+	repoPath := initTestRepo(t)
+	manager := newTestManager(t, repoPath)
+	ctx := context.Background()
+
+	info, err := manager.Create(ctx, "task-1")
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	baseBranch, err := manager.GetBaseBranch(ctx)
+	if err != nil {
+		t.Fatalf("GetBaseBranch() error: %v", err)
+	}
+
+	commit := func(dir, message string) {
+		t.Helper()
+		if out, err := exec.Command("git", "-C", dir, "commit", "--allow-empty", "-m", message).CombinedOutput(); err != nil {
+			t.Fatalf("git commit failed: %v: %s", err, out)
+		}
+	}
+
+	divergence, err := manager.Divergence(ctx, info.Branch, baseBranch)
+	if err != nil {
+		t.Fatalf("Divergence() error: %v", err)
+	}
+	if *divergence != (Divergence{}) {
+		t.Errorf("Divergence() of a new worktree = %+v, want none", *divergence)
+	}
+
+	// The base advances while the worktree's branch gets one commit
+	commit(info.Path, "Work on task")
+	commit(repoPath, "Base change 1")
+	commit(repoPath, "Base change 2")
+	commit(repoPath, "Base change 3")
+
+	divergence, err = manager.Divergence(ctx, info.Branch, baseBranch)
+	if err != nil {
+		t.Fatalf("Divergence() error: %v", err)
+	}
+	if divergence.Ahead != 1 || divergence.Behind != 3 {
+		t.Errorf("Divergence() = %+v, want 1 ahead and 3 behind", *divergence)
+	}
+
+	if _, err := manager.Divergence(ctx, "no-such-branch", baseBranch); err == nil {
+		t.Error("Divergence() should fail for a missing branch")
+	}
+}
+
 func TestWorktreeList(t *testing.T) {
 	repoPath := initTestRepo(t)
 	manager := newTestManager(t, repoPath)
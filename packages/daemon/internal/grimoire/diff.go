@@ -0,0 +1,244 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Diff compares a user grimoire with the built-in grimoire of the same name
+// that it overrides.
+type Diff struct {
+	// Name is the grimoire's name.
+	Name string `json:"name"`
+
+	// Identical is true if the two versions have no differences.
+	Identical bool `json:"identical"`
+
+	// Fields are the grimoire-level fields that differ, other than its steps.
+	Fields []FieldChange `json:"fields,omitempty"`
+
+	// Prepare are the prepare steps that differ.
+	Prepare []StepChange `json:"prepare,omitempty"`
+
+	// Steps are the steps that differ.
+	Steps []StepChange `json:"steps,omitempty"`
+}
+
+// FieldChange is a field with a different value in the user grimoire than in
+// the built-in one. A value is nil where the field isn't set.
+type FieldChange struct {
+	Field   string `json:"field"`
+	Builtin any    `json:"builtin"`
+	User    any    `json:"user"`
+}
+
+// Step change kinds.
+const (
+	StepAdded   = "added"
+	StepRemoved = "removed"
+	StepChanged = "changed"
+	StepMoved   = "moved"
+)
+
+// StepChange is a difference in one step, matched by name.
+type StepChange struct {
+	// Step is the step's name.
+	Step string `json:"step"`
+
+	// Change is added, removed, changed or moved.
+	Change string `json:"change"`
+
+	// BuiltinIndex and UserIndex are the step's position in each version, or
+	// -1 where it doesn't exist.
+	BuiltinIndex int `json:"builtin_index"`
+	UserIndex    int `json:"user_index"`
+
+	// Fields are the step's fields that differ, for changed steps.
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// NotOverriddenError is returned when a grimoire can't be diffed because it
+// doesn't exist both as a user grimoire and a built-in one.
+type NotOverriddenError struct {
+	Name   string
+	Source GrimoireSource
+}
+
+func (e *NotOverriddenError) Error() string {
+	if e.Source == SourceBuiltIn {
+		return fmt.Sprintf("grimoire %q is built in and not overridden by a user grimoire", e.Name)
+	}
+	return fmt.Sprintf("grimoire %q is a user grimoire with no built-in version", e.Name)
+}
+
+// IsNotOverridden returns true if the error is a NotOverriddenError.
+func IsNotOverridden(err error) bool {
+	var notOverridden *NotOverriddenError
+	return errors.As(err, &notOverridden)
+}
+
+// Diff loads the user and built-in versions of a grimoire, ignoring the usual
+// precedence of user grimoires, and returns their differences. It returns a
+// NotOverriddenError if only one version exists.
+func (l *Loader) Diff(name string) (*Diff, error) {
+	if err := validateGrimoireName(name); err != nil {
+		return nil, err
+	}
+
+	user, err := l.loadUserGrimoire(name)
+	if err != nil && !isNotExistError(err) {
+		return nil, fmt.Errorf("failed to load user grimoire %q: %w", name, err)
+	}
+	builtin, builtinErr := l.loadBuiltinGrimoire(name)
+	if builtinErr != nil && !isNotExistError(builtinErr) {
+		return nil, fmt.Errorf("failed to load builtin grimoire %q: %w", name, builtinErr)
+	}
+
+	switch {
+	case user == nil && builtin == nil:
+		return nil, &GrimoireNotFoundError{Name: name}
+	case user == nil:
+		return nil, &NotOverriddenError{Name: name, Source: SourceBuiltIn}
+	case builtin == nil:
+		return nil, &NotOverriddenError{Name: name, Source: SourceUser}
+	}
+
+	return DiffGrimoires(builtin, user)
+}
+
+// DiffGrimoires returns the differences between a built-in grimoire and a user
+// grimoire that overrides it. Fields are named by their YAML keys.
+func DiffGrimoires(builtin, user *Grimoire) (*Diff, error) {
+	builtinFields, err := yamlFields(builtin)
+	if err != nil {
+		return nil, err
+	}
+	userFields, err := yamlFields(user)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range []string{"prepare", "steps"} {
+		delete(builtinFields, key)
+		delete(userFields, key)
+	}
+
+	d := &Diff{Name: user.Name, Fields: diffFields(builtinFields, userFields)}
+	if d.Prepare, err = diffSteps(builtin.Prepare, user.Prepare); err != nil {
+		return nil, err
+	}
+	if d.Steps, err = diffSteps(builtin.Steps, user.Steps); err != nil {
+		return nil, err
+	}
+	d.Identical = len(d.Fields) == 0 && len(d.Prepare) == 0 && len(d.Steps) == 0
+	return d, nil
+}
+
+// diffSteps matches steps by name and returns the ones that were added,
+// removed, changed or moved. Renamed steps show as removed and added.
+func diffSteps(builtin, user []Step) ([]StepChange, error) {
+	userIndex := make(map[string]int, len(user))
+	for i := range user {
+		userIndex[user[i].Name] = i
+	}
+	builtinIndex := make(map[string]int, len(builtin))
+	for i := range builtin {
+		builtinIndex[builtin[i].Name] = i
+	}
+
+	// A step moved if the steps both versions share are in a different
+	// order, not just shifted by steps added or removed around it
+	var builtinShared, userShared []string
+	for i := range builtin {
+		if _, ok := userIndex[builtin[i].Name]; ok {
+			builtinShared = append(builtinShared, builtin[i].Name)
+		}
+	}
+	for i := range user {
+		if _, ok := builtinIndex[user[i].Name]; ok {
+			userShared = append(userShared, user[i].Name)
+		}
+	}
+	sharedPos := make(map[string]int, len(builtinShared))
+	for i, name := range builtinShared {
+		sharedPos[name] = i
+	}
+
+	var changes []StepChange
+	for i := range builtin {
+		if _, ok := userIndex[builtin[i].Name]; !ok {
+			changes = append(changes, StepChange{Step: builtin[i].Name, Change: StepRemoved, BuiltinIndex: i, UserIndex: -1})
+		}
+	}
+	for i := range user {
+		name := user[i].Name
+		bi, ok := builtinIndex[name]
+		if !ok {
+			changes = append(changes, StepChange{Step: name, Change: StepAdded, BuiltinIndex: -1, UserIndex: i})
+			continue
+		}
+
+		builtinFields, err := yamlFields(builtin[bi])
+		if err != nil {
+			return nil, err
+		}
+		userFields, err := yamlFields(user[i])
+		if err != nil {
+			return nil, err
+		}
+
+		change := StepChange{Step: name, BuiltinIndex: bi, UserIndex: i, Fields: diffFields(builtinFields, userFields)}
+		switch {
+		case len(change.Fields) > 0:
+			change.Change = StepChanged
+		case userShared[sharedPos[name]] != name:
+			change.Change = StepMoved
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// diffFields returns the keys whose values differ between two field maps,
+// sorted by key.
+func diffFields(builtin, user map[string]any) []FieldChange {
+	keys := make(map[string]bool, len(builtin)+len(user))
+	for k := range builtin {
+		keys[k] = true
+	}
+	for k := range user {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var changes []FieldChange
+	for _, k := range sorted {
+		if !reflect.DeepEqual(builtin[k], user[k]) {
+			changes = append(changes, FieldChange{Field: k, Builtin: builtin[k], User: user[k]})
+		}
+	}
+	return changes
+}
+
+// yamlFields returns the fields v sets, keyed by their YAML names.
+func yamlFields(v any) (map[string]any, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode grimoire: %w", err)
+	}
+	fields := make(map[string]any)
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode grimoire: %w", err)
+	}
+	return fields, nil
+}
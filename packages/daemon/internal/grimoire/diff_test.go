@@ -0,0 +1,149 @@
+package grimoire
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+const diffBuiltinYAML = `name: ship
+description: Build and ship
+timeout: 1h
+steps:
+  - name: build
+    type: script
+    command: make build
+  - name: test
+    type: script
+    command: make test
+    timeout: 10m
+  - name: lint
+    type: script
+    command: make lint
+`
+
+func newDiffTestLoader(t *testing.T, userYAML string) *Loader {
+	t.Helper()
+	covenDir := t.TempDir()
+	if userYAML != "" {
+		grimoiresDir := filepath.Join(covenDir, "grimoires")
+		if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+			t.Fatalf("Failed to create grimoires dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(grimoiresDir, "ship.yaml"), []byte(userYAML), 0644); err != nil {
+			t.Fatalf("Failed to write user grimoire: %v", err)
+		}
+	}
+	builtinFS := fstest.MapFS{
+		"grimoires/ship.yaml": &fstest.MapFile{Data: []byte(diffBuiltinYAML)},
+	}
+	return NewLoaderWithBuiltins(covenDir, builtinFS, "grimoires")
+}
+
+func TestLoaderDiff(t *testing.T) {
+	userYAML := `name: ship
+description: Build and ship
+timeout: 2h
+steps:
+  - name: build
+    type: script
+    command: make build
+  - name: test
+    type: script
+    command: make test-all
+    timeout: 10m
+  - name: package
+    type: script
+    command: make package
+`
+	loader := newDiffTestLoader(t, userYAML)
+
+	diff, err := loader.Diff("ship")
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	if diff.Identical {
+		t.Error("Identical = true, want false")
+	}
+
+	if len(diff.Fields) != 1 {
+		t.Fatalf("Fields = %+v, want only timeout", diff.Fields)
+	}
+	if f := diff.Fields[0]; f.Field != "timeout" || f.Builtin != "1h" || f.User != "2h" {
+		t.Errorf("Fields[0] = %+v, want timeout 1h -> 2h", f)
+	}
+
+	want := map[string]string{"test": StepChanged, "lint": StepRemoved, "package": StepAdded}
+	if len(diff.Steps) != len(want) {
+		t.Fatalf("Steps = %+v, want %d changes", diff.Steps, len(want))
+	}
+	for _, change := range diff.Steps {
+		if want[change.Step] != change.Change {
+			t.Errorf("step %q change = %q, want %q", change.Step, change.Change, want[change.Step])
+		}
+		if change.Step == "test" {
+			if len(change.Fields) != 1 || change.Fields[0].Field != "command" || change.Fields[0].User != "make test-all" {
+				t.Errorf("test step fields = %+v, want command changed to make test-all", change.Fields)
+			}
+		}
+		if change.Step == "lint" && (change.BuiltinIndex != 2 || change.UserIndex != -1) {
+			t.Errorf("lint indexes = (%d, %d), want (2, -1)", change.BuiltinIndex, change.UserIndex)
+		}
+	}
+}
+
+func TestLoaderDiff_Identical(t *testing.T) {
+	loader := newDiffTestLoader(t, diffBuiltinYAML)
+
+	diff, err := loader.Diff("ship")
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	if !diff.Identical || len(diff.Fields) != 0 || len(diff.Steps) != 0 {
+		t.Errorf("diff = %+v, want identical", diff)
+	}
+}
+
+func TestLoaderDiff_MovedStep(t *testing.T) {
+	userYAML := `name: ship
+description: Build and ship
+timeout: 1h
+steps:
+  - name: build
+    type: script
+    command: make build
+  - name: lint
+    type: script
+    command: make lint
+  - name: test
+    type: script
+    command: make test
+    timeout: 10m
+`
+	loader := newDiffTestLoader(t, userYAML)
+
+	diff, err := loader.Diff("ship")
+	if err != nil {
+		t.Fatalf("Diff() error: %v", err)
+	}
+	for _, change := range diff.Steps {
+		if change.Change != StepMoved {
+			t.Errorf("step %q change = %q, want %q", change.Step, change.Change, StepMoved)
+		}
+	}
+	if len(diff.Steps) != 2 {
+		t.Errorf("Steps = %+v, want test and lint moved", diff.Steps)
+	}
+}
+
+func TestLoaderDiff_NotOverridden(t *testing.T) {
+	loader := newDiffTestLoader(t, "")
+
+	if _, err := loader.Diff("ship"); !IsNotOverridden(err) {
+		t.Errorf("Diff() error = %v, want NotOverriddenError", err)
+	}
+	if _, err := loader.Diff("missing"); !IsNotFound(err) {
+		t.Errorf("Diff() error = %v, want GrimoireNotFoundError", err)
+	}
+}
@@ -0,0 +1,172 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvPrefixes are the environment variable prefixes grimoires may
+// interpolate unless configured otherwise.
+var DefaultEnvPrefixes = []string{"COVEN_"}
+
+// maxInterpolationDepth bounds how deeply variables may refer to other
+// variables, through their values or defaults.
+const maxInterpolationDepth = 10
+
+// InterpolationError is returned when a grimoire refers to an environment
+// variable that can't be resolved.
+type InterpolationError struct {
+	Variable string
+	Message  string
+}
+
+func (e *InterpolationError) Error() string {
+	return fmt.Sprintf("grimoire interpolation failed: ${%s}: %s", e.Variable, e.Message)
+}
+
+// IsInterpolationError returns true if the error is an InterpolationError.
+func IsInterpolationError(err error) bool {
+	var interpErr *InterpolationError
+	return errors.As(err, &interpErr)
+}
+
+// interpolateNode replaces ${VAR} and ${VAR:-default} references to
+// environment variables with one of prefixes in every scalar value under
+// node. Mapping keys are left alone. Plain scalars are re-resolved after
+// substitution, so "max_iterations: ${COVEN_MAX:-3}" decodes as an integer.
+func interpolateNode(node *yaml.Node, prefixes []string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := interpolate(node.Value, prefixes, nil)
+		if err != nil {
+			return err
+		}
+		if value != node.Value {
+			node.Value = value
+			if node.Style == 0 {
+				node.Tag = ""
+			}
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := interpolateNode(node.Content[i], prefixes); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			if err := interpolateNode(child, prefixes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// interpolate replaces references to environment variables with one of
+// prefixes in s. Variable values and defaults are interpolated too; expanding names the
+// variables being resolved, so a variable that refers to itself is an error
+// rather than endless recursion. "$${COVEN_X}" is an escaped, literal
+// "${COVEN_X}"; references to other variables are kept as they are.
+func interpolate(s string, prefixes, expanding []string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		end := closingBrace(s, start+2)
+		if end < 0 {
+			out.WriteString(s)
+			return out.String(), nil
+		}
+		name, def, hasDefault := strings.Cut(s[start+2:end], ":-")
+		if !isAllowedEnvVar(name, prefixes) {
+			out.WriteString(s[:end+1])
+			s = s[end+1:]
+			continue
+		}
+		if start > 0 && s[start-1] == '$' {
+			out.WriteString(s[:start-1])
+			out.WriteString(s[start : end+1])
+			s = s[end+1:]
+			continue
+		}
+
+		value, err := resolveEnvVar(name, def, hasDefault, prefixes, expanding)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(s[:start])
+		out.WriteString(value)
+		s = s[end+1:]
+	}
+}
+
+// resolveEnvVar returns the interpolated value of an environment variable,
+// or of its default when it is unset or empty.
+func resolveEnvVar(name, def string, hasDefault bool, prefixes, expanding []string) (string, error) {
+	for _, v := range expanding {
+		if v == name {
+			return "", &InterpolationError{Variable: name, Message: fmt.Sprintf("refers to itself (%s)", strings.Join(append(expanding, name), " -> "))}
+		}
+	}
+	if len(expanding) >= maxInterpolationDepth {
+		return "", &InterpolationError{Variable: name, Message: fmt.Sprintf("variables nested more than %d levels deep", maxInterpolationDepth)}
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok && !hasDefault {
+		return "", &InterpolationError{Variable: name, Message: "environment variable is required but not set"}
+	}
+	if value == "" && hasDefault {
+		value = def
+	}
+	return interpolate(value, prefixes, append(expanding[:len(expanding):len(expanding)], name))
+}
+
+// closingBrace returns the index of the brace closing a reference whose
+// contents start at from, allowing references nested in a default, or -1.
+func closingBrace(s string, from int) int {
+	depth := 0
+	for i := from; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// isAllowedEnvVar reports whether name is a variable grimoires may
+// interpolate: a valid variable name with one of prefixes.
+func isAllowedEnvVar(name string, prefixes []string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
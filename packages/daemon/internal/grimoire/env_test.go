@@ -0,0 +1,134 @@
+package grimoire
+
+import (
+	"strings"
+	"testing"
+)
+
+const envGrimoire = `name: env-test
+description: Uses host environment values
+steps:
+  - name: push
+    type: script
+    command: "docker push ${COVEN_REGISTRY}/app && echo ${HOME}"
+    timeout: ${COVEN_PUSH_TIMEOUT:-5m}
+  - name: review
+    type: loop
+    max_iterations: ${COVEN_MAX_REVIEWS:-3}
+    steps:
+      - name: check
+        type: script
+        command: "echo $${COVEN_REGISTRY}"
+`
+
+func TestParse_InterpolatesEnvVars(t *testing.T) {
+	t.Setenv("COVEN_REGISTRY", "registry.example.com")
+
+	g, err := Parse([]byte(envGrimoire))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if got, want := g.Steps[0].Command, "docker push registry.example.com/app && echo ${HOME}"; got != want {
+		t.Errorf("Command = %q, want %q", got, want)
+	}
+	if g.Steps[0].Timeout != "5m" {
+		t.Errorf("Timeout = %q, want the default 5m", g.Steps[0].Timeout)
+	}
+	if g.Steps[1].MaxIterations != 3 {
+		t.Errorf("MaxIterations = %d, want the default 3", g.Steps[1].MaxIterations)
+	}
+	if got, want := g.Steps[1].Steps[0].Command, "echo ${COVEN_REGISTRY}"; got != want {
+		t.Errorf("escaped Command = %q, want %q", got, want)
+	}
+
+	t.Setenv("COVEN_MAX_REVIEWS", "5")
+	g, err = Parse([]byte(envGrimoire))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if g.Steps[1].MaxIterations != 5 {
+		t.Errorf("MaxIterations = %d, want 5 from the environment", g.Steps[1].MaxIterations)
+	}
+}
+
+func TestParse_RequiredEnvVarUnset(t *testing.T) {
+	_, err := Parse([]byte(envGrimoire))
+	if !IsInterpolationError(err) {
+		t.Fatalf("Parse() error = %v, want an InterpolationError", err)
+	}
+	if !strings.Contains(err.Error(), "${COVEN_REGISTRY}") || !strings.Contains(err.Error(), "required but not set") {
+		t.Errorf("Error = %q, want it to name the missing variable", err)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("COVEN_HOST", "example.com")
+	t.Setenv("COVEN_URL", "https://${COVEN_HOST}/api")
+	t.Setenv("COVEN_EMPTY", "")
+	t.Setenv("COVEN_LOOP_A", "${COVEN_LOOP_B}")
+	t.Setenv("COVEN_LOOP_B", "x-${COVEN_LOOP_A}")
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{name: "no references", input: "make build", want: "make build"},
+		{name: "value refers to another variable", input: "curl ${COVEN_URL}", want: "curl https://example.com/api"},
+		{name: "default used when unset", input: "${COVEN_UNSET:-fallback}", want: "fallback"},
+		{name: "default used when empty", input: "${COVEN_EMPTY:-fallback}", want: "fallback"},
+		{name: "empty value without default", input: "[${COVEN_EMPTY}]", want: "[]"},
+		{name: "nested default", input: "${COVEN_UNSET:-${COVEN_HOST}}", want: "example.com"},
+		{name: "disallowed prefix is left for the shell", input: "${PATH} ${HOME:-/root}", want: "${PATH} ${HOME:-/root}"},
+		{name: "template tag is untouched", input: "echo ${{.bead.id}}", want: "echo ${{.bead.id}}"},
+		{name: "unclosed reference is literal", input: "echo ${COVEN_HOST", want: "echo ${COVEN_HOST"},
+		{name: "required variable unset", input: "${COVEN_UNSET}", wantErr: "required but not set"},
+		{name: "recursive variables", input: "${COVEN_LOOP_A}", wantErr: "COVEN_LOOP_A -> COVEN_LOOP_B -> COVEN_LOOP_A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := interpolate(tt.input, DefaultEnvPrefixes, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("interpolate(%q) error = %v, want %q", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("interpolate(%q) error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("interpolate(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWithOptions_EnvPrefixes(t *testing.T) {
+	t.Setenv("DEPLOY_TARGET", "staging")
+	data := []byte(`name: deploy
+description: Deploys to ${DEPLOY_TARGET}
+steps:
+  - name: deploy
+    type: script
+    command: deploy ${DEPLOY_TARGET} ${COVEN_X:-none}
+`)
+
+	g, err := ParseWithOptions(data, Options{EnvPrefixes: []string{"DEPLOY_"}})
+	if err != nil {
+		t.Fatalf("ParseWithOptions() error: %v", err)
+	}
+	if g.Steps[0].Command != "deploy staging ${COVEN_X:-none}" {
+		t.Errorf("Command = %q, want only DEPLOY_ variables substituted", g.Steps[0].Command)
+	}
+
+	g, err = Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if g.Description != "Deploys to ${DEPLOY_TARGET}" {
+		t.Errorf("Description = %q, want the default prefixes", g.Description)
+	}
+}
@@ -0,0 +1,208 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// extendsKey is the key naming the grimoire a grimoire inherits from, as in
+// "extends: implement-bead".
+const extendsKey = "extends"
+
+// namedListKeys are the lists whose entries a grimoire that extends another
+// merges with the base's by name, rather than replacing the whole list.
+var namedListKeys = map[string]bool{
+	"prepare": true,
+	"steps":   true,
+	"inputs":  true,
+}
+
+// ExtendsError is returned when the grimoire a grimoire extends can't be
+// resolved.
+type ExtendsError struct {
+	Extends string
+	Message string
+}
+
+func (e *ExtendsError) Error() string {
+	return fmt.Sprintf("grimoire extends %q failed: %s", e.Extends, e.Message)
+}
+
+// IsExtendsError returns true if the error is an ExtendsError.
+func IsExtendsError(err error) bool {
+	var extendsErr *ExtendsError
+	return errors.As(err, &extendsErr)
+}
+
+// resolveContent returns grimoire data with its includes resolved from dir
+// and the grimoire it extends, if any, merged in. builtin is set for built-in
+// grimoires, which may only extend other built-in grimoires. chain names the
+// grimoires being resolved, ending with this one.
+func (l *Loader) resolveContent(data []byte, dir fs.FS, builtin bool, chain []string) ([]byte, error) {
+	data, err := resolveIncludes(data, dir)
+	if err != nil {
+		return nil, err
+	}
+	return l.resolveExtends(data, builtin, chain)
+}
+
+// resolveExtends returns grimoire data merged onto the grimoire it extends,
+// which is looked up like Load does and resolved first. The base's settings
+// are kept unless the grimoire sets them, except its name, which isn't
+// inherited. Steps, prepare steps and inputs are merged by name: an entry
+// with the name of one of the base's replaces the fields it sets and keeps
+// the rest, and other entries are added after the base's. A user grimoire
+// that extends its own name extends the built-in grimoire it overrides. Data
+// that doesn't extend a grimoire is returned unchanged, so its content hash is
+// unaffected.
+func (l *Loader) resolveExtends(data []byte, builtin bool, chain []string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	root := documentRoot(&doc)
+	extends := mappingValue(root, extendsKey)
+	if extends == nil {
+		return data, nil
+	}
+
+	base := extends.Value
+	if extends.Kind != yaml.ScalarNode || base == "" {
+		return nil, &ExtendsError{Extends: base, Message: "extends must be the name of a grimoire"}
+	}
+	if err := validateGrimoireName(base); err != nil {
+		return nil, &ExtendsError{Extends: base, Message: err.Error()}
+	}
+	overrides := !builtin && len(chain) > 0 && chain[len(chain)-1] == base
+	for i, name := range chain {
+		if name == base && !(overrides && i == len(chain)-1) {
+			return nil, &ExtendsError{Extends: base, Message: fmt.Sprintf("extends itself (%s)", strings.Join(append(chain, base), " -> "))}
+		}
+	}
+
+	baseData, baseDir, baseBuiltin, err := l.readGrimoire(base, builtin || overrides)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, &ExtendsError{Extends: base, Message: "grimoire not found"}
+		}
+		return nil, &ExtendsError{Extends: base, Message: err.Error()}
+	}
+	baseData, err = l.resolveContent(baseData, baseDir, baseBuiltin, append(chain[:len(chain):len(chain)], base))
+	if err != nil {
+		return nil, err
+	}
+
+	var baseDoc yaml.Node
+	if err := yaml.Unmarshal(baseData, &baseDoc); err != nil {
+		return nil, &ExtendsError{Extends: base, Message: err.Error()}
+	}
+	baseRoot := documentRoot(&baseDoc)
+	if baseRoot == nil || baseRoot.Kind != yaml.MappingNode {
+		return nil, &ExtendsError{Extends: base, Message: "not a grimoire"}
+	}
+	mergeGrimoireNodes(baseRoot, root)
+
+	out, err := yaml.Marshal(&baseDoc)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	return out, nil
+}
+
+// mergeGrimoireNodes merges the grimoire mapping child onto base, in place.
+func mergeGrimoireNodes(base, child *yaml.Node) {
+	deleteMappingValue(base, "name")
+	for i := 0; i+1 < len(child.Content); i += 2 {
+		key, value := child.Content[i].Value, child.Content[i+1]
+		if key == extendsKey {
+			continue
+		}
+		if existing := mappingValue(base, key); namedListKeys[key] && existing != nil &&
+			existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode {
+			mergeNamedList(existing, value)
+			continue
+		}
+		setMappingValue(base, key, value)
+	}
+}
+
+// mergeNamedList merges the entries of the sequence child into base by their
+// name field, in place.
+func mergeNamedList(base, child *yaml.Node) {
+	for _, entry := range child.Content {
+		name := mappingValue(entry, "name")
+		var existing *yaml.Node
+		if name != nil {
+			for _, candidate := range base.Content {
+				if n := mappingValue(candidate, "name"); n != nil && n.Value == name.Value {
+					existing = candidate
+					break
+				}
+			}
+		}
+		if existing == nil || entry.Kind != yaml.MappingNode {
+			base.Content = append(base.Content, entry)
+			continue
+		}
+		for i := 0; i+1 < len(entry.Content); i += 2 {
+			setMappingValue(existing, entry.Content[i].Value, entry.Content[i+1])
+		}
+	}
+}
+
+// checkUnresolvedExtends returns an error if the grimoire document node still
+// extends another grimoire, which only a Loader can resolve.
+func checkUnresolvedExtends(doc *yaml.Node) error {
+	if extends := mappingValue(documentRoot(doc), extendsKey); extends != nil {
+		return &ExtendsError{Extends: extends.Value, Message: "extends is only resolved for grimoires loaded from a grimoire directory"}
+	}
+	return nil
+}
+
+// readExtends returns the name of the grimoire data extends, or "" if it
+// doesn't extend one.
+func readExtends(data []byte) string {
+	var header struct {
+		Extends string `yaml:"extends"`
+	}
+	yaml.Unmarshal(data, &header)
+	return header.Extends
+}
+
+// documentRoot returns the top-level node of a YAML document node, or nil if
+// it is empty.
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode {
+		if len(doc.Content) == 0 {
+			return nil
+		}
+		return doc.Content[0]
+	}
+	return doc
+}
+
+// setMappingValue sets key to value in a YAML mapping node, adding the key
+// if it isn't there.
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// deleteMappingValue removes key from a YAML mapping node.
+func deleteMappingValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
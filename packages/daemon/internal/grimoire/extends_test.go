@@ -0,0 +1,253 @@
+package grimoire
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// baseGrimoire is a grimoire other grimoires in these tests extend.
+const baseGrimoire = `name: base
+description: Implements and tests a task
+timeout: 2h
+tags:
+  team: platform
+steps:
+  - name: implement
+    type: agent
+    spell: implement
+  - name: test
+    type: script
+    command: make test
+    timeout: 5m
+    on_fail: continue
+  - name: review
+    type: agent
+    spell: review
+`
+
+func TestLoad_Extends(t *testing.T) {
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{
+		"base.yaml": baseGrimoire,
+		"ship.yaml": `name: ship
+extends: base
+steps:
+  - name: test
+    timeout: 20m
+  - name: review
+    spell: strict-review
+  - name: merge
+    type: merge
+`,
+	})
+
+	loader := NewLoaderWithBuiltins(covenDir, nil, "grimoires")
+	g, err := loader.Load("ship")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if g.Name != "ship" || g.Extends != "base" || g.Source != SourceUser {
+		t.Errorf("grimoire = %q extends %q from %s, want ship extending base from user", g.Name, g.Extends, g.Source)
+	}
+	// Settings the child doesn't set are inherited
+	if g.Description != "Implements and tests a task" || g.Timeout != "2h" || g.Tags["team"] != "platform" {
+		t.Errorf("grimoire = %+v, want the base's settings", g)
+	}
+
+	var names []string
+	for _, step := range g.Steps {
+		names = append(names, step.Name)
+	}
+	if got, want := strings.Join(names, ","), "implement,test,review,merge"; got != want {
+		t.Errorf("steps = %s, want %s", got, want)
+	}
+	// Overriding a step changes only the fields set
+	if test := g.Steps[1]; test.Timeout != "20m" || test.Command != "make test" || test.OnFail != "continue" {
+		t.Errorf("test step = %+v, want the base step with the new timeout", test)
+	}
+	if review := g.Steps[2]; review.Spell != "strict-review" || review.Type != StepTypeAgent {
+		t.Errorf("review step = %+v, want the base step with the new spell", review)
+	}
+
+	// The content snapshotted for pinned workflows is the merged grimoire
+	data, err := loader.LoadContent("ship")
+	if err != nil {
+		t.Fatalf("LoadContent() error: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(LoadContent()) error: %v", err)
+	}
+	if len(parsed.Steps) != 4 || parsed.ContentHash != g.ContentHash {
+		t.Errorf("parsed content has %d steps and hash %s, want 4 and %s", len(parsed.Steps), parsed.ContentHash, g.ContentHash)
+	}
+}
+
+func TestLoad_ExtendsBuiltin(t *testing.T) {
+	builtinFS := fstest.MapFS{
+		"grimoires/base.yaml": &fstest.MapFile{Data: []byte(baseGrimoire)},
+	}
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{
+		"quick.yaml": `name: quick
+description: Implements without review
+extends: base
+steps:
+  - name: review
+    when: "false"
+`,
+	})
+
+	g, err := NewLoaderWithBuiltins(covenDir, builtinFS, "grimoires").Load("quick")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if g.Source != SourceUser || g.Description != "Implements without review" {
+		t.Errorf("grimoire = %+v, want the user grimoire's source and description", g)
+	}
+	if len(g.Steps) != 3 || g.Steps[2].When != "false" {
+		t.Errorf("steps = %+v, want the built-in steps with review skipped", g.Steps)
+	}
+}
+
+func TestLoad_ExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"main.yaml": "name: main\nextends: a\n",
+				"a.yaml":    "name: a\nextends: b\n",
+				"b.yaml":    "name: b\nextends: main\n",
+			},
+			wantErr: "extends itself (main -> a -> b -> main)",
+		},
+		{
+			name:    "extends its own name without a built-in",
+			files:   map[string]string{"main.yaml": "name: main\nextends: main\n"},
+			wantErr: `grimoire extends "main" failed: grimoire not found`,
+		},
+		{
+			name:    "missing base",
+			files:   map[string]string{"main.yaml": "name: main\nextends: missing\n"},
+			wantErr: `grimoire extends "missing" failed: grimoire not found`,
+		},
+		{
+			name:    "not a name",
+			files:   map[string]string{"main.yaml": "name: main\nextends: [base]\n"},
+			wantErr: "extends must be the name of a grimoire",
+		},
+		{
+			name: "merged result is validated",
+			files: map[string]string{
+				"base.yaml": baseGrimoire,
+				"main.yaml": "name: main\nextends: base\nsteps:\n  - name: test\n    type: agent\n",
+			},
+			wantErr: "agent step requires spell field",
+		},
+		{
+			name: "name isn't inherited",
+			files: map[string]string{
+				"base.yaml": baseGrimoire,
+				"main.yaml": "extends: base\n",
+			},
+			wantErr: "grimoire name is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			covenDir := t.TempDir()
+			writeGrimoireFiles(t, covenDir, tt.files)
+
+			_, err := NewLoaderWithBuiltins(covenDir, nil, "grimoires").Load("main")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_ExtendsOverriddenBuiltin(t *testing.T) {
+	builtinFS := fstest.MapFS{
+		"grimoires/base.yaml": &fstest.MapFile{Data: []byte(baseGrimoire)},
+	}
+	// A user grimoire overriding a built-in one can extend it by its own name
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{
+		"base.yaml": "name: base\nextends: base\nsteps:\n  - name: review\n    spell: strict-review\n",
+	})
+
+	g, err := NewLoaderWithBuiltins(covenDir, builtinFS, "grimoires").Load("base")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if g.Source != SourceUser || g.Extends != "base" || len(g.Steps) != 3 {
+		t.Fatalf("grimoire = %+v, want the user grimoire merged onto the built-in one", g)
+	}
+	if review := g.Steps[2]; review.Spell != "strict-review" {
+		t.Errorf("review step = %+v, want the user grimoire's spell", review)
+	}
+
+	// A built-in grimoire extending itself is still a cycle
+	builtinFS["grimoires/loop.yaml"] = &fstest.MapFile{Data: []byte("name: loop\nextends: loop\n")}
+	if _, err := NewLoaderWithBuiltins(t.TempDir(), builtinFS, "grimoires").Load("loop"); err == nil || !strings.Contains(err.Error(), "extends itself (loop -> loop)") {
+		t.Errorf("Load() error = %v, want a cycle", err)
+	}
+}
+
+func TestLoad_BuiltinExtendsBuiltin(t *testing.T) {
+	builtinFS := fstest.MapFS{
+		"grimoires/base.yaml": &fstest.MapFile{Data: []byte(baseGrimoire)},
+		"grimoires/ship.yaml": &fstest.MapFile{Data: []byte("name: ship\nextends: base\nsteps:\n  - name: merge\n    type: merge\n")},
+	}
+	// A user grimoire named like the base doesn't change built-in grimoires
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{
+		"base.yaml": "name: base\ndescription: User base\nsteps:\n  - name: only\n    type: script\n    command: true\n",
+	})
+
+	g, err := NewLoaderWithBuiltins(covenDir, builtinFS, "grimoires").Load("ship")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if g.Source != SourceBuiltIn || len(g.Steps) != 4 || g.Description != "Implements and tests a task" {
+		t.Errorf("grimoire = %+v, want the built-in base merged", g)
+	}
+}
+
+func TestParse_ExtendsUnresolved(t *testing.T) {
+	_, err := Parse([]byte(`name: main
+extends: base
+description: Extends a grimoire
+steps:
+  - name: test
+    type: script
+    command: make test
+`))
+	if !IsExtendsError(err) {
+		t.Fatalf("Parse() error = %v, want an ExtendsError", err)
+	}
+}
+
+func TestInstall_Extends(t *testing.T) {
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{"base.yaml": baseGrimoire})
+	loader := NewLoaderWithBuiltins(covenDir, nil, "grimoires")
+
+	results, err := loader.Install([]byte("name: ship\nextends: base\nsteps:\n  - name: merge\n    type: merge\n"))
+	if err != nil {
+		t.Fatalf("Install() error: %v (results %+v)", err, results)
+	}
+	g, err := loader.Load("ship")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(g.Steps) != 4 {
+		t.Errorf("steps = %d, want the base's and the merge step", len(g.Steps))
+	}
+}
@@ -0,0 +1,238 @@
+package grimoire
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/bundle"
+	"github.com/coven/daemon/internal/spell"
+)
+
+// StepRunHandler serves POST /grimoires/:name/steps/:step/run.
+type StepRunHandler func(w http.ResponseWriter, r *http.Request, name, step string)
+
+// Handlers provides HTTP handlers for grimoire management.
+type Handlers struct {
+	loader  *Loader
+	spells  *spell.Loader
+	runStep StepRunHandler
+}
+
+// NewHandlers creates new grimoire handlers that install into covenDir.
+func NewHandlers(covenDir string) *Handlers {
+	return &Handlers{
+		loader: NewLoader(covenDir),
+		spells: spell.NewLoader(covenDir),
+	}
+}
+
+// SetOptions sets how grimoires are validated when they are read, validated
+// or installed.
+func (h *Handlers) SetOptions(opts Options) {
+	h.loader.SetOptions(opts)
+}
+
+// SetStepRunHandler enables POST /grimoires/:name/steps/:step/run, served
+// by handler. Running steps lives outside this package, with the workflow
+// engine; the route is not found until a handler is set.
+func (h *Handlers) SetStepRunHandler(handler StepRunHandler) {
+	h.runStep = handler
+}
+
+// Register registers grimoire handlers with the server.
+func (h *Handlers) Register(server *api.Server) {
+	server.RegisterHandlerFunc("/grimoires", h.handleList)
+	server.RegisterHandlerFunc("/grimoires/install", h.handleInstall)
+	server.RegisterHandlerFunc("/grimoires/validate", h.handleValidate)
+	server.RegisterHandlerFunc("/grimoires/", h.handleGrimoireByName)
+}
+
+// handleGrimoireByName routes requests for a named grimoire.
+func (h *Handlers) handleGrimoireByName(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/grimoires/")
+	name, action, _ := strings.Cut(path, "/")
+	if name == "" {
+		api.WriteError(w, http.StatusNotFound, "not found")
+		return
+	}
+	switch action {
+	case "":
+		h.handleGet(w, r, name)
+		return
+	case "diff":
+		h.handleDiff(w, r, name)
+		return
+	case "spells":
+		h.handleSpells(w, r, name)
+		return
+	}
+
+	// steps/:step/run
+	parts := strings.Split(action, "/")
+	if len(parts) == 3 && parts[0] == "steps" && parts[1] != "" && parts[2] == "run" && h.runStep != nil {
+		h.runStep(w, r, name, parts[1])
+		return
+	}
+	api.WriteError(w, http.StatusNotFound, "not found")
+}
+
+// handleList handles GET /grimoires.
+// @Summary      List grimoires
+// @Description  Lists the grimoires a workflow can be started with, user and built-in, sorted by name. Grimoires that fail to load are listed with the error.
+// @Tags         grimoires
+// @Produce      json
+// @Success      200  {array}   Summary            "Grimoires"
+// @Failure      405  {object}  map[string]string  "Method not allowed"
+// @Failure      500  {object}  map[string]string  "Failed to list grimoires"
+// @Router       /grimoires [get]
+func (h *Handlers) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	summaries, err := h.loader.Summaries()
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, summaries)
+}
+
+// handleGet handles GET /grimoires/:name.
+// @Summary      Get a grimoire
+// @Description  Returns a grimoire's summary and its parsed definition, keyed by YAML field names, with includes and extends resolved and nested steps in place.
+// @Tags         grimoires
+// @Produce      json
+// @Param        name  path      string             true  "Grimoire name"
+// @Success      200   {object}  Detail             "Grimoire"
+// @Failure      404   {object}  map[string]string  "Grimoire not found"
+// @Failure      405   {object}  map[string]string  "Method not allowed"
+// @Failure      422   {object}  map[string]string  "The grimoire doesn't load"
+// @Router       /grimoires/{name} [get]
+func (h *Handlers) handleGet(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	detail, err := h.loader.Describe(name)
+	if err != nil {
+		if IsNotFound(err) {
+			api.WriteError(w, http.StatusNotFound, err.Error())
+		} else {
+			api.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, detail)
+}
+
+// handleDiff handles GET /grimoires/:name/diff.
+// @Summary      Diff a grimoire override
+// @Description  Compares a user grimoire with the built-in grimoire it overrides, field by field and step by step.
+// @Tags         grimoires
+// @Produce      json
+// @Param        name  path      string             true  "Grimoire name"
+// @Success      200   {object}  Diff               "Differences between the built-in and user versions"
+// @Failure      404   {object}  map[string]string  "Grimoire not found, or not both built in and overridden"
+// @Failure      405   {object}  map[string]string  "Method not allowed"
+// @Failure      422   {object}  map[string]string  "One of the versions is invalid"
+// @Failure      500   {object}  map[string]string  "Failed to load the grimoire"
+// @Router       /grimoires/{name}/diff [get]
+func (h *Handlers) handleDiff(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	diff, err := h.loader.Diff(name)
+	if err != nil {
+		switch {
+		case IsNotFound(err), IsNotOverridden(err):
+			api.WriteError(w, http.StatusNotFound, err.Error())
+		case IsParseError(err), IsValidationError(err), IsInterpolationError(err), IsIncludeError(err):
+			api.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		default:
+			api.WriteError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, diff)
+}
+
+// handleSpells handles GET /grimoires/:name/spells.
+// @Summary      List a grimoire's spells
+// @Description  Lists the spell files the grimoire's agent steps use, including steps nested in loops and prompt sections, and whether each one loads.
+// @Tags         grimoires
+// @Produce      json
+// @Param        name  path      string             true  "Grimoire name"
+// @Success      200   {object}  SpellDependencies  "Referenced spells, with the missing ones listed"
+// @Failure      404   {object}  map[string]string  "Grimoire not found"
+// @Failure      405   {object}  map[string]string  "Method not allowed"
+// @Failure      422   {object}  map[string]string  "The grimoire doesn't load"
+// @Router       /grimoires/{name}/spells [get]
+func (h *Handlers) handleSpells(w http.ResponseWriter, r *http.Request, name string) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	g, err := h.loader.Load(name)
+	if err != nil {
+		if IsNotFound(err) {
+			api.WriteError(w, http.StatusNotFound, err.Error())
+		} else {
+			api.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, ResolveSpells(g, h.spells))
+}
+
+// handleInstall handles POST /grimoires/install.
+// @Summary      Install grimoires
+// @Description  Validates a tarball or multi-document YAML bundle of grimoires and installs them into .coven/grimoires. If any grimoire is invalid, nothing is installed.
+// @Tags         grimoires
+// @Accept       application/x-tar,application/gzip,application/yaml
+// @Produce      json
+// @Success      200  {object}  bundle.InstallResponse  "All grimoires installed"
+// @Failure      400  {object}  map[string]string       "Unreadable bundle"
+// @Failure      405  {object}  map[string]string       "Method not allowed"
+// @Failure      422  {object}  bundle.InstallResponse  "One or more grimoires failed validation"
+// @Failure      500  {object}  map[string]string       "Failed to write grimoires"
+// @Router       /grimoires/install [post]
+func (h *Handlers) handleInstall(w http.ResponseWriter, r *http.Request) {
+	bundle.ServeInstall(w, r, h.loader.Install)
+}
+
+// handleValidate handles POST /grimoires/validate.
+// @Summary      Validate a grimoire
+// @Description  Parses and validates grimoire YAML without saving it, resolving includes and extends from .coven/grimoires. Problems are reported with the field and step they are in, with a 200 status whether or not the grimoire is valid. Referenced spells that don't load are reported as warnings.
+// @Tags         grimoires
+// @Accept       application/yaml
+// @Produce      json
+// @Success      200  {object}  ContentValidation  "Validation result"
+// @Failure      400  {object}  map[string]string  "Unreadable request body"
+// @Failure      405  {object}  map[string]string  "Method not allowed"
+// @Router       /grimoires/validate [post]
+func (h *Handlers) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, bundle.MaxBundleSize))
+	if err != nil {
+		api.WriteError(w, http.StatusBadRequest, "failed to read grimoire: "+err.Error())
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, h.loader.ValidateContent(data, h.spells))
+}
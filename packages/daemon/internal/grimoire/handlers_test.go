@@ -0,0 +1,435 @@
+package grimoire
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/bundle"
+)
+
+func setupTestGrimoireHandlers(t *testing.T) (*http.Client, string, func()) {
+	t.Helper()
+	covenDir := t.TempDir()
+
+	socketPath := filepath.Join(os.TempDir(), "coven-grimoire-test-"+time.Now().Format("150405.000")+".sock")
+	server := api.NewServer(socketPath)
+	NewHandlers(covenDir).Register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	return client, covenDir, func() { server.Stop(context.Background()) }
+}
+
+func TestHandleInstall(t *testing.T) {
+	client, covenDir, cleanup := setupTestGrimoireHandlers(t)
+	defer cleanup()
+
+	t.Run("invalid grimoire aborts install", func(t *testing.T) {
+		body := installBundleYAML + "---\nname: broken\n"
+		resp, err := client.Post("http://unix/grimoires/install", "application/yaml", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity)
+		}
+		var result bundle.InstallResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if result.Installed != 0 || len(result.Results) != 3 {
+			t.Errorf("response = %+v, want 3 results and nothing installed", result)
+		}
+		if _, err := os.Stat(filepath.Join(covenDir, "grimoires", "first.yaml")); !os.IsNotExist(err) {
+			t.Error("first.yaml should not be installed when the bundle is invalid")
+		}
+	})
+
+	t.Run("installs valid bundle", func(t *testing.T) {
+		resp, err := client.Post("http://unix/grimoires/install", "application/yaml", strings.NewReader(installBundleYAML))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var result bundle.InstallResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if result.Installed != 2 {
+			t.Errorf("Installed = %d, want 2", result.Installed)
+		}
+		if _, err := NewLoader(covenDir).Load("first"); err != nil {
+			t.Errorf("Load(first) error: %v", err)
+		}
+	})
+
+	t.Run("malformed bundle", func(t *testing.T) {
+		resp, err := client.Post("http://unix/grimoires/install", "application/yaml", strings.NewReader(""))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires/install")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func TestHandleDiff(t *testing.T) {
+	client, covenDir, cleanup := setupTestGrimoireHandlers(t)
+	defer cleanup()
+
+	builtin, err := embeddedGrimoires.ReadFile("grimoires/implement-bead.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read builtin grimoire: %v", err)
+	}
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	override := strings.Replace(string(builtin), "timeout: 2h", "timeout: 4h", 1)
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "implement-bead.yaml"), []byte(override), 0644); err != nil {
+		t.Fatalf("Failed to write override: %v", err)
+	}
+
+	t.Run("shows the overridden field", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires/implement-bead/diff")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var diff Diff
+		if err := json.NewDecoder(resp.Body).Decode(&diff); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if diff.Identical || len(diff.Steps) != 0 || len(diff.Fields) != 1 {
+			t.Fatalf("diff = %+v, want only the timeout changed", diff)
+		}
+		if f := diff.Fields[0]; f.Field != "timeout" || f.Builtin != "2h" || f.User != "4h" {
+			t.Errorf("Fields[0] = %+v, want timeout 2h -> 4h", f)
+		}
+	})
+
+	t.Run("returns 404 for a builtin that isn't overridden", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires/spec-to-beads/diff")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+		}
+	})
+
+	t.Run("rejects non-GET", func(t *testing.T) {
+		resp, err := client.Post("http://unix/grimoires/implement-bead/diff", "application/json", nil)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func TestHandleRunStep_Disabled(t *testing.T) {
+	client, _, cleanup := setupTestGrimoireHandlers(t)
+	defer cleanup()
+
+	// Without a step run handler the route doesn't exist
+	resp, err := client.Post("http://unix/grimoires/review/steps/test/run", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestHandleSpells(t *testing.T) {
+	client, covenDir, cleanup := setupTestGrimoireHandlers(t)
+	defer cleanup()
+
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	spellsDir := filepath.Join(covenDir, "spells")
+	for _, dir := range []string{grimoiresDir, spellsDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", dir, err)
+		}
+	}
+	grimoireYAML := `name: with-spells
+description: Uses one spell that exists and one that doesn't
+steps:
+  - name: implement
+    type: agent
+    spell: implement
+  - name: review-loop
+    type: loop
+    max_iterations: 2
+    steps:
+      - name: review
+        type: agent
+        spell: missing-review
+        sections: [implement]
+      - name: inline
+        type: agent
+        spell: |
+          Fix it.
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "with-spells.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(spellsDir, "implement.md"), []byte("Implement {{.bead.title}}"), 0644); err != nil {
+		t.Fatalf("Failed to write spell: %v", err)
+	}
+
+	t.Run("reports present and missing spells", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires/with-spells/spells")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var deps SpellDependencies
+		if err := json.NewDecoder(resp.Body).Decode(&deps); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if deps.Grimoire != "with-spells" || len(deps.Spells) != 2 {
+			t.Fatalf("deps = %+v, want 2 spells for with-spells", deps)
+		}
+		if s := deps.Spells[0]; s.Name != "implement" || !s.Found || s.Source != "user" || strings.Join(s.Steps, ",") != "implement,review" {
+			t.Errorf("Spells[0] = %+v, want implement found in user spells, used by implement and review", s)
+		}
+		if s := deps.Spells[1]; s.Name != "missing-review" || s.Found || s.Error == "" || strings.Join(s.Steps, ",") != "review" {
+			t.Errorf("Spells[1] = %+v, want missing-review not found, used by review", s)
+		}
+		if len(deps.Missing) != 1 || deps.Missing[0] != "missing-review" {
+			t.Errorf("Missing = %v, want [missing-review]", deps.Missing)
+		}
+	})
+
+	t.Run("returns 404 for an unknown grimoire", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires/nonexistent/spells")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+		}
+	})
+}
+
+func TestHandleValidate(t *testing.T) {
+	client, _, cleanup := setupTestGrimoireHandlers(t)
+	defer cleanup()
+
+	validate := func(t *testing.T, body string) ContentValidation {
+		t.Helper()
+		resp, err := client.Post("http://unix/grimoires/validate", "application/yaml", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var result ContentValidation
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return result
+	}
+
+	t.Run("valid grimoire with a missing spell", func(t *testing.T) {
+		result := validate(t, `name: draft
+description: A grimoire being edited
+steps:
+  - name: implement
+    type: agent
+    spell: not-written-yet
+`)
+		if !result.IsValid || result.Name != "draft" || len(result.Errors) != 0 {
+			t.Fatalf("result = %+v, want a valid grimoire", result)
+		}
+		if len(result.Warnings) != 1 || result.Warnings[0].Step != "implement" || !strings.Contains(result.Warnings[0].Message, `"not-written-yet"`) {
+			t.Errorf("warnings = %+v, want the missing spell used by implement", result.Warnings)
+		}
+	})
+
+	t.Run("invalid step is reported with a 200", func(t *testing.T) {
+		result := validate(t, `name: draft
+description: A grimoire being edited
+steps:
+  - name: test
+    type: script
+`)
+		if result.IsValid || len(result.Errors) != 1 {
+			t.Fatalf("result = %+v, want one error", result)
+		}
+		if e := result.Errors[0]; e.Field != "steps" || e.Step != "test" || !strings.Contains(e.Message, "command") {
+			t.Errorf("error = %+v, want the test step's missing command", e)
+		}
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires/validate")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func TestHandleListAndGet(t *testing.T) {
+	client, covenDir, cleanup := setupTestGrimoireHandlers(t)
+	defer cleanup()
+
+	writeGrimoireFiles(t, covenDir, map[string]string{
+		"with-loop.yaml": `name: with-loop
+description: Fixes until the tests pass
+timeout: 1h
+steps:
+  - name: fix-loop
+    type: loop
+    max_iterations: 3
+    steps:
+      - name: test
+        type: script
+        command: make test
+`,
+		"broken.yaml": "name: broken\n",
+	})
+
+	t.Run("lists user and built-in grimoires", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var summaries []Summary
+		if err := json.NewDecoder(resp.Body).Decode(&summaries); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		byName := make(map[string]Summary)
+		for _, s := range summaries {
+			byName[s.Name] = s
+		}
+		if s := byName["with-loop"]; s.Source != SourceUser || s.StepCount != 1 || s.Timeout != "1h" || s.Description == "" {
+			t.Errorf("with-loop = %+v, want a user grimoire with one step and a 1h timeout", s)
+		}
+		if s := byName["broken"]; s.Source != SourceUser || s.Error == "" {
+			t.Errorf("broken = %+v, want a user grimoire with an error", s)
+		}
+		if s := byName["implement-bead"]; s.Source != SourceBuiltIn || s.StepCount == 0 {
+			t.Errorf("implement-bead = %+v, want the built-in grimoire", s)
+		}
+	})
+
+	t.Run("gets a grimoire with its nested steps", func(t *testing.T) {
+		resp, err := client.Get("http://unix/grimoires/with-loop")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var detail struct {
+			Name        string `json:"name"`
+			ContentHash string `json:"content_hash"`
+			Definition  struct {
+				Steps []struct {
+					Name  string `json:"name"`
+					Steps []struct {
+						Name    string `json:"name"`
+						Command string `json:"command"`
+					} `json:"steps"`
+				} `json:"steps"`
+			} `json:"definition"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if detail.Name != "with-loop" || detail.ContentHash == "" {
+			t.Errorf("detail = %+v, want with-loop with its content hash", detail)
+		}
+		if steps := detail.Definition.Steps; len(steps) != 1 || len(steps[0].Steps) != 1 || steps[0].Steps[0].Command != "make test" {
+			t.Errorf("definition steps = %+v, want the loop and its nested test step", steps)
+		}
+	})
+
+	for path, want := range map[string]int{
+		"/grimoires/nonexistent": http.StatusNotFound,
+		"/grimoires/broken":      http.StatusUnprocessableEntity,
+	} {
+		t.Run(path, func(t *testing.T) {
+			resp, err := client.Get("http://unix" + path)
+			if err != nil {
+				t.Fatalf("Request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != want {
+				t.Errorf("Status = %d, want %d", resp.StatusCode, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey is the key of a step list entry that is replaced by the steps of
+// a fragment file, as in "- include: shared/review.yaml".
+const includeKey = "include"
+
+// IncludeError is returned when a grimoire's include can't be resolved.
+type IncludeError struct {
+	Include string
+	Message string
+}
+
+func (e *IncludeError) Error() string {
+	return fmt.Sprintf("grimoire include %q failed: %s", e.Include, e.Message)
+}
+
+// IsIncludeError returns true if the error is an IncludeError.
+func IsIncludeError(err error) bool {
+	var includeErr *IncludeError
+	return errors.As(err, &includeErr)
+}
+
+// resolveIncludes returns grimoire data with every include replaced by the
+// steps of the fragment file it names. Fragment paths are relative to dir,
+// the grimoire directory, and may not leave it. A fragment is a YAML mapping
+// whose steps may include further fragments. Data without includes is
+// returned unchanged, so its content hash is unaffected.
+func resolveIncludes(data []byte, dir fs.FS) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	changed, err := spliceIncludes(&doc, dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return data, nil
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	return out, nil
+}
+
+// spliceIncludes replaces includes in the step lists of the grimoire or
+// fragment document node, including those nested in loops. With a nil dir
+// any include is an error. including names the fragments being resolved, so
+// a fragment that includes itself is an error rather than endless recursion.
+// It reports whether any include was replaced.
+func spliceIncludes(node *yaml.Node, dir fs.FS, including []string) (bool, error) {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return false, nil
+		}
+		node = node.Content[0]
+	}
+	if node.Kind != yaml.MappingNode {
+		return false, nil
+	}
+
+	changed := false
+	for _, key := range []string{"prepare", "steps"} {
+		steps := mappingValue(node, key)
+		if steps == nil || steps.Kind != yaml.SequenceNode {
+			continue
+		}
+		spliced, err := spliceStepIncludes(steps, dir, including)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || spliced
+	}
+	return changed, nil
+}
+
+// spliceStepIncludes replaces the includes in a sequence of steps.
+func spliceStepIncludes(steps *yaml.Node, dir fs.FS, including []string) (bool, error) {
+	changed := false
+	var content []*yaml.Node
+	for _, step := range steps.Content {
+		include := mappingValue(step, includeKey)
+		if include == nil {
+			// Loop steps have steps of their own
+			spliced, err := spliceIncludes(step, dir, including)
+			if err != nil {
+				return false, err
+			}
+			changed = changed || spliced
+			content = append(content, step)
+			continue
+		}
+
+		fragment, err := loadFragment(step, include, dir, including)
+		if err != nil {
+			return false, err
+		}
+		content = append(content, fragment...)
+		changed = true
+	}
+	steps.Content = content
+	return changed, nil
+}
+
+// loadFragment returns the steps of the fragment an include step names, with
+// the fragment's own includes resolved.
+func loadFragment(step, include *yaml.Node, dir fs.FS, including []string) ([]*yaml.Node, error) {
+	path := include.Value
+	if include.Kind != yaml.ScalarNode || path == "" {
+		return nil, &IncludeError{Include: path, Message: "include must be the path of a fragment file"}
+	}
+	if len(step.Content) != 2 {
+		return nil, &IncludeError{Include: path, Message: "an include can't have other step fields"}
+	}
+	if dir == nil {
+		return nil, &IncludeError{Include: path, Message: "includes are only resolved for grimoires loaded from a grimoire directory"}
+	}
+	if !fs.ValidPath(path) {
+		return nil, &IncludeError{Include: path, Message: "path must be relative to the grimoire directory and stay inside it"}
+	}
+	for _, p := range including {
+		if p == path {
+			return nil, &IncludeError{Include: path, Message: fmt.Sprintf("includes itself (%s)", strings.Join(append(including, path), " -> "))}
+		}
+	}
+
+	data, err := fs.ReadFile(dir, path)
+	if err != nil {
+		if isNotExistError(err) {
+			return nil, &IncludeError{Include: path, Message: "fragment file not found"}
+		}
+		return nil, &IncludeError{Include: path, Message: err.Error()}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &IncludeError{Include: path, Message: err.Error()}
+	}
+	var root *yaml.Node
+	if len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	steps := mappingValue(root, "steps")
+	if steps == nil || steps.Kind != yaml.SequenceNode {
+		return nil, &IncludeError{Include: path, Message: "fragment must have a list of steps"}
+	}
+	if _, err := spliceStepIncludes(steps, dir, append(including[:len(including):len(including)], path)); err != nil {
+		return nil, err
+	}
+	return steps.Content, nil
+}
+
+// mappingValue returns the value of key in a YAML mapping node, or nil if
+// node isn't a mapping or has no such key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
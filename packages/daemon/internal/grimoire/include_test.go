@@ -0,0 +1,192 @@
+package grimoire
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// writeGrimoireFiles writes files under covenDir's grimoire directory.
+func writeGrimoireFiles(t *testing.T, covenDir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(covenDir, "grimoires", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestLoad_Include(t *testing.T) {
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{
+		"ship.yaml": `name: ship
+description: Implements and reviews a task
+steps:
+  - name: implement
+    type: agent
+    spell: implement
+  - include: shared/checks.yaml
+  - name: review
+    type: loop
+    max_iterations: 2
+    steps:
+      - include: shared/review.yaml
+`,
+		"shared/checks.yaml": `steps:
+  - name: lint
+    type: script
+    command: make lint
+  - include: shared/test.yaml
+`,
+		"shared/test.yaml": `steps:
+  - name: test
+    type: script
+    command: make test
+`,
+		"shared/review.yaml": `steps:
+  - name: review-code
+    type: agent
+    spell: review
+`,
+	})
+
+	loader := NewLoaderWithBuiltins(covenDir, nil, "grimoires")
+	g, err := loader.Load("ship")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	var names []string
+	for _, step := range g.Steps {
+		names = append(names, step.Name)
+	}
+	if got, want := strings.Join(names, ","), "implement,lint,test,review"; got != want {
+		t.Errorf("steps = %s, want %s", got, want)
+	}
+	if g.Steps[2].Command != "make test" {
+		t.Errorf("test step Command = %q, want the fragment's command", g.Steps[2].Command)
+	}
+	if len(g.Steps[3].Steps) != 1 || g.Steps[3].Steps[0].Name != "review-code" {
+		t.Errorf("loop steps = %+v, want the review fragment", g.Steps[3].Steps)
+	}
+
+	// The content snapshotted for pinned workflows has the includes resolved
+	data, err := loader.LoadContent("ship")
+	if err != nil {
+		t.Fatalf("LoadContent() error: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse(LoadContent()) error: %v", err)
+	}
+	if parsed.ContentHash != g.ContentHash || len(parsed.Steps) != 4 {
+		t.Errorf("Parse(LoadContent()) = %d steps, hash %s; want the loaded grimoire", len(parsed.Steps), parsed.ContentHash)
+	}
+}
+
+func TestLoad_IncludeBuiltin(t *testing.T) {
+	builtinFS := fstest.MapFS{
+		"grimoires/builtin.yaml": &fstest.MapFile{Data: []byte(`name: builtin
+description: Includes a built-in fragment
+steps:
+  - include: fragments/test.yaml
+`)},
+		"grimoires/fragments/test.yaml": &fstest.MapFile{Data: []byte(`steps:
+  - name: test
+    type: script
+    command: make test
+`)},
+	}
+
+	g, err := NewLoaderWithBuiltins(t.TempDir(), builtinFS, "grimoires").Load("builtin")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(g.Steps) != 1 || g.Steps[0].Name != "test" {
+		t.Errorf("steps = %+v, want the fragment's test step", g.Steps)
+	}
+}
+
+func TestLoad_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"shared/a.yaml": "steps:\n  - include: shared/b.yaml\n",
+				"shared/b.yaml": "steps:\n  - include: shared/a.yaml\n",
+			},
+			wantErr: "includes itself (shared/a.yaml -> shared/b.yaml -> shared/a.yaml)",
+		},
+		{
+			name:    "missing fragment",
+			files:   map[string]string{},
+			wantErr: "fragment file not found",
+		},
+		{
+			name:    "fragment without steps",
+			files:   map[string]string{"shared/a.yaml": "name: not-a-fragment\n"},
+			wantErr: "fragment must have a list of steps",
+		},
+		{
+			name: "escapes the grimoire directory",
+			files: map[string]string{
+				"shared/a.yaml": "steps:\n  - include: ../secrets.yaml\n",
+			},
+			wantErr: "stay inside it",
+		},
+		{
+			name: "include with other fields",
+			files: map[string]string{
+				"shared/a.yaml": "steps:\n  - include: shared/b.yaml\n    when: \"true\"\n",
+			},
+			wantErr: "can't have other step fields",
+		},
+		{
+			name: "spliced result is validated",
+			files: map[string]string{
+				"shared/a.yaml": "steps:\n  - name: main\n    type: script\n    command: echo again\n",
+			},
+			wantErr: "duplicate step name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			covenDir := t.TempDir()
+			tt.files["main.yaml"] = `name: main
+description: Includes a fragment
+steps:
+  - name: main
+    type: script
+    command: echo hi
+  - include: shared/a.yaml
+`
+			writeGrimoireFiles(t, covenDir, tt.files)
+
+			_, err := NewLoaderWithBuiltins(covenDir, nil, "grimoires").Load("main")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse_IncludeUnresolved(t *testing.T) {
+	_, err := Parse([]byte(`name: main
+description: Includes a fragment
+steps:
+  - include: shared/a.yaml
+`))
+	if !IsIncludeError(err) {
+		t.Fatalf("Parse() error = %v, want an IncludeError", err)
+	}
+}
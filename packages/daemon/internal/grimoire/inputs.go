@@ -0,0 +1,182 @@
+package grimoire
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// InputType is the type of a grimoire input's value.
+type InputType string
+
+const (
+	InputTypeString  InputType = "string"
+	InputTypeNumber  InputType = "number"
+	InputTypeBoolean InputType = "boolean"
+)
+
+// Input is a parameter a grimoire's workflows are started with. Its value is
+// available to templates and conditions as {{.inputs.name}}.
+type Input struct {
+	// Name identifies the input.
+	Name string `yaml:"name"`
+
+	// Description explains what the input is for, for whoever provides it.
+	Description string `yaml:"description,omitempty"`
+
+	// Type is the type of the input's value: string (the default), number
+	// or boolean.
+	Type InputType `yaml:"type,omitempty"`
+
+	// Required inputs must be provided when the workflow starts.
+	Required bool `yaml:"required,omitempty"`
+
+	// Default is the value of an optional input that isn't provided.
+	Default interface{} `yaml:"default,omitempty"`
+}
+
+// inputNamePattern matches the input names templates can reference.
+var inputNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// GetType returns the input's type, InputTypeString if not specified.
+func (in *Input) GetType() InputType {
+	if in.Type == "" {
+		return InputTypeString
+	}
+	return in.Type
+}
+
+// coerce returns value as the input's type. Strings are parsed for number and
+// boolean inputs, so values can come from a command line or query string.
+func (in *Input) coerce(value interface{}) (interface{}, error) {
+	switch in.GetType() {
+	case InputTypeNumber:
+		switch v := value.(type) {
+		case int, int32, int64, uint, uint32, uint64, float32, float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("input %q must be a number, got %v", in.Name, value)
+
+	case InputTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("input %q must be a boolean, got %v", in.Name, value)
+
+	default:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case int, int32, int64, uint, uint32, uint64, float32, float64, bool:
+			return fmt.Sprint(v), nil
+		}
+		return nil, fmt.Errorf("input %q must be a string, got %T", in.Name, value)
+	}
+}
+
+// validateInputs checks that each input has a unique, valid name and a known
+// type, and that its default, if any, is of that type.
+func (g *Grimoire) validateInputs() error {
+	names := make(map[string]bool, len(g.Inputs))
+	for i := range g.Inputs {
+		in := &g.Inputs[i]
+		if !inputNamePattern.MatchString(in.Name) {
+			return fmt.Errorf("invalid input name %q, must match %s", in.Name, inputNamePattern)
+		}
+		if names[in.Name] {
+			return fmt.Errorf("duplicate input %q", in.Name)
+		}
+		names[in.Name] = true
+
+		switch in.GetType() {
+		case InputTypeString, InputTypeNumber, InputTypeBoolean:
+		default:
+			return fmt.Errorf("input %q: invalid type %q, must be string, number or boolean", in.Name, in.Type)
+		}
+		if in.Default != nil {
+			if in.Required {
+				return fmt.Errorf("input %q: a required input can't have a default", in.Name)
+			}
+			if _, err := in.coerce(in.Default); err != nil {
+				return fmt.Errorf("invalid default: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveInputs returns the values of g's inputs from those provided, with
+// defaults for the optional inputs that weren't. Optional inputs without a
+// default are left out. A required input that wasn't provided returns a
+// MissingInputsError naming every such input.
+func (g *Grimoire) ResolveInputs(provided map[string]interface{}) (map[string]interface{}, error) {
+	declared := make(map[string]bool, len(g.Inputs))
+	for _, in := range g.Inputs {
+		declared[in.Name] = true
+	}
+	var unknown []string
+	for name := range provided {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("grimoire %q has no input %q", g.Name, unknown[0])
+	}
+
+	values := make(map[string]interface{}, len(g.Inputs))
+	var missing []string
+	for i := range g.Inputs {
+		in := &g.Inputs[i]
+		value, ok := provided[in.Name]
+		switch {
+		case ok:
+		case in.Default != nil:
+			value = in.Default
+		case in.Required:
+			missing = append(missing, in.Name)
+			continue
+		default:
+			continue
+		}
+
+		coerced, err := in.coerce(value)
+		if err != nil {
+			return nil, fmt.Errorf("grimoire %q: %w", g.Name, err)
+		}
+		values[in.Name] = coerced
+	}
+	if len(missing) > 0 {
+		return nil, &MissingInputsError{Grimoire: g.Name, Inputs: missing}
+	}
+	return values, nil
+}
+
+// MissingInputsError is returned when a workflow is started without one or
+// more of its grimoire's required inputs.
+type MissingInputsError struct {
+	Grimoire string
+	Inputs   []string
+}
+
+func (e *MissingInputsError) Error() string {
+	return fmt.Sprintf("grimoire %q: missing required inputs: %s", e.Grimoire, strings.Join(e.Inputs, ", "))
+}
+
+// IsMissingInputs returns true if the error is a MissingInputsError.
+func IsMissingInputs(err error) bool {
+	_, ok := err.(*MissingInputsError)
+	return ok
+}
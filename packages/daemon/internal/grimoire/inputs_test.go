@@ -0,0 +1,177 @@
+package grimoire
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_Inputs(t *testing.T) {
+	g, err := Parse([]byte(`name: deploy
+description: Deploy a service
+inputs:
+  - name: environment
+    description: Where to deploy
+    required: true
+  - name: replicas
+    type: number
+    default: 2
+  - name: dry_run
+    type: boolean
+steps:
+  - name: deploy
+    type: script
+    command: "./deploy.sh {{.inputs.environment}}"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if len(g.Inputs) != 3 {
+		t.Fatalf("inputs = %d, want 3", len(g.Inputs))
+	}
+	if in := g.Inputs[0]; in.Name != "environment" || !in.Required || in.GetType() != InputTypeString {
+		t.Errorf("inputs[0] = %+v, want a required string", in)
+	}
+	if in := g.Inputs[1]; in.GetType() != InputTypeNumber || in.Default != 2 {
+		t.Errorf("inputs[1] = %+v, want a number defaulting to 2", in)
+	}
+}
+
+func TestParse_InvalidInputs(t *testing.T) {
+	_, err := Parse([]byte(`name: deploy
+description: Deploy a service
+inputs:
+  - name: environment
+    required: true
+    default: staging
+steps:
+  - name: deploy
+    type: script
+    command: ./deploy.sh
+`))
+	if err == nil || !strings.Contains(err.Error(), "a required input can't have a default") {
+		t.Errorf("Parse() error = %v, want an inputs validation error", err)
+	}
+}
+
+func TestGrimoire_Validate_Inputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  []Input
+		wantErr string
+	}{
+		{
+			name:   "valid inputs",
+			inputs: []Input{{Name: "environment", Required: true}, {Name: "replicas", Type: InputTypeNumber, Default: 2}},
+		},
+		{
+			name:    "invalid name",
+			inputs:  []Input{{Name: "target env"}},
+			wantErr: `invalid input name "target env"`,
+		},
+		{
+			name:    "duplicate name",
+			inputs:  []Input{{Name: "environment"}, {Name: "environment"}},
+			wantErr: `duplicate input "environment"`,
+		},
+		{
+			name:    "invalid type",
+			inputs:  []Input{{Name: "tags", Type: "list"}},
+			wantErr: `input "tags": invalid type "list"`,
+		},
+		{
+			name:    "required with default",
+			inputs:  []Input{{Name: "environment", Required: true, Default: "staging"}},
+			wantErr: "a required input can't have a default",
+		},
+		{
+			name:    "default of the wrong type",
+			inputs:  []Input{{Name: "replicas", Type: InputTypeNumber, Default: "many"}},
+			wantErr: `invalid default: input "replicas" must be a number`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Grimoire{
+				Name:   "deploy",
+				Inputs: tt.inputs,
+				Steps:  []Step{{Name: "deploy", Type: StepTypeScript, Command: "./deploy.sh"}},
+			}
+			err := g.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGrimoire_ResolveInputs(t *testing.T) {
+	g := &Grimoire{
+		Name: "deploy",
+		Inputs: []Input{
+			{Name: "environment", Required: true},
+			{Name: "replicas", Type: InputTypeNumber, Default: 2},
+			{Name: "dry_run", Type: InputTypeBoolean},
+		},
+	}
+
+	values, err := g.ResolveInputs(map[string]interface{}{"environment": "staging", "dry_run": "true"})
+	if err != nil {
+		t.Fatalf("ResolveInputs() error: %v", err)
+	}
+	if values["environment"] != "staging" || values["replicas"] != 2 || values["dry_run"] != true {
+		t.Errorf("values = %v, want provided values, defaults and parsed strings", values)
+	}
+
+	// Strings are parsed for number inputs too
+	values, err = g.ResolveInputs(map[string]interface{}{"environment": "prod", "replicas": "5"})
+	if err != nil || values["replicas"] != 5.0 {
+		t.Errorf("values = %v, %v; want replicas 5", values, err)
+	}
+	if _, ok := values["dry_run"]; ok {
+		t.Error("optional input without a default should be left out")
+	}
+}
+
+func TestGrimoire_ResolveInputs_Missing(t *testing.T) {
+	g := &Grimoire{
+		Name: "deploy",
+		Inputs: []Input{
+			{Name: "environment", Required: true},
+			{Name: "region", Required: true},
+			{Name: "replicas", Type: InputTypeNumber, Default: 2},
+		},
+	}
+
+	_, err := g.ResolveInputs(nil)
+	if !IsMissingInputs(err) {
+		t.Fatalf("ResolveInputs() error = %v, want MissingInputsError", err)
+	}
+	if missing := err.(*MissingInputsError).Inputs; strings.Join(missing, ",") != "environment,region" {
+		t.Errorf("missing inputs = %v, want environment and region", missing)
+	}
+}
+
+func TestGrimoire_ResolveInputs_Invalid(t *testing.T) {
+	g := &Grimoire{
+		Name:   "deploy",
+		Inputs: []Input{{Name: "replicas", Type: InputTypeNumber}},
+	}
+
+	_, err := g.ResolveInputs(map[string]interface{}{"replicas": "many"})
+	if err == nil || IsMissingInputs(err) || !strings.Contains(err.Error(), `input "replicas" must be a number`) {
+		t.Errorf("ResolveInputs() error = %v, want a type error", err)
+	}
+
+	_, err = g.ResolveInputs(map[string]interface{}{"replica": 3})
+	if err == nil || !strings.Contains(err.Error(), `has no input "replica"`) {
+		t.Errorf("ResolveInputs() error = %v, want an unknown input error", err)
+	}
+}
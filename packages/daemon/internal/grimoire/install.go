@@ -0,0 +1,113 @@
+package grimoire
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coven/daemon/internal/bundle"
+	"gopkg.in/yaml.v3"
+)
+
+// Install validates a bundle of grimoires and writes them to the user's
+// .coven/grimoires/ directory. The bundle is either a tar archive (optionally
+// gzipped) of .yaml/.yml files or a multi-document YAML stream.
+//
+// Every grimoire is validated before any are written. If one fails, nothing
+// is installed and an InvalidBundleError is returned alongside the per-item
+// results. Existing grimoires with the same name are replaced.
+func (l *Loader) Install(data []byte) ([]bundle.Result, error) {
+	items, err := readGrimoireBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, &bundle.FormatError{Err: fmt.Errorf("bundle contains no grimoires")}
+	}
+
+	results := make([]bundle.Result, len(items))
+	files := make([]bundle.File, 0, len(items))
+	seen := make(map[string]bool)
+	for i, item := range items {
+		name, err := l.validateBundleGrimoire(item.Data)
+		if name == "" {
+			name = item.Name
+		}
+		if err == nil && seen[name] {
+			err = fmt.Errorf("duplicate grimoire name %q in bundle", name)
+		}
+		seen[name] = true
+
+		results[i] = bundle.Result{Name: name, Success: err == nil}
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		files = append(files, bundle.File{Name: name + ".yaml", Data: item.Data})
+	}
+
+	if err := bundle.CheckResults(results); err != nil {
+		for i := range results {
+			if results[i].Success {
+				results[i].Success = false
+				results[i].Error = "not installed: other grimoires in the bundle are invalid"
+			}
+		}
+		return results, err
+	}
+
+	if err := bundle.WriteAll(filepath.Join(l.covenDir, "grimoires"), files); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// readGrimoireBundle extracts the grimoire documents from a bundle. Items read
+// from an archive are named after their file; YAML documents are numbered.
+func readGrimoireBundle(data []byte) ([]bundle.File, error) {
+	if bundle.IsArchive(data) {
+		files, err := bundle.ReadArchive(data, ".yaml", ".yml")
+		if err != nil {
+			return nil, err
+		}
+		for i := range files {
+			ext := filepath.Ext(files[i].Name)
+			files[i].Name = strings.TrimSuffix(files[i].Name, ext)
+		}
+		return files, nil
+	}
+
+	docs, err := bundle.SplitYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]bundle.File, len(docs))
+	for i, doc := range docs {
+		files[i] = bundle.File{Name: fmt.Sprintf("document %d", i+1), Data: doc}
+	}
+	return files, nil
+}
+
+// validateBundleGrimoire parses and validates a single grimoire, returning its
+// name when one could be read even if validation failed. Includes and extends
+// are resolved from the user's grimoire directory, so a grimoire may include
+// fragments and extend grimoires that are already installed.
+func (l *Loader) validateBundleGrimoire(data []byte) (string, error) {
+	var header struct {
+		Name string `yaml:"name"`
+	}
+	yaml.Unmarshal(data, &header)
+
+	data, err := l.resolveContent(data, l.userDir(), false, []string{header.Name})
+	if err != nil {
+		return header.Name, err
+	}
+	g, err := ParseWithOptions(data, l.options)
+	if err != nil {
+		return header.Name, err
+	}
+	if err := validateGrimoireName(g.Name); err != nil {
+		return "", err
+	}
+	return g.Name, nil
+}
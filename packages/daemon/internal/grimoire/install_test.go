@@ -0,0 +1,163 @@
+package grimoire
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/bundle"
+)
+
+const installBundleYAML = `name: first
+description: First grimoire
+steps:
+  - name: test
+    type: script
+    command: npm test
+---
+name: second
+description: Second grimoire
+steps:
+  - name: implement
+    type: agent
+    spell: implement
+`
+
+// makeGrimoireTarball builds a gzipped tar archive from name/content pairs.
+func makeGrimoireTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader() error: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("tar Close() error: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip Close() error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInstall_MultiDocumentYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	loader := NewLoader(tmpDir)
+
+	results, err := loader.Install([]byte(installBundleYAML))
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Install() returned %d results, want 2", len(results))
+	}
+	for i, want := range []string{"first", "second"} {
+		if results[i].Name != want || !results[i].Success {
+			t.Errorf("results[%d] = %+v, want successful %q", i, results[i], want)
+		}
+	}
+
+	g, err := loader.Load("second")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if g.Source != SourceUser || g.Description != "Second grimoire" {
+		t.Errorf("installed grimoire = %+v", g)
+	}
+}
+
+func TestInstall_Tarball(t *testing.T) {
+	tmpDir := t.TempDir()
+	loader := NewLoader(tmpDir)
+
+	data := makeGrimoireTarball(t, map[string]string{
+		"grimoires/release.yml": "name: release\ndescription: Release\nsteps:\n  - name: tag\n    type: script\n    command: git tag\n",
+		"grimoires/README.md":   "not a grimoire",
+	})
+
+	results, err := loader.Install(data)
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "release" || !results[0].Success {
+		t.Fatalf("Install() results = %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "grimoires", "release.yaml")); err != nil {
+		t.Errorf("release.yaml not installed: %v", err)
+	}
+}
+
+func TestInstall_InvalidGrimoireAbortsImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	loader := NewLoader(tmpDir)
+
+	data := installBundleYAML + `---
+name: broken
+description: Missing steps
+`
+	results, err := loader.Install([]byte(data))
+	if !bundle.IsInvalidBundle(err) {
+		t.Fatalf("Install() error = %v, want InvalidBundleError", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Install() returned %d results, want 3", len(results))
+	}
+	for _, r := range results {
+		if r.Success {
+			t.Errorf("result %q should not be successful", r.Name)
+		}
+	}
+	if results[2].Name != "broken" || !strings.Contains(results[2].Error, "steps") {
+		t.Errorf("results[2] = %+v, want validation error for broken", results[2])
+	}
+
+	// Nothing from the bundle should have been written
+	if _, err := os.Stat(filepath.Join(tmpDir, "grimoires")); !os.IsNotExist(err) {
+		entries, _ := os.ReadDir(filepath.Join(tmpDir, "grimoires"))
+		t.Errorf("grimoires directory should not be created, found %d entries", len(entries))
+	}
+}
+
+func TestInstall_DuplicateNames(t *testing.T) {
+	loader := NewLoader(t.TempDir())
+
+	data := installBundleYAML + "---\n" + strings.SplitN(installBundleYAML, "---\n", 2)[0]
+	results, err := loader.Install([]byte(data))
+	if !bundle.IsInvalidBundle(err) {
+		t.Fatalf("Install() error = %v, want InvalidBundleError", err)
+	}
+	if !strings.Contains(results[2].Error, "duplicate") {
+		t.Errorf("results[2].Error = %q, want duplicate name error", results[2].Error)
+	}
+}
+
+func TestInstall_MalformedBundle(t *testing.T) {
+	loader := NewLoader(t.TempDir())
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"invalid yaml", "name: [unclosed\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loader.Install([]byte(tt.data))
+			if !bundle.IsFormatError(err) {
+				t.Errorf("Install() error = %v, want FormatError", err)
+			}
+		})
+	}
+}
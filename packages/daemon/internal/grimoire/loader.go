@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -31,6 +32,9 @@ type Loader struct {
 
 	// grimoiresSubdir is the subdirectory within the embedded FS where grimoires are stored.
 	grimoiresSubdir string
+
+	// options configure how loaded grimoires are validated.
+	options Options
 }
 
 // NewLoader creates a new grimoire loader.
@@ -53,16 +57,23 @@ func NewLoaderWithBuiltins(covenDir string, builtinFS fs.FS, grimoiresSubdir str
 	}
 }
 
+// SetOptions sets how the grimoires the loader loads, validates and installs
+// are validated.
+func (l *Loader) SetOptions(opts Options) {
+	l.options = opts
+}
+
+// Options returns how the loader validates grimoires.
+func (l *Loader) Options() Options {
+	return l.options
+}
+
 // Load loads a grimoire by name.
 // It first looks in the user's .coven/grimoires/ directory, then falls back to built-in grimoires.
 // Returns an error if the grimoire is not found in either location.
 func (l *Loader) Load(name string) (*Grimoire, error) {
-	// Validate grimoire name
-	if name == "" {
-		return nil, fmt.Errorf("grimoire name cannot be empty")
-	}
-	if strings.ContainsAny(name, "/\\") {
-		return nil, fmt.Errorf("grimoire name cannot contain path separators: %q", name)
+	if err := validateGrimoireName(name); err != nil {
+		return nil, err
 	}
 
 	// Try user grimoires first
@@ -86,21 +97,78 @@ func (l *Loader) Load(name string) (*Grimoire, error) {
 	return nil, &GrimoireNotFoundError{Name: name}
 }
 
+// LoadContent returns the YAML content of a grimoire by name, with its
+// includes and the grimoire it extends resolved. It uses the same lookup
+// order as Load: user grimoires first, then built-in.
+func (l *Loader) LoadContent(name string) ([]byte, error) {
+	if err := validateGrimoireName(name); err != nil {
+		return nil, err
+	}
+
+	data, dir, builtin, err := l.readGrimoire(name, false)
+	if err != nil {
+		return nil, err
+	}
+	return l.resolveContent(data, dir, builtin, []string{name})
+}
+
+// readGrimoire returns the content of a grimoire by name as written, the
+// directory it includes fragments from, and whether it is built-in. It looks
+// in the same order as Load, or only at built-in grimoires if builtinOnly is
+// set.
+func (l *Loader) readGrimoire(name string, builtinOnly bool) ([]byte, fs.FS, bool, error) {
+	if !builtinOnly {
+		data, err := readGrimoireFile(l.userDir(), name)
+		if err == nil {
+			return data, l.userDir(), false, nil
+		}
+		if !isNotExistError(err) {
+			return nil, nil, false, fmt.Errorf("failed to read user grimoire %q: %w", name, err)
+		}
+	}
+
+	if l.builtinFS != nil {
+		data, err := readGrimoireFile(l.builtinDir(), name)
+		if err == nil {
+			return data, l.builtinDir(), true, nil
+		}
+		if !isNotExistError(err) {
+			return nil, nil, false, fmt.Errorf("failed to read builtin grimoire %q: %w", name, err)
+		}
+	}
+
+	return nil, nil, false, &GrimoireNotFoundError{Name: name}
+}
+
+// validateGrimoireName checks that a grimoire name is safe to use as a file name.
+func validateGrimoireName(name string) error {
+	if name == "" {
+		return fmt.Errorf("grimoire name cannot be empty")
+	}
+	if strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("grimoire name cannot contain path separators: %q", name)
+	}
+	return nil
+}
+
 // loadUserGrimoire loads a grimoire from the user's .coven/grimoires/ directory.
 func (l *Loader) loadUserGrimoire(name string) (*Grimoire, error) {
-	grimoirePath := filepath.Join(l.covenDir, "grimoires", name+".yaml")
-
-	data, err := os.ReadFile(grimoirePath)
+	data, err := readGrimoireFile(l.userDir(), name)
 	if err != nil {
 		return nil, err
 	}
 
-	grimoire, err := Parse(data)
+	resolved, err := l.resolveContent(data, l.userDir(), false, []string{name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse grimoire %q: %w", name, err)
+	}
+	grimoire, err := ParseWithOptions(resolved, l.options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse grimoire %q: %w", name, err)
 	}
 
 	grimoire.Source = SourceUser
+	grimoire.Extends = readExtends(data)
 	return grimoire, nil
 }
 
@@ -110,22 +178,62 @@ func (l *Loader) loadBuiltinGrimoire(name string) (*Grimoire, error) {
 		return nil, fs.ErrNotExist
 	}
 
-	grimoirePath := filepath.Join(l.grimoiresSubdir, name+".yaml")
-
-	data, err := fs.ReadFile(l.builtinFS, grimoirePath)
+	data, err := readGrimoireFile(l.builtinDir(), name)
 	if err != nil {
 		return nil, err
 	}
 
-	grimoire, err := Parse(data)
+	resolved, err := l.resolveContent(data, l.builtinDir(), true, []string{name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse builtin grimoire %q: %w", name, err)
+	}
+	grimoire, err := ParseWithOptions(resolved, l.options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse builtin grimoire %q: %w", name, err)
 	}
 
 	grimoire.Source = SourceBuiltIn
+	grimoire.Extends = readExtends(data)
 	return grimoire, nil
 }
 
+// grimoireExtensions are the extensions of grimoire files, in the order a
+// grimoire's file is looked for.
+var grimoireExtensions = []string{".yaml", ".yml"}
+
+// readGrimoireFile reads the file of the named grimoire in dir, name.yaml or
+// else name.yml.
+func readGrimoireFile(dir fs.FS, name string) ([]byte, error) {
+	if dir == nil {
+		return nil, fs.ErrNotExist
+	}
+	var err error
+	for _, ext := range grimoireExtensions {
+		var data []byte
+		data, err = fs.ReadFile(dir, name+ext)
+		if !isNotExistError(err) {
+			return data, err
+		}
+	}
+	return nil, err
+}
+
+// userDir returns the user's grimoire directory, which user grimoires
+// include fragments from.
+func (l *Loader) userDir() fs.FS {
+	return os.DirFS(filepath.Join(l.covenDir, "grimoires"))
+}
+
+// builtinDir returns the built-in grimoire directory, which built-in
+// grimoires include fragments from.
+func (l *Loader) builtinDir() fs.FS {
+	dir, err := fs.Sub(l.builtinFS, l.grimoiresSubdir)
+	if err != nil {
+		return nil
+	}
+	return dir
+}
+
 // List returns all available grimoire names.
 // User grimoires with the same name as built-in grimoires will only appear once.
 func (l *Loader) List() ([]string, error) {
@@ -212,22 +320,51 @@ func (l *Loader) listBuiltinGrimoires() ([]string, error) {
 	return names, nil
 }
 
-// Parse parses grimoire YAML data and validates it.
+// Parse parses grimoire YAML data and validates it with the default options.
+// Includes and extends must already be resolved, as they are by Loader, since
+// data has no grimoire directory to resolve them in.
 func Parse(data []byte) (*Grimoire, error) {
+	return ParseWithOptions(data, Options{})
+}
+
+// ParseWithOptions parses grimoire YAML data like Parse and validates it with
+// opts.
+func ParseWithOptions(data []byte, opts Options) (*Grimoire, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	if _, err := spliceIncludes(&node, nil, nil); err != nil {
+		return nil, err
+	}
+	if err := checkUnresolvedExtends(&node); err != nil {
+		return nil, err
+	}
+	if err := interpolateNode(&node, opts.envPrefixes()); err != nil {
+		return nil, err
+	}
+
 	var grimoire Grimoire
-	if err := yaml.Unmarshal(data, &grimoire); err != nil {
+	if err := node.Decode(&grimoire); err != nil {
 		return nil, &ParseError{Err: err}
 	}
 
-	if err := Validate(&grimoire); err != nil {
+	if err := ValidateWithOptions(&grimoire, opts); err != nil {
 		return nil, err
 	}
 
+	grimoire.ContentHash = ContentHash(data)
 	return &grimoire, nil
 }
 
-// Validate validates a grimoire's structure and fields.
+// Validate validates a grimoire's structure and fields with the default
+// options.
 func Validate(g *Grimoire) error {
+	return ValidateWithOptions(g, Options{})
+}
+
+// ValidateWithOptions validates a grimoire's structure and fields with opts.
+func ValidateWithOptions(g *Grimoire, opts Options) error {
 	if g.Name == "" {
 		return &ValidationError{Field: "name", Message: "grimoire name is required"}
 	}
@@ -240,19 +377,87 @@ func Validate(g *Grimoire) error {
 		return &ValidationError{Field: "steps", Message: "grimoire must have at least one step"}
 	}
 
+	if g.StepTimeout != "" {
+		if _, err := time.ParseDuration(g.StepTimeout); err != nil {
+			return &ValidationError{Field: "step_timeout", Message: fmt.Sprintf("invalid duration %q: %v", g.StepTimeout, err)}
+		}
+	}
+
+	if g.EnvFile != "" {
+		if err := validateEnvFile(g.EnvFile); err != nil {
+			return &ValidationError{Field: "env_file", Message: err.Error()}
+		}
+	}
+
+	if g.Sandbox != nil {
+		if err := g.Sandbox.validate(); err != nil {
+			return &ValidationError{Field: "sandbox", Message: err.Error()}
+		}
+	}
+
+	if err := g.validateTags(); err != nil {
+		return &ValidationError{Field: "tags", Message: err.Error()}
+	}
+
+	if err := g.validateInputs(); err != nil {
+		return &ValidationError{Field: "inputs", Message: err.Error()}
+	}
+
+	if strings.ContainsAny(g.ConcurrencyGroup, " \t\n") {
+		return &ValidationError{Field: "concurrency_group", Message: fmt.Sprintf("%q must not contain whitespace", g.ConcurrencyGroup)}
+	}
+
+	if err := g.validateSparsePaths(); err != nil {
+		return &ValidationError{Field: "sparse_paths", Message: err.Error()}
+	}
+
+	if g.PostMerge != nil {
+		if err := g.PostMerge.validate(); err != nil {
+			return &ValidationError{Field: "post_merge", Message: err.Error()}
+		}
+	}
+
+	// Check nesting before validating steps, which recurses into loops
+	if err := checkNestingDepth(g.Prepare, opts.NestingDepth()); err != nil {
+		return &ValidationError{Field: "prepare", Message: err.Error()}
+	}
+	if err := checkNestingDepth(g.Steps, opts.NestingDepth()); err != nil {
+		return &ValidationError{Field: "steps", Message: err.Error()}
+	}
+
+	if err := g.validatePrepare(); err != nil {
+		return &ValidationError{Field: "prepare", Message: err.Error()}
+	}
+
 	// Validate each step
 	for i := range g.Steps {
 		if err := g.Steps[i].Validate(); err != nil {
-			return fmt.Errorf("step %d: %w", i, err)
+			return &StepValidationError{Index: i, Step: g.Steps[i].Name, Err: err}
 		}
 	}
 
-	// Check for duplicate step names
+	// Check for duplicate step names, including prepare steps
 	stepNames := make(map[string]bool)
+	if err := checkDuplicateStepNames(g.Prepare, stepNames, ""); err != nil {
+		return err
+	}
 	if err := checkDuplicateStepNames(g.Steps, stepNames, ""); err != nil {
 		return err
 	}
 
+	if err := checkLoopOutputNames(g.Prepare, g.Steps); err != nil {
+		return &ValidationError{Field: "steps", Message: err.Error()}
+	}
+
+	// Check merge step placement, reporting problems at the configured severity
+	g.Warnings = nil
+	for _, diagnostic := range checkMergePlacement(g) {
+		if opts.mergePlacement() == SeverityError {
+			return &ValidationError{Field: "steps", Message: diagnostic}
+		}
+		g.Warnings = append(g.Warnings, diagnostic)
+	}
+
 	return nil
 }
 
@@ -272,8 +477,8 @@ func checkDuplicateStepNames(steps []Step, seen map[string]bool, prefix string)
 		}
 		seen[step.Name] = true
 
-		// Check nested steps in loops
-		if step.Type == StepTypeLoop && len(step.Steps) > 0 {
+		// Check nested steps in loops and parallel steps
+		if (step.Type == StepTypeLoop || step.Type == StepTypeParallel) && len(step.Steps) > 0 {
 			if err := checkDuplicateStepNames(step.Steps, seen, fullName); err != nil {
 				return err
 			}
@@ -332,6 +537,21 @@ func IsValidationError(err error) bool {
 	return ok
 }
 
+// StepValidationError is returned when one of a grimoire's steps is invalid.
+type StepValidationError struct {
+	Index int
+	Step  string
+	Err   error
+}
+
+func (e *StepValidationError) Error() string {
+	return fmt.Sprintf("step %d: %v", e.Index, e.Err)
+}
+
+func (e *StepValidationError) Unwrap() error {
+	return e.Err
+}
+
 // isNotExistError checks if an error indicates a file/directory doesn't exist.
 func isNotExistError(err error) bool {
 	if os.IsNotExist(err) {
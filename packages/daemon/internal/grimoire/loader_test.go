@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 )
 
 func TestNewLoader(t *testing.T) {
@@ -145,6 +146,23 @@ steps:
 	}
 }
 
+func TestLoad_YmlGrimoire(t *testing.T) {
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{
+		"lint.yml": "name: lint\ndescription: Lints\nsteps:\n  - name: lint\n    type: script\n    command: make lint\n",
+	})
+	builtinFS := fstest.MapFS{
+		"grimoires/check.yml": &fstest.MapFile{Data: []byte("name: check\ndescription: Checks\nsteps:\n  - name: check\n    type: script\n    command: make check\n")},
+	}
+	loader := NewLoaderWithBuiltins(covenDir, builtinFS, "grimoires")
+
+	for _, name := range []string{"lint", "check"} {
+		if g, err := loader.Load(name); err != nil || g.Name != name {
+			t.Errorf("Load(%q) = %v, %v; want the .yml grimoire", name, g, err)
+		}
+	}
+}
+
 func TestLoad_NotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -517,6 +535,30 @@ func TestValidate_DuplicateNestedStepNames(t *testing.T) {
 	}
 }
 
+func TestValidate_OutputNamedAfterLoop(t *testing.T) {
+	grimoire := &Grimoire{
+		Name:        "test",
+		Description: "test",
+		Steps: []Step{
+			{
+				Name: "fix",
+				Type: StepTypeLoop,
+				Steps: []Step{
+					{Name: "check", Type: StepTypeScript, Command: "make check", Output: "fix"},
+				},
+			},
+		},
+	}
+
+	err := Validate(grimoire)
+	if err == nil {
+		t.Fatal("Validate() should return error for an output named after a loop")
+	}
+	if !strings.Contains(err.Error(), `output name "fix" is the name of a loop step`) {
+		t.Errorf("Error = %q, want to name the loop", err.Error())
+	}
+}
+
 func TestIsNotFound(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -674,3 +716,187 @@ func TestLoad_NoBuiltins(t *testing.T) {
 		t.Errorf("Expected GrimoireNotFoundError, got: %v", err)
 	}
 }
+
+func TestLoadContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	grimoiresDir := filepath.Join(tmpDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+
+	userYAML := "name: user-grimoire\ndescription: User\nsteps:\n  - name: test\n    type: script\n    command: npm test\n"
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "user-grimoire.yaml"), []byte(userYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	builtinYAML := "name: builtin-grimoire\ndescription: Builtin\nsteps:\n  - name: test\n    type: script\n    command: make test\n"
+	builtinFS := fstest.MapFS{
+		"grimoires/builtin-grimoire.yaml": &fstest.MapFile{Data: []byte(builtinYAML)},
+	}
+
+	loader := NewLoaderWithBuiltins(tmpDir, builtinFS, "grimoires")
+
+	data, err := loader.LoadContent("user-grimoire")
+	if err != nil {
+		t.Fatalf("LoadContent(user) error: %v", err)
+	}
+	if string(data) != userYAML {
+		t.Errorf("LoadContent(user) = %q, want %q", data, userYAML)
+	}
+
+	data, err = loader.LoadContent("builtin-grimoire")
+	if err != nil {
+		t.Fatalf("LoadContent(builtin) error: %v", err)
+	}
+	if string(data) != builtinYAML {
+		t.Errorf("LoadContent(builtin) = %q, want %q", data, builtinYAML)
+	}
+
+	if _, err := loader.LoadContent("missing"); !IsNotFound(err) {
+		t.Errorf("LoadContent(missing) error = %v, want GrimoireNotFoundError", err)
+	}
+	if _, err := loader.LoadContent("../escape"); err == nil {
+		t.Error("LoadContent should reject path separators")
+	}
+}
+
+func TestParse_SetsContentHash(t *testing.T) {
+	data := []byte("name: hashed\ndescription: Hashed\nsteps:\n  - name: test\n    type: script\n    command: npm test\n")
+
+	g, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	if g.ContentHash != ContentHash(data) {
+		t.Errorf("ContentHash = %q, want %q", g.ContentHash, ContentHash(data))
+	}
+}
+
+func TestParse_ConcurrencyGroup(t *testing.T) {
+	yaml := `
+name: migrate
+description: Runs database migrations
+concurrency_group: db-migrations
+steps:
+  - name: migrate
+    type: script
+    command: make migrate
+`
+	g, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if g.ConcurrencyGroup != "db-migrations" {
+		t.Errorf("ConcurrencyGroup = %q, want %q", g.ConcurrencyGroup, "db-migrations")
+	}
+
+	_, err = Parse([]byte(strings.Replace(yaml, "db-migrations", `"db migrations"`, 1)))
+	if !IsValidationError(err) {
+		t.Errorf("Parse() error = %v, want a validation error", err)
+	}
+}
+
+func TestParse_SparsePaths(t *testing.T) {
+	yaml := `
+name: api
+description: Works on the API package
+sparse_paths:
+  - packages/api
+steps:
+  - name: test
+    type: script
+    command: make test
+`
+	g, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(g.SparsePaths) != 1 || g.SparsePaths[0] != "packages/api" {
+		t.Errorf("SparsePaths = %v, want [packages/api]", g.SparsePaths)
+	}
+
+	_, err = Parse([]byte(strings.Replace(yaml, "packages/api", `"../api"`, 1)))
+	if !IsValidationError(err) {
+		t.Errorf("Parse() error = %v, want a validation error", err)
+	}
+}
+
+func TestParse_PostMerge(t *testing.T) {
+	yaml := `
+name: close-out
+description: Comments on the bead after merging
+post_merge:
+  command: bd comment {{.bead_id}} "merged in {{.merge_commit}}"
+  timeout: 30s
+steps:
+  - name: test
+    type: script
+    command: make test
+`
+	g, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if g.PostMerge == nil || g.PostMerge.Command != `bd comment {{.bead_id}} "merged in {{.merge_commit}}"` {
+		t.Fatalf("PostMerge = %+v, want the bd comment command", g.PostMerge)
+	}
+	if timeout, err := g.PostMerge.GetTimeout(); err != nil || timeout != 30*time.Second {
+		t.Errorf("GetTimeout() = %v, %v, want 30s", timeout, err)
+	}
+
+	_, err = Parse([]byte(strings.Replace(yaml, "timeout: 30s", "timeout: later", 1)))
+	if !IsValidationError(err) {
+		t.Errorf("Parse() error = %v, want a validation error", err)
+	}
+}
+
+func TestParse_Prepare(t *testing.T) {
+	yaml := `
+name: node-ci
+description: Installs dependencies before testing
+prepare_timeout: 3m
+prepare:
+  - name: install
+    type: script
+    command: npm ci
+steps:
+  - name: test
+    type: script
+    command: npm test
+`
+	g, err := Parse([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(g.Prepare) != 1 || g.Prepare[0].Name != "install" {
+		t.Fatalf("Prepare = %+v, want the install step", g.Prepare)
+	}
+	if timeout, _ := g.GetPrepareTimeout(); timeout != 3*time.Minute {
+		t.Errorf("GetPrepareTimeout() = %v, want 3m", timeout)
+	}
+
+	invalid := []struct {
+		name    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{name: "agent step", old: "type: script\n    command: npm ci", new: "type: agent\n    spell: install", wantErr: "only script steps"},
+		{name: "on_fail", old: "command: npm ci", new: "command: npm ci\n    on_fail: continue", wantErr: "on_fail and on_success are not supported"},
+		{name: "confirm", old: "command: npm ci", new: "command: npm ci\n    confirm: true", wantErr: "confirm is not supported"},
+		{name: "invalid timeout", old: "prepare_timeout: 3m", new: "prepare_timeout: soon", wantErr: "invalid prepare_timeout"},
+		{name: "name shared with a step", old: "name: install", new: "name: test", wantErr: `duplicate step name "test"`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(strings.Replace(yaml, tt.old, tt.new, 1)))
+			if !IsValidationError(err) {
+				t.Fatalf("Parse() error = %v, want a validation error", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package grimoire
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxMatrixVariants is the most combinations a step's matrix may expand to.
+const MaxMatrixVariants = 64
+
+// MatrixVariant is one combination of matrix values, keyed by dimension name.
+type MatrixVariant map[string]interface{}
+
+// Label describes the variant as "key=value" pairs in key order,
+// e.g. "node=20, os=linux".
+func (v MatrixVariant) Label() string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, v[k])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// MatrixVariants expands the step's matrix into every combination of its
+// values. Dimensions are combined in key order, with the last key varying
+// fastest, and values keep their listed order. Returns nil if the step has no
+// matrix.
+func (s *Step) MatrixVariants() []MatrixVariant {
+	if len(s.Matrix) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(s.Matrix))
+	for k := range s.Matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	variants := []MatrixVariant{{}}
+	for _, key := range keys {
+		var expanded []MatrixVariant
+		for _, base := range variants {
+			for _, value := range s.Matrix[key] {
+				variant := make(MatrixVariant, len(base)+1)
+				for k, v := range base {
+					variant[k] = v
+				}
+				variant[key] = value
+				expanded = append(expanded, variant)
+			}
+		}
+		variants = expanded
+	}
+	return variants
+}
+
+// validateMatrix checks that a step's matrix can be expanded.
+func (s *Step) validateMatrix() error {
+	if len(s.Matrix) == 0 {
+		return nil
+	}
+
+	if s.Type != StepTypeScript && s.Type != StepTypeAgent {
+		return fmt.Errorf("step %q: matrix is only valid on script and agent steps", s.Name)
+	}
+
+	total := 1
+	for key, values := range s.Matrix {
+		if !isMatrixKey(key) {
+			return fmt.Errorf("step %q: matrix key %q may only contain letters, digits and '_'", s.Name, key)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("step %q: matrix key %q has no values", s.Name, key)
+		}
+		total *= len(values)
+		if total > MaxMatrixVariants {
+			return fmt.Errorf("step %q: matrix expands to more than %d variants", s.Name, MaxMatrixVariants)
+		}
+	}
+	return nil
+}
+
+// isMatrixKey reports whether a matrix key can be used in a template path.
+func isMatrixKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package grimoire
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStep_MatrixVariants(t *testing.T) {
+	step := Step{
+		Name:    "test",
+		Type:    StepTypeScript,
+		Command: "make test",
+		Matrix: map[string][]interface{}{
+			"os":   {"linux", "macos"},
+			"node": {18, 20, 22},
+		},
+	}
+
+	variants := step.MatrixVariants()
+	want := []string{
+		"node=18, os=linux",
+		"node=18, os=macos",
+		"node=20, os=linux",
+		"node=20, os=macos",
+		"node=22, os=linux",
+		"node=22, os=macos",
+	}
+	if len(variants) != len(want) {
+		t.Fatalf("variants = %d, want %d", len(variants), len(want))
+	}
+	for i, v := range variants {
+		if got := v.Label(); got != want[i] {
+			t.Errorf("variants[%d] = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestParse_Matrix(t *testing.T) {
+	g, err := Parse([]byte(`name: matrix-grimoire
+description: Test across Node versions
+steps:
+  - name: test
+    type: script
+    command: "nvm use {{.matrix.node}} && npm test"
+    matrix:
+      node: [18, 20, 22]
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	variants := g.Steps[0].MatrixVariants()
+	if len(variants) != 3 {
+		t.Fatalf("variants = %d, want 3", len(variants))
+	}
+	if variants[1]["node"] != 20 {
+		t.Errorf("variants[1].node = %v (%T), want 20", variants[1]["node"], variants[1]["node"])
+	}
+}
+
+func TestStep_MatrixVariants_NoMatrix(t *testing.T) {
+	step := Step{Name: "test", Type: StepTypeScript, Command: "make test"}
+	if variants := step.MatrixVariants(); variants != nil {
+		t.Errorf("MatrixVariants() = %v, want nil", variants)
+	}
+}
+
+func TestStep_Validate_Matrix(t *testing.T) {
+	tooMany := make([]interface{}, MaxMatrixVariants+1)
+	for i := range tooMany {
+		tooMany[i] = i
+	}
+
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr string
+	}{
+		{
+			name: "valid script matrix",
+			step: Step{Name: "test", Type: StepTypeScript, Command: "make test", Matrix: map[string][]interface{}{"node": {18, 20}}},
+		},
+		{
+			name: "valid agent matrix",
+			step: Step{Name: "review", Type: StepTypeAgent, Spell: "review", Matrix: map[string][]interface{}{"area": {"api", "ui"}}},
+		},
+		{
+			name:    "matrix on merge step",
+			step:    Step{Name: "merge", Type: StepTypeMerge, Matrix: map[string][]interface{}{"node": {18}}},
+			wantErr: "matrix is only valid on script and agent steps",
+		},
+		{
+			name:    "empty values",
+			step:    Step{Name: "test", Type: StepTypeScript, Command: "make test", Matrix: map[string][]interface{}{"node": {}}},
+			wantErr: `matrix key "node" has no values`,
+		},
+		{
+			name:    "invalid key",
+			step:    Step{Name: "test", Type: StepTypeScript, Command: "make test", Matrix: map[string][]interface{}{"node-version": {18}}},
+			wantErr: `matrix key "node-version" may only contain`,
+		},
+		{
+			name:    "too many variants",
+			step:    Step{Name: "test", Type: StepTypeScript, Command: "make test", Matrix: map[string][]interface{}{"n": tooMany}},
+			wantErr: "matrix expands to more than",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.step.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package grimoire
+
+import "fmt"
+
+// Severity controls how a validation diagnostic is reported.
+type Severity string
+
+const (
+	// SeverityWarning records the diagnostic in Grimoire.Warnings and accepts the grimoire.
+	SeverityWarning Severity = "warning"
+
+	// SeverityError rejects the grimoire with a ValidationError.
+	SeverityError Severity = "error"
+)
+
+// IsValidSeverity returns true if the severity is a known value.
+func IsValidSeverity(s Severity) bool {
+	return s == SeverityWarning || s == SeverityError
+}
+
+// checkMergePlacement returns a diagnostic for each misplaced merge step.
+// A grimoire should merge at most once, and only script steps (cleanup,
+// notifications) should follow the merge, since the worktree is merged and
+// removed once it is approved.
+func checkMergePlacement(g *Grimoire) []string {
+	var diagnostics []string
+
+	var merges []string
+	collectMergeSteps(g.Steps, &merges)
+	if len(merges) > 1 {
+		diagnostics = append(diagnostics, fmt.Sprintf("grimoire has %d merge steps %q, expected at most one", len(merges), merges))
+	}
+
+	mergeStep := ""
+	for _, step := range g.Steps {
+		if mergeStep == "" {
+			if step.Type == StepTypeMerge {
+				mergeStep = step.Name
+			}
+			continue
+		}
+		if step.Type != StepTypeScript {
+			diagnostics = append(diagnostics, fmt.Sprintf(
+				"%s step %q runs after merge step %q; only script cleanup steps should follow a merge",
+				step.Type, step.Name, mergeStep))
+		}
+	}
+
+	return diagnostics
+}
+
+// collectMergeSteps appends the names of all merge steps, including those
+// nested in loops.
+func collectMergeSteps(steps []Step, names *[]string) {
+	for _, step := range steps {
+		if step.Type == StepTypeMerge {
+			*names = append(*names, step.Name)
+		}
+		collectMergeSteps(step.Steps, names)
+	}
+}
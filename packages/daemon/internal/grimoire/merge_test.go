@@ -0,0 +1,140 @@
+package grimoire
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func twoMergeGrimoire() *Grimoire {
+	return &Grimoire{
+		Name:        "test",
+		Description: "test",
+		Steps: []Step{
+			{Name: "implement", Type: StepTypeAgent, Spell: "implement"},
+			{Name: "merge-once", Type: StepTypeMerge},
+			{Name: "merge-twice", Type: StepTypeMerge},
+		},
+	}
+}
+
+func TestValidate_MultipleMergeStepsWarning(t *testing.T) {
+	g := twoMergeGrimoire()
+	if err := ValidateWithOptions(g, Options{MergePlacement: SeverityWarning}); err != nil {
+		t.Fatalf("Validate() error = %v, want warnings only", err)
+	}
+
+	if len(g.Warnings) != 2 {
+		t.Fatalf("Warnings = %q, want 2", g.Warnings)
+	}
+	if !strings.Contains(g.Warnings[0], "2 merge steps") {
+		t.Errorf("Warnings[0] = %q, want to mention 2 merge steps", g.Warnings[0])
+	}
+	if !strings.Contains(g.Warnings[1], `"merge-twice" runs after merge step "merge-once"`) {
+		t.Errorf("Warnings[1] = %q, want to flag the step after the merge", g.Warnings[1])
+	}
+}
+
+func TestValidate_MultipleMergeStepsError(t *testing.T) {
+	err := ValidateWithOptions(twoMergeGrimoire(), Options{MergePlacement: SeverityError})
+	if !IsValidationError(err) {
+		t.Fatalf("Validate() error = %v, want ValidationError", err)
+	}
+	if !strings.Contains(err.Error(), "2 merge steps") {
+		t.Errorf("Error = %q, want to mention 2 merge steps", err.Error())
+	}
+}
+
+func TestValidate_MergePlacement(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		steps    []Step
+		warnings int
+	}{
+		{
+			name: "merge last",
+			steps: []Step{
+				{Name: "implement", Type: StepTypeAgent, Spell: "implement"},
+				{Name: "merge", Type: StepTypeMerge},
+			},
+		},
+		{
+			name: "cleanup script after merge",
+			steps: []Step{
+				{Name: "merge", Type: StepTypeMerge},
+				{Name: "notify", Type: StepTypeScript, Command: "echo merged"},
+			},
+		},
+		{
+			name: "agent after merge",
+			steps: []Step{
+				{Name: "merge", Type: StepTypeMerge},
+				{Name: "review", Type: StepTypeAgent, Spell: "review"},
+			},
+			warnings: 1,
+		},
+		{
+			name: "merge inside loop and at top level",
+			steps: []Step{
+				{Name: "retry", Type: StepTypeLoop, Steps: []Step{
+					{Name: "inner-merge", Type: StepTypeMerge},
+				}},
+				{Name: "merge", Type: StepTypeMerge},
+			},
+			warnings: 1,
+		},
+		{
+			name: "no merge",
+			steps: []Step{
+				{Name: "analyze", Type: StepTypeScript, Command: "make analyze"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &Grimoire{Name: "test", Description: "test", Steps: tt.steps}
+			if err := Validate(g); err != nil {
+				t.Fatalf("Validate() error: %v", err)
+			}
+			if len(g.Warnings) != tt.warnings {
+				t.Errorf("Warnings = %q, want %d", g.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestLoader_MergePlacementOption(t *testing.T) {
+	covenDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(covenDir, "grimoires"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	content := `name: two-merges
+description: Merges twice
+steps:
+  - name: merge-once
+    type: merge
+  - name: merge-twice
+    type: merge
+`
+	if err := os.WriteFile(filepath.Join(covenDir, "grimoires", "two-merges.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	strict := NewLoader(covenDir)
+	strict.SetOptions(Options{MergePlacement: SeverityError})
+	if _, err := strict.Load("two-merges"); err == nil || !strings.Contains(err.Error(), "2 merge steps") {
+		t.Errorf("Load() with error severity = %v, want it to reject the merge steps", err)
+	}
+
+	// Other loaders keep the default
+	g, err := NewLoader(covenDir).Load("two-merges")
+	if err != nil {
+		t.Fatalf("Load() with default severity error: %v", err)
+	}
+	if len(g.Warnings) == 0 {
+		t.Error("Warnings should report the misplaced merge")
+	}
+}
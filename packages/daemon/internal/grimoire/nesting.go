@@ -0,0 +1,32 @@
+package grimoire
+
+import "fmt"
+
+// DefaultMaxNestingDepth is how deep steps may be nested unless configured
+// otherwise. A grimoire's top-level steps are at depth 1, and the steps of a
+// loop are one level deeper than the loop.
+const DefaultMaxNestingDepth = 10
+
+// checkNestingDepth returns an error if steps nest deeper than limit.
+func checkNestingDepth(steps []Step, limit int) error {
+	if path := tooDeep(steps, 1, limit); path != "" {
+		return fmt.Errorf("step %q is nested more than %d levels deep", path, limit)
+	}
+	return nil
+}
+
+// tooDeep returns the path, as loop/step names, of the first step nested
+// deeper than limit, or "" if there is none. Steps are at the given depth.
+// It stops descending at the first step past the limit, so its own recursion
+// is bounded by the limit.
+func tooDeep(steps []Step, depth, limit int) string {
+	for i := range steps {
+		if depth > limit {
+			return steps[i].Name
+		}
+		if path := tooDeep(steps[i].Steps, depth+1, limit); path != "" {
+			return steps[i].Name + "/" + path
+		}
+	}
+	return ""
+}
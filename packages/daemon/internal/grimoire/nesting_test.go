@@ -0,0 +1,54 @@
+package grimoire
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// nestedGrimoire returns a grimoire whose innermost script step is at the
+// given depth, inside depth-1 nested loops.
+func nestedGrimoire(depth int) *Grimoire {
+	step := Step{Name: "innermost", Type: StepTypeScript, Command: "echo hi"}
+	for i := depth - 1; i >= 1; i-- {
+		step = Step{Name: fmt.Sprintf("loop-%d", i), Type: StepTypeLoop, MaxIterations: 1, Steps: []Step{step}}
+	}
+	return &Grimoire{Name: "nested", Description: "Deeply nested loops", Steps: []Step{step}}
+}
+
+func TestValidate_NestingDepth(t *testing.T) {
+	if err := Validate(nestedGrimoire(DefaultMaxNestingDepth)); err != nil {
+		t.Errorf("Validate() at the default depth error: %v", err)
+	}
+
+	err := Validate(nestedGrimoire(DefaultMaxNestingDepth + 1))
+	if !IsValidationError(err) {
+		t.Fatalf("Validate() error = %v, want a ValidationError", err)
+	}
+	if !strings.Contains(err.Error(), "nested more than 10 levels deep") || !strings.Contains(err.Error(), "loop-1/loop-2") {
+		t.Errorf("Error = %q, want the depth limit and the path to the step", err)
+	}
+	if err := nestedGrimoire(DefaultMaxNestingDepth + 1).Validate(); err == nil {
+		t.Error("Grimoire.Validate() should reject an over-deep grimoire")
+	}
+
+	// An absurdly deep grimoire is rejected without walking all of it
+	if err := Validate(nestedGrimoire(100000)); !IsValidationError(err) {
+		t.Errorf("Validate() of a very deep grimoire error = %v, want a ValidationError", err)
+	}
+}
+
+func TestValidate_MaxNestingDepthOption(t *testing.T) {
+	opts := Options{MaxNestingDepth: 3}
+
+	if err := ValidateWithOptions(nestedGrimoire(3), opts); err != nil {
+		t.Errorf("ValidateWithOptions() at depth 3 error: %v", err)
+	}
+	if err := ValidateWithOptions(nestedGrimoire(4), opts); !IsValidationError(err) {
+		t.Errorf("ValidateWithOptions() at depth 4 error = %v, want a ValidationError", err)
+	}
+
+	if got := (Options{}).NestingDepth(); got != DefaultMaxNestingDepth {
+		t.Errorf("NestingDepth() of the zero options = %d, want %d", got, DefaultMaxNestingDepth)
+	}
+}
@@ -0,0 +1,47 @@
+package grimoire
+
+// Options configure how grimoires are validated. The zero value validates
+// with the defaults.
+type Options struct {
+	// MergePlacement is how grimoires with more than one merge step, or with
+	// steps after a merge that aren't cleanup, are reported. If empty,
+	// SeverityWarning is used.
+	MergePlacement Severity
+
+	// MaxNestingDepth is how deep steps may be nested, so a pathological
+	// grimoire can't nest deep enough to exhaust the stack while it is
+	// validated, displayed or run. A depth below 1 means
+	// DefaultMaxNestingDepth.
+	MaxNestingDepth int
+
+	// EnvPrefixes are the prefixes of the environment variables grimoires
+	// may interpolate. References to other variables, such as ${HOME} in a
+	// script command, are left for the shell. If empty, DefaultEnvPrefixes
+	// is used.
+	EnvPrefixes []string
+}
+
+// NestingDepth returns how deep steps may be nested.
+func (o Options) NestingDepth() int {
+	if o.MaxNestingDepth < 1 {
+		return DefaultMaxNestingDepth
+	}
+	return o.MaxNestingDepth
+}
+
+// envPrefixes returns the prefixes of the environment variables grimoires may
+// interpolate.
+func (o Options) envPrefixes() []string {
+	if len(o.EnvPrefixes) == 0 {
+		return DefaultEnvPrefixes
+	}
+	return o.EnvPrefixes
+}
+
+// mergePlacement returns how misplaced merge steps are reported.
+func (o Options) mergePlacement() Severity {
+	if !IsValidSeverity(o.MergePlacement) {
+		return SeverityWarning
+	}
+	return o.MergePlacement
+}
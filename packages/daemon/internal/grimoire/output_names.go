@@ -0,0 +1,74 @@
+package grimoire
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reservedContextKeys are the workflow context variables the engine sets
+// itself. A step output stored under one of these names would replace it and
+// break templates and conditions that read it.
+var reservedContextKeys = map[string]bool{
+	"bead":         true,
+	"previous":     true,
+	"workflow":     true,
+	"item":         true,
+	"index":        true,
+	"inputs":       true,
+	"matrix":       true,
+	"loop":         true,
+	"loop_entry":   true,
+	"merge_review": true,
+}
+
+// validateOutputName returns an error if the step would store its output
+// under a reserved context key: its output name, or its name for loop, HTTP
+// and parallel steps, which store their result under the step name.
+// Templated output names aren't known until the step runs and aren't checked.
+func (s *Step) validateOutputName() error {
+	if s.Output != "" && !strings.Contains(s.Output, "{{") && reservedContextKeys[s.Output] {
+		return fmt.Errorf("step %q: output name %q is reserved for the workflow context", s.Name, s.Output)
+	}
+	if (s.Type == StepTypeLoop || s.Type == StepTypeHTTP || s.Type == StepTypeParallel) && reservedContextKeys[s.Name] {
+		return fmt.Errorf("step %q: %s step results are stored under the step name, and %q is reserved for the workflow context", s.Name, s.Type, s.Name)
+	}
+	return nil
+}
+
+// checkLoopOutputNames returns an error if a step's output name is the name of
+// a loop, whose iteration count is stored under its name in the context, or
+// of a parallel step, whose nested steps' results are.
+func checkLoopOutputNames(steps ...[]Step) error {
+	loops := make(map[string]StepType)
+	var collect func([]Step)
+	collect = func(steps []Step) {
+		for i := range steps {
+			if steps[i].Type == StepTypeLoop || steps[i].Type == StepTypeParallel {
+				loops[steps[i].Name] = steps[i].Type
+			}
+			collect(steps[i].Steps)
+		}
+	}
+	for _, s := range steps {
+		collect(s)
+	}
+
+	var check func([]Step) error
+	check = func(steps []Step) error {
+		for i := range steps {
+			if stepType, ok := loops[steps[i].Output]; ok {
+				return fmt.Errorf("step %q: output name %q is the name of a %s step", steps[i].Name, steps[i].Output, stepType)
+			}
+			if err := check(steps[i].Steps); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, s := range steps {
+		if err := check(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package grimoire
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sandbox runs a script step's command in a container instead of directly
+// on the host. The worktree is bind-mounted into the container at the same
+// path it has on the host and is the command's working directory; nothing
+// else from the host is mounted.
+type Sandbox struct {
+	// Image is the container image the command runs in. It must provide sh.
+	Image string `yaml:"image"`
+}
+
+// validate checks the sandbox settings.
+func (s *Sandbox) validate() error {
+	switch {
+	case strings.TrimSpace(s.Image) == "":
+		return fmt.Errorf("sandbox image is required")
+	case strings.ContainsAny(s.Image, " \t\n"):
+		return fmt.Errorf("sandbox image %q must not contain whitespace", s.Image)
+	}
+	return nil
+}
+
+// WithDefaultSandbox returns a copy of the step in which it and its nested
+// script steps run in sandbox unless they set their own.
+func (s Step) WithDefaultSandbox(sandbox *Sandbox) Step {
+	if sandbox == nil {
+		return s
+	}
+	if s.Sandbox == nil && s.Type == StepTypeScript {
+		s.Sandbox = sandbox
+	}
+	if len(s.Steps) > 0 {
+		nested := make([]Step, len(s.Steps))
+		for i := range s.Steps {
+			nested[i] = s.Steps[i].WithDefaultSandbox(sandbox)
+		}
+		s.Steps = nested
+	}
+	return s
+}
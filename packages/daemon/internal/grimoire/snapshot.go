@@ -0,0 +1,125 @@
+package grimoire
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SnapshotStore persists grimoire content addressed by its hash.
+// Workflows pinned to a hash keep running against the exact definition
+// they started with, even if the grimoire file is edited afterwards.
+type SnapshotStore struct {
+	dir string
+
+	// options configure how loaded snapshots are validated.
+	options Options
+}
+
+// NewSnapshotStore creates a snapshot store under the given .coven directory.
+func NewSnapshotStore(covenDir string) *SnapshotStore {
+	return &SnapshotStore{
+		dir: filepath.Join(covenDir, "grimoire-snapshots"),
+	}
+}
+
+// SetOptions sets how loaded snapshots are validated.
+func (s *SnapshotStore) SetOptions(opts Options) {
+	s.options = opts
+}
+
+// ContentHash returns the hex-encoded SHA-256 hash of grimoire content.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Save stores grimoire content and returns its hash.
+// Saving content that already exists is a no-op.
+func (s *SnapshotStore) Save(data []byte) (string, error) {
+	hash := ContentHash(data)
+	path := s.path(hash)
+
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+
+	// Write atomically using temp file + rename
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write grimoire snapshot: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to rename grimoire snapshot: %w", err)
+	}
+
+	return hash, nil
+}
+
+// Load loads the grimoire snapshot with the given hash.
+func (s *SnapshotStore) Load(hash string) (*Grimoire, error) {
+	if !isValidHash(hash) {
+		return nil, fmt.Errorf("invalid grimoire hash: %q", hash)
+	}
+
+	data, err := os.ReadFile(s.path(hash))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &SnapshotNotFoundError{Hash: hash}
+		}
+		return nil, fmt.Errorf("failed to read grimoire snapshot: %w", err)
+	}
+
+	grimoire, err := ParseWithOptions(data, s.options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse grimoire snapshot %q: %w", hash, err)
+	}
+
+	grimoire.Source = SourceSnapshot
+	return grimoire, nil
+}
+
+// Exists checks whether a snapshot with the given hash exists.
+func (s *SnapshotStore) Exists(hash string) bool {
+	if !isValidHash(hash) {
+		return false
+	}
+	_, err := os.Stat(s.path(hash))
+	return err == nil
+}
+
+// path returns the file path for a snapshot hash.
+func (s *SnapshotStore) path(hash string) string {
+	return filepath.Join(s.dir, hash+".yaml")
+}
+
+// isValidHash checks that a hash is a hex-encoded SHA-256 digest.
+func isValidHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// SnapshotNotFoundError is returned when a grimoire snapshot cannot be found.
+type SnapshotNotFoundError struct {
+	Hash string
+}
+
+func (e *SnapshotNotFoundError) Error() string {
+	return fmt.Sprintf("grimoire snapshot %q not found", e.Hash)
+}
+
+// IsSnapshotNotFound checks if an error is a SnapshotNotFoundError.
+func IsSnapshotNotFound(err error) bool {
+	_, ok := err.(*SnapshotNotFoundError)
+	return ok
+}
@@ -0,0 +1,97 @@
+package grimoire
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const snapshotTestYAML = `name: snapshot-grimoire
+description: A snapshotted grimoire
+steps:
+  - name: test
+    type: script
+    command: npm test
+`
+
+func TestContentHash(t *testing.T) {
+	a := ContentHash([]byte("one"))
+	b := ContentHash([]byte("one"))
+	c := ContentHash([]byte("two"))
+
+	if a != b {
+		t.Error("ContentHash should be deterministic")
+	}
+	if a == c {
+		t.Error("ContentHash should differ for different content")
+	}
+	if len(a) != 64 {
+		t.Errorf("len(ContentHash) = %d, want 64", len(a))
+	}
+}
+
+func TestSnapshotStore_SaveAndLoad(t *testing.T) {
+	covenDir := t.TempDir()
+	store := NewSnapshotStore(covenDir)
+
+	hash, err := store.Save([]byte(snapshotTestYAML))
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if hash != ContentHash([]byte(snapshotTestYAML)) {
+		t.Errorf("hash = %q, want content hash", hash)
+	}
+
+	if _, err := os.Stat(filepath.Join(covenDir, "grimoire-snapshots", hash+".yaml")); err != nil {
+		t.Errorf("snapshot file not written: %v", err)
+	}
+	if !store.Exists(hash) {
+		t.Error("Exists() should return true after Save")
+	}
+
+	g, err := store.Load(hash)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if g.Name != "snapshot-grimoire" {
+		t.Errorf("Name = %q, want %q", g.Name, "snapshot-grimoire")
+	}
+	if g.Source != SourceSnapshot {
+		t.Errorf("Source = %q, want %q", g.Source, SourceSnapshot)
+	}
+	if g.ContentHash != hash {
+		t.Errorf("ContentHash = %q, want %q", g.ContentHash, hash)
+	}
+
+	// Saving the same content again is idempotent
+	again, err := store.Save([]byte(snapshotTestYAML))
+	if err != nil {
+		t.Fatalf("second Save() error: %v", err)
+	}
+	if again != hash {
+		t.Errorf("second Save() hash = %q, want %q", again, hash)
+	}
+}
+
+func TestSnapshotStore_Load_NotFound(t *testing.T) {
+	store := NewSnapshotStore(t.TempDir())
+
+	_, err := store.Load(strings.Repeat("a", 64))
+	if !IsSnapshotNotFound(err) {
+		t.Errorf("Load() error = %v, want SnapshotNotFoundError", err)
+	}
+}
+
+func TestSnapshotStore_Load_InvalidHash(t *testing.T) {
+	store := NewSnapshotStore(t.TempDir())
+
+	for _, hash := range []string{"", "abc", "../../etc/passwd", strings.Repeat("z", 64)} {
+		if _, err := store.Load(hash); err == nil || IsSnapshotNotFound(err) {
+			t.Errorf("Load(%q) error = %v, want invalid hash error", hash, err)
+		}
+		if store.Exists(hash) {
+			t.Errorf("Exists(%q) should be false", hash)
+		}
+	}
+}
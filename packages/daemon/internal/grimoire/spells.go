@@ -0,0 +1,94 @@
+package grimoire
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/coven/daemon/internal/spell"
+)
+
+// SpellDependencies are the spells a grimoire's agent steps use, and whether
+// each can be loaded.
+type SpellDependencies struct {
+	// Grimoire is the grimoire's name.
+	Grimoire string `json:"grimoire"`
+
+	// Spells are the spells referenced, sorted by name.
+	Spells []SpellDependency `json:"spells"`
+
+	// Missing are the names of the referenced spells that can't be loaded.
+	Missing []string `json:"missing"`
+}
+
+// SpellDependency is a spell a grimoire references.
+type SpellDependency struct {
+	// Name is the spell's name.
+	Name string `json:"name"`
+
+	// Found is true if the spell loads.
+	Found bool `json:"found"`
+
+	// Source is where the spell loads from, user or builtin, if found.
+	Source spell.SpellSource `json:"source,omitempty"`
+
+	// Error is why the spell doesn't load, if it doesn't.
+	Error string `json:"error,omitempty"`
+
+	// Steps are the names of the steps that reference the spell, in the
+	// order they appear.
+	Steps []string `json:"steps"`
+}
+
+// SpellRefs returns the names of the spell files g's agent steps use, as
+// spells or prompt sections, mapped to the steps using them. Steps nested in
+// loops are included. Inline spell content isn't a reference.
+func (g *Grimoire) SpellRefs() map[string][]string {
+	refs := make(map[string][]string)
+	collectSpellRefs(g.Steps, refs)
+	return refs
+}
+
+// collectSpellRefs adds the spell files steps reference to refs.
+func collectSpellRefs(steps []Step, refs map[string][]string) {
+	for i := range steps {
+		step := &steps[i]
+		for _, ref := range append([]string{step.Spell}, step.Sections...) {
+			// Like the agent executor, content with a newline is inline
+			if ref == "" || strings.Contains(ref, "\n") {
+				continue
+			}
+			if users := refs[ref]; len(users) == 0 || users[len(users)-1] != step.Name {
+				refs[ref] = append(users, step.Name)
+			}
+		}
+		collectSpellRefs(step.Steps, refs)
+	}
+}
+
+// ResolveSpells reports which of the spells g references spells can load.
+func ResolveSpells(g *Grimoire, spells *spell.Loader) *SpellDependencies {
+	refs := g.SpellRefs()
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deps := &SpellDependencies{
+		Grimoire: g.Name,
+		Spells:   make([]SpellDependency, 0, len(names)),
+		Missing:  []string{},
+	}
+	for _, name := range names {
+		dep := SpellDependency{Name: name, Steps: refs[name]}
+		if loaded, err := spells.Load(name); err != nil {
+			dep.Error = err.Error()
+			deps.Missing = append(deps.Missing, name)
+		} else {
+			dep.Found = true
+			dep.Source = loaded.Source
+		}
+		deps.Spells = append(deps.Spells, dep)
+	}
+	return deps
+}
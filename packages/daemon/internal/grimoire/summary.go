@@ -0,0 +1,100 @@
+package grimoire
+
+import "sort"
+
+// Summary describes a grimoire a workflow can be started with.
+type Summary struct {
+	// Name is the grimoire's name.
+	Name string `json:"name"`
+
+	// Description explains what the grimoire does.
+	Description string `json:"description,omitempty"`
+
+	// Source is where the grimoire loads from, user or builtin.
+	Source GrimoireSource `json:"source"`
+
+	// StepCount is the number of top-level steps, not counting prepare steps
+	// or steps nested in loops and parallel steps.
+	StepCount int `json:"step_count"`
+
+	// Timeout is the grimoire's workflow timeout, if it declares one.
+	Timeout string `json:"timeout,omitempty"`
+
+	// Error is why the grimoire doesn't load, if it doesn't. The other
+	// fields, except Name and Source, are empty then.
+	Error string `json:"error,omitempty"`
+}
+
+// Detail is a grimoire's summary with its full definition.
+type Detail struct {
+	Summary
+
+	// Extends is the grimoire this one extends, if any.
+	Extends string `json:"extends,omitempty"`
+
+	// ContentHash is the hash of the grimoire's resolved content.
+	ContentHash string `json:"content_hash"`
+
+	// Warnings are validation diagnostics that didn't prevent the grimoire
+	// from loading.
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Definition is the parsed grimoire keyed by its YAML field names, with
+	// includes and extends resolved and nested steps in place.
+	Definition map[string]any `json:"definition"`
+}
+
+// Summaries returns a summary of every grimoire List finds, sorted by name.
+// Grimoires that don't load are included, with the error.
+func (l *Loader) Summaries() ([]Summary, error) {
+	names, err := l.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	summaries := make([]Summary, 0, len(names))
+	for _, name := range names {
+		g, err := l.Load(name)
+		if err != nil {
+			summary := Summary{Name: name, Source: SourceUser, Error: err.Error()}
+			if _, _, builtin, readErr := l.readGrimoire(name, false); readErr == nil && builtin {
+				summary.Source = SourceBuiltIn
+			}
+			summaries = append(summaries, summary)
+			continue
+		}
+		summaries = append(summaries, summarize(g))
+	}
+	return summaries, nil
+}
+
+// Describe loads a grimoire by name and returns its summary and definition.
+func (l *Loader) Describe(name string) (*Detail, error) {
+	g, err := l.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	definition, err := yamlFields(g)
+	if err != nil {
+		return nil, err
+	}
+	return &Detail{
+		Summary:     summarize(g),
+		Extends:     g.Extends,
+		ContentHash: g.ContentHash,
+		Warnings:    g.Warnings,
+		Definition:  definition,
+	}, nil
+}
+
+// summarize returns the summary of a loaded grimoire.
+func summarize(g *Grimoire) Summary {
+	return Summary{
+		Name:        g.Name,
+		Description: g.Description,
+		Source:      g.Source,
+		StepCount:   len(g.Steps),
+		Timeout:     g.Timeout,
+	}
+}
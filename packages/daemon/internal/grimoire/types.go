@@ -2,6 +2,10 @@ package grimoire
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -13,14 +17,108 @@ type Grimoire struct {
 	// Description explains what this grimoire does.
 	Description string `yaml:"description"`
 
+	// Extends names the grimoire this one inherits its settings and steps
+	// from. The loader merges the two before parsing, so this is only set on
+	// grimoires it loads.
+	Extends string `yaml:"extends,omitempty"`
+
+	// Inputs are the parameters the grimoire's workflows are started with,
+	// available to templates as {{.inputs.name}}.
+	Inputs []Input `yaml:"inputs,omitempty"`
+
 	// Timeout is the maximum duration for the entire workflow.
 	Timeout string `yaml:"timeout,omitempty"`
 
+	// StepTimeout is the default timeout for steps that don't set their own.
+	// When empty, steps use the default for their type.
+	StepTimeout string `yaml:"step_timeout,omitempty"`
+
+	// EnvFile is the default env_file for script steps that don't set their
+	// own.
+	EnvFile string `yaml:"env_file,omitempty"`
+
+	// Sandbox is the default sandbox for script steps that don't set their
+	// own.
+	Sandbox *Sandbox `yaml:"sandbox,omitempty"`
+
+	// Tags attribute the grimoire's workflows to a team, project or the
+	// like, so their usage can be aggregated with GET /usage?group_by=team.
+	Tags map[string]string `yaml:"tags,omitempty"`
+
+	// KeepWorktree keeps the worktree after the workflow completes so it can be
+	// inspected. It is removed later with POST /workflows/:id/cleanup.
+	KeepWorktree bool `yaml:"keep_worktree,omitempty"`
+
+	// ConcurrencyGroup names a resource the workflow needs exclusive use of.
+	// The scheduler runs at most one workflow per group at a time, across
+	// every grimoire that shares the group.
+	ConcurrencyGroup string `yaml:"concurrency_group,omitempty"`
+
+	// SparsePaths limits the task's worktree to these directories, relative
+	// to the repository root, using a sparse checkout. Files at the top level
+	// of the repository are always checked out. When empty, the whole
+	// repository is checked out.
+	SparsePaths []string `yaml:"sparse_paths,omitempty"`
+
+	// PostMerge runs after the task's branch is merged, before the worktree
+	// is cleaned up, e.g. to comment on or close the bead.
+	PostMerge *PostMergeHook `yaml:"post_merge,omitempty"`
+
+	// Prepare are setup steps, such as installing dependencies, run once
+	// before Steps. If any of them fails, the workflow fails before its main
+	// steps run. Only script steps may be used.
+	Prepare []Step `yaml:"prepare,omitempty"`
+
+	// PrepareTimeout is the maximum duration for all prepare steps together.
+	PrepareTimeout string `yaml:"prepare_timeout,omitempty"`
+
 	// Steps are the ordered steps to execute.
 	Steps []Step `yaml:"steps"`
 
 	// Source indicates where the grimoire was loaded from.
 	Source GrimoireSource `yaml:"-"`
+
+	// ContentHash is the SHA-256 hash of the YAML content the grimoire was parsed from.
+	ContentHash string `yaml:"-"`
+
+	// Warnings are validation diagnostics that didn't prevent the grimoire from loading.
+	Warnings []string `yaml:"-"`
+}
+
+// PostMergeHook is a command run in the main repository after a workflow's
+// changes are merged. Its command is a template with the bead_id,
+// workflow_id, grimoire, branch, base_branch and merge_commit variables.
+type PostMergeHook struct {
+	// Command is the shell command to run.
+	Command string `yaml:"command"`
+
+	// Timeout is the maximum duration for the command.
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// DefaultPostMergeTimeout is the default timeout for a post-merge hook.
+const DefaultPostMergeTimeout = time.Minute
+
+// GetTimeout returns the hook's timeout as a time.Duration.
+// Returns DefaultPostMergeTimeout if not specified.
+func (h *PostMergeHook) GetTimeout() (time.Duration, error) {
+	if h.Timeout == "" {
+		return DefaultPostMergeTimeout, nil
+	}
+	return time.ParseDuration(h.Timeout)
+}
+
+// validate checks that the hook has a command and a valid timeout.
+func (h *PostMergeHook) validate() error {
+	if strings.TrimSpace(h.Command) == "" {
+		return fmt.Errorf("post_merge command is required")
+	}
+	if h.Timeout != "" {
+		if _, err := time.ParseDuration(h.Timeout); err != nil {
+			return fmt.Errorf("invalid post_merge timeout %q: %w", h.Timeout, err)
+		}
+	}
+	return nil
 }
 
 // GrimoireSource indicates the origin of a grimoire.
@@ -32,6 +130,9 @@ const (
 
 	// SourceUser indicates the grimoire was loaded from user's .coven/grimoires/.
 	SourceUser GrimoireSource = "user"
+
+	// SourceSnapshot indicates the grimoire was loaded from a pinned content snapshot.
+	SourceSnapshot GrimoireSource = "snapshot"
 )
 
 // Step is a unit of work in a grimoire.
@@ -42,29 +143,83 @@ type Step struct {
 	// Type specifies what kind of step this is.
 	Type StepType `yaml:"type"`
 
+	// Description explains what the step does, for display and logs.
+	Description string `yaml:"description,omitempty"`
+
 	// Timeout is the maximum duration for this step.
 	Timeout string `yaml:"timeout,omitempty"`
 
+	// TimeoutAction is what happens when a script or agent step times out:
+	// fail (the default) fails the workflow, block blocks it for someone to
+	// retry or skip the step.
+	TimeoutAction string `yaml:"timeout_action,omitempty"`
+
 	// When is a condition that must be true for the step to execute.
 	When string `yaml:"when,omitempty"`
 
+	// Confirm pauses the workflow before the step runs until it is confirmed
+	// with POST /workflows/:id/confirm. Use it for deploys and other
+	// operations that shouldn't run unattended.
+	Confirm bool `yaml:"confirm,omitempty"`
+
+	// AllowFailure records the step's failure in the results without letting
+	// it affect the workflow: the workflow continues and can still complete
+	// successfully. Use it for informational steps such as collecting metrics.
+	AllowFailure bool `yaml:"allow_failure,omitempty"`
+
+	// TrackFiles records the files the step changed in the worktree in its
+	// result, by comparing the worktree before and after the step. It is off
+	// by default because snapshotting a large worktree takes time.
+	TrackFiles bool `yaml:"track_files,omitempty"`
+
+	// Matrix runs the step once per combination of the listed values, with
+	// the combination available as .matrix (e.g. {{.matrix.node}}).
+	// Only script and agent steps support it.
+	Matrix map[string][]interface{} `yaml:"matrix,omitempty"`
+
 	// For agent steps
-	Spell  string            `yaml:"spell,omitempty"`  // Spell name or inline content
-	Input  map[string]string `yaml:"input,omitempty"`  // Variables to pass to spell
-	Output string            `yaml:"output,omitempty"` // Variable name to store output
+	Spell        string            `yaml:"spell,omitempty"`         // Spell name or inline content
+	Input        map[string]string `yaml:"input,omitempty"`         // Variables to pass to spell
+	Output       string            `yaml:"output,omitempty"`        // Variable name to store output
+	StallTimeout string            `yaml:"stall_timeout,omitempty"` // Kill the agent after this long without output
+	Sections     []string          `yaml:"sections,omitempty"`      // Spells or inline content appended to the prompt, in order
 
 	// For script steps
-	Command   string `yaml:"command,omitempty"`    // Shell command to run
-	OnFail    string `yaml:"on_fail,omitempty"`    // Action on failure: continue, block
-	OnSuccess string `yaml:"on_success,omitempty"` // Action on success: exit_loop
+	Command         string            `yaml:"command,omitempty"`           // Shell command to run
+	OnFail          string            `yaml:"on_fail,omitempty"`           // Action on failure: continue, block, escalate
+	OnSuccess       string            `yaml:"on_success,omitempty"`        // Action on success: exit_loop
+	PreviousJSONEnv bool              `yaml:"previous_json_env,omitempty"` // Pass the previous step's JSON output in COVEN_PREVIOUS_JSON
+	EnvFile         string            `yaml:"env_file,omitempty"`          // Dotenv file, relative to the worktree, added to the command's environment
+	Env             map[string]string `yaml:"env,omitempty"`               // Variables added to the command's environment; values are templates
+	Sandbox         *Sandbox          `yaml:"sandbox,omitempty"`           // Run the command in a container
+	Retries         int               `yaml:"retries,omitempty"`           // Times to rerun a failed command before on_fail applies
+	RetryBackoff    string            `yaml:"retry_backoff,omitempty"`     // Delay before the first retry, doubling after each
+
+	// For http steps
+	Method  string            `yaml:"method,omitempty"`  // Request method, GET by default
+	URL     string            `yaml:"url,omitempty"`     // Request URL
+	Headers map[string]string `yaml:"headers,omitempty"` // Request headers
+	Body    string            `yaml:"body,omitempty"`    // Request body
 
 	// For loop steps
-	Steps            []Step `yaml:"steps,omitempty"`             // Nested steps for loops
-	MaxIterations    int    `yaml:"max_iterations,omitempty"`    // Maximum loop iterations
-	OnMaxIterations  string `yaml:"on_max_iterations,omitempty"` // Action when max reached: block
+	Steps           []Step `yaml:"steps,omitempty"`             // Nested steps for loops and parallel steps
+	MaxIterations   int    `yaml:"max_iterations,omitempty"`    // Maximum loop iterations
+	OnMaxIterations string `yaml:"on_max_iterations,omitempty"` // Action when max reached: block
+	ForEach         string `yaml:"for_each,omitempty"`          // Context path of a list to iterate over
+
+	// For parallel steps
+	MaxConcurrency int  `yaml:"max_concurrency,omitempty"` // Max nested steps run at once, all of them by default
+	FailFast       bool `yaml:"fail_fast,omitempty"`       // Cancel the other nested steps when one fails
+
+	// For registered step types
+	With map[string]interface{} `yaml:"with,omitempty"` // Settings passed as is to the step type's executor
 
 	// For merge steps
-	RequireReview *bool `yaml:"require_review,omitempty"` // Default: true
+	RequireReview    *bool    `yaml:"require_review,omitempty"`    // Default: true
+	Checks           []string `yaml:"checks,omitempty"`            // Commands that must pass before merging
+	CheckConcurrency int      `yaml:"check_concurrency,omitempty"` // Max checks run at once
+	FFOnly           bool     `yaml:"ff_only,omitempty"`           // Only fast-forward the base branch, never create a merge commit
+	Summarize        bool     `yaml:"summarize,omitempty"`         // Ask the agent to summarize the changes for review
 }
 
 // StepType defines the type of a workflow step.
@@ -82,11 +237,49 @@ const (
 
 	// StepTypeMerge merges worktree changes back to main repo.
 	StepTypeMerge StepType = "merge"
+
+	// StepTypeHTTP sends an HTTP request, such as a call to an external API.
+	StepTypeHTTP StepType = "http"
+
+	// StepTypeParallel runs nested steps concurrently.
+	StepTypeParallel StepType = "parallel"
+)
+
+var (
+	stepTypesMu sync.RWMutex
+
+	// stepTypes are the valid step types: the built-in ones, followed by any
+	// added with RegisterStepType in the order they were registered.
+	stepTypes = []StepType{StepTypeAgent, StepTypeScript, StepTypeLoop, StepTypeMerge, StepTypeHTTP, StepTypeParallel}
 )
 
-// ValidStepTypes returns all valid step types.
+// RegisterStepType makes t a valid step type, for step types whose executors
+// are provided outside coven. Registering a type that is already registered
+// has no effect; an empty or built-in type is an error.
+func RegisterStepType(t StepType) error {
+	switch {
+	case strings.TrimSpace(string(t)) == "":
+		return fmt.Errorf("step type is required")
+	case IsBuiltinStepType(t):
+		return fmt.Errorf("step type %q is built in", t)
+	}
+
+	stepTypesMu.Lock()
+	defer stepTypesMu.Unlock()
+	for _, registered := range stepTypes {
+		if registered == t {
+			return nil
+		}
+	}
+	stepTypes = append(stepTypes, t)
+	return nil
+}
+
+// ValidStepTypes returns all valid step types, including registered ones.
 func ValidStepTypes() []StepType {
-	return []StepType{StepTypeAgent, StepTypeScript, StepTypeLoop, StepTypeMerge}
+	stepTypesMu.RLock()
+	defer stepTypesMu.RUnlock()
+	return append([]StepType(nil), stepTypes...)
 }
 
 // IsValidStepType checks if a step type is valid.
@@ -99,12 +292,31 @@ func IsValidStepType(t StepType) bool {
 	return false
 }
 
+// IsBuiltinStepType reports whether t is one of the step types coven provides.
+func IsBuiltinStepType(t StepType) bool {
+	switch t {
+	case StepTypeAgent, StepTypeScript, StepTypeLoop, StepTypeMerge, StepTypeHTTP, StepTypeParallel:
+		return true
+	default:
+		return false
+	}
+}
+
 // OnFailAction defines actions for script step failures.
 type OnFailAction string
 
 const (
 	OnFailContinue OnFailAction = "continue"
 	OnFailBlock    OnFailAction = "block"
+	OnFailEscalate OnFailAction = "escalate"
+)
+
+// TimeoutAction defines actions for a step that times out.
+type TimeoutAction string
+
+const (
+	TimeoutActionFail  TimeoutAction = "fail"
+	TimeoutActionBlock TimeoutAction = "block"
 )
 
 // OnSuccessAction defines actions for script step success.
@@ -132,6 +344,96 @@ func (s *Step) GetTimeout() (time.Duration, error) {
 	return time.ParseDuration(s.Timeout)
 }
 
+// GetStallTimeout returns how long an agent step may run without output
+// before it is considered stalled, or 0 if it has no stall timeout.
+func (s *Step) GetStallTimeout() (time.Duration, error) {
+	if s.StallTimeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s.StallTimeout)
+}
+
+// DefaultRetryBackoff is the delay before a script step's first retry when
+// it sets retries without retry_backoff.
+const DefaultRetryBackoff = time.Second
+
+// GetRetryBackoff returns the delay before the step's first retry.
+// Returns DefaultRetryBackoff if not specified.
+func (s *Step) GetRetryBackoff() (time.Duration, error) {
+	if s.RetryBackoff == "" {
+		return DefaultRetryBackoff, nil
+	}
+	return time.ParseDuration(s.RetryBackoff)
+}
+
+// WithDefaultTimeout returns a copy of the step in which it and its nested
+// steps use timeout unless they set their own. Loop steps keep their type
+// default, since a loop's timeout covers all of its iterations, and parallel
+// steps stay limited only by their nested steps' timeouts.
+func (s Step) WithDefaultTimeout(timeout string) Step {
+	if timeout == "" {
+		return s
+	}
+	if s.Timeout == "" && s.Type != StepTypeLoop && s.Type != StepTypeParallel {
+		s.Timeout = timeout
+	}
+	if len(s.Steps) > 0 {
+		nested := make([]Step, len(s.Steps))
+		for i := range s.Steps {
+			nested[i] = s.Steps[i].WithDefaultTimeout(timeout)
+		}
+		s.Steps = nested
+	}
+	return s
+}
+
+// WithDefaultEnvFile returns a copy of the step in which it and its nested
+// script steps use envFile unless they set their own.
+func (s Step) WithDefaultEnvFile(envFile string) Step {
+	if envFile == "" {
+		return s
+	}
+	if s.EnvFile == "" && s.Type == StepTypeScript {
+		s.EnvFile = envFile
+	}
+	if len(s.Steps) > 0 {
+		nested := make([]Step, len(s.Steps))
+		for i := range s.Steps {
+			nested[i] = s.Steps[i].WithDefaultEnvFile(envFile)
+		}
+		s.Steps = nested
+	}
+	return s
+}
+
+// envKeyPattern matches valid environment variable names.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateEnv checks that env's keys are valid environment variable names.
+func validateEnv(env map[string]string) error {
+	for key := range env {
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid env variable name %q, must match %s", key, envKeyPattern)
+		}
+	}
+	return nil
+}
+
+// validateEnvFile checks that an env_file path is a file inside the
+// worktree.
+func validateEnvFile(path string) error {
+	clean := filepath.ToSlash(filepath.Clean(path))
+	switch {
+	case strings.TrimSpace(path) == "":
+		return fmt.Errorf("env_file must not be empty")
+	case filepath.IsAbs(path) || strings.HasPrefix(path, "/"):
+		return fmt.Errorf("env_file %q must be relative to the worktree", path)
+	case clean == "." || clean == ".." || strings.HasPrefix(clean, "../"):
+		return fmt.Errorf("env_file %q must be a file inside the worktree", path)
+	}
+	return nil
+}
+
 // DefaultTimeout returns the default timeout for a step type.
 func (s *Step) DefaultTimeout() time.Duration {
 	switch s.Type {
@@ -177,6 +479,118 @@ func (s *Step) Validate() error {
 		}
 	}
 
+	if s.ForEach != "" && s.Type != StepTypeLoop {
+		return fmt.Errorf("step %q: for_each is only valid on loop steps", s.Name)
+	}
+
+	if (s.MaxConcurrency != 0 || s.FailFast) && s.Type != StepTypeParallel {
+		return fmt.Errorf("step %q: max_concurrency and fail_fast are only valid on parallel steps", s.Name)
+	}
+
+	if (len(s.Checks) > 0 || s.CheckConcurrency != 0) && s.Type != StepTypeMerge {
+		return fmt.Errorf("step %q: checks are only valid on merge steps", s.Name)
+	}
+
+	if s.FFOnly && s.Type != StepTypeMerge {
+		return fmt.Errorf("step %q: ff_only is only valid on merge steps", s.Name)
+	}
+
+	if s.Summarize && s.Type != StepTypeMerge {
+		return fmt.Errorf("step %q: summarize is only valid on merge steps", s.Name)
+	}
+
+	if s.StallTimeout != "" && s.Type != StepTypeAgent {
+		return fmt.Errorf("step %q: stall_timeout is only valid on agent steps", s.Name)
+	}
+
+	if s.TimeoutAction != "" {
+		if s.Type != StepTypeScript && s.Type != StepTypeAgent {
+			return fmt.Errorf("step %q: timeout_action is only valid on script and agent steps", s.Name)
+		}
+		if s.TimeoutAction != string(TimeoutActionFail) && s.TimeoutAction != string(TimeoutActionBlock) {
+			return fmt.Errorf("step %q: invalid timeout_action %q, must be %q or %q",
+				s.Name, s.TimeoutAction, TimeoutActionFail, TimeoutActionBlock)
+		}
+	}
+
+	if len(s.Sections) > 0 && s.Type != StepTypeAgent {
+		return fmt.Errorf("step %q: sections are only valid on agent steps", s.Name)
+	}
+
+	if len(s.With) > 0 && IsBuiltinStepType(s.Type) {
+		return fmt.Errorf("step %q: with is only valid on registered step types", s.Name)
+	}
+
+	if s.AllowFailure {
+		if s.Type == StepTypeMerge {
+			return fmt.Errorf("step %q: allow_failure is not valid on merge steps", s.Name)
+		}
+		if s.OnFail != "" {
+			return fmt.Errorf("step %q: allow_failure can't be combined with on_fail", s.Name)
+		}
+	}
+
+	if (s.Method != "" || s.URL != "" || len(s.Headers) > 0 || s.Body != "") && s.Type != StepTypeHTTP {
+		return fmt.Errorf("step %q: method, url, headers and body are only valid on http steps", s.Name)
+	}
+
+	if s.PreviousJSONEnv && s.Type != StepTypeScript {
+		return fmt.Errorf("step %q: previous_json_env is only valid on script steps", s.Name)
+	}
+
+	if s.EnvFile != "" {
+		if s.Type != StepTypeScript {
+			return fmt.Errorf("step %q: env_file is only valid on script steps", s.Name)
+		}
+		if err := validateEnvFile(s.EnvFile); err != nil {
+			return fmt.Errorf("step %q: %w", s.Name, err)
+		}
+	}
+
+	if len(s.Env) > 0 {
+		if s.Type != StepTypeScript {
+			return fmt.Errorf("step %q: env is only valid on script steps", s.Name)
+		}
+		if err := validateEnv(s.Env); err != nil {
+			return fmt.Errorf("step %q: %w", s.Name, err)
+		}
+	}
+
+	if s.Retries != 0 || s.RetryBackoff != "" {
+		if s.Type != StepTypeScript {
+			return fmt.Errorf("step %q: retries and retry_backoff are only valid on script steps", s.Name)
+		}
+		if err := s.validateRetries(); err != nil {
+			return err
+		}
+	}
+
+	if s.Sandbox != nil {
+		if s.Type != StepTypeScript {
+			return fmt.Errorf("step %q: sandbox is only valid on script steps", s.Name)
+		}
+		if err := s.Sandbox.validate(); err != nil {
+			return fmt.Errorf("step %q: %w", s.Name, err)
+		}
+	}
+
+	if err := s.validateMatrix(); err != nil {
+		return err
+	}
+
+	if err := s.validateOutputName(); err != nil {
+		return err
+	}
+
+	if strings.Contains(s.Output, "{{") {
+		if s.Type != StepTypeScript && s.Type != StepTypeAgent {
+			return fmt.Errorf("step %q: templated output names are only valid on script and agent steps", s.Name)
+		}
+		if strings.Count(s.Output, "{{") != strings.Count(s.Output, "}}") {
+			return fmt.Errorf("step %q: output name %q has an unclosed template tag", s.Name, s.Output)
+		}
+	}
+
 	// Type-specific validation
 	switch s.Type {
 	case StepTypeAgent:
@@ -187,6 +601,10 @@ func (s *Step) Validate() error {
 		return s.validateLoopStep()
 	case StepTypeMerge:
 		return s.validateMergeStep()
+	case StepTypeHTTP:
+		return s.validateHTTPStep()
+	case StepTypeParallel:
+		return s.validateParallelStep()
 	}
 
 	return nil
@@ -196,6 +614,16 @@ func (s *Step) validateAgentStep() error {
 	if s.Spell == "" {
 		return fmt.Errorf("step %q: agent step requires spell field", s.Name)
 	}
+	for i, section := range s.Sections {
+		if strings.TrimSpace(section) == "" {
+			return fmt.Errorf("step %q: sections[%d] is empty", s.Name, i)
+		}
+	}
+	if s.StallTimeout != "" {
+		if d, err := time.ParseDuration(s.StallTimeout); err != nil || d <= 0 {
+			return fmt.Errorf("step %q: invalid stall_timeout %q, must be a positive duration", s.Name, s.StallTimeout)
+		}
+	}
 	return nil
 }
 
@@ -203,11 +631,44 @@ func (s *Step) validateScriptStep() error {
 	if s.Command == "" {
 		return fmt.Errorf("step %q: script step requires command field", s.Name)
 	}
+	return s.validateHandlers()
+}
+
+// validateRetries checks a script step's retries and retry_backoff.
+func (s *Step) validateRetries() error {
+	if s.Retries < 0 {
+		return fmt.Errorf("step %q: retries must be non-negative, got %d", s.Name, s.Retries)
+	}
+	if s.RetryBackoff != "" {
+		if s.Retries == 0 {
+			return fmt.Errorf("step %q: retry_backoff requires retries", s.Name)
+		}
+		if d, err := time.ParseDuration(s.RetryBackoff); err != nil || d <= 0 {
+			return fmt.Errorf("step %q: invalid retry_backoff %q, must be a positive duration", s.Name, s.RetryBackoff)
+		}
+	}
+	return nil
+}
 
+func (s *Step) validateHTTPStep() error {
+	if s.URL == "" {
+		return fmt.Errorf("step %q: http step requires url field", s.Name)
+	}
+	switch strings.ToUpper(s.Method) {
+	case "", "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+	default:
+		return fmt.Errorf("step %q: invalid method %q", s.Name, s.Method)
+	}
+	return s.validateHandlers()
+}
+
+// validateHandlers validates the on_fail and on_success values of script and
+// http steps.
+func (s *Step) validateHandlers() error {
 	// Validate on_fail if specified
-	if s.OnFail != "" && s.OnFail != string(OnFailContinue) && s.OnFail != string(OnFailBlock) {
-		return fmt.Errorf("step %q: invalid on_fail value %q, must be %q or %q",
-			s.Name, s.OnFail, OnFailContinue, OnFailBlock)
+	if s.OnFail != "" && s.OnFail != string(OnFailContinue) && s.OnFail != string(OnFailBlock) && s.OnFail != string(OnFailEscalate) {
+		return fmt.Errorf("step %q: invalid on_fail value %q, must be %q, %q, or %q",
+			s.Name, s.OnFail, OnFailContinue, OnFailBlock, OnFailEscalate)
 	}
 
 	// Validate on_success if specified
@@ -245,6 +706,38 @@ func (s *Step) validateLoopStep() error {
 		if err := s.Steps[i].Validate(); err != nil {
 			return fmt.Errorf("step %q nested step %d: %w", s.Name, i, err)
 		}
+		if s.Steps[i].Confirm {
+			return fmt.Errorf("step %q nested step %q: confirm is only supported on top-level steps", s.Name, s.Steps[i].Name)
+		}
+	}
+
+	return nil
+}
+
+func (s *Step) validateParallelStep() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("step %q: parallel step requires at least one nested step", s.Name)
+	}
+
+	if s.MaxConcurrency < 0 {
+		return fmt.Errorf("step %q: max_concurrency must be non-negative", s.Name)
+	}
+
+	// Validate nested steps. Merges change the repository and can't run
+	// alongside other steps, however deeply they are nested
+	var merges []string
+	collectMergeSteps(s.Steps, &merges)
+	if len(merges) > 0 {
+		return fmt.Errorf("step %q nested step %q: merge steps can't run in parallel", s.Name, merges[0])
+	}
+	for i := range s.Steps {
+		nested := &s.Steps[i]
+		if err := nested.Validate(); err != nil {
+			return fmt.Errorf("step %q nested step %d: %w", s.Name, i, err)
+		}
+		if nested.Confirm {
+			return fmt.Errorf("step %q nested step %q: confirm is only supported on top-level steps", s.Name, nested.Name)
+		}
 	}
 
 	return nil
@@ -253,9 +746,39 @@ func (s *Step) validateLoopStep() error {
 func (s *Step) validateMergeStep() error {
 	// Merge step has no required fields beyond name and type
 	// require_review defaults to true if not specified
+	for i, check := range s.Checks {
+		if strings.TrimSpace(check) == "" {
+			return fmt.Errorf("step %q: check %d has an empty command", s.Name, i)
+		}
+	}
+
+	if s.CheckConcurrency < 0 {
+		return fmt.Errorf("step %q: check_concurrency must be non-negative", s.Name)
+	}
 	return nil
 }
 
+// DefaultCheckConcurrency is the default number of pre-merge checks run at once.
+const DefaultCheckConcurrency = 4
+
+// GetCheckConcurrency returns how many pre-merge checks may run at once.
+// Returns DefaultCheckConcurrency if not specified.
+func (s *Step) GetCheckConcurrency() int {
+	if s.CheckConcurrency <= 0 {
+		return DefaultCheckConcurrency
+	}
+	return s.CheckConcurrency
+}
+
+// GetMaxConcurrency returns how many of a parallel step's nested steps may
+// run at once. Returns the number of nested steps if not specified.
+func (s *Step) GetMaxConcurrency() int {
+	if s.MaxConcurrency <= 0 || s.MaxConcurrency > len(s.Steps) {
+		return len(s.Steps)
+	}
+	return s.MaxConcurrency
+}
+
 // DefaultWorkflowTimeout is the default timeout for an entire workflow.
 const DefaultWorkflowTimeout = 1 * time.Hour
 
@@ -268,6 +791,77 @@ func (g *Grimoire) GetTimeout() (time.Duration, error) {
 	return time.ParseDuration(g.Timeout)
 }
 
+// DefaultPrepareTimeout is the default timeout for a grimoire's prepare steps.
+const DefaultPrepareTimeout = 10 * time.Minute
+
+// GetPrepareTimeout returns the prepare phase timeout as a time.Duration.
+// Returns DefaultPrepareTimeout if not specified.
+func (g *Grimoire) GetPrepareTimeout() (time.Duration, error) {
+	if g.PrepareTimeout == "" {
+		return DefaultPrepareTimeout, nil
+	}
+	return time.ParseDuration(g.PrepareTimeout)
+}
+
+// validateSparsePaths checks that each sparse path is a directory inside the
+// repository.
+func (g *Grimoire) validateSparsePaths() error {
+	for _, p := range g.SparsePaths {
+		clean := filepath.ToSlash(filepath.Clean(p))
+		switch {
+		case strings.TrimSpace(p) == "":
+			return fmt.Errorf("sparse path must not be empty")
+		case filepath.IsAbs(p) || strings.HasPrefix(p, "/"):
+			return fmt.Errorf("sparse path %q must be relative to the repository root", p)
+		case clean == "." || clean == ".." || strings.HasPrefix(clean, "../"):
+			return fmt.Errorf("sparse path %q must be a directory inside the repository", p)
+		}
+	}
+	return nil
+}
+
+// validateTags checks that each tag has a name without whitespace or "=".
+func (g *Grimoire) validateTags() error {
+	for key := range g.Tags {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("tag names must not be empty")
+		}
+		if strings.ContainsAny(key, " \t\n=") {
+			return fmt.Errorf("tag name %q must not contain whitespace or \"=\"", key)
+		}
+	}
+	return nil
+}
+
+// validatePrepare checks the prepare_timeout and each prepare step. Prepare
+// steps are setup that must succeed, so they are limited to script steps and
+// can't change what happens on failure or wait for confirmation.
+func (g *Grimoire) validatePrepare() error {
+	if g.PrepareTimeout != "" {
+		if _, err := time.ParseDuration(g.PrepareTimeout); err != nil {
+			return fmt.Errorf("invalid prepare_timeout %q: %w", g.PrepareTimeout, err)
+		}
+	}
+
+	for i := range g.Prepare {
+		step := &g.Prepare[i]
+		if err := step.Validate(); err != nil {
+			return err
+		}
+		switch {
+		case step.Type != StepTypeScript:
+			return fmt.Errorf("prepare step %q: only script steps may be used in prepare", step.Name)
+		case step.OnFail != "" || step.OnSuccess != "":
+			return fmt.Errorf("prepare step %q: on_fail and on_success are not supported in prepare", step.Name)
+		case step.AllowFailure:
+			return fmt.Errorf("prepare step %q: allow_failure is not supported in prepare", step.Name)
+		case step.Confirm:
+			return fmt.Errorf("prepare step %q: confirm is not supported in prepare", step.Name)
+		}
+	}
+	return nil
+}
+
 // Validate validates the grimoire configuration.
 func (g *Grimoire) Validate() error {
 	if g.Name == "" {
@@ -284,9 +878,59 @@ func (g *Grimoire) Validate() error {
 			return fmt.Errorf("grimoire %q: invalid timeout %q: %w", g.Name, g.Timeout, err)
 		}
 	}
+	if g.StepTimeout != "" {
+		if _, err := time.ParseDuration(g.StepTimeout); err != nil {
+			return fmt.Errorf("grimoire %q: invalid step_timeout %q: %w", g.Name, g.StepTimeout, err)
+		}
+	}
+	if g.EnvFile != "" {
+		if err := validateEnvFile(g.EnvFile); err != nil {
+			return fmt.Errorf("grimoire %q: %w", g.Name, err)
+		}
+	}
+	if g.Sandbox != nil {
+		if err := g.Sandbox.validate(); err != nil {
+			return fmt.Errorf("grimoire %q: %w", g.Name, err)
+		}
+	}
+	if err := g.validateTags(); err != nil {
+		return fmt.Errorf("grimoire %q: %w", g.Name, err)
+	}
+	if err := g.validateInputs(); err != nil {
+		return fmt.Errorf("grimoire %q: %w", g.Name, err)
+	}
+	if strings.ContainsAny(g.ConcurrencyGroup, " \t\n") {
+		return fmt.Errorf("grimoire %q: concurrency_group %q must not contain whitespace", g.Name, g.ConcurrencyGroup)
+	}
+	if err := g.validateSparsePaths(); err != nil {
+		return fmt.Errorf("grimoire %q: %w", g.Name, err)
+	}
+	if g.PostMerge != nil {
+		if err := g.PostMerge.validate(); err != nil {
+			return fmt.Errorf("grimoire %q: %w", g.Name, err)
+		}
+	}
+
+	// Check nesting before validating steps, which recurses into loops
+	if err := checkNestingDepth(g.Prepare, DefaultMaxNestingDepth); err != nil {
+		return fmt.Errorf("grimoire %q: %w", g.Name, err)
+	}
+	if err := checkNestingDepth(g.Steps, DefaultMaxNestingDepth); err != nil {
+		return fmt.Errorf("grimoire %q: %w", g.Name, err)
+	}
+
+	if err := g.validatePrepare(); err != nil {
+		return fmt.Errorf("grimoire %q: %w", g.Name, err)
+	}
 
 	// Validate all steps
 	stepNames := make(map[string]bool)
+	for _, step := range g.Prepare {
+		if stepNames[step.Name] {
+			return fmt.Errorf("grimoire %q: duplicate step name %q", g.Name, step.Name)
+		}
+		stepNames[step.Name] = true
+	}
 	for i := range g.Steps {
 		step := &g.Steps[i]
 		if err := step.Validate(); err != nil {
@@ -300,6 +944,10 @@ func (g *Grimoire) Validate() error {
 		stepNames[step.Name] = true
 	}
 
+	if err := checkLoopOutputNames(g.Prepare, g.Steps); err != nil {
+		return fmt.Errorf("grimoire %q: %w", g.Name, err)
+	}
+
 	return nil
 }
 
@@ -8,11 +8,11 @@ import (
 
 func TestValidStepTypes(t *testing.T) {
 	types := ValidStepTypes()
-	if len(types) != 4 {
-		t.Errorf("Expected 4 valid step types, got %d", len(types))
+	if len(types) != 6 {
+		t.Errorf("Expected 6 valid step types, got %d", len(types))
 	}
 
-	expected := []StepType{StepTypeAgent, StepTypeScript, StepTypeLoop, StepTypeMerge}
+	expected := []StepType{StepTypeAgent, StepTypeScript, StepTypeLoop, StepTypeMerge, StepTypeHTTP, StepTypeParallel}
 	for i, typ := range expected {
 		if types[i] != typ {
 			t.Errorf("types[%d] = %q, want %q", i, types[i], typ)
@@ -29,6 +29,8 @@ func TestIsValidStepType(t *testing.T) {
 		{StepTypeScript, true},
 		{StepTypeLoop, true},
 		{StepTypeMerge, true},
+		{StepTypeHTTP, true},
+		{StepTypeParallel, true},
 		{StepType("invalid"), false},
 		{StepType(""), false},
 	}
@@ -42,6 +44,40 @@ func TestIsValidStepType(t *testing.T) {
 	}
 }
 
+func TestRegisterStepType(t *testing.T) {
+	saved := ValidStepTypes()
+	t.Cleanup(func() {
+		stepTypesMu.Lock()
+		stepTypes = saved
+		stepTypesMu.Unlock()
+	})
+
+	step := Step{Name: "notify", Type: "webhook", With: map[string]interface{}{"url": "https://example.com/hook"}}
+	if err := step.Validate(); !IsInvalidStepType(err) {
+		t.Fatalf("Validate() error = %v, want an invalid step type before registering", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := RegisterStepType("webhook"); err != nil {
+			t.Fatalf("RegisterStepType() error: %v", err)
+		}
+	}
+	if err := step.Validate(); err != nil {
+		t.Errorf("Validate() error: %v", err)
+	}
+	if types := ValidStepTypes(); len(types) != len(saved)+1 || types[len(types)-1] != "webhook" {
+		t.Errorf("ValidStepTypes() = %v, want webhook registered once after the built-in types", types)
+	}
+
+	if err := RegisterStepType(StepTypeScript); err == nil {
+		t.Error("RegisterStepType() should refuse a built-in step type")
+	}
+	script := Step{Name: "build", Type: StepTypeScript, Command: "make", With: map[string]interface{}{"url": "x"}}
+	if err := script.Validate(); err == nil || !strings.Contains(err.Error(), "with is only valid on registered step types") {
+		t.Errorf("Validate() error = %v, want with rejected on a built-in step type", err)
+	}
+}
+
 func TestStep_GetTimeout(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -101,6 +137,96 @@ func TestStep_GetTimeout(t *testing.T) {
 	}
 }
 
+func TestStep_WithDefaultTimeout(t *testing.T) {
+	step := Step{
+		Name: "loop",
+		Type: StepTypeLoop,
+		Steps: []Step{
+			{Name: "test", Type: StepTypeScript, Command: "make test"},
+			{Name: "fix", Type: StepTypeAgent, Spell: "fix", Timeout: "30m"},
+		},
+	}
+
+	got := step.WithDefaultTimeout("2m")
+
+	if got.Timeout != "" {
+		t.Errorf("Loop timeout = %q, want the loop to keep its type default", got.Timeout)
+	}
+	if got.Steps[0].Timeout != "2m" {
+		t.Errorf("Nested step timeout = %q, want %q", got.Steps[0].Timeout, "2m")
+	}
+	if got.Steps[1].Timeout != "30m" {
+		t.Errorf("Nested step override = %q, want %q", got.Steps[1].Timeout, "30m")
+	}
+	if step.Steps[0].Timeout != "" {
+		t.Error("WithDefaultTimeout should not modify the original step")
+	}
+
+	script := Step{Name: "build", Type: StepTypeScript, Command: "make"}
+	if got := script.WithDefaultTimeout(""); got.Timeout != "" {
+		t.Errorf("Timeout = %q, want empty when there is no default", got.Timeout)
+	}
+	if got := script.WithDefaultTimeout("45s"); got.Timeout != "45s" {
+		t.Errorf("Timeout = %q, want %q", got.Timeout, "45s")
+	}
+}
+
+func TestStep_WithDefaultEnvFile(t *testing.T) {
+	step := Step{
+		Name: "loop",
+		Type: StepTypeLoop,
+		Steps: []Step{
+			{Name: "test", Type: StepTypeScript, Command: "make test"},
+			{Name: "deploy", Type: StepTypeScript, Command: "make deploy", EnvFile: "deploy.env"},
+			{Name: "fix", Type: StepTypeAgent, Spell: "fix"},
+		},
+	}
+
+	got := step.WithDefaultEnvFile(".env")
+
+	if got.EnvFile != "" || got.Steps[2].EnvFile != "" {
+		t.Error("Only script steps should inherit the env_file")
+	}
+	if got.Steps[0].EnvFile != ".env" {
+		t.Errorf("Nested step env_file = %q, want %q", got.Steps[0].EnvFile, ".env")
+	}
+	if got.Steps[1].EnvFile != "deploy.env" {
+		t.Errorf("Nested step override = %q, want %q", got.Steps[1].EnvFile, "deploy.env")
+	}
+	if step.Steps[0].EnvFile != "" {
+		t.Error("WithDefaultEnvFile should not modify the original step")
+	}
+}
+
+func TestStep_WithDefaultSandbox(t *testing.T) {
+	sandbox := &Sandbox{Image: "golang:1.22"}
+	own := &Sandbox{Image: "node:20"}
+	step := Step{
+		Name: "loop",
+		Type: StepTypeLoop,
+		Steps: []Step{
+			{Name: "test", Type: StepTypeScript, Command: "make test"},
+			{Name: "lint", Type: StepTypeScript, Command: "npm run lint", Sandbox: own},
+			{Name: "fix", Type: StepTypeAgent, Spell: "fix"},
+		},
+	}
+
+	got := step.WithDefaultSandbox(sandbox)
+
+	if got.Sandbox != nil || got.Steps[2].Sandbox != nil {
+		t.Error("Only script steps should inherit the sandbox")
+	}
+	if got.Steps[0].Sandbox != sandbox {
+		t.Errorf("Nested step sandbox = %v, want %v", got.Steps[0].Sandbox, sandbox)
+	}
+	if got.Steps[1].Sandbox != own {
+		t.Errorf("Nested step override = %v, want %v", got.Steps[1].Sandbox, own)
+	}
+	if step.Steps[0].Sandbox != nil {
+		t.Error("WithDefaultSandbox should not modify the original step")
+	}
+}
+
 func TestStep_RequiresReview(t *testing.T) {
 	boolTrue := true
 	boolFalse := false
@@ -250,6 +376,11 @@ func TestStep_Validate_ScriptStep(t *testing.T) {
 			step:    Step{Name: "test", Type: StepTypeScript, Command: "npm test", OnFail: "block"},
 			wantErr: false,
 		},
+		{
+			name:    "valid on_fail escalate",
+			step:    Step{Name: "test", Type: StepTypeScript, Command: "npm test", OnFail: "escalate"},
+			wantErr: false,
+		},
 		{
 			name:    "invalid on_fail",
 			step:    Step{Name: "test", Type: StepTypeScript, Command: "npm test", OnFail: "invalid"},
@@ -287,6 +418,62 @@ func TestStep_Validate_ScriptStep(t *testing.T) {
 	}
 }
 
+func TestStep_Validate_HTTPStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "valid http step",
+			step:    Step{Name: "call", Type: StepTypeHTTP, URL: "https://example.com/hooks", Method: "post", Body: "{}", OnFail: "continue"},
+			wantErr: false,
+		},
+		{
+			name:    "missing url",
+			step:    Step{Name: "call", Type: StepTypeHTTP},
+			wantErr: true,
+			errMsg:  "requires url",
+		},
+		{
+			name:    "invalid method",
+			step:    Step{Name: "call", Type: StepTypeHTTP, URL: "https://example.com", Method: "FETCH"},
+			wantErr: true,
+			errMsg:  "invalid method",
+		},
+		{
+			name:    "invalid on_fail",
+			step:    Step{Name: "call", Type: StepTypeHTTP, URL: "https://example.com", OnFail: "retry"},
+			wantErr: true,
+			errMsg:  "invalid on_fail",
+		},
+		{
+			name:    "url on script step",
+			step:    Step{Name: "test", Type: StepTypeScript, Command: "npm test", URL: "https://example.com"},
+			wantErr: true,
+			errMsg:  "only valid on http steps",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.step.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Validate() should return error")
+					return
+				}
+				if tt.errMsg != "" && !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("Error = %q, want to contain %q", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("Validate() error: %v", err)
+			}
+		})
+	}
+}
+
 func TestStep_Validate_LoopStep(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -307,14 +494,14 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 		},
 		{
 			name:    "no nested steps",
-			step:    Step{Name: "loop", Type: StepTypeLoop},
+			step:    Step{Name: "retry", Type: StepTypeLoop},
 			wantErr: true,
 			errMsg:  "at least one nested step",
 		},
 		{
 			name: "invalid nested step",
 			step: Step{
-				Name: "loop",
+				Name: "retry",
 				Type: StepTypeLoop,
 				Steps: []Step{
 					{Name: "bad", Type: "invalid"},
@@ -326,7 +513,7 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 		{
 			name: "with max_iterations",
 			step: Step{
-				Name:          "loop",
+				Name:          "retry",
 				Type:          StepTypeLoop,
 				MaxIterations: 3,
 				Steps: []Step{
@@ -338,7 +525,7 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 		{
 			name: "negative max_iterations",
 			step: Step{
-				Name:          "loop",
+				Name:          "retry",
 				Type:          StepTypeLoop,
 				MaxIterations: -1,
 				Steps: []Step{
@@ -351,7 +538,7 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 		{
 			name: "valid on_max_iterations block",
 			step: Step{
-				Name:            "loop",
+				Name:            "retry",
 				Type:            StepTypeLoop,
 				OnMaxIterations: "block",
 				Steps: []Step{
@@ -363,7 +550,7 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 		{
 			name: "valid on_max_iterations exit",
 			step: Step{
-				Name:            "loop",
+				Name:            "retry",
 				Type:            StepTypeLoop,
 				OnMaxIterations: "exit",
 				Steps: []Step{
@@ -375,7 +562,7 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 		{
 			name: "valid on_max_iterations continue",
 			step: Step{
-				Name:            "loop",
+				Name:            "retry",
 				Type:            StepTypeLoop,
 				OnMaxIterations: "continue",
 				Steps: []Step{
@@ -387,7 +574,7 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 		{
 			name: "invalid on_max_iterations",
 			step: Step{
-				Name:            "loop",
+				Name:            "retry",
 				Type:            StepTypeLoop,
 				OnMaxIterations: "invalid",
 				Steps: []Step{
@@ -397,6 +584,574 @@ func TestStep_Validate_LoopStep(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid on_max_iterations",
 		},
+		{
+			name: "with for_each",
+			step: Step{
+				Name:    "each-issue",
+				Type:    StepTypeLoop,
+				ForEach: "analyze.outputs.issues",
+				Steps: []Step{
+					{Name: "fix", Type: StepTypeAgent, Spell: "Fix {{.item.name}}"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "for_each on non-loop step",
+			step: Step{
+				Name:    "fix",
+				Type:    StepTypeAgent,
+				Spell:   "Fix {{.item.name}}",
+				ForEach: "analyze.outputs.issues",
+			},
+			wantErr: true,
+			errMsg:  "for_each is only valid on loop steps",
+		},
+		{
+			name: "confirm on nested step",
+			step: Step{
+				Name: "retry",
+				Type: StepTypeLoop,
+				Steps: []Step{
+					{Name: "deploy", Type: StepTypeScript, Command: "make deploy", Confirm: true},
+				},
+			},
+			wantErr: true,
+			errMsg:  "confirm is only supported on top-level steps",
+		},
+		{
+			name: "merge step with checks",
+			step: Step{
+				Name:             "merge",
+				Type:             StepTypeMerge,
+				Checks:           []string{"make lint", "make test"},
+				CheckConcurrency: 2,
+			},
+			wantErr: false,
+		},
+		{
+			name: "checks on non-merge step",
+			step: Step{
+				Name:    "test",
+				Type:    StepTypeScript,
+				Command: "make test",
+				Checks:  []string{"make lint"},
+			},
+			wantErr: true,
+			errMsg:  "checks are only valid on merge steps",
+		},
+		{
+			name: "ff_only on non-merge step",
+			step: Step{
+				Name:    "test",
+				Type:    StepTypeScript,
+				Command: "make test",
+				FFOnly:  true,
+			},
+			wantErr: true,
+			errMsg:  "ff_only is only valid on merge steps",
+		},
+		{
+			name: "summarize on non-merge step",
+			step: Step{
+				Name:      "test",
+				Type:      StepTypeScript,
+				Command:   "make test",
+				Summarize: true,
+			},
+			wantErr: true,
+			errMsg:  "summarize is only valid on merge steps",
+		},
+		{
+			name: "empty check command",
+			step: Step{
+				Name:   "merge",
+				Type:   StepTypeMerge,
+				Checks: []string{"make lint", "  "},
+			},
+			wantErr: true,
+			errMsg:  "check 1 has an empty command",
+		},
+		{
+			name: "negative check concurrency",
+			step: Step{
+				Name:             "merge",
+				Type:             StepTypeMerge,
+				Checks:           []string{"make lint"},
+				CheckConcurrency: -1,
+			},
+			wantErr: true,
+			errMsg:  "check_concurrency must be non-negative",
+		},
+		{
+			name: "previous_json_env on script step",
+			step: Step{
+				Name:            "consume",
+				Type:            StepTypeScript,
+				Command:         "cat",
+				PreviousJSONEnv: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "previous_json_env on agent step",
+			step: Step{
+				Name:            "implement",
+				Type:            StepTypeAgent,
+				Spell:           "implement",
+				PreviousJSONEnv: true,
+			},
+			wantErr: true,
+			errMsg:  "previous_json_env is only valid on script steps",
+		},
+		{
+			name: "env_file on script step",
+			step: Step{
+				Name:    "deploy",
+				Type:    StepTypeScript,
+				Command: "make deploy",
+				EnvFile: "config/deploy.env",
+			},
+			wantErr: false,
+		},
+		{
+			name: "env_file on agent step",
+			step: Step{
+				Name:    "implement",
+				Type:    StepTypeAgent,
+				Spell:   "implement",
+				EnvFile: ".env",
+			},
+			wantErr: true,
+			errMsg:  "env_file is only valid on script steps",
+		},
+		{
+			name: "env_file outside the worktree",
+			step: Step{
+				Name:    "deploy",
+				Type:    StepTypeScript,
+				Command: "make deploy",
+				EnvFile: "../secrets.env",
+			},
+			wantErr: true,
+			errMsg:  "must be a file inside the worktree",
+		},
+		{
+			name: "env on script step",
+			step: Step{
+				Name:    "test",
+				Type:    StepTypeScript,
+				Command: "npm test",
+				Env:     map[string]string{"NODE_ENV": "test", "_BEAD": "{{.bead.id}}"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "env on agent step",
+			step: Step{
+				Name:  "implement",
+				Type:  StepTypeAgent,
+				Spell: "implement",
+				Env:   map[string]string{"CI": "1"},
+			},
+			wantErr: true,
+			errMsg:  "env is only valid on script steps",
+		},
+		{
+			name: "invalid env name",
+			step: Step{
+				Name:    "test",
+				Type:    StepTypeScript,
+				Command: "npm test",
+				Env:     map[string]string{"1PASSWORD-TOKEN": "x"},
+			},
+			wantErr: true,
+			errMsg:  `invalid env variable name "1PASSWORD-TOKEN"`,
+		},
+		{
+			name: "retries on script step",
+			step: Step{
+				Name:         "test",
+				Type:         StepTypeScript,
+				Command:      "npm test",
+				Retries:      2,
+				RetryBackoff: "5s",
+			},
+			wantErr: false,
+		},
+		{
+			name: "retries on agent step",
+			step: Step{
+				Name:    "implement",
+				Type:    StepTypeAgent,
+				Spell:   "implement",
+				Retries: 2,
+			},
+			wantErr: true,
+			errMsg:  "retries and retry_backoff are only valid on script steps",
+		},
+		{
+			name: "negative retries",
+			step: Step{
+				Name:    "test",
+				Type:    StepTypeScript,
+				Command: "npm test",
+				Retries: -1,
+			},
+			wantErr: true,
+			errMsg:  "retries must be non-negative",
+		},
+		{
+			name: "retry_backoff without retries",
+			step: Step{
+				Name:         "test",
+				Type:         StepTypeScript,
+				Command:      "npm test",
+				RetryBackoff: "5s",
+			},
+			wantErr: true,
+			errMsg:  "retry_backoff requires retries",
+		},
+		{
+			name: "invalid retry_backoff",
+			step: Step{
+				Name:         "test",
+				Type:         StepTypeScript,
+				Command:      "npm test",
+				Retries:      1,
+				RetryBackoff: "soon",
+			},
+			wantErr: true,
+			errMsg:  `invalid retry_backoff "soon"`,
+		},
+		{
+			name: "sandbox on script step",
+			step: Step{
+				Name:    "test",
+				Type:    StepTypeScript,
+				Command: "make test",
+				Sandbox: &Sandbox{Image: "golang:1.22"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "sandbox on agent step",
+			step: Step{
+				Name:    "implement",
+				Type:    StepTypeAgent,
+				Spell:   "implement",
+				Sandbox: &Sandbox{Image: "golang:1.22"},
+			},
+			wantErr: true,
+			errMsg:  "sandbox is only valid on script steps",
+		},
+		{
+			name: "sandbox without image",
+			step: Step{
+				Name:    "test",
+				Type:    StepTypeScript,
+				Command: "make test",
+				Sandbox: &Sandbox{},
+			},
+			wantErr: true,
+			errMsg:  "sandbox image is required",
+		},
+		{
+			name: "allow_failure on script step",
+			step: Step{
+				Name:         "metrics",
+				Type:         StepTypeScript,
+				Command:      "make metrics",
+				AllowFailure: true,
+			},
+			wantErr: false,
+		},
+		{
+			name: "allow_failure on merge step",
+			step: Step{
+				Name:         "merge",
+				Type:         StepTypeMerge,
+				AllowFailure: true,
+			},
+			wantErr: true,
+			errMsg:  "allow_failure is not valid on merge steps",
+		},
+		{
+			name: "allow_failure with on_fail",
+			step: Step{
+				Name:         "metrics",
+				Type:         StepTypeScript,
+				Command:      "make metrics",
+				OnFail:       "continue",
+				AllowFailure: true,
+			},
+			wantErr: true,
+			errMsg:  "allow_failure can't be combined with on_fail",
+		},
+		{
+			name: "sections on agent step",
+			step: Step{
+				Name:     "review",
+				Type:     StepTypeAgent,
+				Spell:    "review",
+				Sections: []string{"findings", "Diff:\n{{.diff}}\n"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "sections on script step",
+			step: Step{
+				Name:     "test",
+				Type:     StepTypeScript,
+				Command:  "npm test",
+				Sections: []string{"findings"},
+			},
+			wantErr: true,
+			errMsg:  "sections are only valid on agent steps",
+		},
+		{
+			name: "empty section",
+			step: Step{
+				Name:     "review",
+				Type:     StepTypeAgent,
+				Spell:    "review",
+				Sections: []string{"findings", " "},
+			},
+			wantErr: true,
+			errMsg:  "sections[1] is empty",
+		},
+		{
+			name: "stall_timeout on agent step",
+			step: Step{
+				Name:         "implement",
+				Type:         StepTypeAgent,
+				Spell:        "implement",
+				StallTimeout: "5m",
+			},
+			wantErr: false,
+		},
+		{
+			name: "stall_timeout on script step",
+			step: Step{
+				Name:         "test",
+				Type:         StepTypeScript,
+				Command:      "npm test",
+				StallTimeout: "5m",
+			},
+			wantErr: true,
+			errMsg:  "stall_timeout is only valid on agent steps",
+		},
+		{
+			name: "invalid stall_timeout",
+			step: Step{
+				Name:         "implement",
+				Type:         StepTypeAgent,
+				Spell:        "implement",
+				StallTimeout: "0s",
+			},
+			wantErr: true,
+			errMsg:  "must be a positive duration",
+		},
+		{
+			name: "templated output on script step",
+			step: Step{
+				Name:    "check",
+				Type:    StepTypeScript,
+				Command: "make check",
+				Output:  "check_{{.fix.iteration}}",
+			},
+			wantErr: false,
+		},
+		{
+			name: "templated output on merge step",
+			step: Step{
+				Name:   "merge",
+				Type:   StepTypeMerge,
+				Output: "merge_{{.matrix.os}}",
+			},
+			wantErr: true,
+			errMsg:  "templated output names are only valid on script and agent steps",
+		},
+		{
+			name: "templated output with unclosed tag",
+			step: Step{
+				Name:    "check",
+				Type:    StepTypeScript,
+				Command: "make check",
+				Output:  "check_{{.fix.iteration",
+			},
+			wantErr: true,
+			errMsg:  "unclosed template tag",
+		},
+		{
+			name: "timeout_action block on script step",
+			step: Step{
+				Name:          "integration",
+				Type:          StepTypeScript,
+				Command:       "make integration",
+				TimeoutAction: "block",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid timeout_action",
+			step: Step{
+				Name:          "integration",
+				Type:          StepTypeScript,
+				Command:       "make integration",
+				TimeoutAction: "retry",
+			},
+			wantErr: true,
+			errMsg:  "invalid timeout_action",
+		},
+		{
+			name: "timeout_action on loop step",
+			step: Step{
+				Name:          "fix",
+				Type:          StepTypeLoop,
+				TimeoutAction: "block",
+				Steps:         []Step{{Name: "check", Type: StepTypeScript, Command: "make check"}},
+			},
+			wantErr: true,
+			errMsg:  "timeout_action is only valid on script and agent steps",
+		},
+		{
+			name: "output named after a reserved context key",
+			step: Step{
+				Name:    "check",
+				Type:    StepTypeScript,
+				Command: "make check",
+				Output:  "bead",
+			},
+			wantErr: true,
+			errMsg:  `output name "bead" is reserved`,
+		},
+		{
+			name: "loop named after a reserved context key",
+			step: Step{
+				Name:  "previous",
+				Type:  StepTypeLoop,
+				Steps: []Step{{Name: "check", Type: StepTypeScript, Command: "make check"}},
+			},
+			wantErr: true,
+			errMsg:  `"previous" is reserved`,
+		},
+		{
+			name: "output named loop",
+			step: Step{
+				Name:    "check",
+				Type:    StepTypeScript,
+				Command: "make check",
+				Output:  "loop",
+			},
+			wantErr: true,
+			errMsg:  `output name "loop" is reserved`,
+		},
+		{
+			name: "loop named loop",
+			step: Step{
+				Name:  "loop",
+				Type:  StepTypeLoop,
+				Steps: []Step{{Name: "check", Type: StepTypeScript, Command: "make check"}},
+			},
+			wantErr: true,
+			errMsg:  `"loop" is reserved`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.step.Validate()
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Validate() should return error")
+					return
+				}
+				if tt.errMsg != "" && !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("Error = %q, want to contain %q", err.Error(), tt.errMsg)
+				}
+			} else if err != nil {
+				t.Errorf("Validate() error: %v", err)
+			}
+		})
+	}
+}
+
+func TestStep_Validate_ParallelStep(t *testing.T) {
+	checks := []Step{
+		{Name: "lint", Type: StepTypeScript, Command: "npm run lint"},
+		{Name: "test", Type: StepTypeScript, Command: "npm test"},
+	}
+	tests := []struct {
+		name    string
+		step    Step
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "valid parallel step",
+			step:    Step{Name: "checks", Type: StepTypeParallel, MaxConcurrency: 2, FailFast: true, Steps: checks},
+			wantErr: false,
+		},
+		{
+			name:    "no nested steps",
+			step:    Step{Name: "checks", Type: StepTypeParallel},
+			wantErr: true,
+			errMsg:  "at least one nested step",
+		},
+		{
+			name:    "negative max_concurrency",
+			step:    Step{Name: "checks", Type: StepTypeParallel, MaxConcurrency: -1, Steps: checks},
+			wantErr: true,
+			errMsg:  "non-negative",
+		},
+		{
+			name: "nested merge step",
+			step: Step{
+				Name: "checks",
+				Type: StepTypeParallel,
+				Steps: []Step{
+					{Name: "test", Type: StepTypeScript, Command: "npm test"},
+					{Name: "merge", Type: StepTypeMerge},
+				},
+			},
+			wantErr: true,
+			errMsg:  "merge steps can't run in parallel",
+		},
+		{
+			name: "merge step nested in a loop",
+			step: Step{
+				Name: "checks",
+				Type: StepTypeParallel,
+				Steps: []Step{
+					{Name: "test", Type: StepTypeScript, Command: "npm test"},
+					{
+						Name:          "retry",
+						Type:          StepTypeLoop,
+						MaxIterations: 2,
+						Steps:         []Step{{Name: "merge", Type: StepTypeMerge}},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "merge steps can't run in parallel",
+		},
+		{
+			name: "invalid nested step",
+			step: Step{
+				Name:  "checks",
+				Type:  StepTypeParallel,
+				Steps: []Step{{Name: "bad", Type: "invalid"}},
+			},
+			wantErr: true,
+			errMsg:  "invalid step type",
+		},
+		{
+			name:    "max_concurrency on a loop",
+			step:    Step{Name: "retry", Type: StepTypeLoop, MaxConcurrency: 2, Steps: checks},
+			wantErr: true,
+			errMsg:  "only valid on parallel steps",
+		},
 	}
 
 	for _, tt := range tests {
@@ -624,6 +1379,112 @@ func TestGrimoire_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid timeout",
 		},
+		{
+			name: "invalid step timeout",
+			g: Grimoire{
+				Name:        "test",
+				StepTimeout: "soon",
+				Steps:       []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: true,
+			errMsg:  "invalid step_timeout",
+		},
+		{
+			name: "concurrency group",
+			g: Grimoire{
+				Name:             "test",
+				ConcurrencyGroup: "db-migrations",
+				Steps:            []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "concurrency group with whitespace",
+			g: Grimoire{
+				Name:             "test",
+				ConcurrencyGroup: "db migrations",
+				Steps:            []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: true,
+			errMsg:  "concurrency_group",
+		},
+		{
+			name: "tags",
+			g: Grimoire{
+				Name:  "test",
+				Tags:  map[string]string{"team": "payments", "project": "checkout"},
+				Steps: []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "tag name with whitespace",
+			g: Grimoire{
+				Name:  "test",
+				Tags:  map[string]string{"cost center": "42"},
+				Steps: []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: true,
+			errMsg:  "tag name",
+		},
+		{
+			name: "sparse paths",
+			g: Grimoire{
+				Name:        "test",
+				SparsePaths: []string{"packages/api", "libs/shared/"},
+				Steps:       []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "sparse path outside the repository",
+			g: Grimoire{
+				Name:        "test",
+				SparsePaths: []string{"packages/../../other"},
+				Steps:       []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: true,
+			errMsg:  "must be a directory inside the repository",
+		},
+		{
+			name: "absolute sparse path",
+			g: Grimoire{
+				Name:        "test",
+				SparsePaths: []string{"/packages/api"},
+				Steps:       []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: true,
+			errMsg:  "must be relative to the repository root",
+		},
+		{
+			name: "post-merge hook",
+			g: Grimoire{
+				Name:      "test",
+				PostMerge: &PostMergeHook{Command: "bd comment {{.bead_id}} merged", Timeout: "30s"},
+				Steps:     []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "post-merge hook without command",
+			g: Grimoire{
+				Name:      "test",
+				PostMerge: &PostMergeHook{Timeout: "30s"},
+				Steps:     []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: true,
+			errMsg:  "post_merge command is required",
+		},
+		{
+			name: "post-merge hook with invalid timeout",
+			g: Grimoire{
+				Name:      "test",
+				PostMerge: &PostMergeHook{Command: "echo", Timeout: "soon"},
+				Steps:     []Step{{Name: "step1", Type: StepTypeScript, Command: "echo"}},
+			},
+			wantErr: true,
+			errMsg:  "invalid post_merge timeout",
+		},
 		{
 			name: "invalid step",
 			g: Grimoire{
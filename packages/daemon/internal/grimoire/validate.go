@@ -0,0 +1,104 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/coven/daemon/internal/spell"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationIssue is a problem found while validating grimoire content.
+type ValidationIssue struct {
+	// Field is the grimoire field the issue is in, if known.
+	Field string `json:"field,omitempty"`
+
+	// Step is the name of the step the issue is in, if it is in one.
+	Step string `json:"step,omitempty"`
+
+	// Message describes the issue.
+	Message string `json:"message"`
+}
+
+// ContentValidation is the result of validating grimoire content.
+type ContentValidation struct {
+	// Name is the grimoire's name, if one could be read.
+	Name string `json:"name,omitempty"`
+
+	// IsValid is true if the grimoire parses and validates.
+	IsValid bool `json:"is_valid"`
+
+	// Errors are why the grimoire is invalid.
+	Errors []ValidationIssue `json:"errors"`
+
+	// Warnings are problems that don't make the grimoire invalid, such as
+	// spells that don't load and merge steps that may be misplaced.
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+// ValidateContent parses and validates grimoire YAML without saving it,
+// resolving includes and extends from the user's grimoire directory as
+// Install does. The spells the grimoire's agent steps reference that spells
+// can't load are reported as warnings.
+func (l *Loader) ValidateContent(data []byte, spells *spell.Loader) *ContentValidation {
+	var header struct {
+		Name string `yaml:"name"`
+	}
+	yaml.Unmarshal(data, &header)
+
+	result := &ContentValidation{
+		Name:     header.Name,
+		Errors:   []ValidationIssue{},
+		Warnings: []ValidationIssue{},
+	}
+
+	data, err := l.resolveContent(data, l.userDir(), false, []string{header.Name})
+	var g *Grimoire
+	if err == nil {
+		g, err = ParseWithOptions(data, l.options)
+	}
+	if err == nil {
+		if nameErr := validateGrimoireName(g.Name); nameErr != nil {
+			err = &ValidationError{Field: "name", Message: nameErr.Error()}
+		}
+	}
+	if err != nil {
+		result.Errors = append(result.Errors, validationIssue(err))
+		return result
+	}
+
+	result.IsValid = true
+	for _, warning := range g.Warnings {
+		result.Warnings = append(result.Warnings, ValidationIssue{Field: "steps", Message: warning})
+	}
+	for _, dep := range ResolveSpells(g, spells).Spells {
+		if dep.Found {
+			continue
+		}
+		for _, step := range dep.Steps {
+			result.Warnings = append(result.Warnings, ValidationIssue{
+				Field:   "spell",
+				Step:    step,
+				Message: fmt.Sprintf("spell %q doesn't load: %s", dep.Name, dep.Error),
+			})
+		}
+	}
+	return result
+}
+
+// validationIssue describes err, an error parsing or validating a grimoire,
+// with the field and step it is in when the error records them.
+func validationIssue(err error) ValidationIssue {
+	var stepErr *StepValidationError
+	var validationErr *ValidationError
+	var extendsErr *ExtendsError
+	switch {
+	case errors.As(err, &stepErr):
+		return ValidationIssue{Field: "steps", Step: stepErr.Step, Message: stepErr.Err.Error()}
+	case errors.As(err, &validationErr):
+		return ValidationIssue{Field: validationErr.Field, Message: validationErr.Message}
+	case errors.As(err, &extendsErr):
+		return ValidationIssue{Field: extendsKey, Message: err.Error()}
+	}
+	return ValidationIssue{Message: err.Error()}
+}
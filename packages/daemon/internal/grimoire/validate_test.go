@@ -0,0 +1,74 @@
+package grimoire
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/spell"
+)
+
+func TestLoader_ValidateContent(t *testing.T) {
+	covenDir := t.TempDir()
+	writeGrimoireFiles(t, covenDir, map[string]string{"base.yaml": baseGrimoire})
+	loader := NewLoaderWithBuiltins(covenDir, nil, "grimoires")
+	spells := spell.NewLoaderWithBuiltins(covenDir, nil, "spells")
+
+	tests := []struct {
+		name      string
+		content   string
+		wantValid bool
+		wantIssue ValidationIssue
+	}{
+		{
+			name:      "extends an installed grimoire",
+			content:   "name: ship\nextends: base\nsteps:\n  - name: merge\n    type: merge\n",
+			wantValid: true,
+		},
+		{
+			name:      "YAML syntax error",
+			content:   "name: [draft\n",
+			wantIssue: ValidationIssue{Message: "failed to parse grimoire YAML"},
+		},
+		{
+			name:      "grimoire field",
+			content:   "name: draft\nsteps:\n  - name: test\n    type: script\n    command: make test\n",
+			wantIssue: ValidationIssue{Field: "description", Message: "grimoire description is required"},
+		},
+		{
+			name:      "invalid grimoire name",
+			content:   "name: ../draft\ndescription: Escapes the grimoire directory\nsteps:\n  - name: test\n    type: script\n    command: make test\n",
+			wantIssue: ValidationIssue{Field: "name"},
+		},
+		{
+			name:      "missing base",
+			content:   "name: ship\nextends: missing\n",
+			wantIssue: ValidationIssue{Field: "extends", Message: "grimoire not found"},
+		},
+		{
+			name:      "nested step",
+			content:   "name: draft\ndescription: Loops\nsteps:\n  - name: fix-loop\n    type: loop\n    steps:\n      - name: fix\n        type: agent\n",
+			wantIssue: ValidationIssue{Field: "steps", Step: "fix-loop", Message: `step "fix": agent step requires spell field`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := loader.ValidateContent([]byte(tt.content), spells)
+			if result.IsValid != tt.wantValid {
+				t.Fatalf("IsValid = %v, want %v (errors %+v)", result.IsValid, tt.wantValid, result.Errors)
+			}
+			if tt.wantValid {
+				if len(result.Errors) != 0 {
+					t.Errorf("errors = %+v, want none", result.Errors)
+				}
+				return
+			}
+			if len(result.Errors) != 1 {
+				t.Fatalf("errors = %+v, want one", result.Errors)
+			}
+			got := result.Errors[0]
+			if got.Field != tt.wantIssue.Field || got.Step != tt.wantIssue.Step || !strings.Contains(got.Message, tt.wantIssue.Message) {
+				t.Errorf("error = %+v, want %+v", got, tt.wantIssue)
+			}
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package grimoire
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/spell"
+)
+
+// ChangeEmitter is told about user grimoire and spell files that changed.
+type ChangeEmitter interface {
+	EmitGrimoireChanged(change api.GrimoireChangeEventData)
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher periodically checks the user grimoire and spell directories for
+// files that were added, changed or removed, and reports each one with its
+// validation result once it has stopped changing. Grimoires and spells are
+// read from disk whenever a workflow starts, so a change applies from the
+// next workflow; the watcher makes broken edits visible before then.
+type Watcher struct {
+	mu       sync.Mutex
+	covenDir string
+	loader   *Loader
+	emitter  ChangeEmitter
+	interval time.Duration
+	debounce time.Duration
+
+	// files are the files seen by the last check, by path relative to
+	// covenDir
+	files map[string]fileStamp
+
+	// pending are the files changed since they were last reported, mapped
+	// to when the change was seen
+	pending map[string]time.Time
+
+	// grimoireErrors are the validation errors of the user grimoires when
+	// they were last validated, "" for a valid one
+	grimoireErrors map[string]string
+
+	stopCh  chan struct{}
+	running bool
+}
+
+// NewWatcher creates a watcher of covenDir's grimoires and spells. Files
+// already there are taken as unchanged.
+func NewWatcher(covenDir string, emitter ChangeEmitter) *Watcher {
+	w := &Watcher{
+		covenDir: covenDir,
+		loader:   NewLoader(covenDir),
+		emitter:  emitter,
+		interval: 1 * time.Second,
+		debounce: 500 * time.Millisecond,
+		pending:  make(map[string]time.Time),
+	}
+	w.files = w.scan()
+	w.grimoireErrors = w.validateGrimoires()
+	return w
+}
+
+// SetOptions sets how user grimoires are validated, and revalidates them
+// without reporting the results.
+func (w *Watcher) SetOptions(opts Options) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.loader.SetOptions(opts)
+	w.grimoireErrors = w.validateGrimoires()
+}
+
+// SetInterval sets how often the directories are checked (for testing).
+func (w *Watcher) SetInterval(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.interval = d
+}
+
+// SetDebounce sets how long a file must go unchanged before it is reported,
+// so an editor's rapid saves are reported once.
+func (w *Watcher) SetDebounce(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.debounce = d
+}
+
+// Start begins checking for changes.
+func (w *Watcher) Start() {
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = true
+	w.stopCh = make(chan struct{})
+	interval := w.interval
+	stopCh := w.stopCh
+	w.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				w.Check()
+			}
+		}
+	}()
+}
+
+// Stop stops checking for changes.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.running {
+		return
+	}
+	w.running = false
+	close(w.stopCh)
+}
+
+// Check looks for changed files once and reports those that have settled.
+func (w *Watcher) Check() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	current := w.scan()
+	for path, stamp := range current {
+		if old, ok := w.files[path]; !ok || old != stamp {
+			w.pending[path] = now
+		}
+	}
+	for path := range w.files {
+		if _, ok := current[path]; !ok {
+			w.pending[path] = now
+		}
+	}
+	w.files = current
+
+	var settled []string
+	for path, changedAt := range w.pending {
+		if now.Sub(changedAt) >= w.debounce {
+			settled = append(settled, path)
+			delete(w.pending, path)
+		}
+	}
+	if len(settled) == 0 {
+		return
+	}
+	sort.Strings(settled)
+
+	grimoiresChanged := false
+	for _, path := range settled {
+		if strings.HasPrefix(path, "spells/") {
+			w.emitter.EmitGrimoireChanged(w.spellChange(path))
+		} else {
+			grimoiresChanged = true
+		}
+	}
+	if grimoiresChanged {
+		w.reportGrimoires(settled)
+	}
+}
+
+// reportGrimoires revalidates every user grimoire, since a changed file may
+// be a fragment others include, and reports those whose file changed or
+// whose validation result did.
+func (w *Watcher) reportGrimoires(changed []string) {
+	// changedPaths maps the names of the grimoires whose file changed to it
+	changedPaths := make(map[string]string)
+	for _, path := range changed {
+		if name, ok := grimoireFileName(path); ok {
+			changedPaths[name] = path
+		}
+	}
+
+	errs := w.validateGrimoires()
+	names := make([]string, 0, len(errs))
+	for name := range errs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		previous, known := w.grimoireErrors[name]
+		if _, fileChanged := changedPaths[name]; !fileChanged && known && previous == errs[name] {
+			continue
+		}
+		w.emitter.EmitGrimoireChanged(api.GrimoireChangeEventData{
+			Kind:  "grimoire",
+			Name:  name,
+			Path:  grimoirePath(name, w.files),
+			Valid: errs[name] == "",
+			Error: errs[name],
+		})
+	}
+	for name, path := range changedPaths {
+		if _, exists := errs[name]; !exists {
+			w.emitter.EmitGrimoireChanged(api.GrimoireChangeEventData{
+				Kind:    "grimoire",
+				Name:    name,
+				Path:    path,
+				Removed: true,
+				Valid:   true,
+			})
+		}
+	}
+	w.grimoireErrors = errs
+}
+
+// validateGrimoires loads every user grimoire and returns its validation
+// error, "" if it loaded.
+func (w *Watcher) validateGrimoires() map[string]string {
+	errs := make(map[string]string)
+	for path := range w.files {
+		name, ok := grimoireFileName(path)
+		if !ok {
+			continue
+		}
+		errs[name] = ""
+		if _, err := w.loader.loadUserGrimoire(name); err != nil {
+			errs[name] = err.Error()
+		}
+	}
+	return errs
+}
+
+// spellChange describes the change to the spell file at path.
+func (w *Watcher) spellChange(path string) api.GrimoireChangeEventData {
+	change := api.GrimoireChangeEventData{
+		Kind:  "spell",
+		Name:  strings.TrimSuffix(strings.TrimPrefix(path, "spells/"), ".md"),
+		Path:  path,
+		Valid: true,
+	}
+	content, err := os.ReadFile(filepath.Join(w.covenDir, path))
+	if errors.Is(err, fs.ErrNotExist) {
+		change.Removed = true
+		return change
+	}
+	if err != nil {
+		change.Valid = false
+		change.Error = err.Error()
+		return change
+	}
+	if result := spell.Validate(change.Name, string(content)); !result.Valid {
+		change.Valid = false
+		change.Error = result.Errors[0].Message
+	}
+	return change
+}
+
+// scan returns the grimoire and spell files under covenDir.
+func (w *Watcher) scan() map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	watched := map[string][]string{
+		"grimoires": {".yaml", ".yml"},
+		"spells":    {".md"},
+	}
+	for dir, exts := range watched {
+		root := filepath.Join(w.covenDir, dir)
+		filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || !hasAnySuffix(path, exts) {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			rel, err := filepath.Rel(w.covenDir, path)
+			if err != nil {
+				return nil
+			}
+			files[filepath.ToSlash(rel)] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+	}
+	return files
+}
+
+// grimoireFileName returns the name of the grimoire whose file is at path,
+// relative to the .coven directory. Other files in the grimoire directory,
+// such as fragments in subdirectories, aren't grimoires.
+func grimoireFileName(path string) (string, bool) {
+	file, ok := strings.CutPrefix(path, "grimoires/")
+	if !ok || strings.Contains(file, "/") {
+		return "", false
+	}
+	for _, ext := range grimoireExtensions {
+		if name, ok := strings.CutSuffix(file, ext); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// grimoirePath returns the path of the named user grimoire's file relative to
+// the .coven directory, as the loader finds it among files.
+func grimoirePath(name string, files map[string]fileStamp) string {
+	for _, ext := range grimoireExtensions {
+		if _, ok := files["grimoires/"+name+ext]; ok {
+			return "grimoires/" + name + ext
+		}
+	}
+	return "grimoires/" + name + grimoireExtensions[0]
+}
+
+// hasAnySuffix reports whether s ends with one of suffixes.
+func hasAnySuffix(s string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package grimoire
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+)
+
+// recordingEmitter records the changes a watcher reports.
+type recordingEmitter struct {
+	mu      sync.Mutex
+	changes []api.GrimoireChangeEventData
+}
+
+func (e *recordingEmitter) EmitGrimoireChanged(change api.GrimoireChangeEventData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.changes = append(e.changes, change)
+}
+
+func (e *recordingEmitter) take() []api.GrimoireChangeEventData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	changes := e.changes
+	e.changes = nil
+	return changes
+}
+
+const watchedGrimoire = `name: review
+description: Reviews code
+steps:
+  - name: test
+    type: script
+    command: make test
+`
+
+// writeWatched writes a file under covenDir, moving its modification time
+// forward so the change is seen however coarse the filesystem's clock is.
+func writeWatched(t *testing.T, covenDir, path, content string) {
+	t.Helper()
+	full := filepath.Join(covenDir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	var modTime time.Time
+	if info, err := os.Stat(full); err == nil {
+		modTime = info.ModTime().Add(time.Second)
+	} else {
+		modTime = time.Now()
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	if err := os.Chtimes(full, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set times of %s: %v", path, err)
+	}
+}
+
+func newTestWatcher(t *testing.T) (*Watcher, *recordingEmitter, string) {
+	t.Helper()
+	covenDir := t.TempDir()
+	writeWatched(t, covenDir, "grimoires/review.yaml", watchedGrimoire)
+	emitter := &recordingEmitter{}
+	watcher := NewWatcher(covenDir, emitter)
+	watcher.SetDebounce(0)
+	return watcher, emitter, covenDir
+}
+
+func TestWatcher_ReportsChangedGrimoire(t *testing.T) {
+	watcher, emitter, covenDir := newTestWatcher(t)
+
+	// Files present at startup aren't reported
+	watcher.Check()
+	if changes := emitter.take(); len(changes) != 0 {
+		t.Fatalf("changes at startup = %+v, want none", changes)
+	}
+
+	writeWatched(t, covenDir, "grimoires/review.yaml", strings.Replace(watchedGrimoire, "Reviews code", "Reviews and tests code", 1))
+	watcher.Check()
+
+	changes := emitter.take()
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v, want one", changes)
+	}
+	change := changes[0]
+	if change.Kind != "grimoire" || change.Name != "review" || change.Path != "grimoires/review.yaml" {
+		t.Errorf("change = %+v, want grimoire review", change)
+	}
+	if !change.Valid || change.Error != "" {
+		t.Errorf("change = %+v, want valid", change)
+	}
+}
+
+func TestWatcher_ReportsInvalidGrimoire(t *testing.T) {
+	watcher, emitter, covenDir := newTestWatcher(t)
+
+	writeWatched(t, covenDir, "grimoires/review.yaml", "name: review\ndescription: Reviews code\nsteps: []\n")
+	watcher.Check()
+
+	changes := emitter.take()
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v, want one", changes)
+	}
+	if changes[0].Valid || !strings.Contains(changes[0].Error, "at least one step") {
+		t.Errorf("change = %+v, want the validation error", changes[0])
+	}
+
+	// The broken grimoire isn't loaded in place of a working version
+	if _, err := watcher.loader.Load("review"); err == nil {
+		t.Error("Load() succeeded for the invalid grimoire")
+	}
+}
+
+func TestWatcher_ReportsFragmentChangeForIncludingGrimoire(t *testing.T) {
+	covenDir := t.TempDir()
+	writeWatched(t, covenDir, "grimoires/fragments/checks.yaml", "steps:\n  - name: test\n    type: script\n    command: make test\n")
+	writeWatched(t, covenDir, "grimoires/review.yaml", "name: review\ndescription: Reviews code\nsteps:\n  - include: fragments/checks.yaml\n")
+	writeWatched(t, covenDir, "grimoires/other.yaml", watchedGrimoire)
+	emitter := &recordingEmitter{}
+	watcher := NewWatcher(covenDir, emitter)
+	watcher.SetDebounce(0)
+
+	// Breaking the fragment breaks the grimoire that includes it
+	writeWatched(t, covenDir, "grimoires/fragments/checks.yaml", "steps:\n  - name: test\n    type: script\n")
+	watcher.Check()
+
+	changes := emitter.take()
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v, want one", changes)
+	}
+	if changes[0].Name != "review" || changes[0].Valid {
+		t.Errorf("change = %+v, want review reported invalid", changes[0])
+	}
+}
+
+func TestWatcher_ReportsRemovedGrimoire(t *testing.T) {
+	watcher, emitter, covenDir := newTestWatcher(t)
+
+	if err := os.Remove(filepath.Join(covenDir, "grimoires/review.yaml")); err != nil {
+		t.Fatalf("Failed to remove grimoire: %v", err)
+	}
+	watcher.Check()
+
+	changes := emitter.take()
+	if len(changes) != 1 || !changes[0].Removed || changes[0].Name != "review" {
+		t.Fatalf("changes = %+v, want review removed", changes)
+	}
+}
+
+func TestWatcher_ReportsYmlGrimoire(t *testing.T) {
+	watcher, emitter, covenDir := newTestWatcher(t)
+
+	ymlGrimoire := strings.Replace(watchedGrimoire, "name: review", "name: lint", 1)
+	writeWatched(t, covenDir, "grimoires/lint.yml", ymlGrimoire)
+	watcher.Check()
+
+	changes := emitter.take()
+	if len(changes) != 1 {
+		t.Fatalf("changes = %+v, want one", changes)
+	}
+	if change := changes[0]; change.Name != "lint" || change.Path != "grimoires/lint.yml" || !change.Valid {
+		t.Errorf("change = %+v, want valid grimoire lint at its .yml path", change)
+	}
+
+	if err := os.Remove(filepath.Join(covenDir, "grimoires/lint.yml")); err != nil {
+		t.Fatalf("Failed to remove grimoire: %v", err)
+	}
+	watcher.Check()
+
+	changes = emitter.take()
+	if len(changes) != 1 || !changes[0].Removed || changes[0].Path != "grimoires/lint.yml" {
+		t.Fatalf("changes = %+v, want lint.yml removed", changes)
+	}
+}
+
+func TestWatcher_ReportsSpells(t *testing.T) {
+	watcher, emitter, covenDir := newTestWatcher(t)
+
+	writeWatched(t, covenDir, "spells/implement.md", "Implement {{.bead.title}}\n")
+	writeWatched(t, covenDir, "spells/broken.md", "Fix {{.bead.title\n")
+	watcher.Check()
+
+	changes := emitter.take()
+	if len(changes) != 2 {
+		t.Fatalf("changes = %+v, want two", changes)
+	}
+	// Changes are reported in path order
+	broken, implement := changes[0], changes[1]
+	if broken.Kind != "spell" || broken.Name != "broken" || broken.Valid || broken.Error == "" {
+		t.Errorf("broken = %+v, want an invalid spell", broken)
+	}
+	if implement.Kind != "spell" || implement.Name != "implement" || !implement.Valid {
+		t.Errorf("implement = %+v, want a valid spell", implement)
+	}
+}
+
+func TestWatcher_DebouncesRapidSaves(t *testing.T) {
+	watcher, emitter, covenDir := newTestWatcher(t)
+	watcher.SetDebounce(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		writeWatched(t, covenDir, "grimoires/review.yaml", watchedGrimoire+strings.Repeat("\n", i+1))
+		watcher.Check()
+	}
+	if changes := emitter.take(); len(changes) != 0 {
+		t.Fatalf("changes while saving = %+v, want none", changes)
+	}
+
+	// Once the file has settled it is reported once
+	watcher.SetDebounce(0)
+	watcher.Check()
+	if changes := emitter.take(); len(changes) != 1 {
+		t.Fatalf("changes after settling = %+v, want one", changes)
+	}
+	watcher.Check()
+	if changes := emitter.take(); len(changes) != 0 {
+		t.Errorf("changes on the next check = %+v, want none", changes)
+	}
+}
+
+func TestWatcher_StartReportsChanges(t *testing.T) {
+	watcher, emitter, covenDir := newTestWatcher(t)
+	watcher.SetInterval(10 * time.Millisecond)
+	watcher.Start()
+	defer watcher.Stop()
+
+	writeWatched(t, covenDir, "grimoires/review.yaml", strings.Replace(watchedGrimoire, "Reviews code", "Reviews and tests code", 1))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if changes := emitter.take(); len(changes) > 0 {
+			if changes[0].Name != "review" {
+				t.Errorf("change = %+v, want review", changes[0])
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no change reported")
+}
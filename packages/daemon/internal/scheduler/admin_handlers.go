@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/coven/daemon/internal/api"
+)
+
+// MaxAgentsRequest is the request body for POST /admin/max-agents.
+type MaxAgentsRequest struct {
+	// MaxAgents is the new maximum number of concurrent agents.
+	MaxAgents int `json:"max_agents"`
+
+	// Converge stops the workflows running agents over the new limit, so
+	// they resume once an agent slot is free, instead of leaving them to
+	// finish.
+	Converge bool `json:"converge,omitempty"`
+}
+
+// MaxAgentsResponse is the response for POST /admin/max-agents.
+type MaxAgentsResponse struct {
+	MaxAgents int `json:"max_agents"`
+
+	// Drained are the tasks whose workflows were stopped to converge.
+	Drained []string `json:"drained"`
+}
+
+// AdminHandlers provides HTTP handlers for daemon maintenance operations.
+type AdminHandlers struct {
+	scheduler *Scheduler
+}
+
+// NewAdminHandlers creates new admin handlers.
+func NewAdminHandlers(scheduler *Scheduler) *AdminHandlers {
+	return &AdminHandlers{
+		scheduler: scheduler,
+	}
+}
+
+// Register registers admin handlers with the server.
+func (h *AdminHandlers) Register(server *api.Server) {
+	server.RegisterHandlerFunc("/admin/reconcile-state", h.handleReconcileState)
+	server.RegisterHandlerFunc("/admin/max-agents", h.handleSetMaxAgents)
+}
+
+// handleSetMaxAgents handles POST /admin/max-agents.
+// @Summary      Change the agent limit
+// @Description  Sets the maximum number of concurrent agents until the daemon restarts. Agents over a lowered limit are left to finish unless converge is set, which stops their workflows, lowest priority and most recently started first, to resume once an agent slot is free.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      MaxAgentsRequest   true  "New limit"
+// @Success      200      {object}  MaxAgentsResponse  "The new limit and the workflows stopped"
+// @Failure      400      {object}  map[string]string  "Invalid request body or limit"
+// @Failure      405      {object}  map[string]string  "Method not allowed"
+// @Router       /admin/max-agents [post]
+func (h *AdminHandlers) handleSetMaxAgents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req MaxAgentsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.MaxAgents < 1 {
+		api.WriteError(w, http.StatusBadRequest, "max_agents must be at least 1")
+		return
+	}
+
+	h.scheduler.SetMaxAgents(req.MaxAgents)
+	resp := MaxAgentsResponse{MaxAgents: req.MaxAgents, Drained: []string{}}
+	if req.Converge {
+		resp.Drained = append(resp.Drained, h.scheduler.ConvergeAgents()...)
+	}
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
+// handleReconcileState handles POST /admin/reconcile-state.
+// @Summary      Reconcile the state store with persisted workflows
+// @Description  Corrects tasks and agents whose status in the state store has drifted from their persisted workflow state, such as a task left in progress after its workflow finished. Running workflows and workflows waiting to resume are left alone. Returns the changes made.
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  StateReconciliation  "What was checked and changed"
+// @Failure      405  {object}  map[string]string    "Method not allowed"
+// @Failure      500  {object}  map[string]string    "Failed to load workflow states"
+// @Router       /admin/reconcile-state [post]
+func (h *AdminHandlers) handleReconcileState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	report, err := h.scheduler.ReconcileState(r.Context())
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.WriteJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,187 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+func TestHandleReconcileState(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	statePersister := workflow.NewStatePersister(filepath.Join(repoDir, ".coven"))
+
+	// The store still shows these tasks in progress, as after a crash
+	store.SetTasks([]types.Task{
+		{ID: "task-done", Title: "Finished", Status: types.TaskStatusInProgress},
+		{ID: "task-orphan", Title: "No workflow", Status: types.TaskStatusInProgress},
+		{ID: "task-interrupted", Title: "Interrupted", Status: types.TaskStatusInProgress},
+		{ID: "task-ended", Title: "Ended", Status: types.TaskStatusInProgress},
+	})
+	store.AddAgent(&types.Agent{TaskID: "task-done", Status: types.AgentStatusRunning})
+	for _, state := range []*workflow.WorkflowState{
+		{TaskID: "task-done", WorkflowID: "wf-done", Status: workflow.WorkflowCompleted, StartedAt: time.Now()},
+		{TaskID: "task-interrupted", WorkflowID: "wf-interrupted", Status: workflow.WorkflowRunning, StartedAt: time.Now()},
+	} {
+		if err := statePersister.Save(state); err != nil {
+			t.Fatalf("Failed to save state: %v", err)
+		}
+	}
+	// A completed run that is no longer current is only in the history
+	if err := statePersister.Save(&workflow.WorkflowState{TaskID: "task-ended", WorkflowID: "wf-ended", Status: workflow.WorkflowCompleted, StartedAt: time.Now()}); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+	if err := statePersister.Delete("task-ended"); err != nil {
+		t.Fatalf("Failed to delete state: %v", err)
+	}
+
+	socketPath := filepath.Join(os.TempDir(), "coven-admin-test-"+time.Now().Format("150405")+".sock")
+	server := api.NewServer(socketPath)
+	NewAdminHandlers(sched).Register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://unix/admin/reconcile-state")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+
+	resp, err = client.Post("http://unix/admin/reconcile-state", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var report StateReconciliation
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if report.Checked != 5 {
+		t.Errorf("Checked = %d, want 5", report.Checked)
+	}
+
+	want := map[string]StateChange{
+		"task-done/task_status":   {WorkflowID: "wf-done", From: "in_progress", To: "closed"},
+		"task-orphan/task_status": {From: "in_progress", To: "open"},
+		"task-ended/task_status":  {WorkflowID: "wf-ended", From: "in_progress", To: "closed"},
+		"task-done/agent_status":  {WorkflowID: "wf-done", From: "running", To: "completed"},
+	}
+	if len(report.Changes) != len(want) {
+		t.Fatalf("Changes = %+v, want %d changes", report.Changes, len(want))
+	}
+	for _, change := range report.Changes {
+		w, ok := want[change.TaskID+"/"+change.Field]
+		if !ok {
+			t.Errorf("unexpected change %+v", change)
+			continue
+		}
+		if change.WorkflowID != w.WorkflowID || change.From != w.From || change.To != w.To || change.Reason == "" {
+			t.Errorf("change = %+v, want %+v with a reason", change, w)
+		}
+	}
+
+	statuses := make(map[string]types.TaskStatus)
+	for _, task := range store.GetTasks() {
+		statuses[task.ID] = task.Status
+	}
+	if statuses["task-done"] != types.TaskStatusClosed {
+		t.Errorf("task-done status = %s, want closed", statuses["task-done"])
+	}
+	if statuses["task-orphan"] != types.TaskStatusOpen {
+		t.Errorf("task-orphan status = %s, want open", statuses["task-orphan"])
+	}
+	if statuses["task-interrupted"] != types.TaskStatusInProgress {
+		t.Errorf("task-interrupted status = %s, want it left in progress for resume", statuses["task-interrupted"])
+	}
+	if agent := store.GetAgent("task-done"); agent.Status != types.AgentStatusCompleted {
+		t.Errorf("agent status = %s, want completed", agent.Status)
+	}
+}
+
+func TestHandleSetMaxAgents(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	socketPath := filepath.Join(os.TempDir(), "coven-admin-max-test-"+time.Now().Format("150405")+".sock")
+	server := api.NewServer(socketPath)
+	NewAdminHandlers(sched).Register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://unix/admin/max-agents")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+
+	resp, err = client.Post("http://unix/admin/max-agents", "application/json", strings.NewReader(`{"max_agents": 0}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("zero limit status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+
+	resp, err = client.Post("http://unix/admin/max-agents", "application/json", strings.NewReader(`{"max_agents": 5, "converge": true}`))
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var result MaxAgentsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.MaxAgents != 5 || len(result.Drained) != 0 {
+		t.Errorf("response = %+v, want max_agents 5 and nothing drained", result)
+	}
+
+	sched.mu.RLock()
+	max := sched.maxAgents
+	sched.mu.RUnlock()
+	if max != 5 {
+		t.Errorf("maxAgents = %d, want 5", max)
+	}
+}
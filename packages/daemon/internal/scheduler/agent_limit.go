@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/coven/daemon/internal/questions"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// errAgentLimitLowered is the cancellation cause of a workflow drained by
+// ConvergeAgents. It wraps workflow.ErrShutdown so the workflow is saved as
+// running, as it is when the daemon stops, and can be resumed.
+var errAgentLimitLowered = fmt.Errorf("agent limit lowered: %w", workflow.ErrShutdown)
+
+// errPreempted is the cancellation cause of a workflow stopped to make room
+// for a higher-priority task. Like errAgentLimitLowered, the workflow is
+// saved as running and resumes once an agent slot is free.
+var errPreempted = fmt.Errorf("preempted by a higher-priority task: %w", workflow.ErrShutdown)
+
+// pausedForAgentSlot reports whether ctx was cancelled to free an agent slot,
+// by ConvergeAgents or by preemption.
+func pausedForAgentSlot(ctx context.Context) bool {
+	cause := context.Cause(ctx)
+	return errors.Is(cause, errAgentLimitLowered) || errors.Is(cause, errPreempted)
+}
+
+// drainExcessAgents stops workflows until no more than max agents are
+// running. Workflows of the lowest priority tasks are stopped first, and of
+// those the most recently started. A stopped workflow is saved so it resumes,
+// rerunning its interrupted step, once an agent slot is free. It returns the
+// IDs of the tasks whose workflows were stopped.
+func (s *Scheduler) drainExcessAgents(max int) []string {
+	agentsByTask := make(map[string]int)
+	runningAgents := s.processManager.ListRunning()
+	for _, stepTaskID := range runningAgents {
+		mainTaskID, _ := questions.ParseStepTaskID(stepTaskID)
+		agentsByTask[mainTaskID]++
+	}
+	excess := len(runningAgents) - max
+	if excess <= 0 {
+		return nil
+	}
+
+	candidates := make([]string, 0, len(agentsByTask))
+	for taskID := range agentsByTask {
+		candidates = append(candidates, taskID)
+	}
+	s.sortLowestPriorityFirst(candidates, s.taskPriorities())
+
+	var drained []string
+	for _, taskID := range candidates {
+		if excess <= 0 {
+			break
+		}
+		if !s.cancelTaskWorkflow(taskID, errAgentLimitLowered) {
+			continue
+		}
+		excess -= agentsByTask[taskID]
+		drained = append(drained, taskID)
+	}
+
+	if len(drained) > 0 {
+		s.logger.Info("draining workflows over the agent limit",
+			"max_agents", max,
+			"running_agents", len(runningAgents),
+			"task_ids", drained,
+		)
+	}
+	return drained
+}
+
+// taskPriorities returns the priority of each task in the store.
+func (s *Scheduler) taskPriorities() map[string]int {
+	priorities := make(map[string]int)
+	for _, task := range s.store.GetTasks() {
+		priorities[task.ID] = task.Priority
+	}
+	return priorities
+}
+
+// sortLowestPriorityFirst orders taskIDs lowest priority first, and of
+// tasks with the same priority, the most recently started agent first.
+func (s *Scheduler) sortLowestPriorityFirst(taskIDs []string, priorities map[string]int) {
+	// A higher priority number is a lower priority
+	sort.Slice(taskIDs, func(i, j int) bool {
+		a, b := taskIDs[i], taskIDs[j]
+		if priorities[a] != priorities[b] {
+			return priorities[a] > priorities[b]
+		}
+		agentA, agentB := s.store.GetAgent(a), s.store.GetAgent(b)
+		if agentA != nil && agentB != nil && !agentA.StartedAt.Equal(agentB.StartedAt) {
+			return agentA.StartedAt.After(agentB.StartedAt)
+		}
+		return a < b
+	})
+}
+
+// SetPreemption sets whether, when every agent slot is taken and a task is
+// ready of higher priority than a running one, the lowest-priority running
+// workflow is paused to make room. A paused workflow resumes, rerunning its
+// interrupted step, once an agent slot is free and no task of higher
+// priority is waiting for one.
+func (s *Scheduler) SetPreemption(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.preemption = enabled
+}
+
+// highestReadyPriority returns the highest priority, the lowest number, of
+// the open tasks, and false if none is open.
+func highestReadyPriority(tasks []types.Task) (int, bool) {
+	highest, found := 0, false
+	for _, task := range tasks {
+		if task.Status != types.TaskStatusOpen {
+			continue
+		}
+		if !found || task.Priority < highest {
+			highest, found = task.Priority, true
+		}
+	}
+	return highest, found
+}
+
+// preemptForReadyTasks pauses the lowest-priority running workflow if
+// preemption is enabled and a ready task has higher priority, so the task
+// can start once its agent stops. One workflow is preempted at a time, and
+// none while the workflow limit would keep the task from starting anyway. It
+// returns the task whose workflow was preempted, or "".
+func (s *Scheduler) preemptForReadyTasks(runningAgents []string, maxWorkflows int) string {
+	s.mu.Lock()
+	if !s.preemption {
+		s.mu.Unlock()
+		return ""
+	}
+	// Wait for the last preempted workflow to stop before picking another
+	for taskID := range s.preempting {
+		if s.hasTaskWorkflow(taskID) {
+			s.mu.Unlock()
+			return ""
+		}
+		delete(s.preempting, taskID)
+	}
+	s.mu.Unlock()
+
+	topReady, ok := highestReadyPriority(s.store.GetTasks())
+	if !ok {
+		return ""
+	}
+	if maxWorkflows > 0 && s.activeWorkflowCount() >= maxWorkflows {
+		return ""
+	}
+
+	running := make(map[string]bool)
+	for _, stepTaskID := range runningAgents {
+		mainTaskID, _ := questions.ParseStepTaskID(stepTaskID)
+		running[mainTaskID] = true
+	}
+	candidates := make([]string, 0, len(running))
+	for taskID := range running {
+		candidates = append(candidates, taskID)
+	}
+	priorities := s.taskPriorities()
+	s.sortLowestPriorityFirst(candidates, priorities)
+
+	for _, taskID := range candidates {
+		if priorities[taskID] <= topReady {
+			// Every remaining workflow is of the same or higher priority
+			return ""
+		}
+		if !s.cancelTaskWorkflow(taskID, errPreempted) {
+			continue
+		}
+		s.mu.Lock()
+		s.preempting[taskID] = true
+		s.mu.Unlock()
+		s.logger.Info("preempting workflow for a higher-priority task",
+			"task_id", taskID,
+			"priority", priorities[taskID],
+			"ready_priority", topReady,
+		)
+		return taskID
+	}
+	return ""
+}
+
+// hasTaskWorkflow reports whether the task has a running workflow.
+func (s *Scheduler) hasTaskWorkflow(taskID string) bool {
+	s.taskWorkflowsMu.Lock()
+	defer s.taskWorkflowsMu.Unlock()
+	_, ok := s.taskWorkflows[taskID]
+	return ok
+}
+
+// pauseIfDrained queues a stopped workflow to resume once an agent slot is
+// free, if drainExcessAgents or preemption stopped it. It reports whether it
+// did.
+func (s *Scheduler) pauseIfDrained(ctx context.Context, taskID string) bool {
+	if !pausedForAgentSlot(ctx) {
+		return false
+	}
+
+	state, err := s.statePersister.Load(taskID)
+	if err != nil || state == nil {
+		s.logger.Error("failed to load drained workflow state, it will resume on restart",
+			"task_id", taskID,
+			"error", err,
+		)
+		return true
+	}
+
+	s.mu.Lock()
+	s.pendingResumes[taskID] = state
+	s.awaitingAgentSlot[taskID] = true
+	s.mu.Unlock()
+
+	s.logger.Info("workflow paused, will resume when an agent slot is free",
+		"task_id", taskID,
+		"workflow_id", state.WorkflowID,
+		"reason", context.Cause(ctx).Error(),
+	)
+	return true
+}
@@ -18,6 +18,7 @@ type ProcessAgentRunner struct {
 	processManager *agent.ProcessManager
 	command        string
 	args           []string
+	promptMode     agent.PromptMode
 
 	// Per-task step counters for concurrent workflow support
 	mu           sync.Mutex
@@ -25,8 +26,15 @@ type ProcessAgentRunner struct {
 
 	// onProcessSpawn is called when a process is spawned with its step task ID and PID.
 	onProcessSpawn func(mainTaskID, stepTaskID string, pid int)
+
+	// progressPollInterval is how often WatchProgress checks for new output.
+	progressPollInterval time.Duration
 }
 
+// defaultProgressPollInterval is how often WatchProgress checks a running
+// agent for new output.
+const defaultProgressPollInterval = time.Second
+
 // NewProcessAgentRunner creates a new ProcessAgentRunner.
 func NewProcessAgentRunner(pm *agent.ProcessManager, cmd string, args []string) *ProcessAgentRunner {
 	return &ProcessAgentRunner{
@@ -34,6 +42,8 @@ func NewProcessAgentRunner(pm *agent.ProcessManager, cmd string, args []string)
 		command:        cmd,
 		args:           args,
 		stepCounters:   make(map[string]uint64),
+
+		progressPollInterval: defaultProgressPollInterval,
 	}
 }
 
@@ -52,6 +62,15 @@ func (r *ProcessAgentRunner) SetCommand(cmd string, args []string) {
 	r.args = args
 }
 
+// SetProfile configures the runner to invoke agents as described by profile.
+func (r *ProcessAgentRunner) SetProfile(profile agent.Profile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.command = profile.Command
+	r.args = profile.Args
+	r.promptMode = profile.PromptMode
+}
+
 // OnProcessSpawn sets a callback that's invoked when a process is spawned.
 // The callback receives the main task ID (e.g., "coven-7ub"), the step task ID
 // (e.g., "coven-7ub-step-1"), and the process PID.
@@ -73,13 +92,12 @@ func (r *ProcessAgentRunner) getNextStepID(taskID string) uint64 {
 // It spawns an agent process with the given prompt and waits for completion.
 // If onSpawn is provided, it's called immediately after spawn with the stepTaskID.
 func (r *ProcessAgentRunner) Run(ctx context.Context, workDir, prompt string, onSpawn func(stepTaskID string)) (*workflow.AgentRunResult, error) {
-	// Build args with prompt
+	// Build the invocation for the configured profile
 	r.mu.Lock()
-	args := append([]string{}, r.args...)
-	command := r.command
+	profile := agent.Profile{Command: r.command, Args: r.args, PromptMode: r.promptMode}
 	onProcessSpawn := r.onProcessSpawn
 	r.mu.Unlock()
-	args = append(args, prompt)
+	args, stdin := profile.Invocation(prompt)
 
 	// Extract task ID from workDir (worktree path ends with task ID)
 	// e.g., /path/to/.coven/worktrees/coven-7ub -> coven-7ub
@@ -92,22 +110,26 @@ func (r *ProcessAgentRunner) Run(ctx context.Context, workDir, prompt string, on
 	stepNum := r.getNextStepID(taskID)
 	stepTaskID := fmt.Sprintf("%s-step-%d", taskID, stepNum)
 
-	// Check if we should close stdin (for non-interactive agents like claude -p)
+	// Check if we should close stdin (for non-interactive agents like claude -p).
+	// A prompt passed on stdin closes it once written.
 	closeStdin := false
-	for _, arg := range args {
-		if arg == "-p" || arg == "--print" {
-			closeStdin = true
-			break
+	if stdin == "" {
+		for _, arg := range args {
+			if arg == "-p" || arg == "--print" {
+				closeStdin = true
+				break
+			}
 		}
 	}
 
 	// Spawn the agent
 	processInfo, spawnErr := r.processManager.Spawn(ctx, agent.SpawnConfig{
 		TaskID:     stepTaskID,
-		Command:    command,
+		Command:    profile.Command,
 		Args:       args,
 		WorkingDir: workDir,
 		CloseStdin: closeStdin,
+		Stdin:      stdin,
 	})
 	if spawnErr != nil {
 		return nil, spawnErr
@@ -155,6 +177,43 @@ func (r *ProcessAgentRunner) IsRunning(stepTaskID string) bool {
 	return r.processManager.IsRunning(stepTaskID)
 }
 
+// WatchProgress implements workflow.ProgressAgentRunner.
+// It polls the process's output and calls onProgress with the timestamp of
+// the latest line whenever new output appears.
+func (r *ProcessAgentRunner) WatchProgress(stepTaskID string, onProgress func(at time.Time)) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(r.progressPollInterval)
+		defer ticker.Stop()
+
+		var nextSeq uint64
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				lines, err := r.processManager.GetOutputSince(stepTaskID, nextSeq)
+				if err != nil || len(lines) == 0 {
+					continue
+				}
+				last := lines[len(lines)-1]
+				nextSeq = last.Sequence + 1
+				onProgress(last.Timestamp)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
 // waitForProcess waits for a process to complete and returns the result.
 func (r *ProcessAgentRunner) waitForProcess(ctx context.Context, stepTaskID string) (*workflow.AgentRunResult, error) {
 	// Use a goroutine to handle context cancellation
@@ -173,8 +232,13 @@ func (r *ProcessAgentRunner) waitForProcess(ctx context.Context, stepTaskID stri
 	// Wait for either completion or context cancellation
 	select {
 	case <-ctx.Done():
-		// Context cancelled - kill the agent
-		r.processManager.Kill(stepTaskID)
+		// Context cancelled - stop the agent, gracefully if the task was
+		// stopped rather than killed
+		if killOnCancel(ctx) {
+			r.processManager.Kill(stepTaskID)
+		} else {
+			r.processManager.Stop(stepTaskID)
+		}
 		return &workflow.AgentRunResult{StepTaskID: stepTaskID, ExitCode: -1}, ctx.Err()
 
 	case waitErr := <-errCh:
@@ -204,4 +268,4 @@ func (r *ProcessAgentRunner) waitForProcess(ctx context.Context, stepTaskID stri
 }
 
 // Verify interface compliance
-var _ workflow.AgentRunner = (*ProcessAgentRunner)(nil)
+var _ workflow.ProgressAgentRunner = (*ProcessAgentRunner)(nil)
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -137,6 +138,67 @@ func TestProcessAgentRunner_Run_WithArgs(t *testing.T) {
 	}
 }
 
+func TestProcessAgentRunner_Run_ProfilePromptMode(t *testing.T) {
+	prompt := "Implement the feature.\nRun the tests."
+
+	tests := []struct {
+		name    string
+		profile agent.Profile
+	}{
+		{
+			name: "arg",
+			profile: agent.Profile{
+				Name:       "mock",
+				Command:    "sh",
+				Args:       []string{"-c", `printf '%s' "$0" > prompt.txt`},
+				PromptMode: agent.PromptArg,
+			},
+		},
+		{
+			name: "stdin",
+			profile: agent.Profile{
+				Name:       "mock",
+				Command:    "sh",
+				Args:       []string{"-c", "cat > prompt.txt"},
+				PromptMode: agent.PromptStdin,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := newTestProcessManager(t)
+			runner := NewProcessAgentRunner(pm, "echo", nil)
+			runner.SetProfile(tt.profile)
+
+			workDir := filepath.Join(t.TempDir(), "profile-"+tt.name)
+			if err := os.MkdirAll(workDir, 0755); err != nil {
+				t.Fatalf("Failed to create workDir: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			result, err := runner.Run(ctx, workDir, prompt, nil)
+			if err != nil {
+				t.Fatalf("Run() error: %v", err)
+			}
+			if result.ExitCode != 0 {
+				t.Errorf("exitCode = %d, want 0", result.ExitCode)
+			}
+
+			// The mock agent records the prompt it received
+			got, err := os.ReadFile(filepath.Join(workDir, "prompt.txt"))
+			if err != nil {
+				t.Fatalf("Failed to read received prompt: %v", err)
+			}
+			if string(got) != prompt {
+				t.Errorf("received prompt = %q, want %q", got, prompt)
+			}
+		})
+	}
+}
+
 func TestProcessAgentRunner_Run_ContextCancellation(t *testing.T) {
 	pm := newTestProcessManager(t)
 	// Use sleep to create a long-running process
@@ -342,3 +404,43 @@ func TestProcessAgentRunner_InterfaceCompliance(t *testing.T) {
 		t.Error("ProcessAgentRunner does not implement workflow.AgentRunner")
 	}
 }
+
+func TestProcessAgentRunner_WatchProgress(t *testing.T) {
+	pm := newTestProcessManager(t)
+	runner := NewProcessAgentRunner(pm, "sh", []string{"-c"})
+	runner.progressPollInterval = 10 * time.Millisecond
+
+	baseDir := t.TempDir()
+	workDir := filepath.Join(baseDir, "progress-test")
+	if err := os.MkdirAll(workDir, 0755); err != nil {
+		t.Fatalf("Failed to create workDir: %v", err)
+	}
+
+	var mu sync.Mutex
+	var progress []time.Time
+	stop := func() {}
+	onSpawn := func(stepTaskID string) {
+		stop = runner.WatchProgress(stepTaskID, func(at time.Time) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, at)
+		})
+	}
+
+	_, err := runner.Run(context.Background(), workDir, "for i in 1 2 3 4; do echo tick; sleep 0.05; done", onSpawn)
+	stop()
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) < 2 {
+		t.Fatalf("got %d progress reports, want at least 2", len(progress))
+	}
+	for i := 1; i < len(progress); i++ {
+		if !progress[i].After(progress[i-1]) {
+			t.Errorf("progress[%d] = %v, want after %v", i, progress[i], progress[i-1])
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// BeadClosedError is the cancellation cause of a workflow whose bead was
+// closed or deleted in beads while the workflow ran.
+type BeadClosedError struct {
+	TaskID string
+	Reason string
+}
+
+func (e *BeadClosedError) Error() string {
+	return "workflow cancelled: " + e.Reason
+}
+
+// IsBeadClosed returns true if the error is a BeadClosedError.
+func IsBeadClosed(err error) bool {
+	var closedErr *BeadClosedError
+	return errors.As(err, &closedErr)
+}
+
+// CancelForClosedBead cancels the running workflow of a task whose bead was
+// closed or deleted outside coven, which kills its agent. As the workflow
+// stops, it is recorded as cancelled with reason and its worktree is removed;
+// the task branch is kept so no committed work is lost. It reports whether the
+// task had a running workflow to cancel.
+func (s *Scheduler) CancelForClosedBead(taskID, reason string) bool {
+	if !s.cancelTaskWorkflow(taskID, &BeadClosedError{TaskID: taskID, Reason: reason}) {
+		return false
+	}
+	s.logger.Info("cancelling workflow for closed bead", "task_id", taskID, "reason", reason)
+	return true
+}
+
+// beadClosedCause returns the BeadClosedError a workflow context was
+// cancelled with, if any.
+func beadClosedCause(ctx context.Context) (*BeadClosedError, bool) {
+	var closedErr *BeadClosedError
+	if errors.As(context.Cause(ctx), &closedErr) {
+		return closedErr, true
+	}
+	return nil, false
+}
+
+// finishClosedBead cleans up after a workflow cancelled by CancelForClosedBead.
+// The bead's status is left as beads reports it.
+func (s *Scheduler) finishClosedBead(taskID string, cause *BeadClosedError) {
+	// A workflow stopped mid-step is saved as failed; record why it stopped
+	if state, err := s.statePersister.Load(taskID); err == nil && state != nil {
+		state.Status = workflow.WorkflowCancelled
+		state.Error = cause.Error()
+		if err := s.statePersister.Save(state); err != nil {
+			s.logger.Warn("failed to save cancelled workflow state", "task_id", taskID, "error", err)
+		}
+	}
+
+	if err := s.worktreesFor(taskID).Remove(context.Background(), taskID); err != nil {
+		s.logger.Warn("failed to remove worktree", "task_id", taskID, "error", err)
+	}
+
+	// Mark the agent killed last, once the cleanup it reports is done
+	s.store.UpdateAgentStatus(taskID, types.AgentStatusKilled)
+	s.store.SetAgentError(taskID, cause.Error())
+
+	s.logger.Info("workflow cancelled for closed bead", "task_id", taskID, "reason", cause.Reason)
+}
@@ -0,0 +1,81 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/beads"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+func TestCancelForClosedBead(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	writeShutdownTestGrimoire(t, covenDir, "exec sleep 30")
+	startShutdownTestWorkflow(t, sched, store)
+
+	// beads now reports the bead closed, as if closed from the command line
+	bdDir := t.TempDir()
+	mockBd := filepath.Join(bdDir, "bd")
+	script := `#!/bin/bash
+if [ "$1" = "list" ]; then
+    echo '[{"id":"task-1","title":"Test Task","status":"closed","priority":2,"issue_type":"task"}]'
+fi
+`
+	if err := os.WriteFile(mockBd, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write mock bd: %v", err)
+	}
+	client := beads.NewClient(repoDir)
+	client.SetBdPath(mockBd)
+
+	poller := beads.NewPoller(client, store, nil, sched.logger)
+	poller.OnBeadClosed(func(taskID, reason string) {
+		sched.CancelForClosedBead(taskID, reason)
+	})
+	if err := poller.Poll(context.Background()); err != nil {
+		t.Fatalf("Poll() error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if agent := store.GetAgent("task-1"); agent != nil && agent.Status == types.AgentStatusKilled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("workflow was not cancelled after its bead was closed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if _, err := os.Stat(sched.worktreeManager.GetPath("task-1")); !os.IsNotExist(err) {
+		t.Error("worktree should be removed")
+	}
+
+	state, err := workflow.NewStatePersister(covenDir).Load("task-1")
+	if err != nil || state == nil {
+		t.Fatalf("Load() = %v, %v, want the cancelled state", state, err)
+	}
+	if state.Status != workflow.WorkflowCancelled {
+		t.Errorf("workflow status = %q, want %q", state.Status, workflow.WorkflowCancelled)
+	}
+	if !strings.Contains(state.Error, "bead was closed") {
+		t.Errorf("workflow error = %q, want the reason", state.Error)
+	}
+
+	if status := store.GetTasks()[0].Status; status != types.TaskStatusClosed {
+		t.Errorf("task status = %q, want it left %q", status, types.TaskStatusClosed)
+	}
+}
+
+func TestCancelForClosedBead_NoWorkflow(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	if sched.CancelForClosedBead("task-unknown", "bead was deleted") {
+		t.Error("CancelForClosedBead() = true for a task with no running workflow")
+	}
+}
@@ -17,8 +17,11 @@ const (
 func (s *Scheduler) cleanupOldFiles() {
 	cutoff := time.Now().Add(-time.Duration(DefaultRetentionDays) * 24 * time.Hour)
 
-	// Clean up old workflow state files
+	// Clean up old workflow state files: each run's state, task indexes, and
+	// task-keyed state from older daemons
 	workflowsDir := filepath.Join(s.covenDir, "workflows")
+	s.cleanupDirectory(filepath.Join(workflowsDir, "runs"), cutoff, ".json")
+	s.cleanupDirectory(filepath.Join(workflowsDir, "tasks"), cutoff, ".json")
 	s.cleanupDirectory(workflowsDir, cutoff, ".json")
 
 	// Clean up old workflow log files
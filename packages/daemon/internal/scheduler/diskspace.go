@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/coven/daemon/pkg/types"
+)
+
+// DiskSpaceChecker reports the free disk space, in bytes, available at a path.
+type DiskSpaceChecker func(path string) (uint64, error)
+
+// FreeDiskSpace returns the free disk space available to unprivileged users at path.
+func FreeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem: %w", err)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
+
+// DiskSpaceError is returned when there is not enough free disk space to start an agent.
+type DiskSpaceError struct {
+	Path     string
+	Free     uint64
+	Required uint64
+}
+
+func (e *DiskSpaceError) Error() string {
+	return fmt.Sprintf("insufficient disk space at %s: %d MB free, %d MB required",
+		e.Path, e.Free/(1024*1024), e.Required/(1024*1024))
+}
+
+// IsDiskSpaceError checks if an error is a DiskSpaceError.
+func IsDiskSpaceError(err error) bool {
+	_, ok := err.(*DiskSpaceError)
+	return ok
+}
+
+// SetMinFreeDisk sets the minimum free disk space, in bytes, required to start
+// a new agent. Zero disables the check.
+func (s *Scheduler) SetMinFreeDisk(bytes uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.minFreeDisk = bytes
+}
+
+// SetDiskSpaceChecker sets the function used to measure free disk space (for testing).
+func (s *Scheduler) SetDiskSpaceChecker(checker DiskSpaceChecker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.diskChecker = checker
+}
+
+// checkDiskSpace verifies there is enough free space for a new worktree.
+// It returns a DiskSpaceError when space is below the configured threshold.
+// Failures to measure disk space are logged and do not block agents.
+func (s *Scheduler) checkDiskSpace() error {
+	s.mu.RLock()
+	required := s.minFreeDisk
+	checker := s.diskChecker
+	s.mu.RUnlock()
+
+	if required == 0 || checker == nil {
+		return nil
+	}
+
+	path := s.worktreeManager.RepoPath()
+	free, err := checker(path)
+	if err != nil {
+		s.logger.Warn("failed to check free disk space", "path", path, "error", err)
+		return nil
+	}
+
+	if free < required {
+		return &DiskSpaceError{Path: path, Free: free, Required: required}
+	}
+	return nil
+}
+
+// hasDiskSpaceForAgents checks free disk space during reconciliation.
+// It logs when space drops below or recovers above the threshold rather than
+// on every reconcile, so a full disk doesn't flood the log.
+func (s *Scheduler) hasDiskSpaceForAgents() bool {
+	err := s.checkDiskSpace()
+
+	s.mu.Lock()
+	wasLow := s.diskLow
+	s.diskLow = err != nil
+	s.mu.Unlock()
+
+	if err != nil {
+		if !wasLow {
+			s.logger.Warn("disk space low, not starting new agents", "error", err)
+		}
+		return false
+	}
+	if wasLow {
+		s.logger.Info("disk space recovered, resuming agent starts")
+	}
+	return true
+}
+
+// Conditions returns degraded conditions that prevent the scheduler from
+// starting new agents. An empty result means the scheduler is healthy.
+func (s *Scheduler) Conditions() []types.HealthCondition {
+	var conditions []types.HealthCondition
+	if err := s.checkDiskSpace(); err != nil {
+		conditions = append(conditions, types.HealthCondition{
+			Name:    types.HealthConditionLowDiskSpace,
+			Message: err.Error() + "; new agents will not be started",
+		})
+	}
+	return conditions
+}
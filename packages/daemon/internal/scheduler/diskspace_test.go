@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/pkg/types"
+)
+
+const testMB = 1024 * 1024
+
+// fakeDiskSpace returns a checker that always reports the given free space.
+func fakeDiskSpace(free uint64) DiskSpaceChecker {
+	return func(path string) (uint64, error) {
+		return free, nil
+	}
+}
+
+func TestFreeDiskSpace(t *testing.T) {
+	free, err := FreeDiskSpace(t.TempDir())
+	if err != nil {
+		t.Fatalf("FreeDiskSpace() error: %v", err)
+	}
+	if free == 0 {
+		t.Error("FreeDiskSpace() = 0, want non-zero")
+	}
+
+	if _, err := FreeDiskSpace("/nonexistent/path/for/coven"); err == nil {
+		t.Error("FreeDiskSpace() should fail for a missing path")
+	}
+}
+
+func TestSchedulerStartAgent_LowDiskSpace(t *testing.T) {
+	sched, store, _ := newTestScheduler(t)
+	sched.SetMinFreeDisk(1024 * testMB)
+	sched.SetDiskSpaceChecker(fakeDiskSpace(10 * testMB))
+
+	task := types.Task{ID: "task-1", Title: "Test Task", Status: types.TaskStatusOpen}
+	err := sched.StartAgentForTask(context.Background(), task)
+	if !IsDiskSpaceError(err) {
+		t.Fatalf("StartAgentForTask() error = %v, want DiskSpaceError", err)
+	}
+
+	if store.GetAgent("task-1") != nil {
+		t.Error("No agent should be created when disk space is low")
+	}
+	if _, err := os.Stat(sched.worktreeManager.GetPath("task-1")); !os.IsNotExist(err) {
+		t.Error("No worktree should be created when disk space is low")
+	}
+}
+
+func TestSchedulerReconcile_LowDiskSpace(t *testing.T) {
+	sched, store, _ := newTestScheduler(t)
+	sched.SetMinFreeDisk(1024 * testMB)
+
+	free := uint64(10 * testMB)
+	sched.SetDiskSpaceChecker(func(path string) (uint64, error) {
+		return free, nil
+	})
+
+	store.SetTasks([]types.Task{
+		{ID: "task-1", Title: "Test Task", Status: types.TaskStatusOpen},
+	})
+
+	if err := sched.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	if store.GetAgent("task-1") != nil {
+		t.Fatal("Reconcile should not start agents when disk space is low")
+	}
+
+	// Once space is freed, agents start again
+	sched.SetDiskSpaceChecker(fakeDiskSpace(2048 * testMB))
+	if err := sched.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if store.GetAgent("task-1") == nil {
+		t.Error("Reconcile should start agents once disk space recovers")
+	}
+}
+
+func TestSchedulerCheckDiskSpace(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	t.Run("disabled by default", func(t *testing.T) {
+		sched.SetDiskSpaceChecker(fakeDiskSpace(0))
+		if err := sched.checkDiskSpace(); err != nil {
+			t.Errorf("checkDiskSpace() error = %v, want nil when no threshold is set", err)
+		}
+	})
+
+	t.Run("enough space", func(t *testing.T) {
+		sched.SetMinFreeDisk(100 * testMB)
+		sched.SetDiskSpaceChecker(fakeDiskSpace(200 * testMB))
+		if err := sched.checkDiskSpace(); err != nil {
+			t.Errorf("checkDiskSpace() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("checker error does not block", func(t *testing.T) {
+		sched.SetMinFreeDisk(100 * testMB)
+		sched.SetDiskSpaceChecker(func(path string) (uint64, error) {
+			return 0, errors.New("statfs failed")
+		})
+		if err := sched.checkDiskSpace(); err != nil {
+			t.Errorf("checkDiskSpace() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("low space", func(t *testing.T) {
+		sched.SetMinFreeDisk(100 * testMB)
+		sched.SetDiskSpaceChecker(fakeDiskSpace(50 * testMB))
+		err := sched.checkDiskSpace()
+		if !IsDiskSpaceError(err) {
+			t.Fatalf("checkDiskSpace() error = %v, want DiskSpaceError", err)
+		}
+		dsErr := err.(*DiskSpaceError)
+		if dsErr.Free != 50*testMB || dsErr.Required != 100*testMB {
+			t.Errorf("DiskSpaceError = %+v", dsErr)
+		}
+	})
+}
+
+func TestSchedulerConditions(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+	sched.SetMinFreeDisk(100 * testMB)
+
+	sched.SetDiskSpaceChecker(fakeDiskSpace(200 * testMB))
+	if conditions := sched.Conditions(); len(conditions) != 0 {
+		t.Errorf("Conditions() = %+v, want none", conditions)
+	}
+
+	sched.SetDiskSpaceChecker(fakeDiskSpace(50 * testMB))
+	conditions := sched.Conditions()
+	if len(conditions) != 1 {
+		t.Fatalf("Conditions() returned %d conditions, want 1", len(conditions))
+	}
+	if conditions[0].Name != types.HealthConditionLowDiskSpace {
+		t.Errorf("Name = %q, want %q", conditions[0].Name, types.HealthConditionLowDiskSpace)
+	}
+	if conditions[0].Message == "" {
+		t.Error("Message should describe the condition")
+	}
+}
@@ -0,0 +1,47 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+)
+
+// BranchDivergence reports how far a task's worktree branch and the base
+// branch have moved apart.
+type BranchDivergence struct {
+	Branch     string `json:"branch"`
+	BaseBranch string `json:"base_branch"`
+
+	// CommitsAhead is the number of commits on the branch that aren't on
+	// the base branch.
+	CommitsAhead int `json:"commits_ahead"`
+
+	// CommitsBehind is the number of commits made to the base branch since
+	// the branch was created that aren't on it. The more there are, the
+	// more likely merging is to conflict.
+	CommitsBehind int `json:"commits_behind"`
+}
+
+// BranchDivergence compares the task's worktree branch with the base branch.
+// Only commits are compared; uncommitted changes in the worktree aren't
+// counted.
+func (s *Scheduler) BranchDivergence(ctx context.Context, taskID string) (*BranchDivergence, error) {
+	worktrees := s.worktreesFor(taskID)
+	wtInfo, err := worktrees.Get(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get worktree info: %w", err)
+	}
+	baseBranch, err := worktrees.GetBaseBranch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base branch: %w", err)
+	}
+	divergence, err := worktrees.Divergence(ctx, wtInfo.Branch, baseBranch)
+	if err != nil {
+		return nil, err
+	}
+	return &BranchDivergence{
+		Branch:        wtInfo.Branch,
+		BaseBranch:    baseBranch,
+		CommitsAhead:  divergence.Ahead,
+		CommitsBehind: divergence.Behind,
+	}, nil
+}
@@ -3,10 +3,13 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/grimoire"
 	"github.com/coven/daemon/internal/state"
 	"github.com/coven/daemon/pkg/types"
 )
@@ -49,6 +52,8 @@ func (h *Handlers) handleTaskByID(w http.ResponseWriter, r *http.Request) {
 		h.handleTaskStart(w, r, taskID)
 	case "stop":
 		h.handleTaskStop(w, r, taskID)
+	case "workflows":
+		h.handleTaskWorkflows(w, r, taskID)
 	default:
 		http.Error(w, "Unknown action", http.StatusNotFound)
 	}
@@ -61,10 +66,15 @@ func (h *Handlers) handleTaskByID(w http.ResponseWriter, r *http.Request) {
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Task ID"
+// @Param        body body      object  false "Optional body: {\"grimoire_hash\": \"...\"} to pin a grimoire snapshot and {\"inputs\": {...}} for the grimoire's inputs"
 // @Success      200  {object}  map[string]interface{}  "Start response"
+// @Failure      400  {object}  map[string]string       "Invalid request body or inputs, or grimoire snapshot not found"
 // @Failure      404  {object}  map[string]string       "Task not found"
 // @Failure      405  {object}  map[string]string       "Method not allowed"
+// @Failure      409  {object}  map[string]string       "Concurrency group busy"
+// @Failure      422  {object}  map[string]string       "Required grimoire inputs missing"
 // @Failure      500  {object}  map[string]string       "Failed to start agent"
+// @Failure      507  {object}  map[string]string       "Insufficient disk space"
 // @Router       /tasks/{id}/start [post]
 func (h *Handlers) handleTaskStart(w http.ResponseWriter, r *http.Request, taskID string) {
 	if r.Method != http.MethodPost {
@@ -103,10 +113,34 @@ func (h *Handlers) handleTaskStart(w http.ResponseWriter, r *http.Request, taskI
 		return
 	}
 
+	// Parse optional grimoire pin and inputs from body. A pin that can't be
+	// read must not start the task unpinned.
+	var body struct {
+		GrimoireHash string                 `json:"grimoire_hash"`
+		Inputs       map[string]interface{} `json:"inputs"`
+	}
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Force start the task (bypass scheduler)
-	ctx := context.Background()
-	if err := h.scheduler.StartAgentForTask(ctx, *task); err != nil {
-		http.Error(w, "Failed to start agent: "+err.Error(), http.StatusInternalServerError)
+	err := h.scheduler.StartAgentForTaskWithInputs(context.Background(), *task, body.GrimoireHash, body.Inputs)
+	if err != nil {
+		switch {
+		case grimoire.IsSnapshotNotFound(err), IsInvalidInputs(err):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case grimoire.IsMissingInputs(err):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		case IsDiskSpaceError(err):
+			http.Error(w, err.Error(), http.StatusInsufficientStorage)
+		case IsConcurrencyGroupBusy(err):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to start agent: "+err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -126,11 +160,12 @@ func (h *Handlers) handleTaskStart(w http.ResponseWriter, r *http.Request, taskI
 
 // handleTaskStop handles POST /tasks/:id/stop
 // @Summary      Stop a task
-// @Description  Stops the agent working on a specific task
+// @Description  Stops the task's workflow without ending it: the agent is stopped gracefully, or killed with force, and the task is blocked with its worktree kept so the scheduler doesn't restart it. The workflow resumes from the interrupted step when the task is started again. Use POST /workflows/:id/cancel to end the workflow instead.
 // @Tags         tasks
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Task ID"
+// @Param        body body      object  false "Optional body: {\"force\": true} to kill the agent without a grace period"
 // @Success      200  {object}  map[string]interface{}  "Stop response"
 // @Failure      404  {object}  map[string]string       "No agent running for task"
 // @Failure      405  {object}  map[string]string       "Method not allowed"
@@ -148,24 +183,91 @@ func (h *Handlers) handleTaskStop(w http.ResponseWriter, r *http.Request, taskID
 		return
 	}
 
-	// Stop the agent
-	if err := h.scheduler.StopAgent(taskID); err != nil {
-		// Agent might already be stopped
-		h.store.UpdateAgentStatus(taskID, types.AgentStatusKilled)
-	} else {
+	// Parse optional force flag from body
+	var body struct {
+		Force bool `json:"force"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	// Stop the workflow; the task is returned to open as it winds down
+	if !h.scheduler.StopTask(taskID, body.Force) {
+		// No workflow to stop, so just stop the agent; it might already be stopped
+		if body.Force {
+			h.scheduler.KillAgent(taskID)
+		} else {
+			h.scheduler.StopAgent(taskID)
+		}
 		h.store.UpdateAgentStatus(taskID, types.AgentStatusKilled)
 	}
 
+	status, message := "stopped", "Agent stopped for task"
+	if body.Force {
+		status, message = "killed", "Agent killed for task"
+	}
 	response := struct {
 		TaskID  string `json:"task_id"`
 		Status  string `json:"status"`
 		Message string `json:"message"`
 	}{
 		TaskID:  taskID,
-		Status:  "stopped",
-		Message: "Agent stopped for task",
+		Status:  status,
+		Message: message,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// TaskWorkflowItem is one of a task's workflow runs in GET /tasks/:id/workflows.
+type TaskWorkflowItem struct {
+	WorkflowListItem
+
+	// Current is set for the run the task's workflow endpoints act on.
+	Current bool `json:"current"`
+}
+
+// TaskWorkflowsResponse is the response for GET /tasks/:id/workflows.
+type TaskWorkflowsResponse struct {
+	TaskID    string             `json:"task_id"`
+	Workflows []TaskWorkflowItem `json:"workflows"`
+	Count     int                `json:"count"`
+}
+
+// handleTaskWorkflows handles GET /tasks/:id/workflows
+// @Summary      List a task's workflow runs
+// @Description  Lists every retained workflow run for a task, oldest first
+// @Tags         tasks
+// @Produce      json
+// @Param        id   path      string                 true  "Task ID"
+// @Success      200  {object}  TaskWorkflowsResponse  "Workflow runs"
+// @Failure      405  {object}  map[string]string      "Method not allowed"
+// @Failure      500  {object}  map[string]string      "Failed to read workflow history"
+// @Router       /tasks/{id}/workflows [get]
+func (h *Handlers) handleTaskWorkflows(w http.ResponseWriter, r *http.Request, taskID string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	history, currentID, err := h.scheduler.WorkflowHistory(taskID)
+	if err != nil {
+		http.Error(w, "Failed to read workflow history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	workflows := make([]TaskWorkflowItem, 0, len(history))
+	for _, state := range history {
+		workflows = append(workflows, TaskWorkflowItem{
+			WorkflowListItem: newWorkflowListItem(state),
+			Current:          currentID != "" && state.WorkflowID == currentID,
+		})
+	}
+
+	api.WriteJSON(w, http.StatusOK, TaskWorkflowsResponse{
+		TaskID:    taskID,
+		Workflows: workflows,
+		Count:     len(workflows),
+	})
+}
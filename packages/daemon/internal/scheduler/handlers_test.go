@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/coven/daemon/internal/api"
 	"github.com/coven/daemon/internal/state"
+	"github.com/coven/daemon/internal/workflow"
 	"github.com/coven/daemon/pkg/types"
 )
 
@@ -163,6 +165,68 @@ func TestHandleTaskStart(t *testing.T) {
 		sched.KillAgent("task-running")
 	})
 
+	t.Run("POST with unknown grimoire hash returns bad request", func(t *testing.T) {
+		store.SetTasks([]types.Task{
+			{ID: "task-pinned", Title: "Test Task", Status: types.TaskStatusOpen},
+		})
+
+		body := strings.NewReader(`{"grimoire_hash": "` + strings.Repeat("a", 64) + `"}`)
+		resp, err := client.Post("http://unix/tasks/task-pinned/start", "application/json", body)
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+		if sched.IsAgentRunning("task-pinned") {
+			t.Error("Agent should not start for unknown grimoire hash")
+		}
+	})
+
+	t.Run("POST with malformed body returns bad request", func(t *testing.T) {
+		store.SetTasks([]types.Task{
+			{ID: "task-malformed", Title: "Test Task", Status: types.TaskStatusOpen},
+		})
+
+		body := strings.NewReader(`{"grimoire_hash": `)
+		resp, err := client.Post("http://unix/tasks/task-malformed/start", "application/json", body)
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+		if sched.IsAgentRunning("task-malformed") {
+			t.Error("Agent should not start unpinned for a malformed body")
+		}
+	})
+
+	t.Run("POST with low disk space returns insufficient storage", func(t *testing.T) {
+		store.SetTasks([]types.Task{
+			{ID: "task-disk", Title: "Test Task", Status: types.TaskStatusOpen},
+		})
+		sched.SetMinFreeDisk(1024 * 1024 * 1024)
+		sched.SetDiskSpaceChecker(fakeDiskSpace(1024))
+		defer sched.SetMinFreeDisk(0)
+
+		resp, err := client.Post("http://unix/tasks/task-disk/start", "application/json", nil)
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusInsufficientStorage {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusInsufficientStorage)
+		}
+		if sched.IsAgentRunning("task-disk") {
+			t.Error("Agent should not start when disk space is low")
+		}
+	})
+
 	t.Run("GET returns method not allowed", func(t *testing.T) {
 		store.SetTasks([]types.Task{
 			{ID: "task-method", Title: "Test Task", Status: types.TaskStatusOpen},
@@ -180,6 +244,104 @@ func TestHandleTaskStart(t *testing.T) {
 	})
 }
 
+func TestHandleTaskStart_ConcurrencyGroupBusy(t *testing.T) {
+	_, store, sched, client, cleanup := setupTestTaskHandlers(t)
+	defer cleanup()
+
+	grimoiresDir := filepath.Join(sched.covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	grimoireYAML := `name: migrate
+description: Run migrations
+concurrency_group: db-migrations
+steps:
+  - name: migrate
+    type: script
+    command: "true"
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "migrate.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	store.SetTasks([]types.Task{
+		{ID: "task-migrate", Title: "Migrate", Status: types.TaskStatusOpen, Labels: []string{"grimoire:migrate"}},
+	})
+	sched.acquireConcurrencyGroup("db-migrations", "task-other")
+
+	resp, err := client.Post("http://unix/tasks/task-migrate/start", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+	if sched.IsAgentRunning("task-migrate") {
+		t.Error("task-migrate started while its concurrency group was held")
+	}
+}
+
+func TestHandleTaskStart_Inputs(t *testing.T) {
+	_, store, sched, client, cleanup := setupTestTaskHandlers(t)
+	defer cleanup()
+	writeInputsTestGrimoire(t, sched.covenDir)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"missing required inputs", `{}`, http.StatusUnprocessableEntity},
+		{"undeclared input", `{"inputs": {"environment": "staging", "region": "eu"}}`, http.StatusBadRequest},
+		{"inputs given", `{"inputs": {"environment": "staging"}}`, http.StatusOK},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskID := fmt.Sprintf("task-inputs-%d", i)
+			store.SetTasks([]types.Task{
+				{ID: taskID, Title: "Deploy", Status: types.TaskStatusOpen, Labels: []string{"grimoire:inputs-test"}},
+			})
+
+			resp, err := client.Post("http://unix/tasks/"+taskID+"/start", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if agent := store.GetAgent(taskID); agent != nil {
+					t.Errorf("task started with agent %+v, want it not started", agent)
+				}
+				return
+			}
+
+			// The completed run is kept in the task's history
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				history, _, err := sched.WorkflowHistory(taskID)
+				if err != nil {
+					t.Fatalf("WorkflowHistory() error: %v", err)
+				}
+				if len(history) > 0 && history[0].Status == workflow.WorkflowCompleted {
+					if got := history[0].Inputs["environment"]; got != "staging" {
+						t.Errorf("Inputs[environment] = %v, want %q", got, "staging")
+					}
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("workflow for %s did not complete", taskID)
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		})
+	}
+}
+
 func TestHandleTaskStop(t *testing.T) {
 	_, store, _, client, cleanup := setupTestTaskHandlers(t)
 	defer cleanup()
@@ -231,6 +393,33 @@ func TestHandleTaskStop(t *testing.T) {
 		}
 	})
 
+	t.Run("POST with force kills the agent", func(t *testing.T) {
+		store.AddAgent(&types.Agent{
+			TaskID:    "task-kill",
+			Status:    types.AgentStatusRunning,
+			StartedAt: time.Now(),
+		})
+
+		resp, err := client.Post("http://unix/tasks/task-kill/stop", "application/json", strings.NewReader(`{"force": true}`))
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if result.Status != "killed" {
+			t.Errorf("Status = %q, want %q", result.Status, "killed")
+		}
+		if agent := store.GetAgent("task-kill"); agent.Status != types.AgentStatusKilled {
+			t.Errorf("agent status = %q, want %q", agent.Status, types.AgentStatusKilled)
+		}
+	})
+
 	t.Run("GET returns method not allowed", func(t *testing.T) {
 		store.AddAgent(&types.Agent{
 			TaskID:    "task-method-stop",
@@ -250,6 +439,71 @@ func TestHandleTaskStop(t *testing.T) {
 	})
 }
 
+func TestHandleTaskWorkflows(t *testing.T) {
+	_, _, sched, client, cleanup := setupTestTaskHandlers(t)
+	defer cleanup()
+
+	persister := workflow.NewStatePersister(sched.covenDir)
+	for _, state := range []*workflow.WorkflowState{
+		{TaskID: "task-1", WorkflowID: "wf-1", GrimoireName: "build", Status: workflow.WorkflowFailed, Error: "tests failed"},
+		{TaskID: "task-1", WorkflowID: "wf-2", GrimoireName: "build", Status: workflow.WorkflowBlocked},
+		{TaskID: "task-2", WorkflowID: "wf-3", GrimoireName: "build", Status: workflow.WorkflowRunning},
+	} {
+		if err := persister.Save(state); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+	}
+
+	resp, err := client.Get("http://unix/tasks/task-1/workflows")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var body TaskWorkflowsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if body.TaskID != "task-1" || body.Count != 2 || len(body.Workflows) != 2 {
+		t.Fatalf("response = %+v, want task-1's two runs", body)
+	}
+	first, second := body.Workflows[0], body.Workflows[1]
+	if first.WorkflowID != "wf-1" || first.Current || first.Error != "tests failed" {
+		t.Errorf("first run = %+v, want wf-1, not current, with its error", first)
+	}
+	if second.WorkflowID != "wf-2" || !second.Current || second.Status != workflow.WorkflowBlocked {
+		t.Errorf("second run = %+v, want wf-2, current and blocked", second)
+	}
+
+	t.Run("POST returns 405", func(t *testing.T) {
+		resp, err := client.Post("http://unix/tasks/task-1/workflows", "application/json", nil)
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("task without runs", func(t *testing.T) {
+		resp, err := client.Get("http://unix/tasks/task-none/workflows")
+		if err != nil {
+			t.Fatalf("GET error: %v", err)
+		}
+		defer resp.Body.Close()
+		var body TaskWorkflowsResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		if resp.StatusCode != http.StatusOK || body.Count != 0 || body.Workflows == nil {
+			t.Errorf("Status = %d, body = %+v, want 200 with an empty list", resp.StatusCode, body)
+		}
+	})
+}
+
 func TestHandleTaskUnknownAction(t *testing.T) {
 	_, _, _, client, cleanup := setupTestTaskHandlers(t)
 	defer cleanup()
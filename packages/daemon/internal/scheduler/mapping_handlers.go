@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/workflow"
+)
+
+// MappingHandlers provides HTTP handlers for the grimoire mapping configuration.
+type MappingHandlers struct {
+	runner *WorkflowRunner
+}
+
+// NewMappingHandlers creates new grimoire mapping handlers.
+func NewMappingHandlers(scheduler *Scheduler) *MappingHandlers {
+	return &MappingHandlers{
+		runner: scheduler.workflowRunner,
+	}
+}
+
+// Register registers grimoire mapping handlers with the server.
+func (h *MappingHandlers) Register(server *api.Server) {
+	server.RegisterHandlerFunc("/config/grimoire-mapping", h.handleGrimoireMapping)
+}
+
+// handleGrimoireMapping handles GET and PUT /config/grimoire-mapping.
+// @Summary      Get or update the grimoire mapping
+// @Description  GET returns the rules that pick a task's grimoire when it has no grimoire: label. PUT replaces them, after checking every referenced grimoire exists, and saves them to .coven/grimoire-mapping.json. Tasks started afterwards use the new rules.
+// @Tags         config
+// @Accept       json
+// @Produce      json
+// @Param        body body      workflow.GrimoireMappingConfig  false  "New mapping (PUT only)"
+// @Success      200  {object}  workflow.GrimoireMappingConfig  "Current mapping"
+// @Failure      400  {object}  map[string]string               "Invalid mapping"
+// @Failure      405  {object}  map[string]string               "Method not allowed"
+// @Failure      500  {object}  map[string]string               "Failed to load or save mapping"
+// @Router       /config/grimoire-mapping [get]
+// @Router       /config/grimoire-mapping [put]
+func (h *MappingHandlers) handleGrimoireMapping(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		cfg, err := h.runner.GrimoireMapping()
+		if err != nil {
+			api.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		api.WriteJSON(w, http.StatusOK, cfg)
+
+	case http.MethodPut:
+		var cfg workflow.GrimoireMappingConfig
+		if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+			api.WriteError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if err := h.runner.ValidateGrimoireMapping(&cfg); err != nil {
+			api.WriteError(w, http.StatusBadRequest, "invalid grimoire mapping: "+err.Error())
+			return
+		}
+		if err := h.runner.SetGrimoireMapping(&cfg); err != nil {
+			api.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		api.WriteJSON(w, http.StatusOK, &cfg)
+
+	default:
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+func setupTestMappingHandlers(t *testing.T) (*Scheduler, *http.Client, string) {
+	t.Helper()
+
+	sched, _, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+
+	socketPath := filepath.Join(os.TempDir(), "coven-mapping-test-"+time.Now().Format("150405")+".sock")
+	server := api.NewServer(socketPath)
+	NewMappingHandlers(sched).Register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	return sched, client, covenDir
+}
+
+func writeMappingTestGrimoire(t *testing.T, covenDir, name string) {
+	t.Helper()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	content := "name: " + name + "\ndescription: Test grimoire\nsteps:\n  - name: test\n    type: script\n    command: echo test\n"
+	if err := os.WriteFile(filepath.Join(grimoireDir, name+".yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+}
+
+func putGrimoireMapping(t *testing.T, client *http.Client, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPut, "http://unix/config/grimoire-mapping", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatalf("NewRequest error: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("PUT error: %v", err)
+	}
+	return resp
+}
+
+func TestHandleGrimoireMapping_Update(t *testing.T) {
+	sched, client, covenDir := setupTestMappingHandlers(t)
+	writeMappingTestGrimoire(t, covenDir, "implement-bead")
+	writeMappingTestGrimoire(t, covenDir, "bugfix-bead")
+
+	task := types.Task{ID: "task-bug", Type: "bug"}
+	if name, err := sched.workflowRunner.grimoireMapper.Resolve(beadInfoForTask(task)); err != nil || name != "implement-bead" {
+		t.Fatalf("Resolve() = %q, %v; want the default mapping", name, err)
+	}
+
+	resp := putGrimoireMapping(t, client, `{"default": "implement-bead", "by_type": {"bug": "bugfix-bead"}}`)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The scheduler's mapper uses the new rule straight away
+	if name, err := sched.workflowRunner.grimoireMapper.Resolve(beadInfoForTask(task)); err != nil || name != "bugfix-bead" {
+		t.Errorf("Resolve() = %q, %v; want %q", name, err, "bugfix-bead")
+	}
+
+	resp, err := client.Get("http://unix/config/grimoire-mapping")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var cfg workflow.GrimoireMappingConfig
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if cfg.ByType["bug"] != "bugfix-bead" {
+		t.Errorf("by_type[bug] = %q, want %q", cfg.ByType["bug"], "bugfix-bead")
+	}
+
+	if _, err := os.Stat(filepath.Join(covenDir, "grimoire-mapping.json")); err != nil {
+		t.Errorf("mapping should be saved: %v", err)
+	}
+}
+
+func TestHandleGrimoireMapping_Invalid(t *testing.T) {
+	_, client, covenDir := setupTestMappingHandlers(t)
+	writeMappingTestGrimoire(t, covenDir, "implement-bead")
+
+	tests := []struct {
+		name   string
+		body   string
+		errMsg string
+	}{
+		{name: "malformed JSON", body: `{"default":`, errMsg: "invalid request body"},
+		{name: "unknown grimoire", body: `{"by_label": {"security": "missing"}}`, errMsg: `grimoire \"missing\" not found`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := putGrimoireMapping(t, client, tt.body)
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+			}
+			var body bytes.Buffer
+			body.ReadFrom(resp.Body)
+			if !strings.Contains(body.String(), tt.errMsg) {
+				t.Errorf("body = %s, want it to contain %s", body.String(), tt.errMsg)
+			}
+		})
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, "http://unix/config/grimoire-mapping", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("DELETE error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/workflow"
+)
+
+// runPostMergeHook runs a grimoire's post-merge hook in the main repository
+// after a task's branch was merged. The merge has already happened, so a
+// failing hook is logged rather than returned.
+func (s *Scheduler) runPostMergeHook(ctx context.Context, hook *grimoire.PostMergeHook, meta workflow.CommitMetadata, branch, baseBranch string, mergeResult *workflow.MergeResult) {
+	if hook == nil {
+		return
+	}
+	if err := s.postMergeHook(ctx, hook, meta, branch, baseBranch, mergeResult); err != nil {
+		s.logger.Warn("post-merge hook failed",
+			"task_id", meta.TaskID,
+			"merge_commit", mergeResult.MergeCommit,
+			"error", err,
+		)
+		return
+	}
+	s.logger.Info("post-merge hook completed",
+		"task_id", meta.TaskID,
+		"merge_commit", mergeResult.MergeCommit,
+	)
+}
+
+// postMergeHook renders and runs the hook's command.
+func (s *Scheduler) postMergeHook(ctx context.Context, hook *grimoire.PostMergeHook, meta workflow.CommitMetadata, branch, baseBranch string, mergeResult *workflow.MergeResult) error {
+	timeout, err := hook.GetTimeout()
+	if err != nil {
+		return fmt.Errorf("invalid timeout: %w", err)
+	}
+
+	command, err := workflow.RenderCommand(hook.Command, map[string]interface{}{
+		"bead_id":      meta.TaskID,
+		"workflow_id":  meta.WorkflowID,
+		"grimoire":     meta.Grimoire,
+		"branch":       branch,
+		"base_branch":  baseBranch,
+		"merge_commit": mergeResult.MergeCommit,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render command: %w", err)
+	}
+	if err := s.workflowRunner.commandPolicy.Check(command); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	runner := &workflow.DefaultCommandRunner{}
+	_, stderr, exitCode, err := runner.Run(ctx, s.worktreesFor(meta.TaskID).RepoPath(), command)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("exited with code %d: %s", exitCode, strings.TrimSpace(stderr))
+	}
+	return nil
+}
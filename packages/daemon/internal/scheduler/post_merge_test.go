@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// writePostMergeTestGrimoire writes a grimoire that changes a file, merges it
+// and records the hook's variables in outFile.
+func writePostMergeTestGrimoire(t *testing.T, covenDir, mergeOptions, outFile string) {
+	t.Helper()
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	content := `name: post-merge-test
+description: Post-merge hook test grimoire
+post_merge:
+  command: "echo {{.bead_id}} {{.merge_commit}} > ` + outFile + `"
+steps:
+  - name: change
+    type: script
+    command: "echo change > feature.txt"
+  - name: merge
+    type: merge
+` + mergeOptions
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "post-merge-test.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+}
+
+// readPostMergeOutput waits for the hook to write outFile and returns its
+// fields.
+func readPostMergeOutput(t *testing.T, outFile string) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(outFile); err == nil && len(data) > 0 {
+			return strings.Fields(string(data))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("post-merge hook did not run")
+	return nil
+}
+
+func headCommit(t *testing.T, repoDir string) string {
+	t.Helper()
+	out, err := exec.Command("git", "-C", repoDir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("git rev-parse failed: %v", err)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestApproveMerge_RunsPostMergeHook(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	outFile := filepath.Join(t.TempDir(), "post-merge")
+	writePostMergeTestGrimoire(t, covenDir, "", outFile)
+
+	task := types.Task{ID: "task-1", Title: "Test Task", Status: types.TaskStatusOpen, Labels: []string{"grimoire:post-merge-test"}}
+	store.SetTasks([]types.Task{task})
+	if err := sched.StartAgentForTask(context.Background(), task); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+	waitForWorkflowStatus(t, workflow.NewStatePersister(covenDir), task.ID, workflow.WorkflowPendingMerge)
+
+	if _, err := os.Stat(outFile); err == nil {
+		t.Fatal("post-merge hook ran before the merge was approved")
+	}
+
+	result, err := sched.ApproveMerge(task.ID)
+	if err != nil {
+		t.Fatalf("ApproveMerge() error: %v", err)
+	}
+	if !result.Success || result.MergeCommit == "" {
+		t.Fatalf("ApproveMerge() = %+v, want a successful merge", result)
+	}
+
+	fields := readPostMergeOutput(t, outFile)
+	if len(fields) != 2 || fields[0] != task.ID || fields[1] != result.MergeCommit {
+		t.Errorf("hook output = %v, want [%s %s]", fields, task.ID, result.MergeCommit)
+	}
+}
+
+func TestAutoMerge_RunsPostMergeHook(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	outFile := filepath.Join(t.TempDir(), "post-merge")
+	writePostMergeTestGrimoire(t, covenDir, "    require_review: false\n", outFile)
+
+	task := types.Task{ID: "task-1", Title: "Test Task", Status: types.TaskStatusOpen, Labels: []string{"grimoire:post-merge-test"}}
+	store.SetTasks([]types.Task{task})
+	if err := sched.StartAgentForTask(context.Background(), task); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+
+	fields := readPostMergeOutput(t, outFile)
+	if want := headCommit(t, repoDir); len(fields) != 2 || fields[0] != task.ID || fields[1] != want {
+		t.Errorf("hook output = %v, want [%s %s]", fields, task.ID, want)
+	}
+}
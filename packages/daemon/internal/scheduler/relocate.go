@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/coven/daemon/internal/workflow"
+)
+
+// WorktreeMissingError is returned when a saved workflow's worktree is neither
+// at the path recorded in its state nor under the current worktree root.
+type WorktreeMissingError struct {
+	TaskID        string
+	RecordedPath  string
+	CandidatePath string
+}
+
+func (e *WorktreeMissingError) Error() string {
+	if e.CandidatePath == e.RecordedPath {
+		return fmt.Sprintf("worktree for task %s not found at %s", e.TaskID, e.RecordedPath)
+	}
+	return fmt.Sprintf("worktree for task %s not found at %s or %s", e.TaskID, e.RecordedPath, e.CandidatePath)
+}
+
+// IsWorktreeMissing returns true if the error is a WorktreeMissingError.
+func IsWorktreeMissing(err error) bool {
+	var missing *WorktreeMissingError
+	return errors.As(err, &missing)
+}
+
+// relocateWorktree points a saved workflow at its worktree under the current
+// worktree root when the recorded path no longer exists, as happens when the
+// repository was moved or the state was saved on another machine. The
+// worktree's git links are repaired and the new path is saved.
+func (s *Scheduler) relocateWorktree(ctx context.Context, state *workflow.WorkflowState) error {
+	if state.WorktreePath == "" {
+		return nil
+	}
+	if _, err := os.Stat(state.WorktreePath); err == nil {
+		return nil
+	}
+
+	worktrees := s.worktreesFor(state.TaskID)
+	candidate := worktrees.GetPath(state.TaskID)
+	missing := &WorktreeMissingError{TaskID: state.TaskID, RecordedPath: state.WorktreePath, CandidatePath: candidate}
+	if candidate == state.WorktreePath {
+		return missing
+	}
+	if _, err := os.Stat(candidate); err != nil {
+		return missing
+	}
+
+	wtInfo, err := worktrees.Repair(ctx, state.TaskID)
+	if err != nil {
+		return fmt.Errorf("failed to relink worktree for task %s: %w", state.TaskID, err)
+	}
+
+	s.logger.Info("relocated workflow worktree",
+		"task_id", state.TaskID,
+		"recorded_path", state.WorktreePath,
+		"worktree", wtInfo.Path,
+	)
+	state.WorktreePath = wtInfo.Path
+	if err := s.statePersister.Save(state); err != nil {
+		return fmt.Errorf("failed to save workflow state: %w", err)
+	}
+	return nil
+}
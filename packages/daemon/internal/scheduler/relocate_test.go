@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/git"
+	"github.com/coven/daemon/internal/state"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// saveInterruptedShutdownTestWorkflow creates task-1's worktree and saves a
+// shutdown test workflow for it that was interrupted after its first step.
+func saveInterruptedShutdownTestWorkflow(t *testing.T, sched *Scheduler, store *state.Store, covenDir string) *workflow.WorkflowState {
+	t.Helper()
+	writeShutdownTestGrimoire(t, covenDir, "touch first")
+	store.SetTasks([]types.Task{
+		{ID: "task-1", Title: "Test Task", Status: types.TaskStatusInProgress, Labels: []string{"grimoire:shutdown-test"}},
+	})
+
+	wtInfo, err := sched.worktreeManager.Create(context.Background(), "task-1")
+	if err != nil {
+		t.Fatalf("Create() error: %v", err)
+	}
+	saved := &workflow.WorkflowState{
+		TaskID:         "task-1",
+		WorkflowID:     "wf-1",
+		GrimoireName:   "shutdown-test",
+		WorktreePath:   wtInfo.Path,
+		Status:         workflow.WorkflowRunning,
+		CurrentStep:    0,
+		CompletedSteps: map[string]*workflow.StepResult{"first": {Success: true}},
+		StepOutputs:    map[string]string{},
+		StartedAt:      time.Now(),
+	}
+	if err := workflow.NewStatePersister(covenDir).Save(saved); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	return saved
+}
+
+func TestSchedulerResume_RelocatedWorktreeRoot(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	saved := saveInterruptedShutdownTestWorkflow(t, sched, store, covenDir)
+
+	// Move the worktree to a new root, as a different machine would have it
+	newRoot := filepath.Join(t.TempDir(), "worktrees")
+	if err := os.MkdirAll(newRoot, 0755); err != nil {
+		t.Fatalf("Failed to create worktree root: %v", err)
+	}
+	if err := os.Rename(saved.WorktreePath, filepath.Join(newRoot, "task-1")); err != nil {
+		t.Fatalf("Failed to move worktree: %v", err)
+	}
+	sched.worktreeManager.(*git.WorktreeManager).SetWorktreesDir(newRoot)
+
+	task := store.GetTasks()[0]
+	sched.resumeWorkflow(context.Background(), task, saved)
+
+	moved := filepath.Join(newRoot, "task-1")
+	if _, err := os.Stat(filepath.Join(moved, "second")); err != nil {
+		t.Errorf("Resumed step should run in the relocated worktree: %v", err)
+	}
+	if agentState := store.GetAgent("task-1"); agentState == nil || agentState.Status != types.AgentStatusCompleted {
+		t.Errorf("Agent = %+v, want completed", agentState)
+	}
+	if saved.WorktreePath != moved {
+		t.Errorf("WorktreePath = %q, want %q", saved.WorktreePath, moved)
+	}
+	out, err := exec.Command("git", "-C", repoDir, "worktree", "list").Output()
+	if err != nil {
+		t.Fatalf("git worktree list failed: %v", err)
+	}
+	if !strings.Contains(string(out), moved) {
+		t.Errorf("git worktree list = %q, want the relocated worktree", out)
+	}
+}
+
+func TestSchedulerResume_WorktreeMissing(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	saved := saveInterruptedShutdownTestWorkflow(t, sched, store, covenDir)
+
+	if err := os.RemoveAll(saved.WorktreePath); err != nil {
+		t.Fatalf("Failed to remove worktree: %v", err)
+	}
+
+	task := store.GetTasks()[0]
+	sched.resumeWorkflow(context.Background(), task, saved)
+
+	agentState := store.GetAgent("task-1")
+	if agentState == nil || agentState.Status != types.AgentStatusFailed {
+		t.Fatalf("Agent = %+v, want failed", agentState)
+	}
+	if !strings.Contains(agentState.Error, "worktree for task task-1 not found") {
+		t.Errorf("Agent error = %q, want worktree not found", agentState.Error)
+	}
+	if got := store.GetTasks()[0].Status; got != types.TaskStatusBlocked {
+		t.Errorf("Task status = %q, want %q", got, types.TaskStatusBlocked)
+	}
+}
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"net/http"
+
+	"github.com/coven/daemon/internal/api"
+)
+
+// ScheduleHandlers provides HTTP handlers for cron schedules.
+type ScheduleHandlers struct {
+	cron *CronScheduler
+}
+
+// NewScheduleHandlers creates new schedule handlers.
+func NewScheduleHandlers(cron *CronScheduler) *ScheduleHandlers {
+	return &ScheduleHandlers{
+		cron: cron,
+	}
+}
+
+// Register registers schedule handlers with the server.
+func (h *ScheduleHandlers) Register(server *api.Server) {
+	server.RegisterHandlerFunc("/schedules", h.handleSchedulesList)
+}
+
+// ScheduleListResponse is the response for GET /schedules.
+type ScheduleListResponse struct {
+	Schedules []ScheduleInfo `json:"schedules"`
+	Count     int            `json:"count"`
+}
+
+// handleSchedulesList handles GET /schedules.
+// @Summary      List cron schedules
+// @Description  Returns all configured cron schedules with their next and last run times
+// @Tags         schedules
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  ScheduleListResponse  "Schedules list response"
+// @Failure      405  {object}  map[string]string     "Method not allowed"
+// @Router       /schedules [get]
+func (h *ScheduleHandlers) handleSchedulesList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	schedules := h.cron.List()
+	api.WriteJSON(w, http.StatusOK, ScheduleListResponse{
+		Schedules: schedules,
+		Count:     len(schedules),
+	})
+}
@@ -4,12 +4,13 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/coven/daemon/internal/agent"
-	"github.com/coven/daemon/internal/beads"
 	"github.com/coven/daemon/internal/git"
+	"github.com/coven/daemon/internal/grimoire"
 	"github.com/coven/daemon/internal/logging"
 	"github.com/coven/daemon/internal/questions"
 	"github.com/coven/daemon/internal/state"
@@ -23,35 +24,102 @@ const (
 
 	// DefaultMaxAgents is the default maximum concurrent agents.
 	DefaultMaxAgents = 3
+
+	// DefaultShutdownGrace is the default time running workflows are given to
+	// finish their current step when the daemon shuts down.
+	DefaultShutdownGrace = 30 * time.Second
+
+	// shutdownKillTimeout bounds how long Shutdown waits for workflows to
+	// save their state after their agents are killed.
+	shutdownKillTimeout = 5 * time.Second
 )
 
+// BeadsClient records task status changes in beads.
+// *beads.Client is the production implementation.
+type BeadsClient interface {
+	UpdateStatus(ctx context.Context, taskID string, status types.TaskStatus) error
+}
+
+// WorktreeManager creates and removes the per-task git worktrees workflows
+// run in. *git.WorktreeManager is the production implementation.
+type WorktreeManager interface {
+	Create(ctx context.Context, taskID string) (*git.WorktreeInfo, error)
+	CreateSparse(ctx context.Context, taskID string, paths []string) (*git.WorktreeInfo, error)
+	Get(taskID string) (*git.WorktreeInfo, error)
+	Remove(ctx context.Context, taskID string) error
+	DeleteBranch(ctx context.Context, branchName string) error
+	GetBaseBranch(ctx context.Context) (string, error)
+	Divergence(ctx context.Context, branch, baseBranch string) (*git.Divergence, error)
+	GetPath(taskID string) string
+	RepoPath() string
+	Repair(ctx context.Context, taskID string) (*git.WorktreeInfo, error)
+}
+
 // Scheduler manages task scheduling and agent orchestration.
 type Scheduler struct {
 	mu                sync.RWMutex
 	store             *state.Store
-	beadsClient       *beads.Client
+	beadsClient       BeadsClient
 	processManager    *agent.ProcessManager
-	worktreeManager   *git.WorktreeManager
+	worktreeManager   WorktreeManager
 	workflowRunner    *WorkflowRunner
 	agentRunner       *ProcessAgentRunner
+	stepAgentRunner   workflow.AgentRunner
 	logger            *logging.Logger
 	covenDir          string
+	statePersister    *workflow.StatePersister
 	reconcileInterval time.Duration
 	maxAgents         int
+	maxWorkflows      int
 	running           bool
 	stopCh            chan struct{}
 	doneCh            chan struct{}
 	agentCommand      string
 	agentArgs         []string
 	pendingResumes    map[string]*workflow.WorkflowState
+	minFreeDisk       uint64
+	diskChecker       DiskSpaceChecker
+	diskLow           bool
+
+	// awaitingAgentSlot holds the tasks in pendingResumes whose workflows
+	// were drained by ConvergeAgents or preempted, which resume only once
+	// there is room under the agent limit.
+	awaitingAgentSlot map[string]bool
+
+	// preemption enables stopping low-priority workflows for higher-priority
+	// tasks when every agent slot is taken. preempting holds the tasks whose
+	// workflows were preempted and haven't stopped yet.
+	preemption bool
+	preempting map[string]bool
+
+	// concurrencyGroups maps each busy grimoire concurrency group to the
+	// task whose workflow holds it.
+	concurrencyGroups map[string]string
+
+	// taskWorkflows holds the running workflow of each task, so it can be
+	// cancelled on its own. It has its own lock since workflows are started
+	// with mu held.
+	taskWorkflowsMu sync.Mutex
+	taskWorkflows   map[string]*taskWorkflow
+
+	// syntheticTasks holds the tasks run without a bead by RunSyntheticTask,
+	// by task ID. It is guarded by taskWorkflowsMu.
+	syntheticTasks map[string]*syntheticTask
+
+	// Workflow lifecycle, used to wind workflows down on shutdown
+	workflowCtx     context.Context
+	cancelWorkflows context.CancelCauseFunc
+	stopAfterStep   chan struct{}
+	shutdownOnce    sync.Once
+	workflows       sync.WaitGroup
 }
 
 // NewScheduler creates a new scheduler.
 func NewScheduler(
 	store *state.Store,
-	beadsClient *beads.Client,
+	beadsClient BeadsClient,
 	processManager *agent.ProcessManager,
-	worktreeManager *git.WorktreeManager,
+	worktreeManager WorktreeManager,
 	logger *logging.Logger,
 	covenDir string,
 ) *Scheduler {
@@ -76,6 +144,8 @@ func NewScheduler(
 	// Create the workflow runner
 	workflowRunner := NewWorkflowRunner(covenDir, logger)
 
+	workflowCtx, cancelWorkflows := context.WithCancelCause(context.Background())
+
 	return &Scheduler{
 		store:             store,
 		beadsClient:       beadsClient,
@@ -85,11 +155,21 @@ func NewScheduler(
 		agentRunner:       agentRunner,
 		logger:            logger,
 		covenDir:          covenDir,
+		statePersister:    workflowRunner.statePersister,
 		reconcileInterval: DefaultReconcileInterval,
 		maxAgents:         DefaultMaxAgents,
 		agentCommand:      agentCommand,
 		agentArgs:         agentArgs,
 		pendingResumes:    make(map[string]*workflow.WorkflowState),
+		awaitingAgentSlot: make(map[string]bool),
+		preempting:        make(map[string]bool),
+		concurrencyGroups: make(map[string]string),
+		taskWorkflows:     make(map[string]*taskWorkflow),
+		syntheticTasks:    make(map[string]*syntheticTask),
+		diskChecker:       FreeDiskSpace,
+		workflowCtx:       workflowCtx,
+		cancelWorkflows:   cancelWorkflows,
+		stopAfterStep:     make(chan struct{}),
 	}
 }
 
@@ -103,6 +183,42 @@ func (s *Scheduler) SetEventEmitter(emitter workflow.EventEmitter) {
 	}
 }
 
+// SetLogFlushPolicy sets how workflow JSONL logs are buffered.
+func (s *Scheduler) SetLogFlushPolicy(policy workflow.LogFlushPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.workflowRunner != nil {
+		s.workflowRunner.SetLogFlushPolicy(policy)
+	}
+}
+
+// SetLogFormat sets the encoding of workflow JSONL logs.
+func (s *Scheduler) SetLogFormat(format workflow.LogFormat) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.workflowRunner != nil {
+		s.workflowRunner.SetLogFormat(format)
+	}
+}
+
+// SetCommandPolicy sets the policy script step commands must pass.
+func (s *Scheduler) SetCommandPolicy(policy *workflow.CommandPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.workflowRunner != nil {
+		s.workflowRunner.SetCommandPolicy(policy)
+	}
+}
+
+// SetGrimoireOptions sets how the grimoires workflows run are validated.
+func (s *Scheduler) SetGrimoireOptions(opts grimoire.Options) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.workflowRunner != nil {
+		s.workflowRunner.SetGrimoireOptions(opts)
+	}
+}
+
 // SetReconcileInterval sets the reconciliation interval.
 func (s *Scheduler) SetReconcileInterval(d time.Duration) {
 	s.mu.Lock()
@@ -110,13 +226,34 @@ func (s *Scheduler) SetReconcileInterval(d time.Duration) {
 	s.mu.Unlock()
 }
 
-// SetMaxAgents sets the maximum concurrent agents.
+// SetMaxAgents sets the maximum concurrent agents. Lowering it below the
+// number of running agents leaves them to finish; see ConvergeAgents.
 func (s *Scheduler) SetMaxAgents(max int) {
 	s.mu.Lock()
 	s.maxAgents = max
 	s.mu.Unlock()
 }
 
+// ConvergeAgents stops workflows until no more agents are running than the
+// limit allows, lowest priority and most recently started first. They resume
+// once an agent slot is free. It returns the IDs of the tasks whose workflows
+// were stopped.
+func (s *Scheduler) ConvergeAgents() []string {
+	s.mu.RLock()
+	max := s.maxAgents
+	s.mu.RUnlock()
+	return s.drainExcessAgents(max)
+}
+
+// SetMaxWorkflows sets the maximum number of active workflows, counting
+// every workflow that hasn't finished, whether or not it is running an agent.
+// Zero means no limit.
+func (s *Scheduler) SetMaxWorkflows(max int) {
+	s.mu.Lock()
+	s.maxWorkflows = max
+	s.mu.Unlock()
+}
+
 // SetAgentCommand sets the command to run for agents.
 func (s *Scheduler) SetAgentCommand(cmd string, args []string) {
 	s.mu.Lock()
@@ -127,6 +264,41 @@ func (s *Scheduler) SetAgentCommand(cmd string, args []string) {
 	s.mu.Unlock()
 }
 
+// SetAgentProfile sets how agents are invoked, including how prompts are passed.
+func (s *Scheduler) SetAgentProfile(profile agent.Profile) {
+	s.mu.Lock()
+	s.agentCommand = profile.Command
+	s.agentArgs = profile.Args
+	s.agentRunner.SetProfile(profile)
+	s.mu.Unlock()
+}
+
+// SetAgentRunner replaces the process-backed runner used for agent steps,
+// e.g. with an in-process runner in tests. The agent command and profile
+// settings no longer apply once it is set.
+func (s *Scheduler) SetAgentRunner(runner workflow.AgentRunner) {
+	s.mu.Lock()
+	s.stepAgentRunner = runner
+	s.mu.Unlock()
+}
+
+// RecordAgentRuns records the prompt and response of each agent step that
+// runs from now on to the cassette at path. See workflow.RecordingAgentRunner.
+func (s *Scheduler) RecordAgentRuns(path string) {
+	runner := workflow.NewRecordingAgentRunner(s.workflowAgentRunner(), path)
+	s.SetAgentRunner(runner)
+}
+
+// workflowAgentRunner returns the runner workflows use for agent steps.
+func (s *Scheduler) workflowAgentRunner() workflow.AgentRunner {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.stepAgentRunner != nil {
+		return s.stepAgentRunner
+	}
+	return s.agentRunner
+}
+
 // Start starts the scheduler.
 func (s *Scheduler) Start() {
 	s.mu.Lock()
@@ -146,13 +318,18 @@ func (s *Scheduler) Start() {
 	s.resumeInterruptedWorkflows()
 
 	go s.reconcileLoop()
-	s.logger.Info("scheduler started", "max_agents", s.maxAgents)
+	s.logger.Info("scheduler started", "max_agents", s.maxAgents, "max_workflows", s.maxWorkflows)
 }
 
 // resumeInterruptedWorkflows checks for workflows that were interrupted and resumes them.
 func (s *Scheduler) resumeInterruptedWorkflows() {
-	statePersister := workflow.NewStatePersister(s.covenDir)
-	interrupted, err := statePersister.ListInterrupted()
+	for _, unsupported := range s.statePersister.ListUnsupported() {
+		if unsupported.Interrupted() {
+			s.blockUnsupportedWorkflow(unsupported)
+		}
+	}
+
+	interrupted, err := s.statePersister.ListInterrupted()
 	if err != nil {
 		s.logger.Error("failed to list interrupted workflows", "error", err)
 		return
@@ -192,11 +369,37 @@ func (s *Scheduler) resumeInterruptedWorkflows() {
 			continue
 		}
 
-		// Resume the workflow in background
-		go s.resumeWorkflow(context.Background(), *task, state)
+		if !s.goResume(*task, state) {
+			// Another workflow holds its concurrency group - retry on reconcile
+			s.mu.Lock()
+			s.pendingResumes[state.TaskID] = state
+			s.mu.Unlock()
+		}
 	}
 }
 
+// blockUnsupportedWorkflow blocks a task whose workflow state was written by a
+// newer daemon. The state file is left untouched so a daemon that understands
+// it can resume the workflow after an upgrade.
+func (s *Scheduler) blockUnsupportedWorkflow(unsupported *workflow.UnsupportedStateVersionError) {
+	taskID := unsupported.TaskID
+
+	s.logger.Error("cannot resume workflow: unsupported state version",
+		"task_id", taskID,
+		"workflow_id", unsupported.WorkflowID,
+		"version", unsupported.Version,
+		"supported_version", workflow.StateVersion,
+	)
+
+	s.store.AddAgent(&types.Agent{
+		TaskID:    taskID,
+		Status:    types.AgentStatusFailed,
+		StartedAt: time.Now(),
+		Error:     unsupported.Error(),
+	})
+	s.updateBeadsStatus(context.Background(), taskID, types.TaskStatusBlocked, types.StatusReasonUnsupportedState)
+}
+
 // checkPendingResumes checks if any pending resumes can now proceed.
 func (s *Scheduler) checkPendingResumes() {
 	s.mu.RLock()
@@ -210,35 +413,162 @@ func (s *Scheduler) checkPendingResumes() {
 	for k, v := range s.pendingResumes {
 		pending[k] = v
 	}
+	awaitingAgentSlot := make(map[string]bool)
+	for k := range s.awaitingAgentSlot {
+		awaitingAgentSlot[k] = true
+	}
+	freeAgentSlots := s.maxAgents
+	preemption := s.preemption
 	s.mu.RUnlock()
+	if len(awaitingAgentSlot) > 0 {
+		freeAgentSlots -= len(s.processManager.ListRunning())
+	}
 
 	tasks := s.store.GetTasks()
 	taskMap := make(map[string]types.Task)
 	for _, t := range tasks {
 		taskMap[t.ID] = t
 	}
+	topReady, anyReady := highestReadyPriority(tasks)
 
 	for taskID, state := range pending {
 		task, found := taskMap[taskID]
 		if !found {
 			continue
 		}
+		if awaitingAgentSlot[taskID] && freeAgentSlots <= 0 {
+			continue
+		}
+		// A preempted workflow waits while tasks of higher priority are ready,
+		// so it doesn't take back the slot it gave up for them
+		if awaitingAgentSlot[taskID] && preemption && anyReady && topReady < task.Priority {
+			continue
+		}
+
+		s.logger.Info("resuming pending workflow",
+			"task_id", taskID,
+			"grimoire", state.GrimoireName,
+		)
+
+		// Leave it pending while another workflow holds its concurrency group
+		if !s.goResume(task, state) {
+			continue
+		}
+		if awaitingAgentSlot[taskID] {
+			freeAgentSlots--
+		}
 
-		// Remove from pending
 		s.mu.Lock()
 		delete(s.pendingResumes, taskID)
+		delete(s.awaitingAgentSlot, taskID)
 		s.mu.Unlock()
+	}
+}
 
-		s.logger.Info("resuming pending workflow",
-			"task_id", taskID,
-			"grimoire", state.GrimoireName,
+// goResume resumes a saved workflow in the background, holding its grimoire's
+// concurrency group until it returns. It reports false, without resuming, if
+// another workflow holds the group.
+func (s *Scheduler) goResume(task types.Task, state *workflow.WorkflowState) bool {
+	s.mu.Lock()
+	group, err := s.claimResumeGroupLocked(task.ID, state)
+	s.mu.Unlock()
+	if err != nil {
+		s.logger.Debug("workflow resume waiting for concurrency group",
+			"task_id", task.ID,
+			"error", err,
 		)
+		return false
+	}
+
+	s.goResumeClaimed(task, state, group)
+	return true
+}
+
+// claimResumeGroupLocked claims the concurrency group of the grimoire a saved
+// workflow resumes with, returning the group, or "" if it has none. It returns
+// a ConcurrencyGroupBusyError if another workflow holds the group, so that
+// callers can refuse to resume before changing the workflow's state. s.mu
+// must be held.
+func (s *Scheduler) claimResumeGroupLocked(taskID string, state *workflow.WorkflowState) (string, error) {
+	group, err := s.workflowRunner.ResumeConcurrencyGroup(state)
+	if err != nil {
+		// The resume itself will fail and report the error
+		group = ""
+	}
+	if holder, ok := s.acquireConcurrencyGroupLocked(group, taskID); !ok {
+		return "", &ConcurrencyGroupBusyError{Group: group, TaskID: holder}
+	}
+	return group, nil
+}
+
+// goResumeClaimed resumes a saved workflow in the background once its
+// concurrency group has been claimed, releasing the group when it returns.
+func (s *Scheduler) goResumeClaimed(task types.Task, state *workflow.WorkflowState, group string) {
+	s.goWorkflow(task.ID, func(ctx context.Context) {
+		defer s.releaseConcurrencyGroup(group, task.ID)
+		s.resumeWorkflow(ctx, task, state)
+	})
+}
+
+// acquireConcurrencyGroup claims group for taskID. If another task holds the
+// group it returns that task and false. An empty group is always acquired.
+func (s *Scheduler) acquireConcurrencyGroup(group, taskID string) (string, bool) {
+	if group == "" {
+		return "", true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acquireConcurrencyGroupLocked(group, taskID)
+}
+
+// acquireConcurrencyGroupLocked is acquireConcurrencyGroup with s.mu held.
+func (s *Scheduler) acquireConcurrencyGroupLocked(group, taskID string) (string, bool) {
+	if group == "" {
+		return "", true
+	}
+	if holder, busy := s.concurrencyGroups[group]; busy && holder != taskID {
+		return holder, false
+	}
+	s.concurrencyGroups[group] = taskID
+	return taskID, true
+}
+
+// releaseConcurrencyGroup releases group if taskID holds it.
+func (s *Scheduler) releaseConcurrencyGroup(group, taskID string) {
+	if group == "" {
+		return
+	}
 
-		// Resume the workflow in background
-		go s.resumeWorkflow(context.Background(), task, state)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseConcurrencyGroupLocked(group, taskID)
+}
+
+// releaseConcurrencyGroupLocked is releaseConcurrencyGroup with s.mu held.
+func (s *Scheduler) releaseConcurrencyGroupLocked(group, taskID string) {
+	if group != "" && s.concurrencyGroups[group] == taskID {
+		delete(s.concurrencyGroups, group)
 	}
 }
 
+// ConcurrencyGroupBusyError is returned when a workflow can't start because
+// another workflow holds its grimoire's concurrency group.
+type ConcurrencyGroupBusyError struct {
+	Group  string
+	TaskID string // task whose workflow holds the group
+}
+
+func (e *ConcurrencyGroupBusyError) Error() string {
+	return fmt.Sprintf("concurrency group %q is held by task %s", e.Group, e.TaskID)
+}
+
+// IsConcurrencyGroupBusy checks if an error is a ConcurrencyGroupBusyError.
+func IsConcurrencyGroupBusy(err error) bool {
+	_, ok := err.(*ConcurrencyGroupBusyError)
+	return ok
+}
+
 // Stop stops the scheduler.
 func (s *Scheduler) Stop() {
 	s.mu.Lock()
@@ -254,6 +584,119 @@ func (s *Scheduler) Stop() {
 	s.logger.Info("scheduler stopped")
 }
 
+// Shutdown stops the scheduler and winds down running workflows so they can be
+// resumed on the next start. Workflows are asked to stop once their current
+// step finishes; any still running after grace are cancelled, which kills
+// their agents. Either way the workflow state is saved as running, so
+// resumeInterruptedWorkflows picks it up when the daemon starts again.
+func (s *Scheduler) Shutdown(grace time.Duration) {
+	s.Stop()
+	s.shutdownOnce.Do(func() { close(s.stopAfterStep) })
+
+	if s.waitForWorkflows(grace) {
+		s.logger.Info("workflows stopped for shutdown")
+		return
+	}
+
+	s.logger.Warn("workflows still running after shutdown grace period, stopping agents", "grace", grace)
+	s.cancelWorkflows(workflow.ErrShutdown)
+	if !s.waitForWorkflows(shutdownKillTimeout) {
+		s.logger.Warn("workflows did not stop in time, they will resume from their last saved state")
+	}
+}
+
+// isShuttingDown reports whether Shutdown has been called.
+func (s *Scheduler) isShuttingDown() bool {
+	select {
+	case <-s.stopAfterStep:
+		return true
+	default:
+		return false
+	}
+}
+
+// goWorkflow runs a task's workflow in a new goroutine that Shutdown waits for.
+func (s *Scheduler) goWorkflow(taskID string, run func(ctx context.Context)) {
+	ctx, untrack := s.trackWorkflow(taskID, s.workflowCtx)
+	s.workflows.Add(1)
+	go func() {
+		defer s.workflows.Done()
+		defer untrack()
+		run(ctx)
+	}()
+}
+
+// taskWorkflow is a running workflow that can be cancelled.
+type taskWorkflow struct {
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	loopBreaks *workflow.LoopBreaks
+}
+
+// trackWorkflow returns a context for the task's workflow, derived from
+// parent, that cancelTaskWorkflow cancels. The returned function must be
+// called once the workflow has returned.
+func (s *Scheduler) trackWorkflow(taskID string, parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(parent)
+	wf := &taskWorkflow{ctx: ctx, cancel: cancel, loopBreaks: workflow.NewLoopBreaks()}
+
+	s.taskWorkflowsMu.Lock()
+	s.taskWorkflows[taskID] = wf
+	s.taskWorkflowsMu.Unlock()
+
+	return ctx, func() {
+		s.taskWorkflowsMu.Lock()
+		if s.taskWorkflows[taskID] == wf {
+			delete(s.taskWorkflows, taskID)
+		}
+		s.taskWorkflowsMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// cancelTaskWorkflow cancels the task's running workflow with cause. It
+// reports whether the task had a running workflow that wasn't already
+// cancelled.
+func (s *Scheduler) cancelTaskWorkflow(taskID string, cause error) bool {
+	s.taskWorkflowsMu.Lock()
+	wf := s.taskWorkflows[taskID]
+	s.taskWorkflowsMu.Unlock()
+
+	if wf == nil || wf.ctx.Err() != nil {
+		return false
+	}
+	wf.cancel(cause)
+	return true
+}
+
+// taskLoopBreaks returns the loop break requests for the task's running
+// workflow, or nil if it has none.
+func (s *Scheduler) taskLoopBreaks(taskID string) *workflow.LoopBreaks {
+	s.taskWorkflowsMu.Lock()
+	defer s.taskWorkflowsMu.Unlock()
+	if wf := s.taskWorkflows[taskID]; wf != nil {
+		return wf.loopBreaks
+	}
+	return nil
+}
+
+// waitForWorkflows waits up to timeout for running workflows to return.
+// It reports whether they all did.
+func (s *Scheduler) waitForWorkflows(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		s.workflows.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // IsRunning checks if the scheduler is running.
 func (s *Scheduler) IsRunning() bool {
 	s.mu.RLock()
@@ -298,6 +741,7 @@ func (s *Scheduler) Reconcile(ctx context.Context) error {
 
 	s.mu.RLock()
 	maxAgents := s.maxAgents
+	maxWorkflows := s.maxWorkflows
 	s.mu.RUnlock()
 
 	// Get running agents
@@ -309,8 +753,9 @@ func (s *Scheduler) Reconcile(ctx context.Context) error {
 		"max_agents", maxAgents,
 	)
 
-	// If at capacity, nothing to do
+	// At capacity, only preemption can make room
 	if runningCount >= maxAgents {
+		s.preemptForReadyTasks(runningAgents, maxWorkflows)
 		return nil
 	}
 
@@ -322,6 +767,33 @@ func (s *Scheduler) Reconcile(ctx context.Context) error {
 	if len(readyTasks) == 0 {
 		return nil
 	}
+	s.mu.RLock()
+	preemption := s.preemption
+	s.mu.RUnlock()
+	if preemption {
+		// Start the tasks preemption made room for ahead of the rest
+		sort.SliceStable(readyTasks, func(i, j int) bool {
+			return readyTasks[i].Priority < readyTasks[j].Priority
+		})
+	}
+
+	// Don't start new agents while disk space is low
+	if !s.hasDiskSpaceForAgents() {
+		return nil
+	}
+
+	// Don't start more workflows than allowed, whether or not they run agents
+	if maxWorkflows > 0 {
+		activeCount := s.activeWorkflowCount()
+		if activeCount >= maxWorkflows {
+			s.logger.Debug("at workflow capacity",
+				"active_workflows", activeCount,
+				"max_workflows", maxWorkflows,
+			)
+			return nil
+		}
+		availableSlots = min(availableSlots, maxWorkflows-activeCount)
+	}
 
 	// Filter out tasks that already have running agents
 	// Note: runningAgents contains step task IDs like "taskid-step-1"
@@ -332,27 +804,71 @@ func (s *Scheduler) Reconcile(ctx context.Context) error {
 		runningMainTaskSet[mainTaskID] = true
 	}
 
-	var tasksToStart []types.Task
+	// Start agents for ready tasks
+	started := 0
 	for _, task := range readyTasks {
-		if !runningMainTaskSet[task.ID] && len(tasksToStart) < availableSlots {
-			tasksToStart = append(tasksToStart, task)
+		if started >= availableSlots {
+			break
 		}
-	}
-
-	// Start agents for ready tasks
-	for _, task := range tasksToStart {
-		if err := s.startAgent(ctx, task); err != nil {
+		if runningMainTaskSet[task.ID] {
+			continue
+		}
+		if err := s.startAgent(ctx, task, "", nil); err != nil {
+			if IsConcurrencyGroupBusy(err) {
+				// Picked up again once the group is released
+				s.logger.Debug("task waiting for concurrency group",
+					"task_id", task.ID,
+					"error", err,
+				)
+				continue
+			}
 			s.logger.Error("failed to start agent",
 				"task_id", task.ID,
 				"error", err,
 			)
 			continue
 		}
+		started++
 	}
 
 	return nil
 }
 
+// activeWorkflowCount returns the number of workflows that haven't finished:
+// those running, including ones in script steps, those waiting to resume, and
+// those blocked or waiting for a merge or confirmation. Stopped workflows
+// don't count. It is worked out from what the scheduler holds in memory, since
+// it runs on every reconcile.
+func (s *Scheduler) activeWorkflowCount() int {
+	active := make(map[string]bool)
+	s.taskWorkflowsMu.Lock()
+	for taskID := range s.taskWorkflows {
+		active[taskID] = true
+	}
+	s.taskWorkflowsMu.Unlock()
+
+	s.mu.RLock()
+	for taskID := range s.pendingResumes {
+		active[taskID] = true
+	}
+	s.mu.RUnlock()
+
+	for _, task := range s.store.GetTasks() {
+		if task.Status == types.TaskStatusBlocked && waitingReasons[task.StatusReason] {
+			active[task.ID] = true
+		}
+	}
+	return len(active)
+}
+
+// waitingReasons are the reasons a task is blocked on a workflow that hasn't
+// finished but waits on someone, such as a merge waiting for review.
+var waitingReasons = map[types.TaskStatusReason]bool{
+	types.StatusReasonMergePending:         true,
+	types.StatusReasonAwaitingConfirmation: true,
+	types.StatusReasonBlocked:              true,
+}
+
 func (s *Scheduler) getReadyTasks() []types.Task {
 	tasks := s.store.GetTasks()
 
@@ -366,23 +882,105 @@ func (s *Scheduler) getReadyTasks() []types.Task {
 	return ready
 }
 
-func (s *Scheduler) startAgent(ctx context.Context, task types.Task) error {
+// startAgent creates a worktree for the task and runs its workflow.
+// grimoireHash optionally pins the workflow to a stored grimoire snapshot.
+func (s *Scheduler) startAgent(ctx context.Context, task types.Task, grimoireHash string, inputs map[string]interface{}) error {
+	// A stopped workflow picks up where it left off, unless a grimoire
+	// snapshot is asked for
+	if state := s.stoppedWorkflow(task.ID); state != nil && grimoireHash == "" {
+		return s.resumeStopped(task, state)
+	}
+
+	worktreePath, release, err := s.prepareWorkflow(ctx, task, grimoireHash)
+	if err != nil {
+		return err
+	}
+
+	// Run workflow in a goroutine
+	s.goWorkflow(task.ID, func(ctx context.Context) {
+		defer release()
+		s.runWorkflow(ctx, task, worktreePath, grimoireHash, inputs)
+	})
+
+	s.logger.Info("workflow started",
+		"task_id", task.ID,
+		"worktree", worktreePath,
+	)
+
+	return nil
+}
+
+// RunTask runs the task's workflow in the calling goroutine and returns its
+// result once the workflow stops. The scheduler doesn't need to be started,
+// so a single task can be run without the reconcile loop picking up others.
+// Cancelling ctx stops the workflow; Shutdown stops it as it would any other,
+// leaving it resumable.
+func (s *Scheduler) RunTask(ctx context.Context, task types.Task) (*WorkflowResult, error) {
+	worktreePath, release, err := s.prepareWorkflow(ctx, task, "")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	s.workflows.Add(1)
+	defer s.workflows.Done()
+
+	runCtx, cancel := context.WithCancelCause(s.workflowCtx)
+	defer cancel(nil)
+	stop := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+	defer stop()
+	runCtx, untrack := s.trackWorkflow(task.ID, runCtx)
+	defer untrack()
+
+	return s.runWorkflow(runCtx, task, worktreePath, "", nil)
+}
+
+// prepareWorkflow sets a task up to run its workflow: it claims the
+// grimoire's concurrency group, creates the worktree and marks the task in
+// progress. It returns the worktree path and a function that releases the
+// concurrency group once the workflow has finished.
+func (s *Scheduler) prepareWorkflow(ctx context.Context, task types.Task, grimoireHash string) (string, func(), error) {
 	s.logger.Info("starting workflow for task", "task_id", task.ID, "title", task.Title)
 
-	// Create worktree for the task
-	wtInfo, err := s.worktreeManager.Create(ctx, task.ID)
+	if s.isShuttingDown() {
+		return "", nil, fmt.Errorf("scheduler is shutting down")
+	}
+
+	// Refuse to start if a new worktree could fill the disk
+	if err := s.checkDiskSpace(); err != nil {
+		return "", nil, err
+	}
+
+	// Wait while another workflow holds the grimoire's concurrency group
+	group, err := s.workflowRunner.ConcurrencyGroup(task, grimoireHash)
 	if err != nil {
-		return fmt.Errorf("failed to create worktree: %w", err)
+		// The workflow itself will fail to resolve the grimoire and report it
+		group = ""
 	}
+	if holder, ok := s.acquireConcurrencyGroup(group, task.ID); !ok {
+		return "", nil, &ConcurrencyGroupBusyError{Group: group, TaskID: holder}
+	}
+	release := func() { s.releaseConcurrencyGroup(group, task.ID) }
 
-	// Update task status to in_progress (local store for immediate visibility)
-	s.store.UpdateTaskStatus(task.ID, types.TaskStatusInProgress)
+	// Create worktree for the task, limited to the grimoire's sparse paths
+	sparsePaths, err := s.workflowRunner.SparsePaths(task, grimoireHash)
+	if err != nil {
+		// As above, a grimoire that can't be resolved fails the workflow later
+		sparsePaths = nil
+	}
+	worktrees := s.worktreesFor(task.ID)
+	wtInfo, err := worktrees.CreateSparse(ctx, task.ID, sparsePaths)
+	if err != nil {
+		release()
+		return "", nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
 
-	// Update task status in beads (persistent storage)
-	if err := s.beadsClient.UpdateStatus(ctx, task.ID, types.TaskStatusInProgress); err != nil {
+	// Update task status to in_progress
+	if err := s.updateBeadsStatus(ctx, task.ID, types.TaskStatusInProgress, types.StatusReasonStarted); err != nil {
 		// Clean up worktree on failure
-		s.worktreeManager.Remove(ctx, task.ID)
-		return fmt.Errorf("failed to update task status: %w", err)
+		worktrees.Remove(ctx, task.ID)
+		release()
+		return "", nil, fmt.Errorf("failed to update task status: %w", err)
 	}
 
 	// Create agent record in state
@@ -398,19 +996,12 @@ func (s *Scheduler) startAgent(ctx context.Context, task types.Task) error {
 	// Update agent state to running
 	s.store.UpdateAgentStatus(task.ID, types.AgentStatusRunning)
 
-	// Run workflow in a goroutine
-	go s.runWorkflow(ctx, task, wtInfo.Path)
-
-	s.logger.Info("workflow started",
-		"task_id", task.ID,
-		"worktree", wtInfo.Path,
-	)
-
-	return nil
+	return wtInfo.Path, release, nil
 }
 
-// runWorkflow executes the workflow for a task.
-func (s *Scheduler) runWorkflow(ctx context.Context, task types.Task, worktreePath string) {
+// runWorkflow executes the workflow for a task and records its outcome.
+// It returns the workflow result, or an error if the workflow runner failed.
+func (s *Scheduler) runWorkflow(ctx context.Context, task types.Task, worktreePath, grimoireHash string, inputs map[string]interface{}) (*WorkflowResult, error) {
 	taskID := task.ID
 
 	// Set up the agent runner for this workflow
@@ -421,14 +1012,24 @@ func (s *Scheduler) runWorkflow(ctx context.Context, task types.Task, worktreePa
 
 	// Run the workflow
 	config := WorkflowConfig{
-		WorktreePath: worktreePath,
-		BeadID:       taskID,
-		WorkflowID:   workflowID,
-		AgentRunner:  s.agentRunner,
+		WorktreePath:  worktreePath,
+		BeadID:        taskID,
+		WorkflowID:    workflowID,
+		AgentRunner:   s.workflowAgentRunner(),
+		GrimoireHash:  grimoireHash,
+		Inputs:        inputs,
+		StopAfterStep: s.stopAfterStep,
+		LoopBreaks:    s.taskLoopBreaks(taskID),
 	}
 
 	result, err := s.workflowRunner.Run(ctx, task, config)
 
+	// The bead was closed outside coven; leave its status to beads
+	if closed, ok := beadClosedCause(ctx); ok {
+		s.finishClosedBead(taskID, closed)
+		return result, err
+	}
+
 	// Handle errors from workflow runner itself
 	if err != nil {
 		s.logger.Error("workflow runner error",
@@ -437,8 +1038,21 @@ func (s *Scheduler) runWorkflow(ctx context.Context, task types.Task, worktreePa
 		)
 		s.store.UpdateAgentStatus(taskID, types.AgentStatusFailed)
 		s.store.SetAgentError(taskID, err.Error())
-		s.beadsClient.UpdateStatus(ctx, taskID, types.TaskStatusBlocked)
-		return
+		s.updateBeadsStatus(ctx, taskID, types.TaskStatusBlocked, types.StatusReasonFailed)
+		return nil, err
+	}
+
+	// Leave the task in progress so the workflow resumes on the next start
+	if result.Interrupted {
+		if s.finishIfStopped(ctx, taskID) || s.pauseIfDrained(ctx, taskID) {
+			return result, nil
+		}
+		s.logger.Info("workflow interrupted by shutdown, will resume on restart",
+			"task_id", taskID,
+			"grimoire", result.GrimoireName,
+			"steps", result.StepCount,
+		)
+		return result, nil
 	}
 
 	// Log workflow completion
@@ -449,12 +1063,14 @@ func (s *Scheduler) runWorkflow(ctx context.Context, task types.Task, worktreePa
 		"duration", result.Duration,
 		"steps", result.StepCount,
 		"needs_auto_merge", result.NeedsAutoMerge,
+		"no_changes", result.NoChanges,
 	)
 
 	// Handle auto-merge if needed (merge step with require_review: false)
 	if result.Success && result.NeedsAutoMerge {
 		s.logger.Info("performing auto-merge", "task_id", taskID)
-		if err := s.performAutoMerge(ctx, taskID, worktreePath); err != nil {
+		meta := workflow.CommitMetadata{TaskID: taskID, WorkflowID: workflowID, Grimoire: result.GrimoireName, GrimoireHash: result.GrimoireHash}
+		if err := s.performAutoMerge(ctx, taskID, worktreePath, meta, result.MergeOptions, result.KeepWorktree, result.PostMerge); err != nil {
 			s.logger.Error("auto-merge failed",
 				"task_id", taskID,
 				"error", err,
@@ -488,11 +1104,8 @@ func (s *Scheduler) runWorkflow(ctx context.Context, task types.Task, worktreePa
 		"new_status", newStatus,
 	)
 
-	// Update task status in local store (for immediate API visibility)
-	s.store.UpdateTaskStatus(taskID, newStatus)
-
-	// Update task status in beads (persistent storage)
-	if updateErr := s.beadsClient.UpdateStatus(ctx, taskID, newStatus); updateErr != nil {
+	// Update task status
+	if updateErr := s.updateBeadsStatus(ctx, taskID, newStatus, StatusReasonForResult(result)); updateErr != nil {
 		s.logger.Error("failed to update task status in beads",
 			"task_id", taskID,
 			"status", newStatus,
@@ -504,6 +1117,8 @@ func (s *Scheduler) runWorkflow(ctx context.Context, task types.Task, worktreePa
 			"status", newStatus,
 		)
 	}
+
+	return result, nil
 }
 
 // resumeWorkflow resumes an interrupted workflow from saved state.
@@ -517,9 +1132,25 @@ func (s *Scheduler) resumeWorkflow(ctx context.Context, task types.Task, state *
 		"worktree", state.WorktreePath,
 	)
 
+	// Find the worktree if it has moved since the state was saved
+	if err := s.relocateWorktree(ctx, state); err != nil {
+		s.logger.Error("cannot resume workflow",
+			"task_id", taskID,
+			"error", err,
+		)
+		s.store.AddAgent(&types.Agent{
+			TaskID:    taskID,
+			Worktree:  state.WorktreePath,
+			Status:    types.AgentStatusFailed,
+			StartedAt: time.Now(),
+			Error:     err.Error(),
+		})
+		s.updateBeadsStatus(ctx, taskID, types.TaskStatusBlocked, types.StatusReasonWorktreeMissing)
+		return
+	}
+
 	// Update task status to in_progress
-	s.store.UpdateTaskStatus(taskID, types.TaskStatusInProgress)
-	s.beadsClient.UpdateStatus(ctx, taskID, types.TaskStatusInProgress)
+	s.updateBeadsStatus(ctx, taskID, types.TaskStatusInProgress, types.StatusReasonResumed)
 
 	// Create agent record in state
 	agentState := &types.Agent{
@@ -535,15 +1166,23 @@ func (s *Scheduler) resumeWorkflow(ctx context.Context, task types.Task, state *
 
 	// Run the resumed workflow
 	config := WorkflowConfig{
-		WorktreePath: state.WorktreePath,
-		BeadID:       taskID,
-		WorkflowID:   state.WorkflowID,
-		AgentRunner:  s.agentRunner,
-		ResumeState:  state, // Pass the state for resumption
+		WorktreePath:  state.WorktreePath,
+		BeadID:        taskID,
+		WorkflowID:    state.WorkflowID,
+		AgentRunner:   s.workflowAgentRunner(),
+		ResumeState:   state, // Pass the state for resumption
+		StopAfterStep: s.stopAfterStep,
+		LoopBreaks:    s.taskLoopBreaks(taskID),
 	}
 
 	result, err := s.workflowRunner.RunFromState(ctx, task, config, state)
 
+	// The bead was closed outside coven; leave its status to beads
+	if closed, ok := beadClosedCause(ctx); ok {
+		s.finishClosedBead(taskID, closed)
+		return
+	}
+
 	// Handle errors from workflow runner itself
 	if err != nil {
 		s.logger.Error("resumed workflow error",
@@ -552,7 +1191,20 @@ func (s *Scheduler) resumeWorkflow(ctx context.Context, task types.Task, state *
 		)
 		s.store.UpdateAgentStatus(taskID, types.AgentStatusFailed)
 		s.store.SetAgentError(taskID, err.Error())
-		s.beadsClient.UpdateStatus(ctx, taskID, types.TaskStatusBlocked)
+		s.updateBeadsStatus(ctx, taskID, types.TaskStatusBlocked, types.StatusReasonFailed)
+		return
+	}
+
+	// Leave the task in progress so the workflow resumes on the next start
+	if result.Interrupted {
+		if s.finishIfStopped(ctx, taskID) || s.pauseIfDrained(ctx, taskID) {
+			return
+		}
+		s.logger.Info("resumed workflow interrupted by shutdown, will resume on restart",
+			"task_id", taskID,
+			"grimoire", result.GrimoireName,
+			"steps", result.StepCount,
+		)
 		return
 	}
 
@@ -563,6 +1215,7 @@ func (s *Scheduler) resumeWorkflow(ctx context.Context, task types.Task, state *
 		"status", result.Status,
 		"duration", result.Duration,
 		"steps", result.StepCount,
+		"no_changes", result.NoChanges,
 	)
 
 	// Update agent status based on workflow result
@@ -588,8 +1241,7 @@ func (s *Scheduler) resumeWorkflow(ctx context.Context, task types.Task, state *
 	)
 
 	// Update task status
-	s.store.UpdateTaskStatus(taskID, newStatus)
-	if updateErr := s.beadsClient.UpdateStatus(ctx, taskID, newStatus); updateErr != nil {
+	if updateErr := s.updateBeadsStatus(ctx, taskID, newStatus, StatusReasonForResult(result)); updateErr != nil {
 		s.logger.Error("failed to update task status in beads",
 			"task_id", taskID,
 			"status", newStatus,
@@ -611,7 +1263,7 @@ func statusForWorkflowResult(result *WorkflowResult) types.TaskStatus {
 	case workflow.WorkflowPendingMerge:
 		// Map pending_merge to blocked for beads compatibility
 		return types.TaskStatusBlocked
-	case workflow.WorkflowBlocked:
+	case workflow.WorkflowBlocked, workflow.WorkflowAwaitingConfirmation:
 		return types.TaskStatusBlocked
 	case workflow.WorkflowCancelled:
 		return types.TaskStatusOpen
@@ -646,15 +1298,16 @@ func (s *Scheduler) handleAgentComplete(result *agent.ProcessResult) {
 	// Update task status based on result
 	ctx := context.Background()
 	var newStatus types.TaskStatus
+	var reason types.TaskStatusReason
 	if result.ExitCode == 0 {
-		newStatus = types.TaskStatusClosed
+		newStatus, reason = types.TaskStatusClosed, types.StatusReasonCompleted
 	} else if result.Killed {
-		newStatus = types.TaskStatusOpen // Return to open if killed
+		newStatus, reason = types.TaskStatusOpen, types.StatusReasonCancelled // Return to open if killed
 	} else {
-		newStatus = types.TaskStatusOpen // Return to open on failure for retry
+		newStatus, reason = types.TaskStatusOpen, types.StatusReasonFailed // Return to open on failure for retry
 	}
 
-	if err := s.beadsClient.UpdateStatus(ctx, mainTaskID, newStatus); err != nil {
+	if err := s.updateBeadsStatus(ctx, mainTaskID, newStatus, reason); err != nil {
 		s.logger.Error("failed to update task status",
 			"task_id", mainTaskID,
 			"status", newStatus,
@@ -681,7 +1334,50 @@ func (s *Scheduler) GetRunningAgents() []string {
 // StartAgentForTask manually starts an agent for a specific task.
 // This bypasses the normal scheduler reconciliation.
 func (s *Scheduler) StartAgentForTask(ctx context.Context, task types.Task) error {
-	return s.startAgent(ctx, task)
+	return s.StartAgentForTaskWithInputs(ctx, task, "", nil)
+}
+
+// StartAgentForTaskPinned manually starts an agent for a task, pinned to the
+// grimoire snapshot with the given content hash.
+func (s *Scheduler) StartAgentForTaskPinned(ctx context.Context, task types.Task, grimoireHash string) error {
+	return s.StartAgentForTaskWithInputs(ctx, task, grimoireHash, nil)
+}
+
+// StartAgentForTaskWithInputs manually starts an agent for a task with values
+// for its grimoire's inputs, pinned to the grimoire snapshot with the given
+// content hash unless it is empty. Unlike a task the scheduler starts, which
+// fails its workflow, the task doesn't start if a required input is missing:
+// a grimoire.MissingInputsError is returned so the caller can ask for it. A
+// stopped workflow resumes with the inputs it was started with.
+func (s *Scheduler) StartAgentForTaskWithInputs(ctx context.Context, task types.Task, grimoireHash string, inputs map[string]interface{}) error {
+	if grimoireHash != "" && !grimoire.NewSnapshotStore(s.covenDir).Exists(grimoireHash) {
+		return &grimoire.SnapshotNotFoundError{Hash: grimoireHash}
+	}
+	if grimoireHash != "" || !s.hasStoppedWorkflow(task.ID) {
+		if err := s.workflowRunner.CheckInputs(task, grimoireHash, inputs); err != nil {
+			return err
+		}
+	}
+	return s.startAgent(ctx, task, grimoireHash, inputs)
+}
+
+// TaskIDForWorkflow returns the task ID for a persisted workflow, or "" if unknown.
+func (s *Scheduler) TaskIDForWorkflow(workflowID string) string {
+	state := s.statePersister.FindByWorkflowID(workflowID)
+	if state == nil {
+		return ""
+	}
+	return state.TaskID
+}
+
+// WorkflowHistory returns the task's retained workflow runs, oldest first,
+// and the workflow ID of its current run, or "" if it has none.
+func (s *Scheduler) WorkflowHistory(taskID string) ([]*workflow.WorkflowState, string, error) {
+	history, err := s.statePersister.History(taskID)
+	if err != nil {
+		return nil, "", err
+	}
+	return history, s.statePersister.CurrentWorkflowID(taskID), nil
 }
 
 // IsAgentRunning checks if an agent is running for the given task.
@@ -722,15 +1418,24 @@ func (s *Scheduler) QueueWorkflowResume(state *workflow.WorkflowState) error {
 		return fmt.Errorf("task %s not found", state.TaskID)
 	}
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Hold the grimoire's concurrency group before changing anything
+	group, err := s.claimResumeGroupLocked(task.ID, state)
+	if err != nil {
+		return err
+	}
+
 	// Update state to running for resume
-	statePersister := workflow.NewStatePersister(s.covenDir)
 	state.Status = workflow.WorkflowRunning
-	if err := statePersister.Save(state); err != nil {
+	if err := s.statePersister.Save(state); err != nil {
+		s.releaseConcurrencyGroupLocked(group, task.ID)
 		return fmt.Errorf("failed to update workflow state: %w", err)
 	}
 
 	// Resume the workflow in background
-	go s.resumeWorkflow(context.Background(), *task, state)
+	s.goResumeClaimed(*task, state, group)
 
 	return nil
 }
@@ -744,8 +1449,7 @@ func (s *Scheduler) ApproveMerge(taskID string) (*workflow.MergeResult, error) {
 	defer s.mu.Unlock()
 
 	// Load the workflow state
-	statePersister := workflow.NewStatePersister(s.covenDir)
-	state, err := statePersister.Load(taskID)
+	state, err := s.statePersister.Load(taskID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load workflow state: %w", err)
 	}
@@ -757,29 +1461,60 @@ func (s *Scheduler) ApproveMerge(taskID string) (*workflow.MergeResult, error) {
 		return nil, fmt.Errorf("workflow is not pending merge (status: %s)", state.Status)
 	}
 
+	// Hold the grimoire's concurrency group before merging, so the workflow
+	// can resume once merged
+	group, err := s.claimResumeGroupLocked(taskID, state)
+	if err != nil {
+		return nil, err
+	}
+	resumed := false
+	defer func() {
+		if !resumed {
+			s.releaseConcurrencyGroupLocked(group, taskID)
+		}
+	}()
+
 	mergeRunner := &workflow.DefaultMergeRunner{}
 	ctx := context.Background()
+	meta := workflow.CommitMetadata{
+		TaskID:       state.TaskID,
+		WorkflowID:   state.WorkflowID,
+		Grimoire:     state.GrimoireName,
+		GrimoireHash: state.GrimoireHash,
+	}
+
+	// Find the worktree if it has moved since the state was saved
+	if err := s.relocateWorktree(ctx, state); err != nil {
+		return nil, err
+	}
+
+	// Load the grimoire for the merge step's options and the post-merge hook
+	g, err := s.workflowRunner.loadForResume(state)
+	if err != nil {
+		s.logger.Warn("failed to load grimoire for merge", "task_id", taskID, "error", err)
+	}
 
 	// Step 1: Commit any uncommitted changes in the worktree
-	if err := mergeRunner.CommitWorktree(ctx, state.WorktreePath); err != nil {
+	if err := mergeRunner.CommitWorktree(ctx, state.WorktreePath, meta); err != nil {
 		return nil, fmt.Errorf("failed to commit worktree: %w", err)
 	}
 
 	// Step 2: Get worktree info for branch name
-	wtInfo, err := s.worktreeManager.Get(taskID)
+	worktrees := s.worktreesFor(taskID)
+	wtInfo, err := worktrees.Get(taskID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get worktree info: %w", err)
 	}
 
 	// Step 3: Get the base branch (main/master)
-	baseBranch, err := s.worktreeManager.GetBaseBranch(ctx)
+	baseBranch, err := worktrees.GetBaseBranch(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get base branch: %w", err)
 	}
 
 	// Step 4: Merge the worktree branch to main
-	mainRepoDir := s.worktreeManager.RepoPath()
-	mergeResult, err := mergeRunner.MergeToMain(ctx, mainRepoDir, wtInfo.Branch, baseBranch)
+	mainRepoDir := worktrees.RepoPath()
+	mergeResult, err := mergeRunner.MergeToMain(ctx, mainRepoDir, wtInfo.Branch, baseBranch, meta, pendingMergeOptions(g, state))
 	if err != nil {
 		return nil, fmt.Errorf("merge failed: %w", err)
 	}
@@ -793,18 +1528,20 @@ func (s *Scheduler) ApproveMerge(taskID string) (*workflow.MergeResult, error) {
 		return mergeResult, nil
 	}
 
-	// Step 5: Cleanup - remove worktree and branch
-	if err := s.worktreeManager.Remove(ctx, taskID); err != nil {
-		s.logger.Warn("failed to remove worktree", "task_id", taskID, "error", err)
+	// Step 5: Run the grimoire's post-merge hook while the worktree still exists
+	if g != nil {
+		s.runPostMergeHook(ctx, g.PostMerge, meta, wtInfo.Branch, baseBranch, mergeResult)
 	}
-	if err := s.worktreeManager.DeleteBranch(ctx, wtInfo.Branch); err != nil {
-		s.logger.Warn("failed to delete branch", "branch", wtInfo.Branch, "error", err)
+
+	// Step 6: Cleanup - remove worktree and branch, unless the grimoire keeps them
+	if !state.KeepWorktree {
+		s.removeWorktree(ctx, taskID, wtInfo.Branch)
 	}
 
-	// Step 6: Update state to running and increment step
+	// Step 7: Update state to running and increment step
 	state.Status = workflow.WorkflowRunning
 	state.CurrentStep++ // Move past the merge step
-	if err := statePersister.Save(state); err != nil {
+	if err := s.statePersister.Save(state); err != nil {
 		return nil, fmt.Errorf("failed to save workflow state: %w", err)
 	}
 
@@ -823,7 +1560,8 @@ func (s *Scheduler) ApproveMerge(taskID string) (*workflow.MergeResult, error) {
 	}
 
 	// Resume the workflow in background (from after the merge step)
-	go s.resumeWorkflow(context.Background(), *task, state)
+	s.goResumeClaimed(*task, state, group)
+	resumed = true
 
 	s.logger.Info("merge approved, workflow resuming",
 		"task_id", taskID,
@@ -834,31 +1572,46 @@ func (s *Scheduler) ApproveMerge(taskID string) (*workflow.MergeResult, error) {
 	return mergeResult, nil
 }
 
+// pendingMergeOptions returns the options of the merge step a workflow is
+// waiting on, or the defaults if its grimoire couldn't be loaded.
+func pendingMergeOptions(g *grimoire.Grimoire, state *workflow.WorkflowState) workflow.MergeOptions {
+	if g == nil || state.CurrentStep < 0 || state.CurrentStep >= len(g.Steps) {
+		return workflow.MergeOptions{}
+	}
+	step := &g.Steps[state.CurrentStep]
+	if step.Type != grimoire.StepTypeMerge {
+		return workflow.MergeOptions{}
+	}
+	return workflow.MergeOptionsFor(step)
+}
+
 // performAutoMerge merges the worktree branch to main without requiring approval.
-// Used when a merge step has require_review: false.
-func (s *Scheduler) performAutoMerge(ctx context.Context, taskID, worktreePath string) error {
+// Used when a merge step has require_review: false. The commits it creates
+// carry meta as trailers.
+func (s *Scheduler) performAutoMerge(ctx context.Context, taskID, worktreePath string, meta workflow.CommitMetadata, opts workflow.MergeOptions, keepWorktree bool, postMerge *grimoire.PostMergeHook) error {
 	mergeRunner := &workflow.DefaultMergeRunner{}
 
 	// Step 1: Commit any uncommitted changes in the worktree
-	if err := mergeRunner.CommitWorktree(ctx, worktreePath); err != nil {
+	if err := mergeRunner.CommitWorktree(ctx, worktreePath, meta); err != nil {
 		return fmt.Errorf("failed to commit worktree: %w", err)
 	}
 
 	// Step 2: Get worktree info for branch name
-	wtInfo, err := s.worktreeManager.Get(taskID)
+	worktrees := s.worktreesFor(taskID)
+	wtInfo, err := worktrees.Get(taskID)
 	if err != nil {
 		return fmt.Errorf("failed to get worktree info: %w", err)
 	}
 
 	// Step 3: Get the base branch (main/master)
-	baseBranch, err := s.worktreeManager.GetBaseBranch(ctx)
+	baseBranch, err := worktrees.GetBaseBranch(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get base branch: %w", err)
 	}
 
 	// Step 4: Merge the worktree branch to main
-	mainRepoDir := s.worktreeManager.RepoPath()
-	mergeResult, err := mergeRunner.MergeToMain(ctx, mainRepoDir, wtInfo.Branch, baseBranch)
+	mainRepoDir := worktrees.RepoPath()
+	mergeResult, err := mergeRunner.MergeToMain(ctx, mainRepoDir, wtInfo.Branch, baseBranch, meta, opts)
 	if err != nil {
 		return fmt.Errorf("merge failed: %w", err)
 	}
@@ -872,12 +1625,12 @@ func (s *Scheduler) performAutoMerge(ctx context.Context, taskID, worktreePath s
 		return fmt.Errorf("merge has conflicts: %v", mergeResult.ConflictFiles)
 	}
 
-	// Step 5: Cleanup - remove worktree and branch
-	if err := s.worktreeManager.Remove(ctx, taskID); err != nil {
-		s.logger.Warn("failed to remove worktree", "task_id", taskID, "error", err)
-	}
-	if err := s.worktreeManager.DeleteBranch(ctx, wtInfo.Branch); err != nil {
-		s.logger.Warn("failed to delete branch", "branch", wtInfo.Branch, "error", err)
+	// Step 5: Run the grimoire's post-merge hook while the worktree still exists
+	s.runPostMergeHook(ctx, postMerge, meta, wtInfo.Branch, baseBranch, mergeResult)
+
+	// Step 6: Cleanup - remove worktree and branch, unless the grimoire keeps them
+	if !keepWorktree {
+		s.removeWorktree(ctx, taskID, wtInfo.Branch)
 	}
 
 	s.logger.Info("auto-merge completed successfully",
@@ -888,14 +1641,245 @@ func (s *Scheduler) performAutoMerge(ctx context.Context, taskID, worktreePath s
 	return nil
 }
 
+// removeWorktree removes a task's worktree and branch, logging failures.
+func (s *Scheduler) removeWorktree(ctx context.Context, taskID, branch string) {
+	worktrees := s.worktreesFor(taskID)
+	if err := worktrees.Remove(ctx, taskID); err != nil {
+		s.logger.Warn("failed to remove worktree", "task_id", taskID, "error", err)
+	}
+	if err := worktrees.DeleteBranch(ctx, branch); err != nil {
+		s.logger.Warn("failed to delete branch", "branch", branch, "error", err)
+	}
+}
+
+// CleanupWorktree removes the worktree and branch kept for a finished
+// workflow and deletes its saved state.
+func (s *Scheduler) CleanupWorktree(taskID string) error {
+	ctx := context.Background()
+
+	worktrees := s.worktreesFor(taskID)
+	if wtInfo, err := worktrees.Get(taskID); err == nil {
+		if err := worktrees.Remove(ctx, taskID); err != nil {
+			return fmt.Errorf("failed to remove worktree: %w", err)
+		}
+		if err := worktrees.DeleteBranch(ctx, wtInfo.Branch); err != nil {
+			s.logger.Warn("failed to delete branch", "branch", wtInfo.Branch, "error", err)
+		}
+	}
+
+	if err := s.statePersister.Delete(taskID); err != nil {
+		return err
+	}
+
+	s.logger.Info("cleaned up workflow worktree", "task_id", taskID)
+	return nil
+}
+
+// ConfirmStep confirms the step a workflow is waiting on and resumes the
+// workflow, running the confirmed step next.
+func (s *Scheduler) ConfirmStep(taskID string) (*workflow.WorkflowState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Load the workflow state
+	state, err := s.statePersister.Load(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow state: %w", err)
+	}
+	if state == nil {
+		return nil, fmt.Errorf("workflow state not found for task %s", taskID)
+	}
+
+	if state.Status != workflow.WorkflowAwaitingConfirmation || state.PendingConfirmation == nil {
+		return nil, fmt.Errorf("workflow is not awaiting confirmation (status: %s)", state.Status)
+	}
+
+	// Find the task to resume
+	var task *types.Task
+	for _, t := range s.store.GetTasks() {
+		if t.ID == taskID {
+			task = &t
+			break
+		}
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task %s not found", taskID)
+	}
+
+	// Hold the grimoire's concurrency group before changing anything
+	group, err := s.claimResumeGroupLocked(taskID, state)
+	if err != nil {
+		return nil, err
+	}
+
+	stepName := state.PendingConfirmation.StepName
+	state.ConfirmedStep = stepName
+	state.PendingConfirmation = nil
+	state.Status = workflow.WorkflowRunning
+	if err := s.statePersister.Save(state); err != nil {
+		s.releaseConcurrencyGroupLocked(group, taskID)
+		return nil, fmt.Errorf("failed to save workflow state: %w", err)
+	}
+
+	s.goResumeClaimed(*task, state, group)
+
+	s.logger.Info("step confirmed, workflow resuming",
+		"task_id", taskID,
+		"step", stepName,
+	)
+
+	return state, nil
+}
+
+// RunGrimoireStep runs one top-level step of the named grimoire on its own,
+// outside any workflow, for debugging the grimoire. Agent steps use the
+// scheduler's agent runner unless config sets one.
+func (s *Scheduler) RunGrimoireStep(ctx context.Context, grimoireName, stepName string, config StepRunConfig) (*workflow.StepResult, error) {
+	if config.AgentRunner == nil {
+		config.AgentRunner = s.workflowAgentRunner()
+	}
+	return s.workflowRunner.RunStep(ctx, grimoireName, stepName, config)
+}
+
+// StepNotSkippableError is returned when a step can't be skipped because the
+// workflow is not blocked on it.
+type StepNotSkippableError struct {
+	StepName string
+	Reason   string
+}
+
+func (e *StepNotSkippableError) Error() string {
+	return fmt.Sprintf("cannot skip step %q: %s", e.StepName, e.Reason)
+}
+
+// IsStepNotSkippable checks if an error is a StepNotSkippableError.
+func IsStepNotSkippable(err error) bool {
+	_, ok := err.(*StepNotSkippableError)
+	return ok
+}
+
+// SkipBlockedStep skips the step a blocked workflow is stopped on and resumes
+// the workflow from the step after it. The step is recorded as skipped in the
+// workflow's completed steps. A StepNotSkippableError is returned if the
+// workflow is not blocked or stepName is not the blocking step.
+func (s *Scheduler) SkipBlockedStep(taskID, stepName string) (*workflow.WorkflowState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Load the workflow state
+	state, err := s.statePersister.Load(taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow state: %w", err)
+	}
+	if state == nil {
+		return nil, fmt.Errorf("workflow state not found for task %s", taskID)
+	}
+
+	if state.Status != workflow.WorkflowBlocked {
+		return nil, &StepNotSkippableError{StepName: stepName, Reason: fmt.Sprintf("workflow is not blocked (status: %s)", state.Status)}
+	}
+
+	// The blocked step is the last one the engine executed
+	g, err := s.workflowRunner.loadForResume(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load grimoire %q: %w", state.GrimoireName, err)
+	}
+	if state.CurrentStep < 0 || state.CurrentStep >= len(g.Steps) {
+		return nil, fmt.Errorf("workflow step index %d is out of range for grimoire %q", state.CurrentStep, g.Name)
+	}
+	blocking := g.Steps[state.CurrentStep].Name
+	if blocking != stepName {
+		return nil, &StepNotSkippableError{StepName: stepName, Reason: fmt.Sprintf("workflow is blocked on step %q", blocking)}
+	}
+
+	// Find the task to resume
+	var task *types.Task
+	for _, t := range s.store.GetTasks() {
+		if t.ID == taskID {
+			task = &t
+			break
+		}
+	}
+	if task == nil {
+		return nil, fmt.Errorf("task %s not found", taskID)
+	}
+
+	// Hold the grimoire's concurrency group before changing anything
+	group, err := s.claimResumeGroupLocked(taskID, state)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the skip; resuming continues after the last executed step
+	if state.CompletedSteps == nil {
+		state.CompletedSteps = make(map[string]*workflow.StepResult)
+	}
+	state.CompletedSteps[stepName] = &workflow.StepResult{
+		Success: true,
+		Skipped: true,
+		Output:  "skipped: manually skipped while blocked",
+	}
+	state.Status = workflow.WorkflowRunning
+	state.Error = ""
+	state.Escalation = nil
+	if err := s.statePersister.Save(state); err != nil {
+		s.releaseConcurrencyGroupLocked(group, taskID)
+		return nil, fmt.Errorf("failed to save workflow state: %w", err)
+	}
+
+	s.goResumeClaimed(*task, state, group)
+
+	s.logger.Info("blocked step skipped, workflow resuming",
+		"task_id", taskID,
+		"step", stepName,
+		"next_step", state.CurrentStep+1,
+	)
+
+	return state, nil
+}
+
+// LoopNotBreakableError is returned when a loop can't be broken out of
+// because it isn't running.
+type LoopNotBreakableError struct {
+	StepName string
+	Reason   string
+}
+
+func (e *LoopNotBreakableError) Error() string {
+	return fmt.Sprintf("cannot break loop %q: %s", e.StepName, e.Reason)
+}
+
+// IsLoopNotBreakable checks if an error is a LoopNotBreakableError.
+func IsLoopNotBreakable(err error) bool {
+	_, ok := err.(*LoopNotBreakableError)
+	return ok
+}
+
+// BreakLoop asks the running loop step stepName of the task's workflow to
+// stop once its current iteration finishes, as if a nested step had returned
+// exit_loop. The workflow then continues with the step after the loop. A
+// LoopNotBreakableError is returned if the workflow or the loop isn't
+// running.
+func (s *Scheduler) BreakLoop(taskID, stepName string) error {
+	loopBreaks := s.taskLoopBreaks(taskID)
+	if loopBreaks == nil {
+		return &LoopNotBreakableError{StepName: stepName, Reason: "workflow is not running"}
+	}
+	if !loopBreaks.Request(stepName) {
+		return &LoopNotBreakableError{StepName: stepName, Reason: "loop is not running"}
+	}
+
+	s.logger.Info("loop break requested", "task_id", taskID, "step", stepName)
+	return nil
+}
+
 // RejectMerge rejects a pending merge and blocks the workflow.
 func (s *Scheduler) RejectMerge(taskID string, reason string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Load the workflow state
-	statePersister := workflow.NewStatePersister(s.covenDir)
-	state, err := statePersister.Load(taskID)
+	state, err := s.statePersister.Load(taskID)
 	if err != nil {
 		return fmt.Errorf("failed to load workflow state: %w", err)
 	}
@@ -910,14 +1894,13 @@ func (s *Scheduler) RejectMerge(taskID string, reason string) error {
 	// Update state to blocked
 	state.Status = workflow.WorkflowBlocked
 	state.Error = reason
-	if err := statePersister.Save(state); err != nil {
+	if err := s.statePersister.Save(state); err != nil {
 		return fmt.Errorf("failed to save workflow state: %w", err)
 	}
 
 	// Update task status to blocked
-	s.store.UpdateTaskStatus(taskID, types.TaskStatusBlocked)
 	ctx := context.Background()
-	if err := s.beadsClient.UpdateStatus(ctx, taskID, types.TaskStatusBlocked); err != nil {
+	if err := s.updateBeadsStatus(ctx, taskID, types.TaskStatusBlocked, types.StatusReasonMergeRejected); err != nil {
 		s.logger.Error("failed to update task status in beads",
 			"task_id", taskID,
 			"error", err,
@@ -2,9 +2,12 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,6 +16,7 @@ import (
 	"github.com/coven/daemon/internal/git"
 	"github.com/coven/daemon/internal/logging"
 	"github.com/coven/daemon/internal/state"
+	"github.com/coven/daemon/internal/workflow"
 	"github.com/coven/daemon/pkg/types"
 )
 
@@ -257,6 +261,232 @@ func TestSchedulerReconcileMaxAgents(t *testing.T) {
 	}
 }
 
+func TestSchedulerConvergeAgents(t *testing.T) {
+	sched, store, _ := newTestScheduler(t)
+	sched.SetMaxAgents(3)
+	sched.SetAgentCommand("sh", []string{"-c", "sleep 10"})
+	ctx := context.Background()
+
+	// A higher priority number is a lower priority
+	store.SetTasks([]types.Task{
+		{ID: "task-high", Title: "High", Status: types.TaskStatusOpen, Priority: 1},
+		{ID: "task-mid", Title: "Mid", Status: types.TaskStatusOpen, Priority: 2},
+		{ID: "task-low", Title: "Low", Status: types.TaskStatusOpen, Priority: 3},
+	})
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, taskID := range sched.GetRunningAgents() {
+			sched.KillAgent(taskID)
+		}
+	})
+
+	waitForRunningAgents := func(want int) []string {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			running := sched.GetRunningAgents()
+			if len(running) == want || time.Now().After(deadline) {
+				return running
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	if running := waitForRunningAgents(3); len(running) != 3 {
+		t.Fatalf("Running agents = %v, want 3", running)
+	}
+
+	// Lowering the limit leaves running agents to finish
+	sched.SetMaxAgents(2)
+	time.Sleep(100 * time.Millisecond)
+	if running := sched.GetRunningAgents(); len(running) != 3 {
+		t.Errorf("Running agents after SetMaxAgents(2) = %v, want 3", running)
+	}
+
+	sched.SetMaxAgents(1)
+	if drained := sched.ConvergeAgents(); len(drained) != 2 {
+		t.Errorf("ConvergeAgents() = %v, want task-mid and task-low", drained)
+	}
+	running := waitForRunningAgents(1)
+	if len(running) != 1 || !strings.HasPrefix(running[0], "task-high") {
+		t.Fatalf("Running agents after ConvergeAgents() = %v, want only task-high", running)
+	}
+
+	// Drained workflows wait to resume until an agent slot is free
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sched.mu.RLock()
+		waiting := len(sched.awaitingAgentSlot)
+		sched.mu.RUnlock()
+		if waiting == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	sched.mu.RLock()
+	for _, taskID := range []string{"task-mid", "task-low"} {
+		if !sched.awaitingAgentSlot[taskID] || sched.pendingResumes[taskID] == nil {
+			t.Errorf("%s should be waiting to resume", taskID)
+		}
+	}
+	sched.mu.RUnlock()
+
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if running := sched.GetRunningAgents(); len(running) != 1 {
+		t.Errorf("Running agents after Reconcile() at the limit = %v, want 1", running)
+	}
+	for _, task := range store.GetTasks() {
+		if task.Status != types.TaskStatusInProgress {
+			t.Errorf("%s status = %s, want in_progress while paused", task.ID, task.Status)
+		}
+	}
+
+	// Once the remaining agent finishes, one drained workflow resumes
+	sched.KillAgent(running[0])
+	waitForRunningAgents(0)
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	if running := waitForRunningAgents(1); len(running) != 1 || strings.HasPrefix(running[0], "task-high") {
+		t.Errorf("Running agents after the slot freed = %v, want one drained workflow resumed", running)
+	}
+}
+
+func TestSchedulerReconcilePreemptsLowerPriority(t *testing.T) {
+	sched, store, _ := newTestScheduler(t)
+	sched.SetMaxAgents(1)
+	sched.SetPreemption(true)
+	sched.SetAgentCommand("sh", []string{"-c", "sleep 30"})
+	ctx := context.Background()
+	t.Cleanup(func() {
+		for _, taskID := range sched.GetRunningAgents() {
+			sched.KillAgent(taskID)
+		}
+	})
+
+	waitForRunning := func(prefix string) []string {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			running := sched.GetRunningAgents()
+			if len(running) == 1 && strings.HasPrefix(running[0], prefix) {
+				return running
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Running agents = %v, want only %s", running, prefix)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	store.SetTasks([]types.Task{
+		{ID: "task-low", Title: "Low", Status: types.TaskStatusOpen, Priority: 3},
+	})
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	waitForRunning("task-low")
+
+	// A P0 task arrives with every agent slot taken
+	tasks := store.GetTasks()
+	tasks = append(tasks, types.Task{ID: "task-urgent", Title: "Urgent", Status: types.TaskStatusOpen, Priority: 0})
+	store.SetTasks(tasks)
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+
+	// The P3 workflow is paused to resume later
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		sched.mu.RLock()
+		paused := sched.awaitingAgentSlot["task-low"] && sched.pendingResumes["task-low"] != nil
+		sched.mu.RUnlock()
+		if paused {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("task-low was not paused")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// The freed slot goes to the P0 task, not back to the paused workflow
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	running := waitForRunning("task-urgent")
+	sched.mu.RLock()
+	stillPaused := sched.awaitingAgentSlot["task-low"]
+	sched.mu.RUnlock()
+	if !stillPaused {
+		t.Error("task-low should stay paused while task-urgent runs")
+	}
+
+	// With no higher-priority task ready nothing more is preempted
+	if preempted := sched.preemptForReadyTasks(running, 0); preempted != "" {
+		t.Errorf("preemptForReadyTasks() = %q with no higher-priority task ready, want none", preempted)
+	}
+
+	// Once the P0 agent finishes, the paused workflow resumes
+	sched.KillAgent(running[0])
+	deadline = time.Now().Add(5 * time.Second)
+	for len(sched.GetRunningAgents()) != 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	waitForRunning("task-low")
+}
+
+func TestSchedulerReconcileMaxWorkflows(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	sched.SetMaxAgents(5)
+	sched.SetMaxWorkflows(1)
+	ctx := context.Background()
+
+	// A workflow blocked on review holds a workflow slot without an agent
+	persister := workflow.NewStatePersister(filepath.Join(repoDir, ".coven"))
+	store.SetTasks([]types.Task{
+		{ID: "task-blocked", Title: "Blocked", Status: types.TaskStatusBlocked, StatusReason: types.StatusReasonMergePending},
+		{ID: "task-1", Title: "Task 1", Status: types.TaskStatusOpen},
+	})
+
+	if err := sched.Reconcile(ctx); err != nil {
+		t.Errorf("Reconcile() error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if agentState := store.GetAgent("task-1"); agentState != nil {
+		t.Errorf("task-1 started with %d active workflow(s) and max_workflows 1", sched.activeWorkflowCount())
+	}
+	if persister.Exists("task-1") {
+		t.Error("task-1 should not have a workflow while at workflow capacity")
+	}
+
+	// Stopped and finished workflows don't count against the limit
+	store.UpdateTaskStatus("task-blocked", types.TaskStatusBlocked, types.StatusReasonStopped)
+	if got := sched.activeWorkflowCount(); got != 0 {
+		t.Errorf("activeWorkflowCount() = %d, want 0 once the workflow has stopped", got)
+	}
+	store.UpdateTaskStatus("task-blocked", types.TaskStatusClosed, types.StatusReasonMerged)
+	if got := sched.activeWorkflowCount(); got != 0 {
+		t.Errorf("activeWorkflowCount() = %d, want 0 once the workflow has finished", got)
+	}
+
+	// A workflow waiting to resume holds a slot
+	sched.mu.Lock()
+	sched.pendingResumes["task-blocked"] = &workflow.WorkflowState{TaskID: "task-blocked", Status: workflow.WorkflowRunning}
+	sched.mu.Unlock()
+	if got := sched.activeWorkflowCount(); got != 1 {
+		t.Errorf("activeWorkflowCount() = %d, want 1 with a workflow waiting to resume", got)
+	}
+}
+
 func TestSchedulerReconcileSkipsRunningTasks(t *testing.T) {
 	t.Skip("Skipped: requires workflow infrastructure - covered by E2E tests")
 	sched, store, _ := newTestScheduler(t)
@@ -516,3 +746,422 @@ func TestSchedulerReconcileLoopRuns(t *testing.T) {
 		t.Error("Agent should have been created by reconcile loop")
 	}
 }
+
+// writeFutureWorkflowState writes a running workflow state with a schema
+// version newer than this daemon supports and returns its content.
+func writeFutureWorkflowState(t *testing.T, covenDir, taskID string) string {
+	t.Helper()
+	stateDir := filepath.Join(covenDir, "workflows")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		t.Fatalf("Failed to create state dir: %v", err)
+	}
+	content := `{"version": 99, "task_id": "` + taskID + `", "workflow_id": "wf-future", "grimoire_name": "future", "status": "running", "future_field": true}`
+	if err := os.WriteFile(filepath.Join(stateDir, taskID+".json"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+	return content
+}
+
+func TestSchedulerResume_UnsupportedStateVersion(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	content := writeFutureWorkflowState(t, covenDir, "task-1")
+
+	store.SetTasks([]types.Task{
+		{ID: "task-1", Title: "Test Task", Status: types.TaskStatusInProgress},
+	})
+
+	sched.resumeInterruptedWorkflows()
+
+	agentState := store.GetAgent("task-1")
+	if agentState == nil {
+		t.Fatal("Expected a failed agent record for the unsupported workflow")
+	}
+	if agentState.Status != types.AgentStatusFailed {
+		t.Errorf("Agent status = %q, want %q", agentState.Status, types.AgentStatusFailed)
+	}
+	if !strings.Contains(agentState.Error, "unsupported state version") {
+		t.Errorf("Agent error = %q, want unsupported state version", agentState.Error)
+	}
+
+	for _, task := range store.GetTasks() {
+		if task.ID == "task-1" && task.Status != types.TaskStatusBlocked {
+			t.Errorf("Task status = %q, want %q", task.Status, types.TaskStatusBlocked)
+		}
+	}
+
+	sched.mu.RLock()
+	_, pending := sched.pendingResumes["task-1"]
+	sched.mu.RUnlock()
+	if pending {
+		t.Error("Unsupported workflow should not be queued for resume")
+	}
+
+	data, err := os.ReadFile(filepath.Join(covenDir, "workflows", "task-1.json"))
+	if err != nil {
+		t.Fatalf("State file should be left in place: %v", err)
+	}
+	if string(data) != content {
+		t.Errorf("State file was modified:\n%s", data)
+	}
+}
+
+// writeShutdownTestGrimoire writes a two-step script grimoire for shutdown tests.
+func writeShutdownTestGrimoire(t *testing.T, covenDir, firstCommand string) {
+	t.Helper()
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	content := `name: shutdown-test
+description: Shutdown test grimoire
+steps:
+  - name: first
+    type: script
+    command: "` + firstCommand + `"
+  - name: second
+    type: script
+    command: "touch second"
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "shutdown-test.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+}
+
+// startShutdownTestWorkflow starts the shutdown test grimoire for task-1 and
+// waits for its first step to begin.
+func startShutdownTestWorkflow(t *testing.T, sched *Scheduler, store *state.Store) {
+	t.Helper()
+	task := types.Task{
+		ID:     "task-1",
+		Title:  "Test Task",
+		Status: types.TaskStatusOpen,
+		Labels: []string{"grimoire:shutdown-test"},
+	}
+	store.SetTasks([]types.Task{task})
+	if err := sched.StartAgentForTask(context.Background(), task); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+}
+
+func TestSchedulerShutdown_FinishesCurrentStep(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	writeShutdownTestGrimoire(t, covenDir, "sleep 0.5 && touch first")
+	startShutdownTestWorkflow(t, sched, store)
+
+	sched.Shutdown(5 * time.Second)
+
+	worktree := sched.worktreeManager.GetPath("task-1")
+	if _, err := os.Stat(filepath.Join(worktree, "first")); err != nil {
+		t.Error("Current step should finish within the grace period")
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "second")); err == nil {
+		t.Error("Next step should not start during shutdown")
+	}
+
+	interrupted, err := workflow.NewStatePersister(covenDir).ListInterrupted()
+	if err != nil {
+		t.Fatalf("ListInterrupted() error: %v", err)
+	}
+	if len(interrupted) != 1 {
+		t.Fatalf("ListInterrupted() returned %d states, want 1", len(interrupted))
+	}
+	if interrupted[0].CompletedSteps["first"] == nil {
+		t.Error("First step should be recorded in the saved state")
+	}
+
+	for _, task := range store.GetTasks() {
+		if task.ID == "task-1" && task.Status != types.TaskStatusInProgress {
+			t.Errorf("Task status = %q, want %q", task.Status, types.TaskStatusInProgress)
+		}
+	}
+
+	if err := sched.StartAgentForTask(context.Background(), types.Task{ID: "task-2"}); err == nil {
+		t.Error("StartAgentForTask() should fail after shutdown")
+	}
+}
+
+func TestSchedulerShutdown_GraceExpired(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	writeShutdownTestGrimoire(t, covenDir, "exec sleep 30")
+	startShutdownTestWorkflow(t, sched, store)
+
+	start := time.Now()
+	sched.Shutdown(100 * time.Millisecond)
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("Shutdown took %v, want the step to be killed after the grace period", time.Since(start))
+	}
+
+	state, err := workflow.NewStatePersister(covenDir).Load("task-1")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if state == nil {
+		t.Fatal("Workflow state should be kept after shutdown")
+	}
+	if state.Status != workflow.WorkflowRunning {
+		t.Errorf("State status = %q, want %q so the workflow resumes", state.Status, workflow.WorkflowRunning)
+	}
+	if state.CurrentStep != -1 {
+		t.Errorf("CurrentStep = %d, want -1 so the killed step reruns", state.CurrentStep)
+	}
+}
+
+func TestSchedulerConcurrencyGroups(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	if _, ok := sched.acquireConcurrencyGroup("", "task-1"); !ok {
+		t.Error("acquiring the empty group failed, want it always acquired")
+	}
+	if _, ok := sched.acquireConcurrencyGroup("db", "task-1"); !ok {
+		t.Fatal("acquiring a free group failed")
+	}
+	if _, ok := sched.acquireConcurrencyGroup("db", "task-1"); !ok {
+		t.Error("re-acquiring a group held by the same task failed")
+	}
+	if holder, ok := sched.acquireConcurrencyGroup("db", "task-2"); ok || holder != "task-1" {
+		t.Errorf("acquire by another task = (%q, %v), want (task-1, false)", holder, ok)
+	}
+	if _, ok := sched.acquireConcurrencyGroup("cache", "task-2"); !ok {
+		t.Error("acquiring a different group failed")
+	}
+
+	// Only the holder releases the group
+	sched.releaseConcurrencyGroup("db", "task-2")
+	if _, ok := sched.acquireConcurrencyGroup("db", "task-3"); ok {
+		t.Error("group was released by a task that didn't hold it")
+	}
+	sched.releaseConcurrencyGroup("db", "task-1")
+	if _, ok := sched.acquireConcurrencyGroup("db", "task-3"); !ok {
+		t.Error("acquiring a released group failed")
+	}
+}
+
+func TestSchedulerPrepareWorkflow_SparsePaths(t *testing.T) {
+	sched, _, repoDir := newTestScheduler(t)
+
+	for _, file := range []string{"services/api/main.go", "services/web/main.go"} {
+		path := filepath.Join(repoDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("package main"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+	exec.Command("git", "-C", repoDir, "add", "services").Run()
+	if err := exec.Command("git", "-C", repoDir, "commit", "-m", "Add services").Run(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	grimoiresDir := filepath.Join(repoDir, ".coven", "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	grimoireYAML := `name: api
+description: Work on the API service
+sparse_paths:
+  - services/api
+steps:
+  - name: build
+    type: script
+    command: "true"
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "api.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	task := types.Task{ID: "task-api", Title: "API change", Labels: []string{"grimoire:api"}}
+	worktreePath, release, err := sched.prepareWorkflow(context.Background(), task, "")
+	if err != nil {
+		t.Fatalf("prepareWorkflow() error: %v", err)
+	}
+	defer release()
+
+	if _, err := os.Stat(filepath.Join(worktreePath, "services", "api", "main.go")); err != nil {
+		t.Errorf("services/api should be checked out: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktreePath, "services", "web")); !os.IsNotExist(err) {
+		t.Error("services/web should not be checked out")
+	}
+}
+
+func TestSchedulerResume_ConcurrencyGroupBusy(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	grimoireYAML := `name: grouped-resume
+description: Resume within a concurrency group
+concurrency_group: db-migrations
+steps:
+  - name: migrate
+    type: script
+    command: "true"
+  - name: merge
+    type: merge
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "grouped-resume.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+	sched.acquireConcurrencyGroup("db-migrations", "task-other")
+	persister := workflow.NewStatePersister(covenDir)
+
+	tests := []struct {
+		name   string
+		state  workflow.WorkflowState
+		resume func(state *workflow.WorkflowState) error
+	}{
+		{
+			name:  "retry",
+			state: workflow.WorkflowState{Status: workflow.WorkflowFailed, CurrentStep: 0},
+			resume: func(state *workflow.WorkflowState) error {
+				return sched.QueueWorkflowResume(state)
+			},
+		},
+		{
+			name:  "approve merge",
+			state: workflow.WorkflowState{Status: workflow.WorkflowPendingMerge, CurrentStep: 1},
+			resume: func(state *workflow.WorkflowState) error {
+				_, err := sched.ApproveMerge(state.TaskID)
+				return err
+			},
+		},
+		{
+			name: "confirm step",
+			state: workflow.WorkflowState{
+				Status:              workflow.WorkflowAwaitingConfirmation,
+				CurrentStep:         -1,
+				PendingConfirmation: &workflow.Confirmation{StepName: "migrate"},
+			},
+			resume: func(state *workflow.WorkflowState) error {
+				_, err := sched.ConfirmStep(state.TaskID)
+				return err
+			},
+		},
+		{
+			name:  "skip blocked step",
+			state: workflow.WorkflowState{Status: workflow.WorkflowBlocked, CurrentStep: 0},
+			resume: func(state *workflow.WorkflowState) error {
+				_, err := sched.SkipBlockedStep(state.TaskID, "migrate")
+				return err
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskID := fmt.Sprintf("task-grouped-%d", i)
+			store.SetTasks([]types.Task{{ID: taskID, Title: "Migrate", Labels: []string{"grimoire:grouped-resume"}}})
+			state := tt.state
+			state.TaskID = taskID
+			state.WorkflowID = "wf-" + taskID
+			state.GrimoireName = "grouped-resume"
+			state.WorktreePath = t.TempDir()
+			state.StartedAt = time.Now()
+			if err := persister.Save(&state); err != nil {
+				t.Fatalf("Save() error: %v", err)
+			}
+
+			if err := tt.resume(&state); !IsConcurrencyGroupBusy(err) {
+				t.Fatalf("error = %v, want ConcurrencyGroupBusyError", err)
+			}
+
+			saved, err := persister.Load(taskID)
+			if err != nil || saved == nil {
+				t.Fatalf("Load() = %v, %v; want saved state", saved, err)
+			}
+			if saved.Status != tt.state.Status {
+				t.Errorf("Status = %q, want it left %q", saved.Status, tt.state.Status)
+			}
+			if holder, ok := sched.acquireConcurrencyGroup("db-migrations", taskID); ok || holder != "task-other" {
+				t.Errorf("acquire = (%q, %v), want the group still held by task-other", holder, ok)
+			}
+		})
+	}
+}
+
+func TestIsConcurrencyGroupBusy(t *testing.T) {
+	err := &ConcurrencyGroupBusyError{Group: "db", TaskID: "task-1"}
+	if !IsConcurrencyGroupBusy(err) {
+		t.Error("IsConcurrencyGroupBusy() = false, want true")
+	}
+	if IsConcurrencyGroupBusy(errors.New("other")) {
+		t.Error("IsConcurrencyGroupBusy() = true for another error")
+	}
+	if want := `concurrency group "db" is held by task task-1`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestApproveMerge_FFOnly(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	content := `name: ff-only-test
+description: Fast-forward only merge test grimoire
+steps:
+  - name: change
+    type: script
+    command: "echo change > feature.txt"
+  - name: merge
+    type: merge
+    ff_only: true
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "ff-only-test.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	baseBranch := git(repoDir, "rev-parse", "--abbrev-ref", "HEAD")
+
+	task := types.Task{ID: "task-1", Title: "Test Task", Status: types.TaskStatusOpen, Labels: []string{"grimoire:ff-only-test"}}
+	store.SetTasks([]types.Task{task})
+	if err := sched.StartAgentForTask(context.Background(), task); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+	persister := workflow.NewStatePersister(covenDir)
+	waitForWorkflowStatus(t, persister, task.ID, workflow.WorkflowPendingMerge)
+
+	// Move the base branch on so the task's branch can't fast-forward it
+	if err := os.WriteFile(filepath.Join(repoDir, "other.txt"), []byte("other"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	git(repoDir, "add", "other.txt")
+	git(repoDir, "commit", "-m", "other")
+
+	if _, err := sched.ApproveMerge(task.ID); !workflow.IsNotFastForward(err) {
+		t.Fatalf("ApproveMerge() error = %v, want a NotFastForwardError", err)
+	}
+	if state, _ := persister.Load(task.ID); state == nil || state.Status != workflow.WorkflowPendingMerge {
+		t.Fatalf("state = %+v, want the workflow still pending merge", state)
+	}
+
+	state, _ := persister.Load(task.ID)
+	git(state.WorktreePath, "rebase", baseBranch)
+
+	result, err := sched.ApproveMerge(task.ID)
+	if err != nil {
+		t.Fatalf("ApproveMerge() after rebase error: %v", err)
+	}
+	if head := headCommit(t, repoDir); !result.Success || result.MergeCommit != head {
+		t.Errorf("ApproveMerge() = %+v, want %s fast-forwarded to %s", result, baseBranch, head)
+	}
+	if parents := strings.Fields(git(repoDir, "log", "-1", "--format=%P")); len(parents) != 1 {
+		t.Errorf("%s head has parents %v, want no merge commit", baseBranch, parents)
+	}
+}
@@ -0,0 +1,245 @@
+package schedulertest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/coven/daemon/internal/git"
+	"github.com/coven/daemon/internal/scheduler"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+var (
+	_ scheduler.BeadsClient     = (*Beads)(nil)
+	_ scheduler.WorktreeManager = (*Worktrees)(nil)
+	_ workflow.AgentRunner      = (*Agent)(nil)
+)
+
+// Beads is an in-memory scheduler.BeadsClient that records every status
+// update it receives.
+type Beads struct {
+	mu      sync.Mutex
+	updates map[string][]types.TaskStatus
+	err     error
+}
+
+// NewBeads creates an empty Beads.
+func NewBeads() *Beads {
+	return &Beads{updates: make(map[string][]types.TaskStatus)}
+}
+
+// UpdateStatus records the status, or returns the error set with SetError.
+func (b *Beads) UpdateStatus(ctx context.Context, taskID string, status types.TaskStatus) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.updates[taskID] = append(b.updates[taskID], status)
+	return nil
+}
+
+// SetError makes subsequent updates fail with err. Pass nil to clear it.
+func (b *Beads) SetError(err error) {
+	b.mu.Lock()
+	b.err = err
+	b.mu.Unlock()
+}
+
+// Status returns the last status recorded for the task, or "" if none.
+func (b *Beads) Status(taskID string) types.TaskStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	updates := b.updates[taskID]
+	if len(updates) == 0 {
+		return ""
+	}
+	return updates[len(updates)-1]
+}
+
+// Updates returns every status recorded for the task, in order.
+func (b *Beads) Updates(taskID string) []types.TaskStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]types.TaskStatus{}, b.updates[taskID]...)
+}
+
+// Worktrees is a scheduler.WorktreeManager that creates plain directories
+// instead of git worktrees. Merging is not supported, since there is no
+// repository behind them.
+type Worktrees struct {
+	mu      sync.Mutex
+	root    string
+	removed []string
+	sparse  map[string][]string
+}
+
+// NewWorktrees creates a Worktrees that places worktrees under root.
+func NewWorktrees(root string) *Worktrees {
+	return &Worktrees{root: root}
+}
+
+// Create creates the task's worktree directory, or returns the existing one.
+func (w *Worktrees) Create(ctx context.Context, taskID string) (*git.WorktreeInfo, error) {
+	path := w.GetPath(taskID)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	return w.info(taskID), nil
+}
+
+// CreateSparse creates the task's worktree directory like Create and records
+// the sparse paths it was asked for; the directory isn't limited to them.
+func (w *Worktrees) CreateSparse(ctx context.Context, taskID string, paths []string) (*git.WorktreeInfo, error) {
+	w.mu.Lock()
+	if w.sparse == nil {
+		w.sparse = make(map[string][]string)
+	}
+	w.sparse[taskID] = append([]string(nil), paths...)
+	w.mu.Unlock()
+	return w.Create(ctx, taskID)
+}
+
+// SparsePaths returns the sparse paths the task's worktree was created with.
+func (w *Worktrees) SparsePaths(taskID string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.sparse[taskID]...)
+}
+
+// Get returns the task's worktree if it exists.
+func (w *Worktrees) Get(taskID string) (*git.WorktreeInfo, error) {
+	if _, err := os.Stat(w.GetPath(taskID)); os.IsNotExist(err) {
+		return nil, fmt.Errorf("worktree not found for task %s", taskID)
+	}
+	return w.info(taskID), nil
+}
+
+// Remove deletes the task's worktree directory.
+func (w *Worktrees) Remove(ctx context.Context, taskID string) error {
+	w.mu.Lock()
+	w.removed = append(w.removed, taskID)
+	w.mu.Unlock()
+	return os.RemoveAll(w.GetPath(taskID))
+}
+
+// DeleteBranch does nothing; there are no branches behind the worktrees.
+func (w *Worktrees) DeleteBranch(ctx context.Context, branchName string) error {
+	return nil
+}
+
+// GetBaseBranch returns "main".
+func (w *Worktrees) GetBaseBranch(ctx context.Context) (string, error) {
+	return "main", nil
+}
+
+// Divergence reports no divergence; there are no branches behind the
+// worktrees.
+func (w *Worktrees) Divergence(ctx context.Context, branch, baseBranch string) (*git.Divergence, error) {
+	return &git.Divergence{}, nil
+}
+
+// GetPath returns the path of the task's worktree.
+func (w *Worktrees) GetPath(taskID string) string {
+	return filepath.Join(w.root, taskID)
+}
+
+// RepoPath returns the directory worktrees are created under.
+func (w *Worktrees) RepoPath() string {
+	return w.root
+}
+
+// Repair returns the task's worktree if it exists; there are no git links to
+// repair.
+func (w *Worktrees) Repair(ctx context.Context, taskID string) (*git.WorktreeInfo, error) {
+	return w.Get(taskID)
+}
+
+// Removed returns the task IDs whose worktrees have been removed, in order.
+func (w *Worktrees) Removed() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string{}, w.removed...)
+}
+
+func (w *Worktrees) info(taskID string) *git.WorktreeInfo {
+	return &git.WorktreeInfo{
+		Path:   w.GetPath(taskID),
+		Branch: "coven/" + taskID,
+		TaskID: taskID,
+	}
+}
+
+// AgentCall is a single agent step run by the fake agent.
+type AgentCall struct {
+	// WorkDir is the worktree the step ran in.
+	WorkDir string
+
+	// Prompt is the rendered spell.
+	Prompt string
+}
+
+// AgentFunc answers an agent step. Its output is parsed like a real agent's,
+// so it can return a JSON block with success, summary and outputs.
+type AgentFunc func(ctx context.Context, call AgentCall) (*workflow.AgentRunResult, error)
+
+// Agent is an in-process workflow.AgentRunner.
+type Agent struct {
+	mu      sync.Mutex
+	respond AgentFunc
+	calls   []AgentCall
+}
+
+// NewAgent creates an Agent that answers with respond, or succeeds every
+// step if respond is nil.
+func NewAgent(respond AgentFunc) *Agent {
+	return &Agent{respond: respond}
+}
+
+// Run records the call and answers it.
+func (a *Agent) Run(ctx context.Context, workDir, prompt string, onSpawn func(stepTaskID string)) (*workflow.AgentRunResult, error) {
+	a.mu.Lock()
+	call := AgentCall{WorkDir: workDir, Prompt: prompt}
+	a.calls = append(a.calls, call)
+	stepTaskID := fmt.Sprintf("fake-agent-%d", len(a.calls))
+	respond := a.respond
+	a.mu.Unlock()
+
+	if onSpawn != nil {
+		onSpawn(stepTaskID)
+	}
+
+	if respond == nil {
+		return &workflow.AgentRunResult{
+			Output:     `{"success": true, "summary": "done"}`,
+			StepTaskID: stepTaskID,
+		}, nil
+	}
+
+	result, err := respond(ctx, call)
+	if result != nil && result.StepTaskID == "" {
+		result.StepTaskID = stepTaskID
+	}
+	return result, err
+}
+
+// WaitForExisting returns nil; the fake agent has no processes to reconnect to.
+func (a *Agent) WaitForExisting(ctx context.Context, stepTaskID string) (*workflow.AgentRunResult, error) {
+	return nil, nil
+}
+
+// IsRunning returns false; fake agent steps finish before Run returns.
+func (a *Agent) IsRunning(stepTaskID string) bool {
+	return false
+}
+
+// Calls returns every agent step run so far, in order.
+func (a *Agent) Calls() []AgentCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]AgentCall{}, a.calls...)
+}
@@ -0,0 +1,214 @@
+// Package schedulertest provides an in-memory harness for running workflows
+// through the scheduler in tests. Tasks are fed in through the state store
+// and run by the real workflow engine, while beads, git worktrees and agent
+// processes are replaced with in-process fakes.
+package schedulertest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/agent"
+	"github.com/coven/daemon/internal/logging"
+	"github.com/coven/daemon/internal/scheduler"
+	"github.com/coven/daemon/internal/state"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// DefaultReconcileInterval is how often the harness's scheduler looks for
+// ready tasks. It is short so tests don't wait on the production default.
+const DefaultReconcileInterval = 10 * time.Millisecond
+
+// Builder configures a Harness.
+type Builder struct {
+	t                 testing.TB
+	grimoires         map[string]string
+	spells            map[string]string
+	tasks             []types.Task
+	respond           AgentFunc
+	maxAgents         int
+	reconcileInterval time.Duration
+}
+
+// New returns a Builder for a harness owned by t.
+func New(t testing.TB) *Builder {
+	return &Builder{
+		t:                 t,
+		grimoires:         make(map[string]string),
+		spells:            make(map[string]string),
+		reconcileInterval: DefaultReconcileInterval,
+	}
+}
+
+// WithGrimoire adds a grimoire, given as YAML, to the harness's .coven directory.
+func (b *Builder) WithGrimoire(name, yaml string) *Builder {
+	b.grimoires[name] = yaml
+	return b
+}
+
+// WithSpell adds a spell template to the harness's .coven directory.
+func (b *Builder) WithSpell(name, content string) *Builder {
+	b.spells[name] = content
+	return b
+}
+
+// WithTask adds a task to the state store. Open tasks are picked up by the
+// scheduler once the harness is started.
+func (b *Builder) WithTask(task types.Task) *Builder {
+	b.tasks = append(b.tasks, task)
+	return b
+}
+
+// WithAgent sets how the fake agent answers each agent step.
+// By default every agent step succeeds.
+func (b *Builder) WithAgent(respond AgentFunc) *Builder {
+	b.respond = respond
+	return b
+}
+
+// WithMaxAgents sets the scheduler's maximum number of concurrent workflows.
+func (b *Builder) WithMaxAgents(n int) *Builder {
+	b.maxAgents = n
+	return b
+}
+
+// WithReconcileInterval sets how often the scheduler looks for ready tasks.
+func (b *Builder) WithReconcileInterval(d time.Duration) *Builder {
+	b.reconcileInterval = d
+	return b
+}
+
+// Build creates the harness. The scheduler is not started; call Start.
+// Everything the harness creates is cleaned up when the test ends.
+func (b *Builder) Build() *Harness {
+	t := b.t
+	t.Helper()
+
+	root := t.TempDir()
+	covenDir := filepath.Join(root, ".coven")
+	writeFiles(t, filepath.Join(covenDir, "grimoires"), b.grimoires, ".yaml")
+	writeFiles(t, filepath.Join(covenDir, "spells"), b.spells, ".md")
+
+	logger, err := logging.New(filepath.Join(root, "covend.log"))
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	// The store is never loaded or saved, so task and agent state stay in memory.
+	store := state.NewStore(root)
+	store.SetTasks(append([]types.Task{}, b.tasks...))
+
+	beads := NewBeads()
+	worktrees := NewWorktrees(filepath.Join(root, "worktrees"))
+	agentRunner := NewAgent(b.respond)
+
+	sched := scheduler.NewScheduler(store, beads, agent.NewProcessManager(logger), worktrees, logger, covenDir)
+	sched.SetAgentRunner(agentRunner)
+	sched.SetReconcileInterval(b.reconcileInterval)
+	if b.maxAgents > 0 {
+		sched.SetMaxAgents(b.maxAgents)
+	}
+
+	t.Cleanup(func() {
+		sched.Shutdown(time.Second)
+		logger.Close()
+	})
+
+	return &Harness{
+		t:         t,
+		Scheduler: sched,
+		Store:     store,
+		Beads:     beads,
+		Worktrees: worktrees,
+		Agent:     agentRunner,
+		CovenDir:  covenDir,
+	}
+}
+
+// writeFiles writes each named file into dir with the given extension.
+func writeFiles(t testing.TB, dir string, files map[string]string, ext string) {
+	t.Helper()
+	if len(files) == 0 {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", dir, err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name+ext), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// Harness is a scheduler wired to in-process fakes.
+type Harness struct {
+	t testing.TB
+
+	// Scheduler is the scheduler under test.
+	Scheduler *scheduler.Scheduler
+
+	// Store is the in-memory daemon state the scheduler reads tasks from.
+	Store *state.Store
+
+	// Beads records the task statuses the scheduler reports to beads.
+	Beads *Beads
+
+	// Worktrees provides plain directories in place of git worktrees.
+	Worktrees *Worktrees
+
+	// Agent answers agent steps in-process.
+	Agent *Agent
+
+	// CovenDir is the .coven directory holding grimoires, spells and
+	// workflow state.
+	CovenDir string
+}
+
+// Start starts the scheduler.
+func (h *Harness) Start() {
+	h.Scheduler.Start()
+}
+
+// AddTask adds a task to the state store while the harness is running.
+func (h *Harness) AddTask(task types.Task) {
+	h.Store.SetTasks(append(h.Store.GetTasks(), task))
+}
+
+// WaitForTaskStatus waits until the task reaches status in the state store,
+// failing the test if it doesn't within timeout.
+func (h *Harness) WaitForTaskStatus(taskID string, status types.TaskStatus, timeout time.Duration) {
+	h.t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	var last types.TaskStatus
+	for time.Now().Before(deadline) {
+		for _, task := range h.Store.GetTasks() {
+			if task.ID == taskID {
+				last = task.Status
+			}
+		}
+		if last == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	h.t.Fatalf("task %s status = %q after %v, want %q", taskID, last, timeout, status)
+}
+
+// WorkflowState returns the persisted state of the task's current workflow,
+// or nil if there is none. Workflows that complete stop being current, though
+// their state stays in the task's history, unless the grimoire keeps its
+// worktree.
+func (h *Harness) WorkflowState(taskID string) *workflow.WorkflowState {
+	h.t.Helper()
+
+	st, err := workflow.NewStatePersister(h.CovenDir).Load(taskID)
+	if err != nil {
+		h.t.Fatalf("failed to load workflow state for %s: %v", taskID, err)
+	}
+	return st
+}
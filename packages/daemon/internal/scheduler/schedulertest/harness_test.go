@@ -0,0 +1,265 @@
+package schedulertest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+const buildGrimoire = `name: build-feature
+description: Plan, build and review a feature
+steps:
+  - name: plan
+    type: agent
+    spell: |
+      Plan the work for {{.bead.title}}.
+    output: plan
+
+  - name: build
+    type: script
+    command: echo built {{.bead.title}} > artifact.txt && cat artifact.txt
+    output: build
+
+  - name: review
+    type: agent
+    spell: |
+      Review the build: {{.build}}
+`
+
+func TestHarness_RunsMultiStepGrimoire(t *testing.T) {
+	h := New(t).
+		WithGrimoire("build-feature", buildGrimoire).
+		WithTask(types.Task{
+			ID:     "task-1",
+			Title:  "Add widgets",
+			Status: types.TaskStatusOpen,
+			Labels: []string{"grimoire:build-feature"},
+		}).
+		WithAgent(func(ctx context.Context, call AgentCall) (*workflow.AgentRunResult, error) {
+			if strings.Contains(call.Prompt, "Plan the work") {
+				return &workflow.AgentRunResult{
+					Output: `{"success": true, "summary": "planned"}`,
+				}, nil
+			}
+			return &workflow.AgentRunResult{Output: `{"success": true, "summary": "looks good"}`}, nil
+		}).
+		Build()
+
+	h.Start()
+	h.WaitForTaskStatus("task-1", types.TaskStatusClosed, 5*time.Second)
+
+	if got := h.Beads.Updates("task-1"); len(got) != 2 ||
+		got[0] != types.TaskStatusInProgress || got[1] != types.TaskStatusClosed {
+		t.Errorf("beads updates = %v, want [in_progress closed]", got)
+	}
+
+	calls := h.Agent.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("agent calls = %d, want 2", len(calls))
+	}
+	if !strings.Contains(calls[0].Prompt, "Add widgets") {
+		t.Errorf("plan prompt = %q, want it to contain the task title", calls[0].Prompt)
+	}
+	if !strings.Contains(calls[1].Prompt, "built Add widgets") {
+		t.Errorf("review prompt = %q, want it to contain the build output", calls[1].Prompt)
+	}
+	if calls[0].WorkDir != h.Worktrees.GetPath("task-1") {
+		t.Errorf("agent workdir = %q, want %q", calls[0].WorkDir, h.Worktrees.GetPath("task-1"))
+	}
+
+	data, err := os.ReadFile(filepath.Join(h.Worktrees.GetPath("task-1"), "artifact.txt"))
+	if err != nil {
+		t.Fatalf("failed to read script output: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "built Add widgets" {
+		t.Errorf("artifact.txt = %q, want %q", data, "built Add widgets")
+	}
+
+	if st := h.WorkflowState("task-1"); st != nil {
+		t.Errorf("workflow state = %+v, want it removed after completion", st)
+	}
+}
+
+func TestHarness_FailedAgentStepBlocksTask(t *testing.T) {
+	h := New(t).
+		WithGrimoire("build-feature", buildGrimoire).
+		WithTask(types.Task{
+			ID:     "task-1",
+			Title:  "Add widgets",
+			Status: types.TaskStatusOpen,
+			Labels: []string{"grimoire:build-feature"},
+		}).
+		WithAgent(func(ctx context.Context, call AgentCall) (*workflow.AgentRunResult, error) {
+			return &workflow.AgentRunResult{Output: `{"success": false, "summary": "cannot plan"}`, ExitCode: 1}, nil
+		}).
+		Build()
+
+	h.Start()
+	h.WaitForTaskStatus("task-1", types.TaskStatusBlocked, 5*time.Second)
+
+	if got := h.Beads.Status("task-1"); got != types.TaskStatusBlocked {
+		t.Errorf("beads status = %q, want %q", got, types.TaskStatusBlocked)
+	}
+	if got := len(h.Agent.Calls()); got != 1 {
+		t.Errorf("agent calls = %d, want 1 (workflow should stop at the failed step)", got)
+	}
+}
+
+func migrationGrimoire(name string) string {
+	return `name: ` + name + `
+description: Change the database schema
+concurrency_group: db-migrations
+steps:
+  - name: migrate
+    type: agent
+    spell: |
+      Write the migration.
+`
+}
+
+func TestHarness_ConcurrencyGroupSerializesGrimoires(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+
+	h := New(t).
+		WithGrimoire("add-migration", migrationGrimoire("add-migration")).
+		WithGrimoire("squash-migrations", migrationGrimoire("squash-migrations")).
+		WithGrimoire("build-feature", buildGrimoire).
+		WithTask(types.Task{ID: "task-add", Status: types.TaskStatusOpen, Labels: []string{"grimoire:add-migration"}}).
+		WithTask(types.Task{ID: "task-squash", Status: types.TaskStatusOpen, Labels: []string{"grimoire:squash-migrations"}}).
+		WithTask(types.Task{ID: "task-feature", Status: types.TaskStatusOpen, Labels: []string{"grimoire:build-feature"}}).
+		WithAgent(func(ctx context.Context, call AgentCall) (*workflow.AgentRunResult, error) {
+			if !strings.Contains(call.Prompt, "Write the migration") {
+				return &workflow.AgentRunResult{Output: `{"success": true, "summary": "done"}`}, nil
+			}
+
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+			defer func() {
+				mu.Lock()
+				active--
+				mu.Unlock()
+			}()
+
+			select {
+			case <-release:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			return &workflow.AgentRunResult{Output: `{"success": true, "summary": "migrated"}`}, nil
+		}).
+		Build()
+
+	h.Start()
+
+	// The ungrouped task runs to completion while a migration holds the group
+	h.WaitForTaskStatus("task-feature", types.TaskStatusClosed, 5*time.Second)
+	time.Sleep(10 * DefaultReconcileInterval)
+
+	migrations := 0
+	for _, call := range h.Agent.Calls() {
+		if strings.Contains(call.Prompt, "Write the migration") {
+			migrations++
+		}
+	}
+	if migrations != 1 {
+		t.Fatalf("migration agent calls = %d while the group is held, want 1", migrations)
+	}
+
+	close(release)
+	h.WaitForTaskStatus("task-add", types.TaskStatusClosed, 5*time.Second)
+	h.WaitForTaskStatus("task-squash", types.TaskStatusClosed, 5*time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxActive != 1 {
+		t.Errorf("max concurrent migrations = %d, want 1", maxActive)
+	}
+}
+
+func TestHarness_RunTaskRunsOnlyThatTask(t *testing.T) {
+	task := types.Task{ID: "task-1", Title: "Add widgets", Status: types.TaskStatusOpen, Labels: []string{"grimoire:build-feature"}}
+	h := New(t).
+		WithGrimoire("build-feature", buildGrimoire).
+		WithTask(task).
+		WithTask(types.Task{ID: "task-2", Title: "Other", Status: types.TaskStatusOpen, Labels: []string{"grimoire:build-feature"}}).
+		Build()
+
+	// The scheduler isn't started, so nothing else is picked up
+	result, err := h.Scheduler.RunTask(context.Background(), task)
+	if err != nil {
+		t.Fatalf("RunTask() error: %v", err)
+	}
+	if !result.Success || result.Status != workflow.WorkflowCompleted {
+		t.Errorf("result = %+v, want a completed workflow", result)
+	}
+
+	if got := h.Beads.Status("task-1"); got != types.TaskStatusClosed {
+		t.Errorf("task-1 beads status = %q, want %q", got, types.TaskStatusClosed)
+	}
+	if got := h.Beads.Updates("task-2"); len(got) != 0 {
+		t.Errorf("task-2 beads updates = %v, want none", got)
+	}
+	for _, call := range h.Agent.Calls() {
+		if call.WorkDir != h.Worktrees.GetPath("task-1") {
+			t.Errorf("agent ran in %q, want only task-1's worktree", call.WorkDir)
+		}
+	}
+}
+
+func TestHarness_RerunKeepsWorkflowHistory(t *testing.T) {
+	task := types.Task{ID: "task-1", Title: "Add widgets", Status: types.TaskStatusOpen, Labels: []string{"grimoire:build-feature"}}
+	h := New(t).
+		WithGrimoire("build-feature", buildGrimoire).
+		WithTask(task).
+		Build()
+
+	for run := 1; run <= 2; run++ {
+		result, err := h.Scheduler.RunTask(context.Background(), task)
+		if err != nil {
+			t.Fatalf("run %d: RunTask() error: %v", run, err)
+		}
+		if result.Status != workflow.WorkflowCompleted {
+			t.Fatalf("run %d: Status = %q, want %q (error: %s)", run, result.Status, workflow.WorkflowCompleted, result.Error)
+		}
+	}
+
+	history, current, err := h.Scheduler.WorkflowHistory("task-1")
+	if err != nil {
+		t.Fatalf("WorkflowHistory() error: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history has %d runs, want 2", len(history))
+	}
+	if history[0].WorkflowID == history[1].WorkflowID {
+		t.Errorf("both runs have workflow ID %q, want distinct records", history[0].WorkflowID)
+	}
+	for i, state := range history {
+		if state.TaskID != "task-1" || state.Status != workflow.WorkflowCompleted {
+			t.Errorf("run %d = %s/%s, want a completed run of task-1", i, state.TaskID, state.Status)
+		}
+	}
+	if !history[0].StartedAt.Before(history[1].StartedAt) {
+		t.Error("history should be ordered oldest first")
+	}
+
+	// Completed runs are retained but no longer current
+	if current != "" {
+		t.Errorf("current = %q, want none after completion", current)
+	}
+	if st := h.WorkflowState("task-1"); st != nil {
+		t.Errorf("WorkflowState() = %+v, want nil after completion", st)
+	}
+}
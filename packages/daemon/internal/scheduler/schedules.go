@@ -0,0 +1,284 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coven/daemon/internal/config"
+	"github.com/coven/daemon/internal/cron"
+	"github.com/coven/daemon/internal/git"
+	"github.com/coven/daemon/internal/logging"
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// DefaultScheduleCheckInterval is how often the cron scheduler checks for due schedules.
+const DefaultScheduleCheckInterval = 30 * time.Second
+
+// CronScheduler runs grimoires on cron schedules declared in config.
+// Each trigger creates a synthetic task (not backed by a bead) and runs its
+// workflow in a fresh worktree through the Scheduler, like any other task's.
+// A schedule never overlaps with itself: if the previous run is still in
+// progress when the schedule fires, the trigger is skipped.
+type CronScheduler struct {
+	mu            sync.Mutex
+	scheduler     *Scheduler
+	logger        *logging.Logger
+	entries       []*scheduleEntry
+	checkInterval time.Duration
+	now           func() time.Time
+	running       bool
+	stopCh        chan struct{}
+	doneCh        chan struct{}
+	runs          sync.WaitGroup
+}
+
+// scheduleEntry tracks the runtime state of a single schedule.
+type scheduleEntry struct {
+	config     config.ScheduleConfig
+	schedule   *cron.Schedule
+	nextRun    time.Time
+	lastRun    time.Time
+	lastTaskID string
+	lastStatus string
+	lastError  string
+	running    bool
+}
+
+// ScheduleInfo describes a schedule and its run times.
+type ScheduleInfo struct {
+	Name       string     `json:"name"`
+	Grimoire   string     `json:"grimoire"`
+	Cron       string     `json:"cron"`
+	Repo       string     `json:"repo,omitempty"`
+	NextRun    time.Time  `json:"next_run"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	LastTaskID string     `json:"last_task_id,omitempty"`
+	LastStatus string     `json:"last_status,omitempty"`
+	LastError  string     `json:"last_error,omitempty"`
+	Running    bool       `json:"running"`
+}
+
+// NewCronScheduler creates a cron scheduler for the given schedules.
+// Workflows are run through the given scheduler's workflow runner.
+func NewCronScheduler(sched *Scheduler, schedules []config.ScheduleConfig, logger *logging.Logger) (*CronScheduler, error) {
+	c := &CronScheduler{
+		scheduler:     sched,
+		logger:        logger,
+		checkInterval: DefaultScheduleCheckInterval,
+		now:           time.Now,
+	}
+
+	now := c.now()
+	for _, cfg := range schedules {
+		schedule, err := cron.Parse(cfg.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", cfg.ScheduleName(), err)
+		}
+		c.entries = append(c.entries, &scheduleEntry{
+			config:   cfg,
+			schedule: schedule,
+			nextRun:  schedule.Next(now),
+		})
+	}
+
+	return c, nil
+}
+
+// SetCheckInterval sets how often the scheduler checks for due schedules.
+func (c *CronScheduler) SetCheckInterval(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checkInterval = d
+}
+
+// Start begins checking for due schedules.
+func (c *CronScheduler) Start() {
+	c.mu.Lock()
+	if c.running || len(c.entries) == 0 {
+		c.mu.Unlock()
+		return
+	}
+	c.running = true
+	c.stopCh = make(chan struct{})
+	c.doneCh = make(chan struct{})
+	c.mu.Unlock()
+
+	go c.loop()
+	c.logger.Info("cron scheduler started", "schedules", len(c.entries))
+}
+
+// Stop stops checking for due schedules and waits for runs in progress to
+// return. Runs are workflows of the Scheduler, so Scheduler.Shutdown winds
+// them down; call it first to avoid waiting for them to finish.
+func (c *CronScheduler) Stop() {
+	c.mu.Lock()
+	if !c.running {
+		c.mu.Unlock()
+		return
+	}
+	c.running = false
+	close(c.stopCh)
+	c.mu.Unlock()
+
+	<-c.doneCh
+	c.runs.Wait()
+	c.logger.Info("cron scheduler stopped")
+}
+
+// List returns all schedules with their next and last run times.
+func (c *CronScheduler) List() []ScheduleInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	infos := make([]ScheduleInfo, 0, len(c.entries))
+	for _, e := range c.entries {
+		info := ScheduleInfo{
+			Name:       e.config.ScheduleName(),
+			Grimoire:   e.config.Grimoire,
+			Cron:       e.config.Cron,
+			Repo:       e.config.Repo,
+			NextRun:    e.nextRun,
+			LastTaskID: e.lastTaskID,
+			LastStatus: e.lastStatus,
+			LastError:  e.lastError,
+			Running:    e.running,
+		}
+		if !e.lastRun.IsZero() {
+			lastRun := e.lastRun
+			info.LastRun = &lastRun
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+func (c *CronScheduler) loop() {
+	defer close(c.doneCh)
+
+	c.mu.Lock()
+	interval := c.checkInterval
+	c.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.checkDue(ctx, c.now())
+		}
+	}
+}
+
+// checkDue triggers every schedule whose next run time is at or before now.
+// It returns the number of runs started.
+func (c *CronScheduler) checkDue(ctx context.Context, now time.Time) int {
+	c.mu.Lock()
+	var due []*scheduleEntry
+	for _, e := range c.entries {
+		if e.nextRun.IsZero() || now.Before(e.nextRun) {
+			continue
+		}
+		firedAt := e.nextRun
+		e.nextRun = e.schedule.Next(now)
+
+		if e.running {
+			c.logger.Warn("skipping scheduled run, previous run still in progress",
+				"schedule", e.config.ScheduleName(),
+				"task_id", e.lastTaskID,
+			)
+			continue
+		}
+
+		e.running = true
+		e.lastRun = firedAt
+		e.lastTaskID = scheduledTaskID(e.config.ScheduleName(), firedAt)
+		e.lastStatus = ""
+		e.lastError = ""
+		due = append(due, e)
+	}
+	c.mu.Unlock()
+
+	for _, e := range due {
+		c.runs.Add(1)
+		go c.runEntry(ctx, e)
+	}
+	return len(due)
+}
+
+// runEntry runs the workflow for a triggered schedule and records the outcome.
+func (c *CronScheduler) runEntry(ctx context.Context, e *scheduleEntry) {
+	defer c.runs.Done()
+
+	c.mu.Lock()
+	cfg := e.config
+	taskID := e.lastTaskID
+	firedAt := e.lastRun
+	c.mu.Unlock()
+
+	status, runErr := c.runScheduled(ctx, cfg, taskID, firedAt)
+
+	c.mu.Lock()
+	e.running = false
+	e.lastStatus = status
+	if runErr != nil {
+		e.lastError = runErr.Error()
+	}
+	c.mu.Unlock()
+}
+
+// runScheduled creates a synthetic task for a schedule and runs its grimoire.
+func (c *CronScheduler) runScheduled(ctx context.Context, cfg config.ScheduleConfig, taskID string, firedAt time.Time) (string, error) {
+	name := cfg.ScheduleName()
+	task := types.Task{
+		ID:        taskID,
+		Title:     fmt.Sprintf("Scheduled: %s", name),
+		Type:      "task",
+		Status:    types.TaskStatusInProgress,
+		Labels:    []string{"grimoire:" + cfg.Grimoire},
+		CreatedAt: firedAt,
+		UpdatedAt: firedAt,
+	}
+
+	c.logger.Info("starting scheduled workflow",
+		"schedule", name,
+		"grimoire", cfg.Grimoire,
+		"task_id", taskID,
+	)
+
+	// Schedules for other repositories get worktrees there
+	var worktrees WorktreeManager
+	if cfg.Repo != "" && cfg.Repo != c.scheduler.worktreeManager.RepoPath() {
+		worktrees = git.NewWorktreeManager(cfg.Repo, c.logger)
+	}
+
+	result, err := c.scheduler.RunSyntheticTask(ctx, task, worktrees)
+	if err != nil {
+		c.logger.Error("scheduled workflow failed to run", "schedule", name, "error", err)
+		return string(workflow.WorkflowFailed), err
+	}
+
+	c.logger.Info("scheduled workflow completed",
+		"schedule", name,
+		"task_id", taskID,
+		"status", result.Status,
+		"duration", result.Duration,
+	)
+
+	if result.Error != "" {
+		return string(result.Status), errors.New(result.Error)
+	}
+	return string(result.Status), nil
+}
+
+// scheduledTaskID builds the synthetic task ID for a schedule trigger.
+func scheduledTaskID(name string, firedAt time.Time) string {
+	return fmt.Sprintf("schedule-%s-%s", name, firedAt.UTC().Format("20060102-1504"))
+}
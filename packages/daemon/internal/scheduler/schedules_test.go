@@ -0,0 +1,375 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/config"
+	"github.com/coven/daemon/internal/workflow"
+)
+
+// writeScheduleGrimoire writes a grimoire named nightly with the given steps.
+func writeScheduleGrimoire(t *testing.T, covenDir, steps string) {
+	t.Helper()
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	content := "name: nightly\ndescription: Scheduled grimoire test\nsteps:\n" + steps
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "nightly.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+}
+
+func TestNewCronScheduler(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "0 3 * * *"},
+		{Name: "weekly-audit", Grimoire: "audit", Cron: "@weekly", Repo: "/tmp/other"},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+
+	schedules := c.List()
+	if len(schedules) != 2 {
+		t.Fatalf("List() returned %d schedules, want 2", len(schedules))
+	}
+	if schedules[0].Name != "nightly" {
+		t.Errorf("Name = %q, want nightly", schedules[0].Name)
+	}
+	if schedules[0].NextRun.Hour() != 3 || schedules[0].NextRun.Minute() != 0 {
+		t.Errorf("NextRun = %v, want 03:00", schedules[0].NextRun)
+	}
+	if !schedules[0].NextRun.After(time.Now()) {
+		t.Errorf("NextRun = %v, want a future time", schedules[0].NextRun)
+	}
+	if schedules[1].Name != "weekly-audit" || schedules[1].Repo != "/tmp/other" {
+		t.Errorf("schedules[1] = %+v", schedules[1])
+	}
+	if schedules[0].LastRun != nil || schedules[0].Running {
+		t.Errorf("new schedule should not have run: %+v", schedules[0])
+	}
+}
+
+func TestNewCronScheduler_InvalidCron(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	_, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "not a cron"},
+	}, sched.logger)
+	if err == nil {
+		t.Fatal("NewCronScheduler() should fail for invalid cron expression")
+	}
+}
+
+func TestCronScheduler_DueScheduleTriggersWorkflow(t *testing.T) {
+	sched, _, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	outFile := filepath.Join(t.TempDir(), "scheduled.txt")
+	writeScheduleGrimoire(t, covenDir, `  - name: record
+    type: script
+    command: "pwd > `+outFile+`"
+`)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "* * * * *"},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+
+	// Not due yet
+	if n := c.checkDue(context.Background(), time.Now().Add(-time.Minute)); n != 0 {
+		t.Fatalf("checkDue() started %d runs before schedule was due", n)
+	}
+
+	if n := c.checkDue(context.Background(), time.Now().Add(2*time.Minute)); n != 1 {
+		t.Fatalf("checkDue() started %d runs, want 1", n)
+	}
+	c.runs.Wait()
+
+	info := c.List()[0]
+	if info.Running {
+		t.Error("schedule should not be running after the workflow finished")
+	}
+	if info.LastStatus != string(workflow.WorkflowCompleted) {
+		t.Errorf("LastStatus = %q, want %q (error: %s)", info.LastStatus, workflow.WorkflowCompleted, info.LastError)
+	}
+	if info.LastRun == nil {
+		t.Error("LastRun should be set")
+	}
+	if !strings.HasPrefix(info.LastTaskID, "schedule-nightly-") {
+		t.Errorf("LastTaskID = %q, want schedule-nightly- prefix", info.LastTaskID)
+	}
+
+	// The workflow should have run in the synthetic task's worktree, which
+	// is removed once the run completes
+	worktreePath := sched.worktreeManager.GetPath(info.LastTaskID)
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("scheduled workflow did not write output: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != worktreePath {
+		t.Errorf("workflow ran in %q, want the worktree %q", strings.TrimSpace(string(data)), worktreePath)
+	}
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Errorf("worktree %s should be removed after the run completes (stat error: %v)", worktreePath, err)
+	}
+	if _, err := sched.worktreeManager.Get(info.LastTaskID); err == nil {
+		t.Error("worktree should no longer be registered after the run completes")
+	}
+}
+
+func TestCronScheduler_AutoMerges(t *testing.T) {
+	sched, _, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	writeScheduleGrimoire(t, covenDir, `  - name: change
+    type: script
+    command: "echo change > feature.txt"
+  - name: merge
+    type: merge
+    require_review: false
+`)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "* * * * *"},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+
+	if n := c.checkDue(context.Background(), time.Now().Add(2*time.Minute)); n != 1 {
+		t.Fatalf("checkDue() started %d runs, want 1", n)
+	}
+	c.runs.Wait()
+
+	info := c.List()[0]
+	if info.LastStatus != string(workflow.WorkflowCompleted) {
+		t.Fatalf("LastStatus = %q, want %q (error: %s)", info.LastStatus, workflow.WorkflowCompleted, info.LastError)
+	}
+	if data, err := os.ReadFile(filepath.Join(repoDir, "feature.txt")); err != nil || strings.TrimSpace(string(data)) != "change" {
+		t.Errorf("feature.txt in repo = %q, %v; want the scheduled change merged", data, err)
+	}
+	if _, err := os.Stat(sched.worktreeManager.GetPath(info.LastTaskID)); !os.IsNotExist(err) {
+		t.Errorf("worktree should be removed after the merge (stat error: %v)", err)
+	}
+}
+
+func TestCronScheduler_OtherRepo(t *testing.T) {
+	sched, _, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	otherRepo := initTestRepo(t)
+	writeScheduleGrimoire(t, covenDir, `  - name: change
+    type: script
+    command: "echo change > feature.txt"
+  - name: merge
+    type: merge
+    require_review: false
+`)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "* * * * *", Repo: otherRepo},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+
+	if n := c.checkDue(context.Background(), time.Now().Add(2*time.Minute)); n != 1 {
+		t.Fatalf("checkDue() started %d runs, want 1", n)
+	}
+	c.runs.Wait()
+
+	info := c.List()[0]
+	if info.LastStatus != string(workflow.WorkflowCompleted) {
+		t.Fatalf("LastStatus = %q, want %q (error: %s)", info.LastStatus, workflow.WorkflowCompleted, info.LastError)
+	}
+	if _, err := os.Stat(filepath.Join(otherRepo, "feature.txt")); err != nil {
+		t.Errorf("change should be merged into the schedule's repo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, "feature.txt")); err == nil {
+		t.Error("change should not be merged into the daemon's repo")
+	}
+}
+
+func TestCronScheduler_ShutdownWindsDownRuns(t *testing.T) {
+	sched, _, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	outFile := filepath.Join(t.TempDir(), "second")
+	writeScheduleGrimoire(t, covenDir, `  - name: first
+    type: script
+    command: "sleep 0.5"
+  - name: second
+    type: script
+    command: "touch `+outFile+`"
+`)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "* * * * *"},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+
+	if n := c.checkDue(context.Background(), time.Now().Add(2*time.Minute)); n != 1 {
+		t.Fatalf("checkDue() started %d runs, want 1", n)
+	}
+	taskID := c.List()[0].LastTaskID
+
+	// The run is one of the scheduler's workflows
+	deadline := time.Now().Add(5 * time.Second)
+	for sched.taskLoopBreaks(taskID) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("scheduled run was not tracked as a workflow")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Shutdown stops the run after its current step, leaving it resumable
+	sched.Shutdown(5 * time.Second)
+	c.runs.Wait()
+
+	info := c.List()[0]
+	if info.LastStatus != string(workflow.WorkflowRunning) {
+		t.Errorf("LastStatus = %q, want %q (error: %s)", info.LastStatus, workflow.WorkflowRunning, info.LastError)
+	}
+	if _, err := os.Stat(outFile); err == nil {
+		t.Error("the step after shutdown should not run")
+	}
+}
+
+func TestCronScheduler_SuppressesOverlappingRuns(t *testing.T) {
+	sched, _, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	writeScheduleGrimoire(t, covenDir, `  - name: record
+    type: script
+    command: echo done
+`)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "* * * * *"},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+
+	// Simulate a run that is still in progress
+	c.entries[0].running = true
+	c.entries[0].lastTaskID = "schedule-nightly-previous"
+
+	now := time.Now().Add(2 * time.Minute)
+	if n := c.checkDue(context.Background(), now); n != 0 {
+		t.Fatalf("checkDue() started %d runs while previous run was in progress", n)
+	}
+
+	info := c.List()[0]
+	if info.LastTaskID != "schedule-nightly-previous" {
+		t.Errorf("LastTaskID = %q, skipped trigger should not replace the running task", info.LastTaskID)
+	}
+	if !info.NextRun.After(now) {
+		t.Errorf("NextRun = %v, skipped trigger should advance to after %v", info.NextRun, now)
+	}
+
+	// Once the previous run finishes, the next trigger runs normally
+	c.mu.Lock()
+	c.entries[0].running = false
+	c.mu.Unlock()
+
+	if n := c.checkDue(context.Background(), now.Add(2*time.Minute)); n != 1 {
+		t.Fatalf("checkDue() started %d runs, want 1", n)
+	}
+	c.runs.Wait()
+}
+
+func TestCronScheduler_StartStop(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "0 3 * * *"},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+	c.SetCheckInterval(10 * time.Millisecond)
+
+	c.Start()
+	c.Start() // idempotent
+	time.Sleep(30 * time.Millisecond)
+	c.Stop()
+	c.Stop() // idempotent
+}
+
+func TestHandleSchedulesList(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	c, err := NewCronScheduler(sched, []config.ScheduleConfig{
+		{Grimoire: "nightly", Cron: "30 2 * * *"},
+	}, sched.logger)
+	if err != nil {
+		t.Fatalf("NewCronScheduler() error: %v", err)
+	}
+
+	socketPath := filepath.Join(os.TempDir(), "coven-schedule-test-"+time.Now().Format("150405.000")+".sock")
+	server := api.NewServer(socketPath)
+	NewScheduleHandlers(c).Register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer server.Stop(context.Background())
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+
+	t.Run("lists schedules with next run", func(t *testing.T) {
+		resp, err := client.Get("http://unix/schedules")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+
+		var result ScheduleListResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if result.Count != 1 || len(result.Schedules) != 1 {
+			t.Fatalf("Count = %d, want 1", result.Count)
+		}
+		s := result.Schedules[0]
+		if s.Name != "nightly" || s.Grimoire != "nightly" || s.Cron != "30 2 * * *" {
+			t.Errorf("schedule = %+v", s)
+		}
+		if s.NextRun.IsZero() || s.NextRun.Hour() != 2 || s.NextRun.Minute() != 30 {
+			t.Errorf("NextRun = %v, want 02:30", s.NextRun)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp, err := client.Post("http://unix/schedules", "application/json", nil)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// StateChange is a correction ReconcileState made to the state store.
+type StateChange struct {
+	TaskID     string `json:"task_id"`
+	WorkflowID string `json:"workflow_id,omitempty"`
+
+	// Field is what was corrected: "task_status" or "agent_status".
+	Field string `json:"field"`
+
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// StateReconciliation reports what ReconcileState checked and changed.
+type StateReconciliation struct {
+	// Checked is the number of tasks and agents compared with their
+	// persisted workflow state.
+	Checked int `json:"checked"`
+
+	// Changes are the corrections made, for tasks first and then agents.
+	Changes []StateChange `json:"changes"`
+}
+
+// ReconcileState corrects the state store's view of tasks and agents where
+// it has drifted from the persisted workflow states, as it can after a
+// crash. Tasks and agents with a running or pending-resume workflow are left
+// alone. A task in progress without one is given the status its workflow
+// finished with, taken from its history once its run has ended, or reopened
+// if it has no workflow state, and an agent still
+// marked running is marked completed or failed. Corrected task statuses are
+// also written to beads, so the next task sync doesn't undo them.
+func (s *Scheduler) ReconcileState(ctx context.Context) (*StateReconciliation, error) {
+	taskIDs, err := s.statePersister.TaskIDs()
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]*workflow.WorkflowState, len(taskIDs))
+	ended := make(map[string]bool)
+	for _, taskID := range taskIDs {
+		state, err := s.statePersister.Load(taskID)
+		if err != nil {
+			continue // Skip invalid state files
+		}
+		if state == nil {
+			// The task's current run has ended; its last run is kept in
+			// its history
+			history, err := s.statePersister.History(taskID)
+			if err != nil || len(history) == 0 {
+				continue
+			}
+			state = history[len(history)-1]
+			ended[taskID] = true
+		}
+		states[taskID] = state
+	}
+
+	report := &StateReconciliation{Changes: []StateChange{}}
+
+	for _, task := range s.store.GetTasks() {
+		if task.Status != types.TaskStatusInProgress || s.hasLiveWorkflow(task.ID) {
+			continue
+		}
+		report.Checked++
+
+		state := states[task.ID]
+		change := StateChange{TaskID: task.ID, Field: "task_status", From: string(task.Status)}
+		switch {
+		case state == nil:
+			change.To = string(types.TaskStatusOpen)
+			change.Reason = "task is in progress but has no workflow"
+		case state.Status == workflow.WorkflowRunning && ended[task.ID]:
+			change.WorkflowID = state.WorkflowID
+			change.To = string(types.TaskStatusOpen)
+			change.Reason = "task is in progress but its workflow ended while running"
+		case state.Status == workflow.WorkflowRunning:
+			// Interrupted workflows are resumed when the daemon starts
+			continue
+		default:
+			change.WorkflowID = state.WorkflowID
+			change.To = string(StatusForResult(&WorkflowResult{Status: state.Status, NoChanges: state.NoChanges}))
+			change.Reason = fmt.Sprintf("task is in progress but its workflow is %s", state.Status)
+		}
+
+		if err := s.updateBeadsStatus(ctx, task.ID, types.TaskStatus(change.To), types.StatusReasonReconciled); err != nil {
+			s.logger.Error("failed to update task status in beads",
+				"task_id", task.ID,
+				"status", change.To,
+				"error", err,
+			)
+		}
+		report.Changes = append(report.Changes, change)
+	}
+
+	for taskID, agent := range s.store.GetAllAgents() {
+		if agent.Status != types.AgentStatusRunning && agent.Status != types.AgentStatusStarting {
+			continue
+		}
+		if s.hasLiveWorkflow(taskID) {
+			continue
+		}
+		report.Checked++
+
+		state := states[taskID]
+		change := StateChange{TaskID: taskID, Field: "agent_status", From: string(agent.Status)}
+		if state != nil && state.Status == workflow.WorkflowCompleted {
+			change.WorkflowID = state.WorkflowID
+			change.To = string(types.AgentStatusCompleted)
+			change.Reason = "agent is running but its workflow completed"
+		} else {
+			change.To = string(types.AgentStatusFailed)
+			change.Reason = "agent is running but has no running workflow"
+			if state != nil {
+				change.WorkflowID = state.WorkflowID
+				change.Reason = fmt.Sprintf("agent is running but its workflow is %s", state.Status)
+			}
+		}
+
+		s.store.UpdateAgentStatus(taskID, types.AgentStatus(change.To))
+		report.Changes = append(report.Changes, change)
+	}
+
+	for _, change := range report.Changes {
+		s.logger.Info("reconciled state drift",
+			"task_id", change.TaskID,
+			"field", change.Field,
+			"from", change.From,
+			"to", change.To,
+			"reason", change.Reason,
+		)
+	}
+
+	return report, nil
+}
+
+// hasLiveWorkflow reports whether the task's workflow is running or waiting
+// to resume, so its store entries are expected to be in flux.
+func (s *Scheduler) hasLiveWorkflow(taskID string) bool {
+	s.taskWorkflowsMu.Lock()
+	_, running := s.taskWorkflows[taskID]
+	s.taskWorkflowsMu.Unlock()
+	if running {
+		return true
+	}
+
+	s.mu.RLock()
+	_, pending := s.pendingResumes[taskID]
+	s.mu.RUnlock()
+	return pending
+}
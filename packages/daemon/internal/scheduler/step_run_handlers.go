@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/workflow"
+)
+
+// StepRunRequest is the request body for POST /grimoires/:name/steps/:step/run.
+type StepRunRequest struct {
+	// WorktreePath is the directory the step runs in.
+	WorktreePath string `json:"worktree_path"`
+
+	// Bead is the bead the step's templates see as .bead.
+	Bead *workflow.BeadData `json:"bead,omitempty"`
+
+	// Variables are set as the outputs of earlier steps would be.
+	Variables map[string]string `json:"variables,omitempty"`
+}
+
+// StepRunResponse is the response for POST /grimoires/:name/steps/:step/run.
+type StepRunResponse struct {
+	Grimoire string               `json:"grimoire"`
+	Step     string               `json:"step"`
+	Result   *workflow.StepResult `json:"result"`
+}
+
+// StepRunHandlers provides the HTTP handler for running a single grimoire
+// step while debugging a grimoire.
+type StepRunHandlers struct {
+	scheduler *Scheduler
+}
+
+// NewStepRunHandlers creates new step run handlers.
+func NewStepRunHandlers(scheduler *Scheduler) *StepRunHandlers {
+	return &StepRunHandlers{
+		scheduler: scheduler,
+	}
+}
+
+// HandleRunStep handles POST /grimoires/:name/steps/:step/run.
+// @Summary      Run a single grimoire step
+// @Description  Runs one top-level step of a grimoire on its own in the given directory, with the given bead and variables as its context, and returns its result. Nothing is logged or persisted, and the step's when condition and confirmation are ignored. Only available when debug_step_run is enabled.
+// @Tags         grimoires
+// @Accept       json
+// @Produce      json
+// @Param        name     path      string           true  "Grimoire name"
+// @Param        step     path      string           true  "Step name"
+// @Param        request  body      StepRunRequest   true  "Step context"
+// @Success      200      {object}  StepRunResponse  "The step's result"
+// @Failure      400      {object}  map[string]string  "Invalid request body or worktree path"
+// @Failure      404      {object}  map[string]string  "Grimoire or step not found"
+// @Failure      405      {object}  map[string]string  "Method not allowed"
+// @Failure      422      {object}  map[string]string  "Invalid grimoire, or the step failed to run"
+// @Router       /grimoires/{name}/steps/{step}/run [post]
+func (h *StepRunHandlers) HandleRunStep(w http.ResponseWriter, r *http.Request, name, step string) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req StepRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	if req.WorktreePath == "" {
+		api.WriteError(w, http.StatusBadRequest, "worktree_path is required")
+		return
+	}
+	if info, err := os.Stat(req.WorktreePath); err != nil || !info.IsDir() {
+		api.WriteError(w, http.StatusBadRequest, "worktree_path is not a directory: "+req.WorktreePath)
+		return
+	}
+
+	result, err := h.scheduler.RunGrimoireStep(r.Context(), name, step, StepRunConfig{
+		WorktreePath: req.WorktreePath,
+		Bead:         req.Bead,
+		Variables:    req.Variables,
+	})
+	if err != nil {
+		if grimoire.IsNotFound(err) || workflow.IsStepNotFound(err) {
+			api.WriteError(w, http.StatusNotFound, err.Error())
+		} else {
+			// A grimoire that doesn't load or a step that couldn't run,
+			// both for the grimoire's author to fix
+			api.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+		}
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, StepRunResponse{
+		Grimoire: name,
+		Step:     step,
+		Result:   result,
+	})
+}
@@ -0,0 +1,130 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+func setupTestStepRunHandlers(t *testing.T) (*http.Client, string) {
+	t.Helper()
+
+	sched, _, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+
+	socketPath := filepath.Join(os.TempDir(), "coven-step-run-test-"+time.Now().Format("150405.000")+".sock")
+	server := api.NewServer(socketPath)
+	grimoireHandlers := grimoire.NewHandlers(covenDir)
+	grimoireHandlers.SetStepRunHandler(NewStepRunHandlers(sched).HandleRunStep)
+	grimoireHandlers.Register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	content := `name: debug
+description: Test grimoire
+steps:
+  - name: setup
+    type: script
+    command: exit 1
+  - name: report
+    type: script
+    command: "echo {{.bead.title}}: {{.plan}} && pwd"
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "debug.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	return client, repoDir
+}
+
+func TestHandleRunStep(t *testing.T) {
+	client, _ := setupTestStepRunHandlers(t)
+	worktree := t.TempDir()
+
+	body := `{"worktree_path":"` + worktree + `","bead":{"id":"task-1","title":"Add login"},"variables":{"plan":"use sessions"}}`
+	resp, err := client.Post("http://unix/grimoires/debug/steps/report/run", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var result StepRunResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.Grimoire != "debug" || result.Step != "report" || result.Result == nil {
+		t.Fatalf("response = %+v, want the report step's result", result)
+	}
+	if !result.Result.Success {
+		t.Errorf("Success = false, error %q", result.Result.Error)
+	}
+	if !strings.Contains(result.Result.Output, "Add login: use sessions") || !strings.Contains(result.Result.Output, worktree) {
+		t.Errorf("Output = %q, want the rendered command's output from the worktree", result.Result.Output)
+	}
+}
+
+func TestHandleRunStep_Errors(t *testing.T) {
+	client, _ := setupTestStepRunHandlers(t)
+	worktree := t.TempDir()
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"unknown grimoire", "/grimoires/missing/steps/report/run", `{"worktree_path":"` + worktree + `"}`, http.StatusNotFound},
+		{"unknown step", "/grimoires/debug/steps/missing/run", `{"worktree_path":"` + worktree + `"}`, http.StatusNotFound},
+		{"missing worktree path", "/grimoires/debug/steps/report/run", `{}`, http.StatusBadRequest},
+		{"worktree path not a directory", "/grimoires/debug/steps/report/run", `{"worktree_path":"` + filepath.Join(worktree, "missing") + `"}`, http.StatusBadRequest},
+		{"invalid body", "/grimoires/debug/steps/report/run", `{`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Post("http://unix"+tt.path, "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST error: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				var errResp map[string]string
+				json.NewDecoder(resp.Body).Decode(&errResp)
+				t.Errorf("Status = %d, want %d (%s)", resp.StatusCode, tt.want, errResp["error"])
+			}
+		})
+	}
+
+	resp, err := client.Get("http://unix/grimoires/debug/steps/report/run")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// syntheticTask is a task the scheduler runs without a bead, such as a
+// scheduled run. Its status isn't recorded in beads.
+type syntheticTask struct {
+	// worktrees manages the task's worktree, which may be in another
+	// repository than the scheduler's.
+	worktrees WorktreeManager
+}
+
+// RunSyntheticTask runs a workflow for a task that isn't backed by a bead,
+// in a worktree created by worktrees, or the scheduler's own worktree manager
+// if nil. It runs like RunTask: it holds the grimoire's concurrency group,
+// counts toward the workflow limit and is wound down by Shutdown. A run that
+// completes has its worktree and branch removed, since there is no task to
+// come back to them; one that stops short keeps them for inspection.
+func (s *Scheduler) RunSyntheticTask(ctx context.Context, task types.Task, worktrees WorktreeManager) (*WorkflowResult, error) {
+	if worktrees == nil {
+		worktrees = s.worktreeManager
+	}
+
+	s.mu.RLock()
+	maxWorkflows := s.maxWorkflows
+	s.mu.RUnlock()
+	if maxWorkflows > 0 {
+		if active := s.activeWorkflowCount(); active >= maxWorkflows {
+			return nil, fmt.Errorf("at workflow capacity: %d of %d workflows active", active, maxWorkflows)
+		}
+	}
+
+	s.taskWorkflowsMu.Lock()
+	s.syntheticTasks[task.ID] = &syntheticTask{worktrees: worktrees}
+	s.taskWorkflowsMu.Unlock()
+
+	result, err := s.RunTask(ctx, task)
+	if err != nil {
+		// The workflow never ran, so there is nothing in the worktree to keep
+		s.forgetSyntheticTask(task.ID, worktrees, true)
+		return nil, err
+	}
+
+	// Stopped runs keep their worktree, and the task, for whoever picks
+	// them up: approving a merge, retrying or cleaning up
+	if result.Status == workflow.WorkflowCompleted && !result.KeepWorktree {
+		s.forgetSyntheticTask(task.ID, worktrees, true)
+	}
+	return result, nil
+}
+
+// forgetSyntheticTask stops tracking a synthetic task, first removing its
+// worktree and branch if they still exist and removeWorktree is set.
+func (s *Scheduler) forgetSyntheticTask(taskID string, worktrees WorktreeManager, removeWorktree bool) {
+	if removeWorktree {
+		if wtInfo, err := worktrees.Get(taskID); err == nil {
+			s.removeWorktree(context.Background(), taskID, wtInfo.Branch)
+		}
+	}
+
+	s.taskWorkflowsMu.Lock()
+	delete(s.syntheticTasks, taskID)
+	s.taskWorkflowsMu.Unlock()
+}
+
+// worktreesFor returns the worktree manager for the task's worktree.
+func (s *Scheduler) worktreesFor(taskID string) WorktreeManager {
+	s.taskWorkflowsMu.Lock()
+	defer s.taskWorkflowsMu.Unlock()
+	if synthetic := s.syntheticTasks[taskID]; synthetic != nil {
+		return synthetic.worktrees
+	}
+	return s.worktreeManager
+}
+
+// updateBeadsStatus records the task's status and why it changed in the
+// store, and the status in beads, unless the task is synthetic and has no
+// bead. Beads has nowhere to keep the reason.
+func (s *Scheduler) updateBeadsStatus(ctx context.Context, taskID string, status types.TaskStatus, reason types.TaskStatusReason) error {
+	s.store.UpdateTaskStatus(taskID, status, reason)
+
+	s.taskWorkflowsMu.Lock()
+	synthetic := s.syntheticTasks[taskID] != nil
+	s.taskWorkflowsMu.Unlock()
+	if synthetic {
+		return nil
+	}
+	return s.beadsClient.UpdateStatus(ctx, taskID, status)
+}
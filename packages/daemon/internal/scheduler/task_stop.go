@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+// errTaskStopped is the cancellation cause of a workflow stopped by StopTask.
+// It wraps workflow.ErrShutdown so the workflow is saved as running, as it is
+// when the daemon stops, and can be resumed. Agents are stopped gracefully.
+var errTaskStopped = fmt.Errorf("task stopped: %w", workflow.ErrShutdown)
+
+// errTaskKilled is the cancellation cause of a workflow stopped by StopTask
+// with force, whose agents are killed without a grace period.
+var errTaskKilled = fmt.Errorf("task killed: %w", errTaskStopped)
+
+// StopTask stops the task's running workflow without ending it: its agent is
+// stopped, gracefully unless force is set, and the workflow is saved as
+// stopped at the interrupted step. The task is blocked with its worktree
+// intact, so the scheduler doesn't pick it up again, and the workflow resumes
+// from that step when the task is next started by hand. Unlike cancelling,
+// nothing is recorded as finished. It reports whether the task had a running
+// workflow to stop.
+func (s *Scheduler) StopTask(taskID string, force bool) bool {
+	cause := errTaskStopped
+	if force {
+		cause = errTaskKilled
+	}
+	if !s.cancelTaskWorkflow(taskID, cause) {
+		return false
+	}
+	s.logger.Info("stopping task workflow", "task_id", taskID, "force", force)
+	return true
+}
+
+// killOnCancel reports whether an agent whose workflow context was cancelled
+// should be killed outright rather than stopped gracefully.
+func killOnCancel(ctx context.Context) bool {
+	cause := context.Cause(ctx)
+	return !errors.Is(cause, errTaskStopped) || errors.Is(cause, errTaskKilled)
+}
+
+// finishIfStopped saves a workflow stopped by StopTask as stopped and blocks
+// its task, keeping its worktree, so the scheduler leaves it alone, on
+// restart too, until the task is started again. It reports whether the
+// workflow was stopped.
+func (s *Scheduler) finishIfStopped(ctx context.Context, taskID string) bool {
+	if !errors.Is(context.Cause(ctx), errTaskStopped) {
+		return false
+	}
+
+	s.store.UpdateAgentStatus(taskID, types.AgentStatusKilled)
+
+	state, err := s.statePersister.Load(taskID)
+	if err == nil && state != nil {
+		state.Status = workflow.WorkflowStopped
+		err = s.statePersister.Save(state)
+	}
+	if err != nil || state == nil {
+		s.logger.Error("failed to save stopped workflow state, the task will start over",
+			"task_id", taskID,
+			"error", err,
+		)
+	}
+
+	if err := s.updateBeadsStatus(context.Background(), taskID, types.TaskStatusBlocked, types.StatusReasonStopped); err != nil {
+		s.logger.Error("failed to update task status",
+			"task_id", taskID,
+			"status", types.TaskStatusBlocked,
+			"error", err,
+		)
+	}
+
+	s.logger.Info("workflow stopped, will resume when the task is started again", "task_id", taskID)
+	return true
+}
+
+// stoppedWorkflow returns the saved state of the task's stopped workflow, or
+// nil if it has none.
+func (s *Scheduler) stoppedWorkflow(taskID string) *workflow.WorkflowState {
+	state, err := s.statePersister.Load(taskID)
+	if err != nil || state == nil || state.Status != workflow.WorkflowStopped {
+		return nil
+	}
+	return state
+}
+
+// hasStoppedWorkflow reports whether the task has a stopped workflow that
+// starting it will resume.
+func (s *Scheduler) hasStoppedWorkflow(taskID string) bool {
+	return s.stoppedWorkflow(taskID) != nil
+}
+
+// resumeStopped resumes the task's stopped workflow. If another workflow
+// holds its concurrency group the workflow stays stopped.
+func (s *Scheduler) resumeStopped(task types.Task, state *workflow.WorkflowState) error {
+	s.mu.Lock()
+	group, err := s.claimResumeGroupLocked(task.ID, state)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.goResumeClaimed(task, state, group)
+	return nil
+}
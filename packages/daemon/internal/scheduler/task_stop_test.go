@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/workflow"
+	"github.com/coven/daemon/pkg/types"
+)
+
+func TestStopTask(t *testing.T) {
+	sched, store, repoDir := newTestScheduler(t)
+	covenDir := filepath.Join(repoDir, ".coven")
+	writeShutdownTestGrimoire(t, covenDir, "exec sleep 30")
+	startShutdownTestWorkflow(t, sched, store)
+
+	if !sched.StopTask("task-1", false) {
+		t.Fatal("StopTask() = false for a running workflow")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if agent := store.GetAgent("task-1"); agent != nil && agent.Status == types.AgentStatusKilled {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("workflow was not stopped")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	task := store.GetTasks()[0]
+	if task.Status != types.TaskStatusBlocked || task.StatusReason != types.StatusReasonStopped {
+		t.Errorf("task status = %q (%q), want %q (%q)", task.Status, task.StatusReason, types.TaskStatusBlocked, types.StatusReasonStopped)
+	}
+	if _, err := os.Stat(sched.worktreeManager.GetPath("task-1")); err != nil {
+		t.Errorf("worktree should be kept: %v", err)
+	}
+
+	statePersister := workflow.NewStatePersister(covenDir)
+	stopped, err := statePersister.Load("task-1")
+	if err != nil || stopped == nil {
+		t.Fatalf("Load() = %v, %v, want the stopped state", stopped, err)
+	}
+	if stopped.Status != workflow.WorkflowStopped {
+		t.Errorf("workflow status = %q, want %q", stopped.Status, workflow.WorkflowStopped)
+	}
+
+	// The scheduler leaves the stopped task alone
+	if err := sched.Reconcile(context.Background()); err != nil {
+		t.Fatalf("Reconcile() error: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if sched.hasLiveWorkflow("task-1") {
+		t.Error("Reconcile() restarted the stopped task")
+	}
+	if state, _ := statePersister.Load("task-1"); state == nil || state.Status != workflow.WorkflowStopped {
+		t.Errorf("workflow state after Reconcile() = %+v, want it still stopped", state)
+	}
+
+	// Starting the task again resumes the stopped workflow
+	if err := sched.StartAgentForTask(context.Background(), store.GetTasks()[0]); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	resumed, err := statePersister.Load("task-1")
+	if err != nil || resumed == nil {
+		t.Fatalf("Load() = %v, %v, want the resumed state", resumed, err)
+	}
+	if resumed.WorkflowID != stopped.WorkflowID {
+		t.Errorf("WorkflowID = %q, want the stopped workflow %q resumed", resumed.WorkflowID, stopped.WorkflowID)
+	}
+
+	if !sched.StopTask("task-1", true) {
+		t.Error("StopTask() = false for the resumed workflow")
+	}
+}
+
+func TestStopTask_NoWorkflow(t *testing.T) {
+	sched, _, _ := newTestScheduler(t)
+
+	if sched.StopTask("task-unknown", false) {
+		t.Error("StopTask() = true for a task with no running workflow")
+	}
+}
@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"net/http"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/workflow"
+)
+
+// UsageGroupItem is the agent usage of one group of workflows.
+type UsageGroupItem struct {
+	// Value is the group's tag value, or "" for workflows without the tag.
+	Value       string `json:"value"`
+	Workflows   int    `json:"workflows"`
+	AgentRuns   int    `json:"agent_runs"`
+	AgentTimeMs int64  `json:"agent_time_ms"`
+}
+
+// UsageResponse is the response for GET /usage.
+type UsageResponse struct {
+	GroupBy string           `json:"group_by,omitempty"`
+	Groups  []UsageGroupItem `json:"groups"`
+	Total   UsageGroupItem   `json:"total"`
+}
+
+// newUsageGroupItem converts an aggregated usage group for a response.
+func newUsageGroupItem(group workflow.UsageGroup) UsageGroupItem {
+	return UsageGroupItem{
+		Value:       group.Value,
+		Workflows:   group.Workflows,
+		AgentRuns:   group.AgentRuns,
+		AgentTimeMs: group.AgentTime.Milliseconds(),
+	}
+}
+
+// handleUsage handles GET /usage.
+// @Summary      Get agent usage
+// @Description  Sums the agent runs and agent time of every retained workflow run, grouped by the value of the tag named by group_by. Runs without the tag are grouped under an empty value.
+// @Tags         workflows
+// @Produce      json
+// @Param        group_by query     string  false  "Tag to group by, e.g. team"
+// @Success      200      {object}  UsageResponse      "Usage response"
+// @Failure      405      {object}  map[string]string  "Method not allowed"
+// @Failure      500      {object}  map[string]string  "Failed to read workflow states"
+// @Router       /usage [get]
+func (h *WorkflowHandlers) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	taskIDs, err := h.statePersister.TaskIDs()
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to list workflows: "+err.Error())
+		return
+	}
+	var states []*workflow.WorkflowState
+	for _, taskID := range taskIDs {
+		history, err := h.statePersister.History(taskID)
+		if err != nil {
+			api.WriteError(w, http.StatusInternalServerError, "failed to read workflow history: "+err.Error())
+			return
+		}
+		states = append(states, history...)
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	response := UsageResponse{GroupBy: groupBy, Groups: []UsageGroupItem{}}
+	for _, group := range workflow.AggregateUsage(states, groupBy) {
+		response.Groups = append(response.Groups, newUsageGroupItem(group))
+	}
+	if total := workflow.AggregateUsage(states, ""); len(total) > 0 {
+		response.Total = newUsageGroupItem(total[0])
+	}
+	api.WriteJSON(w, http.StatusOK, response)
+}
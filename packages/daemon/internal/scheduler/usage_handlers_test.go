@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/workflow"
+)
+
+func TestHandleUsage_GroupByTag(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	states := []*workflow.WorkflowState{
+		{
+			TaskID:     "task-1",
+			WorkflowID: "wf-1",
+			Status:     workflow.WorkflowCompleted,
+			Tags:       map[string]string{"team": "payments", "project": "checkout"},
+			Usage:      &workflow.WorkflowUsage{AgentRuns: 2, AgentTime: 3 * time.Minute},
+		},
+		{
+			TaskID:     "task-2",
+			WorkflowID: "wf-2",
+			Status:     workflow.WorkflowFailed,
+			Tags:       map[string]string{"team": "payments"},
+			Usage:      &workflow.WorkflowUsage{AgentRuns: 1, AgentTime: time.Minute},
+		},
+		{
+			TaskID:     "task-3",
+			WorkflowID: "wf-3",
+			Status:     workflow.WorkflowCompleted,
+			Tags:       map[string]string{"team": "search"},
+			Usage:      &workflow.WorkflowUsage{AgentRuns: 1, AgentTime: 30 * time.Second},
+		},
+		{
+			TaskID:     "task-4",
+			WorkflowID: "wf-4",
+			Status:     workflow.WorkflowCompleted,
+		},
+	}
+	for _, state := range states {
+		state.StartedAt = time.Now()
+		if err := statePersister.Save(state); err != nil {
+			t.Fatalf("Save() error: %v", err)
+		}
+	}
+
+	resp, err := client.Get("http://unix/usage?group_by=team")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var result UsageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	want := []UsageGroupItem{
+		{Value: "", Workflows: 1},
+		{Value: "payments", Workflows: 2, AgentRuns: 3, AgentTimeMs: 4 * 60 * 1000},
+		{Value: "search", Workflows: 1, AgentRuns: 1, AgentTimeMs: 30 * 1000},
+	}
+	if len(result.Groups) != len(want) {
+		t.Fatalf("Groups = %+v, want %+v", result.Groups, want)
+	}
+	for i := range want {
+		if result.Groups[i] != want[i] {
+			t.Errorf("Groups[%d] = %+v, want %+v", i, result.Groups[i], want[i])
+		}
+	}
+	if result.GroupBy != "team" {
+		t.Errorf("GroupBy = %q, want %q", result.GroupBy, "team")
+	}
+	wantTotal := UsageGroupItem{Workflows: 4, AgentRuns: 4, AgentTimeMs: 270 * 1000}
+	if result.Total != wantTotal {
+		t.Errorf("Total = %+v, want %+v", result.Total, wantTotal)
+	}
+}
+
+func TestHandleUsage_MethodNotAllowed(t *testing.T) {
+	_, _, _, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	resp, err := client.Post("http://unix/usage", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
@@ -3,6 +3,7 @@ package scheduler
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -30,19 +31,32 @@ type WorkflowHandlers struct {
 	grimoireLoader  *grimoire.Loader
 	covenDir        string
 	eventEmitter    EventEmitter
+	mergeRunner     workflow.MergeRunner
 }
 
-// NewWorkflowHandlers creates new workflow handlers.
+// NewWorkflowHandlers creates new workflow handlers. They save workflow states
+// through the scheduler's persister, so comments they add are kept by the
+// scheduler's running workflows.
 func NewWorkflowHandlers(store *state.Store, scheduler *Scheduler, covenDir string) *WorkflowHandlers {
+	statePersister := workflow.NewStatePersister(covenDir)
+	if scheduler != nil {
+		statePersister = scheduler.statePersister
+	}
 	return &WorkflowHandlers{
 		store:           store,
 		scheduler:       scheduler,
-		statePersister:  workflow.NewStatePersister(covenDir),
+		statePersister:  statePersister,
 		grimoireLoader:  grimoire.NewLoader(covenDir),
 		covenDir:        covenDir,
+		mergeRunner:     &workflow.DefaultMergeRunner{},
 	}
 }
 
+// SetGrimoireOptions sets how the grimoires of workflows shown are validated.
+func (h *WorkflowHandlers) SetGrimoireOptions(opts grimoire.Options) {
+	h.grimoireLoader.SetOptions(opts)
+}
+
 // SetEventEmitter sets the event emitter for broadcasting state changes.
 func (h *WorkflowHandlers) SetEventEmitter(emitter EventEmitter) {
 	h.eventEmitter = emitter
@@ -52,6 +66,7 @@ func (h *WorkflowHandlers) SetEventEmitter(emitter EventEmitter) {
 func (h *WorkflowHandlers) Register(server *api.Server) {
 	server.RegisterHandlerFunc("/workflows", h.handleWorkflowsList)
 	server.RegisterHandlerFunc("/workflows/", h.handleWorkflowByID)
+	server.RegisterHandlerFunc("/usage", h.handleUsage)
 }
 
 // WorkflowListItem represents a workflow in the list response.
@@ -59,6 +74,7 @@ type WorkflowListItem struct {
 	WorkflowID   string                  `json:"workflow_id"`
 	TaskID       string                  `json:"task_id"`
 	GrimoireName string                  `json:"grimoire_name"`
+	GrimoireHash string                  `json:"grimoire_hash,omitempty"`
 	Status       workflow.WorkflowStatus `json:"status"`
 	CurrentStep  int                     `json:"current_step"`
 	WorktreePath string                  `json:"worktree_path"`
@@ -67,6 +83,22 @@ type WorkflowListItem struct {
 	Error        string                  `json:"error,omitempty"`
 }
 
+// newWorkflowListItem summarizes a workflow state for a list response.
+func newWorkflowListItem(state *workflow.WorkflowState) WorkflowListItem {
+	return WorkflowListItem{
+		WorkflowID:   state.WorkflowID,
+		TaskID:       state.TaskID,
+		GrimoireName: state.GrimoireName,
+		GrimoireHash: state.GrimoireHash,
+		Status:       state.Status,
+		CurrentStep:  state.CurrentStep,
+		WorktreePath: state.WorktreePath,
+		StartedAt:    state.StartedAt,
+		UpdatedAt:    state.UpdatedAt,
+		Error:        state.Error,
+	}
+}
+
 // WorkflowListResponse is the response for GET /workflows.
 type WorkflowListResponse struct {
 	Workflows []WorkflowListItem `json:"workflows"`
@@ -88,45 +120,32 @@ func (h *WorkflowHandlers) handleWorkflowsList(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// List all workflow state files
-	stateDir := h.statePersister.StateDir()
-	entries, err := os.ReadDir(stateDir)
+	// List each task's current workflow
+	taskIDs, err := h.statePersister.TaskIDs()
 	if err != nil {
-		if os.IsNotExist(err) {
-			// No workflows directory yet
-			api.WriteJSON(w, http.StatusOK, WorkflowListResponse{
-				Workflows: []WorkflowListItem{},
-				Count:     0,
-			})
-			return
-		}
 		api.WriteError(w, http.StatusInternalServerError, "failed to list workflows: "+err.Error())
 		return
 	}
 
-	var workflows []WorkflowListItem
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+	workflows := []WorkflowListItem{}
+	for _, taskID := range taskIDs {
+		state, err := h.statePersister.Load(taskID)
+		if unsupported, ok := err.(*workflow.UnsupportedStateVersionError); ok {
+			// Written by a newer daemon; report it as blocked without touching it
+			workflows = append(workflows, WorkflowListItem{
+				WorkflowID:   unsupported.WorkflowID,
+				TaskID:       unsupported.TaskID,
+				GrimoireName: unsupported.GrimoireName,
+				Status:       workflow.WorkflowBlocked,
+				Error:        unsupported.Error(),
+			})
 			continue
 		}
-
-		taskID := strings.TrimSuffix(entry.Name(), ".json")
-		state, err := h.statePersister.Load(taskID)
 		if err != nil || state == nil {
 			continue
 		}
 
-		workflows = append(workflows, WorkflowListItem{
-			WorkflowID:   state.WorkflowID,
-			TaskID:       state.TaskID,
-			GrimoireName: state.GrimoireName,
-			Status:       state.Status,
-			CurrentStep:  state.CurrentStep,
-			WorktreePath: state.WorktreePath,
-			StartedAt:    state.StartedAt,
-			UpdatedAt:    state.UpdatedAt,
-			Error:        state.Error,
-		})
+		workflows = append(workflows, newWorkflowListItem(state))
 	}
 
 	api.WriteJSON(w, http.StatusOK, WorkflowListResponse{
@@ -140,13 +159,35 @@ type StepInfo struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
 	Status      string `json:"status"` // pending, running, completed, failed, skipped
-	Depth       int    `json:"depth"`  // 0 = top level, 1+ = nested in loop
+	Depth       int    `json:"depth"`  // 0 = top level, 1+ = nested in a loop or parallel step
 	IsLoop      bool   `json:"is_loop,omitempty"`
 	MaxIter     int    `json:"max_iterations,omitempty"`
 	CurrentIter int    `json:"current_iteration,omitempty"`
 	Error       string `json:"error,omitempty"`
 	StepTaskID  string `json:"step_task_id,omitempty"` // Composite ID for SSE event matching: {task_id}-step-{index}
+	Command     string `json:"command,omitempty"`      // Rendered command of a script step that has run, secrets redacted
+
+	// Iterations is the history of a loop step's iterations that have run.
+	Iterations []IterationInfo `json:"iterations,omitempty"`
+}
+
+// IterationInfo represents one iteration of a loop step for the API response.
+type IterationInfo struct {
+	Iteration  int                 `json:"iteration"` // zero-based
+	Status     string              `json:"status"`    // completed, failed
+	Error      string              `json:"error,omitempty"`
+	DurationMs int64               `json:"duration_ms"`
+	Steps      []IterationStepInfo `json:"steps"`
+}
+
+// IterationStepInfo represents a nested step within a loop iteration.
+type IterationStepInfo struct {
+	Name       string          `json:"name"`
+	Status     string          `json:"status"` // completed, failed, skipped
+	Error      string          `json:"error,omitempty"`
+	Iterations []IterationInfo `json:"iterations,omitempty"` // set when the nested step is a loop
 }
 
 // WorkflowDetailResponse is the response for GET /workflows/:id.
@@ -154,19 +195,55 @@ type WorkflowDetailResponse struct {
 	WorkflowID     string                          `json:"workflow_id"`
 	TaskID         string                          `json:"task_id"`
 	GrimoireName   string                          `json:"grimoire_name"`
+	GrimoireHash   string                          `json:"grimoire_hash,omitempty"`
 	Status         workflow.WorkflowStatus         `json:"status"`
 	CurrentStep    int                             `json:"current_step"`
 	WorktreePath   string                          `json:"worktree_path"`
 	StartedAt      time.Time                       `json:"started_at"`
 	UpdatedAt      time.Time                       `json:"updated_at"`
+	LastProgressAt *time.Time                      `json:"last_progress_at,omitempty"`
 	Error          string                          `json:"error,omitempty"`
 	Steps          []StepInfo                      `json:"steps"`
 	CompletedSteps map[string]*workflow.StepResult `json:"completed_steps,omitempty"`
 	StepOutputs    map[string]string               `json:"step_outputs,omitempty"`
 	MergeReview    *workflow.MergeReview           `json:"merge_review,omitempty"`
+	Escalation     *workflow.Escalation            `json:"escalation,omitempty"`
+	Confirmation   *workflow.Confirmation          `json:"pending_confirmation,omitempty"`
+	Comments       []workflow.Comment              `json:"comments,omitempty"`
+	Tags           map[string]string               `json:"tags,omitempty"`
+	Result         *WorkflowResultSummary          `json:"result,omitempty"`
+	Progress       *WorkflowProgress               `json:"progress,omitempty"`
 	Actions        []string                        `json:"available_actions"`
 }
 
+// WorkflowProgress is a coarse indicator of how far a workflow has got through
+// its top-level steps. A loop counts as a single step, however many times it
+// iterates.
+type WorkflowProgress struct {
+	Percent   int `json:"percent"`   // 0-100
+	Completed int `json:"completed"` // Top-level steps that are done, including skipped ones
+	Skipped   int `json:"skipped"`   // Top-level steps skipped by their when condition or by hand
+	Total     int `json:"total"`     // Top-level steps in the grimoire
+}
+
+// WorkflowResultSummary summarizes a finished workflow run.
+// It is only populated for terminal workflows (completed, failed, cancelled).
+type WorkflowResultSummary struct {
+	Status         workflow.WorkflowStatus `json:"status"`
+	StartedAt      time.Time               `json:"started_at"`
+	EndedAt        time.Time               `json:"ended_at"`
+	DurationMs     int64                   `json:"duration_ms"`
+	TotalSteps     int                     `json:"total_steps"`
+	ExecutedSteps  int                     `json:"executed_steps"`
+	SucceededSteps int                     `json:"succeeded_steps"`
+	FailedSteps    int                     `json:"failed_steps"`
+	SkippedSteps   int                     `json:"skipped_steps"`
+	FailedStep     string                  `json:"failed_step,omitempty"`
+	FailureSummary string                  `json:"failure_summary,omitempty"`
+	KeptWorktree   string                  `json:"kept_worktree,omitempty"`
+	NoChanges      bool                    `json:"no_changes,omitempty"`
+}
+
 // handleWorkflowByID handles /workflows/:id/* endpoints.
 func (h *WorkflowHandlers) handleWorkflowByID(w http.ResponseWriter, r *http.Request) {
 	// Parse path: /workflows/{id}/action or /workflows/{id}
@@ -190,6 +267,12 @@ func (h *WorkflowHandlers) handleWorkflowByID(w http.ResponseWriter, r *http.Req
 		h.handleGetWorkflow(w, r, workflowOrTaskID)
 	case "log":
 		h.handleGetWorkflowLog(w, r, workflowOrTaskID)
+	case "diff":
+		h.handleGetWorkflowDiff(w, r, workflowOrTaskID)
+	case "merge-diff":
+		h.handleGetMergeDiff(w, r, workflowOrTaskID)
+	case "divergence":
+		h.handleGetWorkflowDivergence(w, r, workflowOrTaskID)
 	case "cancel":
 		h.handleCancelWorkflow(w, r, workflowOrTaskID)
 	case "retry":
@@ -198,7 +281,24 @@ func (h *WorkflowHandlers) handleWorkflowByID(w http.ResponseWriter, r *http.Req
 		h.handleApproveMerge(w, r, workflowOrTaskID)
 	case "reject-merge":
 		h.handleRejectMerge(w, r, workflowOrTaskID)
+	case "cleanup":
+		h.handleCleanupWorkflow(w, r, workflowOrTaskID)
+	case "confirm":
+		h.handleConfirmStep(w, r, workflowOrTaskID)
+	case "comment":
+		h.handleAddComment(w, r, workflowOrTaskID)
 	default:
+		// Step actions: step/{name}/skip and step/{name}/break-loop
+		if rest, ok := strings.CutPrefix(action, "step/"); ok {
+			if stepName, ok := strings.CutSuffix(rest, "/skip"); ok && stepName != "" {
+				h.handleSkipStep(w, r, workflowOrTaskID, stepName)
+				return
+			}
+			if stepName, ok := strings.CutSuffix(rest, "/break-loop"); ok && stepName != "" {
+				h.handleBreakLoop(w, r, workflowOrTaskID, stepName)
+				return
+			}
+		}
 		api.WriteError(w, http.StatusNotFound, "unknown action: "+action)
 	}
 }
@@ -213,6 +313,7 @@ func (h *WorkflowHandlers) handleWorkflowByID(w http.ResponseWriter, r *http.Req
 // @Success      200  {object}  WorkflowDetailResponse  "Workflow details"
 // @Failure      404  {object}  map[string]string      "Workflow not found"
 // @Failure      405  {object}  map[string]string      "Method not allowed"
+// @Failure      409  {object}  map[string]string      "Unsupported state version"
 // @Router       /workflows/{id} [get]
 func (h *WorkflowHandlers) handleGetWorkflow(w http.ResponseWriter, r *http.Request, id string) {
 	if r.Method != http.MethodGet {
@@ -222,6 +323,10 @@ func (h *WorkflowHandlers) handleGetWorkflow(w http.ResponseWriter, r *http.Requ
 
 	// Try to load by task ID first (most common case)
 	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "failed to load workflow: "+err.Error())
 		return
@@ -242,11 +347,18 @@ func (h *WorkflowHandlers) handleGetWorkflow(w http.ResponseWriter, r *http.Requ
 	case workflow.WorkflowRunning:
 		actions = []string{"cancel"}
 	case workflow.WorkflowBlocked:
-		actions = []string{"retry", "cancel"}
+		actions = []string{"retry", "skip", "cancel"}
 	case workflow.WorkflowPendingMerge:
 		actions = []string{"approve-merge", "reject-merge", "cancel"}
+	case workflow.WorkflowAwaitingConfirmation:
+		actions = []string{"confirm", "cancel"}
+	case workflow.WorkflowStopped:
+		actions = []string{"cancel"}
 	case workflow.WorkflowCompleted, workflow.WorkflowFailed, workflow.WorkflowCancelled:
 		actions = []string{} // No actions for terminal states
+		if state.KeepWorktree {
+			actions = []string{"cleanup"}
+		}
 	}
 
 	// Load merge review if pending merge
@@ -256,47 +368,188 @@ func (h *WorkflowHandlers) handleGetWorkflow(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Load grimoire to get step definitions
-	steps := h.buildStepInfo(state)
+	steps, progress := h.buildStepInfo(state)
+
+	// Summarize the run for terminal workflows
+	var resultSummary *WorkflowResultSummary
+	switch state.Status {
+	case workflow.WorkflowCompleted, workflow.WorkflowFailed, workflow.WorkflowCancelled:
+		resultSummary = buildResultSummary(state, steps)
+	}
 
 	api.WriteJSON(w, http.StatusOK, WorkflowDetailResponse{
 		WorkflowID:     state.WorkflowID,
 		TaskID:         state.TaskID,
 		GrimoireName:   state.GrimoireName,
+		GrimoireHash:   state.GrimoireHash,
 		Status:         state.Status,
 		CurrentStep:    state.CurrentStep,
 		WorktreePath:   state.WorktreePath,
 		StartedAt:      state.StartedAt,
 		UpdatedAt:      state.UpdatedAt,
+		LastProgressAt: state.LastProgressAt,
 		Error:          state.Error,
 		Steps:          steps,
 		CompletedSteps: state.CompletedSteps,
 		StepOutputs:    state.StepOutputs,
 		MergeReview:    mergeReview,
+		Escalation:     state.Escalation,
+		Confirmation:   state.PendingConfirmation,
+		Comments:       state.Comments,
+		Tags:           state.Tags,
+		Result:         resultSummary,
+		Progress:       progress,
 		Actions:        actions,
 	})
 }
 
-// buildStepInfo loads the grimoire and builds step info with status.
-func (h *WorkflowHandlers) buildStepInfo(state *workflow.WorkflowState) []StepInfo {
-	if state.GrimoireName == "" {
-		return []StepInfo{}
+// buildResultSummary computes a run summary from the persisted workflow state.
+func buildResultSummary(state *workflow.WorkflowState, steps []StepInfo) *WorkflowResultSummary {
+	summary := &WorkflowResultSummary{
+		Status:         state.Status,
+		StartedAt:      state.StartedAt,
+		EndedAt:        state.UpdatedAt,
+		ExecutedSteps:  len(state.CompletedSteps),
+		FailureSummary: state.Error,
+		NoChanges:      state.NoChanges,
+	}
+	if state.KeepWorktree {
+		summary.KeptWorktree = state.WorktreePath
+	}
+	if !state.UpdatedAt.IsZero() && !state.StartedAt.IsZero() {
+		summary.DurationMs = state.UpdatedAt.Sub(state.StartedAt).Milliseconds()
+	}
+
+	// Count top-level steps from the grimoire, falling back to executed steps
+	for _, step := range steps {
+		if step.Depth == 0 {
+			summary.TotalSteps++
+		}
+	}
+	if summary.TotalSteps == 0 {
+		summary.TotalSteps = summary.ExecutedSteps
+	}
+
+	if state.Status == workflow.WorkflowFailed {
+		summary.FailedStep = failedStep(state, steps)
+		if result := state.CompletedSteps[summary.FailedStep]; result != nil && summary.FailureSummary == "" {
+			summary.FailureSummary = result.Error
+		}
+	}
+
+	for _, result := range state.CompletedSteps {
+		switch {
+		case result.Skipped:
+			summary.SkippedSteps++
+		case result.Success:
+			summary.SucceededSteps++
+		default:
+			summary.FailedSteps++
+		}
 	}
 
-	g, err := h.grimoireLoader.Load(state.GrimoireName)
+	return summary
+}
+
+// failedStep returns the top-level step a failed workflow stopped on, or ""
+// if it didn't stop on one. A step whose result failed it is recorded at
+// CurrentStep, as is one whose when condition couldn't be evaluated, which
+// has no result. A step whose executor returned an error has no result
+// either, and CurrentStep is still the step before it.
+func failedStep(state *workflow.WorkflowState, steps []StepInfo) string {
+	var names []string
+	for _, step := range steps {
+		if step.Depth == 0 {
+			names = append(names, step.Name)
+		}
+	}
+
+	i := state.CurrentStep
+	if i >= 0 && i < len(names) {
+		result, ok := state.CompletedSteps[names[i]]
+		if !ok || (!result.Success && !result.AllowedFailure) {
+			return names[i]
+		}
+	}
+	if i+1 >= 0 && i+1 < len(names) {
+		return names[i+1]
+	}
+	return ""
+}
+
+// buildStepInfo loads the grimoire and builds step info with status, along
+// with the workflow's progress through its top-level steps. Progress is nil
+// if the grimoire can't be loaded.
+func (h *WorkflowHandlers) buildStepInfo(state *workflow.WorkflowState) ([]StepInfo, *WorkflowProgress) {
+	g, err := h.loadGrimoire(state)
 	if err != nil {
 		// Can't load grimoire, return empty steps
-		return []StepInfo{}
+		return []StepInfo{}, nil
 	}
 
 	var steps []StepInfo
 	stepIndex := 0
-	h.flattenSteps(g.Steps, state, 0, &steps, &stepIndex)
-	return steps
+	h.flattenSteps(g.Steps, state, state.CompletedSteps, 0, &steps, &stepIndex)
+	return steps, buildProgress(g.Steps, state)
+}
+
+// loadGrimoire loads the grimoire a workflow is running, preferring the
+// pinned snapshot so steps match what is actually running.
+func (h *WorkflowHandlers) loadGrimoire(state *workflow.WorkflowState) (*grimoire.Grimoire, error) {
+	if state.GrimoireName == "" {
+		return nil, fmt.Errorf("workflow %s has no grimoire", state.WorkflowID)
+	}
+
+	var g *grimoire.Grimoire
+	var err error
+	if state.GrimoireHash != "" {
+		g, err = grimoire.NewSnapshotStore(h.covenDir).Load(state.GrimoireHash)
+	}
+	if g == nil {
+		g, err = h.grimoireLoader.Load(state.GrimoireName)
+	}
+	return g, err
+}
+
+// buildProgress counts the top-level steps that are done. A step that ran is
+// done unless the workflow stopped on it: a failed step it blocked or failed
+// on will run again on retry, and a merge step waiting for approval hasn't
+// merged yet. Failures allowed by allow_failure are always done. Steps up to
+// the current one are done even without a result, as a run resumed before
+// results were carried over only has the results of the steps since.
+func buildProgress(steps []grimoire.Step, state *workflow.WorkflowState) *WorkflowProgress {
+	progress := &WorkflowProgress{Total: len(steps)}
+	for i, step := range steps {
+		stopped := i == state.CurrentStep && state.Status != workflow.WorkflowRunning && state.Status != workflow.WorkflowCompleted
+		result, ok := state.CompletedSteps[step.Name]
+		if !ok {
+			if i < state.CurrentStep || (i == state.CurrentStep && !stopped) {
+				progress.Completed++
+			}
+			continue
+		}
+		switch {
+		case result.Skipped:
+			progress.Skipped++
+			progress.Completed++
+		case result.Success:
+			if !stopped || state.Status != workflow.WorkflowPendingMerge {
+				progress.Completed++
+			}
+		case result.AllowedFailure, !stopped:
+			progress.Completed++
+		}
+	}
+	if progress.Total > 0 {
+		progress.Percent = progress.Completed * 100 / progress.Total
+	}
+	return progress
 }
 
-// flattenSteps recursively flattens grimoire steps with status info.
+// flattenSteps recursively flattens grimoire steps with status info, taking
+// the results of completed steps from results.
 // stepIndex is a pointer to track global step numbering for step_task_id generation.
-func (h *WorkflowHandlers) flattenSteps(grimoireSteps []grimoire.Step, state *workflow.WorkflowState, depth int, out *[]StepInfo, stepIndex *int) {
+func (h *WorkflowHandlers) flattenSteps(grimoireSteps []grimoire.Step, state *workflow.WorkflowState, results map[string]*workflow.StepResult, depth int, out *[]StepInfo, stepIndex *int) {
 	for i, step := range grimoireSteps {
 		stepID := step.Name
 		status := "pending"
@@ -306,12 +559,17 @@ func (h *WorkflowHandlers) flattenSteps(grimoireSteps []grimoire.Step, state *wo
 		*stepIndex++
 
 		// Check if this step is completed
-		if result, ok := state.CompletedSteps[stepID]; ok {
-			if result.Success {
+		var command string
+		if result, ok := results[stepID]; ok {
+			switch {
+			case result.Skipped:
+				status = "skipped"
+			case result.Success:
 				status = "completed"
-			} else {
+			default:
 				status = "failed"
 			}
+			command = result.Command
 		} else if depth == 0 {
 			// Determine running step for top-level steps
 			// CurrentStep is -1 initially, then incremented as steps complete
@@ -324,31 +582,85 @@ func (h *WorkflowHandlers) flattenSteps(grimoireSteps []grimoire.Step, state *wo
 		}
 
 		info := StepInfo{
-			ID:         stepID,
-			Name:       step.Name,
-			Type:       string(step.Type),
-			Status:     status,
-			Depth:      depth,
-			IsLoop:     step.Type == grimoire.StepTypeLoop,
-			StepTaskID: stepTaskID,
+			ID:          stepID,
+			Name:        step.Name,
+			Type:        string(step.Type),
+			Description: step.Description,
+			Status:      status,
+			Depth:       depth,
+			IsLoop:      step.Type == grimoire.StepTypeLoop,
+			StepTaskID:  stepTaskID,
+			Command:     command,
 		}
 
 		if step.Type == grimoire.StepTypeLoop {
 			info.MaxIter = step.MaxIterations
+			if result, ok := results[stepID]; ok {
+				info.Iterations = buildIterationInfo(result.Iterations)
+			}
 		}
 
 		*out = append(*out, info)
 
-		// Recurse into loop steps
-		if step.Type == grimoire.StepTypeLoop && len(step.Steps) > 0 {
-			h.flattenSteps(step.Steps, state, depth+1, out, stepIndex)
+		// Recurse into loop and parallel steps, no deeper than grimoires may
+		// nest. A parallel step's nested steps have results of their own
+		if len(step.Steps) > 0 && depth+1 < h.grimoireLoader.Options().NestingDepth() {
+			switch step.Type {
+			case grimoire.StepTypeLoop:
+				h.flattenSteps(step.Steps, state, results, depth+1, out, stepIndex)
+			case grimoire.StepTypeParallel:
+				var children map[string]*workflow.StepResult
+				if result, ok := results[stepID]; ok {
+					children = result.Children
+				}
+				h.flattenSteps(step.Steps, state, children, depth+1, out, stepIndex)
+			}
 		}
 	}
 }
 
+// buildIterationInfo converts recorded loop iterations, including those of
+// nested loops, for the API response.
+func buildIterationInfo(iterations []workflow.LoopIteration) []IterationInfo {
+	if len(iterations) == 0 {
+		return nil
+	}
+
+	out := make([]IterationInfo, 0, len(iterations))
+	for _, it := range iterations {
+		info := IterationInfo{
+			Iteration:  it.Iteration,
+			Status:     "completed",
+			Error:      it.Error,
+			DurationMs: it.Duration.Milliseconds(),
+			Steps:      make([]IterationStepInfo, 0, len(it.Steps)),
+		}
+		if !it.Success {
+			info.Status = "failed"
+		}
+		for _, step := range it.Steps {
+			stepInfo := IterationStepInfo{
+				Name:       step.Name,
+				Status:     "completed",
+				Error:      step.Error,
+				Iterations: buildIterationInfo(step.Iterations),
+			}
+			switch {
+			case step.Skipped:
+				stepInfo.Status = "skipped"
+			case !step.Success:
+				stepInfo.Status = "failed"
+			}
+			info.Steps = append(info.Steps, stepInfo)
+		}
+		out = append(out, info)
+	}
+	return out
+}
+
 // handleGetWorkflowLog handles GET /workflows/:id/log.
 // @Summary      Get workflow log
-// @Description  Returns the JSONL log file for a workflow
+// @Description  Returns the JSONL log for a workflow in the verbose format, whichever format it was written in
 // @Tags         workflows
 // @Accept       json
 // @Produce      application/x-ndjson
@@ -384,8 +696,8 @@ func (h *WorkflowHandlers) handleGetWorkflowLog(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Stream the log file
-	data, err := os.ReadFile(logPath)
+	// Decode the log so compact logs are returned as verbose entries
+	entries, err := workflow.ReadLogFile(logPath)
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "failed to read log: "+err.Error())
 		return
@@ -393,7 +705,260 @@ func (h *WorkflowHandlers) handleGetWorkflowLog(w http.ResponseWriter, r *http.R
 
 	w.Header().Set("Content-Type", "application/x-ndjson")
 	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		enc.Encode(entry)
+	}
+}
+
+// maxWorkflowDiffBytes is the largest diff returned by GET /workflows/:id/diff.
+// Larger diffs are truncated at a line boundary.
+const maxWorkflowDiffBytes = 1 << 20
+
+// WorkflowDiffResponse is the response for GET /workflows/:id/diff.
+type WorkflowDiffResponse struct {
+	WorkflowID     string   `json:"workflow_id"`
+	TaskID         string   `json:"task_id"`
+	WorktreePath   string   `json:"worktree_path"`
+	Diff           string   `json:"diff"`
+	FilesChanged   []string `json:"files_changed"`
+	SizeBytes      int      `json:"size_bytes"`
+	Truncated      bool     `json:"truncated,omitempty"`
+	TruncationNote string   `json:"truncation_note,omitempty"`
+}
+
+// handleGetWorkflowDiff handles GET /workflows/:id/diff.
+// @Summary      Get a workflow's worktree diff
+// @Description  Returns the uncommitted changes in the workflow's worktree, whatever its status. Diffs over 1 MiB are truncated.
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Workflow ID or Task ID"
+// @Success      200  {object}  WorkflowDiffResponse  "Worktree diff"
+// @Failure      404  {object}  map[string]string     "Workflow or worktree not found"
+// @Failure      405  {object}  map[string]string     "Method not allowed"
+// @Failure      409  {object}  map[string]string     "Unsupported state version"
+// @Failure      500  {object}  map[string]string     "Failed to compute diff"
+// @Router       /workflows/{id}/diff [get]
+func (h *WorkflowHandlers) handleGetWorkflowDiff(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	if state.WorktreePath == "" {
+		api.WriteError(w, http.StatusNotFound, "workflow has no worktree")
+		return
+	}
+	if _, err := os.Stat(state.WorktreePath); os.IsNotExist(err) {
+		api.WriteError(w, http.StatusNotFound, "worktree no longer exists: "+state.WorktreePath)
+		return
+	}
+
+	diff, err := h.mergeRunner.GetDiff(r.Context(), state.WorktreePath)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to get diff: "+err.Error())
+		return
+	}
+	files, err := h.mergeRunner.GetStatus(r.Context(), state.WorktreePath)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to get status: "+err.Error())
+		return
+	}
+	if files == nil {
+		files = []string{}
+	}
+
+	resp := WorkflowDiffResponse{
+		WorkflowID:   state.WorkflowID,
+		TaskID:       state.TaskID,
+		WorktreePath: state.WorktreePath,
+		Diff:         diff,
+		FilesChanged: files,
+		SizeBytes:    len(diff),
+	}
+	if len(diff) > maxWorkflowDiffBytes {
+		resp.Diff = truncateDiff(diff, maxWorkflowDiffBytes)
+		resp.Truncated = true
+		resp.TruncationNote = fmt.Sprintf("diff truncated to %d of %d bytes", len(resp.Diff), len(diff))
+	}
+
+	api.WriteJSON(w, http.StatusOK, resp)
+}
+
+// handleGetMergeDiff handles GET /workflows/:id/merge-diff.
+// @Summary      Stream a pending merge's diff
+// @Description  Streams the full diff a workflow pending merge approval would merge, however large. The merge review embeds only small diffs.
+// @Tags         workflows
+// @Produce      plain
+// @Param        id   path      string  true  "Workflow ID or Task ID"
+// @Success      200  {string}  string             "Unified diff"
+// @Failure      400  {object}  map[string]string  "Workflow is not pending merge approval"
+// @Failure      404  {object}  map[string]string  "Workflow or worktree not found"
+// @Failure      405  {object}  map[string]string  "Method not allowed"
+// @Failure      409  {object}  map[string]string  "Unsupported state version"
+// @Failure      500  {object}  map[string]string  "Failed to compute diff"
+// @Router       /workflows/{id}/merge-diff [get]
+func (h *WorkflowHandlers) handleGetMergeDiff(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	if state.Status != workflow.WorkflowPendingMerge {
+		api.WriteError(w, http.StatusBadRequest, "workflow is not pending merge approval")
+		return
+	}
+	if _, err := os.Stat(state.WorktreePath); state.WorktreePath == "" || os.IsNotExist(err) {
+		api.WriteError(w, http.StatusNotFound, "worktree no longer exists: "+state.WorktreePath)
+		return
+	}
+
+	streamer, ok := h.mergeRunner.(workflow.DiffStreamer)
+	if !ok {
+		diff, err := h.mergeRunner.GetDiff(r.Context(), state.WorktreePath)
+		if err != nil {
+			api.WriteError(w, http.StatusInternalServerError, "failed to get diff: "+err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		io.WriteString(w, diff)
+		return
+	}
+
+	// Headers go out with the first chunk, so a diff that fails before
+	// producing any output can still be reported as an error
+	out := &flushWriter{w: w}
+	if err := streamer.StreamDiff(r.Context(), state.WorktreePath, out); err != nil {
+		if !out.started {
+			api.WriteError(w, http.StatusInternalServerError, "failed to get diff: "+err.Error())
+			return
+		}
+		h.scheduler.logger.Warn("merge diff stream ended early", "task_id", state.TaskID, "error", err)
+		return
+	}
+	if !out.started {
+		w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+// flushWriter writes a streamed diff to the response, flushing each chunk so
+// the client receives it as it is produced.
+type flushWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	if !f.started {
+		f.w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+		f.started = true
+	}
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// truncateDiff cuts diff to at most max bytes, ending at a line boundary
+// where there is one.
+func truncateDiff(diff string, max int) string {
+	if len(diff) <= max {
+		return diff
+	}
+	cut := diff[:max]
+	if i := strings.LastIndexByte(cut, '\n'); i >= 0 {
+		cut = cut[:i+1]
+	}
+	return cut
+}
+
+// WorkflowDivergenceResponse is the response for GET /workflows/:id/divergence.
+type WorkflowDivergenceResponse struct {
+	WorkflowID string `json:"workflow_id"`
+	TaskID     string `json:"task_id"`
+	BranchDivergence
+}
+
+// handleGetWorkflowDivergence handles GET /workflows/:id/divergence.
+// @Summary      Compare a workflow's branch with the base branch
+// @Description  Counts the commits the workflow's worktree branch has that the base branch doesn't, and the reverse, so a long-lived branch can be rebased before its merge is approved
+// @Tags         workflows
+// @Produce      json
+// @Param        id   path      string                      true  "Workflow ID or Task ID"
+// @Success      200  {object}  WorkflowDivergenceResponse  "Commits ahead of and behind the base branch"
+// @Failure      404  {object}  map[string]string           "Workflow or worktree not found"
+// @Failure      405  {object}  map[string]string           "Method not allowed"
+// @Failure      409  {object}  map[string]string           "Unsupported state version"
+// @Failure      500  {object}  map[string]string           "Failed to compare branches"
+// @Router       /workflows/{id}/divergence [get]
+func (h *WorkflowHandlers) handleGetWorkflowDivergence(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	if state.WorktreePath == "" {
+		api.WriteError(w, http.StatusNotFound, "workflow has no worktree")
+		return
+	}
+	if _, err := os.Stat(state.WorktreePath); os.IsNotExist(err) {
+		api.WriteError(w, http.StatusNotFound, "worktree no longer exists: "+state.WorktreePath)
+		return
+	}
+
+	divergence, err := h.scheduler.BranchDivergence(r.Context(), state.TaskID)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to compare branches: "+err.Error())
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, WorkflowDivergenceResponse{
+		WorkflowID:       state.WorkflowID,
+		TaskID:           state.TaskID,
+		BranchDivergence: *divergence,
+	})
 }
 
 // handleCancelWorkflow handles POST /workflows/:id/cancel.
@@ -407,6 +972,7 @@ func (h *WorkflowHandlers) handleGetWorkflowLog(w http.ResponseWriter, r *http.R
 // @Failure      400  {object}  map[string]string        "Workflow already in terminal state"
 // @Failure      404  {object}  map[string]string        "Workflow not found"
 // @Failure      405  {object}  map[string]string        "Method not allowed"
+// @Failure      409  {object}  map[string]string        "Unsupported state version"
 // @Router       /workflows/{id}/cancel [post]
 func (h *WorkflowHandlers) handleCancelWorkflow(w http.ResponseWriter, r *http.Request, id string) {
 	if r.Method != http.MethodPost {
@@ -415,7 +981,11 @@ func (h *WorkflowHandlers) handleCancelWorkflow(w http.ResponseWriter, r *http.R
 	}
 
 	// Find the workflow
-	state, _ := h.statePersister.Load(id)
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if state == nil {
 		state = h.findWorkflowByID(id)
 	}
@@ -447,7 +1017,7 @@ func (h *WorkflowHandlers) handleCancelWorkflow(w http.ResponseWriter, r *http.R
 	}
 
 	// Update task status back to open
-	h.store.UpdateTaskStatus(state.TaskID, "open")
+	h.store.UpdateTaskStatus(state.TaskID, types.TaskStatusOpen, types.StatusReasonCancelled)
 
 	// Emit events to notify clients
 	if h.eventEmitter != nil {
@@ -464,6 +1034,60 @@ func (h *WorkflowHandlers) handleCancelWorkflow(w http.ResponseWriter, r *http.R
 	})
 }
 
+// handleCleanupWorkflow handles POST /workflows/:id/cleanup.
+// @Summary      Clean up a finished workflow
+// @Description  Removes the worktree and branch kept for a finished workflow and deletes its state
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Workflow ID or Task ID"
+// @Success      200  {object}  map[string]interface{}  "Cleanup response"
+// @Failure      400  {object}  map[string]string        "Workflow is not finished"
+// @Failure      404  {object}  map[string]string        "Workflow not found"
+// @Failure      405  {object}  map[string]string        "Method not allowed"
+// @Failure      409  {object}  map[string]string        "Unsupported state version"
+// @Router       /workflows/{id}/cleanup [post]
+func (h *WorkflowHandlers) handleCleanupWorkflow(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Find the workflow
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	// Only finished workflows can be cleaned up
+	switch state.Status {
+	case workflow.WorkflowCompleted, workflow.WorkflowFailed, workflow.WorkflowCancelled:
+	default:
+		api.WriteError(w, http.StatusBadRequest, fmt.Sprintf("workflow is not finished (status: %s)", state.Status))
+		return
+	}
+
+	if err := h.scheduler.CleanupWorktree(state.TaskID); err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to clean up workflow: "+err.Error())
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "cleaned_up",
+		"workflow_id":   state.WorkflowID,
+		"task_id":       state.TaskID,
+		"worktree_path": state.WorktreePath,
+	})
+}
+
 // handleRetryWorkflow handles POST /workflows/:id/retry.
 // @Summary      Retry a blocked workflow
 // @Description  Retries a blocked or failed workflow, optionally with modified inputs
@@ -476,6 +1100,7 @@ func (h *WorkflowHandlers) handleCancelWorkflow(w http.ResponseWriter, r *http.R
 // @Failure      400  {object}  map[string]string        "Workflow is not in blocked or failed state"
 // @Failure      404  {object}  map[string]string        "Workflow not found"
 // @Failure      405  {object}  map[string]string        "Method not allowed"
+// @Failure      409  {object}  map[string]string        "Unsupported state version, or concurrency group busy"
 // @Router       /workflows/{id}/retry [post]
 func (h *WorkflowHandlers) handleRetryWorkflow(w http.ResponseWriter, r *http.Request, id string) {
 	if r.Method != http.MethodPost {
@@ -484,7 +1109,11 @@ func (h *WorkflowHandlers) handleRetryWorkflow(w http.ResponseWriter, r *http.Re
 	}
 
 	// Find the workflow
-	state, _ := h.statePersister.Load(id)
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if state == nil {
 		state = h.findWorkflowByID(id)
 	}
@@ -501,6 +1130,10 @@ func (h *WorkflowHandlers) handleRetryWorkflow(w http.ResponseWriter, r *http.Re
 
 	// Queue the workflow for resumption
 	if err := h.scheduler.QueueWorkflowResume(state); err != nil {
+		if IsConcurrencyGroupBusy(err) {
+			api.WriteError(w, http.StatusConflict, err.Error())
+			return
+		}
 		api.WriteError(w, http.StatusInternalServerError, "failed to queue workflow resume: "+err.Error())
 		return
 	}
@@ -513,6 +1146,250 @@ func (h *WorkflowHandlers) handleRetryWorkflow(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// AddCommentRequest is the request body for POST /workflows/:id/comment.
+type AddCommentRequest struct {
+	Author string `json:"author"`
+	Text   string `json:"text"`
+}
+
+// handleAddComment handles POST /workflows/:id/comment.
+// @Summary      Comment on a workflow
+// @Description  Attaches a note to a workflow. Comments are kept with the workflow state and returned in its details.
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true  "Workflow ID or Task ID"
+// @Param        request  body      AddCommentRequest  true  "Comment"
+// @Success      200      {object}  map[string]interface{}  "Comment response"
+// @Failure      400      {object}  map[string]string        "Invalid request body or empty text"
+// @Failure      404      {object}  map[string]string        "Workflow not found"
+// @Failure      405      {object}  map[string]string        "Method not allowed"
+// @Failure      409      {object}  map[string]string        "Unsupported state version"
+// @Router       /workflows/{id}/comment [post]
+func (h *WorkflowHandlers) handleAddComment(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req AddCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	if req.Text == "" {
+		api.WriteError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+
+	// Find the workflow
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	comment := workflow.Comment{
+		Author:    strings.TrimSpace(req.Author),
+		Text:      req.Text,
+		CreatedAt: time.Now(),
+	}
+	updated, err := h.statePersister.AddComment(state.TaskID, comment)
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to add comment: "+err.Error())
+		return
+	}
+	if updated == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "commented",
+		"workflow_id":   updated.WorkflowID,
+		"task_id":       updated.TaskID,
+		"comment":       comment,
+		"comment_count": len(updated.Comments),
+	})
+}
+
+// handleConfirmStep handles POST /workflows/:id/confirm.
+// @Summary      Confirm a gated step
+// @Description  Confirms the confirm-gated step a workflow is waiting on and resumes the workflow, running that step next
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Workflow ID or Task ID"
+// @Success      200  {object}  map[string]interface{}  "Confirm response"
+// @Failure      400  {object}  map[string]string        "Workflow is not awaiting confirmation"
+// @Failure      404  {object}  map[string]string        "Workflow not found"
+// @Failure      405  {object}  map[string]string        "Method not allowed"
+// @Failure      409  {object}  map[string]string        "Unsupported state version, or concurrency group busy"
+// @Router       /workflows/{id}/confirm [post]
+func (h *WorkflowHandlers) handleConfirmStep(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Find the workflow
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	// Check if workflow is waiting for confirmation
+	if state.Status != workflow.WorkflowAwaitingConfirmation || state.PendingConfirmation == nil {
+		api.WriteError(w, http.StatusBadRequest, "workflow is not awaiting confirmation")
+		return
+	}
+	stepName := state.PendingConfirmation.StepName
+
+	state, err = h.scheduler.ConfirmStep(state.TaskID)
+	if IsConcurrencyGroupBusy(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to confirm step: "+err.Error())
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "confirmed",
+		"workflow_id": state.WorkflowID,
+		"task_id":     state.TaskID,
+		"step":        stepName,
+		"message":     "step confirmed, workflow resuming",
+	})
+}
+
+// handleSkipStep handles POST /workflows/:id/step/:name/skip.
+// @Summary      Skip a blocked step
+// @Description  Marks the step a blocked workflow is stopped on as skipped and resumes the workflow from the next step
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Workflow ID or Task ID"
+// @Param        name path      string  true  "Name of the blocking step"
+// @Success      200  {object}  map[string]interface{}  "Skip response"
+// @Failure      400  {object}  map[string]string        "Workflow is not blocked on the named step"
+// @Failure      404  {object}  map[string]string        "Workflow not found"
+// @Failure      405  {object}  map[string]string        "Method not allowed"
+// @Failure      409  {object}  map[string]string        "Unsupported state version, or concurrency group busy"
+// @Router       /workflows/{id}/step/{name}/skip [post]
+func (h *WorkflowHandlers) handleSkipStep(w http.ResponseWriter, r *http.Request, id, stepName string) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Find the workflow
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	state, err = h.scheduler.SkipBlockedStep(state.TaskID, stepName)
+	if IsStepNotSkippable(err) {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if IsConcurrencyGroupBusy(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to skip step: "+err.Error())
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "skipped",
+		"workflow_id": state.WorkflowID,
+		"task_id":     state.TaskID,
+		"step":        stepName,
+		"message":     "step skipped, workflow resuming",
+	})
+}
+
+// handleBreakLoop handles POST /workflows/:id/step/:name/break-loop.
+// @Summary      Break out of a running loop
+// @Description  Stops a running loop step once its current iteration finishes, as if exit_loop fired, and continues the workflow with the next step
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Workflow ID or Task ID"
+// @Param        name path      string  true  "Name of the loop step"
+// @Success      200  {object}  map[string]interface{}  "Break response"
+// @Failure      400  {object}  map[string]string        "The named loop is not running"
+// @Failure      404  {object}  map[string]string        "Workflow not found"
+// @Failure      405  {object}  map[string]string        "Method not allowed"
+// @Failure      409  {object}  map[string]string        "Unsupported state version"
+// @Router       /workflows/{id}/step/{name}/break-loop [post]
+func (h *WorkflowHandlers) handleBreakLoop(w http.ResponseWriter, r *http.Request, id, stepName string) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Find the workflow
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if state == nil {
+		state = h.findWorkflowByID(id)
+	}
+	if state == nil {
+		api.WriteError(w, http.StatusNotFound, "workflow not found")
+		return
+	}
+
+	err = h.scheduler.BreakLoop(state.TaskID, stepName)
+	if IsLoopNotBreakable(err) {
+		api.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		api.WriteError(w, http.StatusInternalServerError, "failed to break loop: "+err.Error())
+		return
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "breaking",
+		"workflow_id": state.WorkflowID,
+		"task_id":     state.TaskID,
+		"step":        stepName,
+		"message":     "loop will stop after its current iteration",
+	})
+}
+
 // ApproveMergeResponse is the response for approve-merge endpoint.
 type ApproveMergeResponse struct {
 	Status        string   `json:"status"`
@@ -536,6 +1413,7 @@ type ApproveMergeResponse struct {
 // @Failure      400  {object}  map[string]string      "Workflow is not pending merge approval"
 // @Failure      404  {object}  map[string]string      "Workflow not found"
 // @Failure      405  {object}  map[string]string      "Method not allowed"
+// @Failure      409  {object}  map[string]string      "Unsupported state version, or an ff_only merge that can't fast-forward, or concurrency group busy"
 // @Router       /workflows/{id}/approve-merge [post]
 func (h *WorkflowHandlers) handleApproveMerge(w http.ResponseWriter, r *http.Request, id string) {
 	if r.Method != http.MethodPost {
@@ -544,7 +1422,11 @@ func (h *WorkflowHandlers) handleApproveMerge(w http.ResponseWriter, r *http.Req
 	}
 
 	// Find the workflow
-	state, _ := h.statePersister.Load(id)
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if state == nil {
 		state = h.findWorkflowByID(id)
 	}
@@ -561,6 +1443,10 @@ func (h *WorkflowHandlers) handleApproveMerge(w http.ResponseWriter, r *http.Req
 
 	// Signal merge approval
 	result, err := h.scheduler.ApproveMerge(state.TaskID)
+	if workflow.IsNotFastForward(err) || IsConcurrencyGroupBusy(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		api.WriteError(w, http.StatusInternalServerError, "failed to approve merge: "+err.Error())
 		return
@@ -600,6 +1486,7 @@ func (h *WorkflowHandlers) handleApproveMerge(w http.ResponseWriter, r *http.Req
 // @Failure      400  {object}  map[string]string        "Workflow is not pending merge approval"
 // @Failure      404  {object}  map[string]string        "Workflow not found"
 // @Failure      405  {object}  map[string]string        "Method not allowed"
+// @Failure      409  {object}  map[string]string        "Unsupported state version"
 // @Router       /workflows/{id}/reject-merge [post]
 func (h *WorkflowHandlers) handleRejectMerge(w http.ResponseWriter, r *http.Request, id string) {
 	if r.Method != http.MethodPost {
@@ -619,7 +1506,11 @@ func (h *WorkflowHandlers) handleRejectMerge(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Find the workflow
-	state, _ := h.statePersister.Load(id)
+	state, err := h.statePersister.Load(id)
+	if workflow.IsUnsupportedStateVersion(err) {
+		api.WriteError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if state == nil {
 		state = h.findWorkflowByID(id)
 	}
@@ -650,29 +1541,7 @@ func (h *WorkflowHandlers) handleRejectMerge(w http.ResponseWriter, r *http.Requ
 
 // findWorkflowByID searches for a workflow by workflow ID (not task ID).
 func (h *WorkflowHandlers) findWorkflowByID(workflowID string) *workflow.WorkflowState {
-	stateDir := h.statePersister.StateDir()
-	entries, err := os.ReadDir(stateDir)
-	if err != nil {
-		return nil
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
-		}
-
-		taskID := strings.TrimSuffix(entry.Name(), ".json")
-		state, err := h.statePersister.Load(taskID)
-		if err != nil || state == nil {
-			continue
-		}
-
-		if state.WorkflowID == workflowID {
-			return state
-		}
-	}
-
-	return nil
+	return h.statePersister.FindByWorkflowID(workflowID)
 }
 
 // getMergeReview retrieves merge review information for a pending merge workflow.
@@ -688,9 +1557,16 @@ func (h *WorkflowHandlers) getMergeReview(state *workflow.WorkflowState) *workfl
 		return nil
 	}
 
-	// We could regenerate the merge review here, but for now
-	// just return basic info indicating there's a pending merge
+	// Show the summary the merge step recorded, which the agent wrote for
+	// merge steps with summarize set
+	summary := "Merge pending approval"
+	if g, err := h.loadGrimoire(state); err == nil && state.CurrentStep >= 0 && state.CurrentStep < len(g.Steps) {
+		if result := state.CompletedSteps[g.Steps[state.CurrentStep].Name]; result != nil && result.Summary != "" {
+			summary = result.Summary
+		}
+	}
+
 	return &workflow.MergeReview{
-		Summary: "Merge pending approval",
+		Summary: summary,
 	}
 }
@@ -1,17 +1,22 @@
 package scheduler
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/grimoire"
 	"github.com/coven/daemon/internal/workflow"
 	"github.com/coven/daemon/pkg/types"
 )
@@ -67,6 +72,9 @@ func TestNewWorkflowHandlers(t *testing.T) {
 	if handlers.covenDir != covenDir {
 		t.Error("covenDir not set correctly")
 	}
+	if handlers.statePersister != sched.statePersister {
+		t.Error("statePersister should be shared with the scheduler")
+	}
 }
 
 func TestHandleWorkflowsList_Empty(t *testing.T) {
@@ -184,6 +192,7 @@ func TestHandleGetWorkflow_ByTaskID(t *testing.T) {
 		TaskID:       "task-get-1",
 		WorkflowID:   "wf-get-1",
 		GrimoireName: "test-grimoire",
+		GrimoireHash: "abc123",
 		Status:       workflow.WorkflowRunning,
 		CurrentStep:  2,
 		WorktreePath: "/path/to/worktree",
@@ -217,6 +226,443 @@ func TestHandleGetWorkflow_ByTaskID(t *testing.T) {
 	if result.Status != workflow.WorkflowRunning {
 		t.Errorf("Status = %q, want %q", result.Status, workflow.WorkflowRunning)
 	}
+	if result.GrimoireHash != "abc123" {
+		t.Errorf("GrimoireHash = %q, want %q", result.GrimoireHash, "abc123")
+	}
+	if result.Result != nil {
+		t.Error("Result should not be set for a running workflow")
+	}
+}
+
+func TestHandleGetWorkflow_Escalation(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	state := &workflow.WorkflowState{
+		TaskID:       "task-escalated",
+		WorkflowID:   "wf-escalated",
+		GrimoireName: "test-grimoire",
+		Status:       workflow.WorkflowBlocked,
+		StartedAt:    time.Now(),
+		Escalation: &workflow.Escalation{
+			StepName:        "run-tests",
+			StepType:        "script",
+			Output:          "FAIL: TestLogin",
+			ExitCode:        1,
+			SuggestedAction: "Fix the tests, then retry the workflow.",
+		},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-escalated")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	if result.Escalation == nil {
+		t.Fatal("Escalation should be included in response")
+	}
+	if result.Escalation.Output != "FAIL: TestLogin" {
+		t.Errorf("Output = %q, want %q", result.Escalation.Output, "FAIL: TestLogin")
+	}
+	if result.Escalation.SuggestedAction != "Fix the tests, then retry the workflow." {
+		t.Errorf("SuggestedAction = %q", result.Escalation.SuggestedAction)
+	}
+}
+
+func TestHandleGetWorkflow_MergeReviewSummary(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: summarized-merge
+description: Merges with an agent-written summary
+steps:
+  - name: implement
+    type: script
+    command: make
+  - name: merge
+    type: merge
+    summarize: true
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "summarized-merge.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	state := &workflow.WorkflowState{
+		TaskID:       "task-merge-summary",
+		WorkflowID:   "wf-merge-summary",
+		GrimoireName: "summarized-merge",
+		WorktreePath: t.TempDir(),
+		Status:       workflow.WorkflowPendingMerge,
+		CurrentStep:  1,
+		StartedAt:    time.Now(),
+		CompletedSteps: map[string]*workflow.StepResult{
+			"implement": {Success: true},
+			"merge":     {Success: true, Action: workflow.ActionBlock, Summary: "Adds retries to the HTTP client."},
+		},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-merge-summary")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+	if result.MergeReview == nil || result.MergeReview.Summary != "Adds retries to the HTTP client." {
+		t.Errorf("MergeReview = %+v, want the merge step's summary", result.MergeReview)
+	}
+}
+
+func TestHandleGetWorkflow_ResultSummary(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: summary-grimoire
+steps:
+  - name: build
+    type: script
+    command: make build
+  - name: lint
+    type: script
+    command: make lint
+    when: "false"
+  - name: test
+    type: script
+    command: make test
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "summary-grimoire.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	state := &workflow.WorkflowState{
+		TaskID:       "task-summary",
+		WorkflowID:   "wf-summary",
+		GrimoireName: "summary-grimoire",
+		Status:       workflow.WorkflowCompleted,
+		CurrentStep:  2,
+		StartedAt:    time.Now().Add(-time.Minute),
+		CompletedSteps: map[string]*workflow.StepResult{
+			"build": {Success: true},
+			"lint":  {Success: true, Skipped: true},
+			"test":  {Success: true},
+		},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-summary")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	if result.Result == nil {
+		t.Fatal("Result should be populated for completed workflow")
+	}
+	summary := result.Result
+	if summary.Status != workflow.WorkflowCompleted {
+		t.Errorf("Status = %q, want %q", summary.Status, workflow.WorkflowCompleted)
+	}
+	if summary.TotalSteps != 3 {
+		t.Errorf("TotalSteps = %d, want 3", summary.TotalSteps)
+	}
+	if summary.ExecutedSteps != 3 {
+		t.Errorf("ExecutedSteps = %d, want 3", summary.ExecutedSteps)
+	}
+	if summary.SucceededSteps != 2 {
+		t.Errorf("SucceededSteps = %d, want 2", summary.SucceededSteps)
+	}
+	if summary.SkippedSteps != 1 {
+		t.Errorf("SkippedSteps = %d, want 1", summary.SkippedSteps)
+	}
+	if summary.FailedSteps != 0 {
+		t.Errorf("FailedSteps = %d, want 0", summary.FailedSteps)
+	}
+	if summary.DurationMs < time.Minute.Milliseconds() {
+		t.Errorf("DurationMs = %d, want at least %d", summary.DurationMs, time.Minute.Milliseconds())
+	}
+	if summary.EndedAt.Before(summary.StartedAt) {
+		t.Error("EndedAt should not be before StartedAt")
+	}
+}
+
+func TestHandleGetWorkflow_StepDescriptions(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: described-grimoire
+description: Grimoire with step descriptions
+steps:
+  - name: build
+    type: script
+    description: Compile the project to catch type errors early
+    command: make build
+  - name: refine
+    type: loop
+    description: Iterate until tests pass
+    max_iterations: 3
+    steps:
+      - name: test
+        type: script
+        description: Run the unit tests
+        command: make test
+  - name: finish
+    type: script
+    command: echo done
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "described-grimoire.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	state := &workflow.WorkflowState{
+		TaskID:         "task-described",
+		WorkflowID:     "wf-described",
+		GrimoireName:   "described-grimoire",
+		Status:         workflow.WorkflowRunning,
+		CurrentStep:    -1,
+		StartedAt:      time.Now(),
+		CompletedSteps: map[string]*workflow.StepResult{},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-described")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	want := map[string]string{
+		"build":  "Compile the project to catch type errors early",
+		"refine": "Iterate until tests pass",
+		"test":   "Run the unit tests",
+		"finish": "",
+	}
+	if len(result.Steps) != len(want) {
+		t.Fatalf("Steps = %d, want %d", len(result.Steps), len(want))
+	}
+	for _, step := range result.Steps {
+		if step.Description != want[step.Name] {
+			t.Errorf("step %q Description = %q, want %q", step.Name, step.Description, want[step.Name])
+		}
+	}
+}
+
+func TestHandleGetWorkflow_StepCommands(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: command-grimoire
+description: Grimoire with templated commands
+steps:
+  - name: build
+    type: script
+    command: make build TITLE={{.bead.title}}
+  - name: test
+    type: script
+    command: make test
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "command-grimoire.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	state := &workflow.WorkflowState{
+		TaskID:       "task-commands",
+		WorkflowID:   "wf-commands",
+		GrimoireName: "command-grimoire",
+		Status:       workflow.WorkflowBlocked,
+		CurrentStep:  0,
+		StartedAt:    time.Now(),
+		CompletedSteps: map[string]*workflow.StepResult{
+			"build": {Success: false, ExitCode: 2, Command: "make build TITLE='Add widgets'"},
+		},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-commands")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	want := map[string]string{
+		"build": "make build TITLE='Add widgets'",
+		"test":  "", // not run yet
+	}
+	if len(result.Steps) != len(want) {
+		t.Fatalf("Steps = %d, want %d", len(result.Steps), len(want))
+	}
+	for _, step := range result.Steps {
+		if step.Command != want[step.Name] {
+			t.Errorf("step %q Command = %q, want %q", step.Name, step.Command, want[step.Name])
+		}
+	}
+}
+
+func TestHandleGetWorkflow_ResultSummary_Failed(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: failure-grimoire
+description: Grimoire that fails
+steps:
+  - name: build
+    type: script
+    command: make build
+  - name: test
+    type: script
+    command: make test
+  - name: lint
+    type: script
+    command: make lint
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "failure-grimoire.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		currentStep    int
+		completedSteps map[string]*workflow.StepResult
+		wantFailedStep string
+		wantFailed     int
+	}{
+		{
+			name:        "step result failed",
+			currentStep: 1,
+			completedSteps: map[string]*workflow.StepResult{
+				"build": {Success: true},
+				"test":  {Success: false, Error: "exit status 1"},
+			},
+			wantFailedStep: "test",
+			wantFailed:     1,
+		},
+		{
+			// An executor error records no result and leaves CurrentStep on
+			// the last step that finished
+			name:        "step executor errored",
+			currentStep: 0,
+			completedSteps: map[string]*workflow.StepResult{
+				"build": {Success: true},
+			},
+			wantFailedStep: "test",
+		},
+		{
+			name:           "first step executor errored",
+			currentStep:    -1,
+			completedSteps: map[string]*workflow.StepResult{},
+			wantFailedStep: "build",
+		},
+		{
+			// Only the step the workflow stopped on counts, not earlier
+			// failures it was allowed to continue past
+			name:        "after allowed failure",
+			currentStep: 2,
+			completedSteps: map[string]*workflow.StepResult{
+				"build": {Success: true},
+				"test":  {Success: false, AllowedFailure: true},
+				"lint":  {Success: false, Error: "lint errors"},
+			},
+			wantFailedStep: "lint",
+			wantFailed:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &workflow.WorkflowState{
+				TaskID:         "task-summary-failed",
+				WorkflowID:     "wf-summary-failed",
+				GrimoireName:   "failure-grimoire",
+				Status:         workflow.WorkflowFailed,
+				CurrentStep:    tt.currentStep,
+				StartedAt:      time.Now(),
+				Error:          `step failed: exit status 1`,
+				CompletedSteps: tt.completedSteps,
+			}
+			if err := statePersister.Save(state); err != nil {
+				t.Fatalf("Failed to save state: %v", err)
+			}
+
+			resp, err := client.Get("http://unix/workflows/task-summary-failed")
+			if err != nil {
+				t.Fatalf("GET error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var result WorkflowDetailResponse
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("Decode error: %v", err)
+			}
+
+			if result.Result == nil {
+				t.Fatal("Result should be populated for failed workflow")
+			}
+			if result.Result.FailedSteps != tt.wantFailed {
+				t.Errorf("FailedSteps = %d, want %d", result.Result.FailedSteps, tt.wantFailed)
+			}
+			if result.Result.FailedStep != tt.wantFailedStep {
+				t.Errorf("FailedStep = %q, want %q", result.Result.FailedStep, tt.wantFailedStep)
+			}
+			if result.Result.FailureSummary != state.Error {
+				t.Errorf("FailureSummary = %q, want %q", result.Result.FailureSummary, state.Error)
+			}
+		})
+	}
 }
 
 func TestHandleGetWorkflow_AvailableActions(t *testing.T) {
@@ -229,7 +675,7 @@ func TestHandleGetWorkflow_AvailableActions(t *testing.T) {
 		expected []string
 	}{
 		{"running", workflow.WorkflowRunning, []string{"cancel"}},
-		{"blocked", workflow.WorkflowBlocked, []string{"retry", "cancel"}},
+		{"blocked", workflow.WorkflowBlocked, []string{"retry", "skip", "cancel"}},
 		{"pending_merge", workflow.WorkflowPendingMerge, []string{"approve-merge", "reject-merge", "cancel"}},
 		{"completed", workflow.WorkflowCompleted, []string{}},
 		{"failed", workflow.WorkflowFailed, []string{}},
@@ -427,65 +873,781 @@ func TestHandleRetryWorkflow_NotBlocked(t *testing.T) {
 	}
 }
 
-func TestHandleGetWorkflowLog_NotFound(t *testing.T) {
-	_, _, _, client, _, cleanup := setupTestWorkflowHandlers(t)
-	defer cleanup()
-
-	resp, err := client.Get("http://unix/workflows/nonexistent/log")
-	if err != nil {
-		t.Fatalf("GET error: %v", err)
+// writeSkipTestGrimoire writes a grimoire whose second step blocks.
+func writeSkipTestGrimoire(t *testing.T, covenDir string) {
+	t.Helper()
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	content := `name: skip-test
+description: Skip test grimoire
+steps:
+  - name: first
+    type: script
+    command: "touch first"
+  - name: check
+    type: script
+    command: "exit 1"
+    on_fail: block
+  - name: last
+    type: script
+    command: "touch last"
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "skip-test.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
 	}
-	defer resp.Body.Close()
+}
 
-	if resp.StatusCode != http.StatusNotFound {
-		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+// waitForWorkflowStatus polls the saved state of a task until it has the given status.
+func waitForWorkflowStatus(t *testing.T, statePersister *workflow.StatePersister, taskID string, status workflow.WorkflowStatus) *workflow.WorkflowState {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if state, _ := statePersister.Load(taskID); state != nil && state.Status == status {
+			return state
+		}
+		time.Sleep(20 * time.Millisecond)
 	}
+	t.Fatalf("workflow for %s did not reach status %q", taskID, status)
+	return nil
 }
 
-func TestHandleGetWorkflowLog_EmptyLog(t *testing.T) {
-	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+func TestHandleGetWorkflow_Progress(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
 	defer cleanup()
 
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: progress-grimoire
+description: Grimoire for progress
+steps:
+  - name: setup
+    type: script
+    command: make setup
+  - name: lint
+    type: script
+    command: make lint
+    when: "{{.previous.failed}}"
+  - name: refine
+    type: loop
+    max_iterations: 3
+    steps:
+      - name: fix
+        type: script
+        command: make fix
+  - name: test
+    type: script
+    command: make test
+  - name: deploy
+    type: script
+    command: make deploy
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "progress-grimoire.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
 	state := &workflow.WorkflowState{
-		TaskID:     "task-log",
-		WorkflowID: "wf-log",
-		Status:     workflow.WorkflowRunning,
-		StartedAt:  time.Now(),
+		TaskID:       "task-progress",
+		WorkflowID:   "wf-progress",
+		GrimoireName: "progress-grimoire",
+		Status:       workflow.WorkflowRunning,
+		CurrentStep:  2,
+		StartedAt:    time.Now(),
+		CompletedSteps: map[string]*workflow.StepResult{
+			"setup": {Success: true, Action: workflow.ActionContinue},
+			"lint":  {Success: true, Skipped: true, Action: workflow.ActionContinue},
+			"refine": {
+				Success: true,
+				Action:  workflow.ActionContinue,
+				Iterations: []workflow.LoopIteration{
+					{Iteration: 0, Success: true, Steps: []workflow.LoopIterationStep{{Name: "fix", Success: true}}},
+					{Iteration: 1, Success: true, Steps: []workflow.LoopIterationStep{{Name: "fix", Success: true}}},
+				},
+			},
+			"fix": {Success: true, Action: workflow.ActionContinue},
+		},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
 	}
-	statePersister.Save(state)
 
-	resp, err := client.Get("http://unix/workflows/task-log/log")
+	resp, err := client.Get("http://unix/workflows/task-progress")
 	if err != nil {
 		t.Fatalf("GET error: %v", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
 	}
 
-	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
-		t.Errorf("Content-Type = %q, want %q", ct, "application/x-ndjson")
+	want := WorkflowProgress{Percent: 60, Completed: 3, Skipped: 1, Total: 5}
+	if result.Progress == nil || *result.Progress != want {
+		t.Errorf("Progress = %+v, want %+v", result.Progress, want)
+	}
+	if result.Steps[1].Name != "lint" || result.Steps[1].Status != "skipped" {
+		t.Errorf("Steps[1] = %s %q, want lint skipped", result.Steps[1].Name, result.Steps[1].Status)
 	}
 }
 
-func TestHandleGetWorkflowLog_WithLog(t *testing.T) {
+func TestHandleGetWorkflow_ParallelSteps(t *testing.T) {
 	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
 	defer cleanup()
 
-	workflowID := "wf-log-content"
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: parallel-grimoire
+description: Grimoire with parallel checks
+steps:
+  - name: checks
+    type: parallel
+    steps:
+      - name: lint
+        type: script
+        command: make lint
+      - name: test
+        type: script
+        command: make test
+  - name: deploy
+    type: script
+    command: make deploy
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "parallel-grimoire.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
 	state := &workflow.WorkflowState{
-		TaskID:     "task-log-content",
-		WorkflowID: workflowID,
-		Status:     workflow.WorkflowRunning,
-		StartedAt:  time.Now(),
+		TaskID:       "task-parallel",
+		WorkflowID:   "wf-parallel",
+		GrimoireName: "parallel-grimoire",
+		Status:       workflow.WorkflowFailed,
+		CurrentStep:  0,
+		StartedAt:    time.Now(),
+		CompletedSteps: map[string]*workflow.StepResult{
+			"checks": {
+				Success: false,
+				Action:  workflow.ActionFail,
+				Children: map[string]*workflow.StepResult{
+					"lint": {Success: true, Action: workflow.ActionContinue},
+					"test": {Success: false, Error: "exit status 1", Action: workflow.ActionFail},
+				},
+			},
+		},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
 	}
-	statePersister.Save(state)
 
-	// Create a log file
-	logDir := filepath.Join(covenDir, "logs", "workflows")
-	os.MkdirAll(logDir, 0755)
-	logContent := `{"timestamp":"2024-01-01T00:00:00Z","event":"workflow.start"}
+	resp, err := client.Get("http://unix/workflows/task-parallel")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	want := []struct {
+		name, status string
+		depth        int
+	}{
+		{"checks", "failed", 0},
+		{"lint", "completed", 1},
+		{"test", "failed", 1},
+		{"deploy", "pending", 0},
+	}
+	if len(result.Steps) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(result.Steps), len(want))
+	}
+	for i, w := range want {
+		if s := result.Steps[i]; s.Name != w.name || s.Status != w.status || s.Depth != w.depth {
+			t.Errorf("Steps[%d] = %s %q depth %d, want %s %q depth %d", i, s.Name, s.Status, s.Depth, w.name, w.status, w.depth)
+		}
+	}
+}
+
+func TestBuildProgress(t *testing.T) {
+	steps := []grimoire.Step{{Name: "build"}, {Name: "test"}, {Name: "merge"}, {Name: "notify"}}
+	succeeded := &workflow.StepResult{Success: true}
+	failed := &workflow.StepResult{Success: false}
+
+	tests := []struct {
+		name        string
+		status      workflow.WorkflowStatus
+		currentStep int
+		completed   map[string]*workflow.StepResult
+		want        WorkflowProgress
+	}{
+		{
+			name:        "not started",
+			status:      workflow.WorkflowRunning,
+			currentStep: -1,
+			want:        WorkflowProgress{Total: 4},
+		},
+		{
+			name:        "blocked on a failed step",
+			status:      workflow.WorkflowBlocked,
+			currentStep: 1,
+			completed:   map[string]*workflow.StepResult{"build": succeeded, "test": failed},
+			want:        WorkflowProgress{Percent: 25, Completed: 1, Total: 4},
+		},
+		{
+			name:        "continued past a failed step",
+			status:      workflow.WorkflowRunning,
+			currentStep: 1,
+			completed:   map[string]*workflow.StepResult{"build": succeeded, "test": failed},
+			want:        WorkflowProgress{Percent: 50, Completed: 2, Total: 4},
+		},
+		{
+			name:        "pending merge",
+			status:      workflow.WorkflowPendingMerge,
+			currentStep: 2,
+			completed:   map[string]*workflow.StepResult{"build": succeeded, "test": succeeded, "merge": succeeded},
+			want:        WorkflowProgress{Percent: 50, Completed: 2, Total: 4},
+		},
+		{
+			name:        "completed with an allowed failure and a skipped step",
+			status:      workflow.WorkflowCompleted,
+			currentStep: 3,
+			completed: map[string]*workflow.StepResult{
+				"build":  succeeded,
+				"test":   {Success: false, AllowedFailure: true},
+				"merge":  succeeded,
+				"notify": {Success: true, Skipped: true},
+			},
+			want: WorkflowProgress{Percent: 100, Completed: 4, Skipped: 1, Total: 4},
+		},
+		{
+			name:        "resumed without the results of earlier steps",
+			status:      workflow.WorkflowRunning,
+			currentStep: 2,
+			completed:   map[string]*workflow.StepResult{"merge": succeeded},
+			want:        WorkflowProgress{Percent: 75, Completed: 3, Total: 4},
+		},
+		{
+			name:        "resumed and blocked without the results of earlier steps",
+			status:      workflow.WorkflowBlocked,
+			currentStep: 2,
+			completed:   map[string]*workflow.StepResult{"merge": failed},
+			want:        WorkflowProgress{Percent: 50, Completed: 2, Total: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &workflow.WorkflowState{Status: tt.status, CurrentStep: tt.currentStep, CompletedSteps: tt.completed}
+			if got := buildProgress(steps, state); *got != tt.want {
+				t.Errorf("buildProgress() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleGetWorkflow_LoopIterations(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: loop-grimoire
+description: Grimoire with a loop
+steps:
+  - name: refine
+    type: loop
+    max_iterations: 3
+    steps:
+      - name: test
+        type: script
+        command: make test
+        on_fail: continue
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "loop-grimoire.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	iteration := func(n int, success bool, errMsg string) workflow.LoopIteration {
+		return workflow.LoopIteration{
+			Iteration: n,
+			Success:   success,
+			Error:     errMsg,
+			Duration:  time.Second,
+			Steps:     []workflow.LoopIterationStep{{Name: "test", Success: success, Error: errMsg}},
+		}
+	}
+	state := &workflow.WorkflowState{
+		TaskID:       "task-loop",
+		WorkflowID:   "wf-loop",
+		GrimoireName: "loop-grimoire",
+		Status:       workflow.WorkflowBlocked,
+		CurrentStep:  0,
+		StartedAt:    time.Now(),
+		CompletedSteps: map[string]*workflow.StepResult{
+			"refine": {
+				Success: false,
+				Error:   "loop reached max iterations (3)",
+				Action:  workflow.ActionBlock,
+				Iterations: []workflow.LoopIteration{
+					iteration(0, false, "2 tests failed"),
+					iteration(1, false, "1 test failed"),
+					iteration(2, false, "1 test failed"),
+				},
+			},
+		},
+	}
+	if err := statePersister.Save(state); err != nil {
+		t.Fatalf("Failed to save state: %v", err)
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-loop")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	if len(result.Steps) != 2 {
+		t.Fatalf("Steps = %d, want 2", len(result.Steps))
+	}
+	loop := result.Steps[0]
+	if len(loop.Iterations) != 3 {
+		t.Fatalf("Iterations = %d, want 3", len(loop.Iterations))
+	}
+	for i, it := range loop.Iterations {
+		if it.Iteration != i {
+			t.Errorf("Iterations[%d].Iteration = %d, want %d", i, it.Iteration, i)
+		}
+		if it.Status != "failed" {
+			t.Errorf("Iterations[%d].Status = %q, want failed", i, it.Status)
+		}
+		if it.DurationMs != 1000 {
+			t.Errorf("Iterations[%d].DurationMs = %d, want 1000", i, it.DurationMs)
+		}
+		if len(it.Steps) != 1 || it.Steps[0].Name != "test" || it.Steps[0].Status != "failed" {
+			t.Errorf("Iterations[%d].Steps = %+v, want failed test step", i, it.Steps)
+		}
+	}
+	if loop.Iterations[0].Error != "2 tests failed" {
+		t.Errorf("Iterations[0].Error = %q, want %q", loop.Iterations[0].Error, "2 tests failed")
+	}
+	if result.Steps[1].Iterations != nil {
+		t.Errorf("Nested step Iterations = %v, want none", result.Steps[1].Iterations)
+	}
+}
+
+func TestHandleAddComment(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	state := &workflow.WorkflowState{
+		TaskID:     "task-comment",
+		WorkflowID: "wf-comment",
+		Status:     workflow.WorkflowPendingMerge,
+		StartedAt:  time.Now(),
+	}
+	statePersister.Save(state)
+
+	comments := []AddCommentRequest{
+		{Author: "alice", Text: "Rejecting: the migration isn't reversible"},
+		{Author: "bob", Text: "Add a down migration and retry"},
+	}
+	for i, c := range comments {
+		body, _ := json.Marshal(c)
+		// Post by workflow ID as well as task ID
+		id := "task-comment"
+		if i == 1 {
+			id = "wf-comment"
+		}
+		resp, err := client.Post("http://unix/workflows/"+id+"/comment", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		var result map[string]any
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d: %v", resp.StatusCode, http.StatusOK, result)
+		}
+		if result["comment_count"] != float64(i+1) {
+			t.Errorf("comment_count = %v, want %d", result["comment_count"], i+1)
+		}
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-comment")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+	var detail WorkflowDetailResponse
+	if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
+		t.Fatalf("Decode error: %v", err)
+	}
+
+	if len(detail.Comments) != 2 {
+		t.Fatalf("Comments = %d, want 2", len(detail.Comments))
+	}
+	for i, c := range comments {
+		if detail.Comments[i].Author != c.Author || detail.Comments[i].Text != c.Text {
+			t.Errorf("Comments[%d] = %+v, want %+v", i, detail.Comments[i], c)
+		}
+		if detail.Comments[i].CreatedAt.IsZero() {
+			t.Errorf("Comments[%d].CreatedAt should be set", i)
+		}
+	}
+}
+
+func TestHandleAddComment_Errors(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:     "task-comment",
+		WorkflowID: "wf-comment",
+		Status:     workflow.WorkflowRunning,
+		StartedAt:  time.Now(),
+	})
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		wantStatus int
+	}{
+		{"empty text", "task-comment", `{"author": "alice", "text": "  "}`, http.StatusBadRequest},
+		{"invalid body", "task-comment", `not json`, http.StatusBadRequest},
+		{"not found", "task-missing", `{"text": "hello"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := client.Post("http://unix/workflows/"+tt.id+"/comment", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("POST error: %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("Status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHandleSkipStep(t *testing.T) {
+	_, sched, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+	writeSkipTestGrimoire(t, covenDir)
+
+	task := types.Task{
+		ID:     "task-skip",
+		Title:  "Test Task",
+		Status: types.TaskStatusOpen,
+		Labels: []string{"grimoire:skip-test"},
+	}
+	sched.store.SetTasks([]types.Task{task})
+	if err := sched.StartAgentForTask(context.Background(), task); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+	state := waitForWorkflowStatus(t, statePersister, task.ID, workflow.WorkflowBlocked)
+	worktree := state.WorktreePath
+	if _, err := os.Stat(filepath.Join(worktree, "last")); err == nil {
+		t.Fatal("Step after the blocked step should not run before it is skipped")
+	}
+
+	t.Run("wrong step", func(t *testing.T) {
+		resp, err := client.Post("http://unix/workflows/"+task.ID+"/step/first/skip", "application/json", nil)
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+		var result map[string]string
+		json.NewDecoder(resp.Body).Decode(&result)
+		if !strings.Contains(result["error"], `blocked on step "check"`) {
+			t.Errorf("error = %q, should name the blocking step", result["error"])
+		}
+	})
+
+	t.Run("blocking step", func(t *testing.T) {
+		resp, err := client.Post("http://unix/workflows/"+state.WorkflowID+"/step/check/skip", "application/json", nil)
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		var result map[string]any
+		json.NewDecoder(resp.Body).Decode(&result)
+		if result["status"] != "skipped" || result["step"] != "check" {
+			t.Errorf("response = %v, want skipped check", result)
+		}
+
+		// The workflow continues with the step after the skipped one and
+		// completes with the skip still recorded
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			history, _, err := sched.WorkflowHistory(task.ID)
+			if err != nil {
+				t.Fatalf("WorkflowHistory() error: %v", err)
+			}
+			if len(history) > 0 && history[len(history)-1].Status == workflow.WorkflowCompleted {
+				completed := history[len(history)-1].CompletedSteps
+				if check := completed["check"]; check == nil || !check.Skipped {
+					t.Errorf("CompletedSteps[check] = %+v, want the skip", check)
+				}
+				for _, name := range []string{"first", "last"} {
+					if completed[name] == nil {
+						t.Errorf("CompletedSteps[%q] missing", name)
+					}
+				}
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Error("Workflow should complete after the blocked step is skipped")
+	})
+}
+
+func TestHandleSkipStep_NotBlocked(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	state := &workflow.WorkflowState{
+		TaskID:       "task-skip-running",
+		WorkflowID:   "wf-skip-running",
+		GrimoireName: "test-grimoire",
+		Status:       workflow.WorkflowRunning,
+		StartedAt:    time.Now(),
+	}
+	statePersister.Save(state)
+
+	resp, err := client.Post("http://unix/workflows/task-skip-running/step/build/skip", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestHandleBreakLoop_NotRunning(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	state := &workflow.WorkflowState{
+		TaskID:       "task-break-idle",
+		WorkflowID:   "wf-break-idle",
+		GrimoireName: "test-grimoire",
+		Status:       workflow.WorkflowBlocked,
+		StartedAt:    time.Now(),
+	}
+	statePersister.Save(state)
+
+	resp, err := client.Post("http://unix/workflows/wf-break-idle/step/review-loop/break-loop", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	var result map[string]string
+	json.NewDecoder(resp.Body).Decode(&result)
+	if !strings.Contains(result["error"], "workflow is not running") {
+		t.Errorf("error = %q, want the workflow to be reported as not running", result["error"])
+	}
+
+	resp, err = client.Post("http://unix/workflows/wf-missing/step/review-loop/break-loop", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Status for a missing workflow = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestHandleConfirmStep(t *testing.T) {
+	_, sched, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	os.MkdirAll(grimoiresDir, 0755)
+	content := `name: confirm-test
+description: Confirm test grimoire
+steps:
+  - name: build
+    type: script
+    command: "touch built"
+  - name: deploy
+    type: script
+    description: Deploy to production
+    command: "touch deployed"
+    confirm: true
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "confirm-test.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	task := types.Task{
+		ID:     "task-confirm",
+		Title:  "Test Task",
+		Status: types.TaskStatusOpen,
+		Labels: []string{"grimoire:confirm-test"},
+	}
+	sched.store.SetTasks([]types.Task{task})
+	if err := sched.StartAgentForTask(context.Background(), task); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+	state := waitForWorkflowStatus(t, statePersister, task.ID, workflow.WorkflowAwaitingConfirmation)
+	deployed := filepath.Join(state.WorktreePath, "deployed")
+
+	// The gated step waits, even after a while
+	time.Sleep(100 * time.Millisecond)
+	if _, err := os.Stat(deployed); err == nil {
+		t.Fatal("Confirm-gated step should not run before it is confirmed")
+	}
+
+	resp, err := client.Get("http://unix/workflows/" + task.ID)
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	var detail WorkflowDetailResponse
+	json.NewDecoder(resp.Body).Decode(&detail)
+	resp.Body.Close()
+	if detail.Confirmation == nil || detail.Confirmation.StepName != "deploy" {
+		t.Errorf("pending_confirmation = %+v, want deploy step", detail.Confirmation)
+	}
+	if len(detail.Actions) == 0 || detail.Actions[0] != "confirm" {
+		t.Errorf("available_actions = %v, want confirm", detail.Actions)
+	}
+
+	resp, err = client.Post("http://unix/workflows/"+task.ID+"/confirm", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var result map[string]any
+	json.NewDecoder(resp.Body).Decode(&result)
+	if result["status"] != "confirmed" || result["step"] != "deploy" {
+		t.Errorf("response = %v, want confirmed deploy", result)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(deployed); err == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("Confirmed step should run")
+}
+
+func TestHandleConfirmStep_NotAwaiting(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	state := &workflow.WorkflowState{
+		TaskID:     "task-confirm-blocked",
+		WorkflowID: "wf-confirm-blocked",
+		Status:     workflow.WorkflowBlocked,
+		StartedAt:  time.Now(),
+	}
+	statePersister.Save(state)
+
+	resp, err := client.Post("http://unix/workflows/task-confirm-blocked/confirm", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestHandleGetWorkflowLog_NotFound(t *testing.T) {
+	_, _, _, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	resp, err := client.Get("http://unix/workflows/nonexistent/log")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestHandleGetWorkflowLog_EmptyLog(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	state := &workflow.WorkflowState{
+		TaskID:     "task-log",
+		WorkflowID: "wf-log",
+		Status:     workflow.WorkflowRunning,
+		StartedAt:  time.Now(),
+	}
+	statePersister.Save(state)
+
+	resp, err := client.Get("http://unix/workflows/task-log/log")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want %q", ct, "application/x-ndjson")
+	}
+}
+
+func TestHandleGetWorkflowLog_WithLog(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	workflowID := "wf-log-content"
+	state := &workflow.WorkflowState{
+		TaskID:     "task-log-content",
+		WorkflowID: workflowID,
+		Status:     workflow.WorkflowRunning,
+		StartedAt:  time.Now(),
+	}
+	statePersister.Save(state)
+
+	// Create a log file
+	logDir := filepath.Join(covenDir, "logs", "workflows")
+	os.MkdirAll(logDir, 0755)
+	logContent := `{"timestamp":"2024-01-01T00:00:00Z","event":"workflow.start"}
 {"timestamp":"2024-01-01T00:00:01Z","event":"step.start"}
 `
 	os.WriteFile(filepath.Join(logDir, workflowID+".jsonl"), []byte(logContent), 0644)
@@ -501,6 +1663,83 @@ func TestHandleGetWorkflowLog_WithLog(t *testing.T) {
 	}
 }
 
+func TestHandleGetWorkflowLog_PartialLastLine(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	workflowID := "wf-log-partial"
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:     "task-log-partial",
+		WorkflowID: workflowID,
+		Status:     workflow.WorkflowRunning,
+		StartedAt:  time.Now(),
+	})
+
+	// The last entry is still being written
+	logDir := filepath.Join(covenDir, "logs", "workflows")
+	os.MkdirAll(logDir, 0755)
+	logContent := `{"timestamp":"2024-01-01T00:00:00Z","event":"workflow.start"}
+{"timestamp":"2024-01-01T00:00:01Z","event":"step.st`
+	os.WriteFile(filepath.Join(logDir, workflowID+".jsonl"), []byte(logContent), 0644)
+
+	resp, err := client.Get("http://unix/workflows/task-log-partial/log")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	var entry workflow.LogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		t.Fatalf("Failed to decode entry: %v", err)
+	}
+	if entry.Event != "workflow.start" {
+		t.Errorf("entry = %+v, want the complete workflow.start entry", entry)
+	}
+}
+
+func TestHandleGetWorkflowLog_CompactLog(t *testing.T) {
+	_, _, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	workflowID := "wf-log-compact"
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:     "task-log-compact",
+		WorkflowID: workflowID,
+		Status:     workflow.WorkflowRunning,
+		StartedAt:  time.Now(),
+	})
+
+	logger := workflow.NewLoggerWithPolicy(covenDir, workflow.LogFlushPolicy{})
+	logger.SetFormat(workflow.LogFormatCompact)
+	logger.LogStepStart(workflowID, "task-log-compact", "implement", "agent", 0, "")
+	logger.Close()
+
+	resp, err := client.Get("http://unix/workflows/task-log-compact/log")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The compact entry is returned in the verbose format
+	var entry workflow.LogEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		t.Fatalf("Failed to decode entry: %v", err)
+	}
+	if entry.Event != workflow.LogEventStepStart || entry.WorkflowID != workflowID {
+		t.Errorf("entry = %+v, want step.start for %s", entry, workflowID)
+	}
+	if string(entry.Data) != `{"step_name":"implement","step_type":"agent","step_index":0}` {
+		t.Errorf("Data = %s, want the verbose step.start data", entry.Data)
+	}
+}
+
 func TestHandleRejectMerge(t *testing.T) {
 	_, sched, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
 	defer cleanup()
@@ -541,6 +1780,14 @@ func TestHandleRejectMerge(t *testing.T) {
 	if result["reason"] != "Changes need revision" {
 		t.Errorf("reason = %q, want %q", result["reason"], "Changes need revision")
 	}
+
+	task := sched.store.GetTasks()[0]
+	if task.Status != types.TaskStatusBlocked {
+		t.Errorf("task Status = %q, want %q", task.Status, types.TaskStatusBlocked)
+	}
+	if task.StatusReason != types.StatusReasonMergeRejected {
+		t.Errorf("task StatusReason = %q, want %q", task.StatusReason, types.StatusReasonMergeRejected)
+	}
 }
 
 func TestHandleRejectMerge_NotPending(t *testing.T) {
@@ -626,3 +1873,379 @@ func TestHandleEmptyWorkflowID(t *testing.T) {
 		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
 	}
 }
+
+func TestHandleWorkflows_UnsupportedStateVersion(t *testing.T) {
+	_, _, _, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	writeFutureWorkflowState(t, covenDir, "task-future")
+
+	t.Run("get returns conflict", func(t *testing.T) {
+		resp, err := client.Get("http://unix/workflows/task-future")
+		if err != nil {
+			t.Fatalf("GET error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusConflict)
+		}
+	})
+
+	t.Run("retry returns conflict", func(t *testing.T) {
+		resp, err := client.Post("http://unix/workflows/task-future/retry", "application/json", nil)
+		if err != nil {
+			t.Fatalf("POST error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusConflict {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusConflict)
+		}
+	})
+
+	t.Run("list reports blocked", func(t *testing.T) {
+		resp, err := client.Get("http://unix/workflows")
+		if err != nil {
+			t.Fatalf("GET error: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var result WorkflowListResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("Decode error: %v", err)
+		}
+		if result.Count != 1 {
+			t.Fatalf("Count = %d, want 1", result.Count)
+		}
+		item := result.Workflows[0]
+		if item.Status != workflow.WorkflowBlocked || item.WorkflowID != "wf-future" {
+			t.Errorf("item = %+v, want blocked wf-future", item)
+		}
+		if !strings.Contains(item.Error, "unsupported state version") {
+			t.Errorf("Error = %q, want unsupported state version", item.Error)
+		}
+	})
+}
+
+func TestHandleCleanupWorkflow_KeepWorktree(t *testing.T) {
+	_, sched, statePersister, client, covenDir, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	grimoireDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	grimoireYAML := `name: analyze
+description: Analysis that leaves its worktree for inspection
+keep_worktree: true
+steps:
+  - name: report
+    type: script
+    command: "echo findings > report.txt"
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "analyze.yaml"), []byte(grimoireYAML), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	task := types.Task{
+		ID:     "task-keep",
+		Title:  "Analyze",
+		Status: types.TaskStatusOpen,
+		Labels: []string{"grimoire:analyze"},
+	}
+	sched.store.SetTasks([]types.Task{task})
+	if err := sched.StartAgentForTask(context.Background(), task); err != nil {
+		t.Fatalf("StartAgentForTask() error: %v", err)
+	}
+
+	// Wait for the workflow to complete
+	var state *workflow.WorkflowState
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		state, _ = statePersister.Load("task-keep")
+		if state != nil && state.Status == workflow.WorkflowCompleted {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if state == nil || state.Status != workflow.WorkflowCompleted {
+		t.Fatalf("Workflow state = %+v, want completed state kept", state)
+	}
+
+	worktree := sched.worktreeManager.GetPath("task-keep")
+	if _, err := os.Stat(filepath.Join(worktree, "report.txt")); err != nil {
+		t.Fatalf("Worktree should survive completion: %v", err)
+	}
+
+	resp, err := client.Get("http://unix/workflows/task-keep")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	var detail WorkflowDetailResponse
+	json.NewDecoder(resp.Body).Decode(&detail)
+	resp.Body.Close()
+
+	if detail.Result == nil || detail.Result.KeptWorktree != worktree {
+		t.Errorf("Result = %+v, want kept_worktree %q", detail.Result, worktree)
+	}
+	if len(detail.Actions) != 1 || detail.Actions[0] != "cleanup" {
+		t.Errorf("Actions = %v, want [cleanup]", detail.Actions)
+	}
+
+	resp, err = client.Post("http://unix/workflows/task-keep/cleanup", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if _, err := os.Stat(worktree); !os.IsNotExist(err) {
+		t.Error("Worktree should be removed by cleanup")
+	}
+	if state, _ := statePersister.Load("task-keep"); state != nil {
+		t.Error("Workflow state should be deleted by cleanup")
+	}
+}
+
+func TestHandleCleanupWorkflow_NotFinished(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	state := &workflow.WorkflowState{
+		TaskID:     "task-running",
+		WorkflowID: "wf-running",
+		Status:     workflow.WorkflowRunning,
+		StartedAt:  time.Now(),
+	}
+	statePersister.Save(state)
+
+	resp, err := client.Post("http://unix/workflows/task-running/cleanup", "application/json", nil)
+	if err != nil {
+		t.Fatalf("POST error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+	if state, _ := statePersister.Load("task-running"); state == nil {
+		t.Error("Running workflow state should not be deleted")
+	}
+}
+
+func TestHandleGetWorkflowDiff(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	worktree := initTestRepo(t)
+	if err := os.WriteFile(filepath.Join(worktree, "README.md"), []byte("# Test\n\nWork in progress\n"), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:       "task-diff",
+		WorkflowID:   "wf-diff",
+		Status:       workflow.WorkflowBlocked,
+		WorktreePath: worktree,
+		StartedAt:    time.Now(),
+	})
+
+	resp, err := client.Get("http://unix/workflows/wf-diff/diff")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var result WorkflowDiffResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !strings.Contains(result.Diff, "+Work in progress") {
+		t.Errorf("Diff = %q, want the README change", result.Diff)
+	}
+	if len(result.FilesChanged) != 1 || result.FilesChanged[0] != "README.md" {
+		t.Errorf("FilesChanged = %v, want [README.md]", result.FilesChanged)
+	}
+	if result.SizeBytes != len(result.Diff) || result.Truncated {
+		t.Errorf("SizeBytes = %d, Truncated = %v, want %d and not truncated", result.SizeBytes, result.Truncated, len(result.Diff))
+	}
+}
+
+func TestHandleGetWorkflowDiff_WorktreeGone(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:       "task-diff-gone",
+		WorkflowID:   "wf-diff-gone",
+		Status:       workflow.WorkflowCompleted,
+		WorktreePath: filepath.Join(t.TempDir(), "removed"),
+		StartedAt:    time.Now(),
+	})
+
+	for _, id := range []string{"task-diff-gone", "missing"} {
+		resp, err := client.Get("http://unix/workflows/" + id + "/diff")
+		if err != nil {
+			t.Fatalf("GET error: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: Status = %d, want %d", id, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+}
+
+func TestHandleGetMergeDiff(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	// A change far larger than the diff embedded in the merge review
+	worktree := initTestRepo(t)
+	var content strings.Builder
+	content.WriteString("# Test\n")
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&content, "line %d of a large but legitimate change\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(worktree, "README.md"), []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to modify file: %v", err)
+	}
+
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:       "task-merge-diff",
+		WorkflowID:   "wf-merge-diff",
+		Status:       workflow.WorkflowPendingMerge,
+		WorktreePath: worktree,
+		StartedAt:    time.Now(),
+	})
+
+	resp, err := client.Get("http://unix/workflows/wf-merge-diff/merge-diff")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(resp.TransferEncoding) == 0 || resp.TransferEncoding[0] != "chunked" {
+		t.Errorf("TransferEncoding = %v, want the diff streamed in chunks", resp.TransferEncoding)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response: %v", err)
+	}
+	diff := string(body)
+	if len(diff) <= 10000 {
+		t.Errorf("diff is %d bytes, want it larger than the embedded review diff", len(diff))
+	}
+	for _, line := range []string{"+line 0 of a large", "+line 1999 of a large"} {
+		if !strings.Contains(diff, line) {
+			t.Errorf("diff is missing %q", line)
+		}
+	}
+}
+
+func TestHandleGetMergeDiff_NotPendingMerge(t *testing.T) {
+	_, _, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:       "task-merge-diff-running",
+		WorkflowID:   "wf-merge-diff-running",
+		Status:       workflow.WorkflowRunning,
+		WorktreePath: initTestRepo(t),
+		StartedAt:    time.Now(),
+	})
+
+	resp, err := client.Get("http://unix/workflows/wf-merge-diff-running/merge-diff")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestHandleGetWorkflowDivergence(t *testing.T) {
+	_, sched, statePersister, client, _, cleanup := setupTestWorkflowHandlers(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	wtInfo, err := sched.worktreeManager.Create(ctx, "task-div")
+	if err != nil {
+		t.Fatalf("Failed to create worktree: %v", err)
+	}
+	statePersister.Save(&workflow.WorkflowState{
+		TaskID:       "task-div",
+		WorkflowID:   "wf-div",
+		Status:       workflow.WorkflowPendingMerge,
+		WorktreePath: wtInfo.Path,
+		StartedAt:    time.Now(),
+	})
+
+	// The base branch moves on while the workflow waits for review
+	repoDir := sched.worktreeManager.RepoPath()
+	for _, ref := range []struct{ dir, message string }{
+		{wtInfo.Path, "Implement task"},
+		{repoDir, "Unrelated fix"},
+		{repoDir, "Another unrelated fix"},
+	} {
+		if out, err := exec.Command("git", "-C", ref.dir, "commit", "--allow-empty", "-m", ref.message).CombinedOutput(); err != nil {
+			t.Fatalf("git commit failed: %v: %s", err, out)
+		}
+	}
+
+	resp, err := client.Get("http://unix/workflows/wf-div/divergence")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	var result WorkflowDivergenceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if result.TaskID != "task-div" || result.Branch != wtInfo.Branch {
+		t.Errorf("TaskID = %q, Branch = %q, want task-div on %q", result.TaskID, result.Branch, wtInfo.Branch)
+	}
+	if result.CommitsAhead != 1 || result.CommitsBehind != 2 {
+		t.Errorf("CommitsAhead = %d, CommitsBehind = %d, want 1 and 2", result.CommitsAhead, result.CommitsBehind)
+	}
+
+	resp, err = client.Get("http://unix/workflows/missing/divergence")
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Missing workflow: Status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	diff := "line one\nline two\nline three\n"
+
+	if got := truncateDiff(diff, len(diff)); got != diff {
+		t.Errorf("truncateDiff() = %q, want the diff unchanged", got)
+	}
+	if got, want := truncateDiff(diff, 15), "line one\n"; got != want {
+		t.Errorf("truncateDiff() = %q, want %q", got, want)
+	}
+	if got, want := truncateDiff("no newline here", 5), "no ne"; got != want {
+		t.Errorf("truncateDiff() = %q, want %q", got, want)
+	}
+}
@@ -14,9 +14,18 @@ import (
 // WorkflowRunner executes grimoire workflows for beads.
 type WorkflowRunner struct {
 	covenDir       string
+	statePersister *workflow.StatePersister
+	grimoireLoader *grimoire.Loader
 	grimoireMapper *workflow.GrimoireMapper
+	snapshots      *grimoire.SnapshotStore
 	logger         *logging.Logger
 	eventEmitter   workflow.EventEmitter
+	logFlushPolicy *workflow.LogFlushPolicy
+	logFormat      workflow.LogFormat
+	commandPolicy  *workflow.CommandPolicy
+
+	// grimoireOptions configure how grimoires are validated.
+	grimoireOptions grimoire.Options
 }
 
 // NewWorkflowRunner creates a new workflow runner.
@@ -26,7 +35,10 @@ func NewWorkflowRunner(covenDir string, logger *logging.Logger) *WorkflowRunner
 
 	return &WorkflowRunner{
 		covenDir:       covenDir,
+		statePersister: workflow.NewStatePersister(covenDir),
+		grimoireLoader: grimoireLoader,
 		grimoireMapper: mapper,
+		snapshots:      grimoire.NewSnapshotStore(covenDir),
 		logger:         logger,
 	}
 }
@@ -36,6 +48,44 @@ func (r *WorkflowRunner) SetEventEmitter(emitter workflow.EventEmitter) {
 	r.eventEmitter = emitter
 }
 
+// SetLogFlushPolicy sets how workflow JSONL logs are buffered.
+func (r *WorkflowRunner) SetLogFlushPolicy(policy workflow.LogFlushPolicy) {
+	r.logFlushPolicy = &policy
+}
+
+// SetLogFormat sets the encoding of workflow JSONL logs.
+func (r *WorkflowRunner) SetLogFormat(format workflow.LogFormat) {
+	r.logFormat = format
+}
+
+// SetCommandPolicy sets the policy script step commands must pass.
+func (r *WorkflowRunner) SetCommandPolicy(policy *workflow.CommandPolicy) {
+	r.commandPolicy = policy
+}
+
+// SetGrimoireOptions sets how the grimoires workflows run are validated.
+func (r *WorkflowRunner) SetGrimoireOptions(opts grimoire.Options) {
+	r.grimoireOptions = opts
+	r.grimoireLoader.SetOptions(opts)
+	r.snapshots.SetOptions(opts)
+}
+
+// GrimoireMapping returns the configuration used to pick a task's grimoire.
+func (r *WorkflowRunner) GrimoireMapping() (*workflow.GrimoireMappingConfig, error) {
+	return r.grimoireMapper.Config()
+}
+
+// ValidateGrimoireMapping checks that every grimoire cfg refers to exists.
+func (r *WorkflowRunner) ValidateGrimoireMapping(cfg *workflow.GrimoireMappingConfig) error {
+	return r.grimoireMapper.ValidateConfig(cfg)
+}
+
+// SetGrimoireMapping saves cfg and uses it to pick the grimoire of tasks
+// started from now on.
+func (r *WorkflowRunner) SetGrimoireMapping(cfg *workflow.GrimoireMappingConfig) error {
+	return r.grimoireMapper.SaveConfig(cfg)
+}
+
 // WorkflowConfig contains configuration for a workflow execution.
 type WorkflowConfig struct {
 	// WorktreePath is the path to the worktree for execution.
@@ -50,12 +100,27 @@ type WorkflowConfig struct {
 	// AgentRunner is the runner for agent steps (optional).
 	AgentRunner workflow.AgentRunner
 
+	// GrimoireHash pins the workflow to a stored grimoire snapshot (optional).
+	// When empty, the grimoire is resolved from the bead and snapshotted.
+	GrimoireHash string
+
+	// Inputs are the values of the grimoire's inputs (optional).
+	Inputs map[string]interface{}
+
 	// ResumeState contains saved state for resuming an interrupted workflow.
 	ResumeState *workflow.WorkflowState
 
 	// OnProcessSpawn is called when an agent process is spawned.
 	// It provides the step task ID and PID for tracking.
 	OnProcessSpawn func(stepTaskID string, pid int)
+
+	// StopAfterStep, when closed, stops the workflow before its next step
+	// so the daemon can shut down without losing progress (optional).
+	StopAfterStep <-chan struct{}
+
+	// LoopBreaks receives requests to break out of running loop steps
+	// (optional).
+	LoopBreaks *workflow.LoopBreaks
 }
 
 // WorkflowResult represents the result of a workflow execution.
@@ -69,6 +134,9 @@ type WorkflowResult struct {
 	// GrimoireName is the name of the grimoire that was executed.
 	GrimoireName string
 
+	// GrimoireHash is the content hash of the grimoire that was executed.
+	GrimoireHash string
+
 	// Duration is how long the workflow took to execute.
 	Duration time.Duration
 
@@ -84,6 +152,32 @@ type WorkflowResult struct {
 	// NeedsAutoMerge indicates the workflow had a merge step with require_review: false
 	// and the scheduler should perform the actual merge to main.
 	NeedsAutoMerge bool
+
+	// MergeOptions are the options of the merge step to auto-merge with.
+	MergeOptions workflow.MergeOptions
+
+	// KeepWorktree indicates the grimoire asked for its worktree to be kept
+	// after completion.
+	KeepWorktree bool
+
+	// PostMerge is the grimoire's hook to run after its changes are merged.
+	PostMerge *grimoire.PostMergeHook
+
+	// Interrupted indicates the workflow was stopped by a daemon shutdown and
+	// left in a resumable state.
+	Interrupted bool
+
+	// NoChanges indicates the workflow completed without changing anything:
+	// its merge steps found nothing to merge.
+	NoChanges bool
+
+	// MissingInputs indicates the workflow failed before any step ran
+	// because it wasn't given one or more of its grimoire's required inputs.
+	MissingInputs bool
+
+	// TimedOut indicates the workflow failed because a step ran past its
+	// timeout.
+	TimedOut bool
 }
 
 // Run executes the appropriate grimoire for a bead.
@@ -95,34 +189,71 @@ func (r *WorkflowRunner) Run(ctx context.Context, task types.Task, config Workfl
 		"worktree", config.WorktreePath,
 	)
 
-	// Resolve which grimoire to use
-	beadInfo := workflow.BeadInfo{
-		ID:       task.ID,
-		Type:     string(task.Type),
-		Labels:   task.Labels,
-		Title:    task.Title,
-		Body:     task.Description,
-		Priority: fmt.Sprintf("P%d", task.Priority),
-	}
+	var g *grimoire.Grimoire
+	if config.GrimoireHash != "" {
+		// Pinned: run the stored snapshot regardless of grimoire mapping
+		pinned, err := r.snapshots.Load(config.GrimoireHash)
+		if err != nil {
+			r.logger.Error("failed to load pinned grimoire",
+				"bead_id", config.BeadID,
+				"grimoire_hash", config.GrimoireHash,
+				"error", err,
+			)
+			return &WorkflowResult{
+				Success:      false,
+				Status:       workflow.WorkflowFailed,
+				Error:        fmt.Sprintf("failed to load pinned grimoire: %v", err),
+				Duration:     time.Since(start),
+				GrimoireHash: config.GrimoireHash,
+			}, nil
+		}
+		g = pinned
+	} else {
+		// Resolve which grimoire to use
+		grimoireName, err := r.grimoireMapper.Resolve(beadInfoForTask(task))
+		if err != nil {
+			r.logger.Error("failed to resolve grimoire",
+				"bead_id", config.BeadID,
+				"error", err,
+			)
+			return &WorkflowResult{
+				Success:      false,
+				Error:        fmt.Sprintf("failed to resolve grimoire: %v", err),
+				Duration:     time.Since(start),
+				GrimoireName: "",
+			}, nil
+		}
 
-	grimoireName, err := r.grimoireMapper.Resolve(beadInfo)
-	if err != nil {
-		r.logger.Error("failed to resolve grimoire",
-			"bead_id", config.BeadID,
-			"error", err,
-		)
-		return &WorkflowResult{
-			Success:      false,
-			Error:        fmt.Sprintf("failed to resolve grimoire: %v", err),
-			Duration:     time.Since(start),
-			GrimoireName: "",
-		}, nil
+		g, err = r.loadAndSnapshot(grimoireName)
+		if err != nil {
+			r.logger.Error("failed to load grimoire",
+				"bead_id", config.BeadID,
+				"grimoire", grimoireName,
+				"error", err,
+			)
+			return &WorkflowResult{
+				Success:      false,
+				Status:       workflow.WorkflowFailed,
+				Error:        fmt.Sprintf("failed to load grimoire %q: %v", grimoireName, err),
+				Duration:     time.Since(start),
+				GrimoireName: grimoireName,
+			}, nil
+		}
 	}
+	grimoireName := g.Name
 
 	r.logger.Info("resolved grimoire",
 		"bead_id", config.BeadID,
 		"grimoire", grimoireName,
+		"grimoire_hash", g.ContentHash,
 	)
+	for _, warning := range g.Warnings {
+		r.logger.Warn("grimoire validation warning",
+			"bead_id", config.BeadID,
+			"grimoire", grimoireName,
+			"warning", warning,
+		)
+	}
 
 	// Create bead data for template context
 	beadData := &workflow.BeadData{
@@ -136,11 +267,18 @@ func (r *WorkflowRunner) Run(ctx context.Context, task types.Task, config Workfl
 
 	// Create workflow engine
 	engine := workflow.NewEngine(workflow.EngineConfig{
-		CovenDir:     r.covenDir,
-		WorktreePath: config.WorktreePath,
-		BeadID:       config.BeadID,
-		WorkflowID:   config.WorkflowID,
-		Bead:         beadData,
+		CovenDir:        r.covenDir,
+		StatePersister:  r.statePersister,
+		GrimoireOptions: r.grimoireOptions,
+		WorktreePath:    config.WorktreePath,
+		BeadID:          config.BeadID,
+		WorkflowID:      config.WorkflowID,
+		Bead:            beadData,
+		StopAfterStep:   config.StopAfterStep,
+		LoopBreaks:      config.LoopBreaks,
+		LogFlushPolicy:  r.logFlushPolicy,
+		LogFormat:       r.logFormat,
+		CommandPolicy:   r.commandPolicy,
 	})
 
 	// Set event emitter if provided
@@ -154,7 +292,7 @@ func (r *WorkflowRunner) Run(ctx context.Context, task types.Task, config Workfl
 	}
 
 	// Execute the grimoire
-	result := engine.ExecuteByName(ctx, grimoireName)
+	result := engine.ExecuteWithInputs(ctx, g, config.Inputs)
 
 	r.logger.Info("workflow completed",
 		"bead_id", config.BeadID,
@@ -165,22 +303,26 @@ func (r *WorkflowRunner) Run(ctx context.Context, task types.Task, config Workfl
 
 	// Determine the last step name
 	lastStepName := ""
-	if result.CurrentStep >= 0 {
-		// Get the grimoire to find step names
-		g, err := r.grimoireMapper.GetGrimoire(grimoireName)
-		if err == nil && result.CurrentStep < len(g.Steps) {
-			lastStepName = g.Steps[result.CurrentStep].Name
-		}
+	if result.CurrentStep >= 0 && result.CurrentStep < len(g.Steps) {
+		lastStepName = g.Steps[result.CurrentStep].Name
 	}
 
 	workflowResult := &WorkflowResult{
 		Success:        result.Status == workflow.WorkflowCompleted,
 		Status:         result.Status,
 		GrimoireName:   grimoireName,
+		GrimoireHash:   g.ContentHash,
 		Duration:       result.Duration,
 		StepCount:      len(result.StepResults),
 		LastStepName:   lastStepName,
 		NeedsAutoMerge: result.NeedsAutoMerge,
+		MergeOptions:   result.MergeOptions,
+		KeepWorktree:   result.KeepWorktree,
+		PostMerge:      g.PostMerge,
+		Interrupted:    result.Interrupted,
+		NoChanges:      result.NoChanges,
+		MissingInputs:  grimoire.IsMissingInputs(result.Error),
+		TimedOut:       result.TimedOut,
 	}
 
 	if result.Error != nil {
@@ -202,7 +344,7 @@ func (r *WorkflowRunner) RunFromState(ctx context.Context, task types.Task, conf
 	)
 
 	// Load the grimoire that was being executed
-	g, err := r.grimoireMapper.GetGrimoire(state.GrimoireName)
+	g, err := r.loadForResume(state)
 	if err != nil {
 		r.logger.Error("failed to load grimoire for resume",
 			"bead_id", config.BeadID,
@@ -229,11 +371,18 @@ func (r *WorkflowRunner) RunFromState(ctx context.Context, task types.Task, conf
 
 	// Create workflow engine
 	engine := workflow.NewEngine(workflow.EngineConfig{
-		CovenDir:     r.covenDir,
-		WorktreePath: config.WorktreePath,
-		BeadID:       config.BeadID,
-		WorkflowID:   config.WorkflowID,
-		Bead:         beadData,
+		CovenDir:        r.covenDir,
+		StatePersister:  r.statePersister,
+		GrimoireOptions: r.grimoireOptions,
+		WorktreePath:    config.WorktreePath,
+		BeadID:          config.BeadID,
+		WorkflowID:      config.WorkflowID,
+		Bead:            beadData,
+		StopAfterStep:   config.StopAfterStep,
+		LoopBreaks:      config.LoopBreaks,
+		LogFlushPolicy:  r.logFlushPolicy,
+		LogFormat:       r.logFormat,
+		CommandPolicy:   r.commandPolicy,
 	})
 
 	// Set event emitter if provided
@@ -266,10 +415,17 @@ func (r *WorkflowRunner) RunFromState(ctx context.Context, task types.Task, conf
 		Success:        result.Status == workflow.WorkflowCompleted,
 		Status:         result.Status,
 		GrimoireName:   state.GrimoireName,
+		GrimoireHash:   g.ContentHash,
 		Duration:       result.Duration,
 		StepCount:      len(result.StepResults),
 		LastStepName:   lastStepName,
 		NeedsAutoMerge: result.NeedsAutoMerge,
+		MergeOptions:   result.MergeOptions,
+		KeepWorktree:   result.KeepWorktree,
+		PostMerge:      g.PostMerge,
+		Interrupted:    result.Interrupted,
+		NoChanges:      result.NoChanges,
+		TimedOut:       result.TimedOut,
 	}
 
 	if result.Error != nil {
@@ -279,9 +435,200 @@ func (r *WorkflowRunner) RunFromState(ctx context.Context, task types.Task, conf
 	return workflowResult, nil
 }
 
+// StepRunConfig is the context a single grimoire step runs in.
+type StepRunConfig struct {
+	// WorktreePath is the directory the step runs in.
+	WorktreePath string
+
+	// Bead is the bead the step's templates see as .bead (optional).
+	Bead *workflow.BeadData
+
+	// Variables are set as the outputs of earlier steps would be.
+	Variables map[string]string
+
+	// AgentRunner runs agent steps.
+	AgentRunner workflow.AgentRunner
+}
+
+// RunStep runs one top-level step of the named grimoire on its own. See
+// workflow.Engine.RunStep.
+func (r *WorkflowRunner) RunStep(ctx context.Context, grimoireName, stepName string, config StepRunConfig) (*workflow.StepResult, error) {
+	g, err := r.grimoireLoader.Load(grimoireName)
+	if err != nil {
+		return nil, err
+	}
+
+	beadID := ""
+	if config.Bead != nil {
+		beadID = config.Bead.ID
+	}
+	engine := workflow.NewEngine(workflow.EngineConfig{
+		CovenDir:        r.covenDir,
+		StatePersister:  r.statePersister,
+		GrimoireOptions: r.grimoireOptions,
+		WorktreePath:    config.WorktreePath,
+		BeadID:          beadID,
+		WorkflowID:      fmt.Sprintf("step-run-%d", time.Now().UnixNano()),
+		Bead:            config.Bead,
+		CommandPolicy:   r.commandPolicy,
+	})
+	if config.AgentRunner != nil {
+		engine.SetAgentRunner(config.AgentRunner)
+	}
+
+	r.logger.Info("running grimoire step",
+		"grimoire", grimoireName,
+		"step", stepName,
+		"worktree", config.WorktreePath,
+	)
+	return engine.RunStep(ctx, g, stepName, config.Variables)
+}
+
+// ConcurrencyGroup returns the concurrency group of the grimoire that would
+// run for the task, or "" if it has none. grimoireHash is the snapshot the
+// workflow is pinned to, if any, as passed in WorkflowConfig.
+func (r *WorkflowRunner) ConcurrencyGroup(task types.Task, grimoireHash string) (string, error) {
+	g, err := r.grimoireForTask(task, grimoireHash)
+	if err != nil {
+		return "", err
+	}
+	return g.ConcurrencyGroup, nil
+}
+
+// SparsePaths returns the sparse checkout paths of the grimoire that would run
+// for the task, or nil if its worktree should be checked out in full.
+// grimoireHash is as for ConcurrencyGroup.
+func (r *WorkflowRunner) SparsePaths(task types.Task, grimoireHash string) ([]string, error) {
+	g, err := r.grimoireForTask(task, grimoireHash)
+	if err != nil {
+		return nil, err
+	}
+	return g.SparsePaths, nil
+}
+
+// grimoireForTask returns the grimoire that would run for the task: the pinned
+// snapshot if grimoireHash is set, otherwise the one the task maps to.
+func (r *WorkflowRunner) grimoireForTask(task types.Task, grimoireHash string) (*grimoire.Grimoire, error) {
+	if grimoireHash != "" {
+		return r.snapshots.Load(grimoireHash)
+	}
+
+	name, err := r.grimoireMapper.Resolve(beadInfoForTask(task))
+	if err != nil {
+		return nil, err
+	}
+	return r.grimoireMapper.GetGrimoire(name)
+}
+
+// CheckInputs checks the values of the inputs of the grimoire that would run
+// for the task, as the workflow will when it starts. grimoireHash is as for
+// ConcurrencyGroup. A missing required input returns a
+// grimoire.MissingInputsError, any other problem with the values an
+// InvalidInputsError. A grimoire that can't be resolved isn't an error here:
+// the workflow fails to resolve it and reports it.
+func (r *WorkflowRunner) CheckInputs(task types.Task, grimoireHash string, inputs map[string]interface{}) error {
+	g, err := r.grimoireForTask(task, grimoireHash)
+	if err != nil {
+		return nil
+	}
+	if _, err := g.ResolveInputs(inputs); err != nil {
+		if grimoire.IsMissingInputs(err) {
+			return err
+		}
+		return &InvalidInputsError{Err: err}
+	}
+	return nil
+}
+
+// InvalidInputsError is returned when a workflow can't start because a value
+// given for its grimoire's inputs isn't valid.
+type InvalidInputsError struct {
+	Err error
+}
+
+func (e *InvalidInputsError) Error() string {
+	return e.Err.Error()
+}
+
+// IsInvalidInputs checks if an error is an InvalidInputsError.
+func IsInvalidInputs(err error) bool {
+	_, ok := err.(*InvalidInputsError)
+	return ok
+}
+
+// ResumeConcurrencyGroup returns the concurrency group of the grimoire a saved
+// workflow will resume with, or "" if it has none.
+func (r *WorkflowRunner) ResumeConcurrencyGroup(state *workflow.WorkflowState) (string, error) {
+	g, err := r.loadForResume(state)
+	if err != nil {
+		return "", err
+	}
+	return g.ConcurrencyGroup, nil
+}
+
+// beadInfoForTask returns the bead fields grimoire mapping rules match on.
+func beadInfoForTask(task types.Task) workflow.BeadInfo {
+	return workflow.BeadInfo{
+		ID:       task.ID,
+		Type:     string(task.Type),
+		Labels:   task.Labels,
+		Title:    task.Title,
+		Body:     task.Description,
+		Priority: fmt.Sprintf("P%d", task.Priority),
+	}
+}
+
+// loadAndSnapshot loads a grimoire by name and stores a snapshot of its content
+// so the workflow can later be resumed against the same definition.
+func (r *WorkflowRunner) loadAndSnapshot(name string) (*grimoire.Grimoire, error) {
+	data, err := r.grimoireLoader.LoadContent(name)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := grimoire.ParseWithOptions(data, r.grimoireOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.snapshots.Save(data); err != nil {
+		// Not fatal: resume falls back to loading the grimoire by name
+		r.logger.Warn("failed to snapshot grimoire",
+			"grimoire", name,
+			"error", err,
+		)
+	}
+
+	return g, nil
+}
+
+// loadForResume loads the grimoire a saved workflow was running.
+// Workflows with a content hash use the pinned snapshot so later edits to the
+// grimoire don't affect them; older states fall back to loading by name.
+func (r *WorkflowRunner) loadForResume(state *workflow.WorkflowState) (*grimoire.Grimoire, error) {
+	if state.GrimoireHash != "" {
+		g, err := r.snapshots.Load(state.GrimoireHash)
+		if err == nil {
+			return g, nil
+		}
+		if !grimoire.IsSnapshotNotFound(err) {
+			return nil, err
+		}
+		r.logger.Warn("grimoire snapshot not found, loading by name",
+			"grimoire", state.GrimoireName,
+			"grimoire_hash", state.GrimoireHash,
+		)
+	}
+
+	return r.grimoireMapper.GetGrimoire(state.GrimoireName)
+}
+
 // StatusForResult converts a workflow result to a task status.
 // Note: beads doesn't support "pending_merge" as a status, so we map it to "blocked".
 // The workflow status is still tracked internally for proper state management.
+// A workflow that completed without changing anything is also mapped to
+// "blocked" rather than "closed", so a task the agent did nothing for isn't
+// reported as done.
 func StatusForResult(result *WorkflowResult) types.TaskStatus {
 	if result == nil {
 		return types.TaskStatusOpen
@@ -289,11 +636,14 @@ func StatusForResult(result *WorkflowResult) types.TaskStatus {
 
 	switch result.Status {
 	case workflow.WorkflowCompleted:
+		if result.NoChanges {
+			return types.TaskStatusBlocked
+		}
 		return types.TaskStatusClosed
 	case workflow.WorkflowPendingMerge:
 		// Map pending_merge to blocked for beads compatibility
 		return types.TaskStatusBlocked
-	case workflow.WorkflowBlocked:
+	case workflow.WorkflowBlocked, workflow.WorkflowAwaitingConfirmation, workflow.WorkflowStopped:
 		return types.TaskStatusBlocked
 	case workflow.WorkflowCancelled:
 		return types.TaskStatusOpen
@@ -304,6 +654,43 @@ func StatusForResult(result *WorkflowResult) types.TaskStatus {
 	}
 }
 
+// StatusReasonForResult returns why a workflow result moves its task to the
+// status StatusForResult gives it.
+func StatusReasonForResult(result *WorkflowResult) types.TaskStatusReason {
+	if result == nil {
+		return types.StatusReasonFailed
+	}
+
+	switch result.Status {
+	case workflow.WorkflowCompleted:
+		switch {
+		case result.NoChanges:
+			return types.StatusReasonNoChanges
+		case result.NeedsAutoMerge:
+			return types.StatusReasonMerged
+		}
+		return types.StatusReasonCompleted
+	case workflow.WorkflowPendingMerge:
+		return types.StatusReasonMergePending
+	case workflow.WorkflowAwaitingConfirmation:
+		return types.StatusReasonAwaitingConfirmation
+	case workflow.WorkflowBlocked:
+		return types.StatusReasonBlocked
+	case workflow.WorkflowCancelled:
+		return types.StatusReasonCancelled
+	case workflow.WorkflowFailed:
+		switch {
+		case result.MissingInputs:
+			return types.StatusReasonMissingInputs
+		case result.TimedOut:
+			return types.StatusReasonTimedOut
+		}
+		return types.StatusReasonFailed
+	default:
+		return types.StatusReasonFailed
+	}
+}
+
 // ShouldRetry determines if a workflow should be retried.
 func ShouldRetry(result *WorkflowResult, attempt int, maxRetries int) bool {
 	if result == nil {
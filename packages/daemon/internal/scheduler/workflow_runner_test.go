@@ -2,10 +2,13 @@ package scheduler
 
 import (
 	"context"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/coven/daemon/internal/grimoire"
 	"github.com/coven/daemon/internal/logging"
 	"github.com/coven/daemon/internal/workflow"
 	"github.com/coven/daemon/pkg/types"
@@ -56,6 +59,16 @@ func TestStatusForResult(t *testing.T) {
 			},
 			expected: types.TaskStatusClosed,
 		},
+		{
+			name: "completed without changes",
+			result: &WorkflowResult{
+				Success:   true,
+				Status:    workflow.WorkflowCompleted,
+				NoChanges: true,
+			},
+			// Not closed, so a task the agent did nothing for isn't reported as done
+			expected: types.TaskStatusBlocked,
+		},
 		{
 			name: "pending merge",
 			result: &WorkflowResult{
@@ -89,6 +102,14 @@ func TestStatusForResult(t *testing.T) {
 			},
 			expected: types.TaskStatusBlocked,
 		},
+		{
+			name: "stopped workflow",
+			result: &WorkflowResult{
+				Success: false,
+				Status:  workflow.WorkflowStopped,
+			},
+			expected: types.TaskStatusBlocked,
+		},
 		{
 			name: "running workflow (default)",
 			result: &WorkflowResult{
@@ -109,6 +130,73 @@ func TestStatusForResult(t *testing.T) {
 	}
 }
 
+func TestStatusReasonForResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   *WorkflowResult
+		expected types.TaskStatusReason
+	}{
+		{
+			name:     "completed workflow",
+			result:   &WorkflowResult{Success: true, Status: workflow.WorkflowCompleted},
+			expected: types.StatusReasonCompleted,
+		},
+		{
+			name:     "completed without changes",
+			result:   &WorkflowResult{Success: true, Status: workflow.WorkflowCompleted, NoChanges: true},
+			expected: types.StatusReasonNoChanges,
+		},
+		{
+			name:     "auto-merged",
+			result:   &WorkflowResult{Success: true, Status: workflow.WorkflowCompleted, NeedsAutoMerge: true},
+			expected: types.StatusReasonMerged,
+		},
+		{
+			name:     "pending merge",
+			result:   &WorkflowResult{Status: workflow.WorkflowPendingMerge},
+			expected: types.StatusReasonMergePending,
+		},
+		{
+			name:     "blocked workflow",
+			result:   &WorkflowResult{Status: workflow.WorkflowBlocked},
+			expected: types.StatusReasonBlocked,
+		},
+		{
+			name:     "failed workflow",
+			result:   &WorkflowResult{Status: workflow.WorkflowFailed, Error: "step failed"},
+			expected: types.StatusReasonFailed,
+		},
+		{
+			name:     "missing inputs",
+			result:   &WorkflowResult{Status: workflow.WorkflowFailed, Error: "missing required inputs: environment", MissingInputs: true},
+			expected: types.StatusReasonMissingInputs,
+		},
+		{
+			name:     "timed out workflow",
+			result:   &WorkflowResult{Status: workflow.WorkflowFailed, Error: `step "test" failed: step timed out after 5m0s`, TimedOut: true},
+			expected: types.StatusReasonTimedOut,
+		},
+		{
+			name:     "failure that mentions a timeout",
+			result:   &WorkflowResult{Status: workflow.WorkflowFailed, Error: `step "test" failed: curl: connection timed out`},
+			expected: types.StatusReasonFailed,
+		},
+		{
+			name:     "cancelled workflow",
+			result:   &WorkflowResult{Status: workflow.WorkflowCancelled},
+			expected: types.StatusReasonCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StatusReasonForResult(tt.result); got != tt.expected {
+				t.Errorf("StatusReasonForResult() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestShouldRetry(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -321,3 +409,255 @@ func TestWorkflowRunner_Run_WithLabels(t *testing.T) {
 		t.Error("Expected duration to be set")
 	}
 }
+
+// writePinTestGrimoire writes a grimoire whose final step records the given version.
+// The first step blocks so the workflow can be resumed later.
+func writePinTestGrimoire(t *testing.T, covenDir, version string) {
+	t.Helper()
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	content := `name: pin-test
+description: Pinned grimoire test
+steps:
+  - name: gate
+    type: script
+    command: "test -f approved"
+    on_fail: block
+  - name: record
+    type: script
+    command: "echo ` + version + ` > version.txt"
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "pin-test.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+}
+
+func TestWorkflowRunner_RunFromState_PinnedIgnoresEdits(t *testing.T) {
+	covenDir := t.TempDir()
+	worktree := t.TempDir()
+	logger := newTestLogger(t)
+	runner := NewWorkflowRunner(covenDir, logger)
+
+	writePinTestGrimoire(t, covenDir, "v1")
+
+	task := types.Task{
+		ID:     "coven-pin",
+		Title:  "Pinned task",
+		Type:   "task",
+		Labels: []string{"grimoire:pin-test"},
+	}
+	config := WorkflowConfig{
+		WorktreePath: worktree,
+		BeadID:       "coven-pin",
+		WorkflowID:   "wf-pin",
+	}
+
+	result, err := runner.Run(context.Background(), task, config)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Status != workflow.WorkflowBlocked {
+		t.Fatalf("Status = %q, want %q (error: %s)", result.Status, workflow.WorkflowBlocked, result.Error)
+	}
+	if result.GrimoireHash == "" {
+		t.Fatal("GrimoireHash should be set")
+	}
+
+	state, err := workflow.NewStatePersister(covenDir).Load("coven-pin")
+	if err != nil || state == nil {
+		t.Fatalf("Load state error: %v", err)
+	}
+	if state.GrimoireHash != result.GrimoireHash {
+		t.Errorf("state.GrimoireHash = %q, want %q", state.GrimoireHash, result.GrimoireHash)
+	}
+
+	// Edit the grimoire after the workflow started
+	writePinTestGrimoire(t, covenDir, "v2")
+
+	resumed, err := runner.RunFromState(context.Background(), task, config, state)
+	if err != nil {
+		t.Fatalf("RunFromState() error: %v", err)
+	}
+	if resumed.Status != workflow.WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %s)", resumed.Status, workflow.WorkflowCompleted, resumed.Error)
+	}
+	if resumed.GrimoireHash != result.GrimoireHash {
+		t.Errorf("resumed GrimoireHash = %q, want %q", resumed.GrimoireHash, result.GrimoireHash)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "version.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "v1" {
+		t.Errorf("version = %q, want %q (pinned grimoire should ignore edits)", got, "v1")
+	}
+}
+
+func TestWorkflowRunner_Run_PinnedHash(t *testing.T) {
+	covenDir := t.TempDir()
+	worktree := t.TempDir()
+	logger := newTestLogger(t)
+	runner := NewWorkflowRunner(covenDir, logger)
+
+	// Snapshot v1, then replace the grimoire file with v2
+	writePinTestGrimoire(t, covenDir, "v1")
+	content, err := grimoire.NewLoader(covenDir).LoadContent("pin-test")
+	if err != nil {
+		t.Fatalf("LoadContent() error: %v", err)
+	}
+	hash, err := grimoire.NewSnapshotStore(covenDir).Save(content)
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	writePinTestGrimoire(t, covenDir, "v2")
+
+	// Gate passes so the workflow runs to completion
+	if err := os.WriteFile(filepath.Join(worktree, "approved"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	task := types.Task{ID: "coven-pinned", Title: "Pinned", Type: "task"}
+	config := WorkflowConfig{
+		WorktreePath: worktree,
+		BeadID:       "coven-pinned",
+		WorkflowID:   "wf-pinned",
+		GrimoireHash: hash,
+	}
+
+	result, err := runner.Run(context.Background(), task, config)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Expected success, got status %q error %q", result.Status, result.Error)
+	}
+	if result.GrimoireName != "pin-test" {
+		t.Errorf("GrimoireName = %q, want %q", result.GrimoireName, "pin-test")
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "version.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "v1" {
+		t.Errorf("version = %q, want %q", got, "v1")
+	}
+}
+
+func TestWorkflowRunner_Run_PinnedHashNotFound(t *testing.T) {
+	covenDir := t.TempDir()
+	runner := NewWorkflowRunner(covenDir, newTestLogger(t))
+
+	config := WorkflowConfig{
+		WorktreePath: t.TempDir(),
+		BeadID:       "coven-missing",
+		WorkflowID:   "wf-missing",
+		GrimoireHash: strings.Repeat("0", 64),
+	}
+
+	result, err := runner.Run(context.Background(), types.Task{ID: "coven-missing"}, config)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Success {
+		t.Error("Expected failure for missing snapshot")
+	}
+	if !strings.Contains(result.Error, "pinned grimoire") {
+		t.Errorf("Error = %q, should mention pinned grimoire", result.Error)
+	}
+}
+
+// writeInputsTestGrimoire writes a grimoire with a required input that its
+// step writes to environment.txt.
+func writeInputsTestGrimoire(t *testing.T, covenDir string) {
+	t.Helper()
+	grimoiresDir := filepath.Join(covenDir, "grimoires")
+	if err := os.MkdirAll(grimoiresDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoires dir: %v", err)
+	}
+	content := `name: inputs-test
+description: Grimoire inputs test
+inputs:
+  - name: environment
+    required: true
+steps:
+  - name: deploy
+    type: script
+    command: "echo {{.inputs.environment}} > environment.txt"
+`
+	if err := os.WriteFile(filepath.Join(grimoiresDir, "inputs-test.yaml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+}
+
+func TestWorkflowRunner_Run_Inputs(t *testing.T) {
+	covenDir := t.TempDir()
+	runner := NewWorkflowRunner(covenDir, newTestLogger(t))
+	writeInputsTestGrimoire(t, covenDir)
+	task := types.Task{ID: "coven-inputs", Title: "Deploy", Type: "task", Labels: []string{"grimoire:inputs-test"}}
+
+	t.Run("given", func(t *testing.T) {
+		worktree := t.TempDir()
+		config := WorkflowConfig{
+			WorktreePath: worktree,
+			BeadID:       task.ID,
+			WorkflowID:   "wf-inputs-given",
+			Inputs:       map[string]interface{}{"environment": "staging"},
+		}
+		result, err := runner.Run(context.Background(), task, config)
+		if err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+		if !result.Success {
+			t.Fatalf("Expected success, got status %q error %q", result.Status, result.Error)
+		}
+		data, err := os.ReadFile(filepath.Join(worktree, "environment.txt"))
+		if err != nil {
+			t.Fatalf("ReadFile error: %v", err)
+		}
+		if got := strings.TrimSpace(string(data)); got != "staging" {
+			t.Errorf("environment = %q, want %q", got, "staging")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		config := WorkflowConfig{
+			WorktreePath: t.TempDir(),
+			BeadID:       task.ID,
+			WorkflowID:   "wf-inputs-missing",
+		}
+		result, err := runner.Run(context.Background(), task, config)
+		if err != nil {
+			t.Fatalf("Run() error: %v", err)
+		}
+		if !result.MissingInputs {
+			t.Errorf("MissingInputs = false, want true (status %q error %q)", result.Status, result.Error)
+		}
+		if reason := StatusReasonForResult(result); reason != types.StatusReasonMissingInputs {
+			t.Errorf("StatusReasonForResult() = %q, want %q", reason, types.StatusReasonMissingInputs)
+		}
+	})
+}
+
+func TestWorkflowRunner_CheckInputs(t *testing.T) {
+	covenDir := t.TempDir()
+	runner := NewWorkflowRunner(covenDir, newTestLogger(t))
+	writeInputsTestGrimoire(t, covenDir)
+	task := types.Task{ID: "coven-inputs", Labels: []string{"grimoire:inputs-test"}}
+
+	if err := runner.CheckInputs(task, "", map[string]interface{}{"environment": "staging"}); err != nil {
+		t.Errorf("CheckInputs() error = %v, want nil", err)
+	}
+	if err := runner.CheckInputs(task, "", nil); !grimoire.IsMissingInputs(err) {
+		t.Errorf("CheckInputs() without inputs error = %v, want MissingInputsError", err)
+	}
+	if err := runner.CheckInputs(task, "", map[string]interface{}{"environment": "staging", "region": "eu"}); !IsInvalidInputs(err) {
+		t.Errorf("CheckInputs() with an undeclared input error = %v, want InvalidInputsError", err)
+	}
+	if err := runner.CheckInputs(types.Task{ID: "coven-other", Labels: []string{"grimoire:no-such-grimoire"}}, "", nil); err != nil {
+		t.Errorf("CheckInputs() for an unresolvable grimoire error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Package secrets holds sensitive values in memory so they can't be printed
+// by accident and can be cleared once they have been used.
+package secrets
+
+import "fmt"
+
+// Redacted is what a Secret prints in place of its value.
+const Redacted = "***"
+
+// Secret wraps a sensitive value. Formatting it with fmt, encoding it as JSON
+// or text, or passing it to the logger prints Redacted; the value is only
+// available through Reveal. Call Zero once the value is no longer needed to
+// overwrite its backing bytes.
+//
+// Go strings are immutable, so copies made by Reveal can't be cleared. Keep
+// them short-lived, passing them straight into e.g. a process environment.
+// A Secret must not be zeroed while another goroutine is reading it.
+type Secret struct {
+	value []byte
+}
+
+// New creates a Secret holding a copy of value.
+func New(value string) *Secret {
+	return &Secret{value: []byte(value)}
+}
+
+// FromBytes creates a Secret that takes ownership of b. The caller should not
+// use b afterwards; Zero overwrites it.
+func FromBytes(b []byte) *Secret {
+	return &Secret{value: b}
+}
+
+// Reveal returns the secret value, or "" once the secret has been zeroed.
+func (s *Secret) Reveal() string {
+	if s == nil {
+		return ""
+	}
+	return string(s.value)
+}
+
+// EnvVar returns a "NAME=value" entry for a process environment.
+func (s *Secret) EnvVar(name string) string {
+	return name + "=" + s.Reveal()
+}
+
+// IsZero reports whether the secret holds no value, either because it was
+// empty or because it has been zeroed.
+func (s *Secret) IsZero() bool {
+	if s == nil {
+		return true
+	}
+	return len(s.value) == 0
+}
+
+// Zero overwrites the secret's backing bytes and releases them.
+// It is safe to call more than once.
+func (s *Secret) Zero() {
+	if s == nil {
+		return
+	}
+	for i := range s.value {
+		s.value[i] = 0
+	}
+	s.value = nil
+}
+
+// String returns Redacted.
+func (s Secret) String() string {
+	return Redacted
+}
+
+// GoString returns Redacted, so %#v doesn't print the backing bytes.
+func (s Secret) GoString() string {
+	return Redacted
+}
+
+// Format prints Redacted for every verb, including %d and %x, which would
+// otherwise print the struct's fields.
+func (s Secret) Format(f fmt.State, verb rune) {
+	if verb == 'q' {
+		fmt.Fprintf(f, "%q", Redacted)
+		return
+	}
+	f.Write([]byte(Redacted))
+}
+
+// MarshalJSON encodes the secret as the string Redacted.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Redacted + `"`), nil
+}
+
+// MarshalText encodes the secret as Redacted.
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+// Map holds named secrets, such as those injected into a step's environment.
+type Map map[string]*Secret
+
+// Env returns "NAME=value" entries for every secret in the map.
+func (m Map) Env() []string {
+	env := make([]string, 0, len(m))
+	for name, s := range m {
+		env = append(env, s.EnvVar(name))
+	}
+	return env
+}
+
+// Zero zeroes every secret in the map and removes it, so values don't linger
+// in a long-lived map after use.
+func (m Map) Zero() {
+	for name, s := range m {
+		s.Zero()
+		delete(m, name)
+	}
+}
@@ -0,0 +1,160 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/logging"
+)
+
+const testValue = "hunter2-token"
+
+type nopCloser struct {
+	bytes.Buffer
+}
+
+func (n *nopCloser) Close() error { return nil }
+
+func TestSecret_NeverPrintsValue(t *testing.T) {
+	s := New(testValue)
+	wrapped := struct {
+		Name  string
+		Token *Secret
+		Copy  Secret
+	}{Name: "api", Token: s, Copy: *s}
+
+	verbs := []string{"%v", "%+v", "%#v", "%s", "%q", "%d", "%x", "%X", "%10s"}
+	for _, verb := range verbs {
+		for name, arg := range map[string]any{"value": *s, "pointer": s, "struct": wrapped} {
+			out := fmt.Sprintf(verb, arg)
+			if strings.Contains(out, testValue) || strings.Contains(out, fmt.Sprintf("%x", testValue)) {
+				t.Errorf("Sprintf(%q, %s) = %q, leaks the value", verb, name, out)
+			}
+		}
+	}
+
+	if got := fmt.Sprint(s); got != Redacted {
+		t.Errorf("Sprint() = %q, want %q", got, Redacted)
+	}
+	if got := fmt.Sprint(*s); got != Redacted {
+		t.Errorf("Sprint(value) = %q, want %q", got, Redacted)
+	}
+	if got := fmt.Sprintf("%q", s); got != `"***"` {
+		t.Errorf("Sprintf(%%q) = %q, want %q", got, `"***"`)
+	}
+}
+
+func TestSecret_JSONAndTextRedacted(t *testing.T) {
+	s := New(testValue)
+	data, err := json.Marshal(map[string]any{"pointer": s, "value": *s})
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	if strings.Contains(string(data), testValue) {
+		t.Errorf("json.Marshal() = %s, leaks the value", data)
+	}
+	if want := `{"pointer":"***","value":"***"}`; string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+
+	text, err := s.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText() error: %v", err)
+	}
+	if string(text) != Redacted {
+		t.Errorf("MarshalText() = %q, want %q", text, Redacted)
+	}
+}
+
+func TestSecret_LoggedAndWrappedRedacted(t *testing.T) {
+	s := New(testValue)
+
+	w := &nopCloser{}
+	logger := logging.NewWithWriter(w)
+	logger.Info("injecting secret", "name", "API_KEY", "secret", s)
+	if out := w.String(); strings.Contains(out, testValue) {
+		t.Errorf("log output = %q, leaks the value", out)
+	} else if !strings.Contains(out, Redacted) {
+		t.Errorf("log output = %q, want it to contain %q", out, Redacted)
+	}
+
+	err := fmt.Errorf("failed to inject %v: %w", s, errors.New("boom"))
+	if strings.Contains(err.Error(), testValue) {
+		t.Errorf("error = %q, leaks the value", err)
+	}
+}
+
+func TestSecret_Reveal(t *testing.T) {
+	s := New(testValue)
+	if got := s.Reveal(); got != testValue {
+		t.Errorf("Reveal() = %q, want %q", got, testValue)
+	}
+	if got := s.EnvVar("API_KEY"); got != "API_KEY="+testValue {
+		t.Errorf("EnvVar() = %q, want %q", got, "API_KEY="+testValue)
+	}
+	if s.IsZero() {
+		t.Error("IsZero() = true, want false")
+	}
+}
+
+func TestSecret_Zero(t *testing.T) {
+	b := []byte(testValue)
+	s := FromBytes(b)
+
+	s.Zero()
+
+	for i, c := range b {
+		if c != 0 {
+			t.Fatalf("byte %d = %q after Zero(), want 0", i, c)
+		}
+	}
+	if !s.IsZero() {
+		t.Error("IsZero() = false after Zero(), want true")
+	}
+	if got := s.Reveal(); got != "" {
+		t.Errorf("Reveal() after Zero() = %q, want empty", got)
+	}
+
+	// Zeroing twice is harmless.
+	s.Zero()
+}
+
+func TestSecret_Nil(t *testing.T) {
+	var s *Secret
+	if got := s.Reveal(); got != "" {
+		t.Errorf("Reveal() = %q, want empty", got)
+	}
+	if !s.IsZero() {
+		t.Error("IsZero() = false, want true")
+	}
+	s.Zero()
+}
+
+func TestMap_EnvAndZero(t *testing.T) {
+	b := []byte(testValue)
+	m := Map{"API_KEY": FromBytes(b)}
+
+	env := m.Env()
+	if len(env) != 1 || env[0] != "API_KEY="+testValue {
+		t.Errorf("Env() = %v, want [API_KEY=%s]", env, testValue)
+	}
+
+	m.Zero()
+
+	if len(m) != 0 {
+		t.Errorf("len(map) = %d after Zero(), want 0", len(m))
+	}
+	if !bytes.Equal(b, make([]byte, len(testValue))) {
+		t.Errorf("backing bytes = %q after Zero(), want all zero", b)
+	}
+
+	var nilMap Map
+	if env := nilMap.Env(); len(env) != 0 {
+		t.Errorf("nil Map Env() = %v, want empty", env)
+	}
+	nilMap.Zero()
+}
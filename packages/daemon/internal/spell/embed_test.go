@@ -215,3 +215,20 @@ func TestBuiltinSpells_RenderFixTests(t *testing.T) {
 		t.Error("Rendered spell should contain test output")
 	}
 }
+
+func TestBuiltinSpells_SummarizeChanges(t *testing.T) {
+	loader := NewLoader(t.TempDir())
+
+	spell, err := loader.Load("summarize-changes")
+	if err != nil {
+		t.Fatalf("Load(summarize-changes) error: %v", err)
+	}
+
+	if spell.Source != SourceBuiltIn {
+		t.Errorf("Source = %q, want %q", spell.Source, SourceBuiltIn)
+	}
+
+	if !strings.Contains(spell.Content, "{{.merge_review.Diff}}") {
+		t.Error("summarize-changes spell should contain {{.merge_review.Diff}}")
+	}
+}
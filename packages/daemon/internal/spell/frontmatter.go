@@ -0,0 +1,174 @@
+package spell
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatterDelimiter opens and closes a spell's front matter, on lines of
+// their own at the start of the file.
+const frontMatterDelimiter = "---"
+
+// FrontMatter declares the variables a spell uses, in a YAML block at the
+// start of the spell file:
+//
+//	---
+//	required_vars: [bead.title, test_output]
+//	defaults:
+//	  tone: concise
+//	---
+type FrontMatter struct {
+	// RequiredVars are the variables the spell can't be rendered without.
+	// Nested variables are named by their dotted path, as in "bead.title".
+	RequiredVars []string `yaml:"required_vars,omitempty"`
+
+	// Defaults are values for top-level variables the context doesn't set.
+	Defaults map[string]interface{} `yaml:"defaults,omitempty"`
+}
+
+// FrontMatterError is returned when a spell's front matter can't be parsed.
+type FrontMatterError struct {
+	Name string
+	Err  error
+}
+
+func (e *FrontMatterError) Error() string {
+	return fmt.Sprintf("invalid front matter in spell %q: %v", e.Name, e.Err)
+}
+
+func (e *FrontMatterError) Unwrap() error {
+	return e.Err
+}
+
+// MissingVariablesError is returned when a spell is rendered without
+// variables its front matter requires.
+type MissingVariablesError struct {
+	Name string
+	Vars []string
+}
+
+func (e *MissingVariablesError) Error() string {
+	return fmt.Sprintf("spell %q requires variables that aren't set: %s", e.Name, strings.Join(e.Vars, ", "))
+}
+
+// IsFrontMatterError returns true if the error is a FrontMatterError.
+func IsFrontMatterError(err error) bool {
+	_, ok := err.(*FrontMatterError)
+	return ok
+}
+
+// IsMissingVariables returns true if the error is a MissingVariablesError.
+func IsMissingVariables(err error) bool {
+	_, ok := err.(*MissingVariablesError)
+	return ok
+}
+
+// ParseFrontMatter splits spell file content into its front matter and the
+// template that follows it. Content without front matter is returned whole,
+// with an empty FrontMatter. lines is the number of lines the front matter
+// takes, so template line numbers can be mapped back to the file.
+func ParseFrontMatter(name, content string) (fm FrontMatter, body string, lines int, err error) {
+	first, rest, ok := strings.Cut(content, "\n")
+	if !ok || strings.TrimSuffix(first, "\r") != frontMatterDelimiter {
+		return FrontMatter{}, content, 0, nil
+	}
+
+	var block []string
+	for {
+		line, next, more := strings.Cut(rest, "\n")
+		if strings.TrimSuffix(line, "\r") == frontMatterDelimiter {
+			body = next
+			break
+		}
+		if !more {
+			return FrontMatter{}, "", 0, &FrontMatterError{Name: name, Err: fmt.Errorf("front matter isn't closed with %q", frontMatterDelimiter)}
+		}
+		block = append(block, line)
+		rest = next
+	}
+
+	if len(block) > 0 {
+		decoder := yaml.NewDecoder(bytes.NewReader([]byte(strings.Join(block, "\n"))))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&fm); err != nil {
+			return FrontMatter{}, "", 0, &FrontMatterError{Name: name, Err: err}
+		}
+	}
+	if err := fm.validate(); err != nil {
+		return FrontMatter{}, "", 0, &FrontMatterError{Name: name, Err: err}
+	}
+	return fm, body, len(block) + 2, nil
+}
+
+// validate checks that the required variables are named and don't have
+// defaults, which would always satisfy them.
+func (fm *FrontMatter) validate() error {
+	for _, name := range fm.RequiredVars {
+		for _, part := range strings.Split(name, ".") {
+			if strings.TrimSpace(part) == "" {
+				return fmt.Errorf("invalid required variable %q", name)
+			}
+		}
+		if _, ok := fm.Defaults[name]; ok {
+			return fmt.Errorf("required variable %q can't have a default", name)
+		}
+	}
+	return nil
+}
+
+// ApplyVars returns a copy of ctx with the spell's defaults set for the
+// variables ctx doesn't set. A MissingVariablesError naming every required
+// variable still unset is returned if there are any. A variable set to nil
+// is unset.
+func (s *Spell) ApplyVars(ctx RenderContext) (RenderContext, error) {
+	vars := make(RenderContext, len(ctx)+len(s.Defaults))
+	for k, v := range ctx {
+		vars[k] = v
+	}
+	for k, v := range s.Defaults {
+		if vars[k] == nil {
+			vars[k] = v
+		}
+	}
+
+	var missing []string
+	for _, name := range s.RequiredVars {
+		if !hasVariable(vars, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &MissingVariablesError{Name: s.Name, Vars: missing}
+	}
+	return vars, nil
+}
+
+// hasVariable reports whether the dotted path is set in ctx, looking through
+// nested maps and struct fields as a template would.
+func hasVariable(ctx RenderContext, path string) bool {
+	v := reflect.ValueOf(map[string]interface{}(ctx))
+	for _, key := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return false
+			}
+			v = v.Elem()
+		}
+		switch {
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			v = v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+		case v.Kind() == reflect.Struct:
+			v = v.FieldByName(key)
+		default:
+			return false
+		}
+		if !v.IsValid() {
+			return false
+		}
+	}
+	return !((v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) && v.IsNil())
+}
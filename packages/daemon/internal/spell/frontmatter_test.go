@@ -0,0 +1,166 @@
+package spell
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	content := "---\nrequired_vars: [bead.title, test_output]\ndefaults:\n  tone: concise\n---\n# Fix {{.bead.title}}\n"
+
+	fm, body, lines, err := ParseFrontMatter("fix", content)
+	if err != nil {
+		t.Fatalf("ParseFrontMatter() error: %v", err)
+	}
+	if strings.Join(fm.RequiredVars, ",") != "bead.title,test_output" || fm.Defaults["tone"] != "concise" {
+		t.Errorf("front matter = %+v, want the required variables and default", fm)
+	}
+	if body != "# Fix {{.bead.title}}\n" || lines != 5 {
+		t.Errorf("body = %q after %d lines, want the template after 5 lines", body, lines)
+	}
+
+	// Content without front matter is returned whole
+	for _, content := range []string{"# Fix\n---\nmore", "---", "--- \n"} {
+		fm, body, lines, err := ParseFrontMatter("fix", content)
+		if err != nil || body != content || lines != 0 || fm.RequiredVars != nil {
+			t.Errorf("ParseFrontMatter(%q) = %+v, %q, %d, %v; want the content unchanged", content, fm, body, lines, err)
+		}
+	}
+}
+
+func TestParseFrontMatter_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"not closed", "---\nrequired_vars: [a]\n# Fix\n", "isn't closed"},
+		{"unknown key", "---\nrequired: [a]\n---\n", "field required not found"},
+		{"empty variable", "---\nrequired_vars: [bead.]\n---\n", `invalid required variable "bead."`},
+		{"required with default", "---\nrequired_vars: [tone]\ndefaults:\n  tone: concise\n---\n", `required variable "tone" can't have a default`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := ParseFrontMatter("fix", tt.content)
+			if !IsFrontMatterError(err) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseFrontMatter() error = %v, want a FrontMatterError containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoad_FrontMatter(t *testing.T) {
+	tmpDir := t.TempDir()
+	spellsDir := filepath.Join(tmpDir, "spells")
+	if err := os.MkdirAll(spellsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"fix.md":    "---\nrequired_vars: [test_output]\n---\nFix:\n{{.test_output}}\n",
+		"broken.md": "---\nrequired_vars: test_output\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(spellsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loader := NewLoaderWithBuiltins(tmpDir, nil, "spells")
+
+	spell, err := loader.Load("fix")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if spell.Content != "Fix:\n{{.test_output}}\n" || len(spell.RequiredVars) != 1 {
+		t.Errorf("Load() = %+v, want the front matter parsed and stripped", spell)
+	}
+
+	if _, err := loader.Load("broken"); err == nil || !strings.Contains(err.Error(), "invalid front matter") {
+		t.Errorf("Load() error = %v, want a front matter error", err)
+	}
+}
+
+func TestRenderer_Render_RequiredVars(t *testing.T) {
+	spell := &Spell{
+		Name:         "fix",
+		Content:      "Fix {{.bead.title}} ({{.tone}}): {{.test_output}}",
+		RequiredVars: []string{"test_output", "bead.title"},
+		Defaults:     map[string]interface{}{"tone": "concise"},
+	}
+	renderer := NewRendererWithOptions(RenderOptions{MissingKeyError: false})
+
+	_, err := renderer.Render(spell, RenderContext{"bead": map[string]interface{}{"id": "cv-1"}})
+	if !IsMissingVariables(err) {
+		t.Fatalf("Render() error = %v, want MissingVariablesError", err)
+	}
+	if got := err.Error(); !strings.Contains(got, `spell "fix"`) || !strings.Contains(got, "test_output, bead.title") {
+		t.Errorf("Render() error = %q, want the spell and missing variables named", got)
+	}
+
+	// A nil value doesn't count as set
+	_, err = renderer.Render(spell, RenderContext{"test_output": nil, "bead": map[string]interface{}{"title": "Login"}})
+	if !IsMissingVariables(err) || !strings.HasSuffix(err.Error(), ": test_output") {
+		t.Errorf("Render() error = %v, want test_output missing", err)
+	}
+
+	result, err := renderer.Render(spell, RenderContext{
+		"test_output": "",
+		"bead":        map[string]interface{}{"title": "Login"},
+	})
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if result != "Fix Login (concise): " {
+		t.Errorf("Render() = %q, want the default applied", result)
+	}
+
+	// The context's value wins over the default
+	result, err = renderer.Render(spell, RenderContext{
+		"test_output": "FAIL",
+		"tone":        "thorough",
+		"bead":        map[string]interface{}{"title": "Login"},
+	})
+	if err != nil || result != "Fix Login (thorough): FAIL" {
+		t.Errorf("Render() = %q, %v; want the context's tone", result, err)
+	}
+}
+
+func TestPartialRenderer_IncludeRequiredVars(t *testing.T) {
+	tmpDir := t.TempDir()
+	spellsDir := filepath.Join(tmpDir, "spells")
+	if err := os.MkdirAll(spellsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	partial := "---\nrequired_vars: [language]\ndefaults:\n  style: gofmt\n---\nWrite {{.language}}, formatted with {{.style}}."
+	if err := os.WriteFile(filepath.Join(spellsDir, "standards.md"), []byte(partial), 0644); err != nil {
+		t.Fatal(err)
+	}
+	renderer := NewPartialRenderer(NewLoaderWithBuiltins(tmpDir, nil, "spells"))
+	spell := &Spell{Name: "implement", Content: `{{include "standards"}}`}
+
+	_, err := renderer.Render(spell, nil)
+	if err == nil || !strings.Contains(err.Error(), `spell "standards" requires variables that aren't set: language`) {
+		t.Fatalf("Render() error = %v, want the partial's missing variable", err)
+	}
+
+	result, err := renderer.Render(spell, RenderContext{"language": "Go"})
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if result != "Write Go, formatted with gofmt." {
+		t.Errorf("Render() = %q, want the partial's default applied", result)
+	}
+}
+
+func TestValidate_FrontMatter(t *testing.T) {
+	result := Validate("fix", "---\nrequired_vars: [test_output]\n---\nFix:\n{{.test_output")
+	if result.Valid || len(result.Errors) != 1 || result.Errors[0].Line != 5 {
+		t.Errorf("Validate() = %+v, want the syntax error on line 5 of the file", result)
+	}
+
+	result = Validate("fix", "---\nrequired_vars: [a]\n")
+	if result.Valid || len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "isn't closed") {
+		t.Errorf("Validate() = %+v, want the front matter error", result)
+	}
+}
@@ -0,0 +1,99 @@
+package spell
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/coven/daemon/internal/api"
+	"github.com/coven/daemon/internal/bundle"
+)
+
+// Handlers provides HTTP handlers for spell management.
+type Handlers struct {
+	loader *Loader
+}
+
+// NewHandlers creates new spell handlers that install into covenDir.
+func NewHandlers(covenDir string) *Handlers {
+	return &Handlers{
+		loader: NewLoader(covenDir),
+	}
+}
+
+// Register registers spell handlers with the server.
+func (h *Handlers) Register(server *api.Server) {
+	server.RegisterHandlerFunc("/spells/install", h.handleInstall)
+	server.RegisterHandlerFunc("/spells/validate", h.handleValidate)
+}
+
+// ValidateRequest is the request body for POST /spells/validate.
+// When Content is empty, the installed spell with the given name is validated.
+type ValidateRequest struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// handleInstall handles POST /spells/install.
+// @Summary      Install spells
+// @Description  Validates a tarball of .md files or multi-document YAML bundle of spells and installs them into .coven/spells. If any spell is invalid, nothing is installed.
+// @Tags         spells
+// @Accept       application/x-tar,application/gzip,application/yaml
+// @Produce      json
+// @Success      200  {object}  bundle.InstallResponse  "All spells installed"
+// @Failure      400  {object}  map[string]string       "Unreadable bundle"
+// @Failure      405  {object}  map[string]string       "Method not allowed"
+// @Failure      422  {object}  bundle.InstallResponse  "One or more spells failed validation"
+// @Failure      500  {object}  map[string]string       "Failed to write spells"
+// @Router       /spells/install [post]
+func (h *Handlers) handleInstall(w http.ResponseWriter, r *http.Request) {
+	bundle.ServeInstall(w, r, h.loader.Install)
+}
+
+// handleValidate handles POST /spells/validate.
+// @Summary      Validate a spell template
+// @Description  Parses a spell template without rendering it and reports syntax errors with their line. Validates the given content, or the installed spell with the given name when no content is sent.
+// @Tags         spells
+// @Accept       json
+// @Produce      json
+// @Param        body body      ValidateRequest   true  "Spell to validate"
+// @Success      200  {object}  ValidationResult  "Validation result"
+// @Failure      400  {object}  map[string]string "Invalid request body"
+// @Failure      404  {object}  map[string]string "Spell not found"
+// @Failure      405  {object}  map[string]string "Method not allowed"
+// @Router       /spells/validate [post]
+func (h *Handlers) handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.WriteError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	if req.Content == "" {
+		if req.Name == "" {
+			api.WriteError(w, http.StatusBadRequest, "name or content is required")
+			return
+		}
+		// Validate the file as written, so front matter errors are reported
+		// and lines are counted from the top of the file
+		content, _, err := h.loader.ReadContent(req.Name)
+		if IsNotFound(err) {
+			api.WriteError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			api.WriteError(w, http.StatusInternalServerError, "failed to read spell: "+err.Error())
+			return
+		}
+		req.Content = content
+	}
+	if req.Name == "" {
+		req.Name = "spell"
+	}
+
+	api.WriteJSON(w, http.StatusOK, Validate(req.Name, req.Content))
+}
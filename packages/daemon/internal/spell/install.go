@@ -0,0 +1,114 @@
+package spell
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/coven/daemon/internal/bundle"
+	"gopkg.in/yaml.v3"
+)
+
+// bundleSpell is a spell entry in a multi-document YAML bundle.
+type bundleSpell struct {
+	Name    string `yaml:"name"`
+	Content string `yaml:"content"`
+}
+
+// Install validates a bundle of spells and writes them to the user's
+// .coven/spells/ directory. The bundle is either a tar archive (optionally
+// gzipped) of .md files named after each spell, or a multi-document YAML
+// stream where each document has a name and content.
+//
+// Every spell is validated before any are written. If one fails, nothing is
+// installed and an InvalidBundleError is returned alongside the per-item
+// results. Existing spells with the same name are replaced.
+func (l *Loader) Install(data []byte) ([]bundle.Result, error) {
+	items, err := readSpellBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, &bundle.FormatError{Err: fmt.Errorf("bundle contains no spells")}
+	}
+
+	results := make([]bundle.Result, len(items))
+	files := make([]bundle.File, 0, len(items))
+	seen := make(map[string]bool)
+	for i, item := range items {
+		err := validateBundleSpell(item.Name, string(item.Data))
+		if err == nil && seen[item.Name] {
+			err = fmt.Errorf("duplicate spell name %q in bundle", item.Name)
+		}
+		seen[item.Name] = true
+
+		results[i] = bundle.Result{Name: item.Name, Success: err == nil}
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		files = append(files, bundle.File{Name: item.Name + ".md", Data: item.Data})
+	}
+
+	if err := bundle.CheckResults(results); err != nil {
+		for i := range results {
+			if results[i].Success {
+				results[i].Success = false
+				results[i].Error = "not installed: other spells in the bundle are invalid"
+			}
+		}
+		return results, err
+	}
+
+	if err := bundle.WriteAll(filepath.Join(l.covenDir, "spells"), files); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// readSpellBundle extracts the spells from a bundle, named by spell name.
+func readSpellBundle(data []byte) ([]bundle.File, error) {
+	if bundle.IsArchive(data) {
+		files, err := bundle.ReadArchive(data, ".md")
+		if err != nil {
+			return nil, err
+		}
+		for i := range files {
+			files[i].Name = strings.TrimSuffix(files[i].Name, ".md")
+		}
+		return files, nil
+	}
+
+	docs, err := bundle.SplitYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]bundle.File, len(docs))
+	for i, doc := range docs {
+		var s bundleSpell
+		if err := yaml.Unmarshal(doc, &s); err != nil {
+			return nil, &bundle.FormatError{Err: fmt.Errorf("failed to parse spell document %d: %w", i+1, err)}
+		}
+		files[i] = bundle.File{Name: s.Name, Data: []byte(s.Content)}
+	}
+	return files, nil
+}
+
+// validateBundleSpell checks that a spell has a usable name and that its
+// front matter and template parse.
+func validateBundleSpell(name, content string) error {
+	if name == "" {
+		return fmt.Errorf("spell name cannot be empty")
+	}
+	if strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("spell name cannot contain path separators: %q", name)
+	}
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("spell %q has no content", name)
+	}
+	_, body, _, err := ParseFrontMatter(name, content)
+	if err != nil {
+		return err
+	}
+	return ParseTemplate(name, body)
+}
@@ -0,0 +1,131 @@
+package spell
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coven/daemon/internal/bundle"
+)
+
+// makeSpellTarball builds a tar archive from name/content pairs.
+func makeSpellTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("WriteHeader() error: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestInstall_Tarball(t *testing.T) {
+	tmpDir := t.TempDir()
+	loader := NewLoader(tmpDir)
+
+	data := makeSpellTarball(t, map[string]string{
+		"spells/review.md": "Review {{.task.title}}\n{{include \"checklist\"}}",
+		"spells/notes.txt": "ignored",
+	})
+
+	results, err := loader.Install(data)
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if len(results) != 1 || results[0].Name != "review" || !results[0].Success {
+		t.Fatalf("Install() results = %+v", results)
+	}
+
+	spell, err := loader.Load("review")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if spell.Source != SourceUser {
+		t.Errorf("Source = %q, want %q", spell.Source, SourceUser)
+	}
+}
+
+func TestInstall_MultiDocumentYAML(t *testing.T) {
+	tmpDir := t.TempDir()
+	loader := NewLoader(tmpDir)
+
+	data := `name: plan
+content: Plan {{.task.title}}
+---
+name: implement
+content: |
+  Implement {{.task.title}}
+`
+	results, err := loader.Install([]byte(data))
+	if err != nil {
+		t.Fatalf("Install() error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Install() returned %d results, want 2", len(results))
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "spells", "implement.md"))
+	if err != nil {
+		t.Fatalf("implement.md not installed: %v", err)
+	}
+	if string(content) != "Implement {{.task.title}}\n" {
+		t.Errorf("content = %q", content)
+	}
+}
+
+func TestInstall_InvalidSpellAbortsImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	loader := NewLoader(tmpDir)
+
+	data := makeSpellTarball(t, map[string]string{
+		"good.md":   "Hello {{.name}}",
+		"broken.md": "Hello {{.name",
+	})
+
+	results, err := loader.Install(data)
+	if !bundle.IsInvalidBundle(err) {
+		t.Fatalf("Install() error = %v, want InvalidBundleError", err)
+	}
+	for _, r := range results {
+		if r.Success {
+			t.Errorf("result %q should not be successful", r.Name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "spells")); !os.IsNotExist(err) {
+		t.Error("spells directory should not be created when the bundle is invalid")
+	}
+}
+
+func TestValidateBundleSpell(t *testing.T) {
+	tests := []struct {
+		name    string
+		spell   string
+		content string
+		wantErr bool
+	}{
+		{"valid", "plan", "Plan {{.task.title}}", false},
+		{"empty name", "", "content", true},
+		{"path separator", "../plan", "content", true},
+		{"empty content", "plan", "  \n", true},
+		{"bad template", "plan", "{{if}}", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBundleSpell(tt.spell, tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateBundleSpell() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package spell
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath returns the value at path in data, which is JSON text, such as a
+// step's output, or already decoded data, such as a step's outputs:
+//
+//	{{ .review.output | jsonpath "$.findings[0].file" }}
+//	{{ jsonpath "files" .implement.outputs | join " " }}
+//
+// Paths are a subset of JSONPath: an optional "$" followed by ".key",
+// "['key']" and "[index]" selectors, where a negative index counts from the
+// end. The leading "." may be left out. A path that selects nothing returns
+// "", so it can be piped to default. Numbers keep the digits they were
+// written with.
+func jsonPath(path string, data interface{}) (interface{}, error) {
+	selectors, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if text, ok := data.(string); ok {
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return nil, fmt.Errorf("jsonpath %q: input isn't JSON: %w", path, err)
+		}
+	}
+
+	current := data
+	for _, sel := range selectors {
+		next, ok := selectJSON(current, sel)
+		if !ok {
+			return "", nil
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// jsonSelector is one step of a JSON path: a key, or an index if isIndex is
+// set.
+type jsonSelector struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath splits a path such as "$.items[0]['a key']" into selectors.
+func parseJSONPath(path string) ([]jsonSelector, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	if rest != "" && rest[0] != '.' && rest[0] != '[' {
+		rest = "." + rest
+	}
+
+	var selectors []jsonSelector
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("jsonpath %q: empty key", path)
+			}
+			selectors = append(selectors, jsonSelector{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("jsonpath %q: unclosed [", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				selectors = append(selectors, jsonSelector{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath %q: invalid index %q", path, inner)
+			}
+			selectors = append(selectors, jsonSelector{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return selectors, nil
+}
+
+// selectJSON applies one selector to a decoded value, reporting whether it
+// selected anything.
+func selectJSON(value interface{}, sel jsonSelector) (interface{}, bool) {
+	if sel.isIndex {
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case []string:
+			for _, s := range v {
+				items = append(items, s)
+			}
+		default:
+			return nil, false
+		}
+		index := sel.index
+		if index < 0 {
+			index += len(items)
+		}
+		if index < 0 || index >= len(items) {
+			return nil, false
+		}
+		return items[index], true
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		next, ok := v[sel.key]
+		return next, ok
+	case RenderContext:
+		next, ok := v[sel.key]
+		return next, ok
+	case map[string]string:
+		next, ok := v[sel.key]
+		return next, ok
+	}
+	return nil, false
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -14,9 +15,14 @@ type Spell struct {
 	// Name is the spell identifier (e.g., "implement").
 	Name string
 
-	// Content is the raw template content.
+	// Content is the template content, without the front matter.
 	Content string
 
+	// RequiredVars and Defaults are declared in the spell's front matter.
+	// See FrontMatter.
+	RequiredVars []string
+	Defaults     map[string]interface{}
+
 	// Source indicates where the spell was loaded from.
 	Source SpellSource
 }
@@ -76,70 +82,105 @@ func NewLoaderWithBuiltins(covenDir string, builtinFS fs.FS, spellsSubdir string
 
 // Load loads a spell by name.
 // It first looks in the user's .coven/spells/ directory, then falls back to built-in spells.
+// Spells in subdirectories are named by their slash-separated path, such as
+// "common/standards" for common/standards.md.
 // Returns an error if the spell is not found in either location.
 func (l *Loader) Load(name string) (*Spell, error) {
-	// Validate spell name
-	if name == "" {
-		return nil, fmt.Errorf("spell name cannot be empty")
+	content, source, err := l.ReadContent(name)
+	if err != nil {
+		return nil, err
 	}
-	if strings.ContainsAny(name, "/\\") {
-		return nil, fmt.Errorf("spell name cannot contain path separators: %q", name)
+
+	spell, err := newSpell(name, content, source)
+	if err != nil {
+		if source == SourceUser {
+			return nil, fmt.Errorf("failed to load user spell %q: %w", name, err)
+		}
+		return nil, fmt.Errorf("failed to load builtin spell %q: %w", name, err)
+	}
+	return spell, nil
+}
+
+// ReadContent returns the file content of a spell, front matter included, found
+// as Load finds it, and where it was found. The content isn't parsed, so a
+// spell whose front matter is invalid can still be read and validated.
+func (l *Loader) ReadContent(name string) (string, SpellSource, error) {
+	if err := validateName(name); err != nil {
+		return "", "", err
 	}
 
 	// Try user spells first
-	spell, err := l.loadUserSpell(name)
+	content, err := l.readUserSpell(name)
 	if err == nil {
-		return spell, nil
+		return content, SourceUser, nil
 	}
 	if !os.IsNotExist(err) && !isNotExistError(err) {
-		return nil, fmt.Errorf("failed to load user spell %q: %w", name, err)
+		return "", "", fmt.Errorf("failed to load user spell %q: %w", name, err)
 	}
 
 	// Fall back to built-in spells
-	spell, err = l.loadBuiltinSpell(name)
+	content, err = l.readBuiltinSpell(name)
 	if err == nil {
-		return spell, nil
+		return content, SourceBuiltIn, nil
 	}
 	if !isNotExistError(err) {
-		return nil, fmt.Errorf("failed to load builtin spell %q: %w", name, err)
+		return "", "", fmt.Errorf("failed to load builtin spell %q: %w", name, err)
 	}
 
-	return nil, &SpellNotFoundError{Name: name}
+	return "", "", &SpellNotFoundError{Name: name}
+}
+
+// validateName checks that a spell name is a slash-separated path inside the
+// spells directory.
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("spell name cannot be empty")
+	}
+	if strings.Contains(name, "\\") || !fs.ValidPath(name) {
+		return fmt.Errorf("invalid spell name %q: must be a relative path such as \"common/standards\" without . or .. elements", name)
+	}
+	return nil
 }
 
-// loadUserSpell loads a spell from the user's .coven/spells/ directory.
-func (l *Loader) loadUserSpell(name string) (*Spell, error) {
-	spellPath := filepath.Join(l.covenDir, "spells", name+".md")
+// readUserSpell reads a spell from the user's .coven/spells/ directory.
+func (l *Loader) readUserSpell(name string) (string, error) {
+	spellPath := filepath.Join(l.covenDir, "spells", filepath.FromSlash(name)+".md")
 
 	content, err := os.ReadFile(spellPath)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	return &Spell{
-		Name:    name,
-		Content: string(content),
-		Source:  SourceUser,
-	}, nil
+	return string(content), nil
 }
 
-// loadBuiltinSpell loads a spell from the embedded built-in spells.
-func (l *Loader) loadBuiltinSpell(name string) (*Spell, error) {
+// readBuiltinSpell reads a spell from the embedded built-in spells.
+func (l *Loader) readBuiltinSpell(name string) (string, error) {
 	if l.builtinFS == nil {
-		return nil, fs.ErrNotExist
+		return "", fs.ErrNotExist
 	}
 
-	spellPath := filepath.Join(l.spellsSubdir, name+".md")
+	spellPath := path.Join(l.spellsSubdir, name+".md")
 
 	content, err := fs.ReadFile(l.builtinFS, spellPath)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	return string(content), nil
+}
 
+// newSpell returns the spell with the given file content, with its front
+// matter parsed and stripped.
+func newSpell(name, content string, source SpellSource) (*Spell, error) {
+	fm, body, _, err := ParseFrontMatter(name, content)
+	if err != nil {
+		return nil, err
+	}
 	return &Spell{
-		Name:    name,
-		Content: string(content),
-		Source:  SourceBuiltIn,
+		Name:         name,
+		Content:      body,
+		RequiredVars: fm.RequiredVars,
+		Defaults:     fm.Defaults,
+		Source:       source,
 	}, nil
 }
 
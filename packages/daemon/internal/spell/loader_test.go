@@ -151,13 +151,15 @@ func TestLoad_EmptyName(t *testing.T) {
 	}
 }
 
-func TestLoad_InvalidName_PathSeparator(t *testing.T) {
+func TestLoad_InvalidName(t *testing.T) {
 	loader := NewLoader("/tmp")
 
 	tests := []string{
-		"foo/bar",
 		"foo\\bar",
 		"../escape",
+		"common/../../escape",
+		"./implement",
+		"common//standards",
 		"/absolute",
 	}
 
@@ -165,12 +167,44 @@ func TestLoad_InvalidName_PathSeparator(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			_, err := loader.Load(name)
 			if err == nil {
-				t.Fatal("Load() should return error for name with path separator")
+				t.Fatal("Load() should return error for a name outside the spells directory")
 			}
 		})
 	}
 }
 
+func TestLoad_Subdirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	commonDir := filepath.Join(tmpDir, "spells", "common")
+	if err := os.MkdirAll(commonDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(commonDir, "standards.md"), []byte("User standards"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	builtinFS := fstest.MapFS{
+		"spells/common/standards.md": &fstest.MapFile{Data: []byte("Built-in standards")},
+		"spells/common/commits.md":   &fstest.MapFile{Data: []byte("Built-in commit format")},
+	}
+	loader := NewLoaderWithBuiltins(tmpDir, builtinFS, "spells")
+
+	spell, err := loader.Load("common/standards")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if spell.Content != "User standards" || spell.Source != SourceUser || spell.Name != "common/standards" {
+		t.Errorf("Load() = %+v, want the user spell overriding the built-in one", spell)
+	}
+
+	spell, err = loader.Load("common/commits")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if spell.Content != "Built-in commit format" || spell.Source != SourceBuiltIn {
+		t.Errorf("Load() = %+v, want the built-in spell", spell)
+	}
+}
+
 func TestLoad_NoBuiltins(t *testing.T) {
 	tmpDir := t.TempDir()
 
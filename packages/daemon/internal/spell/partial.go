@@ -75,6 +75,10 @@ func (r *PartialRenderer) Render(spell *Spell, ctx RenderContext) (string, error
 		return "", fmt.Errorf("spell cannot be nil")
 	}
 
+	ctx, err := spell.ApplyVars(ctx)
+	if err != nil {
+		return "", err
+	}
 	return r.renderWithIncludes(spell.Name, spell.Content, ctx, nil)
 }
 
@@ -119,7 +123,7 @@ func (r *PartialRenderer) renderWithIncludes(name, content string, ctx RenderCon
 	}
 
 	// Add custom template functions including 'include'
-	funcs := templateFuncs()
+	funcs := r.options.templateFuncs()
 	funcs["include"] = r.makeIncludeFunc(ctx, append(stack, name))
 	tmpl = tmpl.Funcs(funcs)
 
@@ -189,7 +193,14 @@ func (r *PartialRenderer) makeIncludeFunc(parentCtx RenderContext, stack []strin
 			}
 		}
 
-		// Render the partial with the merged context
+		// Render the partial with the merged context and its own defaults
+		includeCtx, err = partial.ApplyVars(includeCtx)
+		if err != nil {
+			return "", &IncludeError{
+				PartialName: partialName,
+				Err:         err,
+			}
+		}
 		result, err := r.renderWithIncludes(partialName, partial.Content, includeCtx, stack)
 		if err != nil {
 			return "", &IncludeError{
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestPartialRenderer_Include(t *testing.T) {
@@ -40,6 +41,38 @@ func TestPartialRenderer_Include(t *testing.T) {
 	}
 }
 
+func TestPartialRenderer_IncludeSubdirectory(t *testing.T) {
+	tmpDir := t.TempDir()
+	commonDir := filepath.Join(tmpDir, "spells", "common")
+	if err := os.MkdirAll(commonDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// The user partial includes a built-in one, and sees the same context
+	if err := os.WriteFile(filepath.Join(commonDir, "standards.md"), []byte(`Standards for {{.task.title}}. {{include "common/commits"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	builtinFS := fstest.MapFS{
+		"spells/common/commits.md": &fstest.MapFile{Data: []byte("Commits start with {{.task.id}}.")},
+	}
+	renderer := NewPartialRenderer(NewLoaderWithBuiltins(tmpDir, builtinFS, "spells"))
+
+	spell := &Spell{
+		Name:    "implement",
+		Content: `{{include "common/standards"}}`,
+	}
+	ctx := RenderContext{"task": map[string]interface{}{"id": "cv-1", "title": "Fix login"}}
+
+	result, err := renderer.Render(spell, ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Standards for Fix login. Commits start with cv-1."
+	if result != expected {
+		t.Errorf("expected %q, got %q", expected, result)
+	}
+}
+
 func TestPartialRenderer_IncludeWithContextVar(t *testing.T) {
 	tmpDir := t.TempDir()
 	spellsDir := filepath.Join(tmpDir, "spells")
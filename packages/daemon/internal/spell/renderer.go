@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"text/template"
+	"time"
 )
 
 // RenderContext contains the data available during template rendering.
@@ -22,6 +23,19 @@ type RenderOptions struct {
 	// When true (default), accessing a missing key returns an error.
 	// When false, missing keys are replaced with an empty string.
 	MissingKeyError bool
+
+	// Clock returns the current time for the now template function. When
+	// nil, time.Now is used.
+	Clock func() time.Time
+}
+
+// templateFuncs returns the template functions for rendering with these
+// options.
+func (o RenderOptions) templateFuncs() template.FuncMap {
+	if o.Clock == nil {
+		return templateFuncs(time.Now)
+	}
+	return templateFuncs(o.Clock)
 }
 
 // DefaultRenderOptions returns the default rendering options.
@@ -48,11 +62,17 @@ func NewRendererWithOptions(opts RenderOptions) *Renderer {
 // Render renders a spell template with the provided context.
 // The context map is available as the root object in templates.
 // Example: {{.taskTitle}} accesses context["taskTitle"]
+// The spell's defaults are applied first, and a MissingVariablesError is
+// returned if the context lacks any of its required variables.
 func (r *Renderer) Render(spell *Spell, ctx RenderContext) (string, error) {
 	if spell == nil {
 		return "", fmt.Errorf("spell cannot be nil")
 	}
 
+	ctx, err := spell.ApplyVars(ctx)
+	if err != nil {
+		return "", err
+	}
 	return r.RenderString(spell.Name, spell.Content, ctx)
 }
 
@@ -74,7 +94,7 @@ func (r *Renderer) RenderString(name, content string, ctx RenderContext) (string
 	}
 
 	// Add custom template functions
-	tmpl = tmpl.Funcs(templateFuncs())
+	tmpl = tmpl.Funcs(r.options.templateFuncs())
 
 	// Parse the template
 	parsed, err := tmpl.Parse(content)
@@ -98,9 +118,16 @@ func (r *Renderer) RenderString(name, content string, ctx RenderContext) (string
 	return buf.String(), nil
 }
 
-// templateFuncs returns custom template functions available in spells.
-func templateFuncs() template.FuncMap {
-	return template.FuncMap{
+// TemplateFuncs returns the template functions available in spells. They are
+// also available in step commands; see workflow.RenderCommand.
+func TemplateFuncs() template.FuncMap {
+	return templateFuncs(time.Now)
+}
+
+// templateFuncs returns the template functions, with now reading the time
+// from clock.
+func templateFuncs(clock func() time.Time) template.FuncMap {
+	funcs := template.FuncMap{
 		// default returns the default value if the given value is empty.
 		"default": func(defaultVal, val interface{}) interface{} {
 			if val == nil {
@@ -137,6 +164,24 @@ func templateFuncs() template.FuncMap {
 		// trim removes leading and trailing whitespace.
 		"trim": strings.TrimSpace,
 
+		// truncate shortens a value's text to at most n characters.
+		"truncate": func(n int, val interface{}) (string, error) {
+			if n < 0 {
+				return "", fmt.Errorf("truncate length must be non-negative, got %d", n)
+			}
+			if val == nil {
+				return "", nil
+			}
+			runes := []rune(fmt.Sprint(val))
+			if len(runes) <= n {
+				return string(runes), nil
+			}
+			return string(runes[:n]), nil
+		},
+
+		// jsonpath selects a value from JSON text or decoded data.
+		"jsonpath": jsonPath,
+
 		// indent adds a prefix to each line.
 		"indent": func(spaces int, s string) string {
 			prefix := strings.Repeat(" ", spaces)
@@ -154,6 +199,10 @@ func templateFuncs() template.FuncMap {
 			return fmt.Sprintf("%q", s)
 		},
 	}
+	for name, fn := range timeFuncs(clock) {
+		funcs[name] = fn
+	}
+	return funcs
 }
 
 // TemplateParseError is returned when a template fails to parse.
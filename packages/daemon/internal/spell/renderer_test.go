@@ -1,8 +1,10 @@
 package spell
 
 import (
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewRenderer(t *testing.T) {
@@ -341,6 +343,141 @@ func TestRender_TemplateFunctions_StringOps(t *testing.T) {
 	}
 }
 
+func TestRender_TemplateFunctions_Truncate(t *testing.T) {
+	r := NewRenderer()
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"shorter", `{{ .title | truncate 5 }}`, "Fix l"},
+		{"longer than the text", `{{ truncate 50 .title }}`, "Fix login ✓"},
+		{"counts characters", `{{ truncate 11 .title }}`, "Fix login ✓"},
+		{"zero", `[{{ truncate 0 .title }}]`, "[]"},
+		{"number", `{{ truncate 2 .count }}`, "12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := r.RenderString("test", tt.template, RenderContext{"title": "Fix login ✓", "count": 12345})
+			if err != nil {
+				t.Fatalf("RenderString() error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Result = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := r.RenderString("test", `{{ truncate -1 .title }}`, RenderContext{"title": "x"}); !IsRenderError(err) {
+		t.Errorf("RenderString() with a negative length error = %v, want a render error", err)
+	}
+}
+
+func TestRender_TemplateFunctions_JSONPath(t *testing.T) {
+	r := NewRenderer()
+	ctx := RenderContext{
+		"output": `{"summary": "2 issues", "count": 1234567890123, "findings": [{"file": "a.go"}, {"file": "b.go", "line": 7}], "a key": true}`,
+		"outputs": map[string]interface{}{
+			"files": []interface{}{"a.go", "b.go"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"key", `{{ .output | jsonpath "$.summary" }}`, "2 issues"},
+		{"without $", `{{ jsonpath "findings[1].line" .output }}`, "7"},
+		{"negative index", `{{ .output | jsonpath "$.findings[-1].file" }}`, "b.go"},
+		{"quoted key", `{{ .output | jsonpath "$['a key']" }}`, "true"},
+		{"large number", `{{ .output | jsonpath "$.count" }}`, "1234567890123"},
+		{"missing", `[{{ .output | jsonpath "$.findings[5].file" }}]`, "[]"},
+		{"missing with default", `{{ .output | jsonpath "$.title" | default "untitled" }}`, "untitled"},
+		{"decoded data", `{{ .outputs | jsonpath "$.files" | join " " }}`, "a.go b.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := r.RenderString("test", tt.template, ctx)
+			if err != nil {
+				t.Fatalf("RenderString() error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Result = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+
+	for _, template := range []string{
+		`{{ jsonpath "$.a" "not json" }}`,
+		`{{ jsonpath "$.findings[x]" .output }}`,
+		`{{ jsonpath "$.findings[0" .output }}`,
+		`{{ jsonpath "$..file" .output }}`,
+	} {
+		if _, err := r.RenderString("test", template, ctx); !IsRenderError(err) {
+			t.Errorf("RenderString(%s) error = %v, want a render error", template, err)
+		}
+	}
+}
+
+func TestRender_TemplateFunctions_Time(t *testing.T) {
+	r := NewRenderer()
+
+	result, err := r.RenderString("test", `{{ now | date "2006" }}`, nil)
+	if err != nil {
+		t.Fatalf("RenderString() error: %v", err)
+	}
+	if want := strconv.Itoa(time.Now().Year()); result != want {
+		t.Errorf("Result = %q, want %q", result, want)
+	}
+
+	fixed := time.Date(2024, time.March, 1, 9, 30, 0, 0, time.UTC)
+	opts := RenderOptions{
+		MissingKeyError: true,
+		Clock:           func() time.Time { return fixed },
+	}
+	r = NewRendererWithOptions(opts)
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"date", `{{ now | date "2006-01-02" }}`, "2024-03-01"},
+		{"add", `{{ now | dateAdd "36h" | date "Jan 2 15:04" }}`, "Mar 2 21:30"},
+		{"subtract", `{{ now | dateAdd "-24h" | date "2006-01-02" }}`, "2024-02-29"},
+		{"duration", `{{ duration "90m" }}`, "1h30m0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := r.RenderString("test", tt.template, nil)
+			if err != nil {
+				t.Fatalf("RenderString() error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Result = %q, want %q", result, tt.expected)
+			}
+		})
+	}
+
+	if _, err := r.RenderString("test", `{{ now | dateAdd "soon" }}`, nil); !IsRenderError(err) {
+		t.Errorf("RenderString() with an invalid duration error = %v, want a render error", err)
+	}
+
+	// Renderers with includes read the same clock
+	result, err = NewPartialRendererWithOptions(nil, opts).RenderString("test", `{{ now | date "2006-01-02" }}`, nil)
+	if err != nil {
+		t.Fatalf("RenderString() error: %v", err)
+	}
+	if result != "2024-03-01" {
+		t.Errorf("Result = %q, want %q", result, "2024-03-01")
+	}
+}
+
 func TestRender_TemplateFunctions_Indent(t *testing.T) {
 	r := NewRenderer()
 	spell := &Spell{
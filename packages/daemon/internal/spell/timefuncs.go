@@ -0,0 +1,39 @@
+package spell
+
+import (
+	"text/template"
+	"time"
+)
+
+// timeFuncs returns the date and time template functions, with now reading
+// the time from clock:
+//
+//	{{ now | date "2006-01-02" }}               today's date
+//	{{ now | dateAdd "-24h" | date "Jan 2" }}   yesterday
+//
+// Durations use Go's syntax, as in "90m" or "168h".
+func timeFuncs(clock func() time.Time) template.FuncMap {
+	return template.FuncMap{
+		// now returns the current time.
+		"now": func() time.Time {
+			return clock()
+		},
+
+		// date formats a time with a Go reference-time layout.
+		"date": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+
+		// dateAdd adds a duration, which may be negative, to a time.
+		"dateAdd": func(duration string, t time.Time) (time.Time, error) {
+			d, err := time.ParseDuration(duration)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return t.Add(d), nil
+		},
+
+		// duration parses a duration, so it can be compared or printed.
+		"duration": time.ParseDuration,
+	}
+}
@@ -0,0 +1,77 @@
+package spell
+
+import (
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// SyntaxError is a template syntax error found while validating a spell.
+type SyntaxError struct {
+	// Line is the 1-based line of the error, or 0 if it is unknown.
+	Line int `json:"line,omitempty"`
+
+	// Message describes the error, without the template name and position.
+	Message string `json:"message"`
+}
+
+// ValidationResult is the result of validating a spell template.
+type ValidationResult struct {
+	Name   string        `json:"name"`
+	Valid  bool          `json:"valid"`
+	Errors []SyntaxError `json:"errors,omitempty"`
+}
+
+// ParseTemplate parses spell content as a template without rendering it, so
+// no context is needed. Partials are resolved at render time, so include
+// calls are accepted without checking the partial exists. A
+// TemplateParseError is returned when the content doesn't parse.
+func ParseTemplate(name, content string) error {
+	funcs := TemplateFuncs()
+	funcs["include"] = func(args ...interface{}) (string, error) { return "", nil }
+	if _, err := template.New(name).Funcs(funcs).Parse(content); err != nil {
+		return &TemplateParseError{Name: name, Content: content, Err: err}
+	}
+	return nil
+}
+
+// Validate parses spell content and reports any syntax error with its position.
+// Content may start with front matter, which is checked too; template lines
+// are counted from the start of the content, front matter included.
+func Validate(name, content string) *ValidationResult {
+	result := &ValidationResult{Name: name, Valid: true}
+	_, body, lines, err := ParseFrontMatter(name, content)
+	if err != nil {
+		result.Valid = false
+		result.Errors = append(result.Errors, SyntaxError{Message: err.(*FrontMatterError).Err.Error()})
+		return result
+	}
+	if err := ParseTemplate(name, body); err != nil {
+		syntaxErr := err.(*TemplateParseError).SyntaxError()
+		if syntaxErr.Line > 0 {
+			syntaxErr.Line += lines
+		}
+		result.Valid = false
+		result.Errors = append(result.Errors, syntaxErr)
+	}
+	return result
+}
+
+// SyntaxError extracts the line and message from the template parser error,
+// which has the form "template: <name>:<line>: <message>".
+func (e *TemplateParseError) SyntaxError() SyntaxError {
+	msg := e.Err.Error()
+	rest, ok := strings.CutPrefix(msg, "template: "+e.Name+":")
+	if !ok {
+		return SyntaxError{Message: msg}
+	}
+	lineStr, detail, ok := strings.Cut(rest, ": ")
+	if !ok {
+		return SyntaxError{Message: msg}
+	}
+	line, err := strconv.Atoi(lineStr)
+	if err != nil {
+		return SyntaxError{Message: msg}
+	}
+	return SyntaxError{Line: line, Message: detail}
+}
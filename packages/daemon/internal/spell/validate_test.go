@@ -0,0 +1,175 @@
+package spell
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/api"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantLine int
+		wantMsg  string
+	}{
+		{"unclosed action", "Hello {{.name", 1, "unclosed action"},
+		{"unknown function", "line one\n{{ shout .name }}", 2, `function "shout" not defined`},
+		{"unexpected end", "{{if .ok}}\nyes\n{{end}}\n{{end}}", 4, "unexpected {{end}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Validate("draft", tt.content)
+			if result.Valid {
+				t.Fatal("Validate() should report malformed template as invalid")
+			}
+			if len(result.Errors) != 1 {
+				t.Fatalf("Errors = %+v, want one error", result.Errors)
+			}
+			if result.Errors[0].Line != tt.wantLine {
+				t.Errorf("Line = %d, want %d", result.Errors[0].Line, tt.wantLine)
+			}
+			if !strings.Contains(result.Errors[0].Message, tt.wantMsg) {
+				t.Errorf("Message = %q, want it to contain %q", result.Errors[0].Message, tt.wantMsg)
+			}
+		})
+	}
+
+	t.Run("valid template", func(t *testing.T) {
+		result := Validate("draft", `Fix {{.bead.title}} {{include "footer.md"}} {{default "x" .missing}}`)
+		if !result.Valid || len(result.Errors) != 0 {
+			t.Errorf("Validate() = %+v, want valid", result)
+		}
+	})
+}
+
+func setupTestSpellHandlers(t *testing.T) (*http.Client, string, func()) {
+	t.Helper()
+	covenDir := t.TempDir()
+
+	socketPath := filepath.Join(os.TempDir(), "coven-spell-test-"+time.Now().Format("150405.000")+".sock")
+	server := api.NewServer(socketPath)
+	NewHandlers(covenDir).Register(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+	return client, covenDir, func() { server.Stop(context.Background()) }
+}
+
+func TestHandleValidate(t *testing.T) {
+	client, covenDir, cleanup := setupTestSpellHandlers(t)
+	defer cleanup()
+
+	post := func(t *testing.T, body string) (*http.Response, ValidationResult) {
+		t.Helper()
+		resp, err := client.Post("http://unix/spells/validate", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var result ValidationResult
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return resp, result
+	}
+
+	t.Run("malformed content", func(t *testing.T) {
+		resp, result := post(t, `{"name": "draft", "content": "Hello {{.broken"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if result.Valid || len(result.Errors) != 1 {
+			t.Fatalf("Result = %+v, want one error", result)
+		}
+		if result.Errors[0].Line != 1 || result.Errors[0].Message != "unclosed action" {
+			t.Errorf("Error = %+v, want line 1 unclosed action", result.Errors[0])
+		}
+	})
+
+	t.Run("installed spell", func(t *testing.T) {
+		spellsDir := filepath.Join(covenDir, "spells")
+		os.MkdirAll(spellsDir, 0755)
+		os.WriteFile(filepath.Join(spellsDir, "custom.md"), []byte("{{if .ok}}\nno end"), 0644)
+
+		resp, result := post(t, `{"name": "custom"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if result.Valid || result.Name != "custom" {
+			t.Errorf("Result = %+v, want invalid custom spell", result)
+		}
+	})
+
+	t.Run("installed spell with invalid front matter", func(t *testing.T) {
+		spellsDir := filepath.Join(covenDir, "spells")
+		os.MkdirAll(spellsDir, 0755)
+		os.WriteFile(filepath.Join(spellsDir, "broken.md"), []byte("---\nrequired_vars: [unclosed\n---\nHello"), 0644)
+
+		resp, result := post(t, `{"name": "broken"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if result.Valid || len(result.Errors) == 0 {
+			t.Errorf("Result = %+v, want the front matter error", result)
+		}
+	})
+
+	t.Run("installed spell lines count front matter", func(t *testing.T) {
+		spellsDir := filepath.Join(covenDir, "spells")
+		os.MkdirAll(spellsDir, 0755)
+		os.WriteFile(filepath.Join(spellsDir, "framed.md"), []byte("---\nrequired_vars: [title]\n---\nHello\n{{.broken"), 0644)
+
+		resp, result := post(t, `{"name": "framed"}`)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if result.Valid || len(result.Errors) != 1 || result.Errors[0].Line != 5 {
+			t.Errorf("Result = %+v, want one error on line 5", result)
+		}
+	})
+
+	t.Run("unknown spell", func(t *testing.T) {
+		resp, _ := post(t, `{"name": "nonexistent"}`)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+		}
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		resp, _ := post(t, `not json`)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		resp, err := client.Get("http://unix/spells/validate")
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("Status = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -276,14 +276,16 @@ func (s *Store) GetLastTaskSync() *time.Time {
 	return s.state.LastTaskSync
 }
 
-// UpdateTaskStatus updates the status of a specific task.
-func (s *Store) UpdateTaskStatus(taskID string, status types.TaskStatus) {
+// UpdateTaskStatus updates the status of a specific task, recording why it
+// changed.
+func (s *Store) UpdateTaskStatus(taskID string, status types.TaskStatus, reason types.TaskStatusReason) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for i := range s.state.Tasks {
 		if s.state.Tasks[i].ID == taskID {
 			s.state.Tasks[i].Status = status
+			s.state.Tasks[i].StatusReason = reason
 			s.state.Tasks[i].UpdatedAt = time.Now()
 			s.dirty = true
 			return
@@ -0,0 +1,110 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// agentProgressInterval is how often the watchdog forwards agent progress to
+// the step context and checks for a stall.
+const agentProgressInterval = 10 * time.Second
+
+// errAgentStalled is the cancellation cause of an agent step that went longer
+// than its stall timeout without producing output.
+var errAgentStalled = errors.New("agent stalled")
+
+// ProgressAgentRunner is an AgentRunner that can report when a running agent
+// makes progress. Agent steps with a stall_timeout require one.
+type ProgressAgentRunner interface {
+	AgentRunner
+
+	// WatchProgress calls onProgress each time the agent process tracked by
+	// stepTaskID produces output, with the time of its latest output, until
+	// the returned stop function is called.
+	WatchProgress(stepTaskID string, onProgress func(at time.Time)) (stop func())
+}
+
+// agentWatchdog tracks the last progress of a running agent step. It records
+// progress on the step context and, if stall is set, cancels the step once it
+// has gone longer than stall without progress.
+type agentWatchdog struct {
+	stall    time.Duration
+	cancel   context.CancelCauseFunc
+	stepCtx  *StepContext
+	last     atomic.Int64 // UnixNano of the last progress
+	reported int64        // last progress forwarded to stepCtx, owned by run
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// startAgentWatchdog starts watching a step that started at start.
+func startAgentWatchdog(stall time.Duration, cancel context.CancelCauseFunc, stepCtx *StepContext, start time.Time) *agentWatchdog {
+	w := &agentWatchdog{
+		stall:    stall,
+		cancel:   cancel,
+		stepCtx:  stepCtx,
+		reported: start.UnixNano(),
+		done:     make(chan struct{}),
+	}
+	w.last.Store(start.UnixNano())
+
+	interval := agentProgressInterval
+	if stall > 0 && stall/4 < interval {
+		interval = stall / 4
+	}
+
+	w.wg.Add(1)
+	go w.run(interval)
+	return w
+}
+
+// progress records that the agent produced output at at.
+func (w *agentWatchdog) progress(at time.Time) {
+	n := at.UnixNano()
+	for {
+		old := w.last.Load()
+		if n <= old || w.last.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+// stop stops the watchdog and forwards any progress it hadn't reported yet.
+func (w *agentWatchdog) stop() {
+	close(w.done)
+	w.wg.Wait()
+	w.report()
+}
+
+func (w *agentWatchdog) run(interval time.Duration) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case now := <-ticker.C:
+			w.report()
+			if w.stall > 0 && now.Sub(time.Unix(0, w.last.Load())) > w.stall {
+				w.cancel(errAgentStalled)
+				return
+			}
+		}
+	}
+}
+
+// report forwards the latest progress to the step context if it changed.
+func (w *agentWatchdog) report() {
+	last := w.last.Load()
+	if last == w.reported {
+		return
+	}
+	w.reported = last
+	w.stepCtx.RecordProgress(time.Unix(0, last))
+}
@@ -0,0 +1,152 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// progressMockRunner is a MockAgentRunner that reports progress every
+// ProgressEvery while it runs, or never if ProgressEvery is zero.
+type progressMockRunner struct {
+	MockAgentRunner
+	ProgressEvery time.Duration
+
+	mu         sync.Mutex
+	onProgress func(at time.Time)
+	stopped    bool
+}
+
+func (m *progressMockRunner) Run(ctx context.Context, workDir, prompt string, onSpawn func(stepTaskID string)) (*AgentRunResult, error) {
+	if onSpawn != nil {
+		onSpawn(m.stepTaskID())
+	}
+
+	var tick <-chan time.Time
+	if m.ProgressEvery > 0 {
+		ticker := time.NewTicker(m.ProgressEvery)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	finished := time.After(m.Delay)
+	for {
+		select {
+		case <-finished:
+			return &AgentRunResult{Output: m.Output, ExitCode: m.ExitCode, StepTaskID: m.stepTaskID()}, nil
+		case <-ctx.Done():
+			return &AgentRunResult{Output: m.Output, ExitCode: -1, StepTaskID: m.stepTaskID()}, ctx.Err()
+		case at := <-tick:
+			m.mu.Lock()
+			if m.onProgress != nil {
+				m.onProgress(at)
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+func (m *progressMockRunner) WatchProgress(stepTaskID string, onProgress func(at time.Time)) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onProgress = onProgress
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.onProgress = nil
+		m.stopped = true
+	}
+}
+
+func TestAgentExecutor_Execute_StallTimeout(t *testing.T) {
+	tests := []struct {
+		name          string
+		progressEvery time.Duration
+		wantSuccess   bool
+		wantError     string
+	}{
+		{
+			name:          "agent producing output runs past its stall timeout",
+			progressEvery: 20 * time.Millisecond,
+			wantSuccess:   true,
+		},
+		{
+			name:        "silent agent is stopped",
+			wantSuccess: false,
+			wantError:   "agent stalled: no output for 100ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, _ := setupTestSpellLoader(t, map[string]string{
+				"implement": "Implement the feature",
+			})
+			runner := &progressMockRunner{
+				MockAgentRunner: MockAgentRunner{Delay: 400 * time.Millisecond},
+				ProgressEvery:   tt.progressEvery,
+			}
+			executor := NewAgentExecutor(loader, runner)
+
+			step := &grimoire.Step{
+				Name:         "implement",
+				Type:         grimoire.StepTypeAgent,
+				Spell:        "implement",
+				StallTimeout: "100ms",
+			}
+			stepCtx := NewStepContext("/worktree", "bead-123", "wf-456")
+
+			var mu sync.Mutex
+			var lastProgress time.Time
+			stepCtx.OnProgress = func(at time.Time) {
+				mu.Lock()
+				defer mu.Unlock()
+				lastProgress = at
+			}
+
+			result, err := executor.Execute(context.Background(), step, stepCtx)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if result.Error != tt.wantError {
+				t.Errorf("Error = %q, want %q", result.Error, tt.wantError)
+			}
+			if !runner.stopped {
+				t.Error("progress watch should be stopped after the step")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if gotProgress := !lastProgress.IsZero(); gotProgress != (tt.progressEvery > 0) {
+				t.Errorf("progress recorded = %v, want %v", gotProgress, tt.progressEvery > 0)
+			}
+		})
+	}
+}
+
+func TestAgentExecutor_Execute_StallTimeoutRequiresProgressRunner(t *testing.T) {
+	loader, _ := setupTestSpellLoader(t, map[string]string{
+		"implement": "Implement the feature",
+	})
+	executor := NewAgentExecutor(loader, &MockAgentRunner{})
+
+	step := &grimoire.Step{
+		Name:         "implement",
+		Type:         grimoire.StepTypeAgent,
+		Spell:        "implement",
+		StallTimeout: "1m",
+	}
+
+	_, err := executor.Execute(context.Background(), step, NewStepContext("/worktree", "bead-123", "wf-456"))
+	if err == nil || !strings.Contains(err.Error(), "does not report progress") {
+		t.Errorf("Execute() error = %v, want progress reporting error", err)
+	}
+}
@@ -0,0 +1,301 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cassetteVersion is the version of the cassette file format.
+const cassetteVersion = 1
+
+// cassetteWorktree stands in for the worktree path in recorded prompts, so a
+// cassette replays in a different worktree than it was recorded in.
+const cassetteWorktree = "$WORKTREE"
+
+// Cassette is a recording of agent runs: each prompt an agent was given and
+// how it responded. RecordingAgentRunner writes one and ReplayAgentRunner
+// serves responses from it, so grimoires can be tested against real agent
+// behaviour without running an agent.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded agent run.
+type Interaction struct {
+	// Task is the ID of the task whose workflow ran the agent. Runs for a
+	// task the cassette recorded are only given that task's responses, so
+	// workflows replayed side by side don't take each other's.
+	Task string `json:"task,omitempty"`
+
+	// Step is the name of the step the agent ran for. Runs are replayed by
+	// step, so prompts that differ between runs, as with a workflow ID in a
+	// spell, still get their response. Older cassettes without it are
+	// replayed by prompt.
+	Step string `json:"step,omitempty"`
+
+	// Prompt is the rendered prompt, with the worktree path replaced by
+	// $WORKTREE.
+	Prompt   string `json:"prompt"`
+	Output   string `json:"output"`
+	ExitCode int    `json:"exit_code"`
+
+	// Error is the error the runner returned, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	if cassette.Version != cassetteVersion {
+		return nil, fmt.Errorf("cassette %s has unsupported version %d", path, cassette.Version)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to path.
+func (c *Cassette) Save(path string) error {
+	c.Version = cassetteVersion
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := writeFileAtomic(path, data); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// cassettePrompt normalizes a prompt for recording or lookup.
+func cassettePrompt(workDir, prompt string) string {
+	if workDir == "" {
+		return prompt
+	}
+	return strings.ReplaceAll(prompt, workDir, cassetteWorktree)
+}
+
+// agentStepKey is the context key for the task and step an agent runs for.
+type agentStepKey struct{}
+
+// agentStepInfo is the task and step an agent runs for.
+type agentStepInfo struct {
+	task string
+	step string
+}
+
+// withAgentStep returns ctx carrying the ID of the task and the name of the
+// step an agent is run for, which cassettes record runs by.
+func withAgentStep(ctx context.Context, task, step string) context.Context {
+	return context.WithValue(ctx, agentStepKey{}, agentStepInfo{task: task, step: step})
+}
+
+// agentStep returns the task and step an agent is run for, or empty strings
+// if ctx doesn't carry them.
+func agentStep(ctx context.Context) (task, step string) {
+	info, _ := ctx.Value(agentStepKey{}).(agentStepInfo)
+	return info.task, info.step
+}
+
+// RecordingAgentRunner runs agents with another runner and records each
+// run's prompt and response to a cassette file. The file is rewritten after
+// every run, so a workflow that is stopped partway keeps what it recorded.
+// Runs resumed with WaitForExisting aren't recorded, as their prompt isn't
+// known.
+type RecordingAgentRunner struct {
+	runner AgentRunner
+	path   string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// progressRecordingAgentRunner is a RecordingAgentRunner around a runner
+// that reports progress.
+type progressRecordingAgentRunner struct {
+	*RecordingAgentRunner
+	progress ProgressAgentRunner
+}
+
+// WatchProgress watches the progress of the wrapped runner's agent.
+func (r *progressRecordingAgentRunner) WatchProgress(stepTaskID string, onProgress func(at time.Time)) func() {
+	return r.progress.WatchProgress(stepTaskID, onProgress)
+}
+
+// NewRecordingAgentRunner wraps runner to record its runs to the cassette at
+// path, replacing any recording already there. The returned runner reports
+// progress if runner does.
+func NewRecordingAgentRunner(runner AgentRunner, path string) AgentRunner {
+	recorder := &RecordingAgentRunner{runner: runner, path: path}
+	if progress, ok := runner.(ProgressAgentRunner); ok {
+		return &progressRecordingAgentRunner{RecordingAgentRunner: recorder, progress: progress}
+	}
+	return recorder
+}
+
+// Run runs the agent and records its response.
+func (r *RecordingAgentRunner) Run(ctx context.Context, workDir, prompt string, onSpawn func(stepTaskID string)) (*AgentRunResult, error) {
+	result, err := r.runner.Run(ctx, workDir, prompt, onSpawn)
+	// A run cut short by cancellation isn't how the agent responds
+	if ctx.Err() != nil {
+		return result, err
+	}
+
+	task, step := agentStep(ctx)
+	interaction := Interaction{Task: task, Step: step, Prompt: cassettePrompt(workDir, prompt)}
+	if result != nil {
+		interaction.Output = result.Output
+		interaction.ExitCode = result.ExitCode
+	}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if saveErr := r.cassette.Save(r.path); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return result, err
+}
+
+// WaitForExisting waits for an agent started by the wrapped runner.
+func (r *RecordingAgentRunner) WaitForExisting(ctx context.Context, stepTaskID string) (*AgentRunResult, error) {
+	return r.runner.WaitForExisting(ctx, stepTaskID)
+}
+
+// IsRunning reports whether the wrapped runner's agent is running.
+func (r *RecordingAgentRunner) IsRunning(stepTaskID string) bool {
+	return r.runner.IsRunning(stepTaskID)
+}
+
+// ReplayAgentRunner answers agent runs from a cassette instead of running an
+// agent. A run is given the response recorded for the same step of the same
+// task, or of any task if the cassette recorded none for the run's task; when
+// a step was recorded more than once, as in a loop, the responses are given
+// in the order they were recorded. Interactions recorded without a step are
+// matched by prompt instead. A run with no recorded response left fails.
+type ReplayAgentRunner struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+	tasks        map[string]bool
+	runs         int
+
+	onPromptMismatch func(task, step, recorded, prompt string)
+}
+
+// NewReplayAgentRunner creates a runner that replays cassette.
+func NewReplayAgentRunner(cassette *Cassette) *ReplayAgentRunner {
+	r := &ReplayAgentRunner{
+		interactions: cassette.Interactions,
+		used:         make([]bool, len(cassette.Interactions)),
+		tasks:        make(map[string]bool),
+	}
+	for _, interaction := range cassette.Interactions {
+		if interaction.Task != "" {
+			r.tasks[interaction.Task] = true
+		}
+	}
+	return r
+}
+
+// OnPromptMismatch sets a callback for runs given a response recorded for
+// their step with a different prompt, as after a spell was changed, with the
+// recorded prompt and the run's.
+func (r *ReplayAgentRunner) OnPromptMismatch(fn func(task, step, recorded, prompt string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onPromptMismatch = fn
+}
+
+// Run returns the next response recorded for the run's step, or for prompt.
+func (r *ReplayAgentRunner) Run(ctx context.Context, workDir, prompt string, onSpawn func(stepTaskID string)) (*AgentRunResult, error) {
+	task, step := agentStep(ctx)
+	key := cassettePrompt(workDir, prompt)
+
+	r.mu.Lock()
+	i := r.next(task, step, key)
+	if i < 0 {
+		r.mu.Unlock()
+		if step != "" {
+			return nil, fmt.Errorf("no recorded response for step %q with prompt %q", step, truncatePrompt(key))
+		}
+		return nil, fmt.Errorf("no recorded response for prompt %q", truncatePrompt(key))
+	}
+	r.used[i] = true
+	interaction := r.interactions[i]
+	onPromptMismatch := r.onPromptMismatch
+	r.runs++
+	stepTaskID := fmt.Sprintf("replay-%d", r.runs)
+	r.mu.Unlock()
+
+	if interaction.Prompt != key && onPromptMismatch != nil {
+		onPromptMismatch(task, step, interaction.Prompt, key)
+	}
+	if onSpawn != nil {
+		onSpawn(stepTaskID)
+	}
+
+	result := &AgentRunResult{
+		Output:     interaction.Output,
+		ExitCode:   interaction.ExitCode,
+		StepTaskID: stepTaskID,
+	}
+	if interaction.Error != "" {
+		return result, errors.New(interaction.Error)
+	}
+	return result, nil
+}
+
+// next returns the index of the first unused interaction recorded for a run
+// of step for task, falling back to those recorded without a step for
+// prompt, or -1 if there is none. The caller must hold r.mu.
+func (r *ReplayAgentRunner) next(task, step, prompt string) int {
+	if step != "" {
+		recordedTask := r.tasks[task]
+		for i, interaction := range r.interactions {
+			if !r.used[i] && interaction.Step == step && (!recordedTask || interaction.Task == task) {
+				return i
+			}
+		}
+	}
+	for i, interaction := range r.interactions {
+		if !r.used[i] && interaction.Step == "" && interaction.Prompt == prompt {
+			return i
+		}
+	}
+	return -1
+}
+
+// WaitForExisting returns nil: replayed runs finish as soon as they start.
+func (r *ReplayAgentRunner) WaitForExisting(ctx context.Context, stepTaskID string) (*AgentRunResult, error) {
+	return nil, nil
+}
+
+// IsRunning returns false: replayed runs finish as soon as they start.
+func (r *ReplayAgentRunner) IsRunning(stepTaskID string) bool {
+	return false
+}
+
+// truncatePrompt shortens a prompt for an error message.
+func truncatePrompt(prompt string) string {
+	const max = 80
+	if len(prompt) <= max {
+		return prompt
+	}
+	return prompt[:max] + "..."
+}
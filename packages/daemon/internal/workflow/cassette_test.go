@@ -0,0 +1,213 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// scriptedAgentRunner answers each run with the next of its outputs.
+type scriptedAgentRunner struct {
+	outputs []string
+	calls   int
+}
+
+func (r *scriptedAgentRunner) Run(ctx context.Context, workDir, prompt string, onSpawn func(stepTaskID string)) (*AgentRunResult, error) {
+	output := r.outputs[r.calls%len(r.outputs)]
+	r.calls++
+	if onSpawn != nil {
+		onSpawn(fmt.Sprintf("scripted-%d", r.calls))
+	}
+	return &AgentRunResult{Output: output}, nil
+}
+
+func (r *scriptedAgentRunner) WaitForExisting(ctx context.Context, stepTaskID string) (*AgentRunResult, error) {
+	return nil, nil
+}
+
+func (r *scriptedAgentRunner) IsRunning(stepTaskID string) bool {
+	return false
+}
+
+// cassetteGrimoire has an agent step whose prompt names the worktree and the
+// workflow ID, which differs between runs, and a loop that gives the same
+// prompt twice.
+func cassetteGrimoire() *grimoire.Grimoire {
+	return &grimoire.Grimoire{
+		Name: "cassette-test",
+		Steps: []grimoire.Step{
+			{Name: "implement", Type: grimoire.StepTypeAgent, Spell: "Implement {{.bead.title}} in {{.workflow.worktree}} for {{.workflow.id}}.\n", Output: "plan"},
+			{
+				Name:            "review-loop",
+				Type:            grimoire.StepTypeLoop,
+				MaxIterations:   2,
+				OnMaxIterations: "continue",
+				Steps: []grimoire.Step{
+					{Name: "review", Type: grimoire.StepTypeAgent, Spell: "Review the changes.\n", OnFail: "continue"},
+				},
+			},
+		},
+	}
+}
+
+func runWithAgentRunner(t *testing.T, runner AgentRunner, workflowID string) *ExecutionResult {
+	t.Helper()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: t.TempDir(),
+		BeadID:       "bead-1",
+		WorkflowID:   workflowID,
+		Bead:         &BeadData{ID: "bead-1", Title: "the parser"},
+	})
+	engine.SetAgentRunner(runner)
+	return engine.Execute(context.Background(), cassetteGrimoire())
+}
+
+func TestAgentCassette_RecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	agent := &scriptedAgentRunner{outputs: []string{
+		"```json\n{\"success\": true, \"summary\": \"implemented\"}\n```",
+		"```json\n{\"success\": false, \"summary\": \"needs work\", \"error\": \"tests fail\"}\n```",
+		"```json\n{\"success\": true, \"summary\": \"approved\"}\n```",
+	}}
+
+	recorded := runWithAgentRunner(t, NewRecordingAgentRunner(agent, path), "wf-bead-1-1")
+	if recorded.Status != WorkflowCompleted {
+		t.Fatalf("recorded Status = %q, want %q, error: %v", recorded.Status, WorkflowCompleted, recorded.Error)
+	}
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatalf("LoadCassette() error: %v", err)
+	}
+	if len(cassette.Interactions) != 3 {
+		t.Fatalf("Interactions = %d, want 3", len(cassette.Interactions))
+	}
+	if prompt := cassette.Interactions[0].Prompt; !strings.Contains(prompt, cassetteWorktree) {
+		t.Errorf("Prompt = %q, want the worktree path replaced by %s", prompt, cassetteWorktree)
+	}
+	for i, want := range []string{"implement", "review", "review"} {
+		if got := cassette.Interactions[i].Step; got != want {
+			t.Errorf("Interactions[%d].Step = %q, want %q", i, got, want)
+		}
+		if got := cassette.Interactions[i].Task; got != "bead-1" {
+			t.Errorf("Interactions[%d].Task = %q, want bead-1", i, got)
+		}
+	}
+
+	// Replay runs in a different worktree and workflow, without the agent
+	replayed := runWithAgentRunner(t, NewReplayAgentRunner(cassette), "wf-bead-1-2")
+	if replayed.Status != recorded.Status {
+		t.Errorf("replayed Status = %q, want %q, error: %v", replayed.Status, recorded.Status, replayed.Error)
+	}
+	if len(replayed.StepResults) != len(recorded.StepResults) {
+		t.Fatalf("replayed %d steps, want %d", len(replayed.StepResults), len(recorded.StepResults))
+	}
+	for name, want := range recorded.StepResults {
+		got := replayed.StepResults[name]
+		if got == nil {
+			t.Errorf("step %q was not replayed", name)
+			continue
+		}
+		if got.Success != want.Success || got.Output != want.Output || got.Action != want.Action || got.Summary != want.Summary {
+			t.Errorf("step %q replayed as %+v, want %+v", name, got, want)
+		}
+		if len(got.Iterations) != len(want.Iterations) {
+			t.Errorf("step %q replayed %d iterations, want %d", name, len(got.Iterations), len(want.Iterations))
+		}
+	}
+}
+
+func TestReplayAgentRunner_ByStep(t *testing.T) {
+	runner := NewReplayAgentRunner(&Cassette{Interactions: []Interaction{
+		{Step: "review", Prompt: "Review wf-1", Output: "first"},
+		{Step: "review", Prompt: "Review wf-1", Output: "second"},
+	}})
+	ctx := withAgentStep(context.Background(), "bead-1", "review")
+
+	// The step's responses are given in order, whatever the prompt
+	for _, want := range []string{"first", "second"} {
+		result, err := runner.Run(ctx, "/wt", "Review wf-2", nil)
+		if err != nil || result.Output != want {
+			t.Fatalf("Run() = %+v, %v; want %q", result, err, want)
+		}
+	}
+	if _, err := runner.Run(ctx, "/wt", "Review wf-2", nil); err == nil || !strings.Contains(err.Error(), `step "review"`) {
+		t.Errorf("Run() for a used up step error = %v, want no recorded response for the step", err)
+	}
+	if _, err := runner.Run(withAgentStep(context.Background(), "bead-1", "implement"), "/wt", "Review wf-1", nil); err == nil {
+		t.Error("Run() for an unrecorded step should fail")
+	}
+}
+
+func TestReplayAgentRunner_ByTask(t *testing.T) {
+	runner := NewReplayAgentRunner(&Cassette{Interactions: []Interaction{
+		{Task: "task-a", Step: "review", Prompt: "Review a", Output: "for a"},
+		{Task: "task-b", Step: "review", Prompt: "Review b", Output: "for b"},
+	}})
+
+	// Each task gets its own response, whichever runs first
+	result, err := runner.Run(withAgentStep(context.Background(), "task-b", "review"), "/wt", "Review b", nil)
+	if err != nil || result.Output != "for b" {
+		t.Fatalf("Run() for task-b = %+v, %v; want its own response", result, err)
+	}
+	if _, err := runner.Run(withAgentStep(context.Background(), "task-b", "review"), "/wt", "Review b", nil); err == nil {
+		t.Error("Run() for task-b should not be given task-a's response")
+	}
+
+	// A task the cassette didn't record is given any task's response
+	result, err = runner.Run(withAgentStep(context.Background(), "task-c", "review"), "/wt", "Review a", nil)
+	if err != nil || result.Output != "for a" {
+		t.Fatalf("Run() for task-c = %+v, %v; want the remaining response", result, err)
+	}
+}
+
+func TestReplayAgentRunner_PromptMismatch(t *testing.T) {
+	runner := NewReplayAgentRunner(&Cassette{Interactions: []Interaction{
+		{Task: "bead-1", Step: "review", Prompt: "Review the changes", Output: "first"},
+		{Task: "bead-1", Step: "review", Prompt: "Review the changes", Output: "second"},
+	}})
+	var mismatches []string
+	runner.OnPromptMismatch(func(task, step, recorded, prompt string) {
+		mismatches = append(mismatches, task+"/"+step+": "+recorded+" -> "+prompt)
+	})
+	ctx := withAgentStep(context.Background(), "bead-1", "review")
+
+	if _, err := runner.Run(ctx, "/wt", "Review the changes", nil); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("mismatches = %q, want none for the recorded prompt", mismatches)
+	}
+
+	result, err := runner.Run(ctx, "/wt", "Review the new spell", nil)
+	if err != nil || result.Output != "second" {
+		t.Fatalf("Run() = %+v, %v; want the step's response despite the prompt", result, err)
+	}
+	if len(mismatches) != 1 || mismatches[0] != "bead-1/review: Review the changes -> Review the new spell" {
+		t.Errorf("mismatches = %q, want the changed prompt reported", mismatches)
+	}
+}
+
+func TestReplayAgentRunner_UnrecordedPrompt(t *testing.T) {
+	runner := NewReplayAgentRunner(&Cassette{Interactions: []Interaction{
+		{Prompt: "Review $WORKTREE", Output: "first"},
+	}})
+
+	result, err := runner.Run(context.Background(), "/wt", "Review /wt", nil)
+	if err != nil || result.Output != "first" {
+		t.Fatalf("Run() = %+v, %v; want the recorded output", result, err)
+	}
+
+	// Each recorded response is only given once
+	if _, err := runner.Run(context.Background(), "/wt", "Review /wt", nil); err == nil {
+		t.Error("Run() for a used up prompt should fail")
+	}
+	if _, err := runner.Run(context.Background(), "/wt", "Something else", nil); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("Run() for an unrecorded prompt error = %v, want no recorded response", err)
+	}
+}
@@ -0,0 +1,132 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// CommandPolicy restricts the commands script steps may run. It is checked
+// against the rendered command before it runs.
+//
+// The policy guards against grimoires running commands they shouldn't; it
+// inspects the command text and is not a sandbox.
+type CommandPolicy struct {
+	deny  []*regexp.Regexp
+	allow map[string]bool
+}
+
+// NewCommandPolicy creates a policy that refuses commands matching any of the
+// deny regular expressions and, if allow is not empty, commands that run an
+// executable not named in allow.
+func NewCommandPolicy(deny, allow []string) (*CommandPolicy, error) {
+	p := &CommandPolicy{}
+	for _, pattern := range deny {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny pattern %q: %w", pattern, err)
+		}
+		p.deny = append(p.deny, re)
+	}
+	if len(allow) > 0 {
+		p.allow = make(map[string]bool, len(allow))
+		for _, name := range allow {
+			p.allow[name] = true
+		}
+	}
+	return p, nil
+}
+
+// PolicyViolationError is returned when a command is refused by a CommandPolicy.
+type PolicyViolationError struct {
+	Reason string
+}
+
+func (e *PolicyViolationError) Error() string {
+	return "command policy violation: " + e.Reason
+}
+
+// IsPolicyViolation returns true if the error is a PolicyViolationError.
+func IsPolicyViolation(err error) bool {
+	var policyErr *PolicyViolationError
+	return errors.As(err, &policyErr)
+}
+
+// Check returns a PolicyViolationError if the policy refuses command.
+// A nil policy allows every command.
+func (p *CommandPolicy) Check(command string) error {
+	if p == nil {
+		return nil
+	}
+
+	for _, re := range p.deny {
+		if re.MatchString(command) {
+			return &PolicyViolationError{Reason: fmt.Sprintf("command matches deny pattern %q", re.String())}
+		}
+	}
+
+	if p.allow != nil {
+		for _, name := range commandExecutables(command) {
+			if !p.allow[name] && !p.allow[filepath.Base(name)] {
+				return &PolicyViolationError{Reason: fmt.Sprintf("executable %q is not in the allowlist", name)}
+			}
+		}
+	}
+
+	return nil
+}
+
+// fdRedirections are redirections such as 2>&1 and &>, whose & doesn't
+// separate commands.
+var fdRedirections = regexp.MustCompile(`[0-9]*[<>]&[0-9-]*|&>`)
+
+// commandSeparators split a shell command into the simple commands it runs.
+var commandSeparators = regexp.MustCompile("\\$\\(|&&|\\|\\||[;&|\n()`{}]")
+
+// shellKeywords introduce or close compound commands and are skipped to find
+// the executable they run.
+var shellKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"do": true, "done": true, "while": true, "until": true, "!": true,
+	"exec": true, "command": true, "time": true,
+}
+
+// commandExecutables returns the executable each simple command in a shell
+// command runs, skipping leading variable assignments and shell keywords.
+// The lists of for, case and select heads are not commands and are skipped.
+func commandExecutables(command string) []string {
+	var names []string
+	command = fdRedirections.ReplaceAllString(command, " ")
+	for _, segment := range commandSeparators.Split(command, -1) {
+		fields := strings.Fields(segment)
+		for len(fields) > 0 && (shellKeywords[fields[0]] || isAssignment(fields[0])) {
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "for", "case", "select", "esac", "in":
+			continue
+		}
+		names = append(names, strings.Trim(fields[0], `"'`))
+	}
+	return names
+}
+
+// isAssignment reports whether a shell word is a variable assignment, such as
+// FOO=bar.
+func isAssignment(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	if !ok || name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package workflow
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+func TestCommandPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		deny    []string
+		allow   []string
+		command string
+		wantErr bool
+	}{
+		{name: "no policy", command: "rm -rf /"},
+		{name: "denied pattern", deny: []string{`rm\s+-rf\s+/(\s|$)`}, command: "rm -rf /", wantErr: true},
+		{name: "denied pattern later in command", deny: []string{`rm\s+-rf\s+/(\s|$)`}, command: "make clean && rm -rf / ", wantErr: true},
+		{name: "deny pattern not matched", deny: []string{`rm\s+-rf\s+/(\s|$)`}, command: "rm -rf /tmp/build"},
+		{name: "allowed executable", allow: []string{"go"}, command: "go test ./..."},
+		{name: "allowed pipeline", allow: []string{"go", "grep"}, command: "go test ./... 2>&1 | grep -v '^ok'"},
+		{name: "executable not allowed", allow: []string{"go"}, command: "go build ./... && curl https://example.com", wantErr: true},
+		{name: "command substitution checked", allow: []string{"echo"}, command: "echo $(cat /etc/passwd)", wantErr: true},
+		{name: "allowed by base name", allow: []string{"release.sh"}, command: "./scripts/release.sh v1"},
+		{name: "assignments and keywords skipped", allow: []string{"npm", "test"}, command: "if test -f package.json; then CI=1 npm test; fi"},
+		{name: "deny checked before allow", deny: []string{"--force"}, allow: []string{"git"}, command: "git push --force", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewCommandPolicy(tt.deny, tt.allow)
+			if err != nil {
+				t.Fatalf("NewCommandPolicy() error: %v", err)
+			}
+
+			err = policy.Check(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+			if err != nil && !IsPolicyViolation(err) {
+				t.Errorf("Check() error = %v, want a PolicyViolationError", err)
+			}
+		})
+	}
+}
+
+func TestNewCommandPolicy_InvalidPattern(t *testing.T) {
+	if _, err := NewCommandPolicy([]string{"rm ("}, nil); err == nil {
+		t.Error("NewCommandPolicy() should reject an invalid deny pattern")
+	}
+}
+
+func TestCommandExecutables(t *testing.T) {
+	got := commandExecutables(`for f in *.go; do gofmt -l "$f"; done; (cd web && npm ci) || echo failed >&2`)
+	want := []string{"gofmt", "cd", "npm", "echo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commandExecutables() = %v, want %v", got, want)
+	}
+}
+
+func TestScriptExecutor_Execute_CommandPolicy(t *testing.T) {
+	policy, err := NewCommandPolicy([]string{`rm\s+-rf\s+/(\s|$)`}, []string{"echo", "rm"})
+	if err != nil {
+		t.Fatalf("NewCommandPolicy() error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		command     string
+		wantRun     bool
+		wantSuccess bool
+	}{
+		{name: "allowed command runs", command: "echo {{.target}}", wantRun: true, wantSuccess: true},
+		{name: "denied command is blocked", command: "rm -rf {{.root}}"},
+		{name: "command outside the allowlist is blocked", command: "curl https://example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockCommandRunner{Stdout: "done"}
+			executor := NewScriptExecutorWithRunner(mock)
+			executor.SetPolicy(policy)
+
+			step := &grimoire.Step{
+				Name:    "run",
+				Type:    grimoire.StepTypeScript,
+				Command: tt.command,
+				OnFail:  "continue",
+			}
+			stepCtx := NewStepContext("/tmp", "bead-1", "wf-1")
+			stepCtx.SetVariable("target", "build")
+			stepCtx.SetVariable("root", "/")
+
+			result, err := executor.Execute(context.Background(), step, stepCtx)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+
+			if ran := mock.Command != ""; ran != tt.wantRun {
+				t.Errorf("command ran = %v, want %v", ran, tt.wantRun)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v (error: %s)", result.Success, tt.wantSuccess, result.Error)
+			}
+			if !tt.wantSuccess {
+				if result.Action != ActionFail {
+					t.Errorf("Action = %q, want %q despite on_fail: continue", result.Action, ActionFail)
+				}
+				if !strings.HasPrefix(result.Error, "command policy violation: ") {
+					t.Errorf("Error = %q, want a policy violation", result.Error)
+				}
+			}
+		})
+	}
+}
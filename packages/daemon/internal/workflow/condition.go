@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -43,9 +44,14 @@ func NewConditionEvaluator() *ConditionEvaluator {
 // templatePattern matches Go template expressions like {{.foo.bar}} or {{not .foo.bar}}
 var templatePattern = regexp.MustCompile(`\{\{.*\}\}`)
 
+// comparisonPattern matches a template compared with == or != to a value,
+// like {{.bead.priority}} == "P0".
+var comparisonPattern = regexp.MustCompile(`^(\{\{.*\}\})\s*(==|!=)\s*(.*)$`)
+
 // Evaluate evaluates a 'when' condition and returns whether it is true.
 // The condition can be:
 // - A simple template variable: "{{.previous.failed}}"
+// - A template compared to a value: "{{.bead.priority}} == \"P0\""
 // - A literal boolean: "true" or "false"
 // - An empty string (returns true - unconditional execution)
 //
@@ -79,10 +85,62 @@ func (e *ConditionEvaluator) Evaluate(condition string, ctx *StepContext) (bool,
 
 // evaluateTemplate evaluates a template-style condition.
 func (e *ConditionEvaluator) evaluateTemplate(condition string, ctx *StepContext) (bool, error) {
-	// Parse and execute the template
-	tmpl, err := template.New("condition").Parse(condition)
+	_, result, err := e.evaluateTemplateWithValue(condition, ctx)
+	return result, err
+}
+
+// renderCondition executes a template-style condition and returns its value.
+// A comparison renders as "true" or "false".
+func renderCondition(condition string, ctx *StepContext) (string, error) {
+	m := comparisonPattern.FindStringSubmatch(strings.TrimSpace(condition))
+	if m == nil {
+		return renderConditionTemplate(condition, condition, ctx)
+	}
+
+	left, err := renderConditionTemplate(m[1], condition, ctx)
 	if err != nil {
-		return false, &ConditionError{
+		return "", err
+	}
+	right, err := comparisonOperand(m[3], condition, ctx)
+	if err != nil {
+		return "", err
+	}
+	equal := strings.TrimSpace(left) == right
+	return strconv.FormatBool(equal == (m[2] == "==")), nil
+}
+
+// comparisonOperand returns the value the right side of a comparison stands
+// for: a quoted string, another template, or a bare word such as a number.
+func comparisonOperand(operand, condition string, ctx *StepContext) (string, error) {
+	switch {
+	case operand == "":
+		return "", &ConditionError{
+			Condition: condition,
+			Message:   "comparison has no value to compare to",
+		}
+	case strings.HasPrefix(operand, `"`) || strings.HasPrefix(operand, "`"):
+		value, err := strconv.Unquote(operand)
+		if err != nil {
+			return "", &ConditionError{
+				Condition: condition,
+				Message:   fmt.Sprintf("invalid quoted value %s", operand),
+				Cause:     err,
+			}
+		}
+		return value, nil
+	case templatePattern.MatchString(operand):
+		value, err := renderConditionTemplate(operand, condition, ctx)
+		return strings.TrimSpace(value), err
+	default:
+		return operand, nil
+	}
+}
+
+// renderConditionTemplate executes text, part of condition, as a template.
+func renderConditionTemplate(text, condition string, ctx *StepContext) (string, error) {
+	tmpl, err := template.New("condition").Parse(text)
+	if err != nil {
+		return "", &ConditionError{
 			Condition: condition,
 			Message:   "failed to parse template",
 			Cause:     err,
@@ -91,15 +149,13 @@ func (e *ConditionEvaluator) evaluateTemplate(condition string, ctx *StepContext
 
 	var buf strings.Builder
 	if err := tmpl.Execute(&buf, ctx.ToMap()); err != nil {
-		return false, &ConditionError{
+		return "", &ConditionError{
 			Condition: condition,
 			Message:   "failed to evaluate template",
 			Cause:     err,
 		}
 	}
-
-	result := buf.String()
-	return e.coerceToBool(result, condition)
+	return buf.String(), nil
 }
 
 // coerceToBool converts a value string to a boolean following the documented rules.
@@ -244,25 +300,11 @@ func (e *ConditionEvaluator) EvaluateWithResult(condition string, ctx *StepConte
 
 // evaluateTemplateWithValue evaluates a template and returns both the evaluated string and boolean result.
 func (e *ConditionEvaluator) evaluateTemplateWithValue(condition string, ctx *StepContext) (string, bool, error) {
-	tmpl, err := template.New("condition").Parse(condition)
+	result, err := renderCondition(condition, ctx)
 	if err != nil {
-		return "", false, &ConditionError{
-			Condition: condition,
-			Message:   "failed to parse template",
-			Cause:     err,
-		}
-	}
-
-	var buf strings.Builder
-	if err := tmpl.Execute(&buf, ctx.ToMap()); err != nil {
-		return "", false, &ConditionError{
-			Condition: condition,
-			Message:   "failed to evaluate template",
-			Cause:     err,
-		}
+		return "", false, err
 	}
 
-	result := buf.String()
 	boolResult, err := e.coerceToBool(result, condition)
 	if err != nil {
 		return result, false, err
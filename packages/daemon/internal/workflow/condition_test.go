@@ -576,3 +576,39 @@ func TestConditionEvaluator_EvaluateWithResult_Template(t *testing.T) {
 		t.Errorf("EvaluatedValue = %q, want %q", result.EvaluatedValue, "42")
 	}
 }
+
+func TestConditionEvaluator_Evaluate_Comparison(t *testing.T) {
+	evaluator := NewConditionEvaluator()
+	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
+	ctx.SetBead(&BeadData{ID: "bead-123", Priority: "P0", Type: "bug"})
+	_ = ctx.StoreStepOutput("tests", &StepResult{Success: false, ExitCode: 2}, "tests")
+
+	tests := []struct {
+		condition string
+		expected  bool
+	}{
+		{`{{.bead.priority}} == "P0"`, true},
+		{`{{.bead.priority}} == "P2"`, false},
+		{`{{.bead.priority}} != "P0"`, false},
+		{`{{.bead.priority}}!="P2"`, true},
+		{"{{.tests.exit_code}} == 2", true},
+		{"{{.bead.type}} == {{.bead.type}}", true},
+		{"{{.bead.type}} == `feature`", false},
+	}
+	for _, tt := range tests {
+		got, err := evaluator.Evaluate(tt.condition, ctx)
+		if err != nil {
+			t.Errorf("Evaluate(%q) error: %v", tt.condition, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Evaluate(%q) = %v, want %v", tt.condition, got, tt.expected)
+		}
+	}
+
+	for _, condition := range []string{`{{.bead.priority}} ==`, `{{.bead.priority}} == "P0`} {
+		if _, err := evaluator.Evaluate(condition, ctx); !IsConditionError(err) {
+			t.Errorf("Evaluate(%q) error = %v, want ConditionError", condition, err)
+		}
+	}
+}
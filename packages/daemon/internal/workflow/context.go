@@ -20,6 +20,9 @@ type StepOutput struct {
 
 	// ExitCode is the exit code for script steps.
 	ExitCode int `json:"exit_code,omitempty"`
+
+	// StatusCode is the response status for http steps.
+	StatusCode int `json:"status_code,omitempty"`
 }
 
 // BeadData contains bead information available in workflow context.
@@ -62,10 +65,29 @@ func (c *StepContext) StoreStepOutput(stepName string, result *StepResult, outpu
 		return &ContextError{Path: stepName, Message: "step output already exists and cannot be overwritten"}
 	}
 
-	// Create step output
+	output := newStepOutput(result)
+
+	// Store under step name
+	c.Variables[stepName] = output
+
+	// Also store under output alias if provided
+	if outputName != "" && outputName != stepName {
+		if _, exists := c.Variables[outputName]; exists {
+			return &ContextError{Path: outputName, Message: "output name already exists and cannot be overwritten"}
+		}
+		c.Variables[outputName] = output
+	}
+
+	return nil
+}
+
+// newStepOutput creates the context entry for a step's result, parsing its
+// output as JSON for structured access.
+func newStepOutput(result *StepResult) *StepOutput {
 	output := &StepOutput{
-		Output:   result.Output,
-		ExitCode: result.ExitCode,
+		Output:     result.Output,
+		ExitCode:   result.ExitCode,
+		StatusCode: result.StatusCode,
 	}
 
 	if result.Success {
@@ -74,26 +96,13 @@ func (c *StepContext) StoreStepOutput(stepName string, result *StepResult, outpu
 		output.Status = "failed"
 	}
 
-	// Try to parse output as JSON for structured access
 	if result.Output != "" {
 		var parsed map[string]interface{}
 		if err := json.Unmarshal([]byte(result.Output), &parsed); err == nil {
 			output.Outputs = parsed
 		}
 	}
-
-	// Store under step name
-	c.Variables[stepName] = output
-
-	// Also store under output alias if provided
-	if outputName != "" && outputName != stepName {
-		if _, exists := c.Variables[outputName]; exists {
-			return &ContextError{Path: outputName, Message: "output name already exists and cannot be overwritten"}
-		}
-		c.Variables[outputName] = output
-	}
-
-	return nil
+	return output
 }
 
 // SetBead stores bead data in the context.
@@ -110,21 +119,92 @@ func (c *StepContext) GetBead() *BeadData {
 }
 
 // SetLoopVariable sets the loop iteration variable in the context.
-// The variable is stored as loop_name.iteration for access via {{.loop_name.iteration}}.
+// The variable is stored as loop_name.iteration for access via
+// {{.loop_name.iteration}}, and as loop.iteration for the innermost loop.
 func (c *StepContext) SetLoopVariable(loopName string, iteration int) {
-	c.Variables[loopName] = map[string]interface{}{
+	loop := map[string]interface{}{
 		"iteration": iteration,
+		"index":     iteration,
 	}
+	c.Variables[loopName] = loop
+	c.Variables["loop"] = loop
+}
+
+// SetLoopItem binds the current for_each element and its index in the context.
+// The element is available as "item" (so "item.field" resolves into objects)
+// and the index as "index". Both are also stored on the loop variable as
+// loop_name.item and loop_name.index, alongside loop_name.iteration, and on
+// loop for the innermost loop (e.g. {{.loop.index}}).
+func (c *StepContext) SetLoopItem(loopName string, index int, item interface{}) {
+	item = normalizeContextValue(item)
+	c.Variables["item"] = item
+	c.Variables["index"] = index
+	loop := map[string]interface{}{
+		"iteration": index,
+		"index":     index,
+		"item":      item,
+	}
+	c.Variables[loopName] = loop
+	c.Variables["loop"] = loop
+}
+
+// preserveLoopBindings saves the current item, index and loop bindings and
+// returns a function that restores them, so a nested loop doesn't clobber
+// those of the loop enclosing it. The variable of the loop named loopName is
+// kept even if it is named "loop".
+func (c *StepContext) preserveLoopBindings(loopName string) func() {
+	saved := make(map[string]interface{}, 3)
+	for _, key := range []string{"item", "index", "loop"} {
+		if value, ok := c.Variables[key]; ok {
+			saved[key] = value
+		}
+	}
+	return func() {
+		for _, key := range []string{"item", "index", "loop"} {
+			if key == loopName {
+				continue
+			}
+			if value, ok := saved[key]; ok {
+				c.Variables[key] = value
+			} else {
+				delete(c.Variables, key)
+			}
+		}
+	}
+}
+
+// builtinVariable returns the value of a variable the context provides from
+// its own fields rather than from Variables:
+//   - "workflow" - the workflow's "id" and "worktree" path
+//   - "bead" - the bead "id", when no bead data has been set
+//
+// A variable stored under the same name takes precedence.
+func (c *StepContext) builtinVariable(name string) (interface{}, bool) {
+	switch name {
+	case "workflow":
+		return map[string]interface{}{
+			"id":       c.WorkflowID,
+			"worktree": c.WorktreePath,
+		}, true
+	case "bead":
+		if c.BeadID != "" {
+			return map[string]interface{}{"id": c.BeadID}, true
+		}
+	}
+	return nil, false
 }
 
 // GetPath resolves a dot-notation path to retrieve a value from the context.
 // Supports paths like:
+//   - "workflow.id", "workflow.worktree" - returns the workflow ID or worktree path
 //   - "bead" - returns the entire bead object
-//   - "bead.title" - returns the bead title
+//   - "bead.id", "bead.title" - returns the bead ID or title
 //   - "step_name.output" - returns the step's raw output
 //   - "step_name.outputs.field" - returns a specific field from parsed JSON
 //   - "previous.success" - returns whether previous step succeeded
 //   - "loop_name.iteration" - returns current loop iteration
+//   - "item", "item.field" - returns the current for_each element or one of its fields
+//   - "index" - returns the current for_each index
 //
 // Returns an error if the path is invalid or the value doesn't exist.
 func (c *StepContext) GetPath(path string) (interface{}, error) {
@@ -139,6 +219,9 @@ func (c *StepContext) GetPath(path string) (interface{}, error) {
 
 	// Get the root value
 	root, exists := c.Variables[parts[0]]
+	if !exists {
+		root, exists = c.builtinVariable(parts[0])
+	}
 	if !exists {
 		return nil, &ContextError{Path: path, Message: fmt.Sprintf("variable %q not found", parts[0])}
 	}
@@ -214,12 +297,21 @@ func (c *StepContext) HasPath(path string) bool {
 
 // ToMap returns a copy of all context variables as a map.
 // This is useful for template rendering. Struct types are converted to maps
-// so templates can access nested fields.
+// so templates can access nested fields. Built-in variables such as
+// workflow.id are included so templates resolve the same paths as GetPath.
 func (c *StepContext) ToMap() map[string]interface{} {
 	result := make(map[string]interface{})
 	for k, v := range c.Variables {
 		result[k] = toTemplateValue(v)
 	}
+	for _, name := range []string{"workflow", "bead"} {
+		if _, exists := result[name]; exists {
+			continue
+		}
+		if v, ok := c.builtinVariable(name); ok {
+			result[name] = v
+		}
+	}
 	return result
 }
 
@@ -236,6 +328,9 @@ func toTemplateValue(v interface{}) interface{} {
 		if val.Outputs != nil {
 			m["outputs"] = val.Outputs
 		}
+		if val.StatusCode != 0 {
+			m["status_code"] = val.StatusCode
+		}
 		return m
 	case *BeadData:
 		m := map[string]interface{}{
@@ -257,6 +352,26 @@ func toTemplateValue(v interface{}) interface{} {
 	}
 }
 
+// normalizeContextValue converts a value to the generic form produced by
+// decoding JSON (maps, slices, and primitives), so fields of arbitrary values
+// resolve the same way as fields of parsed step outputs.
+func normalizeContextValue(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, string, bool, int, int64, float64, map[string]interface{}, []interface{}:
+		return v
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return v
+	}
+	return normalized
+}
+
 // resolvePath navigates through a value following the path parts.
 func resolvePath(current interface{}, parts []string, fullPath string) (interface{}, error) {
 	for i, part := range parts {
@@ -311,6 +311,54 @@ func TestGetPath_BeadFields(t *testing.T) {
 	}
 }
 
+func TestGetPath_Builtins(t *testing.T) {
+	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
+
+	tests := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"workflow.id", "workflow-456"},
+		{"workflow.worktree", "/worktree"},
+		{"bead.id", "bead-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			val, err := ctx.GetPath(tt.path)
+			if err != nil {
+				t.Fatalf("GetPath(%q) error: %v", tt.path, err)
+			}
+			if val != tt.expected {
+				t.Errorf("GetPath(%q) = %v, want %v", tt.path, val, tt.expected)
+			}
+		})
+	}
+
+	t.Run("stored variable takes precedence", func(t *testing.T) {
+		ctx.SetVariable("workflow", map[string]interface{}{"id": "custom"})
+		val, err := ctx.GetPath("workflow.id")
+		if err != nil {
+			t.Fatalf("GetPath() error: %v", err)
+		}
+		if val != "custom" {
+			t.Errorf("GetPath(workflow.id) = %v, want custom", val)
+		}
+	})
+}
+
+func TestToMap_BuiltinsMatchGetPath(t *testing.T) {
+	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
+
+	command, err := RenderCommand("echo {{.workflow.id}} {{.workflow.worktree}} {{.bead.id}}", ctx.ToMap())
+	if err != nil {
+		t.Fatalf("RenderCommand() error: %v", err)
+	}
+	if command != "echo workflow-456 /worktree bead-123" {
+		t.Errorf("RenderCommand() = %q", command)
+	}
+}
+
 func TestGetPath_StepOutput(t *testing.T) {
 	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
 
@@ -820,3 +868,102 @@ func TestGetPathInt_Int64(t *testing.T) {
 		t.Errorf("GetPathInt() = %d, want %d", val, 9999999999)
 	}
 }
+
+func TestSetLoopItem(t *testing.T) {
+	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
+
+	ctx.SetLoopItem("each_issue", 2, map[string]interface{}{
+		"name": "alpha",
+		"meta": map[string]interface{}{"severity": "high"},
+	})
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"item.name", "alpha"},
+		{"item.meta.severity", "high"},
+		{"index", 2},
+		{"each_issue.index", 2},
+		{"each_issue.iteration", 2},
+		{"each_issue.item.name", "alpha"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := ctx.GetPath(tt.path)
+			if err != nil {
+				t.Fatalf("GetPath(%q) error: %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("GetPath(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ctx.GetPath("item.missing"); !IsContextError(err) {
+		t.Errorf("GetPath(item.missing) error = %v, want ContextError", err)
+	}
+}
+
+func TestSetLoopItem_ScalarAndStruct(t *testing.T) {
+	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
+
+	ctx.SetLoopItem("loop", 0, "plain")
+	if got, _ := ctx.GetPathString("item"); got != "plain" {
+		t.Errorf("item = %q, want plain", got)
+	}
+
+	// Arbitrary values are normalized so their fields resolve by JSON name
+	type finding struct {
+		Name string `json:"name"`
+		Line int    `json:"line"`
+	}
+	ctx.SetLoopItem("loop", 1, finding{Name: "beta", Line: 7})
+	if got, _ := ctx.GetPathString("item.name"); got != "beta" {
+		t.Errorf("item.name = %q, want beta", got)
+	}
+	if got, _ := ctx.GetPathInt("item.line"); got != 7 {
+		t.Errorf("item.line = %d, want 7", got)
+	}
+}
+
+func TestPreserveLoopItem(t *testing.T) {
+	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
+
+	restore := ctx.preserveLoopBindings("outer")
+	ctx.SetLoopItem("outer", 0, "first")
+	innerRestore := ctx.preserveLoopBindings("inner")
+	ctx.SetLoopItem("inner", 3, "nested")
+	if got, _ := ctx.GetPathInt("loop.index"); got != 3 {
+		t.Errorf("loop.index = %d, want the inner loop's", got)
+	}
+
+	innerRestore()
+	if got, _ := ctx.GetPathString("item"); got != "first" {
+		t.Errorf("item = %q, want outer element restored", got)
+	}
+	if got, _ := ctx.GetPathInt("index"); got != 0 {
+		t.Errorf("index = %d, want 0", got)
+	}
+	if got, _ := ctx.GetPathString("loop.item"); got != "first" {
+		t.Errorf("loop.item = %q, want outer element restored", got)
+	}
+
+	restore()
+	if ctx.HasPath("item") || ctx.HasPath("index") || ctx.HasPath("loop") {
+		t.Error("item, index and loop should be unbound")
+	}
+}
+
+func TestPreserveLoopBindings_LoopNamedLoop(t *testing.T) {
+	ctx := NewStepContext("/worktree", "bead-123", "workflow-456")
+
+	restore := ctx.preserveLoopBindings("loop")
+	ctx.SetLoopVariable("loop", 2)
+	restore()
+
+	// The loop's own variable outlives it, as for any other loop name
+	if got, err := ctx.GetPathInt("loop.iteration"); err != nil || got != 2 {
+		t.Errorf("loop.iteration = %d, %v; want 2", got, err)
+	}
+}
@@ -2,13 +2,20 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/coven/daemon/internal/grimoire"
 	"github.com/coven/daemon/internal/spell"
 )
 
+// ErrShutdown is the cancellation cause used when the daemon is shutting down.
+// A workflow cancelled with this cause is left in the running state so it is
+// resumed on the next start rather than being marked cancelled.
+var ErrShutdown = errors.New("daemon shutting down")
+
 // EngineConfig contains configuration for the workflow engine.
 type EngineConfig struct {
 	// CovenDir is the path to the .coven directory.
@@ -25,6 +32,33 @@ type EngineConfig struct {
 
 	// Bead contains the full bead data for template context.
 	Bead *BeadData
+
+	// StopAfterStep, when closed, stops the workflow before its next step
+	// starts. The workflow state is saved as running so it can be resumed.
+	StopAfterStep <-chan struct{}
+
+	// LoopBreaks receives requests to break out of the workflow's running
+	// loop steps (optional).
+	LoopBreaks *LoopBreaks
+
+	// LogFlushPolicy controls buffering of the workflow's JSONL log.
+	// If nil, DefaultLogFlushPolicy is used.
+	LogFlushPolicy *LogFlushPolicy
+
+	// LogFormat is the encoding of the workflow's JSONL log.
+	// If empty, LogFormatVerbose is used.
+	LogFormat LogFormat
+
+	// CommandPolicy restricts the commands script steps may run.
+	// If nil, any command may run.
+	CommandPolicy *CommandPolicy
+
+	// StatePersister saves the workflow's state. If nil, one is created for
+	// CovenDir.
+	StatePersister *StatePersister
+
+	// GrimoireOptions configure how grimoires loaded by name are validated.
+	GrimoireOptions grimoire.Options
 }
 
 // ExecutionResult contains the result of workflow execution.
@@ -47,6 +81,28 @@ type ExecutionResult struct {
 	// NeedsAutoMerge indicates a merge step completed with require_review: false
 	// and the scheduler should perform the actual merge to main.
 	NeedsAutoMerge bool
+
+	// MergeOptions are the options of the merge step that needs auto-merge.
+	MergeOptions MergeOptions
+
+	// Escalation contains the failure details when a step escalated the workflow.
+	Escalation *Escalation
+
+	// KeepWorktree indicates the worktree should be kept after completion
+	// rather than removed when the workflow's changes are merged.
+	KeepWorktree bool
+
+	// Interrupted indicates the workflow was stopped by a daemon shutdown.
+	// Its state was saved as running and it will resume on the next start.
+	Interrupted bool
+
+	// NoChanges indicates the workflow completed but every merge step that
+	// ran found nothing to merge, i.e. its agents and scripts changed nothing.
+	NoChanges bool
+
+	// TimedOut indicates the workflow failed because a step, or its prepare
+	// phase, ran past its timeout.
+	TimedOut bool
 }
 
 // Engine executes workflow steps in sequence.
@@ -58,6 +114,13 @@ type Engine struct {
 	agentExecutor  *AgentExecutor
 	loopExecutor   *LoopExecutor
 	mergeExecutor  *MergeExecutor
+	httpExecutor   *HTTPExecutor
+
+	// steps dispatches steps to the executors above and to the step types
+	// added with RegisterStepType. It is created on first use by
+	// stepRegistry.
+	steps     *StepRegistry
+	stepsOnce sync.Once
 
 	// Loaders
 	spellLoader    *spell.Loader
@@ -77,17 +140,28 @@ type Engine struct {
 func NewEngine(config EngineConfig) *Engine {
 	spellLoader := spell.NewLoader(config.CovenDir)
 	grimoireLoader := grimoire.NewLoader(config.CovenDir)
+	grimoireLoader.SetOptions(config.GrimoireOptions)
 
 	scriptExecutor := NewScriptExecutor()
+	scriptExecutor.SetPolicy(config.CommandPolicy)
 	agentExecutor := NewAgentExecutor(spellLoader, nil) // Agent runner set separately
 
 	// Create loop executor with script and agent executors
 	loopExecutor := NewLoopExecutor(scriptExecutor, agentExecutor)
 
 	mergeExecutor := NewMergeExecutor()
+	mergeExecutor.SetSummarizer(agentExecutor)
 
-	statePersister := NewStatePersister(config.CovenDir)
-	logger := NewLogger(config.CovenDir)
+	statePersister := config.StatePersister
+	if statePersister == nil {
+		statePersister = NewStatePersister(config.CovenDir)
+	}
+	logPolicy := DefaultLogFlushPolicy()
+	if config.LogFlushPolicy != nil {
+		logPolicy = *config.LogFlushPolicy
+	}
+	logger := NewLoggerWithPolicy(config.CovenDir, logPolicy)
+	logger.SetFormat(config.LogFormat)
 
 	return &Engine{
 		config:         config,
@@ -95,6 +169,7 @@ func NewEngine(config EngineConfig) *Engine {
 		agentExecutor:  agentExecutor,
 		loopExecutor:   loopExecutor,
 		mergeExecutor:  mergeExecutor,
+		httpExecutor:   NewHTTPExecutor(),
 		spellLoader:    spellLoader,
 		grimoireLoader: grimoireLoader,
 		statePersister: statePersister,
@@ -116,36 +191,43 @@ func NewEngineWithExecutors(
 		agentExecutor:  agentExec,
 		loopExecutor:   loopExec,
 		mergeExecutor:  mergeExec,
+		httpExecutor:   NewHTTPExecutor(),
 	}
 }
 
 // Execute runs a grimoire workflow and returns the result.
 func (e *Engine) Execute(ctx context.Context, g *grimoire.Grimoire) *ExecutionResult {
-	return e.executeFromStep(ctx, g, 0, nil)
+	return e.ExecuteWithInputs(ctx, g, nil)
+}
+
+// ExecuteWithInputs executes a grimoire with values for its inputs. The
+// workflow fails before any step runs if a required input is missing, with a
+// grimoire.MissingInputsError, or if a value isn't of its input's type.
+func (e *Engine) ExecuteWithInputs(ctx context.Context, g *grimoire.Grimoire, inputs map[string]interface{}) *ExecutionResult {
+	return e.executeFromStep(ctx, g, 0, nil, inputs)
 }
 
 // ExecuteFromState resumes a workflow from saved state.
 func (e *Engine) ExecuteFromState(ctx context.Context, g *grimoire.Grimoire, state *WorkflowState) *ExecutionResult {
 	// Start from the next step after the last completed one
-	startStep := state.CurrentStep + 1
-
-	// Pass active step task ID for agent process resumption
-	return e.executeFromStepWithActiveProcess(ctx, g, startStep, state.StepOutputs, state.ActiveStepTaskID)
+	return e.executeFromStep(ctx, g, state.CurrentStep+1, state, state.Inputs)
 }
 
-// executeFromStep runs a grimoire starting from a specific step.
-func (e *Engine) executeFromStep(ctx context.Context, g *grimoire.Grimoire, startStep int, savedOutputs map[string]string) *ExecutionResult {
-	return e.executeFromStepWithActiveProcess(ctx, g, startStep, savedOutputs, "")
-}
-
-// executeFromStepWithActiveProcess runs a grimoire starting from a specific step,
-// with optional active process resumption.
-func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoire.Grimoire, startStep int, savedOutputs map[string]string, activeStepTaskID string) *ExecutionResult {
+// executeFromStep runs a grimoire starting from a specific step. saved is the
+// state of the run being resumed, or nil for a new run: the resumed run keeps
+// its step results and outputs, reconnects to its active agent process, runs
+// its confirmed step instead of waiting for confirmation again and keeps
+// adding up its usage. inputs are the values of the grimoire's inputs.
+func (e *Engine) executeFromStep(ctx context.Context, g *grimoire.Grimoire, startStep int, saved *WorkflowState, inputs map[string]interface{}) *ExecutionResult {
 	start := time.Now()
+	if saved == nil {
+		saved = &WorkflowState{}
+	}
 
 	result := &ExecutionResult{
-		Status:      WorkflowRunning,
-		StepResults: make(map[string]*StepResult),
+		Status:       WorkflowRunning,
+		StepResults:  make(map[string]*StepResult),
+		KeepWorktree: g.KeepWorktree,
 	}
 
 	// Emit workflow started event and log
@@ -156,13 +238,18 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 	if e.loopExecutor != nil && e.logger != nil {
 		e.loopExecutor.SetLogger(e.logger, e.config.WorkflowID, e.config.BeadID)
 	}
+	if e.loopExecutor != nil && e.config.LoopBreaks != nil {
+		e.loopExecutor.SetLoopBreaks(e.config.LoopBreaks)
+	}
 
 	// Create step context
 	stepCtx := NewStepContext(e.config.WorktreePath, e.config.BeadID, e.config.WorkflowID)
+	stepCtx.GrimoireName = g.Name
+	stepCtx.GrimoireHash = g.ContentHash
 
 	// Set active step task ID for agent process resumption
-	if activeStepTaskID != "" {
-		stepCtx.ActiveStepTaskID = activeStepTaskID
+	if saved.ActiveStepTaskID != "" {
+		stepCtx.ActiveStepTaskID = saved.ActiveStepTaskID
 	}
 
 	// Set bead data in context if provided
@@ -171,23 +258,35 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 	}
 
 	// Restore saved outputs from previous steps
-	if savedOutputs != nil {
-		for key, value := range savedOutputs {
-			stepCtx.SetVariable(key, value)
-		}
+	for key, value := range saved.StepOutputs {
+		stepCtx.SetVariable(key, value)
 	}
+	restoreHTTPResponses(g, saved, stepCtx)
 
 	// Initialize persisted state
+	var labels []string
+	if e.config.Bead != nil {
+		labels = e.config.Bead.Labels
+	}
+	workflowUsage := &WorkflowUsage{}
+	if saved.Usage != nil {
+		*workflowUsage = *saved.Usage
+	}
 	workflowState := &WorkflowState{
 		TaskID:         e.config.BeadID,
 		WorkflowID:     e.config.WorkflowID,
 		GrimoireName:   g.Name,
+		GrimoireHash:   g.ContentHash,
 		WorktreePath:   e.config.WorktreePath,
 		Status:         WorkflowRunning,
 		CurrentStep:    startStep - 1, // -1 because we haven't started yet
 		CompletedSteps: make(map[string]*StepResult),
 		StepOutputs:    make(map[string]string),
 		StartedAt:      start,
+		KeepWorktree:   g.KeepWorktree,
+		ConfirmedStep:  saved.ConfirmedStep,
+		Tags:           WorkflowTags(g, labels),
+		Usage:          workflowUsage,
 	}
 
 	// Set up callback to save workflow state when active step task ID changes
@@ -199,32 +298,78 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 		}
 	}
 
-	// Copy any saved outputs to the new state
-	if savedOutputs != nil {
-		for k, v := range savedOutputs {
-			workflowState.StepOutputs[k] = v
+	// Record agent output as workflow progress so a quiet agent stands out
+	var progressMu sync.Mutex
+	stepCtx.OnProgress = func(at time.Time) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		workflowState.LastProgressAt = &at
+		if e.statePersister != nil {
+			e.statePersister.Save(workflowState)
 		}
 	}
 
+	// Add up agent usage; it is saved with the step that ran the agent
+	stepCtx.OnAgentRun = func(duration time.Duration) {
+		progressMu.Lock()
+		defer progressMu.Unlock()
+		workflowUsage.add(duration)
+	}
+
+	// Copy any saved outputs and step results to the new state, so the
+	// steps run before the resume still count
+	for k, v := range saved.StepOutputs {
+		workflowState.StepOutputs[k] = v
+	}
+	for k, v := range saved.CompletedSteps {
+		workflowState.CompletedSteps[k] = v
+	}
+
+	// Bind the grimoire's inputs, failing before any step runs if one is
+	// missing or invalid
+	resolvedInputs, err := g.ResolveInputs(inputs)
+	if err != nil {
+		result.Status = WorkflowFailed
+		result.Error = err
+		result.Duration = time.Since(start)
+		e.saveWorkflowState(workflowState, result)
+		e.emitWorkflowBlocked(err.Error())
+		e.logWorkflowEnd(WorkflowFailed, result.Duration, 0, err.Error())
+		return result
+	}
+	if len(g.Inputs) > 0 {
+		stepCtx.SetVariable("inputs", resolvedInputs)
+		workflowState.Inputs = resolvedInputs
+	}
+
 	// Save initial state
 	if e.statePersister != nil {
 		e.statePersister.Save(workflowState)
 	}
 
+	// Run prepare steps once, before the first step. A resumed workflow has
+	// already been prepared, unless it was interrupted during prepare.
+	if startStep == 0 && len(g.Prepare) > 0 {
+		if stopped := e.runPrepare(ctx, g, stepCtx, workflowState, result, start); stopped != nil {
+			return stopped
+		}
+	}
+
 	// Execute steps starting from startStep
 	for i := startStep; i < len(g.Steps); i++ {
 		step := &g.Steps[i]
 		result.CurrentStep = i
 
+		step = withGrimoireDefaults(g, step)
+
+		// Stop between steps when the daemon is shutting down
+		if e.interruptedByShutdown(ctx) {
+			return e.interrupt(workflowState, result, start)
+		}
+
 		// Check for context cancellation
 		if ctx.Err() != nil {
-			result.Status = WorkflowCancelled
-			result.Error = ctx.Err()
-			result.Duration = time.Since(start)
-			e.saveWorkflowState(workflowState, result)
-			e.emitWorkflowCancelled()
-			e.logWorkflowEnd(WorkflowCancelled, result.Duration, len(result.StepResults), ctx.Err().Error())
-			return result
+			return e.cancel(workflowState, result, start, context.Cause(ctx))
 		}
 
 		// Check 'when' condition - skip step if condition is false
@@ -234,7 +379,10 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 				result.Status = WorkflowFailed
 				result.Error = fmt.Errorf("step %q: failed to evaluate condition: %w", step.Name, err)
 				result.Duration = time.Since(start)
+				workflowState.CurrentStep = i
 				e.saveWorkflowState(workflowState, result)
+				e.emitWorkflowBlocked(result.Error.Error())
+				e.logWorkflowEnd(WorkflowFailed, result.Duration, len(result.StepResults), result.Error.Error())
 				return result
 			}
 			if shouldSkip {
@@ -253,9 +401,17 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 			}
 		}
 
+		// Stop before confirm-gated steps until a human confirms them
+		if step.Confirm {
+			if workflowState.ConfirmedStep != step.Name {
+				return e.awaitConfirmation(step, i, workflowState, result, start)
+			}
+			workflowState.ConfirmedStep = ""
+		}
+
 		// Emit step started event and log
 		e.emitStepStarted(step.Name, string(step.Type), i)
-		e.logStepStart(step.Name, string(step.Type), i)
+		e.logStepStart(step.Name, string(step.Type), i, step.Description)
 
 		// Log step input (command or spell)
 		e.logStepInput(step.Name, nil, step.Spell, step.Command)
@@ -263,9 +419,20 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 		stepStart := time.Now()
 
 		// Execute the step
-		stepResult, err := e.executeStep(ctx, step, stepCtx)
+		stepResult, err := executeTrackingFiles(ctx, step, stepCtx, e.executeStep)
 		stepDuration := time.Since(stepStart)
 
+		// A step killed by shutdown is not recorded, so it reruns on resume
+		if ctx.Err() != nil && errors.Is(context.Cause(ctx), ErrShutdown) {
+			e.logStepEnd(step.Name, string(step.Type), i, false, false, stepDuration, 0, ErrShutdown.Error())
+			return e.interrupt(workflowState, result, start)
+		}
+
+		e.logRenderError(step, err)
+
+		// A step that allows failure continues the workflow however it ends
+		stepResult, err = allowStepFailure(step, stepResult, err)
+
 		if err != nil {
 			result.Status = WorkflowFailed
 			result.Error = fmt.Errorf("step %q failed: %w", step.Name, err)
@@ -282,6 +449,9 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 			return result
 		}
 
+		// Guard against executors that leave the action unset or unknown
+		e.normalizeAction(step.Name, stepResult)
+
 		// Emit step completed event and log
 		e.emitStepCompleted(step.Name, i, stepResult.Success, stepDuration, stepResult.Error)
 		e.logStepEnd(step.Name, string(step.Type), i, stepResult.Success, false, stepDuration, stepResult.ExitCode, stepResult.Error)
@@ -297,10 +467,7 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 		workflowState.CompletedSteps[step.Name] = stepResult
 
 		// Store output in context if configured
-		if step.Output != "" {
-			stepCtx.SetVariable(step.Output, stepResult.Output)
-			workflowState.StepOutputs[step.Output] = stepResult.Output
-		}
+		e.storeStepOutput(step, stepResult, stepCtx, workflowState)
 
 		// Save state after each step completes
 		e.saveWorkflowState(workflowState, result)
@@ -314,6 +481,7 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 			// Check if this was a merge step with auto-merge (require_review: false)
 			if step.Type == grimoire.StepTypeMerge && !step.RequiresReview() {
 				result.NeedsAutoMerge = true
+				result.MergeOptions = MergeOptionsFor(step)
 			}
 			// Continue to next step
 			continue
@@ -324,6 +492,11 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 			if step.Type == grimoire.StepTypeMerge {
 				result.Status = WorkflowPendingMerge
 				e.emitWorkflowMergePending()
+			} else if stepResult.Escalation != nil {
+				result.Status = WorkflowBlocked
+				result.Escalation = stepResult.Escalation
+				workflowState.Escalation = stepResult.Escalation
+				e.emitWorkflowBlocked(fmt.Sprintf("step %q escalated: %s", step.Name, stepResult.Escalation.SuggestedAction))
 			} else {
 				result.Status = WorkflowBlocked
 				e.emitWorkflowBlocked(stepResult.Error)
@@ -336,6 +509,7 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 		case ActionFail:
 			// Step failed, workflow fails
 			result.Status = WorkflowFailed
+			result.TimedOut = stepResult.TimedOut
 			result.Error = fmt.Errorf("step %q failed: %s", step.Name, stepResult.Error)
 			result.Duration = time.Since(start)
 			e.saveWorkflowState(workflowState, result)
@@ -352,9 +526,14 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 	// All steps completed successfully
 	result.Status = WorkflowCompleted
 	result.Duration = time.Since(start)
-
-	// Delete state file on successful completion
-	if e.statePersister != nil {
+	result.NoChanges = noEffectiveChanges(g, workflowState.CompletedSteps)
+	workflowState.NoChanges = result.NoChanges
+
+	// Record the completed run in the task's history, then end it as the
+	// task's current run, unless the worktree is kept for inspection; the
+	// state records where it is until cleanup
+	e.saveWorkflowState(workflowState, result)
+	if !g.KeepWorktree && e.statePersister != nil {
 		e.statePersister.Delete(e.config.BeadID)
 	}
 
@@ -365,6 +544,73 @@ func (e *Engine) executeFromStepWithActiveProcess(ctx context.Context, g *grimoi
 	return result
 }
 
+// interruptedByShutdown reports whether the workflow should stop because the
+// daemon is shutting down.
+func (e *Engine) interruptedByShutdown(ctx context.Context) bool {
+	if errors.Is(context.Cause(ctx), ErrShutdown) {
+		return true
+	}
+	select {
+	case <-e.config.StopAfterStep:
+		return true
+	default:
+		return false
+	}
+}
+
+// interrupt stops the workflow for a daemon shutdown, saving its state as
+// running so that it is picked up by the resume path on the next start.
+func (e *Engine) interrupt(state *WorkflowState, result *ExecutionResult, start time.Time) *ExecutionResult {
+	result.Status = WorkflowRunning
+	result.Interrupted = true
+	result.Duration = time.Since(start)
+	e.saveWorkflowState(state, result)
+	e.logWorkflowEnd(WorkflowRunning, result.Duration, len(result.StepResults), "interrupted by daemon shutdown")
+	return result
+}
+
+// cancel stops the workflow because its context was cancelled.
+func (e *Engine) cancel(state *WorkflowState, result *ExecutionResult, start time.Time, err error) *ExecutionResult {
+	result.Status = WorkflowCancelled
+	result.Error = err
+	result.Duration = time.Since(start)
+	e.saveWorkflowState(state, result)
+	e.emitWorkflowCancelled()
+	e.logWorkflowEnd(WorkflowCancelled, result.Duration, len(result.StepResults), err.Error())
+	return result
+}
+
+// storeStepOutput stores a completed step's output in the context and the
+// persisted state, if the step names an output. Templated names were rendered
+// per run of the step, so the output is stored under each rendered name.
+func (e *Engine) storeStepOutput(step *grimoire.Step, stepResult *StepResult, stepCtx *StepContext, state *WorkflowState) {
+	if len(stepResult.RenderedOutputs) > 0 {
+		for name, output := range stepResult.RenderedOutputs {
+			stepCtx.SetVariable(name, output)
+			state.StepOutputs[name] = output
+		}
+		return
+	}
+	if step.Output != "" && !IsTemplatedOutputName(step.Output) {
+		stepCtx.SetVariable(step.Output, stepResult.Output)
+		state.StepOutputs[step.Output] = stepResult.Output
+	}
+}
+
+// restoreHTTPResponses stores the responses of the http steps a resumed run
+// completed before it stopped under their step names again, as the http
+// executor did when they ran. They are rebuilt from the saved step results.
+func restoreHTTPResponses(g *grimoire.Grimoire, saved *WorkflowState, stepCtx *StepContext) {
+	for _, step := range g.Steps {
+		if step.Type != grimoire.StepTypeHTTP {
+			continue
+		}
+		if completed := saved.CompletedSteps[step.Name]; completed != nil && !completed.Skipped {
+			stepCtx.SetVariable(step.Name, newStepOutput(completed))
+		}
+	}
+}
+
 // saveWorkflowState persists the current workflow state.
 func (e *Engine) saveWorkflowState(state *WorkflowState, result *ExecutionResult) {
 	if e.statePersister == nil {
@@ -379,6 +625,104 @@ func (e *Engine) saveWorkflowState(state *WorkflowState, result *ExecutionResult
 	e.statePersister.Save(state)
 }
 
+// awaitConfirmation stops the workflow before a confirm-gated step. The state
+// records the step as not yet executed, so the workflow resumes with it once
+// confirmed.
+func (e *Engine) awaitConfirmation(step *grimoire.Step, stepIndex int, state *WorkflowState, result *ExecutionResult, start time.Time) *ExecutionResult {
+	state.CurrentStep = stepIndex - 1
+	state.ConfirmedStep = ""
+	state.PendingConfirmation = &Confirmation{
+		StepName:    step.Name,
+		Description: step.Description,
+		RequestedAt: time.Now(),
+	}
+
+	result.Status = WorkflowAwaitingConfirmation
+	result.Duration = time.Since(start)
+	e.saveWorkflowState(state, result)
+
+	reason := fmt.Sprintf("step %q awaits confirmation", step.Name)
+	e.emitWorkflowBlocked(reason)
+	e.logWorkflowEnd(result.Status, result.Duration, len(result.StepResults), reason)
+	return result
+}
+
+// withGrimoireDefaults returns step with the grimoire-wide settings it
+// doesn't set itself applied.
+func withGrimoireDefaults(g *grimoire.Grimoire, step *grimoire.Step) *grimoire.Step {
+	// Steps without their own timeout inherit the grimoire's step_timeout
+	if g.StepTimeout != "" {
+		resolved := step.WithDefaultTimeout(g.StepTimeout)
+		step = &resolved
+	}
+	if g.EnvFile != "" {
+		resolved := step.WithDefaultEnvFile(g.EnvFile)
+		step = &resolved
+	}
+	if g.Sandbox != nil {
+		resolved := step.WithDefaultSandbox(g.Sandbox)
+		step = &resolved
+	}
+	return step
+}
+
+// StepNotFoundError is returned when a grimoire has no top-level step with
+// the requested name.
+type StepNotFoundError struct {
+	GrimoireName string
+	StepName     string
+}
+
+func (e *StepNotFoundError) Error() string {
+	return fmt.Sprintf("grimoire %q has no step %q", e.GrimoireName, e.StepName)
+}
+
+// IsStepNotFound checks if an error is a StepNotFoundError.
+func IsStepNotFound(err error) bool {
+	var notFound *StepNotFoundError
+	return errors.As(err, &notFound)
+}
+
+// RunStep runs the named top-level step of g on its own and returns its
+// result, for trying out a step while writing a grimoire. variables are set
+// in the step's context as the outputs of earlier steps would be. Nothing is
+// logged or persisted, and the step's when condition and confirmation are
+// ignored. A StepNotFoundError is returned if g has no such step.
+func (e *Engine) RunStep(ctx context.Context, g *grimoire.Grimoire, stepName string, variables map[string]string) (*StepResult, error) {
+	var step *grimoire.Step
+	for i := range g.Steps {
+		if g.Steps[i].Name == stepName {
+			step = &g.Steps[i]
+			break
+		}
+	}
+	if step == nil {
+		return nil, &StepNotFoundError{GrimoireName: g.Name, StepName: stepName}
+	}
+	step = withGrimoireDefaults(g, step)
+
+	stepCtx := NewStepContext(e.config.WorktreePath, e.config.BeadID, e.config.WorkflowID)
+	stepCtx.GrimoireName = g.Name
+	stepCtx.GrimoireHash = g.ContentHash
+	if e.config.Bead != nil {
+		stepCtx.SetBead(e.config.Bead)
+	}
+	for key, value := range variables {
+		stepCtx.SetVariable(key, value)
+	}
+
+	start := time.Now()
+	result, err := e.executeStep(ctx, step, stepCtx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Duration == 0 {
+		result.Duration = time.Since(start)
+	}
+	result.NormalizeAction()
+	return result, nil
+}
+
 // GetStatePersister returns the state persister (for external use like resume detection).
 func (e *Engine) GetStatePersister() *StatePersister {
 	return e.statePersister
@@ -386,38 +730,98 @@ func (e *Engine) GetStatePersister() *StatePersister {
 
 // executeStep dispatches to the appropriate executor.
 func (e *Engine) executeStep(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
-	switch step.Type {
-	case grimoire.StepTypeScript:
+	return e.stepRegistry().Execute(ctx, step, stepCtx)
+}
+
+// RegisterStepType adds a step type run by executor to the engine, so its
+// grimoires can use step types defined outside coven. Its steps run at the
+// top level, inside loops and inside parallel steps; see StepRegistry.Register.
+func (e *Engine) RegisterStepType(stepType grimoire.StepType, executor StepExecutor) error {
+	return e.stepRegistry().Register(stepType, executor)
+}
+
+// stepRegistry returns the engine's step registry, creating it with the
+// built-in step types on first use. Its loop executor runs nested steps
+// through the registry too.
+func (e *Engine) stepRegistry() *StepRegistry {
+	e.stepsOnce.Do(func() {
+		e.steps = NewStepRegistry()
+		e.registerBuiltinSteps()
+		if e.loopExecutor != nil {
+			e.loopExecutor.SetStepRegistry(e.steps)
+		}
+	})
+	return e.steps
+}
+
+// registerBuiltinSteps adds the built-in step types to the engine's
+// registry. Each runs with the engine's executor for it when the step starts.
+func (e *Engine) registerBuiltinSteps() {
+	e.steps.set(grimoire.StepTypeScript, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
 		if e.scriptExecutor == nil {
 			return nil, fmt.Errorf("script executor not configured")
 		}
-		return e.scriptExecutor.Execute(ctx, step, stepCtx)
-
-	case grimoire.StepTypeAgent:
+		return executeWithMatrix(ctx, step, stepCtx, e.scriptExecutor)
+	}))
+	e.steps.set(grimoire.StepTypeAgent, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
 		if e.agentExecutor == nil {
 			return nil, fmt.Errorf("agent executor not configured")
 		}
-		return e.agentExecutor.Execute(ctx, step, stepCtx)
-
-	case grimoire.StepTypeLoop:
+		return executeWithMatrix(ctx, step, stepCtx, e.agentExecutor)
+	}))
+	e.steps.set(grimoire.StepTypeLoop, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
 		if e.loopExecutor == nil {
 			return nil, fmt.Errorf("loop executor not configured")
 		}
 		return e.loopExecutor.Execute(ctx, step, stepCtx)
-
-	case grimoire.StepTypeMerge:
+	}))
+	e.steps.set(grimoire.StepTypeMerge, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
 		if e.mergeExecutor == nil {
 			return nil, fmt.Errorf("merge executor not configured")
 		}
 		return e.mergeExecutor.Execute(ctx, step, stepCtx)
+	}))
+	e.steps.set(grimoire.StepTypeHTTP, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		if e.httpExecutor == nil {
+			return nil, fmt.Errorf("http executor not configured")
+		}
+		return e.httpExecutor.Execute(ctx, step, stepCtx)
+	}))
+	e.steps.set(grimoire.StepTypeParallel, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		return executeParallel(ctx, step, stepCtx, e.steps.Execute)
+	}))
+}
 
-	default:
-		return nil, fmt.Errorf("unknown step type: %s", step.Type)
+// normalizeAction replaces an empty or unknown step action with one derived
+// from the step's success, logging a warning so the executor bug is visible.
+func (e *Engine) normalizeAction(stepName string, result *StepResult) {
+	if original, changed := result.NormalizeAction(); changed {
+		e.logStepWarning(stepName, fmt.Sprintf("step returned invalid action %q, defaulting to %q", original, result.Action))
 	}
 }
 
-// ExecuteByName loads a grimoire by name and executes it.
-func (e *Engine) ExecuteByName(ctx context.Context, grimoireName string) *ExecutionResult {
+// allowStepFailure turns a failure of an allow_failure step, including an
+// error running it, into a failed result that lets the workflow continue.
+// Results of other steps, and successful results, are returned unchanged.
+func allowStepFailure(step *grimoire.Step, result *StepResult, err error) (*StepResult, error) {
+	if !step.AllowFailure {
+		return result, err
+	}
+	if err != nil {
+		result = &StepResult{Success: false, ExitCode: -1, Error: err.Error()}
+	}
+	if result == nil || result.Success || result.Skipped {
+		return result, nil
+	}
+	result.AllowedFailure = true
+	result.Action = ActionContinue
+	result.Escalation = nil
+	return result, nil
+}
+
+// ExecuteByName loads a grimoire by name and executes it with values for its
+// inputs, as ExecuteWithInputs does.
+func (e *Engine) ExecuteByName(ctx context.Context, grimoireName string, inputs map[string]interface{}) *ExecutionResult {
 	if e.grimoireLoader == nil {
 		return &ExecutionResult{
 			Status: WorkflowFailed,
@@ -433,7 +837,7 @@ func (e *Engine) ExecuteByName(ctx context.Context, grimoireName string) *Execut
 		}
 	}
 
-	return e.Execute(ctx, g)
+	return e.ExecuteWithInputs(ctx, g, inputs)
 }
 
 // SetAgentRunner sets the agent runner for agent steps.
@@ -527,9 +931,9 @@ func (e *Engine) logWorkflowEnd(status WorkflowStatus, duration time.Duration, s
 	}
 }
 
-func (e *Engine) logStepStart(stepName, stepType string, stepIndex int) {
+func (e *Engine) logStepStart(stepName, stepType string, stepIndex int, description string) {
 	if e.logger != nil {
-		e.logger.LogStepStart(e.config.WorkflowID, e.config.BeadID, stepName, stepType, stepIndex)
+		e.logger.LogStepStart(e.config.WorkflowID, e.config.BeadID, stepName, stepType, stepIndex, description)
 	}
 }
 
@@ -550,3 +954,39 @@ func (e *Engine) logStepOutput(stepName, output, outputVar string, tokensUsed, t
 		e.logger.LogStepOutput(e.config.WorkflowID, e.config.BeadID, stepName, output, outputVar, tokensUsed, tokensLimit)
 	}
 }
+
+func (e *Engine) logStepWarning(stepName, message string) {
+	if e.logger != nil {
+		e.logger.LogStepWarning(e.config.WorkflowID, e.config.BeadID, stepName, message)
+	}
+}
+
+// logRenderError logs err as a render.error event if one of step's templates
+// failed to render.
+func (e *Engine) logRenderError(step *grimoire.Step, err error) {
+	if e.logger != nil {
+		logRenderError(e.logger, e.config.WorkflowID, e.config.BeadID, step, err)
+	}
+}
+
+// noEffectiveChanges reports whether the grimoire ran at least one merge step
+// and none of them found anything to merge. Grimoires without merge steps are
+// never reported as having no changes, since they may not be meant to change
+// files at all.
+func noEffectiveChanges(g *grimoire.Grimoire, completed map[string]*StepResult) bool {
+	ran := false
+	for _, step := range g.Steps {
+		if step.Type != grimoire.StepTypeMerge {
+			continue
+		}
+		result, ok := completed[step.Name]
+		if !ok || result.Skipped {
+			continue
+		}
+		if !result.NoChanges {
+			return false
+		}
+		ran = true
+	}
+	return ran
+}
@@ -2,6 +2,9 @@ package workflow
 
 import (
 	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -489,6 +492,88 @@ func TestEngine_Execute_FullWorkflow(t *testing.T) {
 	}
 }
 
+func TestEngine_Execute_TimeoutActionBlock(t *testing.T) {
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+
+	g := &grimoire.Grimoire{
+		Name: "test-workflow",
+		Steps: []grimoire.Step{
+			{Name: "integration", Type: grimoire.StepTypeScript, Command: "sleep 5", Timeout: "100ms", TimeoutAction: "block"},
+			{Name: "after", Type: grimoire.StepTypeScript, Command: "echo after"},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+
+	if result.Status != WorkflowBlocked {
+		t.Fatalf("Status = %q, want %q, error: %v", result.Status, WorkflowBlocked, result.Error)
+	}
+	if _, ok := result.StepResults["after"]; ok {
+		t.Error("Steps after a blocked step should not run")
+	}
+	if !strings.Contains(result.StepResults["integration"].Error, "timed out") {
+		t.Errorf("Error = %q, want the timeout", result.StepResults["integration"].Error)
+	}
+}
+
+func TestEngine_Execute_Escalation(t *testing.T) {
+	covenDir := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+
+	engine := NewEngine(config)
+
+	g := &grimoire.Grimoire{
+		Name: "test-workflow",
+		Steps: []grimoire.Step{
+			{Name: "check", Type: grimoire.StepTypeScript, Command: "echo 'lint error in main.go'; exit 3", OnFail: "escalate"},
+			{Name: "after", Type: grimoire.StepTypeScript, Command: "echo after"},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+
+	if result.Status != WorkflowBlocked {
+		t.Fatalf("Status = %q, want %q, error: %v", result.Status, WorkflowBlocked, result.Error)
+	}
+	if _, ok := result.StepResults["after"]; ok {
+		t.Error("Steps after an escalation should not run")
+	}
+	if result.Escalation == nil {
+		t.Fatal("Escalation should be set on the result")
+	}
+
+	// Escalation should be persisted with the workflow state
+	state, err := NewStatePersister(covenDir).Load("test-bead")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if state == nil || state.Escalation == nil {
+		t.Fatal("Escalation should be persisted in workflow state")
+	}
+	if state.Escalation.StepName != "check" {
+		t.Errorf("StepName = %q, want %q", state.Escalation.StepName, "check")
+	}
+	if state.Escalation.ExitCode != 3 {
+		t.Errorf("ExitCode = %d, want 3", state.Escalation.ExitCode)
+	}
+	if !strings.Contains(state.Escalation.Output, "lint error in main.go") {
+		t.Errorf("Output = %q, should contain failure output", state.Escalation.Output)
+	}
+	if state.Escalation.SuggestedAction == "" {
+		t.Error("SuggestedAction should be set")
+	}
+}
+
 func TestEngine_Execute_WithCancelledContext(t *testing.T) {
 	config := EngineConfig{
 		CovenDir:     t.TempDir(),
@@ -519,7 +604,7 @@ func TestEngine_Execute_WithCancelledContext(t *testing.T) {
 func TestEngine_ExecuteByName_NoLoader(t *testing.T) {
 	engine := &Engine{config: EngineConfig{}}
 
-	result := engine.ExecuteByName(context.Background(), "test")
+	result := engine.ExecuteByName(context.Background(), "test", nil)
 
 	if result.Status != WorkflowFailed {
 		t.Errorf("Status = %q, want %q", result.Status, WorkflowFailed)
@@ -529,6 +614,86 @@ func TestEngine_ExecuteByName_NoLoader(t *testing.T) {
 	}
 }
 
+// inputsGrimoire is a grimoire with a required and a defaulted input.
+const inputsGrimoire = `name: deploy
+description: Deploy a service
+inputs:
+  - name: environment
+    required: true
+  - name: replicas
+    type: number
+    default: 2
+steps:
+  - name: deploy
+    type: script
+    command: "echo {{.inputs.environment}} x{{.inputs.replicas}}"
+    output: deployed
+`
+
+func TestEngine_ExecuteByName_Inputs(t *testing.T) {
+	covenDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(covenDir, "grimoires"), 0755); err != nil {
+		t.Fatalf("MkdirAll error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(covenDir, "grimoires", "deploy.yaml"), []byte(inputsGrimoire), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	engine := NewEngine(EngineConfig{CovenDir: covenDir, WorktreePath: t.TempDir(), BeadID: "bead-1", WorkflowID: "wf-1"})
+
+	result := engine.ExecuteByName(context.Background(), "deploy", map[string]interface{}{"environment": "staging"})
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+	if got := result.StepResults["deploy"].Output; got != "staging x2" {
+		t.Errorf("Output = %q, want the input and the default rendered", got)
+	}
+}
+
+func TestEngine_ExecuteByName_MissingInputs(t *testing.T) {
+	covenDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(covenDir, "grimoires"), 0755); err != nil {
+		t.Fatalf("MkdirAll error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(covenDir, "grimoires", "deploy.yaml"), []byte(inputsGrimoire), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	engine := NewEngine(EngineConfig{CovenDir: covenDir, WorktreePath: t.TempDir(), BeadID: "bead-1", WorkflowID: "wf-1"})
+
+	result := engine.ExecuteByName(context.Background(), "deploy", nil)
+	if result.Status != WorkflowFailed {
+		t.Fatalf("Status = %q, want %q", result.Status, WorkflowFailed)
+	}
+	if !grimoire.IsMissingInputs(result.Error) {
+		t.Errorf("Error = %v, want a MissingInputsError", result.Error)
+	}
+	if len(result.StepResults) != 0 {
+		t.Errorf("StepResults = %v, want no steps run", result.StepResults)
+	}
+}
+
+func TestEngine_ExecuteFromState_Inputs(t *testing.T) {
+	g, err := grimoire.Parse([]byte(inputsGrimoire))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	engine := NewEngine(EngineConfig{CovenDir: t.TempDir(), WorktreePath: t.TempDir(), BeadID: "bead-1", WorkflowID: "wf-1"})
+
+	// A resumed workflow keeps the inputs it was started with
+	state := &WorkflowState{
+		WorkflowID:  "wf-1",
+		CurrentStep: -1,
+		StepOutputs: map[string]string{},
+		Inputs:      map[string]interface{}{"environment": "prod", "replicas": 3.0},
+	}
+	result := engine.ExecuteFromState(context.Background(), g, state)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+	if got := result.StepResults["deploy"].Output; got != "prod x3" {
+		t.Errorf("Output = %q, want the saved inputs rendered", got)
+	}
+}
+
 func TestEngine_SetAgentRunner(t *testing.T) {
 	config := EngineConfig{
 		CovenDir:     t.TempDir(),
@@ -647,3 +812,842 @@ func TestEngine_Execute_MissingMergeExecutor(t *testing.T) {
 		t.Errorf("Status = %q, want %q", result.Status, WorkflowFailed)
 	}
 }
+
+func TestEngine_NormalizeAction_EmptyOnFailure(t *testing.T) {
+	covenDir := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+	defer engine.logger.Close()
+
+	result := &StepResult{Success: false, Error: "boom"}
+	engine.normalizeAction("build", result)
+
+	if result.Action != ActionFail {
+		t.Errorf("Action = %q, want %q", result.Action, ActionFail)
+	}
+
+	// A warning should be logged so the executor bug is visible
+	engine.logger.Flush("test-wf")
+	entries := readLogEntries(t, engine.logger.LogPath("test-wf"))
+	if len(entries) != 1 || entries[0].Event != LogEventStepWarning {
+		t.Fatalf("log entries = %+v, want one %q entry", entries, LogEventStepWarning)
+	}
+	if !strings.Contains(string(entries[0].Data), "build") {
+		t.Errorf("warning data = %s, want step name", entries[0].Data)
+	}
+}
+
+func TestEngine_NormalizeAction_Valid(t *testing.T) {
+	engine := NewEngine(EngineConfig{
+		CovenDir:   t.TempDir(),
+		BeadID:     "test-bead",
+		WorkflowID: "test-wf",
+	})
+	defer engine.logger.Close()
+
+	result := &StepResult{Success: false, Action: ActionBlock}
+	engine.normalizeAction("review", result)
+
+	if result.Action != ActionBlock {
+		t.Errorf("Action = %q, want %q (valid actions should be kept)", result.Action, ActionBlock)
+	}
+	if _, err := os.Stat(engine.logger.LogPath("test-wf")); !os.IsNotExist(err) {
+		t.Error("No warning should be logged for a valid action")
+	}
+}
+
+func TestEngine_Execute_StopAfterStep(t *testing.T) {
+	covenDir := t.TempDir()
+	worktree := t.TempDir()
+	stop := make(chan struct{})
+	config := EngineConfig{
+		CovenDir:      covenDir,
+		WorktreePath:  worktree,
+		BeadID:        "test-bead",
+		WorkflowID:    "test-wf",
+		StopAfterStep: stop,
+	}
+
+	g := &grimoire.Grimoire{
+		Name: "test-workflow",
+		Steps: []grimoire.Step{
+			{Name: "first", Type: grimoire.StepTypeScript, Command: "sleep 0.3 && touch first"},
+			{Name: "second", Type: grimoire.StepTypeScript, Command: "touch second"},
+		},
+	}
+
+	// Ask the engine to stop while the first step is still running
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		close(stop)
+	}()
+
+	result := NewEngine(config).Execute(context.Background(), g)
+
+	if !result.Interrupted {
+		t.Fatalf("Interrupted = false, want true (status %q, error %v)", result.Status, result.Error)
+	}
+	if result.Status != WorkflowRunning {
+		t.Errorf("Status = %q, want %q", result.Status, WorkflowRunning)
+	}
+	if _, err := os.Stat(worktree + "/first"); err != nil {
+		t.Error("Current step should finish before the workflow stops")
+	}
+	if _, err := os.Stat(worktree + "/second"); err == nil {
+		t.Error("Next step should not start after stop is requested")
+	}
+
+	interrupted, err := NewStatePersister(covenDir).ListInterrupted()
+	if err != nil {
+		t.Fatalf("ListInterrupted() error: %v", err)
+	}
+	if len(interrupted) != 1 {
+		t.Fatalf("ListInterrupted() returned %d states, want 1", len(interrupted))
+	}
+	state := interrupted[0]
+	if state.CurrentStep != 0 {
+		t.Errorf("CurrentStep = %d, want 0", state.CurrentStep)
+	}
+	if state.CompletedSteps["first"] == nil {
+		t.Error("First step should be recorded as completed")
+	}
+
+	// Resuming runs only the remaining step
+	config.StopAfterStep = nil
+	resumed := NewEngine(config).ExecuteFromState(context.Background(), g, state)
+	if resumed.Status != WorkflowCompleted {
+		t.Fatalf("Resumed status = %q, want %q (error %v)", resumed.Status, WorkflowCompleted, resumed.Error)
+	}
+	if len(resumed.StepResults) != 1 || resumed.StepResults["second"] == nil {
+		t.Errorf("Resumed step results = %v, want only second", resumed.StepResults)
+	}
+}
+
+func TestEngine_Execute_ShutdownCancelsStep(t *testing.T) {
+	covenDir := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+
+	g := &grimoire.Grimoire{
+		Name: "test-workflow",
+		Steps: []grimoire.Step{
+			{Name: "slow", Type: grimoire.StepTypeScript, Command: "exec sleep 10"},
+		},
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel(ErrShutdown)
+	}()
+
+	start := time.Now()
+	result := NewEngine(config).Execute(ctx, g)
+
+	if time.Since(start) > 5*time.Second {
+		t.Error("Shutdown should kill the running step")
+	}
+	if !result.Interrupted {
+		t.Fatalf("Interrupted = false, want true (status %q, error %v)", result.Status, result.Error)
+	}
+
+	state, err := NewStatePersister(covenDir).Load("test-bead")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if state.Status != WorkflowRunning {
+		t.Errorf("State status = %q, want %q so the workflow resumes", state.Status, WorkflowRunning)
+	}
+	if state.CurrentStep != -1 {
+		t.Errorf("CurrentStep = %d, want -1 so the killed step reruns", state.CurrentStep)
+	}
+	if len(state.CompletedSteps) != 0 {
+		t.Errorf("CompletedSteps = %v, want none", state.CompletedSteps)
+	}
+}
+
+func TestEngine_Execute_KeepWorktree(t *testing.T) {
+	covenDir := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+
+	g := &grimoire.Grimoire{
+		Name:         "analysis",
+		KeepWorktree: true,
+		Steps: []grimoire.Step{
+			{Name: "report", Type: grimoire.StepTypeScript, Command: "echo done"},
+		},
+	}
+
+	result := NewEngine(config).Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q", result.Status, WorkflowCompleted)
+	}
+	if !result.KeepWorktree {
+		t.Error("KeepWorktree should be set on the result")
+	}
+
+	state, err := NewStatePersister(covenDir).Load("test-bead")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if state == nil {
+		t.Fatal("State should be kept after completion with keep_worktree")
+	}
+	if state.Status != WorkflowCompleted || !state.KeepWorktree {
+		t.Errorf("State = %q keep_worktree=%v, want completed with keep_worktree", state.Status, state.KeepWorktree)
+	}
+}
+
+func TestEngine_Execute_AllowFailure(t *testing.T) {
+	worktree := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+
+	g := &grimoire.Grimoire{
+		Name: "metrics",
+		Steps: []grimoire.Step{
+			{Name: "collect", Type: grimoire.StepTypeScript, Command: "exit 3", AllowFailure: true},
+			{
+				Name:          "retry",
+				Type:          grimoire.StepTypeLoop,
+				MaxIterations: 1,
+				Steps: []grimoire.Step{
+					{Name: "work", Type: grimoire.StepTypeScript, Command: "touch work"},
+					{Name: "sample", Type: grimoire.StepTypeScript, Command: "exit 1", AllowFailure: true},
+				},
+			},
+			{Name: "finish", Type: grimoire.StepTypeScript, Command: "touch finish"},
+		},
+	}
+
+	result := NewEngine(config).Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	collect := result.StepResults["collect"]
+	if collect == nil || collect.Success || !collect.AllowedFailure || collect.ExitCode != 3 {
+		t.Errorf("collect = %+v, want an allowed failure with exit code 3", collect)
+	}
+
+	loop := result.StepResults["retry"]
+	if loop == nil || !loop.Success {
+		t.Fatalf("retry = %+v, want success despite the allowed failure", loop)
+	}
+	if it := loop.Iterations[0]; !it.Success || len(it.Steps) != 2 || !it.Steps[1].AllowedFailure {
+		t.Errorf("iteration = %+v, want success with sample recorded as an allowed failure", it)
+	}
+
+	if _, err := os.Stat(filepath.Join(worktree, "finish")); err != nil {
+		t.Error("Steps after an allowed failure should run")
+	}
+}
+
+func TestEngine_Execute_ConfirmGate(t *testing.T) {
+	covenDir := t.TempDir()
+	worktree := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+
+	g := &grimoire.Grimoire{
+		Name: "release",
+		Steps: []grimoire.Step{
+			{Name: "build", Type: grimoire.StepTypeScript, Command: "touch built"},
+			{Name: "deploy", Type: grimoire.StepTypeScript, Command: "touch deployed", Description: "Deploy to production", Confirm: true},
+		},
+	}
+
+	result := NewEngine(config).Execute(context.Background(), g)
+	if result.Status != WorkflowAwaitingConfirmation {
+		t.Fatalf("Status = %q, want %q", result.Status, WorkflowAwaitingConfirmation)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "built")); err != nil {
+		t.Error("Steps before the gate should run")
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "deployed")); err == nil {
+		t.Fatal("Confirm-gated step should not run before it is confirmed")
+	}
+
+	persister := NewStatePersister(covenDir)
+	state, err := persister.Load("test-bead")
+	if err != nil || state == nil {
+		t.Fatalf("Load() = %v, %v; state should be saved while awaiting confirmation", state, err)
+	}
+	if state.Status != WorkflowAwaitingConfirmation {
+		t.Errorf("State status = %q, want %q", state.Status, WorkflowAwaitingConfirmation)
+	}
+	if state.PendingConfirmation == nil || state.PendingConfirmation.StepName != "deploy" || state.PendingConfirmation.Description != "Deploy to production" {
+		t.Errorf("PendingConfirmation = %+v, want deploy step", state.PendingConfirmation)
+	}
+
+	// Resuming without confirmation stops at the gate again
+	result = NewEngine(config).ExecuteFromState(context.Background(), g, state)
+	if result.Status != WorkflowAwaitingConfirmation {
+		t.Fatalf("Status = %q, want %q without confirmation", result.Status, WorkflowAwaitingConfirmation)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "deployed")); err == nil {
+		t.Fatal("Confirm-gated step should not run on resume without confirmation")
+	}
+
+	state, _ = persister.Load("test-bead")
+	state.ConfirmedStep = "deploy"
+	result = NewEngine(config).ExecuteFromState(context.Background(), g, state)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q after confirmation", result.Status, WorkflowCompleted)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "deployed")); err != nil {
+		t.Error("Confirmed step should run")
+	}
+}
+
+func TestEngine_Execute_GrimoireStepTimeout(t *testing.T) {
+	config := EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+
+	g := &grimoire.Grimoire{
+		Name:        "test-workflow",
+		StepTimeout: "100ms",
+		Steps: []grimoire.Step{
+			{Name: "quick", Type: grimoire.StepTypeScript, Command: "echo ok", Timeout: "10s"},
+			{Name: "slow", Type: grimoire.StepTypeScript, Command: "exec sleep 10"},
+		},
+	}
+
+	start := time.Now()
+	result := NewEngine(config).Execute(context.Background(), g)
+
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("Step without a timeout should use the grimoire step_timeout, not the type default")
+	}
+	if result.Status != WorkflowFailed {
+		t.Fatalf("Status = %q, want %q", result.Status, WorkflowFailed)
+	}
+	if !result.TimedOut {
+		t.Error("TimedOut = false, want true for a workflow failed by a step timeout")
+	}
+	slow := result.StepResults["slow"]
+	if slow == nil || !strings.Contains(slow.Error, "timed out after 100ms") {
+		t.Errorf("slow step result = %+v, want timeout after 100ms", slow)
+	}
+	if g.Steps[1].Timeout != "" {
+		t.Error("Engine should not modify the grimoire's steps")
+	}
+}
+
+func TestEngine_Execute_LogFlushedOnCompletion(t *testing.T) {
+	covenDir := t.TempDir()
+	// A large buffer and no interval flush, so only the end-of-workflow
+	// flush can get entries to disk
+	engine := NewEngine(EngineConfig{
+		CovenDir:       covenDir,
+		WorktreePath:   t.TempDir(),
+		BeadID:         "test-bead",
+		WorkflowID:     "test-wf",
+		LogFlushPolicy: &LogFlushPolicy{BufferSize: 1 << 20},
+	})
+
+	g := &grimoire.Grimoire{
+		Name: "log-test",
+		Steps: []grimoire.Step{
+			{Name: "one", Type: grimoire.StepTypeScript, Command: "echo one"},
+			{Name: "two", Type: grimoire.StepTypeScript, Command: "echo two"},
+			{Name: "three", Type: grimoire.StepTypeScript, Command: "echo three"},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q", result.Status, WorkflowCompleted)
+	}
+
+	entries := readLogEntries(t, engine.logger.LogPath("test-wf"))
+	if len(entries) == 0 {
+		t.Fatal("Expected log entries on disk after completion")
+	}
+	if entries[0].Event != LogEventWorkflowStart {
+		t.Errorf("First event = %q, want %q", entries[0].Event, LogEventWorkflowStart)
+	}
+	if last := entries[len(entries)-1]; last.Event != LogEventWorkflowEnd {
+		t.Errorf("Last event = %q, want %q", last.Event, LogEventWorkflowEnd)
+	}
+
+	stepEnds := 0
+	for _, entry := range entries {
+		if entry.Event == LogEventStepEnd {
+			stepEnds++
+		}
+	}
+	if stepEnds != 3 {
+		t.Errorf("step.end entries = %d, want 3", stepEnds)
+	}
+}
+
+func TestEngine_Execute_MatrixStep(t *testing.T) {
+	worktree := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+
+	g := &grimoire.Grimoire{
+		Name: "matrix-test",
+		Steps: []grimoire.Step{
+			{
+				Name:    "test",
+				Type:    grimoire.StepTypeScript,
+				Command: "echo node-{{.matrix.node}} >> versions.txt",
+				Matrix:  map[string][]interface{}{"node": {18, 20, 22}},
+			},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "versions.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read versions.txt: %v", err)
+	}
+	if got, want := string(data), "node-18\nnode-20\nnode-22\n"; got != want {
+		t.Errorf("versions.txt = %q, want %q", got, want)
+	}
+}
+
+func TestEngine_Execute_NoChanges(t *testing.T) {
+	noReview := false
+	mergeStep := grimoire.Step{Name: "merge", Type: grimoire.StepTypeMerge, RequireReview: &noReview}
+	scriptStep := grimoire.Step{Name: "work", Type: grimoire.StepTypeScript, Command: "true"}
+
+	tests := []struct {
+		name   string
+		steps  []grimoire.Step
+		runner *MockMergeRunner
+		want   bool
+	}{
+		{
+			name:   "merge with nothing to merge",
+			steps:  []grimoire.Step{scriptStep, mergeStep},
+			runner: &MockMergeRunner{},
+			want:   true,
+		},
+		{
+			name:   "merge with file changes",
+			steps:  []grimoire.Step{scriptStep, mergeStep},
+			runner: &MockMergeRunner{Files: []string{"main.go"}, Diff: "diff"},
+			want:   false,
+		},
+		{
+			name:   "merge with commits only",
+			steps:  []grimoire.Step{scriptStep, mergeStep},
+			runner: &MockMergeRunner{Commits: []CommitInfo{{SHA: "abc", Subject: "Add feature"}}},
+			want:   false,
+		},
+		{
+			name:   "no merge step",
+			steps:  []grimoire.Step{scriptStep},
+			runner: &MockMergeRunner{},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewEngine(EngineConfig{
+				CovenDir:     t.TempDir(),
+				WorktreePath: t.TempDir(),
+				BeadID:       "test-bead",
+				WorkflowID:   "test-wf",
+			})
+			engine.mergeExecutor = NewMergeExecutorWithRunner(tt.runner)
+
+			result := engine.Execute(context.Background(), &grimoire.Grimoire{Name: "no-changes", Steps: tt.steps})
+			if result.Status != WorkflowCompleted {
+				t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+			}
+			if result.NoChanges != tt.want {
+				t.Errorf("NoChanges = %v, want %v", result.NoChanges, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngine_Execute_NoChangesPersisted(t *testing.T) {
+	covenDir := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+	engine.mergeExecutor = NewMergeExecutorWithRunner(&MockMergeRunner{})
+
+	noReview := false
+	g := &grimoire.Grimoire{
+		Name:         "no-changes",
+		KeepWorktree: true,
+		Steps:        []grimoire.Step{{Name: "merge", Type: grimoire.StepTypeMerge, RequireReview: &noReview}},
+	}
+
+	if result := engine.Execute(context.Background(), g); !result.NoChanges {
+		t.Fatalf("NoChanges = false, want true")
+	}
+
+	state, err := NewStatePersister(covenDir).Load("test-bead")
+	if err != nil || state == nil {
+		t.Fatalf("Load() = %v, %v; want saved state", state, err)
+	}
+	if !state.NoChanges {
+		t.Error("state.NoChanges = false, want true")
+	}
+}
+
+func TestEngine_ExecuteFromState_KeepsCompletedSteps(t *testing.T) {
+	covenDir := t.TempDir()
+	worktree := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+	noReview := false
+	g := &grimoire.Grimoire{
+		Name:         "no-changes-resume",
+		KeepWorktree: true,
+		Steps: []grimoire.Step{
+			{Name: "merge", Type: grimoire.StepTypeMerge, RequireReview: &noReview},
+			{Name: "gate", Type: grimoire.StepTypeScript, Command: "test -f approved", OnFail: "block"},
+		},
+	}
+
+	engine := NewEngine(config)
+	engine.mergeExecutor = NewMergeExecutorWithRunner(&MockMergeRunner{})
+	if result := engine.Execute(context.Background(), g); result.Status != WorkflowBlocked {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowBlocked, result.Error)
+	}
+	state, err := NewStatePersister(covenDir).Load("test-bead")
+	if err != nil || state == nil {
+		t.Fatalf("Load() = %v, %v; want saved state", state, err)
+	}
+	state.CurrentStep = 0 // retry the gate
+
+	if err := os.WriteFile(filepath.Join(worktree, "approved"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	resumed := NewEngine(config)
+	resumed.mergeExecutor = NewMergeExecutorWithRunner(&MockMergeRunner{})
+	result := resumed.ExecuteFromState(context.Background(), g, state)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("resumed Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+	// The merge step ran before the resume, and found nothing to merge
+	if !result.NoChanges {
+		t.Error("NoChanges = false, want true")
+	}
+
+	saved, err := NewStatePersister(covenDir).Load("test-bead")
+	if err != nil || saved == nil {
+		t.Fatalf("Load() = %v, %v; want saved state", saved, err)
+	}
+	for _, name := range []string{"merge", "gate"} {
+		if saved.CompletedSteps[name] == nil {
+			t.Errorf("CompletedSteps[%q] missing after resume", name)
+		}
+	}
+}
+
+func TestEngine_Execute_PreviousJSONEnv(t *testing.T) {
+	worktree := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+
+	g := &grimoire.Grimoire{
+		Name: "json-handoff",
+		Steps: []grimoire.Step{
+			{
+				Name:    "produce",
+				Type:    grimoire.StepTypeScript,
+				Command: `echo '{"version": "1.2.3", "notes": "it'"'"'s \"quoted\""}'`,
+			},
+			{
+				Name:            "consume",
+				Type:            grimoire.StepTypeScript,
+				Command:         `printf '%s' "$COVEN_PREVIOUS_JSON" > previous.json`,
+				PreviousJSONEnv: true,
+			},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "previous.json"))
+	if err != nil {
+		t.Fatalf("Failed to read previous.json: %v", err)
+	}
+	var got struct {
+		Version string `json:"version"`
+		Notes   string `json:"notes"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("COVEN_PREVIOUS_JSON is not valid JSON: %v (%q)", err, data)
+	}
+	if got.Version != "1.2.3" || got.Notes != `it's "quoted"` {
+		t.Errorf("parsed = %+v, want version 1.2.3 and quoted notes", got)
+	}
+}
+
+func TestEngine_Execute_Prepare(t *testing.T) {
+	worktree := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+
+	g := &grimoire.Grimoire{
+		Name: "prepared",
+		Prepare: []grimoire.Step{
+			{Name: "install", Type: grimoire.StepTypeScript, Command: "touch installed && echo deps-ok", Output: "install_log"},
+		},
+		Steps: []grimoire.Step{
+			{Name: "test", Type: grimoire.StepTypeScript, Command: "test -f installed && echo {{.install_log}}"},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+	if install := result.StepResults["install"]; install == nil || !install.Success {
+		t.Errorf("install result = %+v, want a successful prepare step", install)
+	}
+	if got := result.StepResults["test"].Output; got != "deps-ok" {
+		t.Errorf("test output = %q, want the prepare step's output", got)
+	}
+}
+
+func TestEngine_Execute_PrepareFailureAbortsSteps(t *testing.T) {
+	tests := []struct {
+		name           string
+		command        string
+		prepareTimeout string
+		wantErr        string
+	}{
+		{name: "failing step", command: "exit 4", wantErr: `prepare step "install" failed`},
+		{name: "timeout", command: "exec sleep 5", prepareTimeout: "100ms", wantErr: "prepare timed out after 100ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worktree := t.TempDir()
+			engine := NewEngine(EngineConfig{
+				CovenDir:     t.TempDir(),
+				WorktreePath: worktree,
+				BeadID:       "test-bead",
+				WorkflowID:   "test-wf",
+			})
+
+			g := &grimoire.Grimoire{
+				Name:           "prepared",
+				PrepareTimeout: tt.prepareTimeout,
+				Prepare: []grimoire.Step{
+					{Name: "install", Type: grimoire.StepTypeScript, Command: tt.command},
+				},
+				Steps: []grimoire.Step{
+					{Name: "implement", Type: grimoire.StepTypeScript, Command: "touch ran"},
+				},
+			}
+
+			result := engine.Execute(context.Background(), g)
+			if result.Status != WorkflowFailed {
+				t.Fatalf("Status = %q, want %q", result.Status, WorkflowFailed)
+			}
+			if result.Error == nil || !strings.Contains(result.Error.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want it to contain %q", result.Error, tt.wantErr)
+			}
+
+			// The main steps never ran
+			if _, ok := result.StepResults["implement"]; ok {
+				t.Error("implement should not run after prepare fails")
+			}
+			if _, err := os.Stat(filepath.Join(worktree, "ran")); !os.IsNotExist(err) {
+				t.Error("implement's command should not have run")
+			}
+		})
+	}
+}
+
+func TestEngine_ExecuteFromState_SkipsPrepare(t *testing.T) {
+	worktree := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+
+	g := &grimoire.Grimoire{
+		Name: "prepared",
+		Prepare: []grimoire.Step{
+			{Name: "install", Type: grimoire.StepTypeScript, Command: "touch installed"},
+		},
+		Steps: []grimoire.Step{
+			{Name: "first", Type: grimoire.StepTypeScript, Command: "true"},
+			{Name: "second", Type: grimoire.StepTypeScript, Command: "true"},
+		},
+	}
+
+	result := engine.ExecuteFromState(context.Background(), g, &WorkflowState{CurrentStep: 0})
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "installed")); !os.IsNotExist(err) {
+		t.Error("prepare should not rerun when resuming after the first step")
+	}
+}
+
+func TestEngine_RunStep(t *testing.T) {
+	worktree := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "bead-1",
+		Bead:         &BeadData{ID: "bead-1", Title: "Add login"},
+	})
+	g := &grimoire.Grimoire{
+		Name: "test-grimoire",
+		Steps: []grimoire.Step{
+			{Name: "first", Type: grimoire.StepTypeScript, Command: "exit 1"},
+			{Name: "greet", Type: grimoire.StepTypeScript, Command: "echo {{.bead.title}}: {{.plan}}", When: "false"},
+		},
+	}
+
+	// Only the named step runs, whatever its condition
+	result, err := engine.RunStep(context.Background(), g, "greet", map[string]string{"plan": "use sessions"})
+	if err != nil {
+		t.Fatalf("RunStep() error: %v", err)
+	}
+	if !result.Success || strings.TrimSpace(result.Output) != "Add login: use sessions" {
+		t.Errorf("result = %+v, want the step's output", result)
+	}
+	if result.Action != ActionContinue {
+		t.Errorf("Action = %q, want %q", result.Action, ActionContinue)
+	}
+
+	// Nothing is persisted for the run
+	if engine.GetStatePersister().Exists("bead-1") {
+		t.Error("workflow state persisted for the run")
+	}
+
+	_, err = engine.RunStep(context.Background(), g, "missing", nil)
+	if !IsStepNotFound(err) {
+		t.Errorf("RunStep() error = %v, want StepNotFoundError", err)
+	}
+}
+
+func TestEngine_Execute_TopLevelWhen(t *testing.T) {
+	g := &grimoire.Grimoire{
+		Name: "conditional",
+		Steps: []grimoire.Step{
+			{Name: "build", Type: grimoire.StepTypeScript, Command: "true"},
+			{Name: "deploy", Type: grimoire.StepTypeScript, Command: "touch deployed", When: `{{.bead.priority}} == "P0"`},
+			{Name: "notify", Type: grimoire.StepTypeScript, Command: "touch notified"},
+		},
+	}
+
+	for _, tt := range []struct {
+		priority string
+		deployed bool
+	}{
+		{"P0", true},
+		{"P2", false},
+	} {
+		t.Run(tt.priority, func(t *testing.T) {
+			worktree := t.TempDir()
+			engine := NewEngine(EngineConfig{
+				CovenDir:     t.TempDir(),
+				WorktreePath: worktree,
+				BeadID:       "bead-1",
+				Bead:         &BeadData{ID: "bead-1", Priority: tt.priority},
+			})
+
+			result := engine.Execute(context.Background(), g)
+			if result.Status != WorkflowCompleted {
+				t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+			}
+			if skipped := result.StepResults["deploy"].Skipped; skipped == tt.deployed {
+				t.Errorf("deploy Skipped = %v, want %v", skipped, !tt.deployed)
+			}
+			if _, err := os.Stat(filepath.Join(worktree, "deployed")); os.IsNotExist(err) == tt.deployed {
+				t.Errorf("deploy ran = %v, want %v", !os.IsNotExist(err), tt.deployed)
+			}
+			// The step after a skipped one still runs
+			if _, err := os.Stat(filepath.Join(worktree, "notified")); err != nil {
+				t.Errorf("notify didn't run: %v", err)
+			}
+		})
+	}
+}
+
+func TestEngine_Execute_TopLevelWhenInvalid(t *testing.T) {
+	worktree := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "bead-1",
+	})
+	g := &grimoire.Grimoire{
+		Name: "conditional",
+		Steps: []grimoire.Step{
+			{Name: "deploy", Type: grimoire.StepTypeScript, Command: "touch deployed", When: "{{.bead.priority"},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowFailed {
+		t.Fatalf("Status = %q, want %q", result.Status, WorkflowFailed)
+	}
+	if !IsConditionError(result.Error) || !strings.Contains(result.Error.Error(), `step "deploy"`) {
+		t.Errorf("Error = %v, want a condition error naming the step", result.Error)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "deployed")); !os.IsNotExist(err) {
+		t.Error("step with an invalid condition ran")
+	}
+}
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// parseEnvFile parses dotenv content into KEY=value entries, in file order.
+// Blank lines and lines starting with # are skipped, and a leading "export "
+// is ignored. A value may be wrapped in single or double quotes; an unquoted
+// value ends at a " #" comment.
+func parseEnvFile(content string) ([]string, error) {
+	var env []string
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !isEnvKey(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=value", lineNum)
+		}
+		env = append(env, key+"="+envValue(strings.TrimSpace(value)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// envValue strips the quotes from a quoted value, or the trailing comment
+// from an unquoted one.
+func envValue(value string) string {
+	if len(value) >= 2 {
+		if q := value[0]; (q == '"' || q == '\'') && value[len(value)-1] == q {
+			return value[1 : len(value)-1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
+
+// isEnvKey reports whether key is a valid environment variable name.
+func isEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// loadEnvFile reads the step's env_file from the worktree and renders each
+// value against variables.
+func loadEnvFile(step *grimoire.Step, worktreePath string, variables map[string]interface{}) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(worktreePath, step.EnvFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("env_file %q not found in the worktree", step.EnvFile)
+		}
+		return nil, fmt.Errorf("failed to read env_file %q: %w", step.EnvFile, err)
+	}
+	env, err := parseEnvFile(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("env_file %q: %w", step.EnvFile, err)
+	}
+
+	for i, entry := range env {
+		key, value, _ := strings.Cut(entry, "=")
+		rendered, err := renderText(value, variables)
+		if err != nil {
+			return nil, newRenderError(step, fmt.Sprintf("env_file %s", key), value, err)
+		}
+		env[i] = key + "=" + rendered
+	}
+	return env, nil
+}
+
+// renderEnv renders the values of the step's env against variables and
+// returns them as KEY=value entries, sorted by key. Values go into the
+// command's environment, not the shell command, so they aren't shell escaped.
+func renderEnv(step *grimoire.Step, variables map[string]interface{}) ([]string, error) {
+	keys := make([]string, 0, len(step.Env))
+	for key := range step.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		rendered, err := renderText(step.Env[key], variables)
+		if err != nil {
+			return nil, newRenderError(step, fmt.Sprintf("env %s", key), step.Env[key], err)
+		}
+		env = append(env, key+"="+rendered)
+	}
+	return env, nil
+}
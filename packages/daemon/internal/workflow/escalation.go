@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"fmt"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// Escalation is the payload attached to a workflow when a step with
+// on_fail: escalate fails. It gives a human enough context to act.
+type Escalation struct {
+	// StepName is the name of the step that failed.
+	StepName string `json:"step_name"`
+
+	// StepType is the type of the step that failed.
+	StepType grimoire.StepType `json:"step_type"`
+
+	// Output is the captured output of the failing step.
+	Output string `json:"output,omitempty"`
+
+	// ExitCode is the exit code of the failing step.
+	ExitCode int `json:"exit_code"`
+
+	// Error contains the error message from the failing step, if any.
+	Error string `json:"error,omitempty"`
+
+	// SuggestedAction describes what a human should do next.
+	SuggestedAction string `json:"suggested_action"`
+}
+
+// NewEscalation builds an escalation payload from a failed step result.
+func NewEscalation(step *grimoire.Step, result *StepResult) *Escalation {
+	return &Escalation{
+		StepName:        step.Name,
+		StepType:        step.Type,
+		Output:          result.Output,
+		ExitCode:        result.ExitCode,
+		Error:           result.Error,
+		SuggestedAction: suggestedAction(step, result),
+	}
+}
+
+// suggestedAction returns a human-readable next step for an escalated failure.
+func suggestedAction(step *grimoire.Step, result *StepResult) string {
+	switch step.Type {
+	case grimoire.StepTypeScript:
+		return fmt.Sprintf("Command %q exited with code %d. Inspect the output, fix the problem in the worktree, then retry the workflow.",
+			step.Command, result.ExitCode)
+	case grimoire.StepTypeAgent:
+		return fmt.Sprintf("Agent step %q reported failure. Review the agent output, resolve the problem in the worktree, then retry the workflow.",
+			step.Name)
+	case grimoire.StepTypeHTTP:
+		return fmt.Sprintf("Request to %s returned status %d. Check the service, then retry the workflow.",
+			step.URL, result.StatusCode)
+	default:
+		return fmt.Sprintf("Step %q failed. Review the output, then retry or cancel the workflow.", step.Name)
+	}
+}
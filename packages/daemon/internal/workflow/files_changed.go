@@ -0,0 +1,143 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// worktreeSnapshot records the state of a worktree's changes at a point in
+// time, so the files changed between two snapshots can be found.
+type worktreeSnapshot struct {
+	// head is the commit checked out, or "" if there is none yet.
+	head string
+
+	// files maps each path git status reports to a hash of its content, so
+	// a file that was already modified is reported again only if it changes.
+	files map[string]string
+}
+
+// executeTrackingFiles runs a step with execute and, if the step has
+// track_files set, records the files it changed in the result's
+// FilesChanged. The files can't be tracked if the worktree isn't a git
+// repository; the step runs as usual and FilesChanged is left empty.
+func executeTrackingFiles(ctx context.Context, step *grimoire.Step, stepCtx *StepContext, execute func(context.Context, *grimoire.Step, *StepContext) (*StepResult, error)) (*StepResult, error) {
+	if !step.TrackFiles {
+		return execute(ctx, step, stepCtx)
+	}
+
+	before, snapErr := snapshotWorktree(ctx, stepCtx.WorktreePath)
+	result, err := execute(ctx, step, stepCtx)
+	if snapErr != nil || result == nil {
+		return result, err
+	}
+	after, snapErr := snapshotWorktree(ctx, stepCtx.WorktreePath)
+	if snapErr != nil {
+		return result, err
+	}
+	result.FilesChanged = filesChangedBetween(ctx, stepCtx.WorktreePath, before, after)
+	return result, err
+}
+
+// snapshotWorktree records the current commit and uncommitted changes of
+// the worktree at dir.
+func snapshotWorktree(ctx context.Context, dir string) (*worktreeSnapshot, error) {
+	snap := &worktreeSnapshot{files: make(map[string]string)}
+	if out, err := gitOutput(ctx, dir, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		snap.head = strings.TrimSpace(out)
+	}
+
+	out, err := gitOutput(ctx, dir, "status", "--porcelain", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++ // The source path of a rename or copy follows
+		}
+		path := entry[3:]
+		snap.files[path] = hashFile(filepath.Join(dir, path))
+	}
+	return snap, nil
+}
+
+// filesChangedBetween returns the sorted paths whose content differs between
+// two snapshots of the worktree at dir, including files changed in commits
+// made in between. A file that is unmodified in a snapshot has the content
+// of that snapshot's commit.
+func filesChangedBetween(ctx context.Context, dir string, before, after *worktreeSnapshot) []string {
+	changed := make(map[string]bool)
+	for path, hash := range after.files {
+		if prev, ok := before.files[path]; !ok || prev != hash {
+			changed[path] = true
+		}
+	}
+	for path, hash := range before.files {
+		// Unmodified now, so the worktree has the current commit's content
+		if _, ok := after.files[path]; !ok && hashFile(filepath.Join(dir, path)) != hash {
+			changed[path] = true
+		}
+	}
+
+	if before.head != "" && after.head != "" && before.head != after.head {
+		out, err := gitOutput(ctx, dir, "diff", "--name-only", "-z", before.head, after.head)
+		if err == nil {
+			for _, path := range strings.Split(out, "\x00") {
+				_, dirtyBefore := before.files[path]
+				_, dirtyAfter := after.files[path]
+				if path != "" && !dirtyBefore && !dirtyAfter {
+					changed[path] = true
+				}
+			}
+		}
+	}
+
+	files := make([]string, 0, len(changed))
+	for path := range changed {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// hashFile returns a hex-encoded SHA-256 hash of a file's content, or "" if
+// it doesn't exist.
+func hashFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "unreadable"
+		}
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// gitOutput runs a git command in dir and returns its standard output.
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,78 @@
+package workflow
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+func TestEngine_TrackFiles(t *testing.T) {
+	worktree := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = worktree
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+	if err := exec.Command("git", "init", "-b", "main", worktree).Run(); err != nil {
+		t.Skipf("git init failed: %v", err)
+	}
+	git("config", "user.name", "Test")
+	git("config", "user.email", "test@test.com")
+	os.WriteFile(filepath.Join(worktree, "README.md"), []byte("initial\n"), 0644)
+	os.WriteFile(filepath.Join(worktree, "old.txt"), []byte("old\n"), 0644)
+	git("add", ".")
+	git("commit", "-m", "initial")
+
+	// A change made before the workflow isn't attributed to any step
+	os.WriteFile(filepath.Join(worktree, "scratch.txt"), []byte("scratch\n"), 0644)
+
+	g, err := grimoire.Parse([]byte(`name: track-test
+description: Steps that change files
+steps:
+  - name: generate
+    type: script
+    command: "mkdir -p gen && echo hi > gen/new.txt && rm old.txt"
+    track_files: true
+  - name: commit
+    type: script
+    command: "echo more >> README.md && git add -A && git commit -qm update"
+    track_files: true
+  - name: untracked
+    type: script
+    command: "echo again > gen/new.txt"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+	defer engine.logger.Close()
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	for step, want := range map[string][]string{
+		"generate":  {"gen/new.txt", "old.txt"},
+		"commit":    {"README.md"},
+		"untracked": nil,
+	} {
+		if got := result.StepResults[step].FilesChanged; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s FilesChanged = %v, want %v", step, got, want)
+		}
+	}
+}
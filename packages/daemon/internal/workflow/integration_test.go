@@ -276,7 +276,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "simple-test")
+	result := engine.ExecuteByName(ctx, "simple-test", nil)
 
 	if result.Status != WorkflowCompleted {
 		t.Errorf("Status = %v, want %v", result.Status, WorkflowCompleted)
@@ -339,7 +339,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "context-test")
+	result := engine.ExecuteByName(ctx, "context-test", nil)
 
 	if result.Status != WorkflowCompleted {
 		t.Errorf("Status = %v, want %v", result.Status, WorkflowCompleted)
@@ -407,7 +407,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "failing-test")
+	result := engine.ExecuteByName(ctx, "failing-test", nil)
 
 	if result.Status != WorkflowFailed {
 		t.Errorf("Status = %v, want %v", result.Status, WorkflowFailed)
@@ -473,7 +473,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "continue-test")
+	result := engine.ExecuteByName(ctx, "continue-test", nil)
 
 	if result.Status != WorkflowCompleted {
 		t.Errorf("Status = %v, want %v", result.Status, WorkflowCompleted)
@@ -512,7 +512,7 @@ func TestIntegration_WorkflowExecution_GrimoireNotFound(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "nonexistent-grimoire")
+	result := engine.ExecuteByName(ctx, "nonexistent-grimoire", nil)
 
 	if result.Status != WorkflowFailed {
 		t.Errorf("Status = %v, want %v", result.Status, WorkflowFailed)
@@ -625,7 +625,7 @@ timeout: 2m
 steps:
   - name: setup
     type: script
-    command: "echo 0 > /tmp/counter-{{.workflow_id}}.txt"
+    command: "echo 0 > /tmp/counter-{{.workflow.id}}.txt"
     timeout: 10s
 
   - name: quality-loop
@@ -635,20 +635,20 @@ steps:
     steps:
       - name: increment
         type: script
-        command: "cat /tmp/counter-{{.workflow_id}}.txt | xargs -I{} bash -c 'echo $(({} + 1)) > /tmp/counter-{{.workflow_id}}.txt && cat /tmp/counter-{{.workflow_id}}.txt'"
+        command: "cat /tmp/counter-{{.workflow.id}}.txt | xargs -I{} bash -c 'echo $(({} + 1)) > /tmp/counter-{{.workflow.id}}.txt && cat /tmp/counter-{{.workflow.id}}.txt'"
         timeout: 10s
         output: counter
 
       - name: check-done
         type: script
-        command: "test $(cat /tmp/counter-{{.workflow_id}}.txt) -ge 2"
+        command: "test $(cat /tmp/counter-{{.workflow.id}}.txt) -ge 2"
         timeout: 10s
         on_success: exit_loop
         on_fail: continue
 
   - name: cleanup
     type: script
-    command: "rm -f /tmp/counter-{{.workflow_id}}.txt"
+    command: "rm -f /tmp/counter-{{.workflow.id}}.txt"
     timeout: 10s
 `
 	grimoirePath := filepath.Join(covenDir, "grimoires", "loop-exit-test.yaml")
@@ -667,7 +667,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "loop-exit-test")
+	result := engine.ExecuteByName(ctx, "loop-exit-test", nil)
 
 	if result.Status != WorkflowCompleted {
 		t.Errorf("Status = %v, want %v", result.Status, WorkflowCompleted)
@@ -732,7 +732,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "max-iter-test")
+	result := engine.ExecuteByName(ctx, "max-iter-test", nil)
 
 	// Should be blocked (on_max_iterations: block)
 	if result.Status != WorkflowBlocked {
@@ -797,7 +797,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "context-prop-test")
+	result := engine.ExecuteByName(ctx, "context-prop-test", nil)
 
 	if result.Status != WorkflowCompleted {
 		t.Errorf("Status = %v, want %v", result.Status, WorkflowCompleted)
@@ -879,7 +879,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "timeout-test")
+	result := engine.ExecuteByName(ctx, "timeout-test", nil)
 
 	// Workflow should fail due to timeout
 	if result.Status != WorkflowFailed {
@@ -944,7 +944,7 @@ steps:
 	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
 	defer cancel()
 
-	result := engine.ExecuteByName(ctx, "slow-workflow")
+	result := engine.ExecuteByName(ctx, "slow-workflow", nil)
 
 	// Workflow should be cancelled
 	if result.Status != WorkflowCancelled && result.Status != WorkflowFailed {
@@ -0,0 +1,252 @@
+package workflow
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// LogFormat is the encoding of workflow log entries.
+type LogFormat string
+
+const (
+	// LogFormatVerbose writes entries as LogEntry marshals them, with full
+	// field names and RFC 3339 timestamps. It is the default.
+	LogFormatVerbose LogFormat = "verbose"
+
+	// LogFormatCompact writes entries with short field names, millisecond
+	// Unix timestamps and zero-valued data fields left out, for workflows
+	// that log heavily.
+	LogFormatCompact LogFormat = "compact"
+)
+
+// IsValid returns true if f is a known format. Empty means verbose.
+func (f LogFormat) IsValid() bool {
+	switch f {
+	case "", LogFormatVerbose, LogFormatCompact:
+		return true
+	default:
+		return false
+	}
+}
+
+// compactLogEntry is a LogEntry in the compact format.
+type compactLogEntry struct {
+	Time       int64           `json:"t"`
+	Event      LogEventType    `json:"e"`
+	WorkflowID string          `json:"w"`
+	BeadID     string          `json:"b,omitempty"`
+	Data       json.RawMessage `json:"d,omitempty"`
+}
+
+// compactDataKeys maps the data field names of the verbose format to their
+// compact names. Fields not listed keep their names.
+var compactDataKeys = map[string]string{
+	"grimoire":       "g",
+	"worktree_path":  "wt",
+	"status":         "st",
+	"duration_ms":    "ms",
+	"error":          "err",
+	"step_count":     "n",
+	"step_name":      "s",
+	"step_type":      "y",
+	"step_index":     "i",
+	"description":    "desc",
+	"success":        "ok",
+	"skipped":        "sk",
+	"exit_code":      "x",
+	"variables":      "v",
+	"spell":          "sp",
+	"command":        "c",
+	"output":         "o",
+	"output_var":     "ov",
+	"tokens_used":    "tu",
+	"tokens_limit":   "tl",
+	"iteration":      "it",
+	"max_iterations": "mi",
+	"loop_type":      "lt",
+	"should_break":   "br",
+	"message":        "m",
+	"field":          "f",
+	"template":       "tp",
+	"expression":     "ex",
+}
+
+// verboseDataKeys maps compact data field names back to their verbose names.
+var verboseDataKeys = func() map[string]string {
+	keys := make(map[string]string, len(compactDataKeys))
+	for verbose, compact := range compactDataKeys {
+		keys[compact] = verbose
+	}
+	return keys
+}()
+
+// logEventData returns a value to decode the data of event into, or nil for
+// an event without a data type.
+func logEventData(event LogEventType) interface{} {
+	switch event {
+	case LogEventWorkflowStart:
+		return &WorkflowStartData{}
+	case LogEventWorkflowEnd:
+		return &WorkflowEndData{}
+	case LogEventStepStart:
+		return &StepStartData{}
+	case LogEventStepEnd:
+		return &StepEndData{}
+	case LogEventStepInput:
+		return &StepInputData{}
+	case LogEventStepOutput:
+		return &StepOutputData{}
+	case LogEventLoopIteration:
+		return &LoopIterationData{}
+	case LogEventStepWarning:
+		return &StepWarningData{}
+	case LogEventRenderError:
+		return &RenderErrorData{}
+	default:
+		return nil
+	}
+}
+
+// encodeLogEntry returns the line for entry in format, without the newline.
+func encodeLogEntry(entry LogEntry, format LogFormat) ([]byte, error) {
+	if format != LogFormatCompact {
+		return json.Marshal(entry)
+	}
+
+	data, err := renameDataKeys(entry.Data, compactDataKeys, true)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(compactLogEntry{
+		Time:       entry.Timestamp.UnixMilli(),
+		Event:      entry.Event,
+		WorkflowID: entry.WorkflowID,
+		BeadID:     entry.BeadID,
+		Data:       data,
+	})
+}
+
+// anyLogEntry holds a log line in either format.
+type anyLogEntry struct {
+	LogEntry
+	compactLogEntry
+}
+
+// ParseLogEntry decodes a log line written in either format. Entries are
+// returned as the verbose format has them, so the same events read the
+// same whichever format they were written in.
+func ParseLogEntry(line []byte) (LogEntry, error) {
+	var raw anyLogEntry
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return LogEntry{}, err
+	}
+	if raw.compactLogEntry.Event == "" {
+		return raw.LogEntry, nil
+	}
+
+	compact := raw.compactLogEntry
+	entry := LogEntry{
+		Timestamp:  time.UnixMilli(compact.Time),
+		Event:      compact.Event,
+		WorkflowID: compact.WorkflowID,
+		BeadID:     compact.BeadID,
+	}
+	data, err := renameDataKeys(compact.Data, verboseDataKeys, false)
+	if err != nil {
+		return LogEntry{}, err
+	}
+	// Round-trip known data through its type to restore the zero values the
+	// compact format left out
+	if target := logEventData(entry.Event); target != nil {
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, target); err != nil {
+				return LogEntry{}, fmt.Errorf("invalid %s data: %w", entry.Event, err)
+			}
+		}
+		if data, err = json.Marshal(target); err != nil {
+			return LogEntry{}, err
+		}
+	}
+	entry.Data = data
+	return entry, nil
+}
+
+// ReadLog decodes the entries of a workflow log written in either format. A
+// final line without a newline that doesn't parse is an entry still being
+// written, and is skipped.
+func ReadLog(r io.Reader) ([]LogEntry, error) {
+	var entries []LogEntry
+	reader := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			entry, parseErr := ParseLogEntry(line)
+			if parseErr != nil && err == io.EOF {
+				return entries, nil
+			}
+			if parseErr != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, parseErr)
+			}
+			entries = append(entries, entry)
+		}
+		if err == io.EOF {
+			return entries, nil
+		}
+	}
+}
+
+// ReadLogFile decodes the entries of the workflow log at path.
+func ReadLogFile(path string) ([]LogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadLog(f)
+}
+
+// renameDataKeys renames the top-level fields of a data object using names,
+// leaving out zero-valued fields if omitZero is set. Data that isn't an
+// object is returned unchanged.
+func renameDataKeys(data json.RawMessage, names map[string]string, omitZero bool) (json.RawMessage, error) {
+	if len(data) == 0 || data[0] != '{' {
+		return data, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("invalid log data: %w", err)
+	}
+
+	renamed := make(map[string]json.RawMessage, len(fields))
+	for key, value := range fields {
+		if omitZero && isZeroJSON(value) {
+			continue
+		}
+		if name, ok := names[key]; ok {
+			key = name
+		}
+		renamed[key] = value
+	}
+	if len(renamed) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(renamed)
+}
+
+// isZeroJSON reports whether value is null, false, zero or an empty string.
+func isZeroJSON(value json.RawMessage) bool {
+	switch string(value) {
+	case "null", "false", "0", `""`:
+		return true
+	default:
+		return false
+	}
+}
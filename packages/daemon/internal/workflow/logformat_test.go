@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSampleLog logs a short workflow in format with a fixed clock and
+// returns the log's path.
+func writeSampleLog(t *testing.T, format LogFormat) string {
+	t.Helper()
+	logger := newUnbufferedLogger(t.TempDir())
+	logger.SetFormat(format)
+	start := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	tick := 0
+	logger.now = func() time.Time {
+		tick++
+		return start.Add(time.Duration(tick) * 250 * time.Millisecond)
+	}
+
+	logger.LogWorkflowStart("wf-1", "bead-1", "implement-feature", "/tmp/worktree")
+	logger.LogStepStart("wf-1", "bead-1", "test", "script", 0, "")
+	logger.LogStepInput("wf-1", "bead-1", "test", map[string]string{"target": "unit"}, "", "make test")
+	logger.LogStepOutput("wf-1", "bead-1", "test", "ok\n", "", 0, 0)
+	logger.LogStepEnd("wf-1", "bead-1", "test", "script", 0, false, false, 1500*time.Millisecond, 2, "exit status 2")
+	logger.LogLoopIteration("wf-1", "bead-1", "fix", 1, 3, "until", false)
+	logger.LogStepWarning("wf-1", "bead-1", "fix", "output truncated")
+	logger.LogWorkflowEnd("wf-1", "bead-1", WorkflowFailed, 3*time.Second, 2, "step test failed")
+
+	return logger.LogPath("wf-1")
+}
+
+func TestReadLog_CompactMatchesVerbose(t *testing.T) {
+	verbosePath := writeSampleLog(t, LogFormatVerbose)
+	compactPath := writeSampleLog(t, LogFormatCompact)
+
+	verbose, err := ReadLogFile(verbosePath)
+	if err != nil {
+		t.Fatalf("ReadLogFile(verbose) error: %v", err)
+	}
+	compact, err := ReadLogFile(compactPath)
+	if err != nil {
+		t.Fatalf("ReadLogFile(compact) error: %v", err)
+	}
+
+	if len(verbose) != 8 || len(compact) != len(verbose) {
+		t.Fatalf("got %d verbose and %d compact entries, want 8 of each", len(verbose), len(compact))
+	}
+	for i := range verbose {
+		v, c := verbose[i], compact[i]
+		if !c.Timestamp.Equal(v.Timestamp) {
+			t.Errorf("entry %d: Timestamp = %v, want %v", i, c.Timestamp, v.Timestamp)
+		}
+		if c.Event != v.Event || c.WorkflowID != v.WorkflowID || c.BeadID != v.BeadID {
+			t.Errorf("entry %d = %+v, want %+v", i, c, v)
+		}
+		if string(c.Data) != string(v.Data) {
+			t.Errorf("entry %d: Data = %s, want %s", i, c.Data, v.Data)
+		}
+	}
+
+	// The compact log is smaller
+	verboseInfo, _ := os.Stat(verbosePath)
+	compactInfo, _ := os.Stat(compactPath)
+	if compactInfo.Size() >= verboseInfo.Size() {
+		t.Errorf("compact log is %d bytes, verbose %d; want it smaller", compactInfo.Size(), verboseInfo.Size())
+	}
+}
+
+func TestLogger_CompactFormat(t *testing.T) {
+	path := writeSampleLog(t, LogFormatCompact)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := `{"t":1705314600500,"e":"step.start","w":"wf-1","b":"bead-1","d":{"s":"test","y":"script"}}`
+	if lines[1] != want {
+		t.Errorf("step.start line = %s, want %s", lines[1], want)
+	}
+}
+
+func TestReadLog_VerboseUnchanged(t *testing.T) {
+	line := `{"timestamp":"2024-01-15T10:30:00Z","event":"step.warning","workflow_id":"wf-1","data":{"step_name":"fix","message":"slow"}}`
+
+	entries, err := ReadLog(strings.NewReader(line + "\n\n"))
+	if err != nil {
+		t.Fatalf("ReadLog() error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if entries[0].Event != LogEventStepWarning || string(entries[0].Data) != `{"step_name":"fix","message":"slow"}` {
+		t.Errorf("entry = %+v", entries[0])
+	}
+}
+
+func TestReadLog_InvalidLine(t *testing.T) {
+	_, err := ReadLog(strings.NewReader("{\"e\":\"step.start\",\"w\":\"wf-1\"}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadLog() error = %v, want one for line 2", err)
+	}
+}
+
+func TestReadLog_PartialLastLine(t *testing.T) {
+	entries, err := ReadLog(strings.NewReader("{\"e\":\"step.start\",\"w\":\"wf-1\"}\n{\"e\":\"step.out"))
+	if err != nil {
+		t.Fatalf("ReadLog() error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Event != LogEventStepStart {
+		t.Errorf("entries = %+v, want the complete step.start entry only", entries)
+	}
+}
+
+func TestReadLogFile_Missing(t *testing.T) {
+	if _, err := ReadLogFile(filepath.Join(t.TempDir(), "missing.jsonl")); !os.IsNotExist(err) {
+		t.Errorf("ReadLogFile() error = %v, want not exist", err)
+	}
+}
+
+func TestLogFormat_IsValid(t *testing.T) {
+	for _, format := range []LogFormat{"", LogFormatVerbose, LogFormatCompact} {
+		if !format.IsValid() {
+			t.Errorf("%q.IsValid() = false, want true", format)
+		}
+	}
+	if LogFormat("binary").IsValid() {
+		t.Error(`"binary".IsValid() = true, want false`)
+	}
+}
@@ -1,6 +1,7 @@
 package workflow
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -20,6 +21,8 @@ const (
 	LogEventStepInput     LogEventType = "step.input"
 	LogEventStepOutput    LogEventType = "step.output"
 	LogEventLoopIteration LogEventType = "loop.iteration"
+	LogEventStepWarning   LogEventType = "step.warning"
+	LogEventRenderError   LogEventType = "render.error"
 )
 
 // LogEntry represents a single JSONL log entry.
@@ -47,9 +50,10 @@ type WorkflowEndData struct {
 
 // StepStartData is the data for step.start events.
 type StepStartData struct {
-	StepName  string `json:"step_name"`
-	StepType  string `json:"step_type"`
-	StepIndex int    `json:"step_index"`
+	StepName    string `json:"step_name"`
+	StepType    string `json:"step_type"`
+	StepIndex   int    `json:"step_index"`
+	Description string `json:"description,omitempty"`
 }
 
 // StepEndData is the data for step.end events.
@@ -90,21 +94,97 @@ type LoopIterationData struct {
 	ShouldBreak bool   `json:"should_break,omitempty"`
 }
 
+// StepWarningData is the data for step.warning events.
+type StepWarningData struct {
+	StepName string `json:"step_name"`
+	Message  string `json:"message"`
+}
+
+// RenderErrorData is the data for render.error events.
+type RenderErrorData struct {
+	StepName   string `json:"step_name"`
+	Field      string `json:"field"`
+	Template   string `json:"template"`
+	Expression string `json:"expression,omitempty"`
+	Error      string `json:"error"`
+}
+
+// Default log buffering policy.
+const (
+	// DefaultLogFlushInterval is how long a written entry may sit in the
+	// buffer before it is flushed to disk.
+	DefaultLogFlushInterval = 500 * time.Millisecond
+
+	// DefaultLogBufferSize is how many bytes are buffered before a flush.
+	DefaultLogBufferSize = 64 * 1024
+)
+
+// LogFlushPolicy controls how workflow log entries are buffered.
+// Buffered entries are always flushed when the workflow ends, including when
+// it blocks or is interrupted, so a finished workflow's log is complete.
+type LogFlushPolicy struct {
+	// Interval is the longest an entry stays buffered before it is flushed,
+	// so readers following the log see new lines promptly. Zero flushes only
+	// when the buffer fills or the workflow ends.
+	Interval time.Duration
+
+	// BufferSize is the number of bytes buffered before a flush.
+	// Zero or less disables buffering and writes each entry immediately.
+	BufferSize int
+}
+
+// DefaultLogFlushPolicy returns the default log buffering policy.
+func DefaultLogFlushPolicy() LogFlushPolicy {
+	return LogFlushPolicy{
+		Interval:   DefaultLogFlushInterval,
+		BufferSize: DefaultLogBufferSize,
+	}
+}
+
+// logFile is an open workflow log file and its write buffer.
+type logFile struct {
+	f     *os.File
+	w     *bufio.Writer // nil when buffering is disabled
+	timer *time.Timer   // pending interval flush, if any
+}
+
 // Logger writes structured JSONL logs for workflow execution.
 type Logger struct {
 	logDir string
+	policy LogFlushPolicy
+	format LogFormat
+	now    func() time.Time
 	mu     sync.Mutex
-	files  map[string]*os.File // workflowID -> file handle
+	files  map[string]*logFile // workflowID -> open log file
 }
 
-// NewLogger creates a new workflow logger.
+// NewLogger creates a new workflow logger with the default flush policy.
 func NewLogger(covenDir string) *Logger {
+	return NewLoggerWithPolicy(covenDir, DefaultLogFlushPolicy())
+}
+
+// NewLoggerWithPolicy creates a new workflow logger with the given flush policy.
+func NewLoggerWithPolicy(covenDir string, policy LogFlushPolicy) *Logger {
 	return &Logger{
 		logDir: filepath.Join(covenDir, "logs", "workflows"),
-		files:  make(map[string]*os.File),
+		policy: policy,
+		format: LogFormatVerbose,
+		now:    time.Now,
+		files:  make(map[string]*logFile),
 	}
 }
 
+// SetFormat sets the encoding of entries written from now on. Empty means
+// verbose.
+func (l *Logger) SetFormat(format LogFormat) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if format == "" {
+		format = LogFormatVerbose
+	}
+	l.format = format
+}
+
 // LogDir returns the directory where workflow logs are stored.
 func (l *Logger) LogDir() string {
 	return l.logDir
@@ -115,14 +195,14 @@ func (l *Logger) LogPath(workflowID string) string {
 	return filepath.Join(l.logDir, workflowID+".jsonl")
 }
 
-// Close closes all open log files.
+// Close flushes and closes all open log files.
 func (l *Logger) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	var lastErr error
-	for id, f := range l.files {
-		if err := f.Close(); err != nil {
+	for id, lf := range l.files {
+		if err := lf.close(); err != nil {
 			lastErr = err
 		}
 		delete(l.files, id)
@@ -130,26 +210,35 @@ func (l *Logger) Close() error {
 	return lastErr
 }
 
-// CloseWorkflow closes the log file for a specific workflow.
+// CloseWorkflow flushes and closes the log file for a specific workflow.
 func (l *Logger) CloseWorkflow(workflowID string) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if f, ok := l.files[workflowID]; ok {
-		err := f.Close()
+	if lf, ok := l.files[workflowID]; ok {
+		err := lf.close()
 		delete(l.files, workflowID)
 		return err
 	}
 	return nil
 }
 
-// getFile returns the file handle for a workflow, creating it if needed.
-func (l *Logger) getFile(workflowID string) (*os.File, error) {
+// Flush writes any buffered entries for a workflow to disk.
+func (l *Logger) Flush(workflowID string) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	if f, ok := l.files[workflowID]; ok {
-		return f, nil
+	if lf, ok := l.files[workflowID]; ok {
+		return lf.flush()
+	}
+	return nil
+}
+
+// getFile returns the log file for a workflow, opening it if needed.
+// The caller must hold l.mu.
+func (l *Logger) getFile(workflowID string) (*logFile, error) {
+	if lf, ok := l.files[workflowID]; ok {
+		return lf, nil
 	}
 
 	// Ensure log directory exists
@@ -164,42 +253,102 @@ func (l *Logger) getFile(workflowID string) (*os.File, error) {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
 
-	l.files[workflowID] = f
-	return f, nil
+	lf := &logFile{f: f}
+	if l.policy.BufferSize > 0 {
+		lf.w = bufio.NewWriterSize(f, l.policy.BufferSize)
+	}
+	l.files[workflowID] = lf
+	return lf, nil
 }
 
-// log writes an entry to the log file.
-func (l *Logger) log(workflowID, beadID string, event LogEventType, data interface{}) error {
-	f, err := l.getFile(workflowID)
-	if err != nil {
+// write appends a line to the log file, flushing first if the buffer can't
+// hold it, and schedules an interval flush for what remains buffered.
+// The caller must hold l.mu.
+func (l *Logger) write(workflowID string, lf *logFile, line []byte) error {
+	if lf.w == nil {
+		_, err := lf.f.Write(line)
 		return err
 	}
 
+	// Flush before a line that doesn't fit so readers never see a partial
+	// line; a line larger than the buffer is then written straight through
+	if lf.w.Buffered() > 0 && len(line) > lf.w.Available() {
+		if err := lf.flush(); err != nil {
+			return err
+		}
+	}
+	if _, err := lf.w.Write(line); err != nil {
+		return err
+	}
+
+	if lf.w.Buffered() > 0 && lf.timer == nil && l.policy.Interval > 0 {
+		lf.timer = time.AfterFunc(l.policy.Interval, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			// The file may have been closed and reopened since the flush was scheduled
+			if current, ok := l.files[workflowID]; ok && current == lf {
+				lf.timer = nil
+				lf.w.Flush()
+			}
+		})
+	}
+	return nil
+}
+
+// flush writes buffered entries to disk and cancels any pending interval flush.
+func (lf *logFile) flush() error {
+	if lf.timer != nil {
+		lf.timer.Stop()
+		lf.timer = nil
+	}
+	if lf.w == nil {
+		return nil
+	}
+	return lf.w.Flush()
+}
+
+// close flushes buffered entries and closes the file.
+func (lf *logFile) close() error {
+	flushErr := lf.flush()
+	if err := lf.f.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// log writes an entry to the log file.
+func (l *Logger) log(workflowID, beadID string, event LogEventType, data interface{}) error {
 	var dataJSON json.RawMessage
 	if data != nil {
+		var err error
 		dataJSON, err = json.Marshal(data)
 		if err != nil {
 			return fmt.Errorf("failed to marshal log data: %w", err)
 		}
 	}
 
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	entry := LogEntry{
-		Timestamp:  time.Now(),
+		Timestamp:  l.now(),
 		Event:      event,
 		WorkflowID: workflowID,
 		BeadID:     beadID,
 		Data:       dataJSON,
 	}
 
-	line, err := json.Marshal(entry)
+	line, err := encodeLogEntry(entry, l.format)
 	if err != nil {
 		return fmt.Errorf("failed to marshal log entry: %w", err)
 	}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	lf, err := l.getFile(workflowID)
+	if err != nil {
+		return err
+	}
 
-	if _, err := f.Write(append(line, '\n')); err != nil {
+	if err := l.write(workflowID, lf, append(line, '\n')); err != nil {
 		return fmt.Errorf("failed to write log entry: %w", err)
 	}
 
@@ -226,16 +375,17 @@ func (l *Logger) LogWorkflowEnd(workflowID, beadID string, status WorkflowStatus
 		return err
 	}
 
-	// Close the log file when workflow ends
+	// Flush and close the log file when workflow ends
 	return l.CloseWorkflow(workflowID)
 }
 
 // LogStepStart logs a step start event.
-func (l *Logger) LogStepStart(workflowID, beadID, stepName, stepType string, stepIndex int) error {
+func (l *Logger) LogStepStart(workflowID, beadID, stepName, stepType string, stepIndex int, description string) error {
 	return l.log(workflowID, beadID, LogEventStepStart, StepStartData{
-		StepName:  stepName,
-		StepType:  stepType,
-		StepIndex: stepIndex,
+		StepName:    stepName,
+		StepType:    stepType,
+		StepIndex:   stepIndex,
+		Description: description,
 	})
 }
 
@@ -284,3 +434,22 @@ func (l *Logger) LogLoopIteration(workflowID, beadID, stepName string, iteration
 		ShouldBreak: shouldBreak,
 	})
 }
+
+// LogStepWarning logs a non-fatal problem detected while running a step.
+func (l *Logger) LogStepWarning(workflowID, beadID, stepName, message string) error {
+	return l.log(workflowID, beadID, LogEventStepWarning, StepWarningData{
+		StepName: stepName,
+		Message:  message,
+	})
+}
+
+// LogRenderError logs a step template that failed to render.
+func (l *Logger) LogRenderError(workflowID, beadID string, renderErr *RenderError) error {
+	return l.log(workflowID, beadID, LogEventRenderError, RenderErrorData{
+		StepName:   renderErr.StepName,
+		Field:      renderErr.Field,
+		Template:   renderErr.Template,
+		Expression: renderErr.Expression,
+		Error:      renderErr.Err.Error(),
+	})
+}
@@ -29,7 +29,7 @@ func TestLogger_LogPath(t *testing.T) {
 
 func TestLogger_LogWorkflowStart(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	err := logger.LogWorkflowStart("wf-test-1", "bead-123", "test-grimoire", "/path/to/worktree")
@@ -70,7 +70,7 @@ func TestLogger_LogWorkflowStart(t *testing.T) {
 
 func TestLogger_LogWorkflowEnd(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	// Don't defer Close - LogWorkflowEnd should close the file
 
 	logger.LogWorkflowStart("wf-test-2", "bead-456", "test-grimoire", "/worktree")
@@ -109,7 +109,7 @@ func TestLogger_LogWorkflowEnd(t *testing.T) {
 
 func TestLogger_LogWorkflowEnd_WithError(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 
 	logger.LogWorkflowStart("wf-error", "bead-err", "test-grimoire", "/worktree")
 
@@ -134,10 +134,10 @@ func TestLogger_LogWorkflowEnd_WithError(t *testing.T) {
 
 func TestLogger_LogStepStart(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
-	err := logger.LogStepStart("wf-step-1", "bead-1", "analyze", "agent", 0)
+	err := logger.LogStepStart("wf-step-1", "bead-1", "analyze", "agent", 0, "Analyze the codebase")
 	if err != nil {
 		t.Fatalf("LogStepStart() error: %v", err)
 	}
@@ -164,11 +164,14 @@ func TestLogger_LogStepStart(t *testing.T) {
 	if data.StepIndex != 0 {
 		t.Errorf("StepIndex = %d, want 0", data.StepIndex)
 	}
+	if data.Description != "Analyze the codebase" {
+		t.Errorf("Description = %q, want %q", data.Description, "Analyze the codebase")
+	}
 }
 
 func TestLogger_LogStepEnd(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	err := logger.LogStepEnd("wf-step-2", "bead-1", "implement", "script", 1, true, false, 10*time.Second, 0, "")
@@ -202,7 +205,7 @@ func TestLogger_LogStepEnd(t *testing.T) {
 
 func TestLogger_LogStepEnd_Skipped(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	err := logger.LogStepEnd("wf-skip", "bead-1", "optional", "script", 2, true, true, 0, 0, "")
@@ -221,7 +224,7 @@ func TestLogger_LogStepEnd_Skipped(t *testing.T) {
 
 func TestLogger_LogStepEnd_WithError(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	err := logger.LogStepEnd("wf-fail", "bead-1", "build", "script", 0, false, false, 5*time.Second, 1, "build failed")
@@ -246,7 +249,7 @@ func TestLogger_LogStepEnd_WithError(t *testing.T) {
 
 func TestLogger_LogStepInput(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	vars := map[string]string{
@@ -284,7 +287,7 @@ func TestLogger_LogStepInput(t *testing.T) {
 
 func TestLogger_LogStepOutput(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	err := logger.LogStepOutput("wf-output", "bead-1", "analyze", "Analysis complete", "analysis_result", 1500, 8000)
@@ -321,7 +324,7 @@ func TestLogger_LogStepOutput(t *testing.T) {
 
 func TestLogger_LogLoopIteration(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	err := logger.LogLoopIteration("wf-loop", "bead-1", "refine", 3, 5, "until_pass", false)
@@ -358,7 +361,7 @@ func TestLogger_LogLoopIteration(t *testing.T) {
 
 func TestLogger_LogLoopIteration_Break(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	err := logger.LogLoopIteration("wf-break", "bead-1", "iterate", 2, 10, "for_each", true)
@@ -420,7 +423,7 @@ func TestLogger_CloseWorkflow(t *testing.T) {
 
 func TestLogger_MultipleEntries(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	workflowID := "wf-multi"
@@ -428,7 +431,7 @@ func TestLogger_MultipleEntries(t *testing.T) {
 
 	// Log a complete workflow sequence
 	logger.LogWorkflowStart(workflowID, beadID, "test-grimoire", "/worktree")
-	logger.LogStepStart(workflowID, beadID, "step-1", "script", 0)
+	logger.LogStepStart(workflowID, beadID, "step-1", "script", 0, "")
 	logger.LogStepInput(workflowID, beadID, "step-1", nil, "", "echo test")
 	logger.LogStepOutput(workflowID, beadID, "step-1", "test output", "", 0, 0)
 	logger.LogStepEnd(workflowID, beadID, "step-1", "script", 0, true, false, time.Second, 0, "")
@@ -457,7 +460,7 @@ func TestLogger_MultipleEntries(t *testing.T) {
 
 func TestLogger_TimestampIsSet(t *testing.T) {
 	tmpDir := t.TempDir()
-	logger := NewLogger(tmpDir)
+	logger := newUnbufferedLogger(tmpDir)
 	defer logger.Close()
 
 	before := time.Now()
@@ -472,6 +475,82 @@ func TestLogger_TimestampIsSet(t *testing.T) {
 	}
 }
 
+func TestLogger_BuffersUntilFlush(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLoggerWithPolicy(tmpDir, LogFlushPolicy{BufferSize: 64 * 1024})
+	defer logger.Close()
+
+	logger.LogWorkflowStart("wf-buffered", "bead-1", "grimoire", "/wt")
+	logger.LogStepStart("wf-buffered", "bead-1", "step-1", "script", 0, "")
+
+	if entries := readLogEntries(t, logger.LogPath("wf-buffered")); len(entries) != 0 {
+		t.Errorf("Expected entries to be buffered, got %d on disk", len(entries))
+	}
+
+	if err := logger.Flush("wf-buffered"); err != nil {
+		t.Fatalf("Flush() error: %v", err)
+	}
+	if entries := readLogEntries(t, logger.LogPath("wf-buffered")); len(entries) != 2 {
+		t.Errorf("Expected 2 entries after Flush, got %d", len(entries))
+	}
+}
+
+func TestLogger_FlushesWhenBufferFull(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLoggerWithPolicy(tmpDir, LogFlushPolicy{BufferSize: 256})
+	defer logger.Close()
+
+	for i := 0; i < 10; i++ {
+		logger.LogStepStart("wf-full", "bead-1", "step", "script", i, "")
+	}
+
+	entries := readLogEntries(t, logger.LogPath("wf-full"))
+	if len(entries) == 0 {
+		t.Error("Expected a full buffer to be flushed to disk")
+	}
+}
+
+func TestLogger_FlushesAfterInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLoggerWithPolicy(tmpDir, LogFlushPolicy{Interval: 20 * time.Millisecond, BufferSize: 64 * 1024})
+	defer logger.Close()
+
+	logger.LogWorkflowStart("wf-interval", "bead-1", "grimoire", "/wt")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if entries := readLogEntries(t, logger.LogPath("wf-interval")); len(entries) == 1 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("Expected buffered entry to be flushed after the interval")
+}
+
+func TestLogger_LogWorkflowEndFlushes(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger := NewLoggerWithPolicy(tmpDir, LogFlushPolicy{BufferSize: 64 * 1024})
+	defer logger.Close()
+
+	logger.LogWorkflowStart("wf-end", "bead-1", "grimoire", "/wt")
+	logger.LogStepStart("wf-end", "bead-1", "step-1", "script", 0, "")
+	logger.LogWorkflowEnd("wf-end", "bead-1", WorkflowBlocked, time.Second, 1, "needs review")
+
+	entries := readLogEntries(t, logger.LogPath("wf-end"))
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[2].Event != LogEventWorkflowEnd {
+		t.Errorf("Last event = %q, want %q", entries[2].Event, LogEventWorkflowEnd)
+	}
+}
+
+// newUnbufferedLogger creates a logger that writes each entry immediately,
+// so tests can read entries back as soon as they are logged.
+func newUnbufferedLogger(covenDir string) *Logger {
+	return NewLoggerWithPolicy(covenDir, LogFlushPolicy{})
+}
+
 // Helper to read log entries from a JSONL file
 func readLogEntries(t *testing.T, path string) []LogEntry {
 	t.Helper()
@@ -0,0 +1,66 @@
+package workflow
+
+import "sync"
+
+// LoopBreaks lets a caller outside the engine ask a running loop step to
+// stop at its next iteration boundary, as if a nested step had returned
+// exit_loop. It is safe for concurrent use. A nil *LoopBreaks never breaks
+// a loop.
+type LoopBreaks struct {
+	mu        sync.Mutex
+	running   map[string]bool
+	requested map[string]bool
+}
+
+// NewLoopBreaks creates an empty set of loop break requests.
+func NewLoopBreaks() *LoopBreaks {
+	return &LoopBreaks{
+		running:   make(map[string]bool),
+		requested: make(map[string]bool),
+	}
+}
+
+// Request asks the loop step named stepName to stop once its current
+// iteration finishes. It reports whether that loop is running; a request for
+// a loop that isn't running is ignored.
+func (b *LoopBreaks) Request(stepName string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.running[stepName] {
+		return false
+	}
+	b.requested[stepName] = true
+	return true
+}
+
+// start marks a loop step as running.
+func (b *LoopBreaks) start(stepName string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.running[stepName] = true
+}
+
+// finish marks a loop step as no longer running and drops any request to
+// break it, so a later run of the loop doesn't stop early.
+func (b *LoopBreaks) finish(stepName string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.running, stepName)
+	delete(b.requested, stepName)
+}
+
+// requestedFor reports whether the loop step has been asked to break.
+func (b *LoopBreaks) requestedFor(stepName string) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.requested[stepName]
+}
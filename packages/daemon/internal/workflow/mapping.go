@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/coven/daemon/internal/grimoire"
 )
@@ -17,10 +19,34 @@ type GrimoireMappingConfig struct {
 
 	// ByType maps bead types to grimoire names.
 	ByType map[string]string `json:"by_type"`
+
+	// ByLabel maps bead labels to grimoire names. It is checked before
+	// ByType; the first of the bead's labels with a mapping wins.
+	ByLabel map[string]string `json:"by_label,omitempty"`
+}
+
+// clone returns a deep copy of the config.
+func (c *GrimoireMappingConfig) clone() *GrimoireMappingConfig {
+	out := &GrimoireMappingConfig{Default: c.Default}
+	if c.ByType != nil {
+		out.ByType = make(map[string]string, len(c.ByType))
+		for k, v := range c.ByType {
+			out.ByType[k] = v
+		}
+	}
+	if c.ByLabel != nil {
+		out.ByLabel = make(map[string]string, len(c.ByLabel))
+		for k, v := range c.ByLabel {
+			out.ByLabel[k] = v
+		}
+	}
+	return out
 }
 
 // GrimoireMapper resolves which grimoire to use for a given bead.
+// It is safe for concurrent use.
 type GrimoireMapper struct {
+	mu             sync.RWMutex
 	config         *GrimoireMappingConfig
 	grimoireLoader *grimoire.Loader
 	covenDir       string
@@ -59,17 +85,14 @@ type BeadInfo struct {
 // Resolve determines which grimoire to use for a bead.
 // Resolution order:
 // 1. Explicit label on bead: grimoire:name
-// 2. Type-based mapping from config
-// 3. Default grimoire from config
-// 4. Built-in default (implement-bead)
+// 2. Label-based mapping from config
+// 3. Type-based mapping from config
+// 4. Default grimoire from config
+// 5. Built-in default (implement-bead)
 func (m *GrimoireMapper) Resolve(bead BeadInfo) (string, error) {
-	// Load config if not already loaded
-	if m.config == nil {
-		cfg, err := m.loadConfig()
-		if err != nil {
-			return "", fmt.Errorf("failed to load grimoire mapping config: %w", err)
-		}
-		m.config = cfg
+	cfg, err := m.Config()
+	if err != nil {
+		return "", err
 	}
 
 	// 1. Check for explicit grimoire label
@@ -78,22 +101,118 @@ func (m *GrimoireMapper) Resolve(bead BeadInfo) (string, error) {
 		return m.validateGrimoire(grimoireName)
 	}
 
-	// 2. Check type-based mapping
-	if m.config.ByType != nil && bead.Type != "" {
-		if mapped, ok := m.config.ByType[bead.Type]; ok && mapped != "" {
+	// 2. Check label-based mapping
+	for _, label := range bead.Labels {
+		if mapped, ok := cfg.ByLabel[label]; ok && mapped != "" {
 			return m.validateGrimoire(mapped)
 		}
 	}
 
-	// 3. Use default from config
-	if m.config.Default != "" {
-		return m.validateGrimoire(m.config.Default)
+	// 3. Check type-based mapping
+	if cfg.ByType != nil && bead.Type != "" {
+		if mapped, ok := cfg.ByType[bead.Type]; ok && mapped != "" {
+			return m.validateGrimoire(mapped)
+		}
 	}
 
-	// 4. Built-in default
+	// 4. Use default from config
+	if cfg.Default != "" {
+		return m.validateGrimoire(cfg.Default)
+	}
+
+	// 5. Built-in default
 	return m.validateGrimoire(BuiltinDefaultGrimoire)
 }
 
+// Config returns a copy of the current configuration, loading it from disk
+// if it hasn't been loaded yet.
+func (m *GrimoireMapper) Config() (*GrimoireMappingConfig, error) {
+	m.mu.RLock()
+	cfg := m.config
+	m.mu.RUnlock()
+	if cfg != nil {
+		return cfg.clone(), nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.config == nil {
+		loaded, err := m.loadConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load grimoire mapping config: %w", err)
+		}
+		m.config = loaded
+	}
+	return m.config.clone(), nil
+}
+
+// ValidateConfig checks that every grimoire the configuration refers to exists.
+func (m *GrimoireMapper) ValidateConfig(cfg *GrimoireMappingConfig) error {
+	check := func(field, name string) error {
+		if name == "" {
+			return fmt.Errorf("%s: grimoire name is required", field)
+		}
+		if _, err := m.validateGrimoire(name); err != nil {
+			return fmt.Errorf("%s: %w", field, err)
+		}
+		return nil
+	}
+
+	if cfg.Default != "" {
+		if err := check("default", cfg.Default); err != nil {
+			return err
+		}
+	}
+	for _, beadType := range sortedKeys(cfg.ByType) {
+		if beadType == "" {
+			return fmt.Errorf("by_type: bead type is required")
+		}
+		if err := check(fmt.Sprintf("by_type[%q]", beadType), cfg.ByType[beadType]); err != nil {
+			return err
+		}
+	}
+	for _, label := range sortedKeys(cfg.ByLabel) {
+		if label == "" {
+			return fmt.Errorf("by_label: label is required")
+		}
+		if err := check(fmt.Sprintf("by_label[%q]", label), cfg.ByLabel[label]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveConfig validates the configuration, writes it to
+// .coven/grimoire-mapping.json and uses it for subsequent resolutions.
+func (m *GrimoireMapper) SaveConfig(cfg *GrimoireMappingConfig) error {
+	if err := m.ValidateConfig(cfg); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal grimoire mapping config: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := writeFileAtomic(m.configPath(), data); err != nil {
+		return fmt.Errorf("failed to write grimoire mapping config: %w", err)
+	}
+	m.config = cfg.clone()
+	return nil
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // BuiltinDefaultGrimoire is the name of the built-in default grimoire.
 const BuiltinDefaultGrimoire = "implement-bead"
 
@@ -128,9 +247,7 @@ func (m *GrimoireMapper) validateGrimoire(name string) (string, error) {
 
 // loadConfig loads the grimoire mapping configuration.
 func (m *GrimoireMapper) loadConfig() (*GrimoireMappingConfig, error) {
-	configPath := filepath.Join(m.covenDir, "grimoire-mapping.json")
-
-	data, err := os.ReadFile(configPath)
+	data, err := os.ReadFile(m.configPath())
 	if os.IsNotExist(err) {
 		// Return default config
 		return &GrimoireMappingConfig{
@@ -154,25 +271,36 @@ func (m *GrimoireMapper) loadConfig() (*GrimoireMappingConfig, error) {
 	return &cfg, nil
 }
 
+// configPath returns the path of the mapping configuration file.
+func (m *GrimoireMapper) configPath() string {
+	return filepath.Join(m.covenDir, "grimoire-mapping.json")
+}
+
 // ReloadConfig reloads the configuration from disk.
 func (m *GrimoireMapper) ReloadConfig() error {
 	cfg, err := m.loadConfig()
 	if err != nil {
 		return err
 	}
+	m.mu.Lock()
 	m.config = cfg
+	m.mu.Unlock()
 	return nil
 }
 
 // GetConfig returns the current configuration.
 // Returns nil if not yet loaded.
 func (m *GrimoireMapper) GetConfig() *GrimoireMappingConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return m.config
 }
 
 // SetConfig sets the configuration directly.
 // Useful for testing.
 func (m *GrimoireMapper) SetConfig(cfg *GrimoireMappingConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.config = cfg
 }
 
@@ -353,6 +353,98 @@ func TestGrimoireMapper_GetSetConfig(t *testing.T) {
 	}
 }
 
+func TestGrimoireMapper_Resolve_LabelMapping(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestGrimoire(t, tmpDir, "security-review")
+	setupTestGrimoire(t, tmpDir, "bugfix-bead")
+
+	mapper := NewGrimoireMapper(tmpDir, grimoire.NewLoader(tmpDir))
+	mapper.SetConfig(&GrimoireMappingConfig{
+		ByType:  map[string]string{"bug": "bugfix-bead"},
+		ByLabel: map[string]string{"security": "security-review"},
+	})
+
+	name, err := mapper.Resolve(BeadInfo{Labels: []string{"priority:high", "security"}, Type: "bug"})
+	if err != nil {
+		t.Fatalf("Resolve() error: %v", err)
+	}
+	if name != "security-review" {
+		t.Errorf("Resolve() = %q, want label mapping %q over type mapping", name, "security-review")
+	}
+}
+
+func TestGrimoireMapper_SaveConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestGrimoire(t, tmpDir, "implement-bead")
+	setupTestGrimoire(t, tmpDir, "bugfix-bead")
+
+	mapper := NewGrimoireMapper(tmpDir, grimoire.NewLoader(tmpDir))
+	bug := BeadInfo{Type: "bug"}
+
+	if name, err := mapper.Resolve(bug); err != nil || name != "implement-bead" {
+		t.Fatalf("Resolve() = %q, %v; want the built-in mapping", name, err)
+	}
+
+	if err := mapper.SaveConfig(&GrimoireMappingConfig{
+		Default: "implement-bead",
+		ByType:  map[string]string{"bug": "bugfix-bead"},
+	}); err != nil {
+		t.Fatalf("SaveConfig() error: %v", err)
+	}
+
+	if name, err := mapper.Resolve(bug); err != nil || name != "bugfix-bead" {
+		t.Errorf("Resolve() = %q, %v; want the saved mapping %q", name, err, "bugfix-bead")
+	}
+
+	// The saved config is used by a new mapper too
+	reloaded := NewGrimoireMapper(tmpDir, grimoire.NewLoader(tmpDir))
+	if name, err := reloaded.Resolve(bug); err != nil || name != "bugfix-bead" {
+		t.Errorf("Resolve() after reload = %q, %v; want %q", name, err, "bugfix-bead")
+	}
+}
+
+func TestGrimoireMapper_SaveConfig_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	setupTestGrimoire(t, tmpDir, "implement-bead")
+
+	mapper := NewGrimoireMapper(tmpDir, grimoire.NewLoader(tmpDir))
+
+	tests := []struct {
+		name   string
+		cfg    *GrimoireMappingConfig
+		errMsg string
+	}{
+		{
+			name:   "missing default grimoire",
+			cfg:    &GrimoireMappingConfig{Default: "missing"},
+			errMsg: `default: grimoire "missing" not found`,
+		},
+		{
+			name:   "missing type grimoire",
+			cfg:    &GrimoireMappingConfig{ByType: map[string]string{"bug": "missing"}},
+			errMsg: `by_type["bug"]: grimoire "missing" not found`,
+		},
+		{
+			name:   "empty label grimoire",
+			cfg:    &GrimoireMappingConfig{ByLabel: map[string]string{"security": ""}},
+			errMsg: `by_label["security"]: grimoire name is required`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mapper.SaveConfig(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Fatalf("SaveConfig() error = %v, want %q", err, tt.errMsg)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "grimoire-mapping.json")); !os.IsNotExist(err) {
+		t.Error("an invalid config should not be written")
+	}
+}
+
 func TestBuiltinDefaultGrimoire_Constant(t *testing.T) {
 	if BuiltinDefaultGrimoire != "implement-bead" {
 		t.Errorf("BuiltinDefaultGrimoire = %q, want %q", BuiltinDefaultGrimoire, "implement-bead")
@@ -0,0 +1,127 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// executeWithMatrix runs a step through executor once per matrix variant,
+// binding the variant's values as "matrix" in the context. Steps without a
+// matrix are run once as usual.
+//
+// Variants run in order and all of them run even if one fails, so a single
+// run reports every failing combination. The combined result succeeds only if
+// every variant did; its output has a section per variant.
+//
+// A templated output name is rendered for each run of the step, after the
+// variant is bound, and the run's output is stored under the rendered name.
+func executeWithMatrix(ctx context.Context, step *grimoire.Step, stepCtx *StepContext, executor StepExecutor) (*StepResult, error) {
+	variants := step.MatrixVariants()
+	if len(variants) == 0 {
+		result, name, err := executeWithOutputName(ctx, step, stepCtx, executor)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			result.RenderedOutputs = map[string]string{name: result.Output}
+		}
+		return result, nil
+	}
+
+	previous, hadPrevious := stepCtx.Variables["matrix"]
+	defer func() {
+		if hadPrevious {
+			stepCtx.Variables["matrix"] = previous
+		} else {
+			delete(stepCtx.Variables, "matrix")
+		}
+	}()
+
+	start := time.Now()
+	var output strings.Builder
+	var failures []string
+	var firstFailure, last *StepResult
+	var renderedOutputs map[string]string
+
+	for _, variant := range variants {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		label := variant.Label()
+		stepCtx.SetVariable("matrix", map[string]interface{}(variant))
+
+		result, name, err := executeWithOutputName(ctx, step, stepCtx, executor)
+		if err != nil {
+			return nil, fmt.Errorf("matrix variant %s: %w", label, err)
+		}
+		result.NormalizeAction()
+		last = result
+		if name != "" {
+			if renderedOutputs == nil {
+				renderedOutputs = make(map[string]string)
+			}
+			renderedOutputs[name] = result.Output
+		}
+
+		fmt.Fprintf(&output, "=== %s ===\n", label)
+		if result.Output != "" {
+			output.WriteString(strings.TrimRight(result.Output, "\n"))
+			output.WriteString("\n")
+		}
+
+		if !result.Success {
+			failures = append(failures, fmt.Sprintf("%s: %s", label, result.Error))
+			if firstFailure == nil {
+				firstFailure = result
+			}
+		}
+	}
+
+	combined := &StepResult{
+		Success:  len(failures) == 0,
+		Output:   strings.TrimRight(output.String(), "\n"),
+		Duration: time.Since(start),
+		Action:   last.Action,
+	}
+	combined.RenderedOutputs = renderedOutputs
+	if firstFailure != nil {
+		combined.ExitCode = firstFailure.ExitCode
+		combined.Error = fmt.Sprintf("%d of %d matrix variants failed: %s", len(failures), len(variants), strings.Join(failures, "; "))
+		combined.Action = firstFailure.Action
+		combined.Escalation = firstFailure.Escalation
+	}
+	return combined, nil
+}
+
+// executeWithOutputName runs step through executor once. If the step's output
+// name is a template, it is rendered and claimed first, the executor sees the
+// rendered name, and the rendered name is returned.
+func executeWithOutputName(ctx context.Context, step *grimoire.Step, stepCtx *StepContext, executor StepExecutor) (*StepResult, string, error) {
+	if !IsTemplatedOutputName(step.Output) {
+		result, err := executor.Execute(ctx, step, stepCtx)
+		return result, "", err
+	}
+
+	name, err := claimOutputName(step, stepCtx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rendered := *step
+	rendered.Output = name
+	result, err := executor.Execute(ctx, &rendered, stepCtx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Agent steps store their parsed output themselves
+	if step.Type != grimoire.StepTypeAgent {
+		stepCtx.SetVariable(name, result.Output)
+	}
+	return result, name, nil
+}
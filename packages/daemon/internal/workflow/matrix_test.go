@@ -0,0 +1,145 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+func TestExecuteWithMatrix(t *testing.T) {
+	executor := &MockStepExecutor{}
+	step := &grimoire.Step{
+		Name:    "test",
+		Type:    grimoire.StepTypeScript,
+		Command: "npm test",
+		Matrix:  map[string][]interface{}{"node": {18, 20, 22}},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executeWithMatrix(context.Background(), step, stepCtx, executor)
+	if err != nil {
+		t.Fatalf("executeWithMatrix() error: %v", err)
+	}
+
+	if executor.CallCount != 3 {
+		t.Fatalf("CallCount = %d, want 3", executor.CallCount)
+	}
+	for i, want := range []int{18, 20, 22} {
+		matrix, ok := executor.CapturedContexts[i].Variables["matrix"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("call %d: matrix variable = %v, want a map", i, executor.CapturedContexts[i].Variables["matrix"])
+		}
+		if matrix["node"] != want {
+			t.Errorf("call %d: matrix.node = %v, want %d", i, matrix["node"], want)
+		}
+	}
+
+	if !result.Success {
+		t.Errorf("Expected success, got error: %s", result.Error)
+	}
+	if result.Action != ActionContinue {
+		t.Errorf("Action = %q, want %q", result.Action, ActionContinue)
+	}
+	if _, ok := stepCtx.Variables["matrix"]; ok {
+		t.Error("matrix variable should be removed after the step")
+	}
+}
+
+func TestExecuteWithMatrix_Failures(t *testing.T) {
+	executor := &MockStepExecutor{
+		Results: []*StepResult{
+			{Success: true, Output: "ok on 18", Action: ActionContinue},
+			{Success: false, Output: "boom", ExitCode: 2, Error: "exit status 2", Action: ActionFail},
+			{Success: true, Output: "ok on 22", Action: ActionContinue},
+		},
+	}
+	step := &grimoire.Step{
+		Name:    "test",
+		Type:    grimoire.StepTypeScript,
+		Command: "npm test",
+		Matrix:  map[string][]interface{}{"node": {18, 20, 22}},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executeWithMatrix(context.Background(), step, stepCtx, executor)
+	if err != nil {
+		t.Fatalf("executeWithMatrix() error: %v", err)
+	}
+
+	// All variants run even after one fails
+	if executor.CallCount != 3 {
+		t.Errorf("CallCount = %d, want 3", executor.CallCount)
+	}
+	if result.Success {
+		t.Error("Expected failure")
+	}
+	if result.Action != ActionFail {
+		t.Errorf("Action = %q, want %q", result.Action, ActionFail)
+	}
+	if result.ExitCode != 2 {
+		t.Errorf("ExitCode = %d, want 2", result.ExitCode)
+	}
+	if !strings.Contains(result.Error, "1 of 3 matrix variants failed: node=20: exit status 2") {
+		t.Errorf("Error = %q, want the failing variant", result.Error)
+	}
+	for _, want := range []string{"=== node=18 ===\nok on 18", "=== node=20 ===\nboom", "=== node=22 ===\nok on 22"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("Output = %q, want section %q", result.Output, want)
+		}
+	}
+}
+
+func TestExecuteWithMatrix_RestoresOuterMatrix(t *testing.T) {
+	step := &grimoire.Step{
+		Name:    "test",
+		Type:    grimoire.StepTypeScript,
+		Command: "npm test",
+		Matrix:  map[string][]interface{}{"node": {18}},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+	stepCtx.SetVariable("matrix", "outer")
+
+	if _, err := executeWithMatrix(context.Background(), step, stepCtx, &MockStepExecutor{}); err != nil {
+		t.Fatalf("executeWithMatrix() error: %v", err)
+	}
+	if stepCtx.Variables["matrix"] != "outer" {
+		t.Errorf("matrix variable = %v, want %q restored", stepCtx.Variables["matrix"], "outer")
+	}
+}
+
+func TestExecuteWithMatrix_TemplatedOutputName(t *testing.T) {
+	executor := &MockStepExecutor{
+		Results: []*StepResult{
+			{Success: true, Output: "linux ok", Action: ActionContinue},
+			{Success: true, Output: "darwin ok", Action: ActionContinue},
+		},
+	}
+	step := &grimoire.Step{
+		Name:    "build",
+		Type:    grimoire.StepTypeScript,
+		Command: "make build",
+		Output:  "build_{{.matrix.os}}",
+		Matrix:  map[string][]interface{}{"os": {"linux", "darwin"}},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executeWithMatrix(context.Background(), step, stepCtx, executor)
+	if err != nil {
+		t.Fatalf("executeWithMatrix() error: %v", err)
+	}
+
+	want := map[string]string{"build_linux": "linux ok", "build_darwin": "darwin ok"}
+	for name, output := range want {
+		if got := result.RenderedOutputs[name]; got != output {
+			t.Errorf("RenderedOutputs[%q] = %q, want %q", name, got, output)
+		}
+		if got := stepCtx.Variables[name]; got != output {
+			t.Errorf("Variables[%q] = %v, want %q", name, got, output)
+		}
+	}
+	if len(result.RenderedOutputs) != len(want) {
+		t.Errorf("RenderedOutputs = %v, want %v", result.RenderedOutputs, want)
+	}
+}
@@ -0,0 +1,40 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// maxReviewDiffBytes is the largest diff embedded in a merge review's output.
+// Larger diffs are left out; GET /workflows/:id/merge-diff streams them.
+const maxReviewDiffBytes = 10000
+
+// DiffStreamer is a MergeRunner that can write a worktree's diff as it is
+// produced, so large diffs needn't be held in memory.
+type DiffStreamer interface {
+	MergeRunner
+
+	// StreamDiff writes the diff of uncommitted changes in the worktree to w.
+	StreamDiff(ctx context.Context, workDir string, w io.Writer) error
+}
+
+// StreamDiff writes the diff of uncommitted changes to w as git produces it.
+func (r *DefaultMergeRunner) StreamDiff(ctx context.Context, workDir string, w io.Writer) error {
+	cmd := exec.CommandContext(ctx, "git", "diff", "HEAD")
+	cmd.Dir = workDir
+	cmd.Stdout = w
+
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("git diff failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("git diff failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// IsTemplatedOutputName reports whether an output name contains template
+// tags, and so must be rendered against the context before it is stored.
+func IsTemplatedOutputName(name string) bool {
+	return strings.Contains(name, "{{")
+}
+
+// RenderOutputName renders an output name template such as
+// "result_{{.<loop_name>.iteration}}" against variables. Values are
+// substituted as is, without shell escaping. The rendered name must be
+// non-empty and may only contain letters, digits, '_' and '-', so it can be
+// referenced from later templates.
+func RenderOutputName(name string, variables map[string]interface{}) (string, error) {
+	if !IsTemplatedOutputName(name) {
+		return name, nil
+	}
+
+	rendered, err := substituteTemplate(name, variables, false, func(value interface{}) string {
+		return fmt.Sprint(value)
+	})
+	if err != nil {
+		return "", fmt.Errorf("output name %q: %w", name, err)
+	}
+	if rendered == "" {
+		return "", fmt.Errorf("output name %q rendered to an empty name", name)
+	}
+	for _, r := range rendered {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return "", fmt.Errorf("output name %q rendered to %q, which may only contain letters, digits, '_' and '-'", name, rendered)
+		}
+	}
+	return rendered, nil
+}
+
+// claimOutputName renders step's templated output name and records it on the
+// context. Each rendered name may be claimed once per run, so an iteration or
+// variant can't silently overwrite another's output.
+func claimOutputName(step *grimoire.Step, stepCtx *StepContext) (string, error) {
+	name, err := RenderOutputName(step.Output, stepCtx.Variables)
+	if err != nil {
+		return "", newRenderError(step, "output", step.Output, err)
+	}
+
+	if stepCtx.outputNames == nil {
+		stepCtx.outputNames = make(map[string]string)
+	}
+	if owner, ok := stepCtx.outputNames[name]; ok {
+		return "", fmt.Errorf("step %q: output name %q is already used by step %q in this run; include a loop or matrix variable in the name", step.Name, name, owner)
+	}
+	stepCtx.outputNames[name] = step.Name
+	return name, nil
+}
@@ -0,0 +1,64 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+func TestRenderOutputName(t *testing.T) {
+	variables := map[string]interface{}{
+		"fix":    map[string]interface{}{"iteration": 2},
+		"matrix": map[string]interface{}{"os": "linux", "arch": "arm 64"},
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr string
+	}{
+		{name: "static name", output: "result", want: "result"},
+		{name: "loop iteration", output: "result_{{.fix.iteration}}", want: "result_2"},
+		{name: "matrix value", output: "build-{{ .matrix.os }}", want: "build-linux"},
+		{name: "empty", output: "{{.missing}}", wantErr: "empty name"},
+		{name: "invalid characters", output: "build_{{.matrix.arch}}", wantErr: `rendered to "build_arm 64"`},
+		{name: "unclosed tag", output: "result_{{.fix.iteration", wantErr: "unclosed template tag"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderOutputName(tt.output, variables)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RenderOutputName() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RenderOutputName() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderOutputName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimOutputName_Duplicate(t *testing.T) {
+	step := &grimoire.Step{Name: "check", Type: grimoire.StepTypeScript, Output: "check_{{.matrix.os}}"}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+	stepCtx.SetVariable("matrix", map[string]interface{}{"os": "linux"})
+
+	if _, err := claimOutputName(step, stepCtx); err != nil {
+		t.Fatalf("claimOutputName() error: %v", err)
+	}
+	_, err := claimOutputName(step, stepCtx)
+	if err == nil {
+		t.Fatal("claimOutputName() should fail when the rendered name was already used")
+	}
+	if !strings.Contains(err.Error(), `output name "check_linux" is already used`) {
+		t.Errorf("error = %v, want it to name the duplicate", err)
+	}
+}
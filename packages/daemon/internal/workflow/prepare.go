@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// prepareStepIndex is the step index prepare steps are logged with, since
+// they come before the grimoire's first step.
+const prepareStepIndex = -1
+
+// runPrepare runs the grimoire's prepare steps before its main steps, under a
+// single timeout covering all of them. A prepare step that fails or errors
+// fails the workflow. It returns the final result if the workflow stopped, or
+// nil if every prepare step succeeded.
+func (e *Engine) runPrepare(ctx context.Context, g *grimoire.Grimoire, stepCtx *StepContext, workflowState *WorkflowState, result *ExecutionResult, start time.Time) *ExecutionResult {
+	timeout, err := g.GetPrepareTimeout()
+	if err != nil {
+		return e.failPrepare(workflowState, result, start, fmt.Errorf("invalid prepare_timeout: %w", err))
+	}
+	prepareCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// No main step has run while preparing
+	result.CurrentStep = -1
+
+	for i := range g.Prepare {
+		step := &g.Prepare[i]
+		if g.StepTimeout != "" {
+			resolved := step.WithDefaultTimeout(g.StepTimeout)
+			step = &resolved
+		}
+		if g.EnvFile != "" {
+			resolved := step.WithDefaultEnvFile(g.EnvFile)
+			step = &resolved
+		}
+		if g.Sandbox != nil {
+			resolved := step.WithDefaultSandbox(g.Sandbox)
+			step = &resolved
+		}
+
+		if e.interruptedByShutdown(ctx) {
+			return e.interrupt(workflowState, result, start)
+		}
+		if ctx.Err() != nil {
+			return e.cancel(workflowState, result, start, ctx.Err())
+		}
+
+		if step.When != "" {
+			shouldSkip, err := ShouldSkipStep(step.When, stepCtx)
+			if err != nil {
+				return e.failPrepare(workflowState, result, start, fmt.Errorf("prepare step %q: failed to evaluate condition: %w", step.Name, err))
+			}
+			if shouldSkip {
+				stepResult := &StepResult{
+					Success: true,
+					Skipped: true,
+					Output:  fmt.Sprintf("skipped: condition %q evaluated to false", step.When),
+				}
+				result.StepResults[step.Name] = stepResult
+				workflowState.CompletedSteps[step.Name] = stepResult
+				e.saveWorkflowState(workflowState, result)
+				e.logStepEnd(step.Name, string(step.Type), prepareStepIndex, true, true, 0, 0, "")
+				continue
+			}
+		}
+
+		e.logStepStart(step.Name, string(step.Type), prepareStepIndex, step.Description)
+		e.logStepInput(step.Name, nil, "", step.Command)
+
+		stepStart := time.Now()
+		stepResult, err := e.executeStep(prepareCtx, step, stepCtx)
+		stepDuration := time.Since(stepStart)
+
+		// A step killed by shutdown is not recorded, so prepare reruns on resume
+		if ctx.Err() != nil && errors.Is(context.Cause(ctx), ErrShutdown) {
+			e.logStepEnd(step.Name, string(step.Type), prepareStepIndex, false, false, stepDuration, 0, ErrShutdown.Error())
+			return e.interrupt(workflowState, result, start)
+		}
+
+		if ctx.Err() == nil && errors.Is(prepareCtx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("prepare timed out after %s", timeout)
+			result.TimedOut = true
+		}
+		if err != nil {
+			e.logStepEnd(step.Name, string(step.Type), prepareStepIndex, false, false, stepDuration, 0, err.Error())
+			return e.failPrepare(workflowState, result, start, fmt.Errorf("prepare step %q failed: %w", step.Name, err))
+		}
+
+		e.normalizeAction(step.Name, stepResult)
+		e.logStepEnd(step.Name, string(step.Type), prepareStepIndex, stepResult.Success, false, stepDuration, stepResult.ExitCode, stepResult.Error)
+		if stepResult.Output != "" || step.Output != "" {
+			e.logStepOutput(step.Name, stepResult.Output, step.Output, 0, 0)
+		}
+
+		result.StepResults[step.Name] = stepResult
+		workflowState.CompletedSteps[step.Name] = stepResult
+		e.storeStepOutput(step, stepResult, stepCtx, workflowState)
+		stepCtx.SetPrevious(stepResult)
+
+		if !stepResult.Success {
+			result.TimedOut = stepResult.TimedOut
+			return e.failPrepare(workflowState, result, start, fmt.Errorf("prepare step %q failed: %s", step.Name, stepResult.Error))
+		}
+		e.saveWorkflowState(workflowState, result)
+	}
+
+	return nil
+}
+
+// failPrepare fails the workflow because its prepare phase didn't succeed.
+func (e *Engine) failPrepare(workflowState *WorkflowState, result *ExecutionResult, start time.Time, err error) *ExecutionResult {
+	result.Status = WorkflowFailed
+	result.Error = err
+	result.Duration = time.Since(start)
+	e.saveWorkflowState(workflowState, result)
+	e.emitWorkflowBlocked(err.Error())
+	e.logWorkflowEnd(WorkflowFailed, result.Duration, len(result.StepResults), err.Error())
+	return result
+}
@@ -107,15 +107,16 @@ func DefaultPreviewOptions() *PreviewOptions {
 type Previewer struct {
 	grimoireLoader *grimoire.Loader
 	spellLoader    *spell.Loader
-	spellRenderer  *spell.Renderer
+	spellRenderer  *spell.PartialRenderer
 }
 
 // NewPreviewer creates a new previewer.
 func NewPreviewer(covenDir string) *Previewer {
+	spellLoader := spell.NewLoader(covenDir)
 	return &Previewer{
 		grimoireLoader: grimoire.NewLoader(covenDir),
-		spellLoader:    spell.NewLoader(covenDir),
-		spellRenderer:  spell.NewRenderer(),
+		spellLoader:    spellLoader,
+		spellRenderer:  spell.NewPartialRenderer(spellLoader),
 	}
 }
 
@@ -210,8 +211,24 @@ func (p *Previewer) previewStep(step *grimoire.Step, index int, ctx *StepContext
 			}
 		}
 
+	case grimoire.StepTypeParallel:
+		// Preview nested steps
+		for i, nested := range step.Steps {
+			nestedPreview := p.previewStep(&nested, i+1, ctx, opts)
+			preview.NestedSteps = append(preview.NestedSteps, nestedPreview)
+
+			// Propagate nested errors
+			if len(nestedPreview.Errors) > 0 {
+				preview.Errors = append(preview.Errors, nestedPreview.Errors...)
+			}
+		}
+
 	case grimoire.StepTypeMerge:
 		preview.RequiresReview = step.RequiresReview()
+
+	case grimoire.StepTypeHTTP:
+		preview.OnFail = step.OnFail
+		preview.OnSuccess = step.OnSuccess
 	}
 
 	// Validate 'when' condition if present
@@ -232,6 +249,25 @@ func (p *Previewer) previewStep(step *grimoire.Step, index int, ctx *StepContext
 func (p *Previewer) previewAgentStep(step *grimoire.Step, preview *StepPreview, ctx *StepContext, opts *PreviewOptions) {
 	preview.SpellName = step.Spell
 
+	// Load the section spells, so every missing one is reported
+	sections := make([]*spell.Spell, len(step.Sections))
+	for i, section := range step.Sections {
+		if IsInlineSpell(section) {
+			sections[i] = &spell.Spell{Name: fmt.Sprintf("%s.sections[%d]", step.Name, i), Content: section}
+			continue
+		}
+		sp, err := p.spellLoader.Load(section)
+		if err != nil {
+			preview.Errors = append(preview.Errors, PreviewError{
+				StepName: step.Name,
+				Field:    "sections",
+				Message:  fmt.Sprintf("failed to load section %d: %v", i, err),
+			})
+			continue
+		}
+		sections[i] = sp
+	}
+
 	// Load the spell
 	sp, err := p.spellLoader.Load(step.Spell)
 	if err != nil {
@@ -267,6 +303,27 @@ func (p *Previewer) previewAgentStep(step *grimoire.Step, preview *StepPreview,
 		return
 	}
 
+	// Append each section as preparePrompt does
+	parts := []string{strings.TrimRight(rendered, "\n")}
+	for i, section := range sections {
+		if section == nil {
+			continue
+		}
+		renderedSection, err := p.spellRenderer.Render(section, renderCtx)
+		if err != nil {
+			preview.Errors = append(preview.Errors, PreviewError{
+				StepName: step.Name,
+				Field:    fmt.Sprintf("sections[%d]", i),
+				Message:  fmt.Sprintf("template rendering error: %v", err),
+			})
+			continue
+		}
+		parts = append(parts, strings.TrimRight(renderedSection, "\n"))
+	}
+	if len(parts) > 1 {
+		rendered = strings.Join(parts, "\n\n") + "\n"
+	}
+
 	// Truncate preview if needed
 	maxLen := opts.MaxSpellPreviewLength
 	if opts.IncludeFullSpells {
@@ -425,8 +482,8 @@ func validateStep(step *grimoire.Step) []PreviewError {
 		}
 	}
 
-	// Validate nested steps for loops
-	if step.Type == grimoire.StepTypeLoop {
+	// Validate nested steps for loops and parallel steps
+	if step.Type == grimoire.StepTypeLoop || step.Type == grimoire.StepTypeParallel {
 		for _, nested := range step.Steps {
 			nestedErrors := validateStep(&nested)
 			errors = append(errors, nestedErrors...)
@@ -218,14 +218,21 @@ func TestPreviewer_Preview_WithBeadData(t *testing.T) {
 		t.Fatalf("Failed to create spells dir: %v", err)
 	}
 
-	// Create a spell
+	// Create a spell that includes a shared partial
 	spellContent := `# Implement: {{.bead.title}}
 
 {{.bead.body}}
+{{include "common/standards"}}
 `
 	if err := os.WriteFile(filepath.Join(spellsDir, "implement.md"), []byte(spellContent), 0644); err != nil {
 		t.Fatalf("Failed to write spell: %v", err)
 	}
+	if err := os.MkdirAll(filepath.Join(spellsDir, "common"), 0755); err != nil {
+		t.Fatalf("Failed to create spells subdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(spellsDir, "common", "standards.md"), []byte("Follow the standards for {{.bead.id}}."), 0644); err != nil {
+		t.Fatalf("Failed to write partial: %v", err)
+	}
 
 	// Create grimoire that uses the spell
 	grimoireContent := `name: agent-workflow
@@ -270,6 +277,65 @@ steps:
 	if !strings.Contains(step.SpellPreview, "Test Feature") {
 		t.Error("SpellPreview should contain bead title")
 	}
+	if !strings.Contains(step.SpellPreview, "Follow the standards for coven-test.") {
+		t.Errorf("SpellPreview = %q, want the included partial rendered", step.SpellPreview)
+	}
+}
+
+func TestPreviewer_Preview_WithSections(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	grimoireDir := filepath.Join(tmpDir, "grimoires")
+	spellsDir := filepath.Join(tmpDir, "spells")
+	if err := os.MkdirAll(grimoireDir, 0755); err != nil {
+		t.Fatalf("Failed to create grimoire dir: %v", err)
+	}
+	if err := os.MkdirAll(spellsDir, 0755); err != nil {
+		t.Fatalf("Failed to create spells dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(spellsDir, "implement.md"), []byte("Implement {{.bead.title}}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write spell: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(spellsDir, "standards.md"), []byte("Follow the standards for {{.bead.id}}.\n"), 0644); err != nil {
+		t.Fatalf("Failed to write section spell: %v", err)
+	}
+
+	grimoireContent := `name: sections-workflow
+description: Test workflow with prompt sections
+steps:
+  - name: implement
+    type: agent
+    spell: implement
+    sections:
+      - standards
+      - |
+        Keep the change small.
+`
+	if err := os.WriteFile(filepath.Join(grimoireDir, "sections-workflow.yaml"), []byte(grimoireContent), 0644); err != nil {
+		t.Fatalf("Failed to write grimoire: %v", err)
+	}
+
+	previewer := NewPreviewer(tmpDir)
+	opts := &PreviewOptions{
+		BeadData:          &BeadData{ID: "coven-test", Title: "Test Feature"},
+		IncludeFullSpells: true,
+	}
+	result, err := previewer.Preview("sections-workflow", opts)
+	if err != nil {
+		t.Fatalf("Preview() error: %v", err)
+	}
+	if len(result.Steps) != 1 {
+		t.Fatalf("Expected 1 step, got %d", len(result.Steps))
+	}
+
+	step := result.Steps[0]
+	if len(step.Errors) != 0 {
+		t.Fatalf("Errors = %v, want none", step.Errors)
+	}
+	want := "Implement Test Feature\n\nFollow the standards for coven-test.\n\nKeep the change small.\n"
+	if step.SpellPreview != want {
+		t.Errorf("SpellPreview = %q, want %q", step.SpellPreview, want)
+	}
 }
 
 func TestPreviewResult_ToJSON(t *testing.T) {
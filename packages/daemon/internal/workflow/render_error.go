@@ -0,0 +1,114 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// RenderError is returned when one of a step's templates, such as its
+// command or spell, fails to render. It records where the template came from
+// so the failure can be traced to the grimoire or spell that needs fixing.
+type RenderError struct {
+	StepName string
+
+	// Field is the part of the step the template came from, such as
+	// "command", "spell" or `input "files"`.
+	Field string
+
+	// Template is the template source.
+	Template string
+
+	// Expression is the template expression that failed to render, when it
+	// can be determined.
+	Expression string
+
+	Err error
+}
+
+func (e *RenderError) Error() string {
+	if e.Expression == "" {
+		return fmt.Sprintf("failed to render %s: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("failed to render %s at %q: %v", e.Field, e.Expression, e.Err)
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// IsRenderError returns true if the error is a RenderError.
+func IsRenderError(err error) bool {
+	var renderErr *RenderError
+	return errors.As(err, &renderErr)
+}
+
+// newRenderError attributes a template's rendering error to a field of step.
+func newRenderError(step *grimoire.Step, field, template string, err error) *RenderError {
+	return &RenderError{
+		StepName:   step.Name,
+		Field:      field,
+		Template:   template,
+		Expression: failedExpression(template, err),
+		Err:        err,
+	}
+}
+
+// logRenderError logs err as a render.error event if it is a RenderError.
+// Errors from loop steps are skipped, since the loop already logged the
+// nested step's error.
+func logRenderError(logger *Logger, workflowID, beadID string, step *grimoire.Step, err error) {
+	if step.Type == grimoire.StepTypeLoop {
+		return
+	}
+	var renderErr *RenderError
+	if errors.As(err, &renderErr) {
+		logger.LogRenderError(workflowID, beadID, renderErr)
+	}
+}
+
+// templateTagError is returned by substituteTemplate for a tag that isn't
+// closed.
+type templateTagError struct {
+	Tag      string
+	Position int
+}
+
+func (e *templateTagError) Error() string {
+	return fmt.Sprintf("unclosed template tag at position %d", e.Position)
+}
+
+var (
+	// execExpressionPattern matches the expression text/template reports
+	// when executing it fails, as in `executing "x" at <.a.b>: ...`.
+	execExpressionPattern = regexp.MustCompile(`at <([^>]*)>`)
+
+	// templateLinePattern matches the line text/template reports for an
+	// error, as in `template: x:3: ...` or `template: x:3:12: ...`.
+	templateLinePattern = regexp.MustCompile(`template: [^:]*:(\d+)`)
+)
+
+// failedExpression returns the part of template that err reports as having
+// failed: the unclosed tag, the expression that failed to execute, or for a
+// syntax error the template line it is on.
+func failedExpression(template string, err error) string {
+	var tagErr *templateTagError
+	if errors.As(err, &tagErr) {
+		return tagErr.Tag
+	}
+	msg := err.Error()
+	if m := execExpressionPattern.FindStringSubmatch(msg); m != nil {
+		return "{{" + m[1] + "}}"
+	}
+	if m := templateLinePattern.FindStringSubmatch(msg); m != nil {
+		lines := strings.Split(template, "\n")
+		if n, convErr := strconv.Atoi(m[1]); convErr == nil && n >= 1 && n <= len(lines) {
+			return strings.TrimSpace(lines[n-1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/spell"
+)
+
+func TestEngine_RenderErrorIsAttributed(t *testing.T) {
+	g, err := grimoire.Parse([]byte(`name: render-test
+description: A step with a malformed template
+steps:
+  - name: build
+    type: script
+    command: "make {{.target"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	})
+	defer engine.logger.Close()
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowFailed {
+		t.Fatalf("Status = %q, want %q", result.Status, WorkflowFailed)
+	}
+	var renderErr *RenderError
+	if !errors.As(result.Error, &renderErr) {
+		t.Fatalf("Error = %v, want a RenderError", result.Error)
+	}
+	if renderErr.StepName != "build" || renderErr.Field != "command" || renderErr.Expression != "{{.target" {
+		t.Errorf("RenderError = %+v, want step build, field command, expression {{.target", renderErr)
+	}
+	for _, want := range []string{`step "build"`, "failed to render command", `"{{.target"`} {
+		if !strings.Contains(result.Error.Error(), want) {
+			t.Errorf("Error = %q, want it to contain %q", result.Error, want)
+		}
+	}
+
+	engine.logger.Flush("test-wf")
+	var logged []RenderErrorData
+	for _, entry := range readLogEntries(t, engine.logger.LogPath("test-wf")) {
+		if entry.Event != LogEventRenderError {
+			continue
+		}
+		var data RenderErrorData
+		if err := json.Unmarshal(entry.Data, &data); err != nil {
+			t.Fatalf("Failed to parse %s data: %v", entry.Event, err)
+		}
+		logged = append(logged, data)
+	}
+	if len(logged) != 1 {
+		t.Fatalf("logged %d %s events, want 1", len(logged), LogEventRenderError)
+	}
+	if data := logged[0]; data.StepName != "build" || data.Field != "command" || data.Template != "make {{.target" || data.Expression != "{{.target" {
+		t.Errorf("render.error data = %+v, want the step, field, template and expression", data)
+	}
+}
+
+func TestFailedExpression(t *testing.T) {
+	renderer := spell.NewPartialRenderer(nil)
+	spellErr := func(content string, ctx spell.RenderContext) error {
+		t.Helper()
+		_, err := renderer.RenderString("implement", content, ctx)
+		if err == nil {
+			t.Fatalf("RenderString(%q) should fail", content)
+		}
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		err      error
+		want     string
+	}{
+		{
+			name:     "unclosed tag",
+			template: "echo {{.name",
+			err:      &templateTagError{Tag: "{{.name", Position: 5},
+			want:     "{{.name",
+		},
+		{
+			name:     "execution error",
+			template: "Fix {{index .files 3}}",
+			err:      spellErr("Fix {{index .files 3}}", spell.RenderContext{"files": []string{"a.go"}}),
+			want:     "{{index .files 3}}",
+		},
+		{
+			name:     "syntax error",
+			template: "Implement the task.\nDone. {{end}}",
+			err:      spellErr("Implement the task.\nDone. {{end}}", nil),
+			want:     "Done. {{end}}",
+		},
+		{
+			name:     "unknown function",
+			template: "Implement the task.\n{{shout .bead.title}}",
+			err:      spellErr("Implement the task.\n{{shout .bead.title}}", nil),
+			want:     "{{shout .bead.title}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := failedExpression(tt.template, tt.err); got != tt.want {
+				t.Errorf("failedExpression() = %q, want %q (error: %v)", got, tt.want, tt.err)
+			}
+		})
+	}
+}
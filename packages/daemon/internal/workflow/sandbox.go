@@ -0,0 +1,114 @@
+package workflow
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// DefaultContainerRuntime is the container CLI sandboxed steps run with.
+// Any runtime accepting docker's run flags, such as podman, can be used.
+const DefaultContainerRuntime = "docker"
+
+// containerRuntimeErrorCode is the exit code docker and podman use when
+// they fail to run the container at all, as for an image that can't be
+// pulled.
+const containerRuntimeErrorCode = 125
+
+// containerSeq numbers the containers the daemon starts so their names are
+// unique.
+var containerSeq atomic.Int64
+
+// ContainerCommandRunner is a CommandRunner that runs each command in a new
+// container:
+//
+//   - The working directory is bind-mounted, read-write, at the same path it
+//     has on the host, and the command runs there, so paths such as
+//     {{.workflow.worktree}} mean the same inside the container.
+//   - The command runs with sh -c as the daemon's user and group, so files it
+//     writes to the worktree are owned as if it had run on the host.
+//   - Only the variables passed to RunWithEnv are set; the daemon's
+//     environment isn't passed through.
+//   - The container is removed when the command finishes or is cancelled.
+type ContainerCommandRunner struct {
+	// Image is the container image commands run in.
+	Image string
+
+	// Runtime is the container CLI to use, DefaultContainerRuntime when
+	// empty.
+	Runtime string
+}
+
+// NewContainerCommandRunner creates a runner for the sandbox.
+func NewContainerCommandRunner(sandbox *grimoire.Sandbox) *ContainerCommandRunner {
+	return &ContainerCommandRunner{Image: sandbox.Image}
+}
+
+// Run executes a shell command in a container and returns its output.
+func (r *ContainerCommandRunner) Run(ctx context.Context, workDir, command string) (stdout, stderr string, exitCode int, err error) {
+	return r.RunWithEnv(ctx, workDir, command, nil)
+}
+
+// RunWithEnv executes a shell command in a container with env set and
+// returns its output.
+func (r *ContainerCommandRunner) RunWithEnv(ctx context.Context, workDir, command string, env []string) (stdout, stderr string, exitCode int, err error) {
+	runtime := r.Runtime
+	if runtime == "" {
+		runtime = DefaultContainerRuntime
+	}
+	name := fmt.Sprintf("coven-%d-%d", os.Getpid(), containerSeq.Add(1))
+
+	cmd := exec.CommandContext(ctx, runtime, r.args(name, workDir, command, env)...)
+	// Values reach the container through the runtime's environment rather
+	// than its arguments, so secrets don't show up in the process list
+	cmd.Env = append(os.Environ(), env...)
+
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+
+	err = cmd.Run()
+
+	// Killing the runtime's client doesn't stop the container
+	if ctx.Err() != nil {
+		exec.Command(runtime, "rm", "--force", name).Run()
+	}
+
+	stdout = stdoutBuf.String()
+	stderr = stderrBuf.String()
+
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return stdout, stderr, -1, fmt.Errorf("failed to run %s: %w", runtime, err)
+		}
+		if exitErr.ExitCode() == containerRuntimeErrorCode {
+			return stdout, stderr, -1, fmt.Errorf("failed to start container from %s: %s", r.Image, strings.TrimSpace(stderr))
+		}
+		return stdout, stderr, exitErr.ExitCode(), nil
+	}
+	return stdout, stderr, 0, nil
+}
+
+// args returns the runtime's arguments for running command in container
+// name.
+func (r *ContainerCommandRunner) args(name, workDir, command string, env []string) []string {
+	args := []string{
+		"run", "--rm",
+		"--name", name,
+		"--user", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
+		"--volume", workDir + ":" + workDir,
+		"--workdir", workDir,
+	}
+	for _, entry := range env {
+		key, _, _ := strings.Cut(entry, "=")
+		args = append(args, "--env", key)
+	}
+	return append(args, r.Image, "sh", "-c", command)
+}
@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// writeFakeRuntime writes a container runtime that runs script instead of
+// a container.
+func writeFakeRuntime(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "runtime")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("Failed to write fake runtime: %v", err)
+	}
+	return path
+}
+
+func TestScriptExecutor_Execute_Sandbox(t *testing.T) {
+	direct := &MockCommandRunner{Stdout: "direct"}
+	sandboxed := &MockCommandRunner{Stdout: "sandboxed"}
+	var image string
+
+	executor := NewScriptExecutorWithRunner(direct)
+	executor.SetSandboxRunner(func(sandbox *grimoire.Sandbox) CommandRunner {
+		image = sandbox.Image
+		return sandboxed
+	})
+	stepCtx := NewStepContext("/tmp/worktree", "bead-1", "wf-1")
+
+	step := &grimoire.Step{
+		Name:    "test",
+		Type:    grimoire.StepTypeScript,
+		Command: "make test",
+		Sandbox: &grimoire.Sandbox{Image: "golang:1.22"},
+	}
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Output != "sandboxed" || direct.Command != "" {
+		t.Fatalf("Output = %q, want the sandboxed runner's", result.Output)
+	}
+	if image != "golang:1.22" {
+		t.Errorf("sandbox image = %q, want %q", image, "golang:1.22")
+	}
+	if sandboxed.WorkDir != "/tmp/worktree" {
+		t.Errorf("WorkDir = %q, want %q", sandboxed.WorkDir, "/tmp/worktree")
+	}
+
+	// Without a sandbox the command runs directly
+	step.Sandbox = nil
+	result, err = executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Output != "direct" {
+		t.Errorf("Output = %q, want %q", result.Output, "direct")
+	}
+}
+
+func TestContainerCommandRunner_Args(t *testing.T) {
+	runtime := writeFakeRuntime(t, `printf '%s\n' "$@"
+echo "TOKEN=$TOKEN"
+exit 3
+`)
+	runner := &ContainerCommandRunner{Image: "alpine:3", Runtime: runtime}
+	workDir := t.TempDir()
+
+	stdout, _, exitCode, err := runner.RunWithEnv(context.Background(), workDir, "make test", []string{"TOKEN=s3cret"})
+	if err != nil {
+		t.Fatalf("RunWithEnv() error: %v", err)
+	}
+	if exitCode != 3 {
+		t.Errorf("exitCode = %d, want 3", exitCode)
+	}
+
+	args := strings.Join(strings.Split(strings.TrimSpace(stdout), "\n"), " ")
+	for _, want := range []string{
+		"run --rm",
+		fmt.Sprintf("--user %d:%d", os.Getuid(), os.Getgid()),
+		"--volume " + workDir + ":" + workDir,
+		"--workdir " + workDir,
+		"--env TOKEN alpine:3 sh -c make test",
+		"TOKEN=s3cret",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("runtime output %q does not contain %q", args, want)
+		}
+	}
+	// The value reaches the runtime through its environment only
+	if strings.Contains(args, "--env TOKEN=s3cret") {
+		t.Errorf("runtime arguments %q contain the variable's value", args)
+	}
+}
+
+func TestContainerCommandRunner_StartFailure(t *testing.T) {
+	runtime := writeFakeRuntime(t, `echo "Unable to find image 'missing:latest'" >&2
+exit 125
+`)
+	runner := &ContainerCommandRunner{Image: "missing:latest", Runtime: runtime}
+
+	_, _, exitCode, err := runner.Run(context.Background(), t.TempDir(), "true")
+	if err == nil || !strings.Contains(err.Error(), "Unable to find image") {
+		t.Fatalf("Run() error = %v, want the runtime's error", err)
+	}
+	if exitCode != -1 {
+		t.Errorf("exitCode = %d, want -1", exitCode)
+	}
+}
+
+// TestContainerCommandRunner_Docker runs a step in a real container. It is
+// skipped unless docker is available; set COVEN_TEST_SANDBOX_IMAGE to use an
+// image other than alpine:3.
+func TestContainerCommandRunner_Docker(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker not available")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skip("docker daemon not running")
+	}
+	image := os.Getenv("COVEN_TEST_SANDBOX_IMAGE")
+	if image == "" {
+		image = "alpine:3"
+	}
+
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, "input.txt"), []byte("from the host\n"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	executor := NewScriptExecutor()
+	step := &grimoire.Step{
+		Name:    "sandboxed",
+		Type:    grimoire.StepTypeScript,
+		Command: `pwd && cat input.txt && echo "from the container" > output.txt`,
+		Sandbox: &grimoire.Sandbox{Image: image},
+	}
+	result, err := executor.Execute(context.Background(), step, NewStepContext(worktree, "bead-1", "wf-1"))
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Execute() failed: %s\n%s", result.Error, result.Output)
+	}
+	if !strings.Contains(result.Output, worktree) || !strings.Contains(result.Output, "from the host") {
+		t.Errorf("Output = %q, want the worktree path and input.txt", result.Output)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "output.txt"))
+	if err != nil {
+		t.Fatalf("output.txt not written to the worktree: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "from the container" {
+		t.Errorf("output.txt = %q, want %q", data, "from the container")
+	}
+}
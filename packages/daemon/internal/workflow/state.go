@@ -5,11 +5,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 )
 
+// StateVersion is the workflow state schema version written by this daemon.
+// States written by a newer daemon are refused rather than resumed, since
+// fields this daemon doesn't know about would be lost on the next save.
+// States without a version predate versioning and are treated as version 1.
+const StateVersion = 1
+
 // WorkflowState represents the persisted state of a workflow execution.
 type WorkflowState struct {
+	// Version is the schema version the state was written with.
+	Version int `json:"version,omitempty"`
+
 	// TaskID is the bead/task ID this workflow is for.
 	TaskID string `json:"task_id"`
 
@@ -19,6 +31,10 @@ type WorkflowState struct {
 	// GrimoireName is the name of the grimoire being executed.
 	GrimoireName string `json:"grimoire_name"`
 
+	// GrimoireHash is the content hash of the grimoire definition being executed.
+	// Resumed and retried workflows use the snapshot with this hash.
+	GrimoireHash string `json:"grimoire_hash,omitempty"`
+
 	// WorktreePath is the path to the git worktree.
 	WorktreePath string `json:"worktree_path"`
 
@@ -46,13 +62,107 @@ type WorkflowState struct {
 	// ActiveStepTaskID is the task ID of the currently running step (for agent steps).
 	// This allows reconnecting to running agents after daemon restart.
 	ActiveStepTaskID string `json:"active_step_task_id,omitempty"`
+
+	// LastProgressAt is when the running agent step last produced output.
+	LastProgressAt *time.Time `json:"last_progress_at,omitempty"`
+
+	// Escalation contains the failure details when a step escalated the workflow.
+	Escalation *Escalation `json:"escalation,omitempty"`
+
+	// KeepWorktree indicates the worktree and this state are kept after the
+	// workflow completes, until explicitly cleaned up.
+	KeepWorktree bool `json:"keep_worktree,omitempty"`
+
+	// NoChanges indicates the workflow completed but its merge steps found
+	// nothing to merge.
+	NoChanges bool `json:"no_changes,omitempty"`
+
+	// PendingConfirmation describes the step the workflow is waiting to have
+	// confirmed when its status is awaiting_confirmation.
+	PendingConfirmation *Confirmation `json:"pending_confirmation,omitempty"`
+
+	// ConfirmedStep is the name of a confirm-gated step that has been
+	// confirmed but not yet run. It lets the step run when the workflow resumes.
+	ConfirmedStep string `json:"confirmed_step,omitempty"`
+
+	// Comments are notes left on the workflow by reviewers, oldest first.
+	Comments []Comment `json:"comments,omitempty"`
+
+	// Tags attribute the workflow to a team, project or the like, for
+	// aggregating usage. See WorkflowTags.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Usage is the agent usage recorded so far.
+	Usage *WorkflowUsage `json:"usage,omitempty"`
+
+	// Inputs are the values of the grimoire's inputs the workflow was
+	// started with, so they are the same when it resumes.
+	Inputs map[string]interface{} `json:"inputs,omitempty"`
+}
+
+// Comment is a human note attached to a workflow.
+type Comment struct {
+	// Author is who left the comment.
+	Author string `json:"author,omitempty"`
+
+	// Text is the comment body.
+	Text string `json:"text"`
+
+	// CreatedAt is when the comment was added.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Confirmation describes a step waiting for human confirmation.
+type Confirmation struct {
+	// StepName is the name of the gated step.
+	StepName string `json:"step_name"`
+
+	// Description is the step's description, shown to whoever confirms it.
+	Description string `json:"description,omitempty"`
+
+	// RequestedAt is when the workflow stopped to wait for confirmation.
+	RequestedAt time.Time `json:"requested_at"`
 }
 
 // StatePersister handles saving and loading workflow state.
+//
+// Each workflow run's state is kept in its own file, keyed by workflow ID, so
+// re-running a task doesn't overwrite its earlier runs. A per-task index lists
+// the task's runs in the order they started and names the current one:
+//
+//	workflows/runs/<workflow-id>.json  state of one workflow run
+//	workflows/tasks/<task-id>.json     index of a task's runs
+//	workflows/<task-id>.json           state saved before runs were kept
+//
+// Task-keyed state from older daemons is still read, and is moved into the
+// task's history the next time the task's state is saved.
+//
+// Saves keep the comments stored for the same workflow run, so a comment added
+// while a workflow runs isn't lost on its next save. Code saving the same
+// tasks' states should share one StatePersister.
 type StatePersister struct {
 	stateDir string
+
+	// commentsMu serializes adding comments with saves.
+	commentsMu sync.Mutex
 }
 
+// taskIndex lists the workflow runs of a task.
+type taskIndex struct {
+	// TaskID is the task the runs are for.
+	TaskID string `json:"task_id"`
+
+	// WorkflowIDs are the task's runs, oldest first.
+	WorkflowIDs []string `json:"workflow_ids"`
+
+	// Current is the run the task's state refers to, or empty once that run
+	// has been deleted with Delete.
+	Current string `json:"current,omitempty"`
+}
+
+// indexMu serializes updates to task indexes.
+var indexMu sync.Mutex
+
 // NewStatePersister creates a new state persister.
 func NewStatePersister(covenDir string) *StatePersister {
 	return &StatePersister{
@@ -65,82 +175,204 @@ func (p *StatePersister) StateDir() string {
 	return p.stateDir
 }
 
-// Save persists workflow state to disk.
+// Save persists workflow state to disk and makes it the task's current run.
+// Its comments are first replaced with those stored for the same workflow
+// run, since comments are only added with AddComment.
 func (p *StatePersister) Save(state *WorkflowState) error {
-	// Ensure directory exists
-	if err := os.MkdirAll(p.stateDir, 0755); err != nil {
-		return fmt.Errorf("failed to create workflow state dir: %w", err)
+	p.commentsMu.Lock()
+	defer p.commentsMu.Unlock()
+
+	if stored, err := p.Load(state.TaskID); err == nil && stored != nil && stored.WorkflowID == state.WorkflowID {
+		state.Comments = stored.Comments
+	}
+	return p.save(state)
+}
+
+// save persists workflow state as it is.
+func (p *StatePersister) save(state *WorkflowState) error {
+	// Ensure directories exist
+	for _, dir := range []string{p.runsDir(), p.tasksDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create workflow state dir: %w", err)
+		}
+	}
+
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	index, err := p.loadIndex(state.TaskID)
+	if err != nil {
+		return err
 	}
+	if index == nil {
+		if index, err = p.migrateLegacyState(state.TaskID); err != nil {
+			return err
+		}
+	}
+
+	runID := stateRunID(state)
+	statePath := p.runPath(runID)
 
+	// Never overwrite a state written by a newer daemon
+	if header, err := readStateHeader(statePath); err == nil && header.Version > StateVersion {
+		return header.unsupportedError()
+	}
+
+	state.Version = StateVersion
 	state.UpdatedAt = time.Now()
 
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal workflow state: %w", err)
 	}
-
-	statePath := p.statePath(state.TaskID)
-
-	// Write atomically
-	tmpPath := statePath + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+	if err := writeFileAtomic(statePath, data); err != nil {
 		return fmt.Errorf("failed to write workflow state: %w", err)
 	}
 
-	if err := os.Rename(tmpPath, statePath); err != nil {
-		os.Remove(tmpPath)
-		return fmt.Errorf("failed to rename workflow state: %w", err)
+	if !slices.Contains(index.WorkflowIDs, runID) {
+		index.WorkflowIDs = append(index.WorkflowIDs, runID)
 	}
+	index.Current = runID
+	return p.saveIndex(index)
+}
 
-	return nil
+// AddComment appends a comment to the stored workflow state and returns the
+// updated state. It returns nil if there is no state for the task.
+func (p *StatePersister) AddComment(taskID string, comment Comment) (*WorkflowState, error) {
+	p.commentsMu.Lock()
+	defer p.commentsMu.Unlock()
+
+	state, err := p.Load(taskID)
+	if err != nil || state == nil {
+		return nil, err
+	}
+
+	if comment.CreatedAt.IsZero() {
+		comment.CreatedAt = time.Now()
+	}
+	state.Comments = append(state.Comments, comment)
+	if err := p.save(state); err != nil {
+		return nil, err
+	}
+	return state, nil
 }
 
-// Load loads workflow state from disk.
+// Load loads the state of a task's current workflow run from disk.
+// It returns nil if the task has no current run.
+// It returns an UnsupportedStateVersionError, leaving the file untouched, if
+// the state was written with a newer schema version than StateVersion.
 func (p *StatePersister) Load(taskID string) (*WorkflowState, error) {
-	statePath := p.statePath(taskID)
+	statePath, ok, err := p.currentPath(taskID)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return loadStateFile(statePath)
+}
 
-	data, err := os.ReadFile(statePath)
+// History returns the states of all of a task's retained workflow runs,
+// oldest first, including runs that are no longer current. Runs whose state
+// can't be read, such as ones removed by retention cleanup, are left out.
+func (p *StatePersister) History(taskID string) ([]*WorkflowState, error) {
+	index, err := p.loadIndex(taskID)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil // No state file
+		return nil, err
+	}
+
+	var paths []string
+	if index != nil {
+		for _, id := range index.WorkflowIDs {
+			paths = append(paths, p.runPath(id))
 		}
-		return nil, fmt.Errorf("failed to read workflow state: %w", err)
+	} else {
+		paths = append(paths, p.legacyStatePath(taskID))
 	}
 
-	var state WorkflowState
-	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, fmt.Errorf("failed to parse workflow state: %w", err)
+	var history []*WorkflowState
+	for _, path := range paths {
+		state, err := loadStateFile(path)
+		if err != nil || state == nil {
+			continue
+		}
+		history = append(history, state)
 	}
+	return history, nil
+}
 
-	return &state, nil
+// CurrentWorkflowID returns the workflow ID of the task's current run, or ""
+// if it has none.
+func (p *StatePersister) CurrentWorkflowID(taskID string) string {
+	index, err := p.loadIndex(taskID)
+	if err != nil {
+		return ""
+	}
+	if index != nil {
+		return index.Current
+	}
+	if header, err := readStateHeader(p.legacyStatePath(taskID)); err == nil {
+		return header.WorkflowID
+	}
+	return ""
 }
 
-// Delete removes workflow state from disk.
+// Delete ends a task's current workflow run, so Load no longer returns it.
+// The run's state is kept in the task's history.
 func (p *StatePersister) Delete(taskID string) error {
-	statePath := p.statePath(taskID)
-	if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete workflow state: %w", err)
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	index, err := p.loadIndex(taskID)
+	if err != nil {
+		return err
 	}
-	return nil
+	if index == nil {
+		if index, err = p.migrateLegacyState(taskID); err != nil {
+			return err
+		}
+		if len(index.WorkflowIDs) == 0 {
+			return nil
+		}
+	}
+
+	index.Current = ""
+	return p.saveIndex(index)
+}
+
+// TaskIDs returns the IDs of all tasks with persisted workflow state.
+func (p *StatePersister) TaskIDs() ([]string, error) {
+	seen := make(map[string]bool)
+	var taskIDs []string
+	for _, dir := range []string{p.tasksDir(), p.stateDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read workflow state dir: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+				continue
+			}
+			taskID := strings.TrimSuffix(entry.Name(), ".json")
+			if !seen[taskID] {
+				seen[taskID] = true
+				taskIDs = append(taskIDs, taskID)
+			}
+		}
+	}
+	return taskIDs, nil
 }
 
 // ListInterrupted returns all workflow states that were interrupted (running status).
 func (p *StatePersister) ListInterrupted() ([]*WorkflowState, error) {
-	entries, err := os.ReadDir(p.stateDir)
+	taskIDs, err := p.TaskIDs()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("failed to read workflow state dir: %w", err)
+		return nil, err
 	}
 
 	var interrupted []*WorkflowState
-	for _, entry := range entries {
-		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
-			continue
-		}
-
-		taskID := entry.Name()[:len(entry.Name())-5] // Remove .json extension
+	for _, taskID := range taskIDs {
 		state, err := p.Load(taskID)
 		if err != nil {
 			continue // Skip invalid state files
@@ -155,13 +387,285 @@ func (p *StatePersister) ListInterrupted() ([]*WorkflowState, error) {
 	return interrupted, nil
 }
 
-// statePath returns the path to the state file for a task.
-func (p *StatePersister) statePath(taskID string) string {
-	return filepath.Join(p.stateDir, taskID+".json")
+// ListUnsupported returns an error for each current workflow state that was
+// written with a newer schema version than this daemon supports.
+func (p *StatePersister) ListUnsupported() []*UnsupportedStateVersionError {
+	taskIDs, err := p.TaskIDs()
+	if err != nil {
+		return nil
+	}
+
+	var unsupported []*UnsupportedStateVersionError
+	for _, taskID := range taskIDs {
+		statePath, ok, err := p.currentPath(taskID)
+		if err != nil || !ok {
+			continue
+		}
+
+		header, err := readStateHeader(statePath)
+		if err != nil || header.Version <= StateVersion {
+			continue
+		}
+		unsupported = append(unsupported, header.unsupportedError())
+	}
+
+	return unsupported
 }
 
-// Exists checks if state exists for a task.
+// FindByWorkflowID returns the current workflow state with the given workflow
+// ID, or nil if no task's current run matches.
+func (p *StatePersister) FindByWorkflowID(workflowID string) *WorkflowState {
+	taskIDs, err := p.TaskIDs()
+	if err != nil {
+		return nil
+	}
+
+	for _, taskID := range taskIDs {
+		state, err := p.Load(taskID)
+		if err != nil || state == nil {
+			continue
+		}
+
+		if state.WorkflowID == workflowID {
+			return state
+		}
+	}
+
+	return nil
+}
+
+// Exists checks if a task has a current workflow run.
 func (p *StatePersister) Exists(taskID string) bool {
-	_, err := os.Stat(p.statePath(taskID))
+	statePath, ok, err := p.currentPath(taskID)
+	if err != nil || !ok {
+		return false
+	}
+	_, err = os.Stat(statePath)
 	return err == nil
 }
+
+// currentPath returns the path to the state file of a task's current run,
+// and false if the task has no current run.
+func (p *StatePersister) currentPath(taskID string) (string, bool, error) {
+	index, err := p.loadIndex(taskID)
+	if err != nil {
+		return "", false, err
+	}
+	if index == nil {
+		legacyPath := p.legacyStatePath(taskID)
+		if _, err := os.Stat(legacyPath); err != nil {
+			return "", false, nil
+		}
+		return legacyPath, true, nil
+	}
+	if index.Current == "" {
+		return "", false, nil
+	}
+	return p.runPath(index.Current), true, nil
+}
+
+// migrateLegacyState moves a task's state saved before runs were kept into
+// the task's history, returning the task's new index. It must be called with
+// indexMu held, only for tasks without an index.
+func (p *StatePersister) migrateLegacyState(taskID string) (*taskIndex, error) {
+	index := &taskIndex{TaskID: taskID}
+
+	legacyPath := p.legacyStatePath(taskID)
+	header, err := readStateHeader(legacyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("failed to read workflow state: %w", err)
+	}
+	// Leave state written by a newer daemon where it is
+	if header.Version > StateVersion {
+		return nil, header.unsupportedError()
+	}
+
+	runID := header.WorkflowID
+	if runID == "" {
+		runID = taskID
+	}
+	if err := os.MkdirAll(p.runsDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workflow state dir: %w", err)
+	}
+	if err := os.Rename(legacyPath, p.runPath(runID)); err != nil {
+		return nil, fmt.Errorf("failed to migrate workflow state: %w", err)
+	}
+
+	index.WorkflowIDs = []string{runID}
+	index.Current = runID
+	if err := p.saveIndex(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// loadIndex loads a task's index, returning nil if it has none.
+func (p *StatePersister) loadIndex(taskID string) (*taskIndex, error) {
+	data, err := os.ReadFile(p.indexPath(taskID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read workflow index: %w", err)
+	}
+
+	var index taskIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow index: %w", err)
+	}
+	return &index, nil
+}
+
+// saveIndex writes a task's index.
+func (p *StatePersister) saveIndex(index *taskIndex) error {
+	if err := os.MkdirAll(p.tasksDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create workflow state dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal workflow index: %w", err)
+	}
+	if err := writeFileAtomic(p.indexPath(index.TaskID), data); err != nil {
+		return fmt.Errorf("failed to write workflow index: %w", err)
+	}
+	return nil
+}
+
+// runsDir returns the directory holding the state of each workflow run.
+func (p *StatePersister) runsDir() string {
+	return filepath.Join(p.stateDir, "runs")
+}
+
+// tasksDir returns the directory holding each task's index.
+func (p *StatePersister) tasksDir() string {
+	return filepath.Join(p.stateDir, "tasks")
+}
+
+// runPath returns the path to the state file for a workflow run.
+func (p *StatePersister) runPath(workflowID string) string {
+	return filepath.Join(p.runsDir(), workflowID+".json")
+}
+
+// indexPath returns the path to a task's index.
+func (p *StatePersister) indexPath(taskID string) string {
+	return filepath.Join(p.tasksDir(), taskID+".json")
+}
+
+// legacyStatePath returns the path a task's state was saved to before
+// workflow runs were kept.
+func (p *StatePersister) legacyStatePath(taskID string) string {
+	return filepath.Join(p.stateDir, taskID+".json")
+}
+
+// stateRunID returns the key a state's run is stored under. States without a
+// workflow ID are keyed by their task ID.
+func stateRunID(state *WorkflowState) string {
+	if state.WorkflowID != "" {
+		return state.WorkflowID
+	}
+	return state.TaskID
+}
+
+// loadStateFile loads a workflow state file, returning nil if it doesn't exist.
+func loadStateFile(statePath string) (*WorkflowState, error) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil // No state file
+		}
+		return nil, fmt.Errorf("failed to read workflow state: %w", err)
+	}
+
+	var header stateHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow state: %w", err)
+	}
+	if header.Version > StateVersion {
+		return nil, header.unsupportedError()
+	}
+
+	var state WorkflowState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// writeFileAtomic writes data to path through a temporary file, so readers
+// never see a partial write.
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// stateHeader holds the fields of a workflow state that are read before
+// checking its schema version. They are stable across versions.
+type stateHeader struct {
+	Version      int            `json:"version"`
+	TaskID       string         `json:"task_id"`
+	WorkflowID   string         `json:"workflow_id"`
+	GrimoireName string         `json:"grimoire_name"`
+	Status       WorkflowStatus `json:"status"`
+}
+
+// readStateHeader reads the version and identifying fields of a state file.
+func readStateHeader(path string) (*stateHeader, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var header stateHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+func (h *stateHeader) unsupportedError() *UnsupportedStateVersionError {
+	return &UnsupportedStateVersionError{
+		TaskID:       h.TaskID,
+		WorkflowID:   h.WorkflowID,
+		GrimoireName: h.GrimoireName,
+		Status:       h.Status,
+		Version:      h.Version,
+	}
+}
+
+// UnsupportedStateVersionError is returned when a workflow state was written
+// by a newer daemon with a schema version this daemon does not understand.
+type UnsupportedStateVersionError struct {
+	TaskID       string
+	WorkflowID   string
+	GrimoireName string
+	Status       WorkflowStatus
+	Version      int
+}
+
+func (e *UnsupportedStateVersionError) Error() string {
+	return fmt.Sprintf("unsupported state version %d for task %s (this daemon supports up to version %d); upgrade the daemon to resume this workflow",
+		e.Version, e.TaskID, StateVersion)
+}
+
+// Interrupted reports whether the unsupported state was running when it was
+// last saved, and so would otherwise have been resumed.
+func (e *UnsupportedStateVersionError) Interrupted() bool {
+	return e.Status == WorkflowRunning || e.Status == ""
+}
+
+// IsUnsupportedStateVersion checks if an error is an UnsupportedStateVersionError.
+func IsUnsupportedStateVersion(err error) bool {
+	_, ok := err.(*UnsupportedStateVersionError)
+	return ok
+}
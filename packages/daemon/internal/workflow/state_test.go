@@ -42,8 +42,8 @@ func TestStatePersister_SaveAndLoad(t *testing.T) {
 		t.Fatalf("Save() error: %v", err)
 	}
 
-	// Verify file exists
-	statePath := filepath.Join(tmpDir, "workflows", "task-123.json")
+	// Verify file exists, keyed by workflow ID
+	statePath := filepath.Join(tmpDir, "workflows", "runs", "wf-456.json")
 	if _, err := os.Stat(statePath); os.IsNotExist(err) {
 		t.Fatal("State file was not created")
 	}
@@ -155,6 +155,93 @@ func TestStatePersister_Delete_NotFound(t *testing.T) {
 	}
 }
 
+func TestStatePersister_History(t *testing.T) {
+	tmpDir := t.TempDir()
+	persister := NewStatePersister(tmpDir)
+
+	// The task is run twice; the second run doesn't overwrite the first
+	first := &WorkflowState{TaskID: "task-1", WorkflowID: "wf-1", Status: WorkflowFailed, Error: "tests failed"}
+	second := &WorkflowState{TaskID: "task-1", WorkflowID: "wf-2", Status: WorkflowRunning}
+	for _, state := range []*WorkflowState{first, second} {
+		if err := persister.Save(state); err != nil {
+			t.Fatalf("Save(%s) error: %v", state.WorkflowID, err)
+		}
+	}
+
+	loaded, err := persister.Load("task-1")
+	if err != nil || loaded == nil || loaded.WorkflowID != "wf-2" {
+		t.Fatalf("Load() = %+v, %v, want the latest run wf-2", loaded, err)
+	}
+	if got := persister.CurrentWorkflowID("task-1"); got != "wf-2" {
+		t.Errorf("CurrentWorkflowID() = %q, want wf-2", got)
+	}
+
+	history, err := persister.History("task-1")
+	if err != nil {
+		t.Fatalf("History() error: %v", err)
+	}
+	if len(history) != 2 || history[0].WorkflowID != "wf-1" || history[1].WorkflowID != "wf-2" {
+		t.Fatalf("History() = %+v, want wf-1 then wf-2", history)
+	}
+	if history[0].Error != "tests failed" {
+		t.Errorf("first run Error = %q, want it retained", history[0].Error)
+	}
+
+	// Deleting ends the current run but keeps it in the history
+	if err := persister.Delete("task-1"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if loaded, _ := persister.Load("task-1"); loaded != nil {
+		t.Errorf("Load() after Delete = %+v, want nil", loaded)
+	}
+	if got := persister.CurrentWorkflowID("task-1"); got != "" {
+		t.Errorf("CurrentWorkflowID() after Delete = %q, want none", got)
+	}
+	if history, _ := persister.History("task-1"); len(history) != 2 {
+		t.Errorf("History() after Delete has %d runs, want 2", len(history))
+	}
+	if state := persister.FindByWorkflowID("wf-2"); state != nil {
+		t.Errorf("FindByWorkflowID() = %+v, want nil for a run that isn't current", state)
+	}
+}
+
+func TestStatePersister_MigratesLegacyState(t *testing.T) {
+	tmpDir := t.TempDir()
+	persister := NewStatePersister(tmpDir)
+
+	// State saved by a daemon that kept one state file per task
+	stateDir := filepath.Join(tmpDir, "workflows")
+	os.MkdirAll(stateDir, 0755)
+	legacyPath := filepath.Join(stateDir, "task-1.json")
+	os.WriteFile(legacyPath, []byte(`{"version": 1, "task_id": "task-1", "workflow_id": "wf-old", "status": "blocked"}`), 0644)
+
+	loaded, err := persister.Load("task-1")
+	if err != nil || loaded == nil || loaded.WorkflowID != "wf-old" {
+		t.Fatalf("Load() = %+v, %v, want the legacy state", loaded, err)
+	}
+	if taskIDs, _ := persister.TaskIDs(); len(taskIDs) != 1 || taskIDs[0] != "task-1" {
+		t.Errorf("TaskIDs() = %v, want [task-1]", taskIDs)
+	}
+
+	// The next save moves it into the task's history
+	if err := persister.Save(&WorkflowState{TaskID: "task-1", WorkflowID: "wf-new", Status: WorkflowRunning}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := os.Stat(legacyPath); !os.IsNotExist(err) {
+		t.Error("legacy state file should be moved")
+	}
+	history, err := persister.History("task-1")
+	if err != nil {
+		t.Fatalf("History() error: %v", err)
+	}
+	if len(history) != 2 || history[0].WorkflowID != "wf-old" || history[1].WorkflowID != "wf-new" {
+		t.Errorf("History() = %+v, want wf-old then wf-new", history)
+	}
+	if taskIDs, _ := persister.TaskIDs(); len(taskIDs) != 1 {
+		t.Errorf("TaskIDs() = %v, want task-1 once", taskIDs)
+	}
+}
+
 func TestStatePersister_ListInterrupted(t *testing.T) {
 	tmpDir := t.TempDir()
 	persister := NewStatePersister(tmpDir)
@@ -245,6 +332,30 @@ func TestStatePersister_Exists(t *testing.T) {
 	}
 }
 
+func TestStatePersister_FindByWorkflowID(t *testing.T) {
+	tmpDir := t.TempDir()
+	persister := NewStatePersister(tmpDir)
+
+	if state := persister.FindByWorkflowID("wf-1"); state != nil {
+		t.Errorf("FindByWorkflowID() = %+v, want nil when no states exist", state)
+	}
+
+	persister.Save(&WorkflowState{TaskID: "task-1", WorkflowID: "wf-1", Status: WorkflowRunning})
+	persister.Save(&WorkflowState{TaskID: "task-2", WorkflowID: "wf-2", Status: WorkflowBlocked})
+
+	state := persister.FindByWorkflowID("wf-2")
+	if state == nil {
+		t.Fatal("FindByWorkflowID() returned nil")
+	}
+	if state.TaskID != "task-2" {
+		t.Errorf("TaskID = %q, want %q", state.TaskID, "task-2")
+	}
+
+	if state := persister.FindByWorkflowID("wf-missing"); state != nil {
+		t.Errorf("FindByWorkflowID() = %+v, want nil for unknown workflow", state)
+	}
+}
+
 func TestStatePersister_Save_AtomicWrite(t *testing.T) {
 	tmpDir := t.TempDir()
 	persister := NewStatePersister(tmpDir)
@@ -265,10 +376,12 @@ func TestStatePersister_Save_AtomicWrite(t *testing.T) {
 
 	// Verify no temp files remain
 	stateDir := filepath.Join(tmpDir, "workflows")
-	entries, _ := os.ReadDir(stateDir)
-	for _, e := range entries {
-		if filepath.Ext(e.Name()) == ".tmp" {
-			t.Errorf("Found leftover temp file: %s", e.Name())
+	for _, dir := range []string{stateDir, filepath.Join(stateDir, "runs"), filepath.Join(stateDir, "tasks")} {
+		entries, _ := os.ReadDir(dir)
+		for _, e := range entries {
+			if filepath.Ext(e.Name()) == ".tmp" {
+				t.Errorf("Found leftover temp file: %s", e.Name())
+			}
 		}
 	}
 
@@ -314,3 +427,187 @@ func TestStatePersister_ListInterrupted_IgnoresDirectories(t *testing.T) {
 		t.Errorf("Expected task 'real-task', got %s", interrupted[0].TaskID)
 	}
 }
+
+// writeFutureState writes a running state with a schema version newer than
+// this daemon supports, including a field this daemon doesn't know about.
+func writeFutureState(t *testing.T, covenDir, taskID string) string {
+	t.Helper()
+	stateDir := filepath.Join(covenDir, "workflows")
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		t.Fatalf("Failed to create state dir: %v", err)
+	}
+	content := `{
+  "version": 99,
+  "task_id": "` + taskID + `",
+  "workflow_id": "wf-future",
+  "grimoire_name": "future-grimoire",
+  "status": "running",
+  "current_step": 1,
+  "new_field_from_the_future": {"keep": "me"}
+}`
+	statePath := filepath.Join(stateDir, taskID+".json")
+	if err := os.WriteFile(statePath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+	return content
+}
+
+func TestStatePersister_Save_SetsVersion(t *testing.T) {
+	persister := NewStatePersister(t.TempDir())
+
+	if err := persister.Save(&WorkflowState{TaskID: "task-1", Status: WorkflowRunning}); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := persister.Load("task-1")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if loaded.Version != StateVersion {
+		t.Errorf("Version = %d, want %d", loaded.Version, StateVersion)
+	}
+}
+
+func TestStatePersister_Load_UnversionedState(t *testing.T) {
+	tmpDir := t.TempDir()
+	stateDir := filepath.Join(tmpDir, "workflows")
+	os.MkdirAll(stateDir, 0755)
+	os.WriteFile(filepath.Join(stateDir, "task-1.json"), []byte(`{"task_id": "task-1", "status": "running"}`), 0644)
+
+	loaded, err := NewStatePersister(tmpDir).Load("task-1")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if loaded == nil || loaded.TaskID != "task-1" {
+		t.Errorf("Load() = %+v, want legacy state to load", loaded)
+	}
+}
+
+func TestStatePersister_Load_FutureVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := writeFutureState(t, tmpDir, "task-1")
+	persister := NewStatePersister(tmpDir)
+
+	state, err := persister.Load("task-1")
+	if !IsUnsupportedStateVersion(err) {
+		t.Fatalf("Load() error = %v, want UnsupportedStateVersionError", err)
+	}
+	if state != nil {
+		t.Errorf("Load() state = %+v, want nil", state)
+	}
+
+	unsupported := err.(*UnsupportedStateVersionError)
+	if unsupported.Version != 99 || unsupported.WorkflowID != "wf-future" || !unsupported.Interrupted() {
+		t.Errorf("UnsupportedStateVersionError = %+v", unsupported)
+	}
+
+	// Saving over the newer state is refused and the file is left intact
+	err = persister.Save(&WorkflowState{TaskID: "task-1", Status: WorkflowBlocked})
+	if !IsUnsupportedStateVersion(err) {
+		t.Errorf("Save() error = %v, want UnsupportedStateVersionError", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(tmpDir, "workflows", "task-1.json"))
+	if string(data) != content {
+		t.Errorf("state file was modified:\n%s", data)
+	}
+}
+
+func TestStatePersister_ListUnsupported(t *testing.T) {
+	tmpDir := t.TempDir()
+	persister := NewStatePersister(tmpDir)
+	writeFutureState(t, tmpDir, "task-future")
+	persister.Save(&WorkflowState{TaskID: "task-current", Status: WorkflowRunning})
+
+	unsupported := persister.ListUnsupported()
+	if len(unsupported) != 1 || unsupported[0].TaskID != "task-future" {
+		t.Errorf("ListUnsupported() = %+v, want only task-future", unsupported)
+	}
+
+	interrupted, err := persister.ListInterrupted()
+	if err != nil {
+		t.Fatalf("ListInterrupted() error: %v", err)
+	}
+	if len(interrupted) != 1 || interrupted[0].TaskID != "task-current" {
+		t.Errorf("ListInterrupted() should skip unsupported states, got %d", len(interrupted))
+	}
+}
+
+func TestStatePersister_AddComment(t *testing.T) {
+	persister := NewStatePersister(t.TempDir())
+	state := &WorkflowState{TaskID: "task-1", WorkflowID: "wf-1", Status: WorkflowPendingMerge}
+	if err := persister.Save(state); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	if _, err := persister.AddComment("task-1", Comment{Author: "alice", Text: "Missing tests for the parser"}); err != nil {
+		t.Fatalf("AddComment() error: %v", err)
+	}
+	updated, err := persister.AddComment("task-1", Comment{Author: "bob", Text: "Agreed"})
+	if err != nil {
+		t.Fatalf("AddComment() error: %v", err)
+	}
+	if len(updated.Comments) != 2 {
+		t.Fatalf("returned Comments = %d, want 2", len(updated.Comments))
+	}
+
+	loaded, err := persister.Load("task-1")
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if len(loaded.Comments) != 2 {
+		t.Fatalf("Comments = %d, want 2", len(loaded.Comments))
+	}
+	if loaded.Comments[0].Author != "alice" || loaded.Comments[1].Text != "Agreed" {
+		t.Errorf("Comments = %+v, want alice then bob", loaded.Comments)
+	}
+	if loaded.Comments[0].CreatedAt.IsZero() {
+		t.Error("CreatedAt should default to now")
+	}
+}
+
+func TestStatePersister_AddComment_NotFound(t *testing.T) {
+	persister := NewStatePersister(t.TempDir())
+
+	state, err := persister.AddComment("missing", Comment{Text: "hello"})
+	if err != nil {
+		t.Fatalf("AddComment() error: %v", err)
+	}
+	if state != nil {
+		t.Errorf("AddComment() = %+v, want nil for missing state", state)
+	}
+}
+
+func TestStatePersister_SaveKeepsComments(t *testing.T) {
+	persister := NewStatePersister(t.TempDir())
+
+	// The engine holds its own copy of the state while a comment is added
+	running := &WorkflowState{TaskID: "task-1", WorkflowID: "wf-1", Status: WorkflowRunning}
+	if err := persister.Save(running); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if _, err := persister.AddComment("task-1", Comment{Text: "Watch the migration step"}); err != nil {
+		t.Fatalf("AddComment() error: %v", err)
+	}
+
+	running.CurrentStep = 1
+	if err := persister.Save(running); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, _ := persister.Load("task-1")
+	if loaded.CurrentStep != 1 {
+		t.Errorf("CurrentStep = %d, want 1", loaded.CurrentStep)
+	}
+	if len(loaded.Comments) != 1 {
+		t.Errorf("Comments = %d, want the comment to be kept", len(loaded.Comments))
+	}
+
+	// A new run for the same task doesn't inherit the old run's comments
+	rerun := &WorkflowState{TaskID: "task-1", WorkflowID: "wf-2", Status: WorkflowRunning}
+	if err := persister.Save(rerun); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, _ = persister.Load("task-1")
+	if len(loaded.Comments) != 0 {
+		t.Errorf("Comments = %d, want none for a new run", len(loaded.Comments))
+	}
+}
@@ -3,6 +3,7 @@ package workflow
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -82,10 +83,24 @@ func (e *AgentExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 		return nil, fmt.Errorf("invalid timeout: %w", err)
 	}
 
+	stallTimeout, err := step.GetStallTimeout()
+	if err != nil {
+		return nil, fmt.Errorf("invalid stall_timeout: %w", err)
+	}
+	progressRunner, watchesProgress := e.runner.(ProgressAgentRunner)
+	if stallTimeout > 0 && !watchesProgress {
+		return nil, fmt.Errorf("agent runner does not report progress, required by stall_timeout on step %q", step.Name)
+	}
+
 	// Create context with timeout
 	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	// The watchdog cancels the run with errAgentStalled if the agent goes
+	// quiet for longer than its stall timeout
+	runCtx, cancelRun := context.WithCancelCause(execCtx)
+	defer cancelRun(nil)
+
 	// Load and render the spell
 	prompt, err := e.preparePrompt(step, stepCtx)
 	if err != nil {
@@ -96,21 +111,40 @@ func (e *AgentExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 	var runResult *AgentRunResult
 	start := time.Now()
 
+	// Track the agent's output as progress while it runs
+	var watchdog *agentWatchdog
+	stopWatching := func() {}
+	watch := func(stepTaskID string) {
+		if !watchesProgress {
+			return
+		}
+		watchdog = startAgentWatchdog(stallTimeout, cancelRun, stepCtx, start)
+		stopWatching = progressRunner.WatchProgress(stepTaskID, watchdog.progress)
+	}
+
 	if stepCtx.ActiveStepTaskID != "" && e.runner.IsRunning(stepCtx.ActiveStepTaskID) {
 		// Resume: wait for the existing process instead of spawning new
-		runResult, err = e.runner.WaitForExisting(execCtx, stepCtx.ActiveStepTaskID)
+		watch(stepCtx.ActiveStepTaskID)
+		runResult, err = e.runner.WaitForExisting(runCtx, stepCtx.ActiveStepTaskID)
 	} else {
 		// Fresh start: spawn new agent with callback to track process
 		onSpawn := func(stepTaskID string) {
 			stepCtx.SetActiveStepTaskID(stepTaskID)
+			watch(stepTaskID)
 		}
-		runResult, err = e.runner.Run(execCtx, stepCtx.WorktreePath, prompt, onSpawn)
+		runResult, err = e.runner.Run(withAgentStep(runCtx, stepCtx.BeadID, step.Name), stepCtx.WorktreePath, prompt, onSpawn)
+	}
+
+	stopWatching()
+	if watchdog != nil {
+		watchdog.stop()
 	}
 
 	// Clear active step since we're done (whether success or failure)
 	stepCtx.ClearActiveStepTaskID()
 
 	duration := time.Since(start)
+	stepCtx.RecordAgentRun(duration)
 
 	// Extract values from result
 	var output string
@@ -120,6 +154,18 @@ func (e *AgentExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 		exitCode = runResult.ExitCode
 	}
 
+	// Check for a stalled agent
+	if errors.Is(context.Cause(runCtx), errAgentStalled) {
+		return &StepResult{
+			Success:  false,
+			Output:   output,
+			ExitCode: -1,
+			Error:    fmt.Sprintf("agent stalled: no output for %s", stallTimeout),
+			Duration: duration,
+			Action:   ActionFail,
+		}, nil
+	}
+
 	// Check for timeout
 	if execCtx.Err() == context.DeadlineExceeded {
 		return &StepResult{
@@ -128,7 +174,8 @@ func (e *AgentExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 			ExitCode: -1,
 			Error:    fmt.Sprintf("agent timed out after %s", timeout),
 			Duration: duration,
-			Action:   ActionFail,
+			Action:   timeoutAction(step),
+			TimedOut: true,
 		}, nil
 	}
 
@@ -145,13 +192,8 @@ func (e *AgentExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 	}
 
 	// Parse structured output if present
-	agentOutput := e.parseAgentOutput(output)
-
-	// Determine success from exit code or parsed output
-	success := exitCode == 0
-	if agentOutput != nil {
-		success = agentOutput.Success
-	}
+	agentOutput, reported := e.parseAgentOutput(output)
+	success, failure := agentStepSuccess(agentOutput, reported, exitCode)
 
 	// Determine action based on success
 	action := ActionContinue
@@ -163,9 +205,19 @@ func (e *AgentExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 		Success:  success,
 		Output:   output,
 		ExitCode: exitCode,
+		Error:    failure,
 		Duration: duration,
 		Action:   action,
 	}
+	if agentOutput != nil {
+		result.Summary = agentOutput.Summary
+	}
+
+	// Escalate instead of failing for on_fail: escalate
+	if !success && step.OnFail == string(grimoire.OnFailEscalate) {
+		result.Action = ActionBlock
+		result.Escalation = NewEscalation(step, result)
+	}
 
 	// Store parsed output in context if step has output field
 	if step.Output != "" && agentOutput != nil {
@@ -175,24 +227,12 @@ func (e *AgentExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 	return result, nil
 }
 
-// preparePrompt loads and renders the spell template.
+// preparePrompt loads and renders the spell template, followed by the step's
+// sections in order.
 func (e *AgentExecutor) preparePrompt(step *grimoire.Step, stepCtx *StepContext) (string, error) {
-	var spellContent string
-
-	// Check if spell is inline (contains newlines) or a file reference
-	if strings.Contains(step.Spell, "\n") {
-		// Inline spell
-		spellContent = step.Spell
-	} else {
-		// Load from file
-		loadedSpell, err := e.spellLoader.Load(step.Spell)
-		if err != nil {
-			if spell.IsNotFound(err) {
-				return "", fmt.Errorf("spell not found: %q", step.Spell)
-			}
-			return "", fmt.Errorf("failed to load spell: %w", err)
-		}
-		spellContent = loadedSpell.Content
+	loadedSpell, err := e.loadSpell(step.Spell)
+	if err != nil {
+		return "", err
 	}
 
 	// Build render context
@@ -208,7 +248,7 @@ func (e *AgentExecutor) preparePrompt(step *grimoire.Step, stepCtx *StepContext)
 		// Render input variable values (they may contain template references)
 		rendered, err := e.renderer.RenderString(k, v, spell.RenderContext(stepCtx.Variables))
 		if err != nil {
-			return "", fmt.Errorf("failed to render input %q: %w", k, err)
+			return "", newRenderError(step, fmt.Sprintf("input %q", k), v, err)
 		}
 		renderCtx[k] = rendered
 	}
@@ -228,32 +268,111 @@ func (e *AgentExecutor) preparePrompt(step *grimoire.Step, stepCtx *StepContext)
 			"id": stepCtx.BeadID,
 		}
 	}
+	if _, exists := renderCtx["workflow"]; !exists {
+		renderCtx["workflow"], _ = stepCtx.builtinVariable("workflow")
+	}
 
-	// Render the spell
-	return e.renderer.RenderString(step.Name, spellContent, renderCtx)
+	// Render the spell, with the defaults and required variables its front
+	// matter declares
+	spellCtx, err := loadedSpell.ApplyVars(renderCtx)
+	if err != nil {
+		return "", newRenderError(step, "spell", loadedSpell.Content, err)
+	}
+	prompt, err := e.renderer.RenderString(step.Name, loadedSpell.Content, spellCtx)
+	if err != nil {
+		return "", newRenderError(step, "spell", loadedSpell.Content, err)
+	}
+
+	// Append each section, rendered against the same context
+	parts := []string{strings.TrimRight(prompt, "\n")}
+	for i, section := range step.Sections {
+		sectionSpell, err := e.loadSpell(section)
+		if err != nil {
+			return "", fmt.Errorf("section %d: %w", i, err)
+		}
+		field := fmt.Sprintf("sections[%d]", i)
+		sectionCtx, err := sectionSpell.ApplyVars(renderCtx)
+		if err != nil {
+			return "", newRenderError(step, field, sectionSpell.Content, err)
+		}
+		rendered, err := e.renderer.RenderString(fmt.Sprintf("%s.sections[%d]", step.Name, i), sectionSpell.Content, sectionCtx)
+		if err != nil {
+			return "", newRenderError(step, field, sectionSpell.Content, err)
+		}
+		parts = append(parts, strings.TrimRight(rendered, "\n"))
+	}
+	if len(parts) == 1 {
+		return prompt, nil
+	}
+	return strings.Join(parts, "\n\n") + "\n", nil
+}
+
+// loadSpell returns the spell for a spell reference, which is either inline
+// content (contains newlines) or the name of a spell file. Inline spells
+// have no front matter.
+func (e *AgentExecutor) loadSpell(ref string) (*spell.Spell, error) {
+	if IsInlineSpell(ref) {
+		return &spell.Spell{Content: ref}, nil
+	}
+
+	loadedSpell, err := e.spellLoader.Load(ref)
+	if err != nil {
+		if spell.IsNotFound(err) {
+			return nil, fmt.Errorf("spell not found: %q", ref)
+		}
+		return nil, fmt.Errorf("failed to load spell: %w", err)
+	}
+	return loadedSpell, nil
+}
+
+// agentStepSuccess decides whether an agent step succeeded, and why not if it
+// didn't. The "success" field of the agent's structured output takes
+// precedence, so an agent can report failure even though it exited with
+// code 0. Without one, the step succeeds if the agent exited with code 0.
+func agentStepSuccess(agentOutput *AgentOutput, reported bool, exitCode int) (bool, string) {
+	if reported {
+		if agentOutput.Success {
+			return true, ""
+		}
+		if agentOutput.Error != nil && *agentOutput.Error != "" {
+			return false, "agent reported failure: " + *agentOutput.Error
+		}
+		return false, "agent reported failure"
+	}
+	if exitCode != 0 {
+		return false, fmt.Sprintf("agent exited with code %d", exitCode)
+	}
+	return true, ""
 }
 
 // parseAgentOutput extracts structured JSON output from agent response.
-// Looks for the last JSON code block matching the AgentOutput schema.
-func (e *AgentExecutor) parseAgentOutput(output string) *AgentOutput {
+// Looks for the last JSON code block matching the AgentOutput schema, that
+// is one with a "success" or "summary" field. It also reports whether that
+// block set "success".
+func (e *AgentExecutor) parseAgentOutput(output string) (*AgentOutput, bool) {
 	// Find all JSON code blocks
 	jsonBlocks := extractJSONBlocks(output)
 
 	// Try parsing each block from last to first
 	for i := len(jsonBlocks) - 1; i >= 0; i-- {
-		var agentOutput AgentOutput
-		if err := json.Unmarshal([]byte(jsonBlocks[i]), &agentOutput); err != nil {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(jsonBlocks[i]), &fields); err != nil {
+			continue
+		}
+		_, hasSuccess := fields["success"]
+		_, hasSummary := fields["summary"]
+		if !hasSuccess && !hasSummary {
 			continue
 		}
 
-		// Check if it looks like a valid AgentOutput
-		// (must have at least a summary or success field set)
-		if agentOutput.Summary != "" || agentOutput.Success {
-			return &agentOutput
+		var agentOutput AgentOutput
+		if err := json.Unmarshal([]byte(jsonBlocks[i]), &agentOutput); err != nil {
+			continue
 		}
+		return &agentOutput, hasSuccess
 	}
 
-	return nil
+	return nil, false
 }
 
 // extractJSONBlocks finds JSON code blocks in the output.
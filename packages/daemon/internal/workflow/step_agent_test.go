@@ -206,6 +206,94 @@ func TestAgentExecutor_Execute_InlineSpell(t *testing.T) {
 	}
 }
 
+func TestAgentExecutor_Execute_Sections(t *testing.T) {
+	loader, _ := setupTestSpellLoader(t, map[string]string{
+		"review":   "Review the change for {{.bead.id}}.",
+		"findings": "Prior findings:\n{{.findings}}",
+	})
+	runner := &MockAgentRunner{
+		Output: `{"success": true, "summary": "done"}`,
+	}
+	executor := NewAgentExecutor(loader, runner)
+
+	step := &grimoire.Step{
+		Name:  "review",
+		Type:  grimoire.StepTypeAgent,
+		Spell: "review",
+		Sections: []string{
+			"findings",
+			"Diff:\n{{.diff}}\n",
+			"Task: {{.bead.title}}\n",
+		},
+		Input: map[string]string{
+			"diff": "+added line",
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead-123", "wf")
+	stepCtx.SetBead(&BeadData{ID: "bead-123", Title: "Fix login"})
+	stepCtx.SetVariable("findings", "missing null check")
+
+	if _, err := executor.Execute(context.Background(), step, stepCtx); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	want := "Review the change for bead-123.\n\n" +
+		"Prior findings:\nmissing null check\n\n" +
+		"Diff:\n+added line\n\n" +
+		"Task: Fix login\n"
+	if runner.Prompt != want {
+		t.Errorf("Prompt = %q, want %q", runner.Prompt, want)
+	}
+}
+
+func TestAgentExecutor_Execute_SectionNotFound(t *testing.T) {
+	loader, _ := setupTestSpellLoader(t, map[string]string{
+		"review": "Review the change.",
+	})
+	executor := NewAgentExecutor(loader, &MockAgentRunner{})
+
+	step := &grimoire.Step{
+		Name:     "review",
+		Type:     grimoire.StepTypeAgent,
+		Spell:    "review",
+		Sections: []string{"missing"},
+	}
+
+	_, err := executor.Execute(context.Background(), step, NewStepContext("/worktree", "bead", "wf"))
+	if err == nil || !strings.Contains(err.Error(), `section 0: spell not found: "missing"`) {
+		t.Errorf("Execute() error = %v, want section not found error", err)
+	}
+}
+
+func TestAgentExecutor_Execute_SpellFrontMatter(t *testing.T) {
+	loader, _ := setupTestSpellLoader(t, map[string]string{
+		"fix": "---\nrequired_vars: [test_output, bead.title]\ndefaults:\n  tone: concise\n---\n" +
+			"Fix {{.bead.title}}, {{.tone}}:\n{{.test_output}}\n",
+	})
+	runner := &MockAgentRunner{Output: `{"success": true, "summary": "done"}`}
+	executor := NewAgentExecutor(loader, runner)
+	step := &grimoire.Step{Name: "fix", Type: grimoire.StepTypeAgent, Spell: "fix"}
+
+	// Without a required variable the spell isn't rendered or run
+	stepCtx := NewStepContext("/worktree", "bead-123", "wf")
+	_, err := executor.Execute(context.Background(), step, stepCtx)
+	if err == nil || !strings.Contains(err.Error(), "test_output, bead.title") {
+		t.Fatalf("Execute() error = %v, want the missing variables named", err)
+	}
+	if runner.Prompt != "" {
+		t.Errorf("agent ran with prompt %q, want no run", runner.Prompt)
+	}
+
+	stepCtx.SetBead(&BeadData{ID: "bead-123", Title: "Fix login"})
+	stepCtx.SetVariable("test_output", "FAIL TestLogin")
+	if _, err := executor.Execute(context.Background(), step, stepCtx); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if want := "Fix Fix login, concise:\nFAIL TestLogin\n"; runner.Prompt != want {
+		t.Errorf("Prompt = %q, want %q", runner.Prompt, want)
+	}
+}
+
 func TestAgentExecutor_Execute_Failure(t *testing.T) {
 	loader, _ := setupTestSpellLoader(t, map[string]string{
 		"test": "Run tests",
@@ -238,6 +326,45 @@ func TestAgentExecutor_Execute_Failure(t *testing.T) {
 	}
 }
 
+func TestAgentExecutor_Execute_OnFail_Escalate(t *testing.T) {
+	loader, _ := setupTestSpellLoader(t, map[string]string{
+		"test": "Run tests",
+	})
+
+	output := `{"success": false, "summary": "Tests failed", "error": "3 tests failed"}`
+	runner := &MockAgentRunner{
+		Output:   output,
+		ExitCode: 0,
+	}
+	executor := NewAgentExecutor(loader, runner)
+
+	step := &grimoire.Step{
+		Name:   "test",
+		Type:   grimoire.StepTypeAgent,
+		Spell:  "test",
+		OnFail: "escalate",
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if result.Action != ActionBlock {
+		t.Errorf("Action = %q, want %q", result.Action, ActionBlock)
+	}
+	if result.Escalation == nil {
+		t.Fatal("Escalation should be set")
+	}
+	if result.Escalation.Output != output {
+		t.Errorf("Output = %q, want %q", result.Escalation.Output, output)
+	}
+	if result.Escalation.StepType != grimoire.StepTypeAgent {
+		t.Errorf("StepType = %q, want %q", result.Escalation.StepType, grimoire.StepTypeAgent)
+	}
+}
+
 func TestAgentExecutor_Execute_Timeout(t *testing.T) {
 	loader, _ := setupTestSpellLoader(t, map[string]string{
 		"slow": "Do something slow",
@@ -267,6 +394,33 @@ func TestAgentExecutor_Execute_Timeout(t *testing.T) {
 	if !strings.Contains(result.Error, "timed out") {
 		t.Errorf("Error should mention timeout, got: %q", result.Error)
 	}
+	if result.Action != ActionFail {
+		t.Errorf("Action = %q, want %q", result.Action, ActionFail)
+	}
+}
+
+func TestAgentExecutor_Execute_TimeoutActionBlock(t *testing.T) {
+	loader, _ := setupTestSpellLoader(t, map[string]string{
+		"slow": "Do something slow",
+	})
+	executor := NewAgentExecutor(loader, &MockAgentRunner{Delay: 5 * time.Second})
+
+	step := &grimoire.Step{
+		Name:          "slow",
+		Type:          grimoire.StepTypeAgent,
+		Spell:         "slow",
+		Timeout:       "100ms",
+		TimeoutAction: "block",
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Action != ActionBlock {
+		t.Errorf("Action = %q, want %q", result.Action, ActionBlock)
+	}
 }
 
 func TestAgentExecutor_Execute_MissingSpell(t *testing.T) {
@@ -357,9 +511,10 @@ func TestParseAgentOutput(t *testing.T) {
 	tests := []struct {
 		name     string
 		output   string
+		found    bool
+		reported bool
 		success  bool
 		summary  string
-		hasError bool
 	}{
 		{
 			name: "json code block",
@@ -367,36 +522,57 @@ func TestParseAgentOutput(t *testing.T) {
   "success": true,
   "summary": "Done"
 }` + "\n```\n",
-			success: true,
-			summary: "Done",
+			found:    true,
+			reported: true,
+			success:  true,
+			summary:  "Done",
 		},
 		{
-			name:    "plain json",
-			output:  `Some text {"success": false, "summary": "Failed", "error": "reason"}`,
-			success: false,
-			summary: "Failed",
+			name:     "plain json",
+			output:   `Some text {"success": false, "summary": "Failed", "error": "reason"}`,
+			found:    true,
+			reported: true,
+			success:  false,
+			summary:  "Failed",
 		},
 		{
 			name: "multiple json blocks",
 			output: `{"success": true, "summary": "first"}
 Some text
 ` + "```json\n" + `{"success": false, "summary": "last"}` + "\n```",
-			success: false,
-			summary: "last", // Last block wins
+			found:    true,
+			reported: true,
+			success:  false,
+			summary:  "last", // Last block wins
+		},
+		{
+			name:     "success false without summary",
+			output:   `{"success": false}`,
+			found:    true,
+			reported: true,
+			success:  false,
+		},
+		{
+			name:    "summary without success",
+			output:  `{"summary": "Looked around"}`,
+			found:   true,
+			summary: "Looked around",
+		},
+		{
+			name:   "unrelated json",
+			output: `{"files": 3}`,
 		},
 		{
-			name:    "no json",
-			output:  "Just plain text output",
-			success: false,
-			summary: "",
+			name:   "no json",
+			output: "Just plain text output",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := executor.parseAgentOutput(tt.output)
+			result, reported := executor.parseAgentOutput(tt.output)
 
-			if tt.summary == "" {
+			if !tt.found {
 				if result != nil {
 					t.Error("Expected nil result for no valid JSON")
 				}
@@ -406,6 +582,9 @@ Some text
 			if result == nil {
 				t.Fatal("Expected non-nil result")
 			}
+			if reported != tt.reported {
+				t.Errorf("reported = %v, want %v", reported, tt.reported)
+			}
 			if result.Success != tt.success {
 				t.Errorf("Success = %v, want %v", result.Success, tt.success)
 			}
@@ -416,6 +595,96 @@ Some text
 	}
 }
 
+func TestAgentExecutor_Execute_SuccessDetection(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		exitCode    int
+		wantSuccess bool
+		wantSummary string
+		wantError   string
+	}{
+		{
+			name:        "json success with exit 0",
+			output:      `{"success": true, "summary": "done"}`,
+			wantSuccess: true,
+			wantSummary: "done",
+		},
+		{
+			name:        "json failure with exit 0 is a failure",
+			output:      `{"success": false, "summary": "tests still fail", "error": "3 failing tests"}`,
+			wantSuccess: false,
+			wantSummary: "tests still fail",
+			wantError:   "agent reported failure: 3 failing tests",
+		},
+		{
+			name:        "json success overrides a non-zero exit",
+			output:      `{"success": true, "summary": "done"}`,
+			exitCode:    1,
+			wantSuccess: true,
+			wantSummary: "done",
+		},
+		{
+			name:        "non-json output with exit 0 succeeds",
+			output:      "All done, no JSON here",
+			wantSuccess: true,
+		},
+		{
+			name:        "non-json output with non-zero exit fails",
+			output:      "crashed",
+			exitCode:    2,
+			wantSuccess: false,
+			wantError:   "agent exited with code 2",
+		},
+		{
+			name:        "summary without success falls back to exit code",
+			output:      `{"summary": "gave up"}`,
+			exitCode:    1,
+			wantSuccess: false,
+			wantSummary: "gave up",
+			wantError:   "agent exited with code 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loader, _ := setupTestSpellLoader(t, map[string]string{
+				"implement": "Implement the feature",
+			})
+			runner := &MockAgentRunner{Output: tt.output, ExitCode: tt.exitCode}
+			executor := NewAgentExecutor(loader, runner)
+
+			step := &grimoire.Step{
+				Name:  "implement",
+				Type:  grimoire.StepTypeAgent,
+				Spell: "implement",
+			}
+
+			result, err := executor.Execute(context.Background(), step, NewStepContext("/worktree", "bead-123", "wf-456"))
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+			wantAction := ActionContinue
+			if !tt.wantSuccess {
+				wantAction = ActionFail
+			}
+			if result.Action != wantAction {
+				t.Errorf("Action = %q, want %q", result.Action, wantAction)
+			}
+			if result.Summary != tt.wantSummary {
+				t.Errorf("Summary = %q, want %q", result.Summary, tt.wantSummary)
+			}
+			if result.Error != tt.wantError {
+				t.Errorf("Error = %q, want %q", result.Error, tt.wantError)
+			}
+		})
+	}
+}
+
 func TestExtractJSONBlocks(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,159 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/secrets"
+)
+
+// maxHTTPResponseBytes limits how much of a response body an http step keeps.
+const maxHTTPResponseBytes = 1 << 20
+
+// HTTPExecutor executes http steps.
+type HTTPExecutor struct {
+	client *http.Client
+}
+
+// NewHTTPExecutor creates a new http executor.
+func NewHTTPExecutor() *HTTPExecutor {
+	return &HTTPExecutor{client: http.DefaultClient}
+}
+
+// Execute sends an http step's request and returns the response body as the
+// step output. The response is also stored in the context under the step
+// name, with its status code and the body parsed as JSON when it is an
+// object, so later steps can use {{.step.status_code}} and
+// {{.step.outputs.field}}. A non-2xx status is a failure handled by the
+// step's on_fail.
+func (e *HTTPExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+	if step.Type != grimoire.StepTypeHTTP {
+		return nil, fmt.Errorf("expected http step, got %s", step.Type)
+	}
+
+	timeout, err := step.GetTimeout()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	variables := stepCtx.ToMap()
+	req, err := e.newRequest(execCtx, step, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := e.client.Do(req)
+	if err != nil {
+		// The client's error repeats the url, which may hold secrets
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		result := &StepResult{
+			Success:  false,
+			Error:    fmt.Sprintf("%s %s failed: %v", req.Method, redactedURL(step.URL, variables), err),
+			Duration: time.Since(start),
+			Action:   ActionFail,
+		}
+		if execCtx.Err() == context.DeadlineExceeded {
+			result.Error = fmt.Sprintf("step timed out after %s", timeout)
+			result.TimedOut = true
+		}
+		return result, nil
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseBytes))
+	duration := time.Since(start)
+	if err != nil {
+		return &StepResult{
+			Success:    false,
+			StatusCode: resp.StatusCode,
+			Error:      fmt.Sprintf("failed to read response: %v", err),
+			Duration:   duration,
+			Action:     ActionFail,
+		}, nil
+	}
+
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	result := &StepResult{
+		Success:    success,
+		Output:     strings.TrimSpace(string(body)),
+		StatusCode: resp.StatusCode,
+		Duration:   duration,
+		Action:     handlerAction(success, step),
+	}
+	if !success {
+		result.Error = fmt.Sprintf("%s %s returned status %d", req.Method, redactedURL(step.URL, variables), resp.StatusCode)
+		if step.OnFail == string(grimoire.OnFailEscalate) {
+			result.Escalation = NewEscalation(step, result)
+		}
+	}
+
+	stepCtx.SetVariable(step.Name, newStepOutput(result))
+	return result, nil
+}
+
+// newRequest renders the step's method, url, headers and body into a request.
+func (e *HTTPExecutor) newRequest(ctx context.Context, step *grimoire.Step, variables map[string]interface{}) (*http.Request, error) {
+	target, err := renderText(step.URL, variables)
+	if err != nil {
+		return nil, newRenderError(step, "url", step.URL, err)
+	}
+	body, err := renderText(step.Body, variables)
+	if err != nil {
+		return nil, newRenderError(step, "body", step.Body, err)
+	}
+
+	method := strings.ToUpper(step.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	for name, value := range step.Headers {
+		rendered, err := renderText(value, variables)
+		if err != nil {
+			return nil, newRenderError(step, fmt.Sprintf("header %q", name), value, err)
+		}
+		req.Header.Set(name, rendered)
+	}
+	return req, nil
+}
+
+// redactedURL renders a step's url for errors, with secret values redacted.
+func redactedURL(target string, variables map[string]interface{}) string {
+	rendered, err := substituteTemplate(target, variables, false, func(value interface{}) string {
+		return fmt.Sprint(value)
+	})
+	if err != nil {
+		return target
+	}
+	return rendered
+}
+
+// renderText substitutes variables into text without shell escaping,
+// revealing secret values.
+func renderText(text string, variables map[string]interface{}) (string, error) {
+	return substituteTemplate(text, variables, true, func(value interface{}) string {
+		if secret, ok := value.(*secrets.Secret); ok {
+			return secret.Reveal()
+		}
+		return fmt.Sprint(value)
+	})
+}
@@ -0,0 +1,179 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+func TestHTTPExecutor_CapturesResponseIntoContext(t *testing.T) {
+	var gotMethod, gotAuth, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/deploys/test-bead" {
+			http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotAuth, gotBody = r.Method, r.Header.Get("Authorization"), string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "deploy-42", "state": "queued"}`)
+	}))
+	defer server.Close()
+
+	g, err := grimoire.Parse([]byte(`name: http-step-test
+description: Calls an API and uses the response
+steps:
+  - name: deploy
+    type: http
+    method: post
+    url: ` + server.URL + `/deploys/{{.bead.id}}
+    headers:
+      Authorization: "Bearer {{.workflow.id}}"
+    body: '{"bead": "{{.bead.id}}"}'
+  - name: lookup
+    type: http
+    url: ` + server.URL + `/missing
+    on_fail: continue
+  - name: record
+    type: script
+    command: "echo {{.deploy.status_code}} {{.deploy.outputs.id}} {{.lookup.status_code}} > deploy.txt"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	worktree := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+	result := NewEngine(config).Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	if gotMethod != http.MethodPost || gotAuth != "Bearer test-wf" || gotBody != `{"bead": "test-bead"}` {
+		t.Errorf("request = %s %q %q, want POST with the rendered header and body", gotMethod, gotAuth, gotBody)
+	}
+
+	deploy := result.StepResults["deploy"]
+	if deploy == nil || !deploy.Success || deploy.StatusCode != http.StatusCreated || !strings.Contains(deploy.Output, "deploy-42") {
+		t.Errorf("deploy = %+v, want success with status 201 and the response body", deploy)
+	}
+	lookup := result.StepResults["lookup"]
+	if lookup == nil || lookup.Success || lookup.StatusCode != http.StatusNotFound || !strings.Contains(lookup.Error, "404") {
+		t.Errorf("lookup = %+v, want a failed step with status 404", lookup)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "deploy.txt"))
+	if err != nil || strings.TrimSpace(string(data)) != "201 deploy-42 404" {
+		t.Errorf("deploy.txt = %q, %v, want %q", data, err, "201 deploy-42 404")
+	}
+}
+
+func TestHTTPExecutor_ResponseSurvivesResume(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "deploy-42"}`)
+	}))
+	defer server.Close()
+
+	g, err := grimoire.Parse([]byte(`name: http-resume-test
+description: Uses a response after the workflow resumes
+keep_worktree: true
+steps:
+  - name: deploy
+    type: http
+    method: post
+    url: ` + server.URL + `/deploys
+    output: response
+  - name: gate
+    type: script
+    command: "test -f approved"
+    on_fail: block
+  - name: record
+    type: script
+    command: "echo {{.deploy.status_code}} {{.deploy.outputs.id}} > deploy.txt"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	covenDir := t.TempDir()
+	worktree := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+	if result := NewEngine(config).Execute(context.Background(), g); result.Status != WorkflowBlocked {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowBlocked, result.Error)
+	}
+	state, err := NewStatePersister(covenDir).Load("test-bead")
+	if err != nil || state == nil {
+		t.Fatalf("Load() = %v, %v; want saved state", state, err)
+	}
+	if state.StepOutputs["response"] != `{"id": "deploy-42"}` {
+		t.Errorf("StepOutputs[response] = %q, want the response body", state.StepOutputs["response"])
+	}
+	state.CurrentStep = 0 // retry the gate
+
+	if err := os.WriteFile(filepath.Join(worktree, "approved"), nil, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	result := NewEngine(config).ExecuteFromState(context.Background(), g, state)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("resumed Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "deploy.txt"))
+	if err != nil || strings.TrimSpace(string(data)) != "201 deploy-42" {
+		t.Errorf("deploy.txt = %q, %v, want %q", data, err, "201 deploy-42")
+	}
+}
+
+func TestHTTPExecutor_NonSuccessStatusFollowsOnFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		onFail string
+		want   StepAction
+	}{
+		{"", ActionFail},
+		{"continue", ActionContinue},
+		{"block", ActionBlock},
+		{"escalate", ActionBlock},
+	}
+	for _, tt := range tests {
+		t.Run("on_fail="+tt.onFail, func(t *testing.T) {
+			step := &grimoire.Step{Name: "call", Type: grimoire.StepTypeHTTP, URL: server.URL, OnFail: tt.onFail}
+			stepCtx := NewStepContext(t.TempDir(), "test-bead", "test-wf")
+
+			result, err := NewHTTPExecutor().Execute(context.Background(), step, stepCtx)
+			if err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+			if result.Success || result.Action != tt.want || result.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("result = %+v, want a failure with action %q", result, tt.want)
+			}
+			if (tt.onFail == "escalate") != (result.Escalation != nil) {
+				t.Errorf("Escalation = %+v, want one only for on_fail: escalate", result.Escalation)
+			}
+		})
+	}
+}
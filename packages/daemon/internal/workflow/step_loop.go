@@ -3,6 +3,7 @@ package workflow
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/coven/daemon/internal/grimoire"
@@ -17,17 +18,54 @@ type StepExecutor interface {
 type LoopExecutor struct {
 	scriptExecutor StepExecutor
 	agentExecutor  StepExecutor
+	httpExecutor   StepExecutor
+	steps          *StepRegistry
 	logger         *Logger
 	workflowID     string
 	beadID         string
+	breaks         *LoopBreaks
 }
 
-// NewLoopExecutor creates a new loop executor.
+// NewLoopExecutor creates a new loop executor. Until SetStepRegistry is
+// called, it runs nested script, agent, http, loop and parallel steps itself.
 func NewLoopExecutor(scriptExecutor, agentExecutor StepExecutor) *LoopExecutor {
-	return &LoopExecutor{
+	e := &LoopExecutor{
 		scriptExecutor: scriptExecutor,
 		agentExecutor:  agentExecutor,
+		httpExecutor:   NewHTTPExecutor(),
+		steps:          NewStepRegistry(),
 	}
+	e.registerBuiltinSteps()
+	return e
+}
+
+// registerBuiltinSteps adds the step types a loop can run on its own to its
+// registry.
+func (e *LoopExecutor) registerBuiltinSteps() {
+	e.steps.set(grimoire.StepTypeScript, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		if e.scriptExecutor == nil {
+			return nil, fmt.Errorf("no script executor configured")
+		}
+		return executeWithMatrix(ctx, step, stepCtx, e.scriptExecutor)
+	}))
+	e.steps.set(grimoire.StepTypeAgent, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		if e.agentExecutor == nil {
+			return nil, fmt.Errorf("no agent executor configured")
+		}
+		return executeWithMatrix(ctx, step, stepCtx, e.agentExecutor)
+	}))
+	// Nested loops are supported
+	e.steps.set(grimoire.StepTypeLoop, stepFunc(e.Execute))
+	e.steps.set(grimoire.StepTypeHTTP, e.httpExecutor)
+	e.steps.set(grimoire.StepTypeParallel, stepFunc(func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		return executeParallel(ctx, step, stepCtx, e.executeStep)
+	}))
+}
+
+// SetStepRegistry sets the registry nested steps are dispatched through, such
+// as the registry of the engine running the loop.
+func (e *LoopExecutor) SetStepRegistry(steps *StepRegistry) {
+	e.steps = steps
 }
 
 // SetLogger sets the logger for loop iteration events.
@@ -37,6 +75,12 @@ func (e *LoopExecutor) SetLogger(logger *Logger, workflowID, beadID string) {
 	e.beadID = beadID
 }
 
+// SetLoopBreaks sets where requests to break out of a running loop are read
+// from. Loops can't be broken out of if it is nil.
+func (e *LoopExecutor) SetLoopBreaks(breaks *LoopBreaks) {
+	e.breaks = breaks
+}
+
 // Execute runs a loop step and returns the result.
 func (e *LoopExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
 	if step.Type != grimoire.StepTypeLoop {
@@ -65,9 +109,29 @@ func (e *LoopExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx
 		usedDefaultLimit = true
 	}
 
+	// A for_each loop runs once per element of the resolved list
+	var items []interface{}
+	forEach := step.ForEach != ""
+	if forEach {
+		items, err = resolveForEachItems(step.ForEach, stepCtx)
+		if err != nil {
+			return nil, fmt.Errorf("loop step %q: %w", step.Name, err)
+		}
+		maxIterations = len(items)
+		usedDefaultLimit = false
+		if step.MaxIterations > 0 && step.MaxIterations < len(items) {
+			maxIterations = step.MaxIterations
+		}
+	}
+	defer stepCtx.preserveLoopBindings(step.Name)()
+
+	e.breaks.start(step.Name)
+	defer e.breaks.finish(step.Name)
+
 	start := time.Now()
 	var lastResult *StepResult
 	var iteration int
+	var iterations []LoopIteration
 
 	// Execute loop iterations
 	for iteration = 0; iteration < maxIterations; iteration++ {
@@ -76,22 +140,30 @@ func (e *LoopExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx
 			duration := time.Since(start)
 			if execCtx.Err() == context.DeadlineExceeded {
 				return &StepResult{
-					Success:  false,
-					Output:   fmt.Sprintf("Loop timed out after %d iterations", iteration),
-					ExitCode: -1,
-					Error:    fmt.Sprintf("loop timed out after %s", timeout),
-					Duration: duration,
-					Action:   ActionFail,
+					Success:    false,
+					Output:     fmt.Sprintf("Loop timed out after %d iterations", iteration),
+					ExitCode:   -1,
+					Error:      fmt.Sprintf("loop timed out after %s", timeout),
+					Duration:   duration,
+					Action:     ActionFail,
+					Iterations: iterations,
+					TimedOut:   true,
 				}, nil
 			}
 			return nil, execCtx.Err()
 		}
 
-		// Execute nested steps
-		result, exitLoop, err := e.executeIteration(execCtx, step, stepCtx, iteration)
+		// Execute nested steps, recording how each one ended
+		record := LoopIteration{Iteration: iteration, Success: true}
+		iterStart := time.Now()
+		result, exitLoop, err := e.executeIteration(execCtx, step, stepCtx, iteration, items, &record)
 		if err != nil {
 			return nil, err
 		}
+		if len(record.Steps) > 0 {
+			record.Duration = time.Since(iterStart)
+			iterations = append(iterations, record)
+		}
 
 		lastResult = result
 
@@ -99,13 +171,29 @@ func (e *LoopExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx
 		if exitLoop {
 			break
 		}
+
+		// A break requested while the iteration ran exits as exit_loop would
+		if e.breaks.requestedFor(step.Name) {
+			e.logStepWarning(step.Name, fmt.Sprintf("loop stopped by request after %d iterations", iteration+1))
+			return &StepResult{
+				Success:    true,
+				Output:     fmt.Sprintf("Loop stopped by request after %d iterations", iteration+1),
+				Duration:   time.Since(start),
+				Action:     ActionContinue,
+				Iterations: iterations,
+			}, nil
+		}
 	}
 
 	duration := time.Since(start)
 
-	// Check if we hit max iterations
-	if iteration >= maxIterations {
-		return e.handleMaxIterations(step, lastResult, duration, iteration, usedDefaultLimit)
+	// Check if we hit max iterations (running out of for_each items is not a limit)
+	if iteration >= maxIterations && !(forEach && iteration >= len(items)) {
+		result, err := e.handleMaxIterations(step, lastResult, duration, iteration, usedDefaultLimit)
+		if result != nil {
+			result.Iterations = iterations
+		}
+		return result, err
 	}
 
 	// Loop completed normally (via exit_loop, block, or success)
@@ -125,29 +213,38 @@ func (e *LoopExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx
 	}
 
 	return &StepResult{
-		Success:  lastResult.Success,
-		Output:   lastResult.Output,
-		ExitCode: lastResult.ExitCode,
-		Error:    lastResult.Error,
-		Duration: duration,
-		Action:   action,
+		Success:    lastResult.Success,
+		Output:     lastResult.Output,
+		ExitCode:   lastResult.ExitCode,
+		Error:      lastResult.Error,
+		Duration:   duration,
+		Action:     action,
+		Escalation: lastResult.Escalation,
+		Iterations: iterations,
+		TimedOut:   lastResult.TimedOut,
 	}, nil
 }
 
 // executeIteration executes all nested steps for one loop iteration.
+// For for_each loops, items holds the list being iterated and the current
+// element is bound in the context. Each nested step's result is added to record.
 // Returns the last step result, whether to exit the loop, and any error.
-func (e *LoopExecutor) executeIteration(ctx context.Context, loopStep *grimoire.Step, stepCtx *StepContext, iteration int) (*StepResult, bool, error) {
+func (e *LoopExecutor) executeIteration(ctx context.Context, loopStep *grimoire.Step, stepCtx *StepContext, iteration int, items []interface{}, record *LoopIteration) (*StepResult, bool, error) {
 	// Set loop context
 	stepCtx.InLoop = true
 	stepCtx.LoopIteration = iteration
 
-	// Set loop variable for template access
-	stepCtx.SetVariable(loopStep.Name, map[string]interface{}{
-		"iteration": iteration,
-	})
+	if loopStep.ForEach != "" {
+		// Bind the element and index for template access
+		stepCtx.SetLoopItem(loopStep.Name, iteration, items[iteration])
+		e.logLoopIteration(loopStep.Name, iteration, len(items), "for_each", false)
+	} else {
+		// Set loop variable for template access
+		stepCtx.SetLoopVariable(loopStep.Name, iteration)
 
-	// Log loop iteration (loop type is "step" for step-based loops)
-	e.logLoopIteration(loopStep.Name, iteration, loopStep.MaxIterations, "step", false)
+		// Log loop iteration (loop type is "step" for step-based loops)
+		e.logLoopIteration(loopStep.Name, iteration, loopStep.MaxIterations, "step", false)
+	}
 
 	var lastResult *StepResult
 
@@ -160,18 +257,29 @@ func (e *LoopExecutor) executeIteration(ctx context.Context, loopStep *grimoire.
 		}
 
 		// Execute the nested step
-		result, err := e.executeStep(ctx, nestedStep, stepCtx)
+		result, err := executeTrackingFiles(ctx, nestedStep, stepCtx, e.executeStep)
+		// Check if it's a context error (timeout)
+		if err != nil && ctx.Err() != nil {
+			return nil, false, nil // Let the main loop handle timeout
+		}
+		e.logRenderError(nestedStep, err)
+		result, err = allowStepFailure(nestedStep, result, err)
 		if err != nil {
-			// Check if it's a context error (timeout)
-			if ctx.Err() != nil {
-				return nil, false, nil // Let the main loop handle timeout
-			}
 			return nil, false, fmt.Errorf("failed to execute step %q: %w", nestedStep.Name, err)
 		}
 
-		// Set previous result for next step
+		// Guard against executors that leave the action unset or unknown
+		if original, changed := result.NormalizeAction(); changed {
+			e.logStepWarning(nestedStep.Name, fmt.Sprintf("step returned invalid action %q, defaulting to %q", original, result.Action))
+		}
+
+		// Set previous result for next step. An allowed failure doesn't
+		// count as the iteration's outcome
 		stepCtx.SetPrevious(result)
-		lastResult = result
+		if !result.AllowedFailure {
+			lastResult = result
+		}
+		record.record(nestedStep.Name, result)
 
 		// Check for exit_loop action
 		if result.Action == ActionExitLoop {
@@ -195,6 +303,7 @@ func (e *LoopExecutor) executeIteration(ctx context.Context, loopStep *grimoire.
 					Error:    result.Error,
 					Duration: result.Duration,
 					Action:   ActionBlock,
+					TimedOut: result.TimedOut,
 				}, true, nil
 			}
 			if nestedStep.OnFail == "" || nestedStep.OnFail == "continue" {
@@ -208,28 +317,51 @@ func (e *LoopExecutor) executeIteration(ctx context.Context, loopStep *grimoire.
 	return lastResult, false, nil
 }
 
-// executeStep dispatches to the appropriate executor based on step type.
+// record adds a nested step's result to the iteration.
+func (it *LoopIteration) record(name string, result *StepResult) {
+	it.Steps = append(it.Steps, LoopIterationStep{
+		Name:           name,
+		Success:        result.Success,
+		Skipped:        result.Skipped,
+		AllowedFailure: result.AllowedFailure,
+		Error:          result.Error,
+		Iterations:     result.Iterations,
+	})
+	if !result.Success && !result.Skipped && !result.AllowedFailure && it.Error == "" {
+		it.Success = false
+		it.Error = result.Error
+	}
+}
+
+// executeStep dispatches a nested step through the loop's registry. Merges
+// can't run inside a loop.
 func (e *LoopExecutor) executeStep(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
-	switch step.Type {
-	case grimoire.StepTypeScript:
-		if e.scriptExecutor == nil {
-			return nil, fmt.Errorf("no script executor configured")
-		}
-		return e.scriptExecutor.Execute(ctx, step, stepCtx)
+	if step.Type == grimoire.StepTypeMerge {
+		return nil, fmt.Errorf("unsupported step type in loop: %s", step.Type)
+	}
+	return e.steps.Execute(ctx, step, stepCtx)
+}
 
-	case grimoire.StepTypeAgent:
-		if e.agentExecutor == nil {
-			return nil, fmt.Errorf("no agent executor configured")
-		}
-		return e.agentExecutor.Execute(ctx, step, stepCtx)
+// resolveForEachItems resolves a for_each expression to the list it names.
+// The expression is a context path such as "analyze.outputs.issues", and may
+// be written in template form ("{{.analyze.outputs.issues}}").
+func resolveForEachItems(expr string, stepCtx *StepContext) ([]interface{}, error) {
+	path := strings.TrimSpace(expr)
+	if strings.HasPrefix(path, "{{") && strings.HasSuffix(path, "}}") {
+		path = strings.TrimSpace(path[2 : len(path)-2])
+	}
+	path = strings.TrimPrefix(path, ".")
 
-	case grimoire.StepTypeLoop:
-		// Nested loops are supported
-		return e.Execute(ctx, step, stepCtx)
+	value, err := stepCtx.GetPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("for_each: %w", err)
+	}
 
-	default:
-		return nil, fmt.Errorf("unsupported step type in loop: %s", step.Type)
+	items, ok := normalizeContextValue(value).([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("for_each: %q is %T, not a list", path, value)
 	}
+	return items, nil
 }
 
 // logLoopIteration logs a loop iteration event.
@@ -239,6 +371,20 @@ func (e *LoopExecutor) logLoopIteration(stepName string, iteration, maxIter int,
 	}
 }
 
+func (e *LoopExecutor) logStepWarning(stepName, message string) {
+	if e.logger != nil {
+		e.logger.LogStepWarning(e.workflowID, e.beadID, stepName, message)
+	}
+}
+
+// logRenderError logs err as a render.error event if one of step's templates
+// failed to render.
+func (e *LoopExecutor) logRenderError(step *grimoire.Step, err error) {
+	if e.logger != nil {
+		logRenderError(e.logger, e.workflowID, e.beadID, step, err)
+	}
+}
+
 // handleMaxIterations handles the case when max iterations is reached.
 func (e *LoopExecutor) handleMaxIterations(step *grimoire.Step, lastResult *StepResult, duration time.Duration, iterations int, usedDefaultLimit bool) (*StepResult, error) {
 	var output string
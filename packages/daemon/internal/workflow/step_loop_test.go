@@ -2,11 +2,15 @@ package workflow
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/spell"
 )
 
 // CapturedContext stores a snapshot of StepContext values at execution time.
@@ -423,6 +427,38 @@ func TestLoopExecutor_Execute_NestedStepFailure_Block(t *testing.T) {
 	}
 }
 
+func TestLoopExecutor_Execute_NestedStepEmptyAction(t *testing.T) {
+	// An executor that forgets to set Action on failure should be treated as ActionFail
+	scriptExec := &MockStepExecutor{
+		Results: []*StepResult{
+			{Success: false, Error: "tests failed"},
+		},
+	}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:          "loop",
+		Type:          grimoire.StepTypeLoop,
+		MaxIterations: 3,
+		Steps: []grimoire.Step{
+			{Name: "test", Type: grimoire.StepTypeScript, Command: "npm test", OnFail: "block"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if result.Action != ActionBlock {
+		t.Errorf("Action = %q, want %q", result.Action, ActionBlock)
+	}
+	if scriptExec.CallCount != 1 {
+		t.Errorf("CallCount = %d, want 1", scriptExec.CallCount)
+	}
+}
+
 func TestLoopExecutor_Execute_Timeout(t *testing.T) {
 	scriptExec := &MockStepExecutor{
 		Results: []*StepResult{},
@@ -538,6 +574,101 @@ func TestLoopExecutor_Execute_UnsupportedStepType(t *testing.T) {
 	}
 }
 
+func TestLoopExecutor_Execute_RecordsIterations(t *testing.T) {
+	scriptExec := &MockStepExecutor{
+		Results: []*StepResult{
+			{Success: false, Error: "2 tests failed", Action: ActionContinue}, // Iteration 0
+			{Success: false, Error: "1 test failed", Action: ActionContinue},  // Iteration 1
+			{Success: true, Action: ActionExitLoop},                           // Iteration 2 - exit
+		},
+	}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:          "retry-loop",
+		Type:          grimoire.StepTypeLoop,
+		MaxIterations: 5,
+		Steps: []grimoire.Step{
+			{Name: "test", Type: grimoire.StepTypeScript, Command: "npm test", OnFail: "continue"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if len(result.Iterations) != 3 {
+		t.Fatalf("Iterations = %d, want 3", len(result.Iterations))
+	}
+	wantSuccess := []bool{false, false, true}
+	wantError := []string{"2 tests failed", "1 test failed", ""}
+	for i, it := range result.Iterations {
+		if it.Iteration != i {
+			t.Errorf("Iterations[%d].Iteration = %d, want %d", i, it.Iteration, i)
+		}
+		if it.Success != wantSuccess[i] {
+			t.Errorf("Iterations[%d].Success = %v, want %v", i, it.Success, wantSuccess[i])
+		}
+		if it.Error != wantError[i] {
+			t.Errorf("Iterations[%d].Error = %q, want %q", i, it.Error, wantError[i])
+		}
+		if len(it.Steps) != 1 || it.Steps[0].Name != "test" {
+			t.Errorf("Iterations[%d].Steps = %+v, want one test step", i, it.Steps)
+		}
+	}
+}
+
+func TestLoopExecutor_Execute_RecordsNestedIterations(t *testing.T) {
+	scriptExec := &MockStepExecutor{
+		Results: []*StepResult{
+			// Outer iteration 0: inner loop runs twice
+			{Success: false, Action: ActionContinue},
+			{Success: true, Action: ActionExitLoop},
+			// Outer iteration 1: inner loop runs once
+			{Success: true, Action: ActionExitLoop},
+		},
+	}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:            "outer-loop",
+		Type:            grimoire.StepTypeLoop,
+		MaxIterations:   2,
+		OnMaxIterations: "continue",
+		Steps: []grimoire.Step{
+			{
+				Name:          "inner-loop",
+				Type:          grimoire.StepTypeLoop,
+				MaxIterations: 3,
+				Steps: []grimoire.Step{
+					{Name: "test", Type: grimoire.StepTypeScript, Command: "npm test", OnFail: "continue"},
+				},
+			},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if len(result.Iterations) != 2 {
+		t.Fatalf("Iterations = %d, want 2", len(result.Iterations))
+	}
+	for i, wantInner := range []int{2, 1} {
+		steps := result.Iterations[i].Steps
+		if len(steps) != 1 || steps[0].Name != "inner-loop" {
+			t.Fatalf("Iterations[%d].Steps = %+v, want inner-loop", i, steps)
+		}
+		if got := len(steps[0].Iterations); got != wantInner {
+			t.Errorf("Iterations[%d] inner iterations = %d, want %d", i, got, wantInner)
+		}
+	}
+}
+
 func TestLoopExecutor_Execute_NestedLoop(t *testing.T) {
 	// Provide enough results for all possible iterations
 	scriptExec := &MockStepExecutor{
@@ -683,3 +814,324 @@ func TestLoopExecutor_Execute_InvalidTimeout(t *testing.T) {
 		t.Errorf("Error should mention invalid timeout, got: %q", err.Error())
 	}
 }
+
+// storeForEachIssues stores a step output whose parsed JSON holds a list of objects.
+func storeForEachIssues(t *testing.T, stepCtx *StepContext) {
+	t.Helper()
+	err := stepCtx.StoreStepOutput("analyze", &StepResult{
+		Success: true,
+		Output:  `{"issues": [{"name": "alpha", "file": "a.go"}, {"name": "beta", "file": "b.go"}]}`,
+	}, "")
+	if err != nil {
+		t.Fatalf("StoreStepOutput() error: %v", err)
+	}
+}
+
+func TestLoopExecutor_Execute_ForEach(t *testing.T) {
+	worktree := t.TempDir()
+	runner := &MockAgentRunner{Output: `{"success": true, "summary": "fixed"}`}
+	agentExec := NewAgentExecutor(spell.NewLoader(t.TempDir()), runner)
+	executor := NewLoopExecutor(NewScriptExecutor(), agentExec)
+
+	step := &grimoire.Step{
+		Name:    "each-issue",
+		Type:    grimoire.StepTypeLoop,
+		ForEach: "{{.analyze.outputs.issues}}",
+		Steps: []grimoire.Step{
+			{Name: "record", Type: grimoire.StepTypeScript, Command: "echo {{.index}}:{{.item.name}}:{{.each-issue.item.file}} >> items.txt"},
+			{Name: "fix", Type: grimoire.StepTypeAgent, Spell: "Fix {{.item.name}} in {{.item.file}} ({{.index}})\n"},
+		},
+	}
+	stepCtx := NewStepContext(worktree, "bead", "wf")
+	storeForEachIssues(t, stepCtx)
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if !result.Success || result.Action != ActionContinue {
+		t.Errorf("result = %+v, want success after iterating all items", result)
+	}
+
+	data, err := os.ReadFile(filepath.Join(worktree, "items.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read script output: %v", err)
+	}
+	if got, want := string(data), "0:alpha:a.go\n1:beta:b.go\n"; got != want {
+		t.Errorf("rendered commands = %q, want %q", got, want)
+	}
+	if !strings.Contains(runner.Prompt, "Fix beta in b.go (1)") {
+		t.Errorf("last prompt = %q, want item fields rendered in spell", runner.Prompt)
+	}
+
+	// Bindings don't leak out of the loop
+	if stepCtx.HasPath("item") || stepCtx.HasPath("index") {
+		t.Error("item and index should be unbound after the loop")
+	}
+}
+
+func TestLoopExecutor_Execute_ForEachMaxIterations(t *testing.T) {
+	scriptExec := &MockStepExecutor{}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:          "each-issue",
+		Type:          grimoire.StepTypeLoop,
+		ForEach:       "analyze.outputs.issues",
+		MaxIterations: 1,
+		Steps: []grimoire.Step{
+			{Name: "record", Type: grimoire.StepTypeScript, Command: "true"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+	storeForEachIssues(t, stepCtx)
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if scriptExec.CallCount != 1 {
+		t.Errorf("CallCount = %d, want 1", scriptExec.CallCount)
+	}
+	if !result.Success {
+		t.Errorf("Expected success when explicit max_iterations is reached, got %q", result.Error)
+	}
+}
+
+func TestLoopExecutor_Execute_ForEachNested(t *testing.T) {
+	scriptExec := &MockStepExecutor{}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:    "outer",
+		Type:    grimoire.StepTypeLoop,
+		ForEach: "analyze.outputs.issues",
+		Steps: []grimoire.Step{
+			{
+				Name:    "inner",
+				Type:    grimoire.StepTypeLoop,
+				ForEach: "tags",
+				Steps: []grimoire.Step{
+					{Name: "tag", Type: grimoire.StepTypeScript, Command: "true"},
+				},
+			},
+			{Name: "after", Type: grimoire.StepTypeScript, Command: "true"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+	stepCtx.SetVariable("tags", []string{"x"})
+	storeForEachIssues(t, stepCtx)
+
+	if _, err := executor.Execute(context.Background(), step, stepCtx); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	// tag, after, tag, after
+	if scriptExec.CallCount != 4 {
+		t.Fatalf("CallCount = %d, want 4", scriptExec.CallCount)
+	}
+	after := scriptExec.CapturedContexts[3].Variables["item"].(map[string]interface{})
+	if after["name"] != "beta" {
+		t.Errorf("item after inner loop = %v, want the outer element restored", after)
+	}
+}
+
+func TestLoopExecutor_Execute_ForEachLoopVariable(t *testing.T) {
+	scriptExec := &MockStepExecutor{}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:    "each-issue",
+		Type:    grimoire.StepTypeLoop,
+		ForEach: "analyze.outputs.issues",
+		Steps: []grimoire.Step{
+			{Name: "file", Type: grimoire.StepTypeScript, Command: "true", Output: "finding_{{.loop.index}}"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+	storeForEachIssues(t, stepCtx)
+
+	if _, err := executor.Execute(context.Background(), step, stepCtx); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	// The innermost loop is bound as loop, whatever its name
+	for i, want := range []string{"alpha", "beta"} {
+		loop := scriptExec.CapturedContexts[i].Variables["loop"].(map[string]interface{})
+		if loop["index"] != i || loop["item"].(map[string]interface{})["name"] != want {
+			t.Errorf("iteration %d: loop = %v, want index %d and item %s", i, loop, i, want)
+		}
+	}
+
+	// Each element's output is stored under its own key
+	for i, want := range []string{"finding_0", "finding_1"} {
+		if got := scriptExec.Steps[i].Output; got != want {
+			t.Errorf("iteration %d: step output = %q, want %q", i, got, want)
+		}
+	}
+	if stepCtx.HasPath("loop") {
+		t.Error("loop should be unbound after the loop")
+	}
+}
+
+func TestLoopExecutor_Execute_ForEachNotAList(t *testing.T) {
+	executor := NewLoopExecutor(&MockStepExecutor{}, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:    "each",
+		Type:    grimoire.StepTypeLoop,
+		ForEach: "bead.title",
+		Steps: []grimoire.Step{
+			{Name: "record", Type: grimoire.StepTypeScript, Command: "true"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+	stepCtx.SetBead(&BeadData{ID: "bead", Title: "Title"})
+
+	_, err := executor.Execute(context.Background(), step, stepCtx)
+	if err == nil || !strings.Contains(err.Error(), "not a list") {
+		t.Errorf("Execute() error = %v, want not a list error", err)
+	}
+
+	step.ForEach = "missing.path"
+	if _, err := executor.Execute(context.Background(), step, stepCtx); err == nil {
+		t.Error("Execute() should fail when the for_each path does not exist")
+	}
+}
+
+func TestLoopExecutor_Execute_TemplatedOutputName(t *testing.T) {
+	scriptExec := &MockStepExecutor{
+		Results: []*StepResult{
+			{Success: true, Output: "first pass", Action: ActionContinue},
+			{Success: true, Output: "second pass", Action: ActionContinue},
+		},
+	}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+
+	step := &grimoire.Step{
+		Name:            "fix",
+		Type:            grimoire.StepTypeLoop,
+		MaxIterations:   2,
+		OnMaxIterations: "exit",
+		Steps: []grimoire.Step{
+			{Name: "check", Type: grimoire.StepTypeScript, Command: "make check", Output: "check_{{.fix.iteration}}"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	if _, err := executor.Execute(context.Background(), step, stepCtx); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	// Each iteration stores its output under its own key
+	if got := stepCtx.Variables["check_0"]; got != "first pass" {
+		t.Errorf("check_0 = %v, want %q", got, "first pass")
+	}
+	if got := stepCtx.Variables["check_1"]; got != "second pass" {
+		t.Errorf("check_1 = %v, want %q", got, "second pass")
+	}
+
+	// The executor sees the rendered name
+	for i, want := range []string{"check_0", "check_1"} {
+		if got := scriptExec.Steps[i].Output; got != want {
+			t.Errorf("iteration %d: step output = %q, want %q", i, got, want)
+		}
+	}
+	if step.Steps[0].Output != "check_{{.fix.iteration}}" {
+		t.Errorf("grimoire step output was modified: %q", step.Steps[0].Output)
+	}
+}
+
+func TestLoopExecutor_Execute_TemplatedOutputNameCollision(t *testing.T) {
+	executor := NewLoopExecutor(&MockStepExecutor{}, &MockStepExecutor{})
+
+	// The name doesn't depend on the iteration, so the second pass collides
+	step := &grimoire.Step{
+		Name:            "fix",
+		Type:            grimoire.StepTypeLoop,
+		MaxIterations:   2,
+		OnMaxIterations: "exit",
+		Steps: []grimoire.Step{
+			{Name: "check", Type: grimoire.StepTypeScript, Command: "make check", Output: "check_{{.bead_id}}"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+	stepCtx.SetVariable("bead_id", "bead")
+
+	_, err := executor.Execute(context.Background(), step, stepCtx)
+	if err == nil {
+		t.Fatal("Execute() should fail when iterations render the same output name")
+	}
+	if !strings.Contains(err.Error(), "is already used") {
+		t.Errorf("error = %v, want a duplicate output name error", err)
+	}
+}
+
+// breakingExecutor requests a break of loop on its call numbered at
+// (counting from 0) and otherwise succeeds.
+type breakingExecutor struct {
+	breaks *LoopBreaks
+	loop   string
+	at     int
+	calls  int
+}
+
+func (b *breakingExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+	if b.calls == b.at && !b.breaks.Request(b.loop) {
+		return nil, fmt.Errorf("loop %q not running", b.loop)
+	}
+	b.calls++
+	return &StepResult{Success: true, Action: ActionContinue}, nil
+}
+
+func TestLoopExecutor_Execute_BreakRequested(t *testing.T) {
+	breaks := NewLoopBreaks()
+	// The first nested step of the second iteration requests the break
+	scriptExec := &breakingExecutor{breaks: breaks, loop: "review-loop", at: 2}
+	executor := NewLoopExecutor(scriptExec, &MockStepExecutor{})
+	executor.SetLoopBreaks(breaks)
+
+	step := &grimoire.Step{
+		Name:          "review-loop",
+		Type:          grimoire.StepTypeLoop,
+		MaxIterations: 5,
+		Steps: []grimoire.Step{
+			{Name: "review", Type: grimoire.StepTypeScript, Command: "review"},
+			{Name: "fix", Type: grimoire.StepTypeScript, Command: "fix"},
+		},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	// The iteration the break was requested in still finishes
+	if scriptExec.calls != 4 {
+		t.Errorf("nested steps run = %d, want 4", scriptExec.calls)
+	}
+	if len(result.Iterations) != 2 {
+		t.Errorf("Iterations = %d, want 2", len(result.Iterations))
+	}
+	if !result.Success || result.Action != ActionContinue {
+		t.Errorf("result = success %v action %q, want a successful continue", result.Success, result.Action)
+	}
+	if !strings.Contains(result.Output, "stopped by request") {
+		t.Errorf("Output = %q, should say the loop was stopped by request", result.Output)
+	}
+
+	// The loop has finished, so it can't be broken out of and the next run
+	// goes the full length
+	if breaks.Request("review-loop") {
+		t.Error("Request() for a finished loop = true, want false")
+	}
+	scriptExec.calls, scriptExec.at = 0, -1
+	result, err = executor.Execute(context.Background(), step, NewStepContext("/worktree", "bead", "wf"))
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if len(result.Iterations) != 5 {
+		t.Errorf("second run Iterations = %d, want 5", len(result.Iterations))
+	}
+}
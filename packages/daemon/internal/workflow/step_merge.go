@@ -3,11 +3,17 @@ package workflow
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/coven/daemon/internal/git"
 	"github.com/coven/daemon/internal/grimoire"
 )
 
@@ -33,6 +39,93 @@ type MergeReview struct {
 
 	// ConflictFiles lists files with conflicts.
 	ConflictFiles []string `json:"conflict_files,omitempty"`
+
+	// BinaryFiles lists changed files git reports as binary, including
+	// untracked files and files changed in the branch's commits.
+	// These are not included in the line counts.
+	BinaryFiles []string `json:"binary_files,omitempty"`
+
+	// LargeFiles lists changed files larger than LargeFileThreshold, from the
+	// same changes as BinaryFiles.
+	LargeFiles []string `json:"large_files,omitempty"`
+
+	// Checks are the results of the step's pre-merge checks, in the order
+	// they are configured.
+	Checks []CheckResult `json:"checks,omitempty"`
+
+	// Commits are the commits on the worktree branch that are not on the
+	// base branch, oldest first.
+	Commits []CommitInfo `json:"commits,omitempty"`
+}
+
+// HasChanges reports whether there is anything to merge: uncommitted file
+// changes or commits on the worktree branch.
+func (r *MergeReview) HasChanges() bool {
+	return len(r.FilesChanged) > 0 || r.Diff != "" || len(r.Commits) > 0
+}
+
+// LargeFileThreshold is the size in bytes above which a changed file is
+// listed in MergeReview.LargeFiles.
+const LargeFileThreshold = 1 << 20
+
+// binarySniffLen is how much of an untracked file is checked for a NUL byte
+// to decide whether it is binary, the same amount git checks.
+const binarySniffLen = 8000
+
+// DiffStats are the line counts of a worktree's uncommitted changes and the
+// files that need a closer look before merging.
+type DiffStats struct {
+	// Additions is the number of lines added, not counting binary files.
+	Additions int
+
+	// Deletions is the number of lines deleted, not counting binary files.
+	Deletions int
+
+	// BinaryFiles are the binary files changed in the worktree, untracked or
+	// committed on its branch, sorted.
+	BinaryFiles []string
+
+	// LargeFiles are the files from the same changes larger than
+	// LargeFileThreshold, sorted.
+	LargeFiles []string
+}
+
+// CommitInfo describes a commit on the branch being merged.
+type CommitInfo struct {
+	// SHA is the full commit hash.
+	SHA string `json:"sha"`
+
+	// Subject is the first line of the commit message.
+	Subject string `json:"subject"`
+
+	// Author is the commit author's name.
+	Author string `json:"author"`
+}
+
+// ShortSHA returns the first 7 characters of the commit hash.
+func (c CommitInfo) ShortSHA() string {
+	if len(c.SHA) > 7 {
+		return c.SHA[:7]
+	}
+	return c.SHA
+}
+
+// CheckResult is the outcome of a single pre-merge check.
+type CheckResult struct {
+	// Command is the check's shell command.
+	Command string `json:"command"`
+
+	// Success indicates the command exited with status 0.
+	Success bool `json:"success"`
+
+	// ExitCode is the command's exit status, or -1 if it could not be run.
+	ExitCode int `json:"exit_code"`
+
+	// Output is the combined stdout and stderr of the command.
+	Output string `json:"output,omitempty"`
+
+	// Error describes why the command could not be run.
+	Error string `json:"error,omitempty"`
 }
 
 // MergeResult contains the result of a merge-to-main operation.
@@ -50,6 +143,80 @@ type MergeResult struct {
 	MergeCommit string `json:"merge_commit,omitempty"`
 }
 
+// MergeOptions controls how a worktree branch is merged into the base branch.
+type MergeOptions struct {
+	// FFOnly requires the merge to fast-forward the base branch, for
+	// protected branches that disallow merge commits.
+	FFOnly bool
+}
+
+// MergeOptionsFor returns the merge options a merge step sets.
+func MergeOptionsFor(step *grimoire.Step) MergeOptions {
+	return MergeOptions{FFOnly: step.FFOnly}
+}
+
+// NotFastForwardError is returned when an ff_only merge can't fast-forward
+// the base branch because it has commits the worktree branch doesn't.
+type NotFastForwardError struct {
+	Branch     string
+	BaseBranch string
+}
+
+func (e *NotFastForwardError) Error() string {
+	return fmt.Sprintf("cannot fast-forward %s to %s: %s has commits that %s doesn't; rebase %s onto %s and merge again",
+		e.BaseBranch, e.Branch, e.BaseBranch, e.Branch, e.Branch, e.BaseBranch)
+}
+
+// IsNotFastForward returns true if the error is a NotFastForwardError.
+func IsNotFastForward(err error) bool {
+	var nff *NotFastForwardError
+	return errors.As(err, &nff)
+}
+
+// Git trailer keys used to link commits back to the daemon's records.
+const (
+	TrailerTaskID       = "Coven-Task-ID"
+	TrailerWorkflowID   = "Coven-Workflow-ID"
+	TrailerGrimoire     = "Coven-Grimoire"
+	TrailerGrimoireHash = "Coven-Grimoire-Hash"
+)
+
+// CommitMetadata identifies the task and workflow a commit is made for,
+// and the grimoire version that made it. It is appended to commit messages
+// as git trailers.
+type CommitMetadata struct {
+	TaskID       string
+	WorkflowID   string
+	Grimoire     string
+	GrimoireHash string
+}
+
+// Trailers returns the metadata as git trailer lines. Empty fields are omitted.
+func (m CommitMetadata) Trailers() string {
+	var lines []string
+	for _, t := range []struct{ key, value string }{
+		{TrailerTaskID, m.TaskID},
+		{TrailerWorkflowID, m.WorkflowID},
+		{TrailerGrimoire, m.Grimoire},
+		{TrailerGrimoireHash, m.GrimoireHash},
+	} {
+		if t.value != "" {
+			lines = append(lines, t.key+": "+t.value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commitMessageArgs returns the -m arguments for a commit with the given
+// subject, adding the trailers as the final paragraph of the message.
+func (m CommitMetadata) commitMessageArgs(subject string) []string {
+	args := []string{"-m", subject}
+	if trailers := m.Trailers(); trailers != "" {
+		args = append(args, "-m", trailers)
+	}
+	return args
+}
+
 // MergeRunner handles git operations for merging.
 type MergeRunner interface {
 	// GetDiff returns the diff of uncommitted changes in the worktree.
@@ -58,22 +225,46 @@ type MergeRunner interface {
 	// GetStatus returns changed files in the worktree.
 	GetStatus(ctx context.Context, workDir string) ([]string, error)
 
-	// GetDiffStats returns additions and deletions count.
-	GetDiffStats(ctx context.Context, workDir string) (additions, deletions int, err error)
+	// GetDiffStats returns the additions and deletions count of uncommitted
+	// changes, and the binary and large files changed in the worktree.
+	GetDiffStats(ctx context.Context, workDir string) (DiffStats, error)
 
 	// HasConflicts checks if there are merge conflicts.
 	HasConflicts(ctx context.Context, workDir string) (bool, []string, error)
 
-	// CommitWorktree stages and commits all changes in the worktree.
-	CommitWorktree(ctx context.Context, workDir string) error
+	// GetCommits returns the commits on the worktree's branch that are not on
+	// the base branch, oldest first.
+	GetCommits(ctx context.Context, workDir string) ([]CommitInfo, error)
+
+	// CommitWorktree stages and commits all changes in the worktree,
+	// recording meta as trailers on the commit.
+	CommitWorktree(ctx context.Context, workDir string, meta CommitMetadata) error
 
-	// MergeToMain merges the worktree branch into the main branch.
+	// MergeToMain merges the worktree branch into the main branch,
+	// recording meta as trailers on the merge commit.
 	// Returns MergeResult with conflict info if merge cannot proceed.
-	MergeToMain(ctx context.Context, mainRepoDir, worktreeBranch, baseBranch string) (*MergeResult, error)
+	MergeToMain(ctx context.Context, mainRepoDir, worktreeBranch, baseBranch string, meta CommitMetadata, opts MergeOptions) (*MergeResult, error)
 }
 
 // DefaultMergeRunner is the default implementation using git commands.
-type DefaultMergeRunner struct{}
+type DefaultMergeRunner struct {
+	// runGit runs the commands that change a repository, git.Exec if nil.
+	runGit git.CommandFunc
+}
+
+// gitWrite runs a git command that changes the repository in dir, retrying
+// while another git process holds a lock. The error includes git's output.
+func (r *DefaultMergeRunner) gitWrite(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	run := r.runGit
+	if run == nil {
+		run = git.Exec
+	}
+	output, err := git.RunWithLockRetry(ctx, run, dir, args...)
+	if err != nil && !git.IsLockError(err) {
+		return output, fmt.Errorf("%s: %w", strings.TrimSpace(string(output)), err)
+	}
+	return output, err
+}
 
 // GetDiff returns the diff of changes.
 func (r *DefaultMergeRunner) GetDiff(ctx context.Context, workDir string) (string, error) {
@@ -102,8 +293,9 @@ func (r *DefaultMergeRunner) GetStatus(ctx context.Context, workDir string) ([]s
 		return nil, fmt.Errorf("git status failed: %w", err)
 	}
 
+	// Keep leading spaces: they are part of each line's status column
 	var files []string
-	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	lines := strings.Split(strings.TrimRight(stdout.String(), "\n"), "\n")
 	for _, line := range lines {
 		if len(line) > 3 {
 			files = append(files, strings.TrimSpace(line[3:]))
@@ -113,38 +305,144 @@ func (r *DefaultMergeRunner) GetStatus(ctx context.Context, workDir string) ([]s
 	return files, nil
 }
 
-// GetDiffStats returns additions and deletions count.
-func (r *DefaultMergeRunner) GetDiffStats(ctx context.Context, workDir string) (additions, deletions int, err error) {
-	cmd := exec.CommandContext(ctx, "git", "diff", "--numstat", "HEAD")
+// GetDiffStats returns additions and deletions count of the changes against
+// HEAD. Binary files are reported by --numstat as "-\t-\t<path>" and are
+// returned separately instead of being counted. Binary and large files are
+// looked for in the uncommitted changes, in untracked files and in the
+// commits on the branch, since an artifact that slipped in earlier is merged
+// all the same.
+func (r *DefaultMergeRunner) GetDiffStats(ctx context.Context, workDir string) (DiffStats, error) {
+	var stats DiffStats
+	binary := make(map[string]bool)
+	changed := make(map[string]bool)
+
+	entries, err := numstat(ctx, workDir, "HEAD")
+	if err != nil {
+		return DiffStats{}, err
+	}
+	for _, entry := range entries {
+		changed[entry.path] = true
+		if entry.binary {
+			binary[entry.path] = true
+			continue
+		}
+		stats.Additions += entry.additions
+		stats.Deletions += entry.deletions
+	}
+
+	if base := baseBranch(ctx, workDir); base != "" {
+		entries, err := numstat(ctx, workDir, "--no-renames", base+"...HEAD")
+		if err != nil {
+			return DiffStats{}, err
+		}
+		for _, entry := range entries {
+			changed[entry.path] = true
+			if entry.binary {
+				binary[entry.path] = true
+			}
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "ls-files", "--others", "--exclude-standard", "-z")
+	cmd.Dir = workDir
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return DiffStats{}, fmt.Errorf("git ls-files failed: %w", err)
+	}
+	for _, path := range strings.Split(stdout.String(), "\x00") {
+		if path == "" {
+			continue
+		}
+		changed[path] = true
+		if isBinaryFile(filepath.Join(workDir, path)) {
+			binary[path] = true
+		}
+	}
+
+	// Deleted files aren't in the worktree and aren't large
+	for path := range changed {
+		info, err := os.Stat(filepath.Join(workDir, path))
+		if err == nil && info.Mode().IsRegular() && info.Size() > LargeFileThreshold {
+			stats.LargeFiles = append(stats.LargeFiles, path)
+		}
+	}
+	for path := range binary {
+		stats.BinaryFiles = append(stats.BinaryFiles, path)
+	}
+	sort.Strings(stats.LargeFiles)
+	sort.Strings(stats.BinaryFiles)
+
+	return stats, nil
+}
+
+// numstatEntry is one file from git diff --numstat.
+type numstatEntry struct {
+	path      string
+	additions int
+	deletions int
+	binary    bool
+}
+
+// numstat runs git diff --numstat with args in workDir.
+func numstat(ctx context.Context, workDir string, args ...string) ([]numstatEntry, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"diff", "--numstat"}, args...)...)
 	cmd.Dir = workDir
 
 	var stdout bytes.Buffer
 	cmd.Stdout = &stdout
 
 	if err := cmd.Run(); err != nil {
-		return 0, 0, fmt.Errorf("git diff --numstat failed: %w", err)
+		return nil, fmt.Errorf("git diff --numstat failed: %w", err)
 	}
 
+	var entries []numstatEntry
 	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
 	for _, line := range lines {
 		if line == "" {
 			continue
 		}
-		parts := strings.Fields(line)
-		if len(parts) >= 2 {
-			var add, del int
-			if parts[0] != "-" {
-				fmt.Sscanf(parts[0], "%d", &add)
-			}
-			if parts[1] != "-" {
-				fmt.Sscanf(parts[1], "%d", &del)
-			}
-			additions += add
-			deletions += del
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
 		}
+		entry := numstatEntry{path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			entry.binary = true
+		} else {
+			fmt.Sscanf(parts[0], "%d", &entry.additions)
+			fmt.Sscanf(parts[1], "%d", &entry.deletions)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// isBinaryFile reports whether the file at path looks binary to git: it has
+// a NUL byte near the start.
+func isBinaryFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
 	}
+	defer f.Close()
 
-	return additions, deletions, nil
+	buf := make([]byte, binarySniffLen)
+	n, _ := f.Read(buf)
+	return bytes.IndexByte(buf[:n], 0) != -1
+}
+
+// baseBranch returns the branch worktrees are created from, main or master,
+// or "" if neither exists in the repository at workDir.
+func baseBranch(ctx context.Context, workDir string) string {
+	for _, branch := range []string{"main", "master"} {
+		cmd := exec.CommandContext(ctx, "git", "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
+		cmd.Dir = workDir
+		if err := cmd.Run(); err == nil {
+			return branch
+		}
+	}
+	return ""
 }
 
 // HasConflicts checks for merge conflicts.
@@ -186,12 +484,44 @@ func (r *DefaultMergeRunner) HasConflicts(ctx context.Context, workDir string) (
 	return len(conflictFiles) > 0, conflictFiles, nil
 }
 
+// GetCommits returns the commits in base..HEAD, oldest first. The base branch
+// is main or master, matching the branch worktrees are created from; if
+// neither exists there is nothing to compare against and no commits are
+// returned.
+func (r *DefaultMergeRunner) GetCommits(ctx context.Context, workDir string) ([]CommitInfo, error) {
+	base := baseBranch(ctx, workDir)
+	if base == "" {
+		return nil, nil
+	}
+
+	// Fields are separated by the ASCII unit separator, which can't appear
+	// in a subject or author name.
+	cmd := exec.CommandContext(ctx, "git", "log", "--reverse", "--format=%H%x1f%an%x1f%s", base+"..HEAD")
+	cmd.Dir = workDir
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+
+	var commits []CommitInfo
+	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+		parts := strings.SplitN(line, "\x1f", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		commits = append(commits, CommitInfo{SHA: parts[0], Author: parts[1], Subject: parts[2]})
+	}
+
+	return commits, nil
+}
+
 // CommitWorktree stages and commits all changes in the worktree.
-func (r *DefaultMergeRunner) CommitWorktree(ctx context.Context, workDir string) error {
+func (r *DefaultMergeRunner) CommitWorktree(ctx context.Context, workDir string, meta CommitMetadata) error {
 	// Stage all changes
-	stageCmd := exec.CommandContext(ctx, "git", "add", "-A")
-	stageCmd.Dir = workDir
-	if err := stageCmd.Run(); err != nil {
+	if _, err := r.gitWrite(ctx, workDir, "add", "-A"); err != nil {
 		return fmt.Errorf("git add failed: %w", err)
 	}
 
@@ -204,9 +534,8 @@ func (r *DefaultMergeRunner) CommitWorktree(ctx context.Context, workDir string)
 	}
 
 	// Create commit
-	commitCmd := exec.CommandContext(ctx, "git", "commit", "-m", "Merge changes from worktree")
-	commitCmd.Dir = workDir
-	if err := commitCmd.Run(); err != nil {
+	commitArgs := append([]string{"commit"}, meta.commitMessageArgs("Merge changes from worktree")...)
+	if _, err := r.gitWrite(ctx, workDir, commitArgs...); err != nil {
 		return fmt.Errorf("git commit failed: %w", err)
 	}
 
@@ -216,17 +545,20 @@ func (r *DefaultMergeRunner) CommitWorktree(ctx context.Context, workDir string)
 // MergeToMain merges the worktree branch into the main/base branch.
 // This performs:
 // 1. Checkout base branch in main repo
-// 2. Attempt merge with --no-ff
+// 2. Attempt merge with --no-ff, or --ff-only when opts.FFOnly is set
 // 3. If conflicts, abort and return conflict info
 // 4. If success, return merge commit SHA
-func (r *DefaultMergeRunner) MergeToMain(ctx context.Context, mainRepoDir, worktreeBranch, baseBranch string) (*MergeResult, error) {
+//
+// Merges into the same repository run one at a time.
+func (r *DefaultMergeRunner) MergeToMain(ctx context.Context, mainRepoDir, worktreeBranch, baseBranch string, meta CommitMetadata, opts MergeOptions) (*MergeResult, error) {
+	unlock := git.LockRepo(mainRepoDir)
+	defer unlock()
+
 	result := &MergeResult{}
 
 	// First, checkout the base branch
-	checkoutCmd := exec.CommandContext(ctx, "git", "checkout", baseBranch)
-	checkoutCmd.Dir = mainRepoDir
-	if output, err := checkoutCmd.CombinedOutput(); err != nil {
-		return nil, fmt.Errorf("failed to checkout %s: %s: %w", baseBranch, string(output), err)
+	if _, err := r.gitWrite(ctx, mainRepoDir, "checkout", baseBranch); err != nil {
+		return nil, fmt.Errorf("failed to checkout %s: %w", baseBranch, err)
 	}
 
 	// Pull latest changes from remote (if remote exists)
@@ -235,16 +567,17 @@ func (r *DefaultMergeRunner) MergeToMain(ctx context.Context, mainRepoDir, workt
 	// Ignore errors - may not have a remote configured
 	_ = pullCmd.Run()
 
-	// Attempt the merge
-	mergeCmd := exec.CommandContext(ctx, "git", "merge", "--no-ff", "-m",
-		fmt.Sprintf("Merge branch '%s'", worktreeBranch), worktreeBranch)
-	mergeCmd.Dir = mainRepoDir
+	if opts.FFOnly {
+		return r.fastForward(ctx, mainRepoDir, worktreeBranch, baseBranch)
+	}
 
-	var mergeOutput bytes.Buffer
-	mergeCmd.Stdout = &mergeOutput
-	mergeCmd.Stderr = &mergeOutput
+	// Attempt the merge
+	mergeArgs := append([]string{"merge", "--no-ff"}, meta.commitMessageArgs(fmt.Sprintf("Merge branch '%s'", worktreeBranch))...)
+	if _, err := r.gitWrite(ctx, mainRepoDir, append(mergeArgs, worktreeBranch)...); err != nil {
+		if git.IsLockError(err) {
+			return nil, fmt.Errorf("merge failed: %w", err)
+		}
 
-	if err := mergeCmd.Run(); err != nil {
 		// Merge failed - check if it's a conflict
 		conflictFiles := r.getConflictFiles(ctx, mainRepoDir)
 		if len(conflictFiles) > 0 {
@@ -260,7 +593,7 @@ func (r *DefaultMergeRunner) MergeToMain(ctx context.Context, mainRepoDir, workt
 		}
 
 		// Not a conflict, some other error
-		return nil, fmt.Errorf("merge failed: %s: %w", mergeOutput.String(), err)
+		return nil, fmt.Errorf("merge failed: %w", err)
 	}
 
 	// Merge succeeded - get the commit SHA
@@ -275,6 +608,32 @@ func (r *DefaultMergeRunner) MergeToMain(ctx context.Context, mainRepoDir, workt
 	return result, nil
 }
 
+// fastForward moves the checked-out base branch to the worktree branch,
+// failing with a NotFastForwardError if the base branch has diverged.
+func (r *DefaultMergeRunner) fastForward(ctx context.Context, mainRepoDir, worktreeBranch, baseBranch string) (*MergeResult, error) {
+	ancestorCmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", baseBranch, worktreeBranch)
+	ancestorCmd.Dir = mainRepoDir
+	if err := ancestorCmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, &NotFastForwardError{Branch: worktreeBranch, BaseBranch: baseBranch}
+		}
+		return nil, fmt.Errorf("failed to compare %s with %s: %w", worktreeBranch, baseBranch, err)
+	}
+
+	if _, err := r.gitWrite(ctx, mainRepoDir, "merge", "--ff-only", worktreeBranch); err != nil {
+		return nil, fmt.Errorf("merge failed: %w", err)
+	}
+
+	result := &MergeResult{Success: true}
+	revCmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	revCmd.Dir = mainRepoDir
+	if revOutput, err := revCmd.Output(); err == nil {
+		result.MergeCommit = strings.TrimSpace(string(revOutput))
+	}
+	return result, nil
+}
+
 // getConflictFiles returns files that have merge conflicts.
 func (r *DefaultMergeRunner) getConflictFiles(ctx context.Context, repoDir string) []string {
 	// Use git diff --name-only --diff-filter=U to get unmerged files
@@ -296,27 +655,63 @@ func (r *DefaultMergeRunner) getConflictFiles(ctx context.Context, repoDir strin
 	return files
 }
 
+// SummarySpell is the built-in spell merge steps with summarize set use to
+// have the agent describe their changes.
+const SummarySpell = "summarize-changes"
+
+// summaryDiffLimit caps how much of the diff is included in the summary
+// spell's prompt.
+const summaryDiffLimit = 64 * 1024
+
 // MergeExecutor executes merge steps.
 type MergeExecutor struct {
-	runner MergeRunner
+	runner      MergeRunner
+	checkRunner CommandRunner
+
+	// summarizer runs the summary spell for merge steps with summarize set.
+	summarizer *AgentExecutor
 }
 
 // NewMergeExecutor creates a new merge executor.
 func NewMergeExecutor() *MergeExecutor {
 	return &MergeExecutor{
-		runner: &DefaultMergeRunner{},
+		runner:      &DefaultMergeRunner{},
+		checkRunner: &DefaultCommandRunner{},
 	}
 }
 
 // NewMergeExecutorWithRunner creates a merge executor with a custom runner.
 func NewMergeExecutorWithRunner(runner MergeRunner) *MergeExecutor {
 	return &MergeExecutor{
-		runner: runner,
+		runner:      runner,
+		checkRunner: &DefaultCommandRunner{},
 	}
 }
 
-// Execute runs a merge step and returns the result.
+// SetCheckRunner sets the runner used for pre-merge checks (for testing).
+func (e *MergeExecutor) SetCheckRunner(runner CommandRunner) {
+	e.checkRunner = runner
+}
+
+// SetSummarizer sets the agent executor used to summarize changes for merge
+// steps with summarize set.
+func (e *MergeExecutor) SetSummarizer(summarizer *AgentExecutor) {
+	e.summarizer = summarizer
+}
+
+// Execute runs a merge step and returns the result. The review is left in
+// the context as merge_review only while the step waits for it to be
+// approved, so later steps don't see a stale one.
 func (e *MergeExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+	result, err := e.execute(ctx, step, stepCtx)
+	if err != nil || result.Action != ActionBlock || !result.Success {
+		stepCtx.DeleteVariable("merge_review")
+	}
+	return result, err
+}
+
+// execute runs a merge step.
+func (e *MergeExecutor) execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
 	if step.Type != grimoire.StepTypeMerge {
 		return nil, fmt.Errorf("expected merge step, got %s", step.Type)
 	}
@@ -357,10 +752,32 @@ func (e *MergeExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 		}, nil
 	}
 
+	// Run pre-merge checks; any failure fails the merge
+	if len(step.Checks) > 0 {
+		review.Checks = e.runChecks(execCtx, step.Checks, step.GetCheckConcurrency(), stepCtx.WorktreePath)
+		if failed := failedChecks(review.Checks); len(failed) > 0 {
+			duration := time.Since(start)
+			return &StepResult{
+				Success:  false,
+				Output:   formatReviewOutput(review),
+				Error:    fmt.Sprintf("%d of %d pre-merge checks failed: %s", len(failed), len(review.Checks), strings.Join(failed, "; ")),
+				Duration: duration,
+				Action:   ActionFail,
+			}, nil
+		}
+	}
+
 	// Check if review is required (default: true)
 	requireReview := step.RequiresReview()
 
 	if requireReview {
+		// Have the agent describe the changes for the reviewer
+		if step.Summarize && review.HasChanges() {
+			if summary := e.summarize(execCtx, step, stepCtx, review); summary != "" {
+				review.Summary = summary
+			}
+		}
+
 		// Return block action to pause for human review
 		duration := time.Since(start)
 
@@ -368,15 +785,17 @@ func (e *MergeExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 		stepCtx.SetVariable("merge_review", review)
 
 		return &StepResult{
-			Success:  true, // Merge preparation successful
-			Output:   formatReviewOutput(review),
-			Duration: duration,
-			Action:   ActionBlock, // Block for human review
+			Success:   true, // Merge preparation successful
+			Output:    formatReviewOutput(review),
+			Summary:   review.Summary,
+			Duration:  duration,
+			Action:    ActionBlock, // Block for human review
+			NoChanges: !review.HasChanges(),
 		}, nil
 	}
 
 	// Auto-merge (require_review: false)
-	if err := e.runner.CommitWorktree(execCtx, stepCtx.WorktreePath); err != nil {
+	if err := e.runner.CommitWorktree(execCtx, stepCtx.WorktreePath, stepCtx.CommitMetadata()); err != nil {
 		duration := time.Since(start)
 		return &StepResult{
 			Success:  false,
@@ -389,46 +808,101 @@ func (e *MergeExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCt
 
 	duration := time.Since(start)
 	return &StepResult{
-		Success:  true,
-		Output:   formatReviewOutput(review),
-		Duration: duration,
-		Action:   ActionContinue,
+		Success:   true,
+		Output:    formatReviewOutput(review),
+		Duration:  duration,
+		Action:    ActionContinue,
+		NoChanges: !review.HasChanges(),
 	}, nil
 }
 
 // generateReview creates the review information for the merge.
+// The git queries are read-only, so they run concurrently; the first error
+// cancels the remaining queries and is returned.
 func (e *MergeExecutor) generateReview(ctx context.Context, workDir string) (*MergeReview, error) {
 	review := &MergeReview{}
 
-	// Get diff
-	diff, err := e.runner.GetDiff(ctx, workDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get diff: %w", err)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
 	}
-	review.Diff = diff
+
+	// Get diff
+	run(func() error {
+		diff, err := e.runner.GetDiff(ctx, workDir)
+		if err != nil {
+			return fmt.Errorf("failed to get diff: %w", err)
+		}
+		review.Diff = diff
+		return nil
+	})
 
 	// Get changed files
-	files, err := e.runner.GetStatus(ctx, workDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get status: %w", err)
-	}
-	review.FilesChanged = files
+	run(func() error {
+		files, err := e.runner.GetStatus(ctx, workDir)
+		if err != nil {
+			return fmt.Errorf("failed to get status: %w", err)
+		}
+		review.FilesChanged = files
+		return nil
+	})
 
 	// Get stats
-	additions, deletions, err := e.runner.GetDiffStats(ctx, workDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get diff stats: %w", err)
-	}
-	review.Additions = additions
-	review.Deletions = deletions
+	run(func() error {
+		stats, err := e.runner.GetDiffStats(ctx, workDir)
+		if err != nil {
+			return fmt.Errorf("failed to get diff stats: %w", err)
+		}
+		review.Additions = stats.Additions
+		review.Deletions = stats.Deletions
+		review.BinaryFiles = stats.BinaryFiles
+		review.LargeFiles = stats.LargeFiles
+		return nil
+	})
 
 	// Check for conflicts
-	hasConflicts, conflictFiles, err := e.runner.HasConflicts(ctx, workDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check conflicts: %w", err)
+	run(func() error {
+		hasConflicts, conflictFiles, err := e.runner.HasConflicts(ctx, workDir)
+		if err != nil {
+			return fmt.Errorf("failed to check conflicts: %w", err)
+		}
+		review.HasConflicts = hasConflicts
+		review.ConflictFiles = conflictFiles
+		return nil
+	})
+
+	// Get commits on the branch
+	run(func() error {
+		commits, err := e.runner.GetCommits(ctx, workDir)
+		if err != nil {
+			return fmt.Errorf("failed to get commits: %w", err)
+		}
+		review.Commits = commits
+		return nil
+	})
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
 	}
-	review.HasConflicts = hasConflicts
-	review.ConflictFiles = conflictFiles
 
 	// Generate summary
 	review.Summary = generateMergeSummary(review)
@@ -436,19 +910,109 @@ func (e *MergeExecutor) generateReview(ctx context.Context, workDir string) (*Me
 	return review, nil
 }
 
+// summarize asks the agent to describe the review's changes with the
+// summary spell, which sees the review as merge_review. It returns "" if no
+// agent is configured or the agent doesn't report a summary, so the review
+// keeps its generated one.
+func (e *MergeExecutor) summarize(ctx context.Context, step *grimoire.Step, stepCtx *StepContext, review *MergeReview) string {
+	if e.summarizer == nil || e.summarizer.runner == nil {
+		return ""
+	}
+
+	prompted := *review
+	if len(prompted.Diff) > summaryDiffLimit {
+		prompted.Diff = prompted.Diff[:summaryDiffLimit] + "\n... (diff truncated)"
+	}
+	stepCtx.SetVariable("merge_review", &prompted)
+
+	summaryStep := &grimoire.Step{
+		Name:    step.Name,
+		Type:    grimoire.StepTypeAgent,
+		Spell:   SummarySpell,
+		Timeout: step.Timeout,
+	}
+	result, err := e.summarizer.Execute(ctx, summaryStep, stepCtx)
+	if err != nil || !result.Success {
+		return ""
+	}
+	return strings.TrimSpace(result.Summary)
+}
+
+// runChecks runs the pre-merge check commands in the worktree, at most limit
+// at a time. Every check runs to completion so that all failures are
+// reported, not just the first. Results are returned in command order.
+func (e *MergeExecutor) runChecks(ctx context.Context, commands []string, limit int, workDir string) []CheckResult {
+	results := make([]CheckResult, len(commands))
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, command := range commands {
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result := CheckResult{Command: command}
+			stdout, stderr, exitCode, err := e.checkRunner.Run(ctx, workDir, command)
+			result.ExitCode = exitCode
+			result.Output = strings.TrimSpace(stdout + stderr)
+			if err != nil {
+				result.ExitCode = -1
+				result.Error = err.Error()
+			}
+			result.Success = err == nil && exitCode == 0
+			results[i] = result
+		}(i, command)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// failedChecks describes each failed check, in command order.
+func failedChecks(results []CheckResult) []string {
+	var failed []string
+	for _, r := range results {
+		if r.Success {
+			continue
+		}
+		if r.Error != "" {
+			failed = append(failed, fmt.Sprintf("%q: %s", r.Command, r.Error))
+		} else {
+			failed = append(failed, fmt.Sprintf("%q exited with code %d", r.Command, r.ExitCode))
+		}
+	}
+	return failed
+}
+
 // generateMergeSummary creates a human-readable summary of the merge.
 func generateMergeSummary(review *MergeReview) string {
-	if len(review.FilesChanged) == 0 {
+	if !review.HasChanges() {
 		return "No changes to merge"
 	}
 
 	var parts []string
-	parts = append(parts, fmt.Sprintf("%d file(s) changed", len(review.FilesChanged)))
+	if len(review.FilesChanged) > 0 {
+		parts = append(parts, fmt.Sprintf("%d file(s) changed", len(review.FilesChanged)))
+	}
+
+	if len(review.Commits) > 0 {
+		parts = append(parts, fmt.Sprintf("%d commit(s)", len(review.Commits)))
+	}
 
 	if review.Additions > 0 || review.Deletions > 0 {
 		parts = append(parts, fmt.Sprintf("+%d/-%d lines", review.Additions, review.Deletions))
 	}
 
+	if len(review.BinaryFiles) > 0 {
+		parts = append(parts, fmt.Sprintf("%d binary file(s)", len(review.BinaryFiles)))
+	}
+
+	if len(review.LargeFiles) > 0 {
+		parts = append(parts, fmt.Sprintf("%d large file(s)", len(review.LargeFiles)))
+	}
+
 	if review.HasConflicts {
 		parts = append(parts, fmt.Sprintf("%d conflict(s)", len(review.ConflictFiles)))
 	}
@@ -463,6 +1027,14 @@ func formatReviewOutput(review *MergeReview) string {
 	sb.WriteString("## Merge Review\n\n")
 	sb.WriteString(fmt.Sprintf("**Summary:** %s\n\n", review.Summary))
 
+	if len(review.Commits) > 0 {
+		sb.WriteString("### Commits\n")
+		for _, c := range review.Commits {
+			sb.WriteString(fmt.Sprintf("- `%s` %s (%s)\n", c.ShortSHA(), c.Subject, c.Author))
+		}
+		sb.WriteString("\n")
+	}
+
 	if len(review.FilesChanged) > 0 {
 		sb.WriteString("### Files Changed\n")
 		for _, file := range review.FilesChanged {
@@ -471,6 +1043,41 @@ func formatReviewOutput(review *MergeReview) string {
 		sb.WriteString("\n")
 	}
 
+	if len(review.BinaryFiles) > 0 {
+		sb.WriteString("### Binary Files\n")
+		sb.WriteString("**Warning:** the following binary files are included in this merge. Check that they are not build artifacts.\n")
+		for _, file := range review.BinaryFiles {
+			sb.WriteString(fmt.Sprintf("- %s\n", file))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(review.LargeFiles) > 0 {
+		sb.WriteString("### Large Files\n")
+		sb.WriteString(fmt.Sprintf("**Warning:** the following files are larger than %d MiB. Check that they are meant to be committed.\n", LargeFileThreshold>>20))
+		for _, file := range review.LargeFiles {
+			sb.WriteString(fmt.Sprintf("- %s\n", file))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(review.Checks) > 0 {
+		sb.WriteString("### Checks\n")
+		for _, check := range review.Checks {
+			status := "passed"
+			if !check.Success {
+				status = "failed"
+			}
+			sb.WriteString(fmt.Sprintf("- `%s`: %s\n", check.Command, status))
+			if !check.Success && check.Output != "" {
+				sb.WriteString("```\n")
+				sb.WriteString(check.Output)
+				sb.WriteString("\n```\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
 	if review.HasConflicts {
 		sb.WriteString("### Conflicts\n")
 		for _, file := range review.ConflictFiles {
@@ -479,13 +1086,13 @@ func formatReviewOutput(review *MergeReview) string {
 		sb.WriteString("\n")
 	}
 
-	if review.Diff != "" && len(review.Diff) <= 10000 {
+	if review.Diff != "" && len(review.Diff) <= maxReviewDiffBytes {
 		sb.WriteString("### Diff\n```diff\n")
 		sb.WriteString(review.Diff)
 		sb.WriteString("\n```\n")
-	} else if len(review.Diff) > 10000 {
+	} else if len(review.Diff) > maxReviewDiffBytes {
 		sb.WriteString("### Diff\n")
-		sb.WriteString("(diff too large to display, use git diff to view)\n")
+		sb.WriteString(fmt.Sprintf("(diff too large to display at %d bytes, get it from GET /workflows/:id/merge-diff)\n", len(review.Diff)))
 	}
 
 	return sb.String()
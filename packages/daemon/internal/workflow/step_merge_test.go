@@ -8,9 +8,14 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/coven/daemon/internal/git"
 	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/spell"
 )
 
 // MockMergeRunner is a mock implementation for testing.
@@ -29,6 +34,10 @@ type MockMergeRunner struct {
 	Additions int
 	// Deletions to return from GetDiffStats.
 	Deletions int
+	// BinaryFiles to return from GetDiffStats.
+	BinaryFiles []string
+	// LargeFiles to return from GetDiffStats.
+	LargeFiles []string
 	// StatsErr to return from GetDiffStats.
 	StatsErr error
 
@@ -39,6 +48,11 @@ type MockMergeRunner struct {
 	// ConflictsErr to return from HasConflicts.
 	ConflictsErr error
 
+	// Commits to return from GetCommits.
+	Commits []CommitInfo
+	// CommitsErr to return from GetCommits.
+	CommitsErr error
+
 	// CommitWorktreeErr to return from CommitWorktree.
 	CommitWorktreeErr error
 
@@ -59,20 +73,29 @@ func (m *MockMergeRunner) GetStatus(ctx context.Context, workDir string) ([]stri
 	return m.Files, m.StatusErr
 }
 
-func (m *MockMergeRunner) GetDiffStats(ctx context.Context, workDir string) (int, int, error) {
-	return m.Additions, m.Deletions, m.StatsErr
+func (m *MockMergeRunner) GetDiffStats(ctx context.Context, workDir string) (DiffStats, error) {
+	return DiffStats{
+		Additions:   m.Additions,
+		Deletions:   m.Deletions,
+		BinaryFiles: m.BinaryFiles,
+		LargeFiles:  m.LargeFiles,
+	}, m.StatsErr
 }
 
 func (m *MockMergeRunner) HasConflicts(ctx context.Context, workDir string) (bool, []string, error) {
 	return m.HasConflictsResult, m.ConflictFiles, m.ConflictsErr
 }
 
-func (m *MockMergeRunner) CommitWorktree(ctx context.Context, workDir string) error {
+func (m *MockMergeRunner) GetCommits(ctx context.Context, workDir string) ([]CommitInfo, error) {
+	return m.Commits, m.CommitsErr
+}
+
+func (m *MockMergeRunner) CommitWorktree(ctx context.Context, workDir string, meta CommitMetadata) error {
 	m.CommitWorktreeCalled = true
 	return m.CommitWorktreeErr
 }
 
-func (m *MockMergeRunner) MergeToMain(ctx context.Context, mainRepoDir, worktreeBranch, baseBranch string) (*MergeResult, error) {
+func (m *MockMergeRunner) MergeToMain(ctx context.Context, mainRepoDir, worktreeBranch, baseBranch string, meta CommitMetadata, opts MergeOptions) (*MergeResult, error) {
 	if m.MergeToMainResult != nil {
 		return m.MergeToMainResult, m.MergeToMainErr
 	}
@@ -171,6 +194,88 @@ func TestMergeExecutor_Execute_RequireReviewTrue(t *testing.T) {
 	}
 }
 
+func TestMergeExecutor_Execute_Summarize(t *testing.T) {
+	runner := &MockMergeRunner{
+		Diff:      "+func retry() {}",
+		Files:     []string{"client.go"},
+		Additions: 1,
+		Commits:   []CommitInfo{{SHA: "abc1234def", Subject: "Add retries", Author: "Agent"}},
+	}
+	agentRunner := &MockAgentRunner{
+		Output: "```json\n{\"success\": true, \"summary\": \"Adds retries to the HTTP client.\"}\n```",
+	}
+	executor := NewMergeExecutorWithRunner(runner)
+	executor.SetSummarizer(NewAgentExecutor(spell.NewLoader(t.TempDir()), agentRunner))
+
+	step := &grimoire.Step{Name: "merge", Type: grimoire.StepTypeMerge, Summarize: true}
+	stepCtx := NewStepContext(t.TempDir(), "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Action != ActionBlock {
+		t.Errorf("Action = %q, want %q", result.Action, ActionBlock)
+	}
+
+	want := "Adds retries to the HTTP client."
+	if result.Summary != want {
+		t.Errorf("Summary = %q, want %q", result.Summary, want)
+	}
+	review := stepCtx.GetVariable("merge_review").(*MergeReview)
+	if review.Summary != want {
+		t.Errorf("merge_review.Summary = %q, want %q", review.Summary, want)
+	}
+	if !strings.Contains(result.Output, want) {
+		t.Errorf("Output should show the summary, got:\n%s", result.Output)
+	}
+	for _, part := range []string{"+func retry() {}", "Add retries", "client.go"} {
+		if !strings.Contains(agentRunner.Prompt, part) {
+			t.Errorf("summary prompt should contain %q, got:\n%s", part, agentRunner.Prompt)
+		}
+	}
+}
+
+func TestMergeExecutor_Execute_SummarizeFallsBack(t *testing.T) {
+	runner := &MockMergeRunner{Diff: "diff", Files: []string{"a.go"}, Additions: 2}
+
+	t.Run("not enabled", func(t *testing.T) {
+		agentRunner := &MockAgentRunner{Output: `{"success": true, "summary": "unused"}`}
+		executor := NewMergeExecutorWithRunner(runner)
+		executor.SetSummarizer(NewAgentExecutor(spell.NewLoader(t.TempDir()), agentRunner))
+
+		step := &grimoire.Step{Name: "merge", Type: grimoire.StepTypeMerge}
+		result, err := executor.Execute(context.Background(), step, NewStepContext(t.TempDir(), "bead", "wf"))
+		if err != nil {
+			t.Fatalf("Execute() error: %v", err)
+		}
+		if agentRunner.Prompt != "" {
+			t.Error("agent should not run without summarize")
+		}
+		if result.Summary != "1 file(s) changed, +2/-0 lines" {
+			t.Errorf("Summary = %q, want the generated summary", result.Summary)
+		}
+	})
+
+	t.Run("agent fails", func(t *testing.T) {
+		agentRunner := &MockAgentRunner{Output: "crashed", ExitCode: 1}
+		executor := NewMergeExecutorWithRunner(runner)
+		executor.SetSummarizer(NewAgentExecutor(spell.NewLoader(t.TempDir()), agentRunner))
+
+		step := &grimoire.Step{Name: "merge", Type: grimoire.StepTypeMerge, Summarize: true}
+		result, err := executor.Execute(context.Background(), step, NewStepContext(t.TempDir(), "bead", "wf"))
+		if err != nil {
+			t.Fatalf("Execute() error: %v", err)
+		}
+		if !result.Success || result.Action != ActionBlock {
+			t.Errorf("result = %+v, want the merge to still wait for review", result)
+		}
+		if result.Summary != "1 file(s) changed, +2/-0 lines" {
+			t.Errorf("Summary = %q, want the generated summary", result.Summary)
+		}
+	})
+}
+
 func TestMergeExecutor_Execute_RequireReviewFalse(t *testing.T) {
 	runner := &MockMergeRunner{
 		Diff:      "diff content",
@@ -205,6 +310,38 @@ func TestMergeExecutor_Execute_RequireReviewFalse(t *testing.T) {
 	if !runner.CommitWorktreeCalled {
 		t.Error("CommitWorktree should be called when require_review is false")
 	}
+	if result.NoChanges {
+		t.Error("NoChanges = true, want false when files changed")
+	}
+}
+
+func TestMergeExecutor_Execute_ClearsReview(t *testing.T) {
+	runner := &MockMergeRunner{Diff: "diff", Files: []string{"a.go"}, Additions: 2}
+	executor := NewMergeExecutorWithRunner(runner)
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	// A merge waiting for review leaves its review in the context
+	review := &grimoire.Step{Name: "review", Type: grimoire.StepTypeMerge}
+	if _, err := executor.Execute(context.Background(), review, stepCtx); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if stepCtx.GetVariable("merge_review") == nil {
+		t.Fatal("merge_review should be set while the merge waits for review")
+	}
+
+	// One that completes removes it, so later steps don't see it
+	requireReview := false
+	auto := &grimoire.Step{Name: "merge", Type: grimoire.StepTypeMerge, RequireReview: &requireReview}
+	result, err := executor.Execute(context.Background(), auto, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Action != ActionContinue {
+		t.Fatalf("Action = %q, want %q", result.Action, ActionContinue)
+	}
+	if review := stepCtx.GetVariable("merge_review"); review != nil {
+		t.Errorf("merge_review = %+v after the merge completed, want it cleared", review)
+	}
 }
 
 func TestMergeExecutor_Execute_WithConflicts(t *testing.T) {
@@ -268,6 +405,9 @@ func TestMergeExecutor_Execute_NoChanges(t *testing.T) {
 	if !strings.Contains(result.Output, "No changes") {
 		t.Errorf("Output should mention no changes, got: %s", result.Output)
 	}
+	if !result.NoChanges {
+		t.Error("NoChanges = false, want true")
+	}
 }
 
 func TestMergeExecutor_Execute_DiffError(t *testing.T) {
@@ -298,6 +438,144 @@ func TestMergeExecutor_Execute_DiffError(t *testing.T) {
 	}
 }
 
+// barrierMergeRunner wraps MockMergeRunner and blocks each review query
+// until all five have started, proving they run concurrently.
+type barrierMergeRunner struct {
+	*MockMergeRunner
+	started sync.WaitGroup
+	calls   atomic.Int32
+}
+
+func newBarrierMergeRunner(mock *MockMergeRunner) *barrierMergeRunner {
+	r := &barrierMergeRunner{MockMergeRunner: mock}
+	r.started.Add(5)
+	return r
+}
+
+func (r *barrierMergeRunner) wait() error {
+	r.calls.Add(1)
+	r.started.Done()
+
+	done := make(chan struct{})
+	go func() {
+		r.started.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(2 * time.Second):
+		return errors.New("review queries did not run concurrently")
+	}
+}
+
+func (r *barrierMergeRunner) GetDiff(ctx context.Context, workDir string) (string, error) {
+	if err := r.wait(); err != nil {
+		return "", err
+	}
+	return r.MockMergeRunner.GetDiff(ctx, workDir)
+}
+
+func (r *barrierMergeRunner) GetStatus(ctx context.Context, workDir string) ([]string, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	return r.MockMergeRunner.GetStatus(ctx, workDir)
+}
+
+func (r *barrierMergeRunner) GetDiffStats(ctx context.Context, workDir string) (DiffStats, error) {
+	if err := r.wait(); err != nil {
+		return DiffStats{}, err
+	}
+	return r.MockMergeRunner.GetDiffStats(ctx, workDir)
+}
+
+func (r *barrierMergeRunner) HasConflicts(ctx context.Context, workDir string) (bool, []string, error) {
+	if err := r.wait(); err != nil {
+		return false, nil, err
+	}
+	return r.MockMergeRunner.HasConflicts(ctx, workDir)
+}
+
+func (r *barrierMergeRunner) GetCommits(ctx context.Context, workDir string) ([]CommitInfo, error) {
+	if err := r.wait(); err != nil {
+		return nil, err
+	}
+	return r.MockMergeRunner.GetCommits(ctx, workDir)
+}
+
+func TestMergeExecutor_GenerateReview_Concurrent(t *testing.T) {
+	runner := newBarrierMergeRunner(&MockMergeRunner{
+		Diff:               "diff --git a/main.go b/main.go",
+		Files:              []string{"main.go", "util.go"},
+		Additions:          12,
+		Deletions:          3,
+		HasConflictsResult: true,
+		ConflictFiles:      []string{"util.go"},
+		Commits:            []CommitInfo{{SHA: "abc1234", Subject: "Add util", Author: "Test"}},
+	})
+	executor := NewMergeExecutorWithRunner(runner)
+
+	review, err := executor.generateReview(context.Background(), "/worktree")
+	if err != nil {
+		t.Fatalf("generateReview() error: %v", err)
+	}
+
+	if got := runner.calls.Load(); got != 5 {
+		t.Errorf("calls = %d, want 5", got)
+	}
+	if review.Diff != "diff --git a/main.go b/main.go" {
+		t.Errorf("Diff = %q", review.Diff)
+	}
+	if len(review.FilesChanged) != 2 {
+		t.Errorf("FilesChanged = %v, want 2 files", review.FilesChanged)
+	}
+	if review.Additions != 12 || review.Deletions != 3 {
+		t.Errorf("Stats = +%d/-%d, want +12/-3", review.Additions, review.Deletions)
+	}
+	if !review.HasConflicts || len(review.ConflictFiles) != 1 {
+		t.Errorf("Conflicts = %v %v, want true [util.go]", review.HasConflicts, review.ConflictFiles)
+	}
+	if len(review.Commits) != 1 || review.Commits[0].Subject != "Add util" {
+		t.Errorf("Commits = %v, want [Add util]", review.Commits)
+	}
+	if review.Summary == "" {
+		t.Error("Summary should be generated")
+	}
+}
+
+func TestMergeExecutor_GenerateReview_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		runner *MockMergeRunner
+		errMsg string
+	}{
+		{"diff", &MockMergeRunner{DiffErr: errors.New("boom")}, "failed to get diff"},
+		{"status", &MockMergeRunner{StatusErr: errors.New("boom")}, "failed to get status"},
+		{"stats", &MockMergeRunner{StatsErr: errors.New("boom")}, "failed to get diff stats"},
+		{"conflicts", &MockMergeRunner{ConflictsErr: errors.New("boom")}, "failed to check conflicts"},
+		{"commits", &MockMergeRunner{CommitsErr: errors.New("boom")}, "failed to get commits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewMergeExecutorWithRunner(newBarrierMergeRunner(tt.runner))
+
+			review, err := executor.generateReview(context.Background(), "/worktree")
+			if err == nil {
+				t.Fatal("generateReview() should return error")
+			}
+			if review != nil {
+				t.Error("review should be nil on error")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Error = %q, want to contain %q", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
 func TestMergeExecutor_Execute_CommitWorktreeError(t *testing.T) {
 	runner := &MockMergeRunner{
 		Diff:              "diff content",
@@ -330,6 +608,126 @@ func TestMergeExecutor_Execute_CommitWorktreeError(t *testing.T) {
 	}
 }
 
+// checkCommandRunner fails commands listed in exitCodes and tracks how many
+// run at once.
+type checkCommandRunner struct {
+	exitCodes map[string]int
+	delay     time.Duration
+
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (r *checkCommandRunner) Run(ctx context.Context, workDir, command string) (string, string, int, error) {
+	r.mu.Lock()
+	r.running++
+	if r.running > r.peak {
+		r.peak = r.running
+	}
+	r.mu.Unlock()
+
+	time.Sleep(r.delay)
+
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+
+	if code := r.exitCodes[command]; code != 0 {
+		return "", command + " failed", code, nil
+	}
+	return "ok", "", 0, nil
+}
+
+func TestMergeExecutor_Execute_ChecksFail(t *testing.T) {
+	runner := &MockMergeRunner{Diff: "diff content", Files: []string{"src/main.go"}}
+	executor := NewMergeExecutorWithRunner(runner)
+	checks := &checkCommandRunner{exitCodes: map[string]int{"make lint": 1, "make test": 2}}
+	executor.SetCheckRunner(checks)
+
+	requireReview := false
+	step := &grimoire.Step{
+		Name:          "merge",
+		Type:          grimoire.StepTypeMerge,
+		RequireReview: &requireReview,
+		Checks:        []string{"make lint", "make build", "make test"},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if result.Success {
+		t.Error("Expected failure when checks fail")
+	}
+	if result.Action != ActionFail {
+		t.Errorf("Action = %q, want %q", result.Action, ActionFail)
+	}
+	if runner.CommitWorktreeCalled {
+		t.Error("CommitWorktree should not be called when checks fail")
+	}
+	for _, want := range []string{"2 of 3 pre-merge checks failed", `"make lint" exited with code 1`, `"make test" exited with code 2`} {
+		if !strings.Contains(result.Error, want) {
+			t.Errorf("Error = %q, want it to contain %q", result.Error, want)
+		}
+	}
+	if strings.Contains(result.Error, "make build") {
+		t.Errorf("Error should not mention the passing check, got: %q", result.Error)
+	}
+	if !strings.Contains(result.Output, "`make lint`: failed") || !strings.Contains(result.Output, "`make build`: passed") {
+		t.Errorf("Output should list check results, got: %q", result.Output)
+	}
+}
+
+func TestMergeExecutor_Execute_ChecksPass(t *testing.T) {
+	runner := &MockMergeRunner{Diff: "diff content", Files: []string{"src/main.go"}}
+	executor := NewMergeExecutorWithRunner(runner)
+	executor.SetCheckRunner(&checkCommandRunner{})
+
+	step := &grimoire.Step{
+		Name:   "merge",
+		Type:   grimoire.StepTypeMerge,
+		Checks: []string{"make lint", "make test"},
+	}
+	stepCtx := NewStepContext("/worktree", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if !result.Success || result.Action != ActionBlock {
+		t.Fatalf("Result = %+v, want successful block for review", result)
+	}
+	review := stepCtx.GetVariable("merge_review").(*MergeReview)
+	if len(review.Checks) != 2 || !review.Checks[0].Success || !review.Checks[1].Success {
+		t.Errorf("Checks = %+v, want two passing checks", review.Checks)
+	}
+}
+
+func TestMergeExecutor_RunChecks_Concurrency(t *testing.T) {
+	executor := NewMergeExecutorWithRunner(&MockMergeRunner{})
+	checks := &checkCommandRunner{delay: 20 * time.Millisecond}
+	executor.SetCheckRunner(checks)
+
+	commands := []string{"a", "b", "c", "d", "e", "f"}
+	results := executor.runChecks(context.Background(), commands, 2, "/worktree")
+
+	if len(results) != len(commands) {
+		t.Fatalf("runChecks() returned %d results, want %d", len(results), len(commands))
+	}
+	for i, r := range results {
+		if r.Command != commands[i] {
+			t.Errorf("results[%d].Command = %q, want %q", i, r.Command, commands[i])
+		}
+	}
+	if checks.peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", checks.peak)
+	}
+}
+
 func TestMergeExecutor_Execute_InvalidTimeout(t *testing.T) {
 	executor := NewMergeExecutorWithRunner(&MockMergeRunner{})
 
@@ -378,6 +776,13 @@ func TestMergeReview_Summary(t *testing.T) {
 			},
 			contains: "conflict",
 		},
+		{
+			name: "commits only",
+			review: &MergeReview{
+				Commits: []CommitInfo{{SHA: "abc", Subject: "Add feature"}},
+			},
+			contains: "1 commit(s)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -418,6 +823,28 @@ func TestFormatReviewOutput(t *testing.T) {
 	}
 }
 
+func TestFormatReviewOutput_WithCommits(t *testing.T) {
+	review := &MergeReview{
+		Summary: "1 file(s) changed",
+		Commits: []CommitInfo{
+			{SHA: "0123456789abcdef", Subject: "Add feature", Author: "Alice"},
+			{SHA: "fedcba9876543210", Subject: "Fix tests", Author: "Bob"},
+		},
+	}
+
+	output := formatReviewOutput(review)
+
+	if !strings.Contains(output, "### Commits") {
+		t.Error("Output should contain Commits section")
+	}
+	if !strings.Contains(output, "- `0123456` Add feature (Alice)") {
+		t.Errorf("Output should list first commit, got:\n%s", output)
+	}
+	if strings.Index(output, "Add feature") > strings.Index(output, "Fix tests") {
+		t.Error("Commits should be listed in order")
+	}
+}
+
 func TestFormatReviewOutput_LargeDiff(t *testing.T) {
 	// Create a diff larger than 10000 characters
 	largeDiff := strings.Repeat("a", 10001)
@@ -437,6 +864,49 @@ func TestFormatReviewOutput_LargeDiff(t *testing.T) {
 	}
 }
 
+func TestFormatReviewOutput_WithBinaryFiles(t *testing.T) {
+	review := &MergeReview{
+		FilesChanged: []string{"main.go", "dist/app.bin"},
+		BinaryFiles:  []string{"dist/app.bin"},
+	}
+	review.Summary = generateMergeSummary(review)
+
+	output := formatReviewOutput(review)
+
+	if !strings.Contains(output, "Binary Files") {
+		t.Error("Output should contain 'Binary Files' section")
+	}
+	if !strings.Contains(output, "Warning") {
+		t.Error("Output should warn about binary files")
+	}
+	if !strings.Contains(output, "dist/app.bin") {
+		t.Error("Output should list the binary file")
+	}
+	if !strings.Contains(review.Summary, "1 binary file(s)") {
+		t.Errorf("Summary = %q, should mention binary files", review.Summary)
+	}
+}
+
+func TestFormatReviewOutput_WithLargeFiles(t *testing.T) {
+	review := &MergeReview{
+		FilesChanged: []string{"main.go", "testdata/dump.json"},
+		LargeFiles:   []string{"testdata/dump.json"},
+	}
+	review.Summary = generateMergeSummary(review)
+
+	output := formatReviewOutput(review)
+
+	if !strings.Contains(output, "### Large Files") || !strings.Contains(output, "larger than 1 MiB") {
+		t.Errorf("Output should warn about large files, got:\n%s", output)
+	}
+	if !strings.Contains(output, "- testdata/dump.json") {
+		t.Error("Output should list the large file")
+	}
+	if !strings.Contains(review.Summary, "1 large file(s)") {
+		t.Errorf("Summary = %q, should mention large files", review.Summary)
+	}
+}
+
 func TestFormatReviewOutput_WithConflicts(t *testing.T) {
 	review := &MergeReview{
 		Summary:       "conflicts",
@@ -583,17 +1053,142 @@ func TestDefaultMergeRunner_GetDiffStats(t *testing.T) {
 	}
 
 	runner := &DefaultMergeRunner{}
-	additions, deletions, err := runner.GetDiffStats(context.Background(), tmpDir)
+	stats, err := runner.GetDiffStats(context.Background(), tmpDir)
 	if err != nil {
 		t.Fatalf("GetDiffStats() error: %v", err)
 	}
 
 	// We added 2 lines and deleted 1
-	if additions != 2 {
-		t.Errorf("Additions = %d, want 2", additions)
+	if stats.Additions != 2 {
+		t.Errorf("Additions = %d, want 2", stats.Additions)
+	}
+	if stats.Deletions != 1 {
+		t.Errorf("Deletions = %d, want 1", stats.Deletions)
+	}
+	if len(stats.BinaryFiles) != 0 || len(stats.LargeFiles) != 0 {
+		t.Errorf("BinaryFiles = %v, LargeFiles = %v, want none", stats.BinaryFiles, stats.LargeFiles)
+	}
+}
+
+func TestDefaultMergeRunner_GetDiffStats_BinaryFile(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Initialize git repo
+	initCmd := exec.Command("git", "init")
+	initCmd.Dir = tmpDir
+	if err := initCmd.Run(); err != nil {
+		t.Skipf("git init failed: %v", err)
+	}
+
+	// Configure git user
+	configName := exec.Command("git", "config", "user.name", "Test")
+	configName.Dir = tmpDir
+	configName.Run()
+
+	configEmail := exec.Command("git", "config", "user.email", "test@test.com")
+	configEmail.Dir = tmpDir
+	configEmail.Run()
+
+	// Create initial commit with a text file and a binary file
+	testFile := filepath.Join(tmpDir, "test.txt")
+	if err := os.WriteFile(testFile, []byte("line1\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	binFile := filepath.Join(tmpDir, "app.bin")
+	if err := os.WriteFile(binFile, []byte{0x00, 0x01, 0x02, 0xff}, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	addCmd := exec.Command("git", "add", ".")
+	addCmd.Dir = tmpDir
+	addCmd.Run()
+
+	commitCmd := exec.Command("git", "commit", "-m", "initial")
+	commitCmd.Dir = tmpDir
+	commitCmd.Run()
+
+	// Modify both files
+	if err := os.WriteFile(testFile, []byte("line1\nline2\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	if err := os.WriteFile(binFile, []byte{0x00, 0x10, 0x20, 0x30, 0xfe, 0x00}, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	runner := &DefaultMergeRunner{}
+	stats, err := runner.GetDiffStats(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("GetDiffStats() error: %v", err)
 	}
-	if deletions != 1 {
-		t.Errorf("Deletions = %d, want 1", deletions)
+
+	// Only the text file is line-counted
+	if stats.Additions != 1 {
+		t.Errorf("Additions = %d, want 1", stats.Additions)
+	}
+	if stats.Deletions != 0 {
+		t.Errorf("Deletions = %d, want 0", stats.Deletions)
+	}
+	if len(stats.BinaryFiles) != 1 || stats.BinaryFiles[0] != "app.bin" {
+		t.Errorf("BinaryFiles = %v, want [app.bin]", stats.BinaryFiles)
+	}
+}
+
+func TestDefaultMergeRunner_GetDiffStats_CommittedAndUntracked(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-b", "main")
+	run("config", "user.name", "Test")
+	run("config", "user.email", "test@test.com")
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("line1\n"), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	run("add", ".")
+	run("commit", "-m", "initial")
+
+	// A build artifact and a large text file committed on the branch
+	run("checkout", "-b", "feature")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "dist"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "dist", "app"), []byte{0x7f, 'E', 'L', 'F', 0x00, 0x01}, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	large := bytes.Repeat([]byte("x"), LargeFileThreshold+1)
+	if err := os.WriteFile(filepath.Join(tmpDir, "dump.txt"), large, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+	run("add", ".")
+	run("commit", "-m", "add build output")
+
+	// An untracked binary file
+	if err := os.WriteFile(filepath.Join(tmpDir, "core"), []byte{0x00, 0x02}, 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	runner := &DefaultMergeRunner{}
+	stats, err := runner.GetDiffStats(context.Background(), tmpDir)
+	if err != nil {
+		t.Fatalf("GetDiffStats() error: %v", err)
+	}
+
+	// Nothing changed against HEAD, so no lines are counted
+	if stats.Additions != 0 || stats.Deletions != 0 {
+		t.Errorf("Additions/Deletions = %d/%d, want 0/0", stats.Additions, stats.Deletions)
+	}
+	if strings.Join(stats.BinaryFiles, ",") != "core,dist/app" {
+		t.Errorf("BinaryFiles = %v, want [core dist/app]", stats.BinaryFiles)
+	}
+	if strings.Join(stats.LargeFiles, ",") != "dump.txt" {
+		t.Errorf("LargeFiles = %v, want [dump.txt]", stats.LargeFiles)
 	}
 }
 
@@ -666,7 +1261,7 @@ func TestDefaultMergeRunner_Merge(t *testing.T) {
 	}
 
 	runner := &DefaultMergeRunner{}
-	err := runner.CommitWorktree(context.Background(), tmpDir)
+	err := runner.CommitWorktree(context.Background(), tmpDir, CommitMetadata{})
 	if err != nil {
 		t.Fatalf("CommitWorktree() error: %v", err)
 	}
@@ -682,3 +1277,257 @@ func TestDefaultMergeRunner_Merge(t *testing.T) {
 		t.Errorf("Expected merge commit, got: %s", stdout.String())
 	}
 }
+
+func TestDefaultMergeRunner_CommitWorktreeRetriesLockContention(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.name", "Test"},
+		{"config", "user.email", "test@test.com"},
+	} {
+		if output, err := git.Exec(context.Background(), tmpDir, args...); err != nil {
+			t.Skipf("git %s failed: %v: %s", args[0], err, output)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "test.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("WriteFile error: %v", err)
+	}
+
+	// Staging fails on a held index lock, then succeeds
+	adds := 0
+	runner := &DefaultMergeRunner{runGit: func(ctx context.Context, dir string, args ...string) ([]byte, error) {
+		if args[0] == "add" {
+			adds++
+			if adds == 1 {
+				return []byte("fatal: Unable to create '" + dir + "/.git/index.lock': File exists."), errors.New("exit status 128")
+			}
+		}
+		return git.Exec(ctx, dir, args...)
+	}}
+	if err := runner.CommitWorktree(context.Background(), tmpDir, CommitMetadata{}); err != nil {
+		t.Fatalf("CommitWorktree() error: %v", err)
+	}
+	if adds != 2 {
+		t.Errorf("git add ran %d times, want 2", adds)
+	}
+
+	output, err := git.Exec(context.Background(), tmpDir, "log", "--oneline", "-1")
+	if err != nil || !strings.Contains(string(output), "Merge changes") {
+		t.Errorf("Expected a commit, got: %s (%v)", output, err)
+	}
+}
+
+func TestCommitMetadata_Trailers(t *testing.T) {
+	meta := CommitMetadata{TaskID: "task-1", WorkflowID: "wf-1", Grimoire: "implement", GrimoireHash: "abc123"}
+	want := "Coven-Task-ID: task-1\nCoven-Workflow-ID: wf-1\nCoven-Grimoire: implement\nCoven-Grimoire-Hash: abc123"
+	if got := meta.Trailers(); got != want {
+		t.Errorf("Trailers() = %q, want %q", got, want)
+	}
+
+	if got := (CommitMetadata{TaskID: "task-1"}).Trailers(); got != "Coven-Task-ID: task-1" {
+		t.Errorf("Trailers() = %q, should omit empty fields", got)
+	}
+	if got := (CommitMetadata{}).Trailers(); got != "" {
+		t.Errorf("Trailers() = %q, want empty", got)
+	}
+}
+
+func TestDefaultMergeRunner_MergeToMain_Trailers(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return string(out)
+	}
+
+	if err := exec.Command("git", "init", "-b", "main", repoDir).Run(); err != nil {
+		t.Skipf("git init failed: %v", err)
+	}
+	git("config", "user.name", "Test")
+	git("config", "user.email", "test@test.com")
+	os.WriteFile(filepath.Join(repoDir, "test.txt"), []byte("initial"), 0644)
+	git("add", ".")
+	git("commit", "-m", "initial")
+
+	git("checkout", "-b", "coven/task-1")
+	os.WriteFile(filepath.Join(repoDir, "feature.txt"), []byte("feature"), 0644)
+
+	runner := &DefaultMergeRunner{}
+	meta := CommitMetadata{TaskID: "task-1", WorkflowID: "wf-task-1-1", Grimoire: "implement", GrimoireHash: "0123abcd"}
+	if err := runner.CommitWorktree(context.Background(), repoDir, meta); err != nil {
+		t.Fatalf("CommitWorktree() error: %v", err)
+	}
+
+	result, err := runner.MergeToMain(context.Background(), repoDir, "coven/task-1", "main", meta, MergeOptions{})
+	if err != nil {
+		t.Fatalf("MergeToMain() error: %v", err)
+	}
+	if !result.Success || result.MergeCommit == "" {
+		t.Fatalf("MergeToMain() = %+v, want successful merge", result)
+	}
+
+	for _, rev := range []string{result.MergeCommit, "coven/task-1"} {
+		trailers := git("log", "-1", "--format=%(trailers:only,unfold)", rev)
+		for _, want := range []string{
+			"Coven-Task-ID: task-1",
+			"Coven-Workflow-ID: wf-task-1-1",
+			"Coven-Grimoire: implement",
+			"Coven-Grimoire-Hash: 0123abcd",
+		} {
+			if !strings.Contains(trailers, want) {
+				t.Errorf("commit %s trailers = %q, want %q", rev, trailers, want)
+			}
+		}
+	}
+
+	subject := git("log", "-1", "--format=%s", result.MergeCommit)
+	if strings.TrimSpace(subject) != "Merge branch 'coven/task-1'" {
+		t.Errorf("merge subject = %q", subject)
+	}
+}
+
+func TestDefaultMergeRunner_MergeToMain_FFOnly(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	if err := exec.Command("git", "init", "-b", "main", repoDir).Run(); err != nil {
+		t.Skipf("git init failed: %v", err)
+	}
+	git("config", "user.name", "Test")
+	git("config", "user.email", "test@test.com")
+	os.WriteFile(filepath.Join(repoDir, "test.txt"), []byte("initial"), 0644)
+	git("add", ".")
+	git("commit", "-m", "initial")
+
+	git("checkout", "-b", "coven/task-1")
+	os.WriteFile(filepath.Join(repoDir, "feature.txt"), []byte("feature"), 0644)
+	git("add", ".")
+	git("commit", "-m", "feature")
+
+	// Move main on so the branch can no longer fast-forward it
+	git("checkout", "main")
+	os.WriteFile(filepath.Join(repoDir, "other.txt"), []byte("other"), 0644)
+	git("add", ".")
+	git("commit", "-m", "other")
+	mainBefore := git("rev-parse", "main")
+
+	runner := &DefaultMergeRunner{}
+	opts := MergeOptions{FFOnly: true}
+	_, err := runner.MergeToMain(context.Background(), repoDir, "coven/task-1", "main", CommitMetadata{}, opts)
+	if !IsNotFastForward(err) {
+		t.Fatalf("MergeToMain() error = %v, want a NotFastForwardError", err)
+	}
+	if got := git("rev-parse", "main"); got != mainBefore {
+		t.Errorf("main = %s after a refused merge, want it unchanged at %s", got, mainBefore)
+	}
+
+	git("checkout", "coven/task-1")
+	git("rebase", "main")
+	git("checkout", "main")
+
+	result, err := runner.MergeToMain(context.Background(), repoDir, "coven/task-1", "main", CommitMetadata{}, opts)
+	if err != nil {
+		t.Fatalf("MergeToMain() after rebase error: %v", err)
+	}
+	if want := git("rev-parse", "coven/task-1"); !result.Success || result.MergeCommit != want {
+		t.Errorf("MergeToMain() = %+v, want main fast-forwarded to %s", result, want)
+	}
+	if parents := strings.Fields(git("log", "-1", "--format=%P", "main")); len(parents) != 1 {
+		t.Errorf("main head has parents %v, want no merge commit", parents)
+	}
+}
+
+func TestDefaultMergeRunner_GetCommits(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+
+	if err := exec.Command("git", "init", "-b", "main", repoDir).Run(); err != nil {
+		t.Skipf("git init failed: %v", err)
+	}
+	git(repoDir, "config", "user.name", "Test")
+	git(repoDir, "config", "user.email", "test@test.com")
+	os.WriteFile(filepath.Join(repoDir, "test.txt"), []byte("initial"), 0644)
+	git(repoDir, "add", ".")
+	git(repoDir, "commit", "-m", "initial")
+
+	// Commits made in a worktree are what the merge step sees.
+	wtDir := filepath.Join(t.TempDir(), "wt")
+	git(repoDir, "worktree", "add", "-b", "coven/task-1", wtDir)
+	os.WriteFile(filepath.Join(wtDir, "one.txt"), []byte("one"), 0644)
+	git(wtDir, "add", ".")
+	git(wtDir, "commit", "-m", "Add one")
+	os.WriteFile(filepath.Join(wtDir, "two.txt"), []byte("two"), 0644)
+	git(wtDir, "add", ".")
+	git(wtDir, "-c", "user.name=Other Author", "commit", "-m", "Add two")
+
+	runner := &DefaultMergeRunner{}
+	commits, err := runner.GetCommits(context.Background(), wtDir)
+	if err != nil {
+		t.Fatalf("GetCommits() error: %v", err)
+	}
+
+	if len(commits) != 2 {
+		t.Fatalf("GetCommits() returned %d commits, want 2: %v", len(commits), commits)
+	}
+	if commits[0].Subject != "Add one" || commits[0].Author != "Test" {
+		t.Errorf("commits[0] = %+v, want Add one by Test", commits[0])
+	}
+	if commits[1].Subject != "Add two" || commits[1].Author != "Other Author" {
+		t.Errorf("commits[1] = %+v, want Add two by Other Author", commits[1])
+	}
+	for _, c := range commits {
+		if len(c.SHA) != 40 {
+			t.Errorf("SHA = %q, want full hash", c.SHA)
+		}
+	}
+
+	// The review includes both commits.
+	executor := NewMergeExecutor()
+	review, err := executor.generateReview(context.Background(), wtDir)
+	if err != nil {
+		t.Fatalf("generateReview() error: %v", err)
+	}
+	output := formatReviewOutput(review)
+	for _, subject := range []string{"Add one", "Add two"} {
+		if !strings.Contains(output, subject) {
+			t.Errorf("review output missing commit %q:\n%s", subject, output)
+		}
+	}
+}
+
+func TestDefaultMergeRunner_GetCommits_NoBaseBranch(t *testing.T) {
+	repoDir := t.TempDir()
+	if err := exec.Command("git", "init", "-b", "trunk", repoDir).Run(); err != nil {
+		t.Skipf("git init failed: %v", err)
+	}
+
+	runner := &DefaultMergeRunner{}
+	commits, err := runner.GetCommits(context.Background(), repoDir)
+	if err != nil {
+		t.Fatalf("GetCommits() error: %v", err)
+	}
+	if len(commits) != 0 {
+		t.Errorf("GetCommits() = %v, want none", commits)
+	}
+}
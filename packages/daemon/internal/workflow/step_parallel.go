@@ -0,0 +1,215 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// executeParallel runs a parallel step's nested steps concurrently through
+// execute, at most the step's max_concurrency at a time, and waits for all of
+// them. With fail_fast, the first nested step to fail cancels the rest.
+//
+// Each nested step runs on its own copy of the context, so siblings don't see
+// each other's outputs. Once all have finished, the variables they added are
+// copied back in step order, and their results are stored under the parallel
+// step's name (e.g. {{.checks.lint.status}}). The combined result succeeds
+// only if every nested step did; its output has a section per nested step.
+func executeParallel(ctx context.Context, step *grimoire.Step, stepCtx *StepContext, execute func(context.Context, *grimoire.Step, *StepContext) (*StepResult, error)) (*StepResult, error) {
+	if step.Type != grimoire.StepTypeParallel {
+		return nil, fmt.Errorf("expected parallel step, got %s", step.Type)
+	}
+	if len(step.Steps) == 0 {
+		return nil, fmt.Errorf("parallel step %q has no nested steps", step.Name)
+	}
+
+	// A timeout, if set, covers all of the nested steps
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var timeout time.Duration
+	if step.Timeout != "" {
+		var err error
+		if timeout, err = step.GetTimeout(); err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		execCtx, cancel = context.WithTimeout(execCtx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	results := make([]*StepResult, len(step.Steps))
+	contexts := make([]*StepContext, len(step.Steps))
+	sem := make(chan struct{}, step.GetMaxConcurrency())
+
+	var wg sync.WaitGroup
+	for i := range step.Steps {
+		contexts[i] = stepCtx.forParallelStep()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i] = executeParallelChild(execCtx, &step.Steps[i], contexts[i], execute)
+			if step.FailFast && !results[i].Success && !results[i].Skipped && !results[i].AllowedFailure {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Stopping the workflow stops the step without a result
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	keys := make(map[string]bool, len(stepCtx.Variables))
+	for name := range stepCtx.Variables {
+		keys[name] = true
+	}
+
+	var output strings.Builder
+	var failures []string
+	var firstFailure *StepResult
+	var renderedOutputs map[string]string
+	children := make(map[string]*StepResult, len(step.Steps))
+	childOutputs := make(map[string]interface{}, len(step.Steps))
+
+	for i := range step.Steps {
+		child, result := &step.Steps[i], results[i]
+		children[child.Name] = result
+		childOutputs[child.Name] = toTemplateValue(newStepOutput(result))
+		stepCtx.mergeParallelStep(contexts[i], keys)
+
+		for name, value := range result.RenderedOutputs {
+			if renderedOutputs == nil {
+				renderedOutputs = make(map[string]string)
+			}
+			renderedOutputs[name] = value
+		}
+
+		fmt.Fprintf(&output, "=== %s ===\n", child.Name)
+		if result.Output != "" {
+			output.WriteString(strings.TrimRight(result.Output, "\n"))
+			output.WriteString("\n")
+		}
+
+		if !result.Success && !result.Skipped && !result.AllowedFailure {
+			failures = append(failures, fmt.Sprintf("%s: %s", child.Name, result.Error))
+			if firstFailure == nil {
+				firstFailure = result
+			}
+		}
+	}
+	stepCtx.SetVariable(step.Name, childOutputs)
+
+	combined := &StepResult{
+		Success:         len(failures) == 0,
+		Output:          strings.TrimRight(output.String(), "\n"),
+		Duration:        time.Since(start),
+		Action:          ActionContinue,
+		Children:        children,
+		RenderedOutputs: renderedOutputs,
+	}
+	if firstFailure != nil {
+		combined.ExitCode = firstFailure.ExitCode
+		combined.Error = fmt.Sprintf("%d of %d parallel steps failed: %s", len(failures), len(step.Steps), strings.Join(failures, "; "))
+		combined.Action = firstFailure.Action
+		combined.Escalation = firstFailure.Escalation
+		combined.TimedOut = firstFailure.TimedOut
+	}
+	if errors.Is(execCtx.Err(), context.DeadlineExceeded) {
+		combined.Success = false
+		combined.Error = fmt.Sprintf("parallel step timed out after %s", timeout)
+		combined.Action = ActionFail
+		combined.TimedOut = true
+	}
+	return combined, nil
+}
+
+// executeParallelChild runs one of a parallel step's nested steps and returns
+// its result. A nested step that can't run, or that is cancelled, fails
+// rather than stopping its siblings.
+func executeParallelChild(ctx context.Context, child *grimoire.Step, childCtx *StepContext, execute func(context.Context, *grimoire.Step, *StepContext) (*StepResult, error)) *StepResult {
+	if child.When != "" {
+		shouldSkip, err := ShouldSkipStep(child.When, childCtx)
+		if err != nil {
+			return &StepResult{Success: false, ExitCode: -1, Error: fmt.Sprintf("failed to evaluate condition: %v", err), Action: ActionFail}
+		}
+		if shouldSkip {
+			return &StepResult{
+				Success: true,
+				Skipped: true,
+				Output:  fmt.Sprintf("skipped: condition %q evaluated to false", child.When),
+				Action:  ActionContinue,
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return &StepResult{Success: false, ExitCode: -1, Error: "cancelled: " + ctx.Err().Error(), Action: ActionFail}
+	}
+
+	start := time.Now()
+	result, err := executeTrackingFiles(ctx, child, childCtx, execute)
+	result, err = allowStepFailure(child, result, err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return &StepResult{Success: false, ExitCode: -1, Error: "cancelled: " + ctx.Err().Error(), Duration: time.Since(start), Action: ActionFail}
+		}
+		return &StepResult{Success: false, ExitCode: -1, Error: err.Error(), Duration: time.Since(start), Action: ActionFail}
+	}
+	result.NormalizeAction()
+	if result.Duration == 0 {
+		result.Duration = time.Since(start)
+	}
+
+	// Store the output under its name, as the engine does for top-level
+	// steps. Agent steps and templated names store their own
+	if child.Output != "" && !IsTemplatedOutputName(child.Output) && child.Type != grimoire.StepTypeAgent {
+		childCtx.SetVariable(child.Output, result.Output)
+		result.RenderedOutputs = map[string]string{child.Output: result.Output}
+	}
+	return result
+}
+
+// forParallelStep returns a copy of the context for one of a parallel step's
+// nested steps to run on. Only one agent's task ID can be tracked for
+// reconnecting after a restart, so the copy doesn't report it.
+func (c *StepContext) forParallelStep() *StepContext {
+	child := *c
+	child.Variables = make(map[string]interface{}, len(c.Variables))
+	for name, value := range c.Variables {
+		child.Variables[name] = value
+	}
+	child.outputNames = make(map[string]string, len(c.outputNames))
+	for name, owner := range c.outputNames {
+		child.outputNames[name] = owner
+	}
+	child.OnActiveStepTaskIDChange = nil
+	return &child
+}
+
+// mergeParallelStep copies the variables and output names a nested step added
+// to child back into c. Variables in existing, those c had before the
+// parallel step ran, and the nested step's previous result are left alone.
+func (c *StepContext) mergeParallelStep(child *StepContext, existing map[string]bool) {
+	for name, value := range child.Variables {
+		if !existing[name] && name != "previous" {
+			c.Variables[name] = value
+		}
+	}
+	for name, owner := range child.outputNames {
+		if _, ok := c.outputNames[name]; !ok {
+			if c.outputNames == nil {
+				c.outputNames = make(map[string]string)
+			}
+			c.outputNames[name] = owner
+		}
+	}
+}
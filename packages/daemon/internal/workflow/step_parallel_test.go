@@ -0,0 +1,247 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// parallelChecks is a parallel step running lint, test and typecheck.
+func parallelChecks(maxConcurrency int, failFast bool) *grimoire.Step {
+	return &grimoire.Step{
+		Name:           "checks",
+		Type:           grimoire.StepTypeParallel,
+		MaxConcurrency: maxConcurrency,
+		FailFast:       failFast,
+		Steps: []grimoire.Step{
+			{Name: "lint", Type: grimoire.StepTypeScript, Command: "lint"},
+			{Name: "test", Type: grimoire.StepTypeScript, Command: "test", Output: "test_output"},
+			{Name: "typecheck", Type: grimoire.StepTypeScript, Command: "typecheck"},
+		},
+	}
+}
+
+func TestExecuteParallel_RunsConcurrently(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		running  int
+		maxSeen  int
+		started  = make(chan struct{}, 3)
+		release  = make(chan struct{})
+		commands []string
+	)
+	execute := func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		mu.Lock()
+		running++
+		if running > maxSeen {
+			maxSeen = running
+		}
+		commands = append(commands, step.Command)
+		mu.Unlock()
+
+		started <- struct{}{}
+		<-release
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &StepResult{Success: true, Output: step.Command + " ok", Action: ActionContinue}, nil
+	}
+
+	stepCtx := NewStepContext("/worktree", "bead-1", "wf-1")
+	done := make(chan *StepResult)
+	go func() {
+		result, err := executeParallel(context.Background(), parallelChecks(0, false), stepCtx, execute)
+		if err != nil {
+			t.Errorf("executeParallel() error: %v", err)
+		}
+		done <- result
+	}()
+
+	// All three start before any finishes
+	for i := 0; i < 3; i++ {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d nested steps started", i)
+		}
+	}
+	close(release)
+	result := <-done
+
+	if maxSeen != 3 {
+		t.Errorf("%d nested steps ran at once, want 3", maxSeen)
+	}
+	if !result.Success || len(result.Children) != 3 {
+		t.Fatalf("result = %+v, want success with 3 children", result)
+	}
+	if !strings.Contains(result.Output, "=== test ===\ntest ok") {
+		t.Errorf("Output = %q, want a section per nested step", result.Output)
+	}
+
+	// Results are stored under the parallel step's name, and outputs under
+	// their names
+	status, err := stepCtx.GetPathString("checks.lint.status")
+	if err != nil || status != "success" {
+		t.Errorf("checks.lint.status = %q, %v; want success", status, err)
+	}
+	if got := stepCtx.GetVariable("test_output"); got != "test ok" {
+		t.Errorf("test_output = %v, want %q", got, "test ok")
+	}
+	if result.RenderedOutputs["test_output"] != "test ok" {
+		t.Errorf("RenderedOutputs = %v, want test_output", result.RenderedOutputs)
+	}
+}
+
+func TestExecuteParallel_MaxConcurrency(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		running int
+		maxSeen int
+	)
+	execute := func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		mu.Lock()
+		running++
+		if running > maxSeen {
+			maxSeen = running
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return &StepResult{Success: true, Action: ActionContinue}, nil
+	}
+
+	result, err := executeParallel(context.Background(), parallelChecks(1, false), NewStepContext("/worktree", "bead-1", "wf-1"), execute)
+	if err != nil {
+		t.Fatalf("executeParallel() error: %v", err)
+	}
+	if !result.Success {
+		t.Errorf("result = %+v, want success", result)
+	}
+	if maxSeen != 1 {
+		t.Errorf("%d nested steps ran at once, want 1", maxSeen)
+	}
+}
+
+func TestExecuteParallel_Failure(t *testing.T) {
+	var mu sync.Mutex
+	ran := make(map[string]bool)
+	execute := func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		mu.Lock()
+		ran[step.Name] = true
+		mu.Unlock()
+		if step.Name == "test" {
+			return &StepResult{Success: false, ExitCode: 1, Error: "2 tests failed", Action: ActionFail}, nil
+		}
+		return &StepResult{Success: true, Action: ActionContinue}, nil
+	}
+
+	stepCtx := NewStepContext("/worktree", "bead-1", "wf-1")
+	result, err := executeParallel(context.Background(), parallelChecks(0, false), stepCtx, execute)
+	if err != nil {
+		t.Fatalf("executeParallel() error: %v", err)
+	}
+	if result.Success || result.Action != ActionFail || result.ExitCode != 1 {
+		t.Errorf("result = %+v, want a failure", result)
+	}
+	if result.Error != "1 of 3 parallel steps failed: test: 2 tests failed" {
+		t.Errorf("Error = %q", result.Error)
+	}
+	// Without fail_fast the other nested steps still run to completion
+	if len(ran) != 3 || !result.Children["lint"].Success || !result.Children["typecheck"].Success {
+		t.Errorf("ran %v, children %+v; want all three run", ran, result.Children)
+	}
+	if status, _ := stepCtx.GetPathString("checks.test.status"); status != "failed" {
+		t.Errorf("checks.test.status = %q, want failed", status)
+	}
+}
+
+func TestExecuteParallel_FailFast(t *testing.T) {
+	execute := func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		if step.Name == "lint" {
+			return &StepResult{Success: false, ExitCode: 1, Error: "lint errors", Action: ActionFail}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Second):
+			return &StepResult{Success: true, Action: ActionContinue}, nil
+		}
+	}
+
+	start := time.Now()
+	result, err := executeParallel(context.Background(), parallelChecks(0, true), NewStepContext("/worktree", "bead-1", "wf-1"), execute)
+	if err != nil {
+		t.Fatalf("executeParallel() error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("parallel step took %s, want the failure to cancel the others", elapsed)
+	}
+	if result.Success {
+		t.Fatal("result succeeded, want failure")
+	}
+	if lint := result.Children["lint"]; lint.Error != "lint errors" {
+		t.Errorf("lint = %+v, want its own failure", lint)
+	}
+	for _, name := range []string{"test", "typecheck"} {
+		if child := result.Children[name]; child.Success || !strings.Contains(child.Error, "cancelled") {
+			t.Errorf("%s = %+v, want cancelled", name, child)
+		}
+	}
+}
+
+func TestExecuteParallel_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	execute := func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+		cancel()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	if _, err := executeParallel(ctx, parallelChecks(0, false), NewStepContext("/worktree", "bead-1", "wf-1"), execute); err != context.Canceled {
+		t.Errorf("executeParallel() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestEngine_Execute_ParallelStep(t *testing.T) {
+	worktree := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "bead-1",
+	})
+	g := &grimoire.Grimoire{
+		Name: "checks",
+		Steps: []grimoire.Step{
+			{
+				Name: "checks",
+				Type: grimoire.StepTypeParallel,
+				Steps: []grimoire.Step{
+					{Name: "lint", Type: grimoire.StepTypeScript, Command: "echo linted"},
+					{Name: "test", Type: grimoire.StepTypeScript, Command: "echo tested", Output: "test_output"},
+				},
+			},
+			{Name: "report", Type: grimoire.StepTypeScript, Command: "echo {{.checks.lint.status}} {{.test_output}}", Output: "report"},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+	checks := result.StepResults["checks"]
+	if checks == nil || len(checks.Children) != 2 || !checks.Children["test"].Success {
+		t.Fatalf("checks = %+v, want both nested results", checks)
+	}
+	if got := strings.TrimSpace(result.StepResults["report"].Output); got != "success tested" {
+		t.Errorf("report output = %q, want %q", got, "success tested")
+	}
+}
@@ -0,0 +1,87 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// StepRegistry maps step types to the executors that run them. Every step an
+// engine runs, at the top level and nested in loops and parallel steps, is
+// dispatched through its registry.
+type StepRegistry struct {
+	mu        sync.RWMutex
+	executors map[grimoire.StepType]StepExecutor
+}
+
+// NewStepRegistry creates an empty step registry.
+func NewStepRegistry() *StepRegistry {
+	return &StepRegistry{executors: make(map[grimoire.StepType]StepExecutor)}
+}
+
+// Register adds a step type run by executor, so grimoires can use step types
+// defined outside coven, such as one that calls a webhook. The type becomes
+// valid in grimoires, and its steps run limited by the step's timeout. A
+// step's with settings are for the executor to read.
+//
+// Registering a type again replaces its executor. An empty or built-in type,
+// or a nil executor, is an error.
+func (r *StepRegistry) Register(stepType grimoire.StepType, executor StepExecutor) error {
+	if executor == nil {
+		return fmt.Errorf("step type %q: executor is required", stepType)
+	}
+	if err := grimoire.RegisterStepType(stepType); err != nil {
+		return err
+	}
+	r.set(stepType, timeoutExecutor{executor: executor})
+	return nil
+}
+
+// set makes executor run steps of stepType, replacing any executor it had.
+func (r *StepRegistry) set(stepType grimoire.StepType, executor StepExecutor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executors[stepType] = executor
+}
+
+// Execute runs a step with the executor registered for its type.
+func (r *StepRegistry) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+	r.mu.RLock()
+	executor, ok := r.executors[step.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown step type: %s", step.Type)
+	}
+	return executor.Execute(ctx, step, stepCtx)
+}
+
+// stepFunc adapts a function to a StepExecutor.
+type stepFunc func(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error)
+
+// Execute calls f.
+func (f stepFunc) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+	return f(ctx, step, stepCtx)
+}
+
+// timeoutExecutor runs a registered step type's steps within their timeout.
+type timeoutExecutor struct {
+	executor StepExecutor
+}
+
+// Execute runs the step with its timeout applied. A step that fails once its
+// timeout has passed is marked as timed out.
+func (e timeoutExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+	timeout, err := step.GetTimeout()
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := e.executor.Execute(ctx, step, stepCtx)
+	if result != nil && !result.Success && ctx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+	}
+	return result, err
+}
@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// getStepExecutor is an out-of-tree step type that requests the URL in its
+// with settings and succeeds on a 2xx response.
+type getStepExecutor struct{}
+
+func (getStepExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
+	url, _ := step.With["url"].(string)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return &StepResult{Success: false, Error: err.Error(), Action: ActionFail}, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	result := &StepResult{Success: true, Output: string(body), Action: ActionContinue}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		result.Success = false
+		result.Action = ActionFail
+		result.Error = fmt.Sprintf("request failed with status %d", resp.StatusCode)
+	}
+	return result, nil
+}
+
+func TestRegisterStepType_RunsThroughEngine(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ping" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "pong")
+	}))
+	defer server.Close()
+
+	worktree := t.TempDir()
+	config := EngineConfig{
+		CovenDir:     t.TempDir(),
+		WorktreePath: worktree,
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+	}
+	engine := NewEngine(config)
+	if err := engine.RegisterStepType("http-get", getStepExecutor{}); err != nil {
+		t.Fatalf("RegisterStepType() error: %v", err)
+	}
+
+	g, err := grimoire.Parse([]byte(`name: http-get-test
+description: Calls a server from a custom step
+steps:
+  - name: ping
+    type: http-get
+    with:
+      url: ` + server.URL + `/ping
+    output: reply
+  - name: poll
+    type: loop
+    max_iterations: 1
+    steps:
+      - name: missing
+        type: http-get
+        with:
+          url: ` + server.URL + `/missing
+        on_fail: continue
+  - name: record
+    type: script
+    command: "echo {{.reply}} > reply.txt"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q (error: %v)", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	if ping := result.StepResults["ping"]; ping == nil || !ping.Success || ping.Output != "pong" {
+		t.Errorf("ping = %+v, want success with output pong", ping)
+	}
+	it := result.StepResults["poll"].Iterations[0]
+	if len(it.Steps) != 1 || it.Steps[0].Success || !strings.Contains(it.Steps[0].Error, "404") {
+		t.Errorf("poll iteration = %+v, want the nested http step to fail with 404", it)
+	}
+	data, err := os.ReadFile(filepath.Join(worktree, "reply.txt"))
+	if err != nil || strings.TrimSpace(string(data)) != "pong" {
+		t.Errorf("reply.txt = %q, %v, want pong", data, err)
+	}
+
+	// The type is registered on that engine only
+	config.WorkflowID = "test-wf-2"
+	other := NewEngine(config).Execute(context.Background(), g)
+	if other.Status != WorkflowFailed || other.Error == nil || !strings.Contains(other.Error.Error(), "unknown step type") {
+		t.Errorf("other engine = %q (error: %v), want it to fail on an unknown step type", other.Status, other.Error)
+	}
+}
+
+func TestRegisterStepType_Errors(t *testing.T) {
+	engine := NewEngine(EngineConfig{CovenDir: t.TempDir()})
+	if err := engine.RegisterStepType(grimoire.StepTypeScript, getStepExecutor{}); err == nil {
+		t.Error("RegisterStepType() should refuse to replace a built-in step type")
+	}
+	if err := engine.RegisterStepType("", getStepExecutor{}); err == nil {
+		t.Error("RegisterStepType() should refuse an empty step type")
+	}
+	if err := engine.RegisterStepType("webhook", nil); err == nil {
+		t.Error("RegisterStepType() should refuse a nil executor")
+	}
+
+	step := grimoire.Step{Name: "notify", Type: "webhook"}
+	if err := step.Validate(); !grimoire.IsInvalidStepType(err) {
+		t.Errorf("Validate() error = %v, want an invalid step type for an unregistered type", err)
+	}
+}
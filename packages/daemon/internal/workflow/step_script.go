@@ -3,27 +3,56 @@ package workflow
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"text/template"
+	"text/template/parse"
 	"time"
 
+	"github.com/coven/daemon/internal/backoff"
 	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/secrets"
+	"github.com/coven/daemon/internal/spell"
 )
 
+// PreviousJSONEnvVar is the environment variable that holds the previous
+// step's JSON output for script steps with previous_json_env set.
+const PreviousJSONEnvVar = "COVEN_PREVIOUS_JSON"
+
 // CommandRunner executes shell commands.
 // This interface allows for mocking in tests.
 type CommandRunner interface {
 	Run(ctx context.Context, workDir, command string) (stdout, stderr string, exitCode int, err error)
 }
 
+// EnvCommandRunner is a CommandRunner that can add variables to the
+// command's environment.
+type EnvCommandRunner interface {
+	CommandRunner
+	RunWithEnv(ctx context.Context, workDir, command string, env []string) (stdout, stderr string, exitCode int, err error)
+}
+
 // DefaultCommandRunner is the default implementation using exec.Command.
 type DefaultCommandRunner struct{}
 
 // Run executes a shell command and returns its output.
 func (r *DefaultCommandRunner) Run(ctx context.Context, workDir, command string) (stdout, stderr string, exitCode int, err error) {
+	return r.RunWithEnv(ctx, workDir, command, nil)
+}
+
+// RunWithEnv executes a shell command with env added to the daemon's
+// environment and returns its output.
+func (r *DefaultCommandRunner) RunWithEnv(ctx context.Context, workDir, command string, env []string) (stdout, stderr string, exitCode int, err error) {
 	cmd := exec.CommandContext(ctx, "sh", "-c", command)
 	cmd.Dir = workDir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	var stdoutBuf, stderrBuf bytes.Buffer
 	cmd.Stdout = &stdoutBuf
@@ -50,24 +79,42 @@ func (r *DefaultCommandRunner) Run(ctx context.Context, workDir, command string)
 
 // ScriptExecutor executes script steps.
 type ScriptExecutor struct {
-	runner CommandRunner
+	runner        CommandRunner
+	sandboxRunner func(sandbox *grimoire.Sandbox) CommandRunner
+	policy        *CommandPolicy
 }
 
 // NewScriptExecutor creates a new script executor.
 func NewScriptExecutor() *ScriptExecutor {
-	return &ScriptExecutor{
-		runner: &DefaultCommandRunner{},
-	}
+	return NewScriptExecutorWithRunner(&DefaultCommandRunner{})
 }
 
 // NewScriptExecutorWithRunner creates a script executor with a custom command runner.
 // This is useful for testing.
 func NewScriptExecutorWithRunner(runner CommandRunner) *ScriptExecutor {
 	return &ScriptExecutor{
-		runner: runner,
+		runner:        runner,
+		sandboxRunner: containerRunner,
 	}
 }
 
+// containerRunner returns the runner for a sandboxed step.
+func containerRunner(sandbox *grimoire.Sandbox) CommandRunner {
+	return NewContainerCommandRunner(sandbox)
+}
+
+// SetSandboxRunner sets how the runner for a step with a sandbox is made,
+// in place of running its command in a container.
+func (e *ScriptExecutor) SetSandboxRunner(newRunner func(sandbox *grimoire.Sandbox) CommandRunner) {
+	e.sandboxRunner = newRunner
+}
+
+// SetPolicy sets the policy commands must pass before they run.
+// A nil policy allows every command.
+func (e *ScriptExecutor) SetPolicy(policy *CommandPolicy) {
+	e.policy = policy
+}
+
 // Execute runs a script step and returns the result.
 func (e *ScriptExecutor) Execute(ctx context.Context, step *grimoire.Step, stepCtx *StepContext) (*StepResult, error) {
 	if step.Type != grimoire.StepTypeScript {
@@ -84,19 +131,87 @@ func (e *ScriptExecutor) Execute(ctx context.Context, step *grimoire.Step, stepC
 		return nil, fmt.Errorf("invalid timeout: %w", err)
 	}
 
-	// Create context with timeout
+	retryBackoff, err := step.GetRetryBackoff()
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry_backoff: %w", err)
+	}
+
+	// Create context with timeout, which covers every attempt
 	execCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	// Render command with variable substitution and escaping
-	command, err := RenderCommand(step.Command, stepCtx.Variables)
+	variables := stepCtx.ToMap()
+	command, err := RenderCommand(step.Command, variables)
+	if err != nil {
+		return nil, newRenderError(step, "command", step.Command, err)
+	}
+	displayCommand, err := RenderCommandRedacted(step.Command, variables)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render command: %w", err)
+		return nil, newRenderError(step, "command", step.Command, err)
 	}
 
-	// Execute the command
+	// Refuse commands the policy doesn't permit, whatever the step's on_fail
+	if err := e.policy.Check(command); err != nil {
+		return &StepResult{
+			Success:  false,
+			ExitCode: -1,
+			Error:    err.Error(),
+			Action:   ActionFail,
+			Command:  displayCommand,
+		}, nil
+	}
+
+	var env []string
+	if step.EnvFile != "" {
+		env, err = loadEnvFile(step, stepCtx.WorktreePath, variables)
+		var renderErr *RenderError
+		if errors.As(err, &renderErr) {
+			return nil, err
+		}
+		if err != nil {
+			return &StepResult{
+				Success:  false,
+				ExitCode: -1,
+				Error:    err.Error(),
+				Action:   ActionFail,
+				Command:  displayCommand,
+			}, nil
+		}
+	}
+	// The step's own variables override the env_file's
+	stepEnv, err := renderEnv(step, variables)
+	if err != nil {
+		return nil, err
+	}
+	env = append(env, stepEnv...)
+	if step.PreviousJSONEnv {
+		if previous, ok := previousJSON(stepCtx); ok {
+			env = append(env, PreviousJSONEnvVar+"="+previous)
+		}
+	}
+
+	// Execute the command, retrying a failure up to the step's retries
+	runner := e.runnerFor(step)
+	retryPolicy := backoff.Policy{Initial: retryBackoff}
 	start := time.Now()
-	stdout, stderr, exitCode, err := e.runner.Run(execCtx, stepCtx.WorktreePath, command)
+	var stdout, stderr string
+	var exitCode, attempts int
+	for {
+		attempts++
+		stdout, stderr, exitCode, err = e.run(execCtx, runner, stepCtx.WorktreePath, command, env)
+		if (err == nil && exitCode == 0) || attempts > step.Retries || execCtx.Err() != nil {
+			break
+		}
+
+		select {
+		case <-execCtx.Done():
+		case <-time.After(retryPolicy.Delay(attempts - 1)):
+		}
+		if execCtx.Err() != nil {
+			break
+		}
+	}
 	duration := time.Since(start)
 
 	// Check for timeout
@@ -106,8 +221,11 @@ func (e *ScriptExecutor) Execute(ctx context.Context, step *grimoire.Step, stepC
 			Output:   combineOutput(stdout, stderr),
 			ExitCode: -1,
 			Error:    fmt.Sprintf("step timed out after %s", timeout),
+			Attempts: attempts,
 			Duration: duration,
-			Action:   ActionFail,
+			Action:   timeoutAction(step),
+			Command:  displayCommand,
+			TimedOut: true,
 		}, nil
 	}
 
@@ -118,8 +236,10 @@ func (e *ScriptExecutor) Execute(ctx context.Context, step *grimoire.Step, stepC
 			Output:   combineOutput(stdout, stderr),
 			ExitCode: exitCode,
 			Error:    fmt.Sprintf("failed to execute command: %v", err),
+			Attempts: attempts,
 			Duration: duration,
 			Action:   ActionFail,
+			Command:  displayCommand,
 		}, nil
 	}
 
@@ -129,17 +249,76 @@ func (e *ScriptExecutor) Execute(ctx context.Context, step *grimoire.Step, stepC
 	// Determine action based on success and handlers
 	action := e.determineAction(success, step)
 
-	return &StepResult{
+	result := &StepResult{
 		Success:  success,
 		Output:   combineOutput(stdout, stderr),
 		ExitCode: exitCode,
+		Attempts: attempts,
 		Duration: duration,
 		Action:   action,
-	}, nil
+		Command:  displayCommand,
+	}
+
+	// Attach the escalation payload for on_fail: escalate
+	if !success && step.OnFail == string(grimoire.OnFailEscalate) {
+		result.Escalation = NewEscalation(step, result)
+	}
+
+	return result, nil
+}
+
+// runnerFor returns the runner for the step's command: a sandboxed one if
+// the step has a sandbox, or the executor's runner otherwise.
+func (e *ScriptExecutor) runnerFor(step *grimoire.Step) CommandRunner {
+	if step.Sandbox != nil {
+		return e.sandboxRunner(step.Sandbox)
+	}
+	return e.runner
+}
+
+// run executes the command, adding env to its environment when there is any.
+func (e *ScriptExecutor) run(ctx context.Context, runner CommandRunner, workDir, command string, env []string) (string, string, int, error) {
+	if len(env) == 0 {
+		return runner.Run(ctx, workDir, command)
+	}
+	envRunner, ok := runner.(EnvCommandRunner)
+	if !ok {
+		return "", "", -1, fmt.Errorf("command runner does not support environment variables")
+	}
+	return envRunner.RunWithEnv(ctx, workDir, command, env)
+}
+
+// previousJSON returns the previous step's output if it is a JSON object, or
+// the last JSON object in it, such as the result block an agent prints.
+func previousJSON(stepCtx *StepContext) (string, bool) {
+	previous, ok := stepCtx.Variables["previous"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	output, _ := previous["output"].(string)
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return "", false
+	}
+
+	var obj map[string]interface{}
+	if json.Unmarshal([]byte(output), &obj) == nil {
+		return output, true
+	}
+	if blocks := extractJSONBlocks(output); len(blocks) > 0 {
+		return blocks[len(blocks)-1], true
+	}
+	return "", false
 }
 
 // determineAction determines the workflow action based on step outcome and handlers.
 func (e *ScriptExecutor) determineAction(success bool, step *grimoire.Step) StepAction {
+	return handlerAction(success, step)
+}
+
+// handlerAction returns the action for a script or http step's outcome,
+// following its on_success and on_fail handlers.
+func handlerAction(success bool, step *grimoire.Step) StepAction {
 	if success {
 		// Handle on_success
 		switch step.OnSuccess {
@@ -154,7 +333,7 @@ func (e *ScriptExecutor) determineAction(success bool, step *grimoire.Step) Step
 	switch step.OnFail {
 	case "continue":
 		return ActionContinue
-	case "block":
+	case "block", "escalate":
 		return ActionBlock
 	case "exit", "fail":
 		return ActionFail
@@ -179,9 +358,39 @@ func combineOutput(stdout, stderr string) string {
 }
 
 // RenderCommand renders a command template with variable substitution.
-// Variables are shell-escaped to prevent command injection.
+// Variables are shell-escaped to prevent command injection. Tags that call
+// one of the spell template functions, such as {{ now | date "2006-01-02" }}
+// or {{ .title | truncate 20 }}, are evaluated and their result
+// shell-escaped the same way.
 func RenderCommand(command string, variables map[string]interface{}) (string, error) {
-	result := command
+	return renderCommand(command, variables, true)
+}
+
+// RenderCommandRedacted renders a command like RenderCommand, but substitutes
+// secrets.Redacted for secret values, so the result is safe to store and show.
+func RenderCommandRedacted(command string, variables map[string]interface{}) (string, error) {
+	return renderCommand(command, variables, false)
+}
+
+// renderCommand substitutes variables into command, revealing secret values
+// only when reveal is set.
+func renderCommand(command string, variables map[string]interface{}, reveal bool) (string, error) {
+	return substituteTemplate(command, variables, reveal, func(value interface{}) string {
+		// Convert to string and shell-escape
+		strValue := fmt.Sprint(value)
+		if secret, ok := value.(*secrets.Secret); ok && reveal {
+			strValue = secret.Reveal()
+		}
+		return ShellEscape(strValue)
+	})
+}
+
+// substituteTemplate replaces each {{.variable}} in text with format applied
+// to the variable's value, and each tag calling a template function with
+// format applied to its result. Other tags are removed. The functions see
+// secret values only when reveal is set.
+func substituteTemplate(text string, variables map[string]interface{}, reveal bool, format func(interface{}) string) (string, error) {
+	result := text
 
 	// Find all {{.variable}} patterns and replace them
 	for {
@@ -192,12 +401,20 @@ func RenderCommand(command string, variables map[string]interface{}) (string, er
 
 		end := strings.Index(result[start:], "}}")
 		if end == -1 {
-			return "", fmt.Errorf("unclosed template tag at position %d", start)
+			return "", &templateTagError{Tag: truncateTag(result[start:]), Position: start}
 		}
 		end += start + 2
 
 		// Extract the variable path
 		varPath := strings.TrimSpace(result[start+2 : end-2])
+		if isFuncCall(varPath) {
+			value, err := evaluateFuncCall(varPath, variables, reveal)
+			if err != nil {
+				return "", err
+			}
+			result = result[:start] + format(value) + result[end:]
+			continue
+		}
 		if !strings.HasPrefix(varPath, ".") {
 			// Not a variable reference, skip
 			result = result[:start] + result[end:]
@@ -213,17 +430,138 @@ func RenderCommand(command string, variables map[string]interface{}) (string, er
 			return "", fmt.Errorf("failed to resolve variable %q: %w", varPath, err)
 		}
 
-		// Convert to string and shell-escape
-		strValue := fmt.Sprint(value)
-		escaped := ShellEscape(strValue)
-
 		// Replace in result
-		result = result[:start] + escaped + result[end:]
+		result = result[:start] + format(value) + result[end:]
 	}
 
 	return result, nil
 }
 
+// isFuncCall reports whether a tag's action starts with a call to one of the
+// template functions, or pipes a variable into one, as in
+// {{ .title | truncate 20 }}.
+func isFuncCall(action string) bool {
+	if strings.HasPrefix(action, ".") {
+		_, piped, ok := strings.Cut(action, "|")
+		if !ok {
+			return false
+		}
+		action = strings.TrimSpace(piped)
+	}
+	name, _, _ := strings.Cut(action, " ")
+	name, _, _ = strings.Cut(name, "|")
+	_, ok := spell.TemplateFuncs()[name]
+	return ok
+}
+
+// evaluateFuncCall executes a tag's action as a text/template pipeline. Its
+// data holds the variables the action refers to, resolved as for a plain
+// {{.variable}} tag, so missing ones are empty strings, and with secret
+// values revealed only when reveal is set.
+func evaluateFuncCall(action string, variables map[string]interface{}, reveal bool) (string, error) {
+	tmpl, err := template.New("command").Funcs(spell.TemplateFuncs()).Parse("{{" + action + "}}")
+	if err != nil {
+		return "", err
+	}
+
+	var paths [][]string
+	collectFieldPaths(tmpl.Tree.Root, &paths)
+	// Shorter paths first, so a variable and a field of it can both be set
+	sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+	data := make(map[string]interface{})
+	for _, path := range paths {
+		value, err := resolveVariable(strings.Join(path, "."), variables)
+		if err != nil {
+			return "", err
+		}
+		setFieldPath(data, path, funcValue(value, reveal))
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// collectFieldPaths appends the path of each field, such as .bead.title, that
+// node refers to.
+func collectFieldPaths(node parse.Node, paths *[][]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			collectFieldPaths(child, paths)
+		}
+	case *parse.ActionNode:
+		collectFieldPaths(n.Pipe, paths)
+	case *parse.PipeNode:
+		for _, cmd := range n.Cmds {
+			collectFieldPaths(cmd, paths)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectFieldPaths(arg, paths)
+		}
+	case *parse.ChainNode:
+		collectFieldPaths(n.Node, paths)
+	case *parse.FieldNode:
+		*paths = append(*paths, n.Ident)
+	}
+}
+
+// setFieldPath sets the value at path in data, creating the maps along it.
+func setFieldPath(data map[string]interface{}, path []string, value interface{}) {
+	for _, part := range path[:len(path)-1] {
+		next, ok := data[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			data[part] = next
+		}
+		data = next
+	}
+	data[path[len(path)-1]] = value
+}
+
+// funcValue returns a copy of value for template functions, with secrets
+// replaced by their values when reveal is set and by secrets.Redacted
+// otherwise. Maps are copied, so setting fields in them leaves the variables
+// unchanged.
+func funcValue(value interface{}, reveal bool) interface{} {
+	switch v := value.(type) {
+	case *secrets.Secret:
+		if reveal {
+			return v.Reveal()
+		}
+		return secrets.Redacted
+	case secrets.Map:
+		m := make(map[string]interface{}, len(v))
+		for key, secret := range v {
+			m[key] = funcValue(secret, reveal)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[key] = funcValue(val, reveal)
+		}
+		return m
+	default:
+		return value
+	}
+}
+
+// truncateTag shortens an unclosed tag and the text after it for display.
+func truncateTag(tag string) string {
+	const maxLen = 40
+	if line, _, found := strings.Cut(tag, "\n"); found {
+		tag = line
+	}
+	if len(tag) > maxLen {
+		return tag[:maxLen] + "..."
+	}
+	return tag
+}
+
 // resolveVariable resolves a dot-separated variable path.
 func resolveVariable(path string, variables map[string]interface{}) (interface{}, error) {
 	parts := strings.Split(path, ".")
@@ -243,6 +581,12 @@ func resolveVariable(path string, variables map[string]interface{}) (interface{}
 				return "", nil
 			}
 			current = val
+		case secrets.Map:
+			val, ok := v[part]
+			if !ok {
+				return "", nil
+			}
+			current = val
 		default:
 			return "", nil
 		}
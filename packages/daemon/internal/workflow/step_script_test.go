@@ -2,11 +2,16 @@ package workflow
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/coven/daemon/internal/grimoire"
+	"github.com/coven/daemon/internal/secrets"
 )
 
 // MockCommandRunner is a mock implementation for testing.
@@ -25,6 +30,13 @@ type MockCommandRunner struct {
 	Err error
 	// Delay is how long to wait before returning.
 	Delay time.Duration
+	// Env is the environment passed to the last RunWithEnv call.
+	Env []string
+}
+
+func (m *MockCommandRunner) RunWithEnv(ctx context.Context, workDir, command string, env []string) (string, string, int, error) {
+	m.Env = env
+	return m.Run(ctx, workDir, command)
 }
 
 func (m *MockCommandRunner) Run(ctx context.Context, workDir, command string) (string, string, int, error) {
@@ -116,6 +128,28 @@ func TestScriptExecutor_Execute_Failure(t *testing.T) {
 	}
 }
 
+func TestScriptExecutor_Execute_ContextVariables(t *testing.T) {
+	mock := &MockCommandRunner{}
+	executor := NewScriptExecutorWithRunner(mock)
+
+	step := &grimoire.Step{
+		Name:    "test",
+		Type:    grimoire.StepTypeScript,
+		Command: "notify {{.workflow.id}} {{.bead.title}} {{.build.output}}",
+	}
+	stepCtx := NewStepContext("/path/to/worktree", "bead-123", "wf-456")
+	stepCtx.SetBead(&BeadData{ID: "bead-123", Title: "Fix"})
+	stepCtx.StoreStepOutput("build", &StepResult{Success: true, Output: "done"}, "")
+
+	if _, err := executor.Execute(context.Background(), step, stepCtx); err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if mock.Command != "notify wf-456 Fix done" {
+		t.Errorf("Command = %q, want %q", mock.Command, "notify wf-456 Fix done")
+	}
+}
+
 func TestScriptExecutor_Execute_OnFail_Continue(t *testing.T) {
 	mock := &MockCommandRunner{
 		ExitCode: 1,
@@ -167,6 +201,78 @@ func TestScriptExecutor_Execute_OnFail_Block(t *testing.T) {
 	}
 }
 
+func TestScriptExecutor_Execute_OnFail_Escalate(t *testing.T) {
+	mock := &MockCommandRunner{
+		Stdout:   "FAIL: TestLogin",
+		Stderr:   "expected 200, got 500",
+		ExitCode: 2,
+	}
+	executor := NewScriptExecutorWithRunner(mock)
+
+	step := &grimoire.Step{
+		Name:    "run-tests",
+		Type:    grimoire.StepTypeScript,
+		Command: "npm test",
+		OnFail:  "escalate",
+	}
+	stepCtx := NewStepContext("/path", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if result.Action != ActionBlock {
+		t.Errorf("Action = %q, want %q", result.Action, ActionBlock)
+	}
+	if result.Escalation == nil {
+		t.Fatal("Escalation should be set")
+	}
+	if result.Escalation.StepName != "run-tests" {
+		t.Errorf("StepName = %q, want %q", result.Escalation.StepName, "run-tests")
+	}
+	if result.Escalation.ExitCode != 2 {
+		t.Errorf("ExitCode = %d, want 2", result.Escalation.ExitCode)
+	}
+	if !strings.Contains(result.Escalation.Output, "FAIL: TestLogin") {
+		t.Errorf("Output = %q, should contain stdout", result.Escalation.Output)
+	}
+	if !strings.Contains(result.Escalation.Output, "expected 200, got 500") {
+		t.Errorf("Output = %q, should contain stderr", result.Escalation.Output)
+	}
+	if !strings.Contains(result.Escalation.SuggestedAction, "npm test") {
+		t.Errorf("SuggestedAction = %q, should mention the command", result.Escalation.SuggestedAction)
+	}
+}
+
+func TestScriptExecutor_Execute_OnFail_Escalate_Success(t *testing.T) {
+	mock := &MockCommandRunner{
+		Stdout:   "ok",
+		ExitCode: 0,
+	}
+	executor := NewScriptExecutorWithRunner(mock)
+
+	step := &grimoire.Step{
+		Name:    "run-tests",
+		Type:    grimoire.StepTypeScript,
+		Command: "npm test",
+		OnFail:  "escalate",
+	}
+	stepCtx := NewStepContext("/path", "bead", "wf")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if result.Action != ActionContinue {
+		t.Errorf("Action = %q, want %q", result.Action, ActionContinue)
+	}
+	if result.Escalation != nil {
+		t.Error("Escalation should not be set on success")
+	}
+}
+
 func TestScriptExecutor_Execute_OnFail_Exit(t *testing.T) {
 	mock := &MockCommandRunner{
 		ExitCode: 1,
@@ -248,6 +354,134 @@ func TestScriptExecutor_Execute_Timeout(t *testing.T) {
 	}
 }
 
+// flakyCommandRunner fails the first Failures runs of a command and then
+// succeeds, calling OnRun after each run.
+type flakyCommandRunner struct {
+	Failures int
+	Runs     int
+	OnRun    func()
+}
+
+func (r *flakyCommandRunner) Run(ctx context.Context, workDir, command string) (string, string, int, error) {
+	r.Runs++
+	if r.OnRun != nil {
+		r.OnRun()
+	}
+	if r.Runs <= r.Failures {
+		return "", fmt.Sprintf("attempt %d failed", r.Runs), 1, nil
+	}
+	return "ok", "", 0, nil
+}
+
+func TestScriptExecutor_Execute_Retries(t *testing.T) {
+	runner := &flakyCommandRunner{Failures: 2}
+	executor := NewScriptExecutorWithRunner(runner)
+
+	step := &grimoire.Step{
+		Name:         "test",
+		Type:         grimoire.StepTypeScript,
+		Command:      "npm test",
+		Retries:      3,
+		RetryBackoff: "1ms",
+	}
+	result, err := executor.Execute(context.Background(), step, NewStepContext("/path", "bead", "wf"))
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if !result.Success || result.Output != "ok" {
+		t.Errorf("result = %+v, want success on the third attempt", result)
+	}
+	if result.Attempts != 3 || runner.Runs != 3 {
+		t.Errorf("Attempts = %d after %d runs, want 3", result.Attempts, runner.Runs)
+	}
+}
+
+func TestScriptExecutor_Execute_RetriesExhausted(t *testing.T) {
+	runner := &flakyCommandRunner{Failures: 10}
+	executor := NewScriptExecutorWithRunner(runner)
+
+	step := &grimoire.Step{
+		Name:         "test",
+		Type:         grimoire.StepTypeScript,
+		Command:      "npm test",
+		OnFail:       "continue",
+		Retries:      2,
+		RetryBackoff: "1ms",
+	}
+	result, err := executor.Execute(context.Background(), step, NewStepContext("/path", "bead", "wf"))
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if result.Success || result.Attempts != 3 {
+		t.Errorf("result = %+v, want failure after 3 attempts", result)
+	}
+	// on_fail applies once the retries are used up, to the last attempt
+	if result.Action != ActionContinue {
+		t.Errorf("Action = %q, want %q", result.Action, ActionContinue)
+	}
+	if result.Output != "attempt 3 failed" {
+		t.Errorf("Output = %q, want the last attempt's", result.Output)
+	}
+}
+
+func TestScriptExecutor_Execute_RetriesShareTimeout(t *testing.T) {
+	runner := &flakyCommandRunner{Failures: 100}
+	executor := NewScriptExecutorWithRunner(runner)
+
+	step := &grimoire.Step{
+		Name:         "test",
+		Type:         grimoire.StepTypeScript,
+		Command:      "npm test",
+		Timeout:      "200ms",
+		Retries:      100,
+		RetryBackoff: "50ms",
+	}
+	start := time.Now()
+	result, err := executor.Execute(context.Background(), step, NewStepContext("/path", "bead", "wf"))
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Execute() took %s, want the timeout to cover all attempts", elapsed)
+	}
+	if result.Success || !strings.Contains(result.Error, "timed out after 200ms") {
+		t.Errorf("result = %+v, want a timeout", result)
+	}
+	if result.Attempts < 2 || result.Attempts > 10 {
+		t.Errorf("Attempts = %d, want the few that fit in the timeout", result.Attempts)
+	}
+}
+
+func TestScriptExecutor_Execute_RetriesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	runner := &flakyCommandRunner{Failures: 10, OnRun: cancel}
+	executor := NewScriptExecutorWithRunner(runner)
+
+	step := &grimoire.Step{
+		Name:         "test",
+		Type:         grimoire.StepTypeScript,
+		Command:      "npm test",
+		Retries:      5,
+		RetryBackoff: "10s",
+	}
+	start := time.Now()
+	result, err := executor.Execute(ctx, step, NewStepContext("/path", "bead", "wf"))
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Execute() took %s, want cancellation to stop the retries", elapsed)
+	}
+	if result.Success || result.Attempts != 1 || runner.Runs != 1 {
+		t.Errorf("result = %+v after %d runs, want one failed attempt", result, runner.Runs)
+	}
+}
+
 func TestScriptExecutor_Execute_WrongStepType(t *testing.T) {
 	executor := NewScriptExecutor()
 
@@ -471,6 +705,136 @@ func TestRenderCommand(t *testing.T) {
 	}
 }
 
+func TestRenderCommand_Secrets(t *testing.T) {
+	variables := map[string]interface{}{
+		"secrets": secrets.Map{"token": secrets.New("s3cr3t value")},
+		"env":     "prod",
+	}
+	command := "deploy --env {{.env}} --token {{.secrets.token}}"
+
+	got, err := RenderCommand(command, variables)
+	if err != nil {
+		t.Fatalf("RenderCommand() error: %v", err)
+	}
+	if want := "deploy --env prod --token 's3cr3t value'"; got != want {
+		t.Errorf("RenderCommand() = %q, want %q", got, want)
+	}
+
+	got, err = RenderCommandRedacted(command, variables)
+	if err != nil {
+		t.Fatalf("RenderCommandRedacted() error: %v", err)
+	}
+	if want := "deploy --env prod --token '***'"; got != want {
+		t.Errorf("RenderCommandRedacted() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderCommand_TemplateFunctions(t *testing.T) {
+	now := time.Now()
+	variables := map[string]interface{}{"name": "release notes"}
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{`git checkout -b release-{{ now | date "2006" }}`, "git checkout -b release-" + now.Format("2006")},
+		{`echo {{ now | date "2006 MST" }}`, "echo '" + now.Format("2006 MST") + "'"},
+		{`git log --since={{ now | dateAdd "-8760h" | date "2006" }}`, "git log --since=" + now.Add(-8760*time.Hour).Format("2006")},
+		{`echo {{ upper .name }}`, "echo 'RELEASE NOTES'"},
+		{`echo {{ end }}done`, "echo done"},
+	}
+
+	for _, tt := range tests {
+		got, err := RenderCommand(tt.command, variables)
+		if err != nil {
+			t.Fatalf("RenderCommand(%q) error: %v", tt.command, err)
+		}
+		if got != tt.expected {
+			t.Errorf("RenderCommand(%q) = %q, want %q", tt.command, got, tt.expected)
+		}
+	}
+
+	if _, err := RenderCommand(`echo {{ now | dateAdd "soon" }}`, nil); err == nil {
+		t.Error("RenderCommand() with an invalid duration succeeded, want an error")
+	}
+}
+
+func TestRenderCommand_PipedVariables(t *testing.T) {
+	variables := map[string]interface{}{
+		"bead": map[string]interface{}{"title": "Don't break `login`; ever"},
+		"review": map[string]interface{}{
+			"output": `{"files": ["a.go", "b c.go"], "summary": "$(rm -rf /)"}`,
+		},
+		"files":   []interface{}{"a.go", "b.go"},
+		"secrets": secrets.Map{"token": secrets.New("s3cr3t")},
+	}
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		// Functions run on the raw value, and their result is escaped once
+		{`git commit -m {{ .bead.title | truncate 12 }}`, `git commit -m 'Don'\''t break '`},
+		{`git commit -m {{.bead.title | truncate 5 | upper}}`, `git commit -m 'DON'\''T'`},
+		{`echo {{ .review.output | jsonpath "$.summary" }}`, `echo '$(rm -rf /)'`},
+		{`gofmt -l {{ .review.output | jsonpath "$.files[1]" }}`, `gofmt -l 'b c.go'`},
+		{`echo {{ .files | join "," }}`, `echo 'a.go,b.go'`},
+		{`echo {{ .missing | default "none" }}`, `echo none`},
+		// Missing variables are empty, as in a plain tag
+		{`echo {{ .missing | upper }}`, `echo ''`},
+		{`echo {{ .bead.missing | trim }}`, `echo ''`},
+		// Functions run on revealed secrets
+		{`echo {{ .secrets.token | truncate 10 }}`, `echo s3cr3t`},
+		{`echo {{ .secrets.token | upper }}`, `echo S3CR3T`},
+	}
+
+	for _, tt := range tests {
+		got, err := RenderCommand(tt.command, variables)
+		if err != nil {
+			t.Fatalf("RenderCommand(%q) error: %v", tt.command, err)
+		}
+		if got != tt.expected {
+			t.Errorf("RenderCommand(%q) = %q, want %q", tt.command, got, tt.expected)
+		}
+	}
+
+	// The redacted command shows secrets redacted, even through functions
+	got, err := RenderCommandRedacted(`echo {{ .secrets.token | upper }}`, variables)
+	if err != nil {
+		t.Fatalf("RenderCommandRedacted() error: %v", err)
+	}
+	if got != `echo '***'` {
+		t.Errorf("RenderCommandRedacted() = %q, want %q", got, `echo '***'`)
+	}
+}
+
+func TestScriptExecutor_Execute_RecordsRenderedCommand(t *testing.T) {
+	mock := &MockCommandRunner{ExitCode: 1, Stderr: "no such release"}
+	executor := NewScriptExecutorWithRunner(mock)
+
+	step := &grimoire.Step{
+		Name:    "release",
+		Type:    grimoire.StepTypeScript,
+		Command: "./release.sh {{.bead.title}} {{.secrets.token}}",
+	}
+	stepCtx := NewStepContext("/tmp", "bead-1", "wf-1")
+	stepCtx.SetBead(&BeadData{ID: "bead-1", Title: "v1.2 it's out"})
+	stepCtx.SetVariable("secrets", secrets.Map{"token": secrets.New("hunter2")})
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Success {
+		t.Fatal("Execute() succeeded, want failure")
+	}
+
+	if want := `./release.sh 'v1.2 it'\''s out' hunter2`; mock.Command != want {
+		t.Errorf("ran %q, want %q", mock.Command, want)
+	}
+	if want := `./release.sh 'v1.2 it'\''s out' '***'`; result.Command != want {
+		t.Errorf("result.Command = %q, want %q", result.Command, want)
+	}
+}
+
 func TestCombineOutput(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -678,3 +1042,225 @@ func TestResolveVariable(t *testing.T) {
 		})
 	}
 }
+
+func TestScriptExecutor_Execute_PreviousJSONEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		previous string
+		want     string
+	}{
+		{
+			name:     "JSON output",
+			enabled:  true,
+			previous: `{"version": "1.2.3", "files": ["a.go"]}`,
+			want:     `{"version": "1.2.3", "files": ["a.go"]}`,
+		},
+		{
+			name:     "JSON block in agent output",
+			enabled:  true,
+			previous: "Done.\n```json\n{\"success\": true, \"summary\": \"ok\"}\n```",
+			want:     `{"success": true, "summary": "ok"}`,
+		},
+		{
+			name:     "plain text output",
+			enabled:  true,
+			previous: "all tests passed",
+		},
+		{
+			name:     "not enabled",
+			previous: `{"version": "1.2.3"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &MockCommandRunner{}
+			executor := NewScriptExecutorWithRunner(mock)
+
+			step := &grimoire.Step{
+				Name:            "consume",
+				Type:            grimoire.StepTypeScript,
+				Command:         "cat",
+				PreviousJSONEnv: tt.enabled,
+			}
+			stepCtx := NewStepContext("/tmp", "bead-1", "wf-1")
+			stepCtx.SetPrevious(&StepResult{Success: true, Output: tt.previous})
+
+			if _, err := executor.Execute(context.Background(), step, stepCtx); err != nil {
+				t.Fatalf("Execute() error: %v", err)
+			}
+
+			if tt.want == "" {
+				if len(mock.Env) != 0 {
+					t.Errorf("Env = %v, want none", mock.Env)
+				}
+				return
+			}
+			if len(mock.Env) != 1 || mock.Env[0] != PreviousJSONEnvVar+"="+tt.want {
+				t.Errorf("Env = %v, want [%s=%s]", mock.Env, PreviousJSONEnvVar, tt.want)
+			}
+		})
+	}
+}
+
+type runOnlyCommandRunner struct{}
+
+func (runOnlyCommandRunner) Run(ctx context.Context, workDir, command string) (string, string, int, error) {
+	return "", "", 0, nil
+}
+
+func TestScriptExecutor_Execute_PreviousJSONEnvUnsupportedRunner(t *testing.T) {
+	executor := NewScriptExecutorWithRunner(runOnlyCommandRunner{})
+	step := &grimoire.Step{Name: "consume", Type: grimoire.StepTypeScript, Command: "cat", PreviousJSONEnv: true}
+	stepCtx := NewStepContext("/tmp", "bead-1", "wf-1")
+	stepCtx.SetPrevious(&StepResult{Success: true, Output: `{"a": 1}`})
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "does not support environment variables") {
+		t.Errorf("result = %+v, want failure about environment variables", result)
+	}
+}
+
+func TestScriptExecutor_Execute_EnvFile(t *testing.T) {
+	worktree := t.TempDir()
+	content := `# Deploy settings
+DEPLOY_ENV=staging
+export REGION="eu west"
+TAG={{.bead.id}}-build  # rendered against the context
+`
+	if err := os.WriteFile(filepath.Join(worktree, "deploy.env"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	executor := NewScriptExecutor()
+	step := &grimoire.Step{
+		Name:    "deploy",
+		Type:    grimoire.StepTypeScript,
+		Command: `echo "$DEPLOY_ENV|$REGION|$TAG"`,
+		EnvFile: "deploy.env",
+	}
+	stepCtx := NewStepContext(worktree, "bead-1", "wf-1")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Execute() failed: %s", result.Error)
+	}
+	if got, want := strings.TrimSpace(result.Output), "staging|eu west|bead-1-build"; got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+}
+
+func TestScriptExecutor_Execute_Env(t *testing.T) {
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, "test.env"), []byte("NODE_ENV=development\nREGION=eu\n"), 0644); err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+	t.Setenv("COVEN_TEST_INHERITED", "inherited")
+
+	executor := NewScriptExecutor()
+	step := &grimoire.Step{
+		Name:    "test",
+		Type:    grimoire.StepTypeScript,
+		Command: `echo "$NODE_ENV|$REGION|$BEAD|$QUOTED|$COVEN_TEST_INHERITED"`,
+		EnvFile: "test.env",
+		Env: map[string]string{
+			"NODE_ENV": "test",
+			"BEAD":     "{{.bead.id}}",
+			"QUOTED":   "{{.note}}",
+		},
+	}
+	stepCtx := NewStepContext(worktree, "bead-1", "wf-1")
+	stepCtx.SetBead(&BeadData{ID: "bead-1"})
+	stepCtx.SetVariable("note", "it's $HOME")
+
+	result, err := executor.Execute(context.Background(), step, stepCtx)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Execute() failed: %s", result.Error)
+	}
+	// env overrides env_file, values are passed as they are, not shell
+	// escaped, and the daemon's environment is inherited
+	if got, want := strings.TrimSpace(result.Output), "test|eu|bead-1|it's $HOME|inherited"; got != want {
+		t.Errorf("Output = %q, want %q", got, want)
+	}
+}
+
+func TestScriptExecutor_Execute_EnvRenderError(t *testing.T) {
+	mock := &MockCommandRunner{}
+	executor := NewScriptExecutorWithRunner(mock)
+	step := &grimoire.Step{
+		Name:    "test",
+		Type:    grimoire.StepTypeScript,
+		Command: "npm test",
+		Env:     map[string]string{"TARGET": "{{.bead.id"},
+	}
+
+	_, err := executor.Execute(context.Background(), step, NewStepContext(t.TempDir(), "bead-1", "wf-1"))
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) || renderErr.Field != "env TARGET" {
+		t.Errorf("Execute() error = %v, want a render error for env TARGET", err)
+	}
+	if mock.Command != "" {
+		t.Errorf("Command = %q, the command should not run", mock.Command)
+	}
+}
+
+func TestScriptExecutor_Execute_EnvFileMissing(t *testing.T) {
+	mock := &MockCommandRunner{}
+	executor := NewScriptExecutorWithRunner(mock)
+	step := &grimoire.Step{Name: "deploy", Type: grimoire.StepTypeScript, Command: "make deploy", EnvFile: "missing.env"}
+
+	result, err := executor.Execute(context.Background(), step, NewStepContext(t.TempDir(), "bead-1", "wf-1"))
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, `env_file "missing.env" not found`) {
+		t.Errorf("result = %+v, want failure about the missing env file", result)
+	}
+	if mock.Command != "" {
+		t.Errorf("Command = %q, the command should not run", mock.Command)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{"plain", "A=1\nB=two\n", []string{"A=1", "B=two"}, false},
+		{"comments and blanks", "# comment\n\nA=1 # trailing\n", []string{"A=1"}, false},
+		{"quoted", "A=\"x # y\"\nB='z'\n", []string{"A=x # y", "B=z"}, false},
+		{"export and empty value", "export A=\nB = 2\n", []string{"A=", "B=2"}, false},
+		{"missing equals", "A=1\nBROKEN\n", nil, true},
+		{"invalid key", "1A=1\n", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEnvFile(tt.content)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseEnvFile() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEnvFile() error: %v", err)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parseEnvFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
 )
 
 // Default timeouts for different step types.
@@ -39,6 +41,15 @@ func IsTimeoutError(err error) bool {
 	return errors.As(err, &te)
 }
 
+// timeoutAction returns the action for a step that timed out: ActionBlock if
+// its timeout_action is block, ActionFail otherwise.
+func timeoutAction(step *grimoire.Step) StepAction {
+	if step.TimeoutAction == string(grimoire.TimeoutActionBlock) {
+		return ActionBlock
+	}
+	return ActionFail
+}
+
 // TimeoutManager manages timeout contexts for workflow and step execution.
 type TimeoutManager struct {
 	// workflowStart is when the workflow started.
@@ -9,7 +9,8 @@ type StepResult struct {
 	// Success indicates whether the step completed successfully.
 	Success bool
 
-	// Skipped indicates whether the step was skipped due to a 'when' condition.
+	// Skipped indicates whether the step was skipped due to a 'when' condition
+	// or manually skipped while the workflow was blocked on it.
 	Skipped bool
 
 	// Output is the captured output from the step.
@@ -20,6 +21,24 @@ type StepResult struct {
 	// ExitCode is the exit code for script steps (0 = success).
 	ExitCode int
 
+	// StatusCode is the response status for http steps.
+	StatusCode int `json:",omitempty"`
+
+	// Attempts is how many times a script step's command ran, more than one
+	// if it failed and was retried.
+	Attempts int `json:",omitempty"`
+
+	// Summary is the summary an agent step reported in its structured output,
+	// or the review summary of a merge step waiting for review.
+	Summary string `json:",omitempty"`
+
+	// AllowedFailure indicates the step failed but has allow_failure set, so
+	// the failure is recorded without affecting the workflow.
+	AllowedFailure bool `json:",omitempty"`
+
+	// TimedOut indicates the step failed because it ran past its timeout.
+	TimedOut bool `json:",omitempty"`
+
 	// Error contains the error message if the step failed.
 	Error string
 
@@ -28,6 +47,76 @@ type StepResult struct {
 
 	// Action indicates what the workflow should do after this step.
 	Action StepAction
+
+	// Escalation is set when a failing step has on_fail: escalate.
+	// It is persisted on WorkflowState rather than with each step result.
+	Escalation *Escalation `json:"-"`
+
+	// Iterations records each pass through a loop step's nested steps.
+	// It is only set for loop steps.
+	Iterations []LoopIteration `json:",omitempty"`
+
+	// Children maps each of a parallel step's nested steps to its result.
+	// It is only set for parallel steps.
+	Children map[string]*StepResult `json:",omitempty"`
+
+	// NoChanges indicates a merge step found nothing to merge: no file
+	// changes and no commits on the worktree branch.
+	NoChanges bool `json:",omitempty"`
+
+	// Command is the command a script step ran, after template substitution
+	// and shell escaping, with secret values redacted.
+	Command string `json:",omitempty"`
+
+	// RenderedOutputs maps each name a templated step output was stored under
+	// to the output stored there, one per matrix variant. It is only set for
+	// steps whose output name is a template, and for parallel steps, where it
+	// holds the outputs their nested steps stored.
+	RenderedOutputs map[string]string `json:",omitempty"`
+
+	// FilesChanged lists the worktree files the step created, modified or
+	// deleted, relative to the worktree. It is only set for steps with
+	// track_files enabled.
+	FilesChanged []string `json:",omitempty"`
+}
+
+// LoopIteration records the outcome of one iteration of a loop step.
+type LoopIteration struct {
+	// Iteration is the zero-based iteration number.
+	Iteration int
+
+	// Success is false if any nested step failed during the iteration.
+	Success bool
+
+	// Error is the error from the first nested step that failed.
+	Error string `json:",omitempty"`
+
+	// Duration is how long the iteration took.
+	Duration time.Duration
+
+	// Steps holds the nested steps that ran, in execution order.
+	Steps []LoopIterationStep
+}
+
+// LoopIterationStep records how a nested step ended within a loop iteration.
+type LoopIterationStep struct {
+	// Name is the nested step's name.
+	Name string
+
+	// Success indicates whether the step completed successfully.
+	Success bool
+
+	// Skipped indicates whether the step was skipped due to a 'when' condition.
+	Skipped bool `json:",omitempty"`
+
+	// AllowedFailure indicates the step failed but has allow_failure set.
+	AllowedFailure bool `json:",omitempty"`
+
+	// Error contains the error message if the step failed.
+	Error string `json:",omitempty"`
+
+	// Iterations holds the iteration history when the step is itself a loop.
+	Iterations []LoopIteration `json:",omitempty"`
 }
 
 // StepAction indicates what the workflow should do after a step completes.
@@ -47,6 +136,33 @@ const (
 	ActionFail StepAction = "fail"
 )
 
+// IsValid reports whether the action is a known step action.
+func (a StepAction) IsValid() bool {
+	switch a {
+	case ActionContinue, ActionExitLoop, ActionBlock, ActionFail:
+		return true
+	default:
+		return false
+	}
+}
+
+// NormalizeAction ensures the result carries a known action.
+// If the action is empty or unknown, it is derived from Success:
+// ActionContinue for a successful step, ActionFail otherwise.
+// It returns the original action and whether it was replaced.
+func (r *StepResult) NormalizeAction() (StepAction, bool) {
+	original := r.Action
+	if original.IsValid() {
+		return original, false
+	}
+	if r.Success {
+		r.Action = ActionContinue
+	} else {
+		r.Action = ActionFail
+	}
+	return original, true
+}
+
 // StepContext provides context for step execution.
 type StepContext struct {
 	// WorktreePath is the path to the worktree where the step executes.
@@ -58,6 +174,12 @@ type StepContext struct {
 	// WorkflowID is the ID of the current workflow run.
 	WorkflowID string
 
+	// GrimoireName is the name of the grimoire being executed.
+	GrimoireName string
+
+	// GrimoireHash is the content hash of the grimoire being executed.
+	GrimoireHash string
+
 	// Variables contains the workflow context variables.
 	// Step outputs are stored here as variables["step_name"] = result.
 	Variables map[string]interface{}
@@ -75,6 +197,18 @@ type StepContext struct {
 	// OnActiveStepTaskIDChange is called when ActiveStepTaskID changes.
 	// This allows the engine to persist state when an agent step starts.
 	OnActiveStepTaskIDChange func(stepTaskID string)
+
+	// OnProgress is called when a running agent step makes progress, with
+	// the time of its latest output.
+	OnProgress func(at time.Time)
+
+	// OnAgentRun is called when an agent finishes running for a step, with
+	// how long it ran.
+	OnAgentRun func(duration time.Duration)
+
+	// outputNames maps each templated output name rendered during this run
+	// to the step that stored it.
+	outputNames map[string]string
 }
 
 // NewStepContext creates a new step context.
@@ -87,6 +221,16 @@ func NewStepContext(worktreePath, beadID, workflowID string) *StepContext {
 	}
 }
 
+// CommitMetadata returns the metadata recorded on commits made for this workflow.
+func (c *StepContext) CommitMetadata() CommitMetadata {
+	return CommitMetadata{
+		TaskID:       c.BeadID,
+		WorkflowID:   c.WorkflowID,
+		Grimoire:     c.GrimoireName,
+		GrimoireHash: c.GrimoireHash,
+	}
+}
+
 // GetVariable retrieves a variable from the context.
 // Returns nil if the variable doesn't exist.
 func (c *StepContext) GetVariable(name string) interface{} {
@@ -98,6 +242,11 @@ func (c *StepContext) SetVariable(name string, value interface{}) {
 	c.Variables[name] = value
 }
 
+// DeleteVariable removes a variable from the context.
+func (c *StepContext) DeleteVariable(name string) {
+	delete(c.Variables, name)
+}
+
 // SetPrevious sets the previous step result in the context.
 func (c *StepContext) SetPrevious(result *StepResult) {
 	c.Variables["previous"] = map[string]interface{}{
@@ -125,6 +274,20 @@ func (c *StepContext) ClearActiveStepTaskID() {
 	}
 }
 
+// RecordProgress reports that a running agent step produced output at at.
+func (c *StepContext) RecordProgress(at time.Time) {
+	if c.OnProgress != nil {
+		c.OnProgress(at)
+	}
+}
+
+// RecordAgentRun reports that an agent ran for duration.
+func (c *StepContext) RecordAgentRun(duration time.Duration) {
+	if c.OnAgentRun != nil {
+		c.OnAgentRun(duration)
+	}
+}
+
 // WorkflowStatus represents the current state of a workflow.
 type WorkflowStatus string
 
@@ -135,8 +298,21 @@ const (
 	WorkflowFailed       WorkflowStatus = "failed"
 	WorkflowPendingMerge WorkflowStatus = "pending_merge"
 	WorkflowCancelled    WorkflowStatus = "cancelled"
+
+	// WorkflowAwaitingConfirmation means the workflow stopped before a step
+	// with confirm: true and waits for it to be confirmed.
+	WorkflowAwaitingConfirmation WorkflowStatus = "awaiting_confirmation"
+
+	// WorkflowStopped means the workflow was stopped with its task, and
+	// resumes from the interrupted step when the task is started again.
+	WorkflowStopped WorkflowStatus = "stopped"
 )
 
+// IsTerminal reports whether a workflow with this status has finished.
+func (s WorkflowStatus) IsTerminal() bool {
+	return s == WorkflowCompleted || s == WorkflowFailed || s == WorkflowCancelled
+}
+
 // EventEmitter is an interface for emitting workflow events.
 // This allows decoupling the workflow engine from the event broker.
 type EventEmitter interface {
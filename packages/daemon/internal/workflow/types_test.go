@@ -108,6 +108,40 @@ func TestStepAction_Constants(t *testing.T) {
 	}
 }
 
+func TestStepResult_NormalizeAction(t *testing.T) {
+	tests := []struct {
+		name        string
+		result      StepResult
+		wantAction  StepAction
+		wantChanged bool
+	}{
+		{"valid continue", StepResult{Success: true, Action: ActionContinue}, ActionContinue, false},
+		{"valid fail", StepResult{Success: false, Action: ActionFail}, ActionFail, false},
+		{"valid exit loop", StepResult{Success: true, Action: ActionExitLoop}, ActionExitLoop, false},
+		{"valid block on failure", StepResult{Success: false, Action: ActionBlock}, ActionBlock, false},
+		{"empty on success", StepResult{Success: true}, ActionContinue, true},
+		{"empty on failure", StepResult{Success: false}, ActionFail, true},
+		{"unknown on success", StepResult{Success: true, Action: "retry"}, ActionContinue, true},
+		{"unknown on failure", StepResult{Success: false, Action: "retry"}, ActionFail, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.result
+			original, changed := result.NormalizeAction()
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if original != tt.result.Action {
+				t.Errorf("original = %q, want %q", original, tt.result.Action)
+			}
+			if result.Action != tt.wantAction {
+				t.Errorf("Action = %q, want %q", result.Action, tt.wantAction)
+			}
+		})
+	}
+}
+
 func TestWorkflowStatus_Constants(t *testing.T) {
 	if WorkflowRunning != "running" {
 		t.Errorf("WorkflowRunning = %q, want %q", WorkflowRunning, "running")
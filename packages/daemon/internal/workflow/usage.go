@@ -0,0 +1,98 @@
+package workflow
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+// TagLabelPrefix marks a bead label that tags its workflows, as in
+// "tag:team=payments".
+const TagLabelPrefix = "tag:"
+
+// WorkflowUsage is the agent usage of a workflow run.
+type WorkflowUsage struct {
+	// AgentRuns is the number of times an agent was run, counting each loop
+	// iteration and matrix variant.
+	AgentRuns int `json:"agent_runs"`
+
+	// AgentTime is the total time agents spent running.
+	AgentTime time.Duration `json:"agent_time"`
+}
+
+// add records one agent run.
+func (u *WorkflowUsage) add(duration time.Duration) {
+	u.AgentRuns++
+	u.AgentTime += duration
+}
+
+// WorkflowTags returns the tags of a workflow running g for a bead: the
+// grimoire's tags, overridden by the bead's tag:key=value labels. It returns
+// nil if there are none.
+func WorkflowTags(g *grimoire.Grimoire, labels []string) map[string]string {
+	var tags map[string]string
+	set := func(key, value string) {
+		if tags == nil {
+			tags = make(map[string]string)
+		}
+		tags[key] = value
+	}
+	for key, value := range g.Tags {
+		set(key, value)
+	}
+	for _, label := range labels {
+		tag, ok := strings.CutPrefix(label, TagLabelPrefix)
+		if !ok {
+			continue
+		}
+		key, value, ok := strings.Cut(tag, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		set(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+	return tags
+}
+
+// UsageGroup is the usage of the workflows sharing a tag value.
+type UsageGroup struct {
+	// Value is the tag's value, or "" for workflows without the tag.
+	Value string `json:"value"`
+
+	// Workflows is the number of workflow runs in the group.
+	Workflows int `json:"workflows"`
+
+	WorkflowUsage
+}
+
+// AggregateUsage sums the usage of workflow runs grouped by the value of the
+// tag named groupBy, ordered by value. Runs without the tag are grouped
+// under "". An empty groupBy puts every run in one group.
+func AggregateUsage(states []*WorkflowState, groupBy string) []UsageGroup {
+	groups := make(map[string]*UsageGroup)
+	for _, state := range states {
+		value := ""
+		if groupBy != "" {
+			value = state.Tags[groupBy]
+		}
+		group, ok := groups[value]
+		if !ok {
+			group = &UsageGroup{Value: value}
+			groups[value] = group
+		}
+		group.Workflows++
+		if state.Usage != nil {
+			group.AgentRuns += state.Usage.AgentRuns
+			group.AgentTime += state.Usage.AgentTime
+		}
+	}
+
+	result := make([]UsageGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Value < result[j].Value })
+	return result
+}
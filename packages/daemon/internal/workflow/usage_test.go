@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/grimoire"
+)
+
+func TestWorkflowTags(t *testing.T) {
+	g := &grimoire.Grimoire{Name: "implement", Tags: map[string]string{"team": "search", "project": "ranking"}}
+	labels := []string{"grimoire:implement", "tag:team=payments", "tag:cost-center = 42", "tag:broken"}
+
+	got := WorkflowTags(g, labels)
+
+	want := map[string]string{"team": "payments", "project": "ranking", "cost-center": "42"}
+	if len(got) != len(want) {
+		t.Fatalf("WorkflowTags() = %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("tag %q = %q, want %q", key, got[key], value)
+		}
+	}
+
+	if tags := WorkflowTags(&grimoire.Grimoire{Name: "plain"}, nil); tags != nil {
+		t.Errorf("WorkflowTags() without tags = %v, want nil", tags)
+	}
+}
+
+func TestAggregateUsage(t *testing.T) {
+	states := []*WorkflowState{
+		{WorkflowID: "wf-1", Tags: map[string]string{"team": "payments"}, Usage: &WorkflowUsage{AgentRuns: 2, AgentTime: 2 * time.Minute}},
+		{WorkflowID: "wf-2", Tags: map[string]string{"team": "payments"}, Usage: &WorkflowUsage{AgentRuns: 1, AgentTime: time.Minute}},
+		{WorkflowID: "wf-3", Tags: map[string]string{"team": "search"}},
+	}
+
+	groups := AggregateUsage(states, "team")
+	if len(groups) != 2 {
+		t.Fatalf("AggregateUsage() = %+v, want payments and search", groups)
+	}
+	if got := groups[0]; got.Value != "payments" || got.Workflows != 2 || got.AgentRuns != 3 || got.AgentTime != 3*time.Minute {
+		t.Errorf("payments = %+v, want 2 workflows with 3 agent runs over 3m", got)
+	}
+	if got := groups[1]; got.Value != "search" || got.Workflows != 1 || got.AgentRuns != 0 {
+		t.Errorf("search = %+v, want 1 workflow without usage", got)
+	}
+
+	total := AggregateUsage(states, "")
+	if len(total) != 1 || total[0].Workflows != 3 || total[0].AgentRuns != 3 {
+		t.Errorf("AggregateUsage() without group_by = %+v, want one group of all runs", total)
+	}
+}
+
+func TestEngine_Execute_RecordsUsage(t *testing.T) {
+	covenDir := t.TempDir()
+	engine := NewEngine(EngineConfig{
+		CovenDir:     covenDir,
+		WorktreePath: t.TempDir(),
+		BeadID:       "test-bead",
+		WorkflowID:   "test-wf",
+		Bead:         &BeadData{ID: "test-bead", Labels: []string{"tag:project=checkout"}},
+	})
+	engine.SetAgentRunner(&MockAgentRunner{Output: "done", Delay: 5 * time.Millisecond})
+
+	g := &grimoire.Grimoire{
+		Name: "test-workflow",
+		Tags: map[string]string{"team": "payments"},
+		Steps: []grimoire.Step{
+			{Name: "implement", Type: grimoire.StepTypeAgent, Spell: "Implement the task.\n"},
+			{
+				Name:            "review-loop",
+				Type:            grimoire.StepTypeLoop,
+				MaxIterations:   2,
+				OnMaxIterations: "continue",
+				Steps: []grimoire.Step{
+					{Name: "review", Type: grimoire.StepTypeAgent, Spell: "Review the changes.\n"},
+				},
+			},
+			{Name: "test", Type: grimoire.StepTypeScript, Command: "echo ok"},
+		},
+	}
+
+	result := engine.Execute(context.Background(), g)
+	if result.Status != WorkflowCompleted {
+		t.Fatalf("Status = %q, want %q, error: %v", result.Status, WorkflowCompleted, result.Error)
+	}
+
+	// The completed run is kept in the task's history
+	history, err := NewStatePersister(covenDir).History("test-bead")
+	if err != nil || len(history) != 1 {
+		t.Fatalf("History() = %d runs, %v; want the completed run", len(history), err)
+	}
+	state := history[0]
+	if state.Tags["team"] != "payments" || state.Tags["project"] != "checkout" {
+		t.Errorf("Tags = %v, want the grimoire's team and the bead's project", state.Tags)
+	}
+	if state.Usage == nil || state.Usage.AgentRuns != 3 {
+		t.Fatalf("Usage = %+v, want 3 agent runs counting each loop iteration", state.Usage)
+	}
+	if state.Usage.AgentTime < 15*time.Millisecond {
+		t.Errorf("AgentTime = %v, want at least the agents' run time", state.Usage.AgentTime)
+	}
+}
@@ -0,0 +1,268 @@
+// Package client is a typed Go client for the coven daemon API.
+//
+// The daemon serves its API over a Unix socket, normally
+// .coven/covend.sock in the workspace. New sets up the transport for it:
+//
+//	c := client.New(filepath.Join(workspace, ".coven", "covend.sock"))
+//	health, err := c.Health(ctx)
+//
+// Requests the daemon rejects return an *APIError carrying the response
+// status, which IsNotFound, IsConflict and IsBadRequest test for.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coven/daemon/pkg/types"
+)
+
+// DefaultTimeout is how long requests made by a client from New may take.
+const DefaultTimeout = 30 * time.Second
+
+// Client makes requests to a coven daemon.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a client for the daemon listening on the Unix socket at
+// socketPath.
+func New(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return NewWithHTTPClient("http://unix", &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	})
+}
+
+// NewWithHTTPClient creates a client that sends requests to baseURL using
+// httpClient, for daemons reached through a transport New doesn't set up.
+func NewWithHTTPClient(baseURL string, httpClient *http.Client) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// APIError is returned when the daemon responds with an error status.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+
+	// Message is the error the daemon reported.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("daemon returned %d: %s", e.StatusCode, e.Message)
+}
+
+// StatusCode returns the HTTP status of an *APIError, or 0 if err isn't one.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound checks if an error is the daemon reporting that the requested
+// task, agent, workflow or grimoire doesn't exist.
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict checks if an error is the daemon refusing a request because of
+// the current state, such as a busy concurrency group or a workflow state
+// written by a newer daemon.
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsBadRequest checks if an error is the daemon rejecting a request as
+// invalid, such as cancelling a workflow that has already finished.
+func IsBadRequest(err error) bool {
+	return StatusCode(err) == http.StatusBadRequest
+}
+
+// Health calls GET /health.
+func (c *Client) Health(ctx context.Context) (*types.HealthStatus, error) {
+	var health types.HealthStatus
+	if err := c.get(ctx, "/health", &health); err != nil {
+		return nil, err
+	}
+	return &health, nil
+}
+
+// Version calls GET /version.
+func (c *Client) Version(ctx context.Context) (*types.VersionInfo, error) {
+	var version types.VersionInfo
+	if err := c.get(ctx, "/version", &version); err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// State calls GET /state.
+func (c *Client) State(ctx context.Context) (*types.StateResponse, error) {
+	var state types.StateResponse
+	if err := c.get(ctx, "/state", &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Shutdown calls POST /shutdown. The daemon stops shortly after responding.
+func (c *Client) Shutdown(ctx context.Context) error {
+	return c.post(ctx, "/shutdown", nil, nil)
+}
+
+// StateReconciliation is the response for POST /admin/reconcile-state.
+type StateReconciliation struct {
+	Checked int           `json:"checked"`
+	Changes []StateChange `json:"changes"`
+}
+
+// StateChange is a correction the daemon made to its state store.
+type StateChange struct {
+	TaskID     string `json:"task_id"`
+	WorkflowID string `json:"workflow_id,omitempty"`
+
+	// Field is what was corrected: "task_status" or "agent_status".
+	Field string `json:"field"`
+
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// ReconcileState calls POST /admin/reconcile-state, correcting task and
+// agent statuses that have drifted from the persisted workflow states.
+func (c *Client) ReconcileState(ctx context.Context) (*StateReconciliation, error) {
+	var report StateReconciliation
+	if err := c.post(ctx, "/admin/reconcile-state", nil, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// get sends a GET request for path and decodes the JSON response into out.
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	return c.do(ctx, http.MethodGet, path, "", nil, out)
+}
+
+// post sends a POST request for path with body encoded as JSON, if it isn't
+// nil, and decodes the JSON response into out, if it isn't nil.
+func (c *Client) post(ctx context.Context, path string, body, out any) error {
+	if body == nil {
+		return c.do(ctx, http.MethodPost, path, "", nil, out)
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	return c.do(ctx, http.MethodPost, path, "application/json", bytes.NewReader(data), out)
+}
+
+// do sends a request and decodes a successful JSON response into out. Error
+// statuses are returned as an *APIError.
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	data, err := c.doRaw(ctx, method, path, contentType, body)
+	if err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+	}
+	return nil
+}
+
+// doRaw sends a request and returns the body of a successful response.
+func (c *Client) doRaw(ctx context.Context, method, path, contentType string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s %s response: %w", method, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return data, newAPIError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// stream sends a GET request for path and copies a successful response's
+// body to w as it arrives. Error statuses are returned as an *APIError.
+func (c *Client) stream(ctx context.Context, path string, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GET %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read GET %s response: %w", path, err)
+		}
+		return newAPIError(resp.StatusCode, data)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read GET %s response: %w", path, err)
+	}
+	return nil
+}
+
+// newAPIError builds an APIError from an error response. Most endpoints
+// report errors as {"error": "..."}; the rest respond with plain text.
+func newAPIError(status int, body []byte) *APIError {
+	var errResp types.ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		return &APIError{StatusCode: status, Message: errResp.Error}
+	}
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &APIError{StatusCode: status, Message: message}
+}
+
+// escape escapes an ID for use as a path segment.
+func escape(id string) string {
+	return url.PathEscape(id)
+}
@@ -0,0 +1,328 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coven/daemon/internal/daemon"
+	"github.com/coven/daemon/internal/workflow"
+)
+
+var testCounter int64
+
+// startDaemon runs a daemon in a fresh workspace and returns a client for it.
+// setup, if not nil, is called with the workspace's .coven directory before
+// the daemon starts.
+func startDaemon(t *testing.T, setup func(covenDir string)) *Client {
+	t.Helper()
+
+	// Unix socket paths are limited in length, so keep the workspace short
+	id := atomic.AddInt64(&testCounter, 1)
+	workspace := filepath.Join("/tmp", fmt.Sprintf("cvc-%d-%d", os.Getpid(), id))
+	covenDir := filepath.Join(workspace, ".coven")
+	if err := os.MkdirAll(covenDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(workspace) })
+	if setup != nil {
+		setup(covenDir)
+	}
+
+	d, err := daemon.New(workspace, "1.2.3")
+	if err != nil {
+		t.Fatalf("daemon.New() error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- d.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-errCh
+	})
+
+	c := New(filepath.Join(covenDir, "covend.sock"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := c.Health(context.Background()); err == nil {
+			return c
+		} else if time.Now().After(deadline) {
+			t.Fatalf("Daemon did not start: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientDaemonEndpoints(t *testing.T) {
+	c := startDaemon(t, nil)
+	ctx := context.Background()
+
+	health, err := c.Health(ctx)
+	if err != nil {
+		t.Fatalf("Health() error: %v", err)
+	}
+	if health.Status != "healthy" {
+		t.Errorf("Health().Status = %q, want %q", health.Status, "healthy")
+	}
+
+	version, err := c.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version() error: %v", err)
+	}
+	if version.Version != "1.2.3" {
+		t.Errorf("Version().Version = %q, want %q", version.Version, "1.2.3")
+	}
+
+	state, err := c.State(ctx)
+	if err != nil {
+		t.Fatalf("State() error: %v", err)
+	}
+	if state.State == nil {
+		t.Error("State().State is nil")
+	}
+
+	tasks, err := c.Tasks(ctx)
+	if err != nil {
+		t.Fatalf("Tasks() error: %v", err)
+	}
+	if tasks.Count != len(tasks.Tasks) {
+		t.Errorf("Tasks().Count = %d, but %d tasks listed", tasks.Count, len(tasks.Tasks))
+	}
+
+	agents, err := c.Agents(ctx)
+	if err != nil {
+		t.Fatalf("Agents() error: %v", err)
+	}
+	if agents.Count != 0 {
+		t.Errorf("Agents().Count = %d, want 0", agents.Count)
+	}
+
+	report, err := c.ReconcileState(ctx)
+	if err != nil {
+		t.Fatalf("ReconcileState() error: %v", err)
+	}
+	if len(report.Changes) != 0 {
+		t.Errorf("ReconcileState() made %d changes, want none", len(report.Changes))
+	}
+}
+
+func TestClientWorkflows(t *testing.T) {
+	started := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	c := startDaemon(t, func(covenDir string) {
+		state := &workflow.WorkflowState{
+			TaskID:       "task-1",
+			WorkflowID:   "wf-1",
+			GrimoireName: "implement",
+			Status:       workflow.WorkflowCompleted,
+			WorktreePath: "/tmp/worktree",
+			StartedAt:    started,
+			CompletedSteps: map[string]*workflow.StepResult{
+				"test": {Success: true, Output: "ok", Duration: time.Second},
+			},
+		}
+		if err := workflow.NewStatePersister(covenDir).Save(state); err != nil {
+			t.Fatalf("Failed to save state: %v", err)
+		}
+	})
+	ctx := context.Background()
+
+	list, err := c.Workflows(ctx)
+	if err != nil {
+		t.Fatalf("Workflows() error: %v", err)
+	}
+	if list.Count != 1 || list.Workflows[0].WorkflowID != "wf-1" {
+		t.Fatalf("Workflows() = %+v, want wf-1 only", list.Workflows)
+	}
+
+	wf, err := c.Workflow(ctx, "task-1")
+	if err != nil {
+		t.Fatalf("Workflow() error: %v", err)
+	}
+	if wf.WorkflowID != "wf-1" || wf.Status != WorkflowCompleted || !wf.Status.IsTerminal() {
+		t.Errorf("Workflow() = %s %q, want wf-1 completed", wf.WorkflowID, wf.Status)
+	}
+	if !wf.StartedAt.Equal(started) {
+		t.Errorf("Workflow().StartedAt = %v, want %v", wf.StartedAt, started)
+	}
+	if result := wf.CompletedSteps["test"]; result == nil || !result.Success || result.Duration != time.Second {
+		t.Errorf("Workflow().CompletedSteps[test] = %+v, want a one-second success", result)
+	}
+	if wf.Result == nil || wf.Result.Status != WorkflowCompleted {
+		t.Errorf("Workflow().Result = %+v, want a completed summary", wf.Result)
+	}
+
+	history, err := c.TaskWorkflows(ctx, "task-1")
+	if err != nil {
+		t.Fatalf("TaskWorkflows() error: %v", err)
+	}
+	if history.Count != 1 || !history.Workflows[0].Current {
+		t.Errorf("TaskWorkflows() = %+v, want wf-1 as the current run", history.Workflows)
+	}
+
+	comment, err := c.AddComment(ctx, "wf-1", "alice", "shipped")
+	if err != nil {
+		t.Fatalf("AddComment() error: %v", err)
+	}
+	if comment.CommentCount != 1 || comment.Comment.Text != "shipped" {
+		t.Errorf("AddComment() = %+v, want the first comment", comment)
+	}
+
+	_, err = c.CancelWorkflow(ctx, "wf-1")
+	if !IsBadRequest(err) {
+		t.Errorf("CancelWorkflow() on a completed workflow error = %v, want a bad request", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "terminal state") {
+		t.Errorf("CancelWorkflow() error = %q, want the daemon's message", err)
+	}
+
+	var diff strings.Builder
+	if err := c.MergeDiff(ctx, "wf-1", &diff); !IsBadRequest(err) {
+		t.Errorf("MergeDiff() on a completed workflow error = %v, want a bad request", err)
+	}
+	if diff.Len() != 0 {
+		t.Errorf("MergeDiff() wrote %q for an error response", diff.String())
+	}
+
+	_, err = c.Workflow(ctx, "wf-missing")
+	if !IsNotFound(err) {
+		t.Errorf("Workflow() for a missing workflow error = %v, want not found", err)
+	}
+}
+
+func TestClientTaskErrors(t *testing.T) {
+	c := startDaemon(t, nil)
+	ctx := context.Background()
+
+	// Task endpoints report errors as plain text rather than JSON
+	_, err := c.StartTask(ctx, "task-missing")
+	if !IsNotFound(err) {
+		t.Fatalf("StartTask() for a missing task error = %v, want not found", err)
+	}
+	if msg := err.(*APIError).Message; msg != "Task not found" {
+		t.Errorf("StartTask() error message = %q, want %q", msg, "Task not found")
+	}
+
+	_, err = c.StopTask(ctx, "task-missing")
+	if !IsNotFound(err) {
+		t.Errorf("StopTask() for a task with no agent error = %v, want not found", err)
+	}
+
+	_, err = c.Agent(ctx, "task-missing")
+	if !IsNotFound(err) {
+		t.Errorf("Agent() for a missing agent error = %v, want not found", err)
+	}
+}
+
+func TestClientGrimoires(t *testing.T) {
+	c := startDaemon(t, nil)
+	ctx := context.Background()
+
+	bundle := []byte(`name: first
+description: First grimoire
+steps:
+  - name: test
+    type: script
+    command: npm test
+---
+name: second
+description: Second grimoire
+steps:
+  - name: build
+    type: script
+    command: make
+`)
+	result, err := c.InstallGrimoires(ctx, bundle)
+	if err != nil {
+		t.Fatalf("InstallGrimoires() error: %v", err)
+	}
+	if result.Installed != 2 {
+		t.Errorf("InstallGrimoires().Installed = %d, want 2", result.Installed)
+	}
+
+	invalid := append(bundle, []byte("---\nname: third\nsteps: []\n")...)
+	result, err = c.InstallGrimoires(ctx, invalid)
+	if StatusCode(err) != http.StatusUnprocessableEntity {
+		t.Fatalf("InstallGrimoires() with an invalid grimoire error = %v, want 422", err)
+	}
+	if result == nil || len(result.Results) != 3 {
+		t.Fatalf("InstallGrimoires() results = %+v, want one per grimoire", result)
+	}
+	if third := result.Results[2]; third.Success || third.Name != "third" || third.Error == "" {
+		t.Errorf("InstallGrimoires() third result = %+v, want a validation error", third)
+	}
+
+	summaries, err := c.ListGrimoires(ctx)
+	if err != nil {
+		t.Fatalf("ListGrimoires() error: %v", err)
+	}
+	if len(summaries) == 0 || summaries[0].Name != "first" || summaries[0].Source != "user" || summaries[0].StepCount != 1 {
+		t.Errorf("ListGrimoires() = %+v, want first listed first", summaries)
+	}
+	detail, err := c.GetGrimoire(ctx, "second")
+	if err != nil {
+		t.Fatalf("GetGrimoire() error: %v", err)
+	}
+	if detail.Description != "Second grimoire" || detail.Definition["steps"] == nil {
+		t.Errorf("GetGrimoire() = %+v, want second with its steps", detail)
+	}
+
+	_, err = c.GrimoireDiff(ctx, "first")
+	if !IsNotFound(err) {
+		t.Errorf("GrimoireDiff() for a grimoire with no built-in version error = %v, want not found", err)
+	}
+
+	validation, err := c.ValidateGrimoire(ctx, []byte("name: third\nsteps: []\n"))
+	if err != nil {
+		t.Fatalf("ValidateGrimoire() error: %v", err)
+	}
+	if validation.IsValid || len(validation.Errors) != 1 || validation.Errors[0].Field != "description" {
+		t.Errorf("ValidateGrimoire() = %+v, want a description error", validation)
+	}
+}
+
+func TestClientShutdown(t *testing.T) {
+	c := startDaemon(t, nil)
+
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := c.Health(context.Background()); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Daemon still responding after Shutdown()")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"json", http.StatusNotFound, `{"error":"workflow not found"}`, "workflow not found"},
+		{"plain text", http.StatusConflict, "Concurrency group busy\n", "Concurrency group busy"},
+		{"empty", http.StatusInternalServerError, "", "Internal Server Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newAPIError(tt.status, []byte(tt.body))
+			if err.StatusCode != tt.status || err.Message != tt.want {
+				t.Errorf("newAPIError() = %d %q, want %d %q", err.StatusCode, err.Message, tt.status, tt.want)
+			}
+		})
+	}
+}
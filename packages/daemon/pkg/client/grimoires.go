@@ -0,0 +1,166 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// InstallResult is the response for POST /grimoires/install.
+type InstallResult struct {
+	// Installed is the number of grimoires installed. It is 0 when any
+	// grimoire in the bundle was invalid, as nothing is installed then.
+	Installed int                 `json:"installed"`
+	Results   []InstallItemResult `json:"results"`
+}
+
+// InstallItemResult is the outcome of installing one grimoire of a bundle.
+type InstallItemResult struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GrimoireDiff is the response for GET /grimoires/:name/diff.
+type GrimoireDiff struct {
+	Name      string        `json:"name"`
+	Identical bool          `json:"identical"`
+	Fields    []FieldChange `json:"fields,omitempty"`
+	Prepare   []StepChange  `json:"prepare,omitempty"`
+	Steps     []StepChange  `json:"steps,omitempty"`
+}
+
+// FieldChange is a field with a different value in the user grimoire than in
+// the built-in one. A value is nil where the field isn't set.
+type FieldChange struct {
+	Field   string `json:"field"`
+	Builtin any    `json:"builtin"`
+	User    any    `json:"user"`
+}
+
+// StepChange is a difference in one step, matched by name.
+type StepChange struct {
+	Step string `json:"step"`
+
+	// Change is "added", "removed", "changed" or "moved".
+	Change string `json:"change"`
+
+	// BuiltinIndex and UserIndex are the step's position in each version, or
+	// -1 where it doesn't exist.
+	BuiltinIndex int           `json:"builtin_index"`
+	UserIndex    int           `json:"user_index"`
+	Fields       []FieldChange `json:"fields,omitempty"`
+}
+
+// GrimoireSummary is a grimoire listed by GET /grimoires.
+type GrimoireSummary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Source is "user" or "builtin".
+	Source string `json:"source"`
+
+	// StepCount is the number of top-level steps.
+	StepCount int    `json:"step_count"`
+	Timeout   string `json:"timeout,omitempty"`
+
+	// Error is why the grimoire doesn't load, if it doesn't.
+	Error string `json:"error,omitempty"`
+}
+
+// GrimoireDetail is the response for GET /grimoires/:name.
+type GrimoireDetail struct {
+	GrimoireSummary
+	Extends     string   `json:"extends,omitempty"`
+	ContentHash string   `json:"content_hash"`
+	Warnings    []string `json:"warnings,omitempty"`
+
+	// Definition is the parsed grimoire keyed by its YAML field names.
+	Definition map[string]any `json:"definition"`
+}
+
+// GrimoireValidation is the response for POST /grimoires/validate.
+type GrimoireValidation struct {
+	Name     string            `json:"name,omitempty"`
+	IsValid  bool              `json:"is_valid"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+// ValidationIssue is a problem found validating a grimoire. Field and Step
+// are empty where the problem isn't in one.
+type ValidationIssue struct {
+	Field   string `json:"field,omitempty"`
+	Step    string `json:"step,omitempty"`
+	Message string `json:"message"`
+}
+
+// InstallGrimoires calls POST /grimoires/install with a bundle: a tar
+// archive, optionally gzipped, or a multi-document YAML file. If any
+// grimoire is invalid, nothing is installed and the per-grimoire results,
+// each with the reason it wasn't installed, are returned along with an
+// *APIError with status 422.
+func (c *Client) InstallGrimoires(ctx context.Context, bundle []byte) (*InstallResult, error) {
+	data, err := c.doRaw(ctx, http.MethodPost, "/grimoires/install", "application/octet-stream", bytes.NewReader(bundle))
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnprocessableEntity {
+		var result InstallResult
+		if json.Unmarshal(data, &result) == nil && len(result.Results) > 0 {
+			apiErr.Message = "bundle has invalid grimoires, nothing installed"
+			return &result, apiErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result InstallResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode install response: %w", err)
+	}
+	return &result, nil
+}
+
+// ListGrimoires calls GET /grimoires, listing the grimoires a workflow can
+// be started with, sorted by name.
+func (c *Client) ListGrimoires(ctx context.Context) ([]GrimoireSummary, error) {
+	var summaries []GrimoireSummary
+	if err := c.get(ctx, "/grimoires", &summaries); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// GetGrimoire calls GET /grimoires/:name, returning the grimoire's summary
+// and definition.
+func (c *Client) GetGrimoire(ctx context.Context, name string) (*GrimoireDetail, error) {
+	var detail GrimoireDetail
+	if err := c.get(ctx, "/grimoires/"+escape(name), &detail); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// GrimoireDiff calls GET /grimoires/:name/diff, comparing a user grimoire
+// with the built-in grimoire it overrides.
+func (c *Client) GrimoireDiff(ctx context.Context, name string) (*GrimoireDiff, error) {
+	var diff GrimoireDiff
+	if err := c.get(ctx, "/grimoires/"+escape(name)+"/diff", &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// ValidateGrimoire calls POST /grimoires/validate, checking grimoire YAML
+// without saving it. An invalid grimoire isn't an error: its problems are in
+// the result.
+func (c *Client) ValidateGrimoire(ctx context.Context, content []byte) (*GrimoireValidation, error) {
+	var result GrimoireValidation
+	if err := c.do(ctx, http.MethodPost, "/grimoires/validate", "application/yaml", bytes.NewReader(content), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/coven/daemon/pkg/types"
+)
+
+// TaskList is the response for GET /tasks.
+type TaskList struct {
+	Tasks    []types.Task `json:"tasks"`
+	Count    int          `json:"count"`
+	LastSync *time.Time   `json:"last_sync,omitempty"`
+}
+
+// TaskAction is the response for starting or stopping a task.
+type TaskAction struct {
+	TaskID string `json:"task_id"`
+
+	// Status is "started", "already_running" or "stopped".
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// TaskWorkflowList is the response for GET /tasks/:id/workflows.
+type TaskWorkflowList struct {
+	TaskID    string             `json:"task_id"`
+	Workflows []TaskWorkflowItem `json:"workflows"`
+	Count     int                `json:"count"`
+}
+
+// TaskWorkflowItem is one of a task's workflow runs.
+type TaskWorkflowItem struct {
+	WorkflowSummary
+
+	// Current is set for the run the task's workflow endpoints act on.
+	Current bool `json:"current"`
+}
+
+// Tasks calls GET /tasks.
+func (c *Client) Tasks(ctx context.Context) (*TaskList, error) {
+	var tasks TaskList
+	if err := c.get(ctx, "/tasks", &tasks); err != nil {
+		return nil, err
+	}
+	return &tasks, nil
+}
+
+// StartTask calls POST /tasks/:id/start, running the task's workflow with
+// the current version of its grimoire.
+func (c *Client) StartTask(ctx context.Context, taskID string) (*TaskAction, error) {
+	return c.StartTaskPinned(ctx, taskID, "")
+}
+
+// StartTaskPinned calls POST /tasks/:id/start, running the task's workflow
+// with the grimoire snapshot that has the given content hash. An empty hash
+// uses the current version of the grimoire.
+func (c *Client) StartTaskPinned(ctx context.Context, taskID, grimoireHash string) (*TaskAction, error) {
+	return c.StartTaskWithInputs(ctx, taskID, grimoireHash, nil)
+}
+
+// StartTaskWithInputs calls POST /tasks/:id/start, running the task's
+// workflow with values for its grimoire's inputs, as for StartTaskPinned. The
+// task doesn't start if a required input is missing; the daemon answers 422
+// Unprocessable Entity naming the missing inputs.
+func (c *Client) StartTaskWithInputs(ctx context.Context, taskID, grimoireHash string, inputs map[string]any) (*TaskAction, error) {
+	var body any
+	if grimoireHash != "" || len(inputs) > 0 {
+		body = struct {
+			GrimoireHash string         `json:"grimoire_hash,omitempty"`
+			Inputs       map[string]any `json:"inputs,omitempty"`
+		}{grimoireHash, inputs}
+	}
+	var action TaskAction
+	if err := c.post(ctx, "/tasks/"+escape(taskID)+"/start", body, &action); err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// StopTask calls POST /tasks/:id/stop, stopping the task's agent gracefully.
+// The task returns to open and its workflow resumes when the task next
+// starts; use CancelWorkflow to end the workflow.
+func (c *Client) StopTask(ctx context.Context, taskID string) (*TaskAction, error) {
+	var action TaskAction
+	if err := c.post(ctx, "/tasks/"+escape(taskID)+"/stop", nil, &action); err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// KillTask calls POST /tasks/:id/stop with force set, killing the task's
+// agent without a grace period. The workflow can be resumed as with StopTask.
+func (c *Client) KillTask(ctx context.Context, taskID string) (*TaskAction, error) {
+	var action TaskAction
+	if err := c.post(ctx, "/tasks/"+escape(taskID)+"/stop", map[string]bool{"force": true}, &action); err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// TaskWorkflows calls GET /tasks/:id/workflows.
+func (c *Client) TaskWorkflows(ctx context.Context, taskID string) (*TaskWorkflowList, error) {
+	var workflows TaskWorkflowList
+	if err := c.get(ctx, "/tasks/"+escape(taskID)+"/workflows", &workflows); err != nil {
+		return nil, err
+	}
+	return &workflows, nil
+}
+
+// AgentList is the response for GET /agents.
+type AgentList struct {
+	Agents []*types.Agent `json:"agents"`
+	Count  int            `json:"count"`
+}
+
+// AgentOutput is the response for GET /agents/:id/output.
+type AgentOutput struct {
+	TaskID    string       `json:"task_id"`
+	Lines     []OutputLine `json:"lines"`
+	LineCount int          `json:"line_count"`
+	LastSeq   uint64       `json:"last_seq"`
+}
+
+// OutputLine is a line of agent output.
+type OutputLine struct {
+	Sequence  uint64    `json:"sequence"`
+	Timestamp time.Time `json:"timestamp"`
+	Stream    string    `json:"stream"` // "stdout" or "stderr"
+	Data      string    `json:"data"`
+}
+
+// AgentAction is the response for killing or responding to an agent.
+type AgentAction struct {
+	TaskID  string `json:"task_id"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Agents calls GET /agents.
+func (c *Client) Agents(ctx context.Context) (*AgentList, error) {
+	var agents AgentList
+	if err := c.get(ctx, "/agents", &agents); err != nil {
+		return nil, err
+	}
+	return &agents, nil
+}
+
+// Agent calls GET /agents/:id.
+func (c *Client) Agent(ctx context.Context, taskID string) (*types.Agent, error) {
+	var agent types.Agent
+	if err := c.get(ctx, "/agents/"+escape(taskID), &agent); err != nil {
+		return nil, err
+	}
+	return &agent, nil
+}
+
+// AgentOutput calls GET /agents/:id/output, returning the lines after the
+// since sequence number. Pass 0 for all buffered output.
+func (c *Client) AgentOutput(ctx context.Context, taskID string, since uint64) (*AgentOutput, error) {
+	path := "/agents/" + escape(taskID) + "/output"
+	if since > 0 {
+		path += fmt.Sprintf("?since=%d", since)
+	}
+	var output AgentOutput
+	if err := c.get(ctx, path, &output); err != nil {
+		return nil, err
+	}
+	return &output, nil
+}
+
+// KillAgent calls POST /agents/:id/kill.
+func (c *Client) KillAgent(ctx context.Context, taskID string) (*AgentAction, error) {
+	var action AgentAction
+	if err := c.post(ctx, "/agents/"+escape(taskID)+"/kill", nil, &action); err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// RespondToAgent calls POST /agents/:id/respond, writing response to the
+// agent's stdin.
+func (c *Client) RespondToAgent(ctx context.Context, taskID, response string) (*AgentAction, error) {
+	var action AgentAction
+	body := map[string]string{"response": response}
+	if err := c.post(ctx, "/agents/"+escape(taskID)+"/respond", body, &action); err != nil {
+		return nil, err
+	}
+	return &action, nil
+}
+
+// Question is a question an agent asked while running a step.
+type Question struct {
+	ID      string          `json:"id"`
+	TaskID  string          `json:"task_id"`
+	Context QuestionContext `json:"context"`
+	Type    string          `json:"type"`
+	Text    string          `json:"text"`
+
+	RawContext  string     `json:"raw_context,omitempty"`
+	Options     []string   `json:"options,omitempty"`
+	Sequence    uint64     `json:"sequence"`
+	DetectedAt  time.Time  `json:"detected_at"`
+	AnsweredAt  *time.Time `json:"answered_at,omitempty"`
+	Answer      string     `json:"answer,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// QuestionContext identifies the workflow step a question came from.
+type QuestionContext struct {
+	WorkflowID string `json:"workflow_id,omitempty"`
+	StepName   string `json:"step_name,omitempty"`
+	StepIndex  int    `json:"step_index"`
+	StepTaskID string `json:"step_task_id"`
+}
+
+// QuestionList is the response for GET /questions.
+type QuestionList struct {
+	Questions    []*Question `json:"questions"`
+	Count        int         `json:"count"`
+	PendingCount int         `json:"pending_count"`
+}
+
+// AnswerResult is the response for POST /questions/:id/answer.
+type AnswerResult struct {
+	QuestionID    string `json:"question_id"`
+	TaskID        string `json:"task_id"`
+	StepTaskID    string `json:"step_task_id,omitempty"`
+	Status        string `json:"status"`
+	Delivered     bool   `json:"delivered"`
+	DeliveryError string `json:"delivery_error,omitempty"`
+	Message       string `json:"message"`
+}
+
+// Questions calls GET /questions.
+func (c *Client) Questions(ctx context.Context) (*QuestionList, error) {
+	var questions QuestionList
+	if err := c.get(ctx, "/questions", &questions); err != nil {
+		return nil, err
+	}
+	return &questions, nil
+}
+
+// AnswerQuestion calls POST /questions/:id/answer.
+func (c *Client) AnswerQuestion(ctx context.Context, questionID, answer string) (*AnswerResult, error) {
+	var result AnswerResult
+	body := map[string]string{"answer": answer}
+	if err := c.post(ctx, "/questions/"+escape(questionID)+"/answer", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,416 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// WorkflowStatus is the status of a workflow run.
+type WorkflowStatus string
+
+const (
+	WorkflowRunning              WorkflowStatus = "running"
+	WorkflowBlocked              WorkflowStatus = "blocked"
+	WorkflowCompleted            WorkflowStatus = "completed"
+	WorkflowFailed               WorkflowStatus = "failed"
+	WorkflowPendingMerge         WorkflowStatus = "pending_merge"
+	WorkflowCancelled            WorkflowStatus = "cancelled"
+	WorkflowAwaitingConfirmation WorkflowStatus = "awaiting_confirmation"
+)
+
+// IsTerminal reports whether a workflow with this status has finished.
+func (s WorkflowStatus) IsTerminal() bool {
+	return s == WorkflowCompleted || s == WorkflowFailed || s == WorkflowCancelled
+}
+
+// WorkflowSummary describes a workflow run in list responses.
+type WorkflowSummary struct {
+	WorkflowID   string         `json:"workflow_id"`
+	TaskID       string         `json:"task_id"`
+	GrimoireName string         `json:"grimoire_name"`
+	GrimoireHash string         `json:"grimoire_hash,omitempty"`
+	Status       WorkflowStatus `json:"status"`
+	CurrentStep  int            `json:"current_step"`
+	WorktreePath string         `json:"worktree_path"`
+	StartedAt    time.Time      `json:"started_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	Error        string         `json:"error,omitempty"`
+}
+
+// WorkflowList is the response for GET /workflows.
+type WorkflowList struct {
+	Workflows []WorkflowSummary `json:"workflows"`
+	Count     int               `json:"count"`
+}
+
+// Workflow is the response for GET /workflows/:id.
+type Workflow struct {
+	WorkflowSummary
+
+	LastProgressAt *time.Time             `json:"last_progress_at,omitempty"`
+	Steps          []Step                 `json:"steps"`
+	CompletedSteps map[string]*StepResult `json:"completed_steps,omitempty"`
+	StepOutputs    map[string]string      `json:"step_outputs,omitempty"`
+	MergeReview    *MergeReview           `json:"merge_review,omitempty"`
+	Escalation     *Escalation            `json:"escalation,omitempty"`
+	Confirmation   *Confirmation          `json:"pending_confirmation,omitempty"`
+	Comments       []Comment              `json:"comments,omitempty"`
+	Tags           map[string]string      `json:"tags,omitempty"`
+	Result         *WorkflowResult        `json:"result,omitempty"`
+	Progress       *WorkflowProgress      `json:"progress,omitempty"`
+
+	// Actions are the actions the workflow's status allows, such as
+	// "cancel", "retry" or "approve-merge".
+	Actions []string `json:"available_actions"`
+}
+
+// Step is a step of a workflow's grimoire and how far it has got.
+type Step struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"` // pending, running, completed, failed, skipped
+	Depth       int    `json:"depth"`  // 0 = top level, 1+ = nested in a loop or parallel step
+	IsLoop      bool   `json:"is_loop,omitempty"`
+	MaxIter     int    `json:"max_iterations,omitempty"`
+	CurrentIter int    `json:"current_iteration,omitempty"`
+	Error       string `json:"error,omitempty"`
+	StepTaskID  string `json:"step_task_id,omitempty"`
+	Command     string `json:"command,omitempty"`
+
+	// Iterations is the history of a loop step's iterations that have run.
+	Iterations []Iteration `json:"iterations,omitempty"`
+}
+
+// Iteration is one iteration of a loop step.
+type Iteration struct {
+	Iteration  int             `json:"iteration"` // zero-based
+	Status     string          `json:"status"`    // completed, failed
+	Error      string          `json:"error,omitempty"`
+	DurationMs int64           `json:"duration_ms"`
+	Steps      []IterationStep `json:"steps"`
+}
+
+// IterationStep is a nested step within a loop iteration.
+type IterationStep struct {
+	Name       string      `json:"name"`
+	Status     string      `json:"status"` // completed, failed, skipped
+	Error      string      `json:"error,omitempty"`
+	Iterations []Iteration `json:"iterations,omitempty"`
+}
+
+// StepResult is the outcome of a step that has run. Its fields are named as
+// the daemon persists them.
+type StepResult struct {
+	Success         bool
+	Skipped         bool
+	Output          string
+	ExitCode        int
+	StatusCode      int    `json:",omitempty"`
+	Summary         string `json:",omitempty"`
+	AllowedFailure  bool   `json:",omitempty"`
+	Error           string
+	Duration        time.Duration
+	Action          string
+	NoChanges       bool              `json:",omitempty"`
+	Command         string            `json:",omitempty"`
+	RenderedOutputs map[string]string `json:",omitempty"`
+	FilesChanged    []string          `json:",omitempty"`
+}
+
+// MergeReview describes the changes a workflow waiting for merge approval
+// would merge.
+type MergeReview struct {
+	Diff          string        `json:"diff"`
+	Summary       string        `json:"summary"`
+	FilesChanged  []string      `json:"files_changed"`
+	Additions     int           `json:"additions"`
+	Deletions     int           `json:"deletions"`
+	HasConflicts  bool          `json:"has_conflicts"`
+	ConflictFiles []string      `json:"conflict_files,omitempty"`
+	BinaryFiles   []string      `json:"binary_files,omitempty"`
+	LargeFiles    []string      `json:"large_files,omitempty"`
+	Checks        []CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a merge step's pre-merge check.
+type CheckResult struct {
+	Command  string `json:"command"`
+	Success  bool   `json:"success"`
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output,omitempty"`
+}
+
+// Escalation describes a failed step that needs human attention.
+type Escalation struct {
+	StepName        string `json:"step_name"`
+	StepType        string `json:"step_type"`
+	Output          string `json:"output,omitempty"`
+	ExitCode        int    `json:"exit_code"`
+	Error           string `json:"error,omitempty"`
+	SuggestedAction string `json:"suggested_action"`
+}
+
+// Confirmation describes a step waiting for human confirmation.
+type Confirmation struct {
+	StepName    string    `json:"step_name"`
+	Description string    `json:"description,omitempty"`
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+// Comment is a note attached to a workflow.
+type Comment struct {
+	Author    string    `json:"author,omitempty"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WorkflowResult summarizes a finished workflow run.
+type WorkflowResult struct {
+	Status         WorkflowStatus `json:"status"`
+	StartedAt      time.Time      `json:"started_at"`
+	EndedAt        time.Time      `json:"ended_at"`
+	DurationMs     int64          `json:"duration_ms"`
+	TotalSteps     int            `json:"total_steps"`
+	ExecutedSteps  int            `json:"executed_steps"`
+	SucceededSteps int            `json:"succeeded_steps"`
+	FailedSteps    int            `json:"failed_steps"`
+	SkippedSteps   int            `json:"skipped_steps"`
+	FailedStep     string         `json:"failed_step,omitempty"`
+	FailureSummary string         `json:"failure_summary,omitempty"`
+	KeptWorktree   string         `json:"kept_worktree,omitempty"`
+	NoChanges      bool           `json:"no_changes,omitempty"`
+}
+
+// WorkflowProgress is how far a workflow has got through its top-level steps.
+type WorkflowProgress struct {
+	Percent   int `json:"percent"`
+	Completed int `json:"completed"`
+	Skipped   int `json:"skipped"`
+	Total     int `json:"total"`
+}
+
+// WorkflowDiff is the response for GET /workflows/:id/diff.
+type WorkflowDiff struct {
+	WorkflowID     string   `json:"workflow_id"`
+	TaskID         string   `json:"task_id"`
+	WorktreePath   string   `json:"worktree_path"`
+	Diff           string   `json:"diff"`
+	FilesChanged   []string `json:"files_changed"`
+	SizeBytes      int      `json:"size_bytes"`
+	Truncated      bool     `json:"truncated,omitempty"`
+	TruncationNote string   `json:"truncation_note,omitempty"`
+}
+
+// WorkflowDivergence is the response for GET /workflows/:id/divergence.
+type WorkflowDivergence struct {
+	WorkflowID    string `json:"workflow_id"`
+	TaskID        string `json:"task_id"`
+	Branch        string `json:"branch"`
+	BaseBranch    string `json:"base_branch"`
+	CommitsAhead  int    `json:"commits_ahead"`
+	CommitsBehind int    `json:"commits_behind"`
+}
+
+// WorkflowAction is the response for actions taken on a workflow.
+type WorkflowAction struct {
+	// Status is what the action did, such as "cancelled", "queued" or
+	// "rejected".
+	Status     string `json:"status"`
+	WorkflowID string `json:"workflow_id"`
+	TaskID     string `json:"task_id"`
+	Message    string `json:"message,omitempty"`
+
+	// Step is the step a confirm or skip acted on.
+	Step string `json:"step,omitempty"`
+
+	// Reason is the reason a merge was rejected with.
+	Reason string `json:"reason,omitempty"`
+
+	// WorktreePath is the worktree a cleanup removed.
+	WorktreePath string `json:"worktree_path,omitempty"`
+}
+
+// MergeApproval is the response for POST /workflows/:id/approve-merge.
+type MergeApproval struct {
+	Status        string   `json:"status"`
+	WorkflowID    string   `json:"workflow_id"`
+	TaskID        string   `json:"task_id"`
+	Message       string   `json:"message"`
+	MergeCommit   string   `json:"merge_commit,omitempty"`
+	HasConflicts  bool     `json:"has_conflicts,omitempty"`
+	ConflictFiles []string `json:"conflict_files,omitempty"`
+}
+
+// CommentResult is the response for POST /workflows/:id/comment.
+type CommentResult struct {
+	Status       string  `json:"status"`
+	WorkflowID   string  `json:"workflow_id"`
+	TaskID       string  `json:"task_id"`
+	Comment      Comment `json:"comment"`
+	CommentCount int     `json:"comment_count"`
+}
+
+// Workflows calls GET /workflows.
+func (c *Client) Workflows(ctx context.Context) (*WorkflowList, error) {
+	var workflows WorkflowList
+	if err := c.get(ctx, "/workflows", &workflows); err != nil {
+		return nil, err
+	}
+	return &workflows, nil
+}
+
+// Workflow calls GET /workflows/:id. The ID may be a workflow ID or the ID
+// of the task whose current workflow to get; the same goes for the other
+// workflow methods.
+func (c *Client) Workflow(ctx context.Context, id string) (*Workflow, error) {
+	var wf Workflow
+	if err := c.get(ctx, "/workflows/"+escape(id), &wf); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+// WorkflowLog calls GET /workflows/:id/log, returning the workflow's JSONL
+// log.
+func (c *Client) WorkflowLog(ctx context.Context, id string) ([]byte, error) {
+	return c.doRaw(ctx, http.MethodGet, "/workflows/"+escape(id)+"/log", "", nil)
+}
+
+// WorkflowDiff calls GET /workflows/:id/diff.
+func (c *Client) WorkflowDiff(ctx context.Context, id string) (*WorkflowDiff, error) {
+	var diff WorkflowDiff
+	if err := c.get(ctx, "/workflows/"+escape(id)+"/diff", &diff); err != nil {
+		return nil, err
+	}
+	return &diff, nil
+}
+
+// WorkflowDivergence calls GET /workflows/:id/divergence, counting the
+// commits the workflow's branch and the base branch each have that the other
+// doesn't.
+func (c *Client) WorkflowDivergence(ctx context.Context, id string) (*WorkflowDivergence, error) {
+	var divergence WorkflowDivergence
+	if err := c.get(ctx, "/workflows/"+escape(id)+"/divergence", &divergence); err != nil {
+		return nil, err
+	}
+	return &divergence, nil
+}
+
+// MergeDiff calls GET /workflows/:id/merge-diff, writing the full diff of a
+// workflow pending merge approval to w as the daemon streams it.
+func (c *Client) MergeDiff(ctx context.Context, id string, w io.Writer) error {
+	return c.stream(ctx, "/workflows/"+escape(id)+"/merge-diff", w)
+}
+
+// CancelWorkflow calls POST /workflows/:id/cancel.
+func (c *Client) CancelWorkflow(ctx context.Context, id string) (*WorkflowAction, error) {
+	return c.workflowAction(ctx, id, "cancel", nil)
+}
+
+// RetryWorkflow calls POST /workflows/:id/retry, queueing a blocked or failed
+// workflow to resume.
+func (c *Client) RetryWorkflow(ctx context.Context, id string) (*WorkflowAction, error) {
+	return c.workflowAction(ctx, id, "retry", nil)
+}
+
+// CleanupWorkflow calls POST /workflows/:id/cleanup.
+func (c *Client) CleanupWorkflow(ctx context.Context, id string) (*WorkflowAction, error) {
+	return c.workflowAction(ctx, id, "cleanup", nil)
+}
+
+// ConfirmStep calls POST /workflows/:id/confirm, confirming the step the
+// workflow is waiting on.
+func (c *Client) ConfirmStep(ctx context.Context, id string) (*WorkflowAction, error) {
+	return c.workflowAction(ctx, id, "confirm", nil)
+}
+
+// SkipStep calls POST /workflows/:id/step/:name/skip, skipping the step the
+// workflow is blocked on.
+func (c *Client) SkipStep(ctx context.Context, id, stepName string) (*WorkflowAction, error) {
+	return c.workflowAction(ctx, id, "step/"+escape(stepName)+"/skip", nil)
+}
+
+// BreakLoop calls POST /workflows/:id/step/:name/break-loop, stopping the
+// running loop step once its current iteration finishes.
+func (c *Client) BreakLoop(ctx context.Context, id, stepName string) (*WorkflowAction, error) {
+	return c.workflowAction(ctx, id, "step/"+escape(stepName)+"/break-loop", nil)
+}
+
+// RejectMerge calls POST /workflows/:id/reject-merge. An empty reason uses
+// the daemon's default.
+func (c *Client) RejectMerge(ctx context.Context, id, reason string) (*WorkflowAction, error) {
+	var body any
+	if reason != "" {
+		body = map[string]string{"reason": reason}
+	}
+	return c.workflowAction(ctx, id, "reject-merge", body)
+}
+
+// ApproveMerge calls POST /workflows/:id/approve-merge. A merge that hits
+// conflicts is reported in the result rather than as an error.
+func (c *Client) ApproveMerge(ctx context.Context, id, feedback string) (*MergeApproval, error) {
+	var body any
+	if feedback != "" {
+		body = map[string]string{"feedback": feedback}
+	}
+	var approval MergeApproval
+	if err := c.post(ctx, "/workflows/"+escape(id)+"/approve-merge", body, &approval); err != nil {
+		return nil, err
+	}
+	return &approval, nil
+}
+
+// AddComment calls POST /workflows/:id/comment.
+func (c *Client) AddComment(ctx context.Context, id, author, text string) (*CommentResult, error) {
+	var result CommentResult
+	body := map[string]string{"author": author, "text": text}
+	if err := c.post(ctx, "/workflows/"+escape(id)+"/comment", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// workflowAction posts to one of a workflow's action endpoints.
+func (c *Client) workflowAction(ctx context.Context, id, action string, body any) (*WorkflowAction, error) {
+	var result WorkflowAction
+	if err := c.post(ctx, "/workflows/"+escape(id)+"/"+action, body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Usage is the response for GET /usage.
+type Usage struct {
+	GroupBy string       `json:"group_by,omitempty"`
+	Groups  []UsageGroup `json:"groups"`
+	Total   UsageGroup   `json:"total"`
+}
+
+// UsageGroup is the agent usage of the workflow runs sharing a tag value.
+type UsageGroup struct {
+	// Value is the tag's value, or "" for runs without the tag.
+	Value       string `json:"value"`
+	Workflows   int    `json:"workflows"`
+	AgentRuns   int    `json:"agent_runs"`
+	AgentTimeMs int64  `json:"agent_time_ms"`
+}
+
+// Usage calls GET /usage, summing agent usage across workflow runs grouped by
+// the tag named groupBy, such as "team". An empty groupBy puts every run in
+// one group.
+func (c *Client) Usage(ctx context.Context, groupBy string) (*Usage, error) {
+	path := "/usage"
+	if groupBy != "" {
+		path += "?group_by=" + url.QueryEscape(groupBy)
+	}
+	var usage Usage
+	if err := c.get(ctx, path, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
@@ -25,6 +25,44 @@ const (
 	TaskStatusPendingMerge TaskStatus = "pending_merge"
 )
 
+// TaskStatusReason records why a task was last moved to its status.
+type TaskStatusReason string
+
+const (
+	// The task's workflow started, or resumed after an interruption.
+	StatusReasonStarted TaskStatusReason = "started"
+	StatusReasonResumed TaskStatusReason = "resumed"
+
+	// The workflow finished: it completed, merged its changes, or
+	// completed without changing anything.
+	StatusReasonCompleted TaskStatusReason = "completed"
+	StatusReasonMerged    TaskStatusReason = "merged"
+	StatusReasonNoChanges TaskStatusReason = "no-changes"
+
+	// The workflow is waiting on someone: to review a merge, to confirm a
+	// step, or to unblock a blocked step.
+	StatusReasonMergePending         TaskStatusReason = "merge-pending"
+	StatusReasonAwaitingConfirmation TaskStatusReason = "awaiting-confirmation"
+	StatusReasonBlocked              TaskStatusReason = "blocked"
+
+	// The workflow ended without finishing, or failed to start as it wasn't
+	// given its grimoire's required inputs.
+	StatusReasonMergeRejected TaskStatusReason = "merge-rejected"
+	StatusReasonFailed        TaskStatusReason = "failed"
+	StatusReasonMissingInputs TaskStatusReason = "missing-inputs"
+	StatusReasonTimedOut      TaskStatusReason = "timed-out"
+	StatusReasonCancelled     TaskStatusReason = "cancelled"
+	StatusReasonStopped       TaskStatusReason = "stopped"
+
+	// The workflow couldn't be resumed, as its state was written by a newer
+	// daemon or its worktree is gone.
+	StatusReasonUnsupportedState TaskStatusReason = "unsupported-state"
+	StatusReasonWorktreeMissing  TaskStatusReason = "worktree-missing"
+
+	// The status was corrected to match the task's persisted workflow.
+	StatusReasonReconciled TaskStatusReason = "reconciled"
+)
+
 // Task represents a task from the beads issue tracker.
 type Task struct {
 	ID          string     `json:"id"`
@@ -38,6 +76,10 @@ type Task struct {
 	Blocks      []string   `json:"blocks,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// StatusReason is why the daemon last set Status. It is empty when the
+	// status came from beads.
+	StatusReason TaskStatusReason `json:"status_reason,omitempty"`
 }
 
 // Agent represents a running or completed agent process.
@@ -98,12 +140,24 @@ func NewDaemonState() *DaemonState {
 
 // HealthStatus represents the health of the daemon.
 type HealthStatus struct {
-	Status    string `json:"status"`
-	Version   string `json:"version"`
-	Uptime    string `json:"uptime"`
-	Workspace string `json:"workspace"`
+	Status     string            `json:"status"`
+	Version    string            `json:"version"`
+	Uptime     string            `json:"uptime"`
+	Workspace  string            `json:"workspace"`
+	Conditions []HealthCondition `json:"conditions,omitempty"`
+}
+
+// HealthCondition describes a problem that leaves the daemon degraded.
+type HealthCondition struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
 }
 
+// Health condition names.
+const (
+	HealthConditionLowDiskSpace = "low_disk_space"
+)
+
 // VersionInfo represents version information about the daemon.
 type VersionInfo struct {
 	Version   string `json:"version"`
@@ -127,8 +181,13 @@ type ErrorResponse struct {
 
 // Event represents an SSE event.
 type Event struct {
-	Type      string    `json:"type"`
-	Data      any       `json:"data"`
+	Type string `json:"type"`
+	Data any    `json:"data"`
+
+	// WorkflowID tags events delivered to a workflow-filtered subscription
+	// with the workflow they belong to.
+	WorkflowID string `json:"workflow_id,omitempty"`
+
 	Timestamp time.Time `json:"timestamp"`
 }
 
@@ -151,4 +210,7 @@ const (
 	EventTypeWorkflowMergePending  = "workflow.merge_pending"
 	EventTypeWorkflowCompleted     = "workflow.completed"
 	EventTypeWorkflowCancelled     = "workflow.cancelled"
+
+	// Grimoire events
+	EventTypeGrimoireChanged = "grimoire.changed"
 )
@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -193,10 +194,13 @@ func TestDaemonStateJSONSerialization(t *testing.T) {
 
 func TestHealthStatusJSONSerialization(t *testing.T) {
 	health := HealthStatus{
-		Status:    "healthy",
+		Status:    "degraded",
 		Version:   "1.0.0",
 		Uptime:    "1h30m",
 		Workspace: "/path/to/workspace",
+		Conditions: []HealthCondition{
+			{Name: HealthConditionLowDiskSpace, Message: "insufficient disk space"},
+		},
 	}
 
 	data, err := json.Marshal(health)
@@ -209,7 +213,7 @@ func TestHealthStatusJSONSerialization(t *testing.T) {
 		t.Fatalf("Unmarshal error: %v", err)
 	}
 
-	if decoded != health {
+	if !reflect.DeepEqual(decoded, health) {
 		t.Errorf("Decoded = %+v, want %+v", decoded, health)
 	}
 }